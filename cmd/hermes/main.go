@@ -0,0 +1,554 @@
+// Command hermes checks the emails generated by hermes.
+//
+// Usage:
+//
+//	hermes audit file.html
+//	hermes subject "subject" ["preheader"]
+//	hermes preview-text --input email.yaml [--width 72] [--theme default] [--diff previous.txt] [--no-color]
+//	hermes doctor [--json]
+//	hermes themes [--json]
+//	hermes render --input email.yaml [--theme default] [--brand brand.yaml | --brand-env HERMES_BRAND] [--locale en] [--out dir] [--format html|eml] [--from address] [--to addresses] [--strict]
+//	hermes manifest file.html
+//	hermes resources file.html [--allow example.com,cdn.example.com]
+//	hermes features [--theme default] [--out dir]
+//
+// audit prints the accessibility report of the HTML email as JSON, and exits with status 1 when the report fails.
+// subject prints the hints about the subject and the preheader as JSON, and exits with status 1 when one is a warning.
+// preview-text prints the plain text body of the email of the YAML file as a terminal of the width displays it, see
+// hermes.TextPreview, and exits with status 1 when a line is wider. With --diff, the lines changed since the text of the
+// file are marked, and the file is replaced by the new text for the next preview.
+// doctor checks the sender configuration of the HERMES_* variables, see send.Diagnose, without sending anything, prints
+// the findings with their remediation, and exits with status 1 when a check fails with an error.
+// themes lists the registered themes with their metadata, see core.Metadata, and the issues of hermes.ValidateTheme, and
+// exits with status 1 when a theme has an error.
+// render writes the HTML and plain text bodies of the email of the JSON or YAML file, see hermes.LoadEmailFromYAML, to
+// {out}/{input name}.html and .txt, or the message with its attachments to {out}/{input name}.eml with --format eml.
+// The theme is a registered theme, or a directory holding the templates theme.html and theme.txt. The brand is a JSON
+// or YAML file of hermes.Branding, see hermes.BrandingFromJSON, or the environment variables of the prefix of
+// --brand-env, see hermes.BrandingFromEnv. It exits with status 3 when a file cannot be read or parsed, 4 when the email
+// or the brand is invalid, see hermes.Email.Validate, and 5 when the email cannot be rendered, the errors being printed
+// to stderr.
+// manifest prints the manifest of the HTML email as JSON, see hermes.Output.Manifest, with the plain text body of the
+// .txt file of the same name when there is one, as written by render.
+// resources prints the external resources of the HTML email as JSON, see hermes.ExternalResources, with the issues of
+// the hosts missing from --allow, whose "*." entries allow subdomains, and exits with status 1 when there is one.
+// features writes the HTML and plain text bodies of the examples of hermes.FeatureExamples to
+// {out}/features/{name}.html and .txt, rendered for hermes.DefaultSegment, to review each feature of the body in
+// isolation. It exits with status 3 when the theme cannot be parsed, and 5 when an example cannot be rendered.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/send"
+	"github.com/unknowns24/hermes/pkg/themes"
+	"gopkg.in/yaml.v3"
+)
+
+const usage = `usage: hermes audit file.html
+       hermes subject "subject" ["preheader"]
+       hermes preview-text --input email.yaml [--width 72] [--theme default] [--diff previous.txt] [--no-color]
+       hermes doctor [--json]
+       hermes themes [--json]
+       hermes render --input email.yaml [--theme default] [--brand brand.yaml | --brand-env HERMES_BRAND] [--locale en] [--out dir] [--format html|eml] [--from address] [--to addresses] [--strict]
+       hermes manifest file.html
+       hermes resources file.html [--allow example.com,cdn.example.com]
+       hermes features [--theme default] [--out dir]`
+
+func main() {
+	args := os.Args[1:]
+	switch {
+	case len(args) == 2 && args[0] == "audit":
+		os.Exit(audit(args[1]))
+	case (len(args) == 2 || len(args) == 3) && args[0] == "subject":
+		args = append(args, "")
+		os.Exit(subject(args[1], args[2]))
+	case len(args) >= 1 && args[0] == "preview-text":
+		os.Exit(previewText(args[1:]))
+	case len(args) >= 1 && args[0] == "doctor":
+		os.Exit(doctor(args[1:]))
+	case len(args) >= 1 && args[0] == "themes":
+		os.Exit(listThemes(args[1:]))
+	case len(args) >= 1 && args[0] == "render":
+		os.Exit(render(args[1:]))
+	case len(args) == 2 && args[0] == "manifest":
+		os.Exit(manifest(args[1]))
+	case len(args) >= 1 && args[0] == "resources":
+		os.Exit(resources(args[1:]))
+	case len(args) >= 1 && args[0] == "features":
+		os.Exit(features(args[1:]))
+	}
+	fmt.Fprintln(os.Stderr, usage)
+	os.Exit(2)
+}
+
+// audit prints the accessibility report of the HTML file and returns the exit status
+func audit(path string) int {
+	html, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "hermes:", err)
+		return 2
+	}
+	report := hermes.AuditAccessibility(string(html))
+	if err := printJSON(report); err != nil {
+		fmt.Fprintln(os.Stderr, "hermes:", err)
+		return 2
+	}
+	if report.Status == hermes.A11yFail {
+		return 1
+	}
+	return 0
+}
+
+// manifest prints the manifest of the HTML file and returns the exit status
+func manifest(path string) int {
+	html, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "hermes:", err)
+		return 2
+	}
+	text, err := os.ReadFile(strings.TrimSuffix(path, filepath.Ext(path)) + ".txt")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintln(os.Stderr, "hermes:", err)
+		return 2
+	}
+	m := hermes.Output{HTML: string(html), PlainText: string(text)}.Manifest()
+	if err := printJSON(m); err != nil {
+		fmt.Fprintln(os.Stderr, "hermes:", err)
+		return 2
+	}
+	return 0
+}
+
+// resourcesReport is the output of the resources command
+type resourcesReport struct {
+	Resources []hermes.Resource `json:"resources"`
+	Issues    hermes.Issues     `json:"issues,omitempty"`
+}
+
+// resources prints the external resources of the HTML file with the issues of their hosts, and returns the exit status
+func resources(args []string) int {
+	flags := flag.NewFlagSet("resources", flag.ContinueOnError)
+	allow := flags.String("allow", "", "comma-separated hosts the resources may come from, e.g. example.com,*.example.com")
+	// The flags may follow the file, e.g. hermes resources file.html --allow example.com
+	if err := flags.Parse(args); err != nil || flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+	path := flags.Arg(0)
+	if err := flags.Parse(flags.Args()[1:]); err != nil || flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	html, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "hermes:", err)
+		return 2
+	}
+	report := resourcesReport{Resources: hermes.ExternalResources(string(html))}
+	if *allow != "" {
+		report.Issues = hermes.CheckResources(report.Resources, strings.Split(*allow, ","), hermes.SeverityError)
+	}
+	if err := printJSON(report); err != nil {
+		fmt.Fprintln(os.Stderr, "hermes:", err)
+		return 2
+	}
+	if len(report.Issues) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// subject prints the hints about the subject and the preheader and returns the exit status
+func subject(subject, preheader string) int {
+	hints := hermes.LintSubject(subject, preheader)
+	if err := printJSON(hints); err != nil {
+		fmt.Fprintln(os.Stderr, "hermes:", err)
+		return 2
+	}
+	for _, hint := range hints {
+		if hint.Severity >= hermes.SeverityWarning {
+			return 1
+		}
+	}
+	return 0
+}
+
+// previewInput is the YAML file of preview-text, fields being named in lower case, e.g. body.intros
+type previewInput struct {
+	Theme  string          `yaml:"theme"` // Name of a built-in theme (default to the one of the flag)
+	Locale string          `yaml:"locale"`
+	Brand  hermes.Branding `yaml:"brand"`
+	Email  hermes.Email    `yaml:"email"`
+}
+
+var previewThemes = map[string]hermes.Theme{
+	"default":   new(themes.Default),
+	"corporate": new(themes.Corporate),
+	"flat":      new(themes.Flat),
+}
+
+// previewText prints the preview of the plain text body of the email and returns the exit status
+func previewText(args []string) int {
+	flags := flag.NewFlagSet("preview-text", flag.ContinueOnError)
+	input := flags.String("input", "", "YAML file of the email, with its theme, locale and brand")
+	width := flags.Int("width", hermes.DefaultPreviewWidth, "width of the terminal of the reader")
+	theme := flags.String("theme", "default", "theme of the email: default, corporate or flat")
+	diff := flags.String("diff", "", "file of the previous text, marking the changed lines, replaced by the new text")
+	noColor := flags.Bool("no-color", os.Getenv("NO_COLOR") != "", "print plain text, without ANSI escape codes")
+	if err := flags.Parse(args); err != nil || *input == "" || flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	text, err := renderPlainText(*input, *theme)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "hermes:", err)
+		return 2
+	}
+	preview := hermes.PreviewPlainText(text, *width)
+	if *diff != "" {
+		previous, err := os.ReadFile(*diff)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintln(os.Stderr, "hermes:", err)
+			return 2
+		}
+		if err == nil {
+			preview = hermes.DiffPlainText(string(previous), text, *width)
+		}
+		if err := os.WriteFile(*diff, []byte(text), 0644); err != nil {
+			fmt.Fprintln(os.Stderr, "hermes:", err)
+			return 2
+		}
+	}
+	if err := preview.Write(os.Stdout, !*noColor); err != nil {
+		fmt.Fprintln(os.Stderr, "hermes:", err)
+		return 2
+	}
+	if preview.Overflows() > 0 {
+		return 1
+	}
+	return 0
+}
+
+// doctor prints the findings of the diagnosis of the sender configuration and returns the exit status
+func doctor(args []string) int {
+	flags := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the findings as JSON")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	findings := send.Diagnose(context.Background(), send.ConfigFromEnv(os.Getenv))
+	if *asJSON {
+		if err := printJSON(findings); err != nil {
+			fmt.Fprintln(os.Stderr, "hermes:", err)
+			return 2
+		}
+	} else {
+		for _, finding := range findings {
+			fmt.Println(finding)
+		}
+	}
+	if send.HasErrors(findings) {
+		return 1
+	}
+	return 0
+}
+
+// themeInfo is a registered theme, as listed by the themes command
+type themeInfo struct {
+	Name     string           `json:"name"`
+	Metadata *themes.Metadata `json:"metadata,omitempty"`
+	Issues   hermes.Issues    `json:"issues,omitempty"`
+}
+
+// listThemes prints the registered themes with their metadata and issues, and returns the exit status
+func listThemes(args []string) int {
+	flags := flag.NewFlagSet("themes", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the themes as JSON")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	status := 0
+	var infos []themeInfo
+	for _, name := range themes.Names() {
+		theme, _ := themes.Lookup(name)
+		info := themeInfo{Name: name}
+		if metadata, ok := themes.LookupMetadata(name); ok {
+			info.Metadata = &metadata
+		}
+		if err := hermes.ValidateTheme(theme); err != nil {
+			if !errors.As(err, &info.Issues) {
+				info.Issues = hermes.Issues{{Code: hermes.IssueThemeTemplate, Severity: hermes.SeverityError, Message: err.Error(), Err: err}}
+			}
+			if info.Issues.HasErrors() {
+				status = 1
+			}
+		}
+		infos = append(infos, info)
+	}
+
+	if *asJSON {
+		if err := printJSON(infos); err != nil {
+			fmt.Fprintln(os.Stderr, "hermes:", err)
+			return 2
+		}
+		return status
+	}
+	for _, info := range infos {
+		if m := info.Metadata; m != nil {
+			fmt.Printf("%s: widths %v, media queries %t, dark mode %s, data version %d+\n", info.Name, m.Widths, m.MediaQueries, m.DarkMode, m.MinDataVersion)
+		} else {
+			fmt.Printf("%s: no metadata\n", info.Name)
+		}
+		for _, issue := range info.Issues {
+			fmt.Printf("  %s %s\n", issue.Severity, issue.Error())
+		}
+	}
+	return status
+}
+
+// Exit statuses of render, telling apart the errors of the files, of the email and of its rendering
+const (
+	exitParse      = 3
+	exitValidation = 4
+	exitRender     = 5
+)
+
+// render writes the bodies or the message of the email of the input file and returns the exit status
+func render(args []string) int {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	input := flags.String("input", "", "JSON or YAML file of the email")
+	theme := flags.String("theme", "default", "registered theme, or directory of the templates theme.html and theme.txt")
+	brand := flags.String("brand", "", "JSON or YAML file of the brand")
+	brandEnv := flags.String("brand-env", "", "prefix of the environment variables of the brand, e.g. HERMES_BRAND")
+	locale := flags.String("locale", "", "locale of the email, e.g. fr (default to en)")
+	out := flags.String("out", ".", "directory of the generated files")
+	format := flags.String("format", "html", "html for the .html and .txt bodies, or eml for a message")
+	from := flags.String("from", "", "sender of the eml message, e.g. Hermes <hello@hermes-example.com>")
+	to := flags.String("to", "", "comma-separated recipients of the eml message")
+	strict := flags.Bool("strict", false, "fail on unknown fields of the input")
+	if err := flags.Parse(args); err != nil || *input == "" || flags.NArg() > 0 || *format != "html" && *format != "eml" ||
+		*brand != "" && *brandEnv != "" {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+	var recipients []string
+	if *format == "eml" {
+		if _, err := mail.ParseAddress(*from); err != nil {
+			fmt.Fprintln(os.Stderr, "hermes: --from:", err)
+			return 2
+		}
+		if *to != "" {
+			list, err := mail.ParseAddressList(*to)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "hermes: --to:", err)
+				return 2
+			}
+			for _, a := range list {
+				recipients = append(recipients, a.String())
+			}
+		}
+	}
+
+	h := hermes.Hermes{Locale: *locale}
+	var err error
+	if h.Theme, err = loadTheme(*theme); err != nil {
+		fmt.Fprintln(os.Stderr, "hermes: parse:", err)
+		return exitParse
+	}
+	switch {
+	case *brand != "":
+		h.Brand, err = loadBrand(*brand)
+	case *brandEnv != "":
+		h.Brand, err = hermes.BrandingFromEnv(*brandEnv)
+	}
+	var issues hermes.Issues
+	if errors.As(err, &issues) {
+		for _, issue := range issues {
+			fmt.Fprintln(os.Stderr, "hermes: validation:", issue.Error())
+		}
+		return exitValidation
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, "hermes: parse:", err)
+		return exitParse
+	}
+	email, err := loadEmail(*input, hermes.LoadOptions{Strict: *strict})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "hermes: parse:", err)
+		return exitParse
+	}
+
+	h.Brand = h.BrandOf(email)
+	for _, err := range []error{h.Brand.Validate(), email.Validate()} {
+		var found hermes.Issues
+		if errors.As(err, &found) {
+			issues = append(issues, found...)
+		}
+	}
+	for _, issue := range issues {
+		fmt.Fprintln(os.Stderr, "hermes: validation:", issue.Error())
+	}
+	if issues.HasErrors() {
+		return exitValidation
+	}
+
+	name := strings.TrimSuffix(filepath.Base(*input), filepath.Ext(*input))
+	files := map[string][]byte{}
+	if *format == "eml" {
+		m, err := send.GenerateMessage(&h, email)
+		if err == nil {
+			m.From, m.To = *from, recipients
+			files[name+".eml"], err = m.Bytes()
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "hermes: render:", err)
+			return exitRender
+		}
+	} else {
+		html, text, err := h.Generate(email)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "hermes: render:", err)
+			return exitRender
+		}
+		files[name+".html"], files[name+".txt"] = []byte(html), []byte(text)
+	}
+
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		fmt.Fprintln(os.Stderr, "hermes:", err)
+		return 2
+	}
+	for file, data := range files {
+		if err := os.WriteFile(filepath.Join(*out, file), data, 0644); err != nil {
+			fmt.Fprintln(os.Stderr, "hermes:", err)
+			return 2
+		}
+	}
+	return 0
+}
+
+// features writes the bodies of the feature examples and returns the exit status
+func features(args []string) int {
+	flags := flag.NewFlagSet("features", flag.ContinueOnError)
+	theme := flags.String("theme", "default", "registered theme, or directory of the templates theme.html and theme.txt")
+	out := flags.String("out", ".", "directory of the features directory")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	var h hermes.Hermes
+	var err error
+	if h.Theme, err = loadTheme(*theme); err != nil {
+		fmt.Fprintln(os.Stderr, "hermes: parse:", err)
+		return exitParse
+	}
+	dir := filepath.Join(*out, "features")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Fprintln(os.Stderr, "hermes:", err)
+		return 2
+	}
+	for name, email := range hermes.FeatureExamples() {
+		rendered, err := h.RenderForSegment(email, hermes.DefaultSegment)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "hermes: render %s: %v\n", name, err)
+			return exitRender
+		}
+		for file, data := range map[string]string{name + ".html": rendered.HTML, name + ".txt": rendered.PlainText} {
+			if err := os.WriteFile(filepath.Join(dir, file), []byte(data), 0644); err != nil {
+				fmt.Fprintln(os.Stderr, "hermes:", err)
+				return 2
+			}
+		}
+	}
+	return 0
+}
+
+// loadTheme returns the registered theme of the name, or the theme of the templates of the directory
+func loadTheme(name string) (hermes.Theme, error) {
+	if theme, ok := themes.Lookup(name); ok {
+		return theme, nil
+	}
+	if info, err := os.Stat(name); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("unknown theme %q, neither registered nor a directory", name)
+	}
+	return themes.NewFromFiles(filepath.Base(name), filepath.Join(name, "theme.html"), filepath.Join(name, "theme.txt"))
+}
+
+// loadEmail loads the email of the JSON file, by its .json extension, or else of the YAML file
+func loadEmail(path string, opts hermes.LoadOptions) (hermes.Email, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return hermes.Email{}, err
+	}
+	defer f.Close()
+	var email hermes.Email
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		email, err = opts.LoadEmailFromJSON(f)
+	} else {
+		email, err = opts.LoadEmailFromYAML(f)
+	}
+	if err != nil {
+		return hermes.Email{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return email, nil
+}
+
+// loadBrand loads the brand of the JSON file, by its .json extension, or else of the YAML file. Its validation errors
+// are hermes.Issues.
+func loadBrand(path string) (hermes.Branding, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return hermes.Branding{}, err
+	}
+	defer f.Close()
+	load := hermes.BrandingFromYAML
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		load = hermes.BrandingFromJSON
+	}
+	brand, err := load(f)
+	var issues hermes.Issues
+	if err != nil && !errors.As(err, &issues) {
+		return hermes.Branding{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return brand, err
+}
+
+// renderPlainText renders the plain text body of the email of the YAML file
+func renderPlainText(path, theme string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	in := previewInput{Theme: theme}
+	if err := yaml.Unmarshal(data, &in); err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	h := hermes.Hermes{Theme: previewThemes[in.Theme], Locale: in.Locale, Brand: in.Brand}
+	if h.Theme == nil {
+		return "", fmt.Errorf("unknown theme %q", in.Theme)
+	}
+	return h.GeneratePlainText(in.Email)
+}
+
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(v)
+}
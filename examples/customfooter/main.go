@@ -0,0 +1,46 @@
+// Command customfooter renders the welcome example with the default theme, its footer replaced by a custom one, under
+// customfooter.html and customfooter.txt
+package main
+
+import (
+	"os"
+
+	"github.com/unknowns24/hermes/examples/mails"
+	"github.com/unknowns24/hermes/examples/matrix"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+// footer replaces the row of the footer of the default theme, below the body
+const footer = `<tr>
+            <td>
+              <table class="email-footer" role="presentation" align="center" width="570" cellpadding="0" cellspacing="0">
+                <tr>
+                  <td class="content-cell">
+                    <p class="sub center">Sent by <a href="{{ .Hermes.Brand.Link | url }}">{{ .Hermes.Brand.Name }}</a> because you signed up.</p>
+                  </td>
+                </tr>
+              </table>
+            </td>
+          </tr>`
+
+func main() {
+	theme, err := themes.NewCustomized(new(themes.Default), map[string]string{
+		themes.BlockFooter:                          footer,
+		themes.TextBlockPrefix + themes.BlockFooter: "Sent by {{ .Hermes.Brand.Name }} because you signed up.",
+	})
+	if err != nil {
+		panic(err)
+	}
+	h := hermes.Hermes{Theme: theme, Brand: matrix.Brand}
+	html, text, err := h.Generate(new(mails.Welcome).Email())
+	if err != nil {
+		panic(err)
+	}
+	if err := os.WriteFile("customfooter.html", []byte(html), 0644); err != nil {
+		panic(err)
+	}
+	if err := os.WriteFile("customfooter.txt", []byte(text), 0644); err != nil {
+		panic(err)
+	}
+}
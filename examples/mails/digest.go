@@ -0,0 +1,43 @@
+package mails
+
+import (
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+type Digest struct {
+}
+
+func (d *Digest) Name() string {
+	return "digest"
+}
+
+func (d *Digest) Email() hermes.Email {
+	summary := new(hermes.Summary).
+		Add("New members", 5).Compare(3).
+		Highlight("Sansa Stark joined the Winterfell team", "Samwell Tarly joined the Citadel team").
+		Add("Closed tickets", 12).Compare(12).
+		Add("Incidents", 3).Compare(1).LowerIsBetter().
+		Highlight("API latency above 2s for 25 minutes on Tuesday", "Two failed deployments of the billing service").
+		Add("Revenue", 12480.5).Compare(10120).Format(hermes.FormatMoney).
+		Add("Active projects", 7)
+
+	return hermes.Email{
+		Subject: "Your weekly Hermes digest",
+		Body: hermes.Body{
+			Name: "Jon Snow",
+			Intros: []string{
+				"Here is what happened in your workspace this week.",
+			},
+			Summary: summary,
+			Actions: []hermes.Action{
+				{
+					Instructions: "See the details in your dashboard:",
+					Button: hermes.Button{
+						Text: "Open the dashboard",
+						Link: "https://hermes-example.com/dashboard",
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,77 @@
+package mails
+
+import (
+	"time"
+
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+type Features struct {
+}
+
+func (f *Features) Name() string {
+	return "features"
+}
+
+func (f *Features) Email() hermes.Email {
+	return hermes.Email{
+		Subject: "What’s new in Hermes",
+		Params: map[string]string{
+			"name":  "Jon Snow",
+			"token": "d9729feb74992cc3482b350163a1a010",
+		},
+		Body: hermes.Body{
+			Name: "Jon Snow",
+			Intros: []string{
+				"Welcome {name}! Your workspace has been migrated to the new region.",
+				"Templates of the new region write placeholders as {{name}}.",
+			},
+			Dictionary: []hermes.Entry{
+				{Key: "Workspace", Value: "winterfell"},
+				{Key: "Region", HTMLValue: "<b>eu-west-3</b>"},
+				{Key: "Account", Value: "AC-1029-XZ", Bidi: hermes.BidiLTR},
+			},
+			Tables: []hermes.Table{
+				{
+					Title: "Migrated services",
+					Data: [][]hermes.Entry{
+						{{Key: "Service", Value: "API"}, {Key: "Status", Value: "Ready"}},
+						{{Key: "Service", Value: "Storage"}, {Key: "Status", Value: "Ready"}},
+					},
+				},
+				{
+					Title: "Quotas",
+					Data: [][]hermes.Entry{
+						{{Key: "Resource", Value: "Storage"}, {Key: "Quota", Value: "500 GB"}},
+					},
+					Columns: hermes.Columns{
+						CustomAlignment: map[string]string{"Quota": "right"},
+					},
+				},
+			},
+			Schedule: []hermes.ScheduleEntry{
+				{
+					Label: "Read-only window",
+					Start: time.Date(2024, time.March, 2, 23, 30, 0, 0, time.UTC),
+					End:   time.Date(2024, time.March, 3, 1, 0, 0, 0, time.UTC),
+				},
+			},
+			Actions: []hermes.Action{
+				{
+					Instructions: "Review the migration report:",
+					Button: hermes.Button{
+						Text: "Open the report",
+						Link: "https://hermes-example.com/report?token={token}",
+					},
+				},
+			},
+			Outros: []string{
+				"Need help, or have questions? Just reply to this email, we'd love to help.",
+			},
+			ContactInstructions: &hermes.ContactInstructions{
+				Email: "support@hermes-example.com",
+				URL:   "https://hermes-example.com/help",
+			},
+		},
+	}
+}
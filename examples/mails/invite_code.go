@@ -13,6 +13,7 @@ func (w *InviteCode) Name() string {
 
 func (w *InviteCode) Email() hermes.Email {
 	return hermes.Email{
+		Subject: "Your invite code to Hermes",
 		Body: hermes.Body{
 			Name: "Jon Snow",
 			Intros: []string{
@@ -0,0 +1,24 @@
+package mails
+
+import (
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+// Example is an example email, rendered by the examples program and the integration tests
+type Example interface {
+	Email() hermes.Email
+	Name() string
+}
+
+// All returns all the example emails
+func All() []Example {
+	return []Example{
+		new(Welcome),
+		new(Reset),
+		new(Maintenance),
+		new(Receipt),
+		new(InviteCode),
+		new(Features),
+		new(Digest),
+	}
+}
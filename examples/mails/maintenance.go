@@ -13,6 +13,7 @@ func (w *Maintenance) Name() string {
 
 func (w *Maintenance) Email() hermes.Email {
 	return hermes.Email{
+		Subject: "Scheduled maintenance of Hermes",
 		Body: hermes.Body{
 			Name: "Jon Snow",
 			FreeMarkdown: `
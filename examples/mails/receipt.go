@@ -13,6 +13,7 @@ func (r *Receipt) Name() string {
 
 func (r *Receipt) Email() hermes.Email {
 	return hermes.Email{
+		Subject: "Your Hermes receipt",
 		Body: hermes.Body{
 			Name: "Jon Snow",
 			Intros: []string{
@@ -13,6 +13,7 @@ func (r *Reset) Name() string {
 
 func (r *Reset) Email() hermes.Email {
 	return hermes.Email{
+		Subject: "Reset your Hermes password",
 		Body: hermes.Body{
 			Name: "Jon Snow",
 			Intros: []string{
@@ -22,7 +23,7 @@ func (r *Reset) Email() hermes.Email {
 				{
 					Instructions: "Click the button below to reset your password:",
 					Button: hermes.Button{
-						Color: "#DC4D2F",
+						Color: "#C13F24",
 						Text:  "Reset your password",
 						Link:  "https://hermes-example.com/reset-password?token=d9729feb74992cc3482b350163a1a010",
 					},
@@ -13,6 +13,7 @@ func (w *Welcome) Name() string {
 
 func (w *Welcome) Email() hermes.Email {
 	return hermes.Email{
+		Subject: "Welcome to Hermes",
 		Body: hermes.Body{
 			Name: "Jon Snow",
 			Intros: []string{
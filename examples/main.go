@@ -1,188 +1,136 @@
 package main
 
 import (
-	"errors"
+	"context"
+	"flag"
 	"fmt"
-	"net/mail"
 	"os"
-	"strconv"
+	"path/filepath"
 
-	"github.com/go-gomail/gomail"
 	"github.com/unknowns24/hermes/examples/mails"
+	"github.com/unknowns24/hermes/examples/matrix"
 	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/preview"
+	"github.com/unknowns24/hermes/pkg/send"
 	"github.com/unknowns24/hermes/pkg/themes"
 	"golang.org/x/term"
 )
 
-type example interface {
-	Email() hermes.Email
-	Name() string
-}
-
 func main() {
-
-	h := hermes.Hermes{
-		Brand: hermes.Branding{
-			Name: "Hermes",
-			Link: "https://example-hermes.com/",
-			Logo: "https://github.com/matcornic/hermes/blob/master/examples/gopher.png?raw=true",
-		},
+	out := flag.String("out", ".", "directory of the generated emails")
+	eml := flag.Bool("eml", false, "also write the emails as .eml messages, to preview them in a mail client")
+	serve := flag.String("serve", "", "serve the emails at the address, e.g. localhost:8080, instead of generating them")
+	theme := flag.String("theme", "default", "registered theme of the served emails")
+	brand := flag.String("brand", "", "JSON file of the brand of the emails, instead of the HERMES_BRAND_* variables or matrix/brand.json")
+	flag.Parse()
+	if err := loadBrand(*brand); err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid brand:", err)
+		os.Exit(2)
+	}
+	if *serve != "" {
+		serveExamples(*serve, *theme)
+		return
 	}
 	sendEmails := os.Getenv("HERMES_SEND_EMAILS") == "true"
 
-	examples := []example{
-		new(mails.Welcome),
-		new(mails.Reset),
-		new(mails.Maintenance),
-		new(mails.Receipt),
-		new(mails.InviteCode),
-	}
-
-	themes := []hermes.Theme{
-		new(themes.Default),
-	}
-
-	// Generate emails
-	for _, theme := range themes {
-		h.Theme = theme
-		for _, e := range examples {
-			generateEmails(h, e.Email(), e.Name())
+	// Generate emails, e.g. default/ltr/inlined/welcome.html (and .eml with -eml)
+	cells := matrix.Cells()
+	for _, cell := range cells {
+		html, text, err := cell.Render()
+		if err != nil {
+			panic(fmt.Errorf("%v: %w", cell, err))
+		}
+		err = cell.Write(*out, html, text)
+		if err != nil {
+			panic(err)
+		}
+		if *eml {
+			err = cell.WriteEML(*out, hermes.EMLOptions{
+				From:    "Hermes <hello@hermes-example.com>",
+				Subject: "Hermes | " + cell.Theme.Name() + " | " + cell.Example.Name(),
+			})
+			if err != nil {
+				panic(err)
+			}
 		}
 	}
 
-	// Send emails only when requested
+	// Send emails only when requested, once the configuration is diagnosed
 	if sendEmails {
-		fmt.Println(">>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>")
-		fmt.Println(os.Getenv("HERMES_SMTP_PORT"))
-		fmt.Println(os.Getenv("HERMES_SMTP_USER"))
-		fmt.Println(os.Getenv("HERMES_SMTP_SERVER"))
-		fmt.Println(os.Getenv("HERMES_SENDER_EMAIL"))
-		fmt.Println(os.Getenv("HERMES_SENDER_IDENTITY"))
-		fmt.Println(os.Getenv("HERMES_TO"))
-		fmt.Println("<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<")
-
-		port, _ := strconv.Atoi(os.Getenv("HERMES_SMTP_PORT"))
-		password := os.Getenv("HERMES_SMTP_PASSWORD")
-		SMTPUser := os.Getenv("HERMES_SMTP_USER")
-		if password == "" {
-			fmt.Printf("Enter SMTP password of '%s' account: ", SMTPUser)
+		config := send.ConfigFromEnv(os.Getenv)
+		if config.SMTP.Username != "" && config.SMTP.Password == "" {
+			fmt.Printf("Enter SMTP password of '%s' account: ", config.SMTP.Username)
 			bytePassword, _ := term.ReadPassword(0)
-			password = string(bytePassword)
+			fmt.Println()
+			config.SMTP.Password = string(bytePassword)
 		}
-		smtpConfig := smtpAuthentication{
-			Server:         os.Getenv("HERMES_SMTP_SERVER"),
-			Port:           port,
-			SenderEmail:    os.Getenv("HERMES_SENDER_EMAIL"),
-			SenderIdentity: os.Getenv("HERMES_SENDER_IDENTITY"),
-			SMTPPassword:   password,
-			SMTPUser:       SMTPUser,
+		findings := send.Diagnose(context.Background(), config)
+		for _, finding := range findings {
+			fmt.Println(finding)
 		}
-		options := sendOptions{
-			To: os.Getenv("HERMES_TO"),
+		if send.HasErrors(findings) {
+			fmt.Fprintln(os.Stderr, "Not sending the emails: fix the errors above, or unset HERMES_SEND_EMAILS")
+			os.Exit(1)
 		}
-		for _, theme := range themes {
-			h.Theme = theme
-			for _, e := range examples {
-				options.Subject = "Hermes | " + h.Theme.Name() + " | " + e.Name()
-				fmt.Printf("Sending email '%s'...\n", options.Subject)
-				htmlBytes, err := os.ReadFile(fmt.Sprintf("examples/%v/%v.%v.html", h.Theme.Name(), h.Theme.Name(), e.Name()))
-				if err != nil {
-					panic(err)
-				}
-				txtBytes, err := os.ReadFile(fmt.Sprintf("examples/%v/%v.%v.txt", h.Theme.Name(), h.Theme.Name(), e.Name()))
-				if err != nil {
-					panic(err)
-				}
-				err = send(smtpConfig, options, string(htmlBytes), string(txtBytes))
-				if err != nil {
-					panic(err)
-				}
+
+		// Only the emails as sent in practice: left-to-right, with inlined CSS
+		for _, cell := range cells {
+			if cell.Direction != "ltr" || !cell.InlineCSS {
+				continue
+			}
+			path := filepath.Join(*out, cell.String())
+			htmlBytes, err := os.ReadFile(path + ".html")
+			if err != nil {
+				panic(err)
+			}
+			txtBytes, err := os.ReadFile(path + ".txt")
+			if err != nil {
+				panic(err)
+			}
+			m := send.BuildMessage(cell.Example.Email(), string(htmlBytes), string(txtBytes))
+			m.From = config.From
+			m.To = config.To
+			m.Subject = "Hermes | " + cell.Theme.Name() + " | " + cell.Example.Name()
+			fmt.Printf("Sending email '%s'...\n", m.Subject)
+			if err := config.SMTP.Send(context.Background(), m); err != nil {
+				fmt.Fprintln(os.Stderr, "Sending failed:", err)
+				os.Exit(1)
 			}
 		}
 	}
 }
 
-func generateEmails(h hermes.Hermes, email hermes.Email, example string) {
-	// Generate the HTML template and save it
-	res, err := h.GenerateHTML(email)
-	if err != nil {
-		panic(err)
-	}
-	err = os.MkdirAll(h.Theme.Name(), 0744)
-	if err != nil {
-		panic(err)
-	}
-	err = os.WriteFile(fmt.Sprintf("examples/%v/%v.%v.html", h.Theme.Name(), h.Theme.Name(), example), []byte(res), 0644)
-	if err != nil {
-		panic(err)
-	}
-
-	// Generate the plaintext template and save it
-	res, err = h.GeneratePlainText(email)
-	if err != nil {
-		panic(err)
-	}
-	err = os.WriteFile(fmt.Sprintf("examples/%v/%v.%v.txt", h.Theme.Name(), h.Theme.Name(), example), []byte(res), 0644)
-	if err != nil {
-		panic(err)
+// loadBrand replaces the brand of the examples by the one of the JSON file, or else of the HERMES_BRAND_* variables
+// when HERMES_BRAND_NAME is set
+func loadBrand(path string) error {
+	var err error
+	switch {
+	case path != "":
+		var f *os.File
+		if f, err = os.Open(path); err != nil {
+			return err
+		}
+		defer f.Close()
+		matrix.Brand, err = hermes.BrandingFromJSON(f)
+	case os.Getenv("HERMES_BRAND_NAME") != "":
+		matrix.Brand, err = hermes.BrandingFromEnv("HERMES_BRAND")
 	}
+	return err
 }
 
-type smtpAuthentication struct {
-	Server         string
-	Port           int
-	SenderEmail    string
-	SenderIdentity string
-	SMTPUser       string
-	SMTPPassword   string
-}
-
-// sendOptions are options for sending an email
-type sendOptions struct {
-	To      string
-	Subject string
-}
-
-// send sends the email
-func send(smtpConfig smtpAuthentication, options sendOptions, htmlBody string, txtBody string) error {
-
-	if smtpConfig.Server == "" {
-		return errors.New("SMTP server config is empty")
-	}
-	if smtpConfig.Port == 0 {
-		return errors.New("SMTP port config is empty")
-	}
-
-	if smtpConfig.SMTPUser == "" {
-		return errors.New("SMTP user is empty")
-	}
-
-	if smtpConfig.SenderIdentity == "" {
-		return errors.New("SMTP sender identity is empty")
-	}
-
-	if smtpConfig.SenderEmail == "" {
-		return errors.New("SMTP sender email is empty")
-	}
-
-	if options.To == "" {
-		return errors.New("no receiver emails configured")
-	}
-
-	from := mail.Address{
-		Name:    smtpConfig.SenderIdentity,
-		Address: smtpConfig.SenderEmail,
-	}
-
-	m := gomail.NewMessage()
-	m.SetHeader("From", from.String())
-	m.SetHeader("To", options.To)
-	m.SetHeader("Subject", options.Subject)
-
-	m.SetBody("text/plain", txtBody)
-	m.AddAlternative("text/html", htmlBody)
-
-	d := gomail.NewDialer(smtpConfig.Server, smtpConfig.Port, smtpConfig.SMTPUser, smtpConfig.SMTPPassword)
-
-	return d.DialAndSend(m)
+// serveExamples serves the example emails rendered with the theme until the server fails
+func serveExamples(addr, name string) {
+	theme, ok := themes.Lookup(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown theme %q\n", name)
+		os.Exit(2)
+	}
+	emails := map[string]hermes.Email{}
+	for _, example := range mails.All() {
+		emails[example.Name()] = example.Email()
+	}
+	fmt.Printf("Serving the example emails at http://%s/\n", addr)
+	err := preview.Serve(addr, hermes.Hermes{Theme: theme, Brand: matrix.Brand}, emails)
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
 }
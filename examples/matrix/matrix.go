@@ -0,0 +1,115 @@
+// Package matrix renders the example emails with every built-in theme, text direction and CSS mode.
+// It is shared by the examples program and the integration tests.
+package matrix
+
+import (
+	"bytes"
+	_ "embed"
+	"os"
+	"path/filepath"
+
+	"github.com/unknowns24/hermes/examples/mails"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+//go:embed brand.json
+var brandJSON []byte
+
+// Brand is the branding of the example emails, the one of brand.json unless replaced, e.g. by the examples program
+var Brand = mustLoadBrand(brandJSON)
+
+func mustLoadBrand(data []byte) hermes.Branding {
+	brand, err := hermes.BrandingFromJSON(bytes.NewReader(data))
+	if err != nil {
+		panic(err)
+	}
+	return brand
+}
+
+// Themes returns the built-in themes
+func Themes() []hermes.Theme {
+	return []hermes.Theme{
+		new(themes.Default),
+		new(themes.Corporate),
+		new(themes.Flat),
+	}
+}
+
+// Cell is a combination of the matrix
+type Cell struct {
+	Example   mails.Example
+	Theme     hermes.Theme
+	Direction hermes.TextDirection
+	InlineCSS bool
+}
+
+// Cells returns every example × built-in theme × text direction × CSS mode, always in the same order
+func Cells() []Cell {
+	var cells []Cell
+	for _, theme := range Themes() {
+		for _, direction := range []hermes.TextDirection{"ltr", "rtl"} {
+			for _, inline := range []bool{true, false} {
+				for _, example := range mails.All() {
+					cells = append(cells, Cell{Example: example, Theme: theme, Direction: direction, InlineCSS: inline})
+				}
+			}
+		}
+	}
+	return cells
+}
+
+// CSSMode is "inlined" or "styled", when CSS is kept in the style element
+func (c Cell) CSSMode() string {
+	if c.InlineCSS {
+		return "inlined"
+	}
+	return "styled"
+}
+
+// String returns the path of the cell, e.g. default/ltr/inlined/welcome
+func (c Cell) String() string {
+	return filepath.Join(c.Theme.Name(), string(c.Direction), c.CSSMode(), c.Example.Name())
+}
+
+// Hermes returns the engine of the cell
+func (c Cell) Hermes() hermes.Hermes {
+	return hermes.Hermes{
+		Theme:              c.Theme,
+		Brand:              Brand,
+		TextDirection:      c.Direction,
+		DisableCSSInlining: !c.InlineCSS,
+	}
+}
+
+// Render generates the HTML and plain text bodies of the example
+func (c Cell) Render() (html, text string, err error) {
+	h := c.Hermes()
+	return h.Generate(c.Example.Email())
+}
+
+// Write writes the bodies of the cell under dir, e.g. dir/default/ltr/inlined/welcome.html and .txt
+func (c Cell) Write(dir, html, text string) error {
+	path := filepath.Join(dir, c.String())
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".html", []byte(html), 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(path+".txt", []byte(text), 0644)
+}
+
+// WriteEML writes the cell as a message under dir, e.g. dir/default/ltr/inlined/welcome.eml, to preview it in a mail client
+func (c Cell) WriteEML(dir string, opts hermes.EMLOptions) error {
+	h := c.Hermes()
+	raw, err := h.GenerateEML(c.Example.Email(), opts)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, c.String())
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path+".eml", raw, 0644)
+}
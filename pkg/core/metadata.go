@@ -0,0 +1,49 @@
+package core
+
+// DataVersion is the version of the data given to the templates of themes, incremented when fields they may rely on
+// are added to it
+const DataVersion = 1
+
+// DefaultWidth is the viewport width in pixels at which themes without metadata are previewed
+const DefaultWidth = 600
+
+// DarkModeSupport is the level of support of dark mode by a theme
+type DarkModeSupport string
+
+// Levels of support of dark mode
+const (
+	DarkModeNone       DarkModeSupport = "none"        // No dark styles, clients may invert the colors
+	DarkModeMediaQuery DarkModeSupport = "media-query" // Dark styles under prefers-color-scheme, e.g. for Apple Mail
+	DarkModeFull       DarkModeSupport = "full"        // Dark styles for Outlook.com as well, under [data-ogsc]
+)
+
+// Metadata describes what a theme was designed for, e.g. for visual regression tooling to know which viewport widths
+// and client quirks to test
+type Metadata struct {
+	Widths         []int           `json:"widths"`           // Viewport widths in pixels, e.g. 320 and 600
+	MediaQueries   bool            `json:"media_queries"`    // Whether the layout relies on media queries, e.g. to stack columns
+	DarkMode       DarkModeSupport `json:"dark_mode"`        // Level of support of dark mode (default to DarkModeNone)
+	MinDataVersion int             `json:"min_data_version"` // Oldest DataVersion the templates can render
+}
+
+// ThemeMetadata is implemented by themes describing what they were designed for
+type ThemeMetadata interface {
+	Metadata() Metadata
+}
+
+// MetadataOf returns the metadata of the theme, if it implements ThemeMetadata
+func MetadataOf(theme Theme) (Metadata, bool) {
+	if t, ok := theme.(ThemeMetadata); ok {
+		return t.Metadata(), true
+	}
+	return Metadata{}, false
+}
+
+// LookupMetadata returns the metadata of the registered theme of the name, if it implements ThemeMetadata
+func LookupMetadata(name string) (Metadata, bool) {
+	theme, ok := LookupTheme(name)
+	if !ok {
+		return Metadata{}, false
+	}
+	return MetadataOf(theme)
+}
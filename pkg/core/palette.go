@@ -0,0 +1,22 @@
+package core
+
+// DarkModeColors is the palette used by the theme when the client is in dark mode. Colors are hex, rgb() or named
+// colors, other values are replaced by the default ones.
+type DarkModeColors struct {
+	Background string // Background of the page
+	Content    string // Background of the content
+	Text       string
+	Heading    string // Color of headings and values
+	Link       string
+	Button     string // Background of buttons, they keep their colors when empty
+	ButtonText string // Text of buttons, they keep their colors when empty
+}
+
+// DefaultDarkModeColors is the palette in dark mode by default
+var DefaultDarkModeColors = DarkModeColors{
+	Background: "#1E1F22",
+	Content:    "#2B2D31",
+	Text:       "#D4D7DC",
+	Heading:    "#FFFFFF",
+	Link:       "#8AB4F8",
+}
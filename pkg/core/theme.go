@@ -0,0 +1,51 @@
+// Package core holds the types shared by the hermes engine and its themes, so that themes can depend on them without
+// depending on the engine, which depends on the themes for its default one.
+package core
+
+import (
+	"sort"
+	"sync"
+)
+
+// Theme is an interface to implement when creating a new theme
+type Theme interface {
+	Name() string              // The name of the theme
+	HTMLTemplate() string      // The golang template for HTML emails
+	PlainTextTemplate() string // The golang templte for plain text emails (can be basic HTML)
+}
+
+// DefaultThemeName is the name of the theme of engines without theme, registered by the themes package
+const DefaultThemeName = "default"
+
+// registry holds the themes by name
+var registry = struct {
+	sync.RWMutex
+	themes map[string]Theme
+}{themes: map[string]Theme{}}
+
+// RegisterTheme adds the theme to the registry, replacing the theme of the same name
+func RegisterTheme(theme Theme) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.themes[theme.Name()] = theme
+}
+
+// LookupTheme returns the registered theme of the name
+func LookupTheme(name string) (Theme, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	theme, ok := registry.themes[name]
+	return theme, ok
+}
+
+// ThemeNames returns the names of the registered themes, sorted
+func ThemeNames() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+	names := make([]string, 0, len(registry.themes))
+	for name := range registry.themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,185 @@
+package hermestest
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+// Update makes AssertRender and AssertGolden write the golden files instead of comparing them. It is also set by an
+// -update flag of the test binary, when the tests define one:
+//
+//	var _ = flag.Bool("update", false, "update the golden files")
+var Update bool
+
+// updating reports whether golden files are to be written
+func updating() bool {
+	if Update {
+		return true
+	}
+	f := flag.Lookup("update")
+	return f != nil && f.Value.String() == "true"
+}
+
+// AssertRender generates both bodies of the email with the engine, and compares them to the golden files
+// goldenPath+".html" and goldenPath+".txt", e.g. "testdata/welcome" for a custom theme. Differences are reported line
+// by line. With Update, the golden files are written instead.
+func AssertRender(t testing.TB, h hermes.Hermes, email hermes.Email, goldenPath string) bool {
+	t.Helper()
+	html, text, err := h.Generate(email)
+	if err != nil {
+		t.Errorf("Generating %s: %v", goldenPath, err)
+		return false
+	}
+	okHTML := AssertGolden(t, goldenPath+".html", html)
+	okText := AssertGolden(t, goldenPath+".txt", text)
+	return okHTML && okText
+}
+
+// AssertGolden compares the output to the golden file at path, reporting the differing lines. With Update, the golden
+// file is written instead, along with its directories.
+func AssertGolden(t testing.TB, path string, output string) bool {
+	t.Helper()
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Errorf("Writing %s: %v", path, err)
+			return false
+		}
+		if err := os.WriteFile(path, []byte(output), 0644); err != nil {
+			t.Errorf("Writing %s: %v", path, err)
+			return false
+		}
+		return true
+	}
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("Reading %s: %v, run the tests with -update to write it", path, err)
+		return false
+	}
+	if diff := Diff(string(golden), output); diff != "" {
+		t.Errorf("Output differs from %s, run the tests with -update if the change is expected:\n%s", path, diff)
+		return false
+	}
+	return true
+}
+
+// diffContext is the number of unchanged lines written around the changes of Diff
+const diffContext = 3
+
+// Diff returns the differences between the lines of want and got, in the unified format: hunks of lines removed from
+// want prefixed with "-", and added in got with "+", between unchanged lines. It returns "" when they are equal.
+func Diff(want, got string) string {
+	if want == got {
+		return ""
+	}
+	a, b := strings.Split(want, "\n"), strings.Split(got, "\n")
+	// Common prefix and suffix are left out of the longest common subsequence, which is quadratic
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	edits := diffLines(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+
+	// Lines of the whole files, each with its operation and its line numbers
+	type line struct {
+		op           byte // ' ', '-' or '+'
+		text         string
+		aLine, bLine int
+	}
+	var lines []line
+	for i := 0; i < prefix; i++ {
+		lines = append(lines, line{' ', a[i], i, i})
+	}
+	i, j := prefix, prefix
+	for _, op := range edits {
+		switch op {
+		case ' ':
+			lines = append(lines, line{' ', a[i], i, j})
+			i, j = i+1, j+1
+		case '-':
+			lines = append(lines, line{'-', a[i], i, j})
+			i++
+		case '+':
+			lines = append(lines, line{'+', b[j], i, j})
+			j++
+		}
+	}
+	for k := 0; k < suffix; k++ {
+		lines = append(lines, line{' ', a[i+k], i + k, j + k})
+	}
+
+	var out strings.Builder
+	for start := 0; start < len(lines); {
+		if lines[start].op == ' ' {
+			start++
+			continue
+		}
+		// A hunk spans the changes less than twice the context apart, with the context around them
+		end := start
+		for k := start; k < len(lines) && k-end <= 2*diffContext; k++ {
+			if lines[k].op != ' ' {
+				end = k
+			}
+		}
+		from, to := max(start-diffContext, 0), min(end+diffContext+1, len(lines))
+		var aCount, bCount int
+		for _, l := range lines[from:to] {
+			if l.op != '+' {
+				aCount++
+			}
+			if l.op != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", lines[from].aLine+1, aCount, lines[from].bLine+1, bCount)
+		for _, l := range lines[from:to] {
+			fmt.Fprintf(&out, "%c%s\n", l.op, l.text)
+		}
+		start = to
+	}
+	return out.String()
+}
+
+// diffLines returns the edits turning a into b, from their longest common subsequence: ' ' keeps a line of both, '-'
+// removes a line of a and '+' adds a line of b
+func diffLines(a, b []string) []byte {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var edits []byte
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, ' ')
+			i, j = i+1, j+1
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, '-')
+			i++
+		default:
+			edits = append(edits, '+')
+			j++
+		}
+	}
+	return edits
+}
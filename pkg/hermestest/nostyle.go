@@ -0,0 +1,196 @@
+// Package hermestest provides helpers to test the emails generated by hermes, and custom themes
+package hermestest
+
+import (
+	"strings"
+	"testing"
+
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// StripStyles removes the style attributes and the style elements of the HTML email, as some mail gateways do
+func StripStyles(htmlEmail string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(htmlEmail))
+	if err != nil {
+		return "", err
+	}
+	stripStyles(doc)
+	var b strings.Builder
+	if err := html.Render(&b, doc); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func stripStyles(n *html.Node) {
+	attrs := n.Attr[:0]
+	for _, a := range n.Attr {
+		if a.Key != "style" {
+			attrs = append(attrs, a)
+		}
+	}
+	n.Attr = attrs
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.ElementNode && c.DataAtom == atom.Style {
+			n.RemoveChild(c)
+		} else {
+			stripStyles(c)
+		}
+		c = next
+	}
+}
+
+// AssertReadableWithoutCSS strips the styles of the HTML email generated for the email, and asserts that its text is still
+// in reading order: the brand first, then the content of the body in the order of the Body fields, the trouble text of the buttons
+// and the copyright last. Values holding HTML, and the content replaced by free markdown, are not checked.
+func AssertReadableWithoutCSS(t testing.TB, h hermes.Hermes, email hermes.Email, htmlEmail string) bool {
+	t.Helper()
+	stripped, err := StripStyles(htmlEmail)
+	if err != nil {
+		t.Errorf("HTML email does not parse: %v", err)
+		return false
+	}
+	doc, err := html.Parse(strings.NewReader(stripped))
+	if err != nil {
+		t.Errorf("HTML email without styles does not parse: %v", err)
+		return false
+	}
+	text := textContent(doc)
+
+	cursor := 0
+	previous := "the start of the email"
+	for _, expected := range readingOrder(h, email) {
+		i := strings.Index(text[cursor:], expected)
+		if i < 0 {
+			if strings.Contains(text, expected) {
+				t.Errorf("Without CSS, %q is read before %q", expected, previous)
+			} else {
+				t.Errorf("Without CSS, %q is missing", expected)
+			}
+			return false
+		}
+		cursor += i + len(expected)
+		previous = expected
+	}
+	return true
+}
+
+// readingOrder returns the texts of the email, in the order they must be read
+func readingOrder(h hermes.Hermes, email hermes.Email) []string {
+	h.Brand = h.BrandOf(email)
+	if err := h.SetDefaultHermesValues(); err != nil {
+		return nil
+	}
+	if err := email.SetDefaultEmailValues(); err != nil {
+		return nil
+	}
+	expand := func(s string) string {
+		s = strings.NewReplacer("{{", "\x00", "}}", "\x01").Replace(s)
+		for name, value := range email.Params {
+			s = strings.ReplaceAll(s, "{"+name+"}", value)
+		}
+		for name, phrase := range email.Phrases {
+			if _, ok := email.Params[name]; !ok {
+				s = strings.ReplaceAll(s, "{"+name+"}", phrase.Format(h.Locale))
+			}
+		}
+		return strings.NewReplacer("\x00", "{", "\x01", "}").Replace(s)
+	}
+
+	var texts []string
+	add := func(s ...string) {
+		for _, s := range s {
+			if s = collapse(s); s != "" {
+				texts = append(texts, s)
+			}
+		}
+	}
+	if h.Brand.EffectiveLogo().URL == "" {
+		add(h.Brand.Name)
+	}
+	body := email.Body
+	if body.Title != "" {
+		add(body.Title)
+	} else {
+		add(body.Greeting, body.Name)
+	}
+	for _, intro := range body.Intros {
+		add(expand(intro))
+	}
+	if body.FreeMarkdown == "" {
+		for _, entry := range body.Dictionary {
+			if entry.Markdown {
+				// The markup of markdown is not written
+				add(entry.Key)
+				continue
+			}
+			add(entry.Key, expand(entry.Value))
+		}
+		if body.Summary != nil {
+			for _, section := range body.Summary.Sections {
+				add(section.Label, section.FormattedValue())
+			}
+		}
+		for _, table := range body.AllTables() {
+			if len(table.Data) == 0 {
+				continue
+			}
+			add(table.Title)
+			for _, entry := range table.Data[0] {
+				add(entry.Key)
+			}
+			for _, row := range table.Data {
+				for _, entry := range row {
+					add(entry.Value)
+				}
+			}
+		}
+		for _, entry := range body.Schedule {
+			add(entry.Label)
+		}
+		for _, action := range body.Actions {
+			add(action.Instructions, action.Button.Text, action.InviteCode)
+		}
+	}
+	for _, outro := range body.Outros {
+		add(outro)
+	}
+	if c := body.ContactInstructions; c != nil {
+		add(c.Text, c.Email, c.URL)
+	}
+	add(body.Signature)
+	if body.FreeMarkdown == "" {
+		for _, action := range body.Actions {
+			add(action.FallbackText(h.Brand.TroubleText))
+		}
+	}
+	add(h.Brand.Copyright)
+	return texts
+}
+
+// textContent returns the text of the document in reading order, without the head, comments and white space runs
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+			b.WriteByte(' ')
+		case n.Type == html.ElementNode && (n.DataAtom == atom.Head || n.DataAtom == atom.Script):
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return collapse(b.String())
+}
+
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,371 @@
+package hermes
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Codes of accessibility issues
+const (
+	A11yMissingLang    = "missing_lang"    // The html element has no lang attribute
+	A11yMissingTitle   = "missing_title"   // The document has no title
+	A11yMissingAlt     = "missing_alt"     // An image has no alt attribute
+	A11yLowContrast    = "low_contrast"    // Text does not contrast enough with its background
+	A11yEmptyLink      = "empty_link"      // A link has no text, nor image with alt text
+	A11yVagueLink      = "vague_link"      // The text of a link does not tell its purpose, e.g. "click here"
+	A11yLayoutTable    = "layout_table"    // A table without header cells is not marked as presentation
+	A11yNoHeading      = "no_heading"      // The document has no heading
+	A11yHeadingSkipped = "heading_skipped" // A heading level is skipped, e.g. h1 then h3
+	A11yEmptyHeading   = "empty_heading"   // A heading has no text
+)
+
+// wcagCriteria are the WCAG 2.1 success criteria failed by the issues, by code
+var wcagCriteria = map[string]string{
+	A11yMissingLang:    "3.1.1",
+	A11yMissingTitle:   "2.4.2",
+	A11yMissingAlt:     "1.1.1",
+	A11yLowContrast:    "1.4.3",
+	A11yEmptyLink:      "2.4.4",
+	A11yVagueLink:      "2.4.4",
+	A11yLayoutTable:    "1.3.1",
+	A11yNoHeading:      "1.3.1",
+	A11yHeadingSkipped: "1.3.1",
+	A11yEmptyHeading:   "2.4.6",
+}
+
+// A11yStatus is the overall result of an accessibility audit
+type A11yStatus string
+
+// Statuses of audits
+const (
+	A11yPass A11yStatus = "pass" // No warning nor error
+	A11yWarn A11yStatus = "warn" // Warnings, but no error
+	A11yFail A11yStatus = "fail" // Errors
+)
+
+// A11yIssue is an accessibility problem of the final HTML, with the WCAG 2.1 success criterion it fails
+type A11yIssue struct {
+	Code      string   `json:"code"`
+	Criterion string   `json:"criterion"` // e.g. "1.1.1" for non-text content
+	Severity  Severity `json:"severity"`
+	Path      string   `json:"path"` // Element path, e.g. html > body > table.email-wrapper
+	Message   string   `json:"message"`
+}
+
+// A11yReport is the result of AuditAccessibility, its JSON encoding is stable for dashboards and fixtures
+type A11yReport struct {
+	Status A11yStatus       `json:"status"`
+	Counts map[Severity]int `json:"counts"` // Number of issues by severity, including the severities without issue
+	Issues []A11yIssue      `json:"issues"`
+}
+
+// vagueLinkTexts are link texts that do not tell where the link leads
+var vagueLinkTexts = map[string]bool{
+	"click here": true, "here": true, "click": true, "link": true, "this link": true, "more": true, "read more": true,
+}
+
+// AuditAccessibility runs the accessibility checks over the final HTML of an email: language, title, alternative text
+// of images, contrast of inline colors, link texts, layout tables and heading structure.
+// Contrast is computed from inline styles and bgcolor attributes, it is only reliable on HTML with inlined CSS: text without
+// inline color is not checked, as a style element may set it.
+// Issues are sorted from the most to the least serious, then in document order.
+func AuditAccessibility(htmlEmail string) A11yReport {
+	report := A11yReport{Counts: map[Severity]int{SeverityInfo: 0, SeverityWarning: 0, SeverityError: 0}, Issues: []A11yIssue{}}
+	add := func(code string, severity Severity, path, format string, args ...interface{}) {
+		report.Issues = append(report.Issues, A11yIssue{
+			Code:      code,
+			Criterion: wcagCriteria[code],
+			Severity:  severity,
+			Path:      path,
+			Message:   fmt.Sprintf(format, args...),
+		})
+	}
+
+	doc, err := html.Parse(strings.NewReader(htmlEmail))
+	if err != nil {
+		// The parser is error tolerant, this does not happen with a strings.Reader
+		return report
+	}
+
+	var title string
+	hasTitle := false
+	headingLevel := 0
+	seenContrast := map[string]bool{}
+	var walk func(n *html.Node, path string, style textStyle)
+	walk = func(n *html.Node, path string, style textStyle) {
+		switch n.Type {
+		case html.ElementNode:
+			path = strings.TrimPrefix(path+" > "+nodeName(n), " > ")
+			style = style.apply(n)
+			switch n.Data {
+			case "html":
+				if lang, _ := attr(n, "lang"); strings.TrimSpace(lang) == "" {
+					add(A11yMissingLang, SeverityError, path, "the html element has no lang attribute, screen readers cannot pick the language")
+				}
+			case "title":
+				hasTitle = true
+				title = strings.TrimSpace(textContent(n))
+			case "img":
+				if _, ok := attr(n, "alt"); !ok && !isDecorative(n) {
+					add(A11yMissingAlt, SeverityError, path, "image without alt attribute, use alt=\"\" for decorative images")
+				}
+			case "a":
+				if _, ok := attr(n, "href"); ok {
+					text := accessibleText(n)
+					switch {
+					case text == "":
+						add(A11yEmptyLink, SeverityError, path, "link without text, nor image with alt text")
+					case vagueLinkTexts[strings.ToLower(strings.Trim(text, ".!… "))]:
+						add(A11yVagueLink, SeverityWarning, path, "link text %q does not tell its purpose", text)
+					}
+				}
+			case "table":
+				if role, _ := attr(n, "role"); role != "presentation" && role != "none" && !hasHeaderCells(n) {
+					add(A11yLayoutTable, SeverityWarning, path, "table without header cells should have role=\"presentation\"")
+				}
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				level := int(n.Data[1] - '0')
+				if level > headingLevel+1 {
+					add(A11yHeadingSkipped, SeverityWarning, path, "%s follows h%d, heading levels should not be skipped", n.Data, headingLevel)
+				}
+				headingLevel = level
+				if strings.TrimSpace(textContent(n)) == "" {
+					add(A11yEmptyHeading, SeverityWarning, path, "heading without text")
+				}
+			case "head", "script", "style":
+				if n.Data != "head" {
+					return
+				}
+			}
+			if style.hidden {
+				return
+			}
+		case html.TextNode:
+			if strings.TrimSpace(n.Data) == "" || style.fg == nil || style.bg == nil {
+				break
+			}
+			ratio := contrastRatio(*style.fg, *style.bg)
+			minimum := 4.5
+			if style.large() {
+				minimum = 3
+			}
+			key := path + style.fg.String() + style.bg.String()
+			if ratio < minimum && !seenContrast[key] {
+				seenContrast[key] = true
+				add(A11yLowContrast, SeverityError, path, "contrast of %s on %s is %.2f:1, below %.1f:1", style.fg, style.bg, math.Floor(ratio*100)/100, minimum)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, path, style)
+		}
+	}
+	white := rgb{255, 255, 255}
+	walk(doc, "", textStyle{bg: &white, size: 16})
+
+	if !hasTitle || title == "" {
+		add(A11yMissingTitle, SeverityWarning, "html > head", "the document has no title")
+	}
+	if headingLevel == 0 {
+		add(A11yNoHeading, SeverityWarning, "html > body", "the document has no heading")
+	}
+
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		return report.Issues[i].Severity > report.Issues[j].Severity
+	})
+	for _, issue := range report.Issues {
+		report.Counts[issue.Severity]++
+	}
+	switch {
+	case report.Counts[SeverityError] > 0:
+		report.Status = A11yFail
+	case report.Counts[SeverityWarning] > 0:
+		report.Status = A11yWarn
+	default:
+		report.Status = A11yPass
+	}
+	return report
+}
+
+// isDecorative reports whether the element is hidden from assistive technologies
+func isDecorative(n *html.Node) bool {
+	role, _ := attr(n, "role")
+	hidden, _ := attr(n, "aria-hidden")
+	return role == "presentation" || role == "none" || hidden == "true"
+}
+
+// accessibleText returns the text of a link read by screen readers: its aria-label, or its text and the alt text of its images
+func accessibleText(n *html.Node) string {
+	if label, ok := attr(n, "aria-label"); ok && strings.TrimSpace(label) != "" {
+		return strings.TrimSpace(label)
+	}
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+		case n.Type == html.ElementNode && n.Data == "img":
+			alt, _ := attr(n, "alt")
+			b.WriteString(" " + alt + " ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// hasHeaderCells reports whether the table has th cells of its own, not of nested tables
+func hasHeaderCells(table *html.Node) bool {
+	var find func(*html.Node) bool
+	find = func(n *html.Node) bool {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || c.Data == "table" {
+				continue
+			}
+			if c.Data == "th" || find(c) {
+				return true
+			}
+		}
+		return false
+	}
+	return find(table)
+}
+
+// textStyle is the style inherited by text, from inline styles and attributes
+type textStyle struct {
+	fg, bg *rgb // nil when unknown, e.g. set by a style element
+	size   float64
+	bold   bool
+	hidden bool
+}
+
+// apply returns the style of the element, inheriting the one of its parent
+func (s textStyle) apply(n *html.Node) textStyle {
+	switch n.Data {
+	case "b", "strong", "th", "h1", "h2", "h3", "h4", "h5", "h6":
+		s.bold = true
+	}
+	if bg, ok := attr(n, "bgcolor"); ok {
+		s.bg = parseColor(bg)
+	}
+	style, _ := attr(n, "style")
+	for _, decl := range parseDeclarations(style) {
+		switch decl.property {
+		case "color":
+			s.fg = parseColor(decl.value)
+		case "background-color":
+			if c := parseColor(decl.value); c != nil || decl.value != "transparent" {
+				s.bg = c
+			}
+		case "background":
+			for _, token := range strings.Fields(decl.value) {
+				if c := parseColor(token); c != nil {
+					s.bg = c
+				}
+			}
+		case "font-size":
+			if size, ok := cssPixels(decl.value); ok {
+				s.size = size
+			}
+		case "font-weight":
+			weight, err := strconv.Atoi(decl.value)
+			s.bold = decl.value == "bold" || decl.value == "bolder" || (err == nil && weight >= 600)
+		case "display":
+			s.hidden = s.hidden || decl.value == "none"
+		}
+	}
+	return s
+}
+
+// large reports whether the text is large in the sense of WCAG: 18pt, or 14pt bold
+func (s textStyle) large() bool {
+	return s.size >= 24 || (s.bold && s.size >= 18.66)
+}
+
+// cssPixels returns the size in pixels of a px or pt length
+func cssPixels(value string) (float64, bool) {
+	for unit, factor := range map[string]float64{"px": 1, "pt": 4.0 / 3} {
+		if strings.HasSuffix(value, unit) {
+			v, err := strconv.ParseFloat(strings.TrimSuffix(value, unit), 64)
+			return v * factor, err == nil
+		}
+	}
+	return 0, false
+}
+
+type rgb struct {
+	r, g, b uint8
+}
+
+func (c rgb) String() string {
+	return fmt.Sprintf("#%02X%02X%02X", c.r, c.g, c.b)
+}
+
+var namedColors = map[string]rgb{
+	"black": {0, 0, 0}, "white": {255, 255, 255}, "gray": {128, 128, 128}, "grey": {128, 128, 128}, "silver": {192, 192, 192},
+	"red": {255, 0, 0}, "green": {0, 128, 0}, "blue": {0, 0, 255}, "navy": {0, 0, 128}, "maroon": {128, 0, 0},
+	"purple": {128, 0, 128}, "teal": {0, 128, 128}, "orange": {255, 165, 0}, "yellow": {255, 255, 0},
+}
+
+// parseColor parses a hex, rgb() or basic named color, it returns nil for other values
+func parseColor(value string) *rgb {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if c, ok := namedColors[value]; ok {
+		return &c
+	}
+	if strings.HasPrefix(value, "#") {
+		hex := value[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if len(hex) != 6 || err != nil {
+			return nil
+		}
+		return &rgb{uint8(v >> 16), uint8(v >> 8), uint8(v)}
+	}
+	if strings.HasPrefix(value, "rgb(") && strings.HasSuffix(value, ")") {
+		parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(value, "rgb("), ")"), ",")
+		if len(parts) != 3 {
+			return nil
+		}
+		var c [3]uint8
+		for i, p := range parts {
+			v, err := strconv.Atoi(strings.TrimSpace(p))
+			if err != nil || v < 0 || v > 255 {
+				return nil
+			}
+			c[i] = uint8(v)
+		}
+		return &rgb{c[0], c[1], c[2]}
+	}
+	return nil
+}
+
+// luminance returns the relative luminance of the color, as defined by WCAG
+func (c rgb) luminance() float64 {
+	channel := func(v uint8) float64 {
+		s := float64(v) / 255
+		if s <= 0.03928 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(c.r) + 0.7152*channel(c.g) + 0.0722*channel(c.b)
+}
+
+// contrastRatio returns the contrast ratio of the colors, from 1 to 21
+func contrastRatio(a, b rgb) float64 {
+	la, lb := a.luminance(), b.luminance()
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
@@ -0,0 +1,167 @@
+package hermes
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// IssueAutoAltText is the code of the warnings listing the images given an alt text by Hermes.AutoAltText
+const IssueAutoAltText = "auto_alt_text"
+
+// genericImageWords are file name words telling nothing about the image, e.g. in IMG_2041.jpg
+var genericImageWords = map[string]bool{"img": true, "image": true, "pic": true, "picture": true, "photo": true, "dsc": true, "file": true}
+
+// altText is an alt text derived for an image, and the context it comes from
+type altText struct {
+	text, source string
+}
+
+// addAltTexts gives an alt text to the images of the HTML without alt attribute, derived from their context:
+// the product name of their table row or dictionary entry, their file name, or the instructions of the previous action.
+// Images with an alt attribute, even empty, are never changed. It returns the HTML and a warning for each image changed.
+func addAltTexts(doc string) (string, Issues) {
+	root, err := html.Parse(strings.NewReader(doc))
+	if err != nil {
+		return doc, nil
+	}
+	var alts []altText
+	var issues Issues
+	var instructions string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if v, _ := attr(n, "data-hermes"); v == "instructions" {
+				instructions = collapsedText(n)
+			}
+			if _, ok := attr(n, "alt"); n.Data == "img" && !ok {
+				alt := deriveAltText(n, instructions)
+				alts = append(alts, alt)
+				if alt.text != "" {
+					src, _ := attr(n, "src")
+					issues = append(issues, Issue{
+						Code:     IssueAutoAltText,
+						Severity: SeverityWarning,
+						Path:     nodePath(n),
+						Message:  fmt.Sprintf("alt text %q generated from the %s of %s", alt.text, alt.source, src),
+					})
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+	if len(issues) == 0 {
+		return doc, nil
+	}
+
+	// The tokens are copied as is, so that only the images change
+	var b strings.Builder
+	z := html.NewTokenizer(strings.NewReader(doc))
+	i := 0
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		raw := string(z.Raw())
+		if tt == html.StartTagToken || tt == html.SelfClosingTagToken {
+			if t := z.Token(); t.Data == "img" && !hasAttr(t, "alt") {
+				if i >= len(alts) {
+					return doc, nil
+				}
+				if alt := alts[i].text; alt != "" {
+					raw = raw[:len("<img")] + ` alt="` + html.EscapeString(alt) + `"` + raw[len("<img"):]
+				}
+				i++
+			}
+		}
+		b.WriteString(raw)
+	}
+	// The parser and the tokenizer must agree on the images, otherwise alt texts could be misplaced
+	if i != len(alts) {
+		return doc, nil
+	}
+	return b.String(), issues
+}
+
+func hasAttr(t html.Token, key string) bool {
+	for _, a := range t.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// deriveAltText returns the alt text of the image from its nearest context, or an empty one
+func deriveAltText(img *html.Node, instructions string) altText {
+	if name := productName(img); name != "" {
+		return altText{name, "product name"}
+	}
+	src, _ := attr(img, "src")
+	if name := fileNameText(src); name != "" {
+		return altText{name, "file name"}
+	}
+	if instructions != "" {
+		return altText{instructions, "action instructions"}
+	}
+	return altText{}
+}
+
+// productName returns the text of the first other cell of the table row of the image, or the key of its dictionary entry
+func productName(img *html.Node) string {
+	for n := img.Parent; n != nil; n = n.Parent {
+		switch n.Data {
+		case "dd":
+			for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+				if s.Type == html.ElementNode && s.Data == "dt" {
+					return strings.TrimSuffix(collapsedText(s), ":")
+				}
+			}
+			return ""
+		case "td", "th":
+			if n.Parent == nil {
+				return ""
+			}
+			for c := n.Parent.FirstChild; c != nil; c = c.NextSibling {
+				if c != n && c.Type == html.ElementNode && (c.Data == "td" || c.Data == "th") {
+					if text := collapsedText(c); text != "" {
+						return text
+					}
+				}
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
+// fileNameText returns the file name of the URL without extension, its separators replaced by spaces,
+// or "" when it tells nothing about the image, e.g. a hash or IMG_2041.jpg
+func fileNameText(src string) string {
+	u, err := url.Parse(src)
+	if err != nil || u.Scheme == "data" {
+		return ""
+	}
+	name := path.Base(u.Path)
+	if ext := path.Ext(name); ext != name {
+		name = strings.TrimSuffix(name, ext)
+	}
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '-' || r == '_' || r == '+' || r == '.' || unicode.IsSpace(r)
+	})
+	for _, word := range words {
+		if len([]rune(word)) >= 3 && strings.IndexFunc(word, func(r rune) bool { return !unicode.IsLetter(r) }) < 0 &&
+			!genericImageWords[strings.ToLower(word)] {
+			return strings.Join(words, " ")
+		}
+	}
+	return ""
+}
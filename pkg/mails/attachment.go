@@ -0,0 +1,45 @@
+package hermes
+
+import (
+	"mime"
+	"path/filepath"
+)
+
+// Attachment is a file sent along with the email.
+// Inline attachments are displayed by the HTML version instead of being listed, e.g. a logo referenced as Brand.Logo = attachment.URL(),
+// so that images render in clients blocking remote content.
+// Generation does not embed them: they are added to the MIME message by send.BuildMessage.
+type Attachment struct {
+	Filename    string `json:"filename,omitempty" yaml:"filename,omitempty"`
+	ContentType string `json:"content_type,omitempty" yaml:"content_type,omitempty"` // e.g. image/png, detected from the extension of Filename when empty
+	Data        []byte `json:"data,omitempty" yaml:"data,omitempty"`
+	Inline      bool   `json:"inline,omitempty" yaml:"inline,omitempty"`
+	CID         string `json:"cid,omitempty" yaml:"cid,omitempty"` // Content-ID of an inline attachment, Filename when empty
+	// Ref references Data stored elsewhere, e.g. the key of an object storage, so that messages serialized for later
+	// sending do not hold it. Attachments with a Ref and without Data are resolved by send.ResolvingSender.
+	Ref string `json:"ref,omitempty" yaml:"ref,omitempty"`
+}
+
+// ContentID returns the Content-ID of the attachment, without angle brackets
+func (a Attachment) ContentID() string {
+	if a.CID != "" {
+		return a.CID
+	}
+	return a.Filename
+}
+
+// URL returns the cid: URL referencing the inline attachment in the HTML version, e.g. cid:logo.png
+func (a Attachment) URL() string {
+	return "cid:" + a.ContentID()
+}
+
+// MediaType returns the content type of the attachment, application/octet-stream when it is unknown
+func (a Attachment) MediaType() string {
+	if a.ContentType != "" {
+		return a.ContentType
+	}
+	if t := mime.TypeByExtension(filepath.Ext(a.Filename)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
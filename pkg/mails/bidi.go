@@ -0,0 +1,97 @@
+package hermes
+
+import (
+	"html/template"
+	"unicode"
+)
+
+// Bidi values of an Entry, telling the direction of its value
+const (
+	BidiAuto = "auto" // Direction is detected by the email client
+	BidiLTR  = "ltr"
+	BidiRTL  = "rtl"
+)
+
+// Unicode directional isolates, used in plain text where <bdi> is not available
+const (
+	lri = "\u2066" // Left-to-right isolate
+	rli = "\u2067" // Right-to-left isolate
+	fsi = "\u2068" // First strong isolate
+	pdi = "\u2069" // Pop directional isolate
+)
+
+// isolationDirection returns the direction to isolate the value in, or "" when it does not need to be isolated.
+// Without an explicit bidi, values of right-to-left emails containing only left-to-right content
+// (URLs, emails, codes, numbers) are isolated so that they are not garbled by the surrounding text.
+func isolationDirection(value string, bidi string, textDirection TextDirection) string {
+	switch bidi {
+	case BidiAuto, BidiLTR, BidiRTL:
+		return bidi
+	}
+	if textDirection != "rtl" {
+		return ""
+	}
+
+	hasLTR, hasRTL := false, false
+	for _, r := range value {
+		switch {
+		case unicode.In(r, unicode.Arabic, unicode.Hebrew, unicode.Syriac, unicode.Thaana, unicode.Nko):
+			hasRTL = true
+		case unicode.IsLetter(r), unicode.IsDigit(r):
+			hasLTR = true
+		}
+	}
+	switch {
+	case hasLTR && !hasRTL:
+		return BidiLTR
+	case hasLTR && hasRTL:
+		return BidiAuto
+	}
+	return ""
+}
+
+// isolate returns the value as HTML, wrapped in a <bdi> element when it needs to be isolated
+func isolate(value string, bidi string, textDirection TextDirection) template.HTML {
+	escaped := template.HTMLEscapeString(value)
+	switch isolationDirection(value, bidi, textDirection) {
+	case BidiLTR:
+		return template.HTML(`<bdi dir="ltr">` + escaped + `</bdi>`)
+	case BidiRTL:
+		return template.HTML(`<bdi dir="rtl">` + escaped + `</bdi>`)
+	case BidiAuto:
+		return template.HTML(`<bdi>` + escaped + `</bdi>`)
+	}
+	return template.HTML(escaped)
+}
+
+// isolateText returns the value wrapped in Unicode isolates when it needs to be isolated
+func isolateText(value string, bidi string, textDirection TextDirection) string {
+	switch isolationDirection(value, bidi, textDirection) {
+	case BidiLTR:
+		return lri + value + pdi
+	case BidiRTL:
+		return rli + value + pdi
+	case BidiAuto:
+		return fsi + value + pdi
+	}
+	return value
+}
+
+// Alignments of table columns given in CustomAlignment, on top of "left", "right" and "center"
+const (
+	AlignStart = "start" // Left in left-to-right emails, right in right-to-left ones
+	AlignEnd   = "end"   // Right in left-to-right emails, left in right-to-left ones
+)
+
+// align returns the alignment as written in the HTML email: "start" and "end" are resolved, as Outlook ignores them,
+// and "left" and "right" are mirrored in right-to-left emails, like the rest of the layout
+func align(alignment string, textDirection TextDirection) string {
+	rtl := textDirection == TDRightToLeft
+	switch {
+	case alignment == AlignStart && rtl, alignment == AlignEnd && !rtl, alignment == "left" && rtl:
+		return "right"
+	case alignment == AlignStart, alignment == AlignEnd, alignment == "right" && rtl:
+		return "left"
+	}
+	return alignment
+}
@@ -0,0 +1,152 @@
+package hermes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/imdario/mergo"
+	"gopkg.in/yaml.v3"
+)
+
+// BrandOf returns the branding of the email: the fields of Email.Brand which are set, the others being the ones of the
+// engine, e.g. a tenant overriding the name and the logo keeps the copyright of the engine. Slices are not merged, and
+// the fields of Unsubscribe are. The logo, Logo or LogoImage, is overridden as a whole. Neither branding is modified.
+func (h *Hermes) BrandOf(email Email) Branding {
+	if email.Brand == nil {
+		return h.Brand
+	}
+	brand := *email.Brand
+	if brand.Unsubscribe != nil {
+		// mergo merges the values of pointers in place
+		u := *brand.Unsubscribe
+		brand.Unsubscribe = &u
+	}
+	// Merging values of the same type can't fail
+	_ = mergo.Merge(&brand, h.Brand)
+	if email.Brand.Logo != "" || email.Brand.LogoImage != (Logo{}) {
+		brand.Logo, brand.LogoImage = email.Brand.Logo, email.Brand.LogoImage
+	}
+	return brand
+}
+
+// BrandingFromJSON returns the branding defined by the JSON document of the reader, e.g. a configuration file shared
+// by services, with fields named in snake case like json.Marshal writes them, e.g. social_links or unsubscribe_link.
+// Unknown fields are ignored. The branding is validated, see BrandingFromEnv.
+func BrandingFromJSON(r io.Reader) (Branding, error) {
+	var b Branding
+	d := json.NewDecoder(r)
+	if err := d.Decode(&b); err != nil {
+		return Branding{}, fmt.Errorf("load brand: %w", err)
+	}
+	if d.More() {
+		return Branding{}, errors.New("load brand: data after the JSON document")
+	}
+	if err := validateBrandConfig(b, func(field string) string { return field }); err != nil {
+		return Branding{}, err
+	}
+	return b, nil
+}
+
+// BrandingFromYAML returns the branding defined by the YAML document of the reader, with the fields of BrandingFromJSON
+func BrandingFromYAML(r io.Reader) (Branding, error) {
+	var b Branding
+	if err := yaml.NewDecoder(r).Decode(&b); errors.Is(err, io.EOF) {
+		return Branding{}, errors.New("load brand: empty YAML document")
+	} else if err != nil {
+		return Branding{}, fmt.Errorf("load brand: %w", err)
+	}
+	if err := validateBrandConfig(b, func(field string) string { return field }); err != nil {
+		return Branding{}, err
+	}
+	return b, nil
+}
+
+// BrandingFromEnv returns the branding defined by the environment variables of the prefix, e.g. HERMES_BRAND_NAME and
+// HERMES_BRAND_LOGO for "HERMES_BRAND". The variables are the fields of BrandingFromJSON in upper case, those of lists
+// and of Unsubscribe holding JSON, e.g. HERMES_BRAND_SOCIAL_LINKS='[{"name": "GitHub", "url": "https://github.com/x"}]'.
+//
+// The branding is validated: the name must be set, and links and images must be absolute https URLs, but the logo
+// which can also be a data: image or a cid: attachment. The errors are Issues, with the variable or the key at fault as
+// path and a ValidationError as underlying error.
+func BrandingFromEnv(prefix string) (Branding, error) {
+	prefix = strings.TrimSuffix(prefix, "_") + "_"
+	key := func(field string) string { return prefix + strings.ToUpper(field) }
+
+	var b Branding
+	for field, value := range map[string]*string{
+		"name":             &b.Name,
+		"link":             &b.Link,
+		"logo":             &b.Logo,
+		"copyright":        &b.Copyright,
+		"trouble_text":     &b.TroubleText,
+		"address":          &b.Address,
+		"unsubscribe_link": &b.UnsubscribeLink,
+	} {
+		*value = os.Getenv(key(field))
+	}
+	for field, value := range map[string]interface{}{
+		"web_fonts":    &b.WebFonts,
+		"social_links": &b.SocialLinks,
+		"unsubscribe":  &b.Unsubscribe,
+		"logo_image":   &b.LogoImage,
+	} {
+		if env := os.Getenv(key(field)); env != "" {
+			if err := json.Unmarshal([]byte(env), value); err != nil {
+				return Branding{}, fmt.Errorf("load brand: %s: %w", key(field), err)
+			}
+		}
+	}
+	if err := validateBrandConfig(b, key); err != nil {
+		return Branding{}, err
+	}
+	return b, nil
+}
+
+// validateBrandConfig returns the Issues of the branding loaded from a configuration, or nil. The key returns the name
+// of the key or of the variable of the field, e.g. "social_links".
+func validateBrandConfig(b Branding, key func(field string) string) error {
+	var issues Issues
+	add := func(code ValidationCode, path, value, message string) {
+		issues = append(issues, ValidationError{Code: code, Path: path, Value: value, Message: message}.issue(SeverityError))
+	}
+	checkHTTPS := func(path, value string) {
+		if u, err := url.Parse(value); value != "" && (err != nil || u.Scheme != "https" || u.Host == "") {
+			add(CodeBrandURLNotHTTPS, path, value, "must be an absolute https URL")
+		}
+	}
+
+	if strings.TrimSpace(b.Name) == "" {
+		add(CodeBrandNameMissing, key("name"), "", "empty name")
+	}
+	checkHTTPS(key("link"), b.Link)
+	checkLogo := func(path, value string) {
+		if value != "" && (!validLogo(value) || strings.HasPrefix(strings.ToLower(value), "http:")) {
+			add(CodeBrandURLNotHTTPS, path, value, "must be an absolute https URL, a data: image or a cid: attachment")
+		}
+	}
+	checkLogo(key("logo"), b.Logo)
+	checkLogo(key("logo_image")+".url", b.LogoImage.URL)
+	checkLogo(key("logo_image")+".dark_url", b.LogoImage.DarkURL)
+	checkHTTPS(key("unsubscribe_link"), b.UnsubscribeLink)
+	for i, font := range b.WebFonts {
+		checkHTTPS(fmt.Sprintf("%s[%d].url", key("web_fonts"), i), font.URL)
+	}
+	for i, link := range b.SocialLinks {
+		checkHTTPS(fmt.Sprintf("%s[%d].url", key("social_links"), i), link.URL)
+		checkHTTPS(fmt.Sprintf("%s[%d].icon_url", key("social_links"), i), link.IconURL)
+	}
+	if u := b.Unsubscribe; u != nil {
+		checkHTTPS(key("unsubscribe")+".url", u.URL)
+		checkHTTPS(key("unsubscribe")+".one_click_url", u.OneClickURL)
+	}
+
+	if len(issues) > 0 {
+		return issues
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package hermes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CalendarEvent is an event invitation, e.g. of a maintenance window, that recipients can add to their calendars.
+// It is sent as an .ics attachment by send.BuildMessage, and the themes can link to Google Calendar and Outlook.
+type CalendarEvent struct {
+	Summary       string    `json:"summary,omitempty" yaml:"summary,omitempty"` // e.g. Scheduled maintenance
+	Description   string    `json:"description,omitempty" yaml:"description,omitempty"`
+	Start         time.Time `json:"start,omitempty" yaml:"start,omitempty"`
+	End           time.Time `json:"end,omitempty" yaml:"end,omitempty"` // Optional, the event has no duration when zero
+	Location      string    `json:"location,omitempty" yaml:"location,omitempty"`
+	URL           string    `json:"url,omitempty" yaml:"url,omitempty"`
+	Organizer     string    `json:"organizer,omitempty" yaml:"organizer,omitempty"` // Email address of the organizer, e.g. status@example.com
+	OrganizerName string    `json:"organizer_name,omitempty" yaml:"organizer_name,omitempty"`
+	// UID identifies the event across updates, derived from the summary, start and organizer when empty.
+	// Send an update with the same UID and a greater Sequence to move the event.
+	UID      string    `json:"uid,omitempty" yaml:"uid,omitempty"`
+	Sequence int       `json:"sequence,omitempty" yaml:"sequence,omitempty"`
+	Stamp    time.Time `json:"stamp,omitempty" yaml:"stamp,omitempty"` // Creation time of the invitation (default to now)
+	// QuickAddLinks renders "Add to calendar" links to Google Calendar and Outlook after the schedule
+	QuickAddLinks bool `json:"quick_add_links,omitempty" yaml:"quick_add_links,omitempty"`
+}
+
+// icsTime is the UTC date-time format of iCalendar, which calendar clients display in the time zone of the reader
+const icsTime = "20060102T150405Z"
+
+// uid returns the UID of the event
+func (e CalendarEvent) uid() string {
+	if e.UID != "" {
+		return e.UID
+	}
+	sum := sha256.Sum256([]byte(e.Summary + "\n" + e.Start.UTC().Format(icsTime) + "\n" + e.Organizer))
+	return hex.EncodeToString(sum[:16]) + "@hermes"
+}
+
+// String returns the event as an iCalendar request (RFC 5545 and RFC 5546): times in UTC, CRLF line endings,
+// lines folded at 75 octets, and backslashes, commas, semicolons and newlines of the text values escaped
+func (e CalendarEvent) String() string {
+	var b strings.Builder
+	line := func(name, value string) {
+		if value != "" {
+			writeFolded(&b, name+":"+value)
+		}
+	}
+	stamp := e.Stamp
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//Hermes//Hermes//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "REQUEST")
+	line("BEGIN", "VEVENT")
+	line("UID", e.uid())
+	line("SEQUENCE", strconv.Itoa(e.Sequence))
+	line("DTSTAMP", stamp.UTC().Format(icsTime))
+	line("DTSTART", e.Start.UTC().Format(icsTime))
+	if !e.End.IsZero() {
+		line("DTEND", e.End.UTC().Format(icsTime))
+	}
+	line("SUMMARY", vcardEscape(e.Summary))
+	line("DESCRIPTION", vcardEscape(e.Description))
+	line("LOCATION", vcardEscape(e.Location))
+	line("URL", e.URL)
+	if e.Organizer != "" {
+		organizer := "ORGANIZER"
+		if e.OrganizerName != "" {
+			// Parameter values are quoted, and cannot hold quotes
+			organizer += `;CN="` + strings.ReplaceAll(e.OrganizerName, `"`, "") + `"`
+		}
+		line(organizer, "mailto:"+e.Organizer)
+	}
+	line("END", "VEVENT")
+	line("END", "VCALENDAR")
+	return b.String()
+}
+
+// Attachment returns the event as an invite.ics attachment, of the text/calendar type with the REQUEST method
+func (e CalendarEvent) Attachment() Attachment {
+	return Attachment{
+		Filename:    "invite.ics",
+		ContentType: "text/calendar; charset=utf-8; method=REQUEST",
+		Data:        []byte(e.String()),
+	}
+}
+
+// GoogleCalendarURL returns the link adding the event to Google Calendar
+func (e CalendarEvent) GoogleCalendarURL() string {
+	end := e.End
+	if end.IsZero() {
+		end = e.Start
+	}
+	q := url.Values{}
+	q.Set("action", "TEMPLATE")
+	q.Set("text", e.Summary)
+	q.Set("dates", e.Start.UTC().Format(icsTime)+"/"+end.UTC().Format(icsTime))
+	setNotEmpty(q, "details", e.Description)
+	setNotEmpty(q, "location", e.Location)
+	return "https://calendar.google.com/calendar/render?" + q.Encode()
+}
+
+// OutlookURL returns the link adding the event to Outlook on the web
+func (e CalendarEvent) OutlookURL() string {
+	end := e.End
+	if end.IsZero() {
+		end = e.Start
+	}
+	q := url.Values{}
+	q.Set("path", "/calendar/action/compose")
+	q.Set("rru", "addevent")
+	q.Set("subject", e.Summary)
+	q.Set("startdt", e.Start.UTC().Format(time.RFC3339))
+	q.Set("enddt", end.UTC().Format(time.RFC3339))
+	setNotEmpty(q, "body", e.Description)
+	setNotEmpty(q, "location", e.Location)
+	return "https://outlook.live.com/calendar/0/deeplink/compose?" + q.Encode()
+}
+
+func setNotEmpty(q url.Values, key, value string) {
+	if value != "" {
+		q.Set(key, value)
+	}
+}
@@ -0,0 +1,314 @@
+package hermes
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Email clients known by the CSS support dataset
+const (
+	ClientGmail      = "gmail"
+	ClientOutlook    = "outlook" // Outlook desktop for Windows (Word rendering engine)
+	ClientOutlookCom = "outlook.com"
+	ClientAppleMail  = "apple-mail"
+	ClientYahoo      = "yahoo"
+)
+
+// Clients lists every client known by the CSS support dataset
+var Clients = []string{ClientGmail, ClientOutlook, ClientOutlookCom, ClientAppleMail, ClientYahoo}
+
+// CSSSupportRule describes a CSS property (or at-rule, like "@media") that some clients do not handle
+type CSSSupportRule struct {
+	Property string   // CSS property name, e.g. "background-image", or at-rule, e.g. "@font-face"
+	Value    string   // Optional value prefix the rule is restricted to, e.g. "flex" for "display"
+	Elements []string // Optional elements the rule is restricted to, e.g. "a" for "padding" (inline styles only)
+	Ignored  []string // Clients silently ignoring the declaration
+	Broken   []string // Clients rendering the declaration incorrectly
+	Note     string   // Advice given along with the issue
+}
+
+// CSSSupportData is the dataset used by CheckClientSupport, based on https://www.caniemail.com.
+// Append your own rules to it at init time to extend it.
+var CSSSupportData = []CSSSupportRule{
+	{Property: "display", Value: "flex", Ignored: []string{ClientOutlook, ClientOutlookCom, ClientYahoo}, Note: "use tables for layout"},
+	{Property: "display", Value: "grid", Ignored: []string{ClientGmail, ClientOutlook, ClientOutlookCom, ClientYahoo}, Note: "use tables for layout"},
+	{Property: "position", Ignored: []string{ClientGmail, ClientOutlook, ClientOutlookCom, ClientYahoo}},
+	{Property: "float", Broken: []string{ClientOutlook}, Note: "use the align attribute instead"},
+	{Property: "background-image", Ignored: []string{ClientOutlook}, Note: "add a VML fallback for Outlook"},
+	{Property: "border-radius", Ignored: []string{ClientOutlook}, Note: "corners will be square in Outlook"},
+	{Property: "box-shadow", Ignored: []string{ClientOutlook, ClientYahoo}},
+	{Property: "text-shadow", Ignored: []string{ClientOutlook}},
+	{Property: "max-width", Ignored: []string{ClientOutlook}, Note: "set a width attribute as well"},
+	{Property: "min-width", Ignored: []string{ClientOutlook}},
+	{Property: "opacity", Ignored: []string{ClientOutlook}},
+	{Property: "transform", Ignored: []string{ClientGmail, ClientOutlook, ClientOutlookCom, ClientYahoo}},
+	{Property: "transition", Ignored: []string{ClientGmail, ClientOutlook, ClientOutlookCom, ClientYahoo}},
+	{Property: "animation", Ignored: []string{ClientGmail, ClientOutlook, ClientOutlookCom, ClientYahoo}},
+	{Property: "padding", Elements: []string{"a", "p", "div"}, Broken: []string{ClientOutlook}, Note: "put the padding on a table cell"},
+	{Property: "@font-face", Ignored: []string{ClientGmail, ClientOutlook, ClientOutlookCom, ClientYahoo}, Note: "always provide a fallback font"},
+	{Property: "@media", Ignored: []string{ClientOutlook}, Note: "the desktop layout must work without media queries"},
+	{Property: "@import", Ignored: []string{ClientGmail, ClientOutlook, ClientOutlookCom, ClientYahoo}},
+}
+
+// SupportIssue is a CSS declaration of the final HTML that a targeted client does not handle
+type SupportIssue struct {
+	Client   string
+	Property string
+	Value    string
+	Path     string // Element path of the inline style, or selector of the style block
+	Severity Severity
+	Message  string
+}
+
+// CheckClientSupport scans the inline styles and style blocks of a generated HTML email
+// and reports the declarations that the given clients ignore or break on.
+// All known clients are checked when clients is empty, unknown ones are ignored.
+func CheckClientSupport(htmlEmail string, clients []string) []SupportIssue {
+	if len(clients) == 0 {
+		clients = Clients
+	}
+	doc, err := html.Parse(strings.NewReader(htmlEmail))
+	if err != nil {
+		// The parser is error tolerant, this does not happen with a strings.Reader
+		return nil
+	}
+
+	var issues []SupportIssue
+	seen := map[SupportIssue]bool{}
+	report := func(element, path string, decl cssDeclaration) {
+		for _, rule := range CSSSupportData {
+			if !rule.matches(element, decl) {
+				continue
+			}
+			for _, client := range clients {
+				severity, ok := rule.severityFor(client)
+				if !ok {
+					continue
+				}
+				issue := SupportIssue{
+					Client:   client,
+					Property: decl.property,
+					Value:    decl.value,
+					Path:     path,
+					Severity: severity,
+					Message:  rule.message(client),
+				}
+				if !seen[issue] {
+					seen[issue] = true
+					issues = append(issues, issue)
+				}
+			}
+		}
+	}
+
+	var walk func(n *html.Node, path string)
+	walk = func(n *html.Node, path string) {
+		if n.Type == html.ElementNode {
+			path = strings.TrimPrefix(path+" > "+nodeName(n), " > ")
+			if style, ok := attr(n, "style"); ok {
+				for _, decl := range parseDeclarations(style) {
+					report(n.Data, path, decl)
+				}
+			}
+			if n.Data == "style" {
+				for _, block := range parseStyleSheet(textContent(n)) {
+					for _, decl := range block.declarations {
+						report("", "style: "+block.selector, decl)
+					}
+				}
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, path)
+		}
+	}
+	walk(doc, "")
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Severity > issues[j].Severity
+	})
+	return issues
+}
+
+func (r CSSSupportRule) matches(element string, decl cssDeclaration) bool {
+	if r.Property != decl.property || !strings.HasPrefix(decl.value, r.Value) {
+		return false
+	}
+	if len(r.Elements) == 0 {
+		return true
+	}
+	for _, e := range r.Elements {
+		if e == element {
+			return true
+		}
+	}
+	return false
+}
+
+func (r CSSSupportRule) severityFor(client string) (Severity, bool) {
+	for _, c := range r.Broken {
+		if c == client {
+			return SeverityError, true
+		}
+	}
+	for _, c := range r.Ignored {
+		if c == client {
+			return SeverityWarning, true
+		}
+	}
+	return SeverityInfo, false
+}
+
+func (r CSSSupportRule) message(client string) string {
+	what := r.Property
+	if r.Value != "" {
+		what += ": " + r.Value
+	}
+	verb := "ignores"
+	if severity, _ := r.severityFor(client); severity == SeverityError {
+		verb = "does not render correctly"
+	}
+	msg := fmt.Sprintf("%s %s %q", client, verb, what)
+	if r.Note != "" {
+		msg += ", " + r.Note
+	}
+	return msg
+}
+
+type cssDeclaration struct {
+	property string
+	value    string
+}
+
+type cssBlock struct {
+	selector     string
+	declarations []cssDeclaration
+}
+
+var cssComments = regexp.MustCompile(`(?s)/\*.*?\*/`)
+
+// parseDeclarations parses the content of a style attribute or of a CSS block
+func parseDeclarations(s string) []cssDeclaration {
+	var decls []cssDeclaration
+	for _, d := range strings.Split(s, ";") {
+		i := strings.IndexByte(d, ':')
+		if i < 0 {
+			continue
+		}
+		property := strings.ToLower(strings.TrimSpace(d[:i]))
+		value := strings.ToLower(strings.TrimSpace(d[i+1:]))
+		value = strings.TrimSpace(strings.TrimSuffix(value, "!important"))
+		if property != "" {
+			decls = append(decls, cssDeclaration{property: property, value: value})
+		}
+	}
+	return decls
+}
+
+// parseStyleSheet parses the content of a style element into blocks.
+// At-rules are reported as a declaration whose property is the at-rule name.
+func parseStyleSheet(css string) []cssBlock {
+	css = cssComments.ReplaceAllString(css, "")
+	var blocks []cssBlock
+	for len(css) > 0 {
+		open := strings.IndexByte(css, '{')
+		if open < 0 {
+			// Statements like @import have no block
+			for _, statement := range strings.Split(css, ";") {
+				statement = strings.TrimSpace(statement)
+				if strings.HasPrefix(statement, "@") {
+					blocks = append(blocks, atRuleBlock(statement))
+				}
+			}
+			break
+		}
+		prelude := strings.TrimSpace(css[:open])
+		end := matchingBrace(css, open)
+		body := css[open+1 : end]
+		if end < len(css) {
+			end++
+		}
+		css = css[end:]
+
+		// Statements ending with ';' before the block, like @import
+		if i := strings.LastIndexByte(prelude, ';'); i >= 0 {
+			blocks = append(blocks, parseStyleSheet(prelude[:i+1])...)
+			prelude = strings.TrimSpace(prelude[i+1:])
+		}
+
+		if strings.HasPrefix(prelude, "@") {
+			at := atRuleBlock(prelude)
+			blocks = append(blocks, at)
+			if strings.HasPrefix(prelude, "@media") || strings.HasPrefix(prelude, "@supports") {
+				for _, inner := range parseStyleSheet(body) {
+					inner.selector = prelude + " " + inner.selector
+					blocks = append(blocks, inner)
+				}
+			} else {
+				blocks = append(blocks, cssBlock{selector: prelude, declarations: parseDeclarations(body)})
+			}
+			continue
+		}
+		blocks = append(blocks, cssBlock{selector: strings.Join(strings.Fields(prelude), " "), declarations: parseDeclarations(body)})
+	}
+	return blocks
+}
+
+func atRuleBlock(prelude string) cssBlock {
+	name := strings.ToLower(strings.Fields(prelude)[0])
+	return cssBlock{selector: prelude, declarations: []cssDeclaration{{property: name}}}
+}
+
+// matchingBrace returns the index of the brace closing the one at index open, or len(s)
+func matchingBrace(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(s)
+}
+
+func nodeName(n *html.Node) string {
+	name := n.Data
+	if class, ok := attr(n, "class"); ok && strings.TrimSpace(class) != "" {
+		name += "." + strings.Join(strings.Fields(class), ".")
+	}
+	return name
+}
+
+func attr(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return b.String()
+}
@@ -0,0 +1,64 @@
+package hermes
+
+import "fmt"
+
+// Compat levels of Hermes.CompatLevel
+const (
+	CompatLatest = 0 // Latest default strings and theme markup, changing with upgrades
+	CompatLevel1 = 1 // Default strings and theme markup of the first release
+)
+
+// VersionedTheme is a Theme keeping the templates of its past revisions, selected by Hermes.CompatLevel
+type VersionedTheme interface {
+	Theme
+	HTMLTemplateAt(level int) string      // The HTML template at the compat level, 0 being the latest
+	PlainTextTemplateAt(level int) string // The plain text template at the compat level, 0 being the latest
+}
+
+// compatDefaults are the default values pinned by a compat level
+type compatDefaults struct {
+	Brand     Branding
+	Greeting  string
+	Signature string
+}
+
+// compatLevels are the default values of each compat level, only strings changed since a level need a new one.
+// No string has changed since the first release yet: those of CompatLevel1 are its own, and must stay so when the ones of
+// CompatLatest change.
+var compatLevels = map[int]compatDefaults{
+	CompatLatest: {
+		Brand: Branding{
+			Name:        "Hermes",
+			Copyright:   "Copyright © 2024 Hermes. All rights reserved.",
+			TroubleText: "If you’re having trouble with the button '{ACTION}', copy and paste the URL below into your web browser.",
+		},
+		Greeting:  "Hi",
+		Signature: "Yours truly",
+	},
+	CompatLevel1: {
+		Brand: Branding{
+			Name:        "Hermes",
+			Copyright:   "Copyright © 2024 Hermes. All rights reserved.",
+			TroubleText: "If you’re having trouble with the button '{ACTION}', copy and paste the URL below into your web browser.",
+		},
+		Greeting:  "Hi",
+		Signature: "Yours truly",
+	},
+}
+
+// defaultsAt returns the default values pinned by the compat level
+func defaultsAt(level int) (compatDefaults, error) {
+	defaults, ok := compatLevels[level]
+	if !ok {
+		return compatDefaults{}, fmt.Errorf("unknown compat level %d", level)
+	}
+	return defaults, nil
+}
+
+// htmlTemplate returns the HTML template of the theme at the compat level of the engine
+func (h *Hermes) htmlTemplate() string {
+	if t, ok := h.Theme.(VersionedTheme); ok {
+		return t.HTMLTemplateAt(h.CompatLevel)
+	}
+	return h.Theme.HTMLTemplate()
+}
@@ -0,0 +1,233 @@
+package hermes
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/vanng822/go-premailer/premailer"
+)
+
+// Sources of the CSS of HTML emails
+const (
+	CSSSourceTheme  = "theme"  // Styles of the theme
+	CSSSourceCustom = "custom" // Hermes.CustomCSS
+	CSSSourceBody   = "body"   // Styles in the body of the email, e.g. from free markdown
+)
+
+// ErrCSSInline is returned by the Inline stage when inlining the CSS of the HTML version fails, e.g. on malformed CustomCSS.
+// The CSS sources of the email are removed in turn to find the one causing the failure, then its rules are bisected.
+type ErrCSSInline struct {
+	Source string // CSSSourceTheme, CSSSourceCustom or CSSSourceBody, "" when the culprit was not found
+	Rule   string // Offending rule of the source, "" when it was not found
+	Err    error  // Error of the CSS inliner
+}
+
+func (e ErrCSSInline) Error() string {
+	msg := "inline CSS"
+	if e.Source != "" {
+		msg += " of " + e.Source + " styles"
+	}
+	if e.Rule != "" {
+		msg += fmt.Sprintf(" at rule %q", e.Rule)
+	}
+	return msg + ": " + e.Err.Error()
+}
+
+// Unwrap returns the error of the CSS inliner
+func (e ErrCSSInline) Unwrap() error {
+	return e.Err
+}
+
+// CSSInliningOptions configure the CSS inliner. The zero value is the configuration by default.
+//
+// KeepBangImportant is safe. RemoveClasses breaks the styles kept in style elements, like media queries and dark mode,
+// and Parse. SkipCSSToAttributes drops the bgcolor, width and align attributes relied upon by Outlook for Windows.
+type CSSInliningOptions struct {
+	RemoveClasses       bool // Removes the class attributes once their styles are inlined
+	KeepBangImportant   bool // Keeps "!important" in the inlined style attributes
+	SkipCSSToAttributes bool // Does not copy CSS properties into HTML attributes, e.g. background-color to bgcolor
+}
+
+// premailerOptions returns the options of premailer
+func (o CSSInliningOptions) premailerOptions() *premailer.Options {
+	options := premailer.NewOptions()
+	options.RemoveClasses = o.RemoveClasses
+	options.KeepBangImportant = o.KeepBangImportant
+	options.CssToAttributes = !o.SkipCSSToAttributes
+	return options
+}
+
+// maxCSSSourceRetries bounds the retries finding the CSS source causing an inlining failure
+const maxCSSSourceRetries = 3
+
+// transformCSS inlines the CSS of the HTML. Premailer panics on some malformed CSS, the panics are returned as errors.
+func transformCSS(html string, opts CSSInliningOptions) (inlined string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("premailer: %v", r)
+		}
+	}()
+	// Media queries are kept in a style element whatever the options. Style elements with data-premailer="ignore",
+	// like the dark mode of the Default theme, are left untouched: Outlook.com selectors would not survive inlining.
+	prem, err := premailer.NewPremailerFromString(mergeStyles(html), opts.premailerOptions())
+	if err != nil {
+		return "", err
+	}
+	return prem.Transform()
+}
+
+var (
+	premailerIgnore = regexp.MustCompile(`(?i)\bdata-premailer\s*=\s*["']?ignore\b`)
+	styleMedia      = regexp.MustCompile(`(?i)\bmedia\s*=\s*["']?([^"'\s>]*)`)
+)
+
+// mergeStyles returns the HTML of which the style elements inlined by premailer, those which are not ignored and are
+// for all media, are merged in order into the first one. Premailer parses its style elements concurrently, and races
+// when there are several of them, e.g. the styles of the theme and CustomCSS.
+// Style elements in comments, like the conditional comments of Outlook, are left as they are.
+func mergeStyles(html string) string {
+	var inlined [][]int
+	for _, m := range styleElement.FindAllStringSubmatchIndex(html, -1) {
+		attrs := html[m[2]:m[3]]
+		if premailerIgnore.MatchString(attrs) || strings.LastIndex(html[:m[0]], "<!--") > strings.LastIndex(html[:m[0]], "-->") {
+			continue
+		}
+		if media := styleMedia.FindStringSubmatch(attrs); media != nil && media[1] != "all" {
+			continue
+		}
+		inlined = append(inlined, m)
+	}
+	if len(inlined) < 2 {
+		return html
+	}
+	var b strings.Builder
+	b.WriteString(html[:inlined[0][5]])
+	for _, m := range inlined[1:] {
+		b.WriteString("\n")
+		b.WriteString(html[m[4]:m[5]])
+	}
+	last := inlined[0][5]
+	for _, m := range inlined[1:] {
+		b.WriteString(html[last:m[0]])
+		last = m[1]
+	}
+	b.WriteString(html[last:])
+	return b.String()
+}
+
+// cssSource is a style element of the HTML, its content is html[start:end]
+type cssSource struct {
+	kind       string
+	start, end int
+}
+
+var styleElement = regexp.MustCompile(`(?is)<style\b([^>]*)>(.*?)</style>`)
+
+// cssSources returns the style elements of the HTML, the likeliest culprits first: custom CSS, then the body, then the theme
+func cssSources(html string) []cssSource {
+	head := strings.Index(strings.ToLower(html), "</head>")
+	var sources []cssSource
+	for _, m := range styleElement.FindAllStringSubmatchIndex(html, -1) {
+		kind := CSSSourceBody
+		switch {
+		case strings.Contains(html[m[2]:m[3]], `data-hermes-css="custom"`):
+			kind = CSSSourceCustom
+		case m[0] < head:
+			kind = CSSSourceTheme
+		}
+		sources = append(sources, cssSource{kind: kind, start: m[4], end: m[5]})
+	}
+	rank := map[string]int{CSSSourceCustom: 0, CSSSourceBody: 1, CSSSourceTheme: 2}
+	sort.SliceStable(sources, func(i, j int) bool {
+		return rank[sources[i].kind] < rank[sources[j].kind]
+	})
+	return sources
+}
+
+// withCSS returns the HTML where the content of the source is replaced by the CSS, and the other sources are emptied
+func withCSS(html string, sources []cssSource, source cssSource, css string) string {
+	var b strings.Builder
+	last := 0
+	sorted := append([]cssSource(nil), sources...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].start < sorted[j].start })
+	for _, s := range sorted {
+		b.WriteString(html[last:s.start])
+		if s == source {
+			b.WriteString(css)
+		}
+		last = s.end
+	}
+	b.WriteString(html[last:])
+	return b.String()
+}
+
+// diagnoseCSS finds the CSS source, and the rule, causing the inlining error.
+// The bisection is skipped when the HTML has a single source.
+func diagnoseCSS(html string, opts CSSInliningOptions, err error) error {
+	e := ErrCSSInline{Err: err}
+	sources := cssSources(html)
+	var culprit *cssSource
+	if len(sources) == 1 {
+		culprit = &sources[0]
+	}
+	for i := 0; len(sources) > 1 && i < len(sources) && i < maxCSSSourceRetries; i++ {
+		// The other sources are kept, the culprit is the source whose removal fixes the inlining
+		if _, err := transformCSS(html[:sources[i].start]+html[sources[i].end:], opts); err == nil {
+			culprit = &sources[i]
+			break
+		}
+	}
+	if culprit == nil {
+		return e
+	}
+	e.Source = culprit.kind
+	e.Rule = offendingRule(html, sources, *culprit, opts)
+	return e
+}
+
+// offendingRule bisects the rules of the source, alone in the HTML, and returns the rule failing the inlining, or ""
+func offendingRule(html string, sources []cssSource, source cssSource, opts CSSInliningOptions) string {
+	fails := func(rules []string) bool {
+		_, err := transformCSS(withCSS(html, sources, source, strings.Join(rules, "\n")), opts)
+		return err != nil
+	}
+	rules := cssRules(html[source.start:source.end])
+	for len(rules) > 1 {
+		if half := rules[:len(rules)/2]; fails(half) {
+			rules = half
+		} else {
+			rules = rules[len(rules)/2:]
+		}
+	}
+	if len(rules) == 1 && fails(rules) {
+		return rules[0]
+	}
+	return ""
+}
+
+// cssRules splits the CSS into its top-level rules, e.g. a whole @media block.
+// Braces in comments and strings are not told apart.
+func cssRules(css string) []string {
+	var rules []string
+	depth, start := 0, 0
+	for i, c := range css {
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			if depth > 0 {
+				depth--
+			}
+			if depth == 0 {
+				rules = append(rules, strings.TrimSpace(css[start:i+1]))
+				start = i + 1
+			}
+		}
+	}
+	if rest := strings.TrimSpace(css[start:]); rest != "" {
+		rules = append(rules, rest)
+	}
+	return rules
+}
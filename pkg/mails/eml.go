@@ -0,0 +1,109 @@
+package hermes
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// EMLOptions are the headers of the message generated by GenerateEML
+type EMLOptions struct {
+	From    string    // e.g. Hermes <hello@hermes-example.com>
+	To      []string  // Recipients, may be empty for archives
+	Subject string    // Default to the subject generated by GenerateSubject
+	Date    time.Time // Default to the clock of the engine
+	// Rand is the source of the MIME boundary (default to crypto/rand), e.g. a seeded source writing the same bytes on
+	// each run, for byte-exact tests
+	Rand io.Reader
+}
+
+// GenerateEML generates the email as a RFC 5322 message (a .eml file), e.g. to archive it or to preview it in a mail client
+// without sending it. It holds the plain text and HTML versions as multipart/alternative, encoded as quoted-printable,
+// so that no line exceeds the 998 characters allowed by SMTP.
+// Attachments are left out, send.BuildMessage adds them to messages.
+func (h *Hermes) GenerateEML(email Email, opts EMLOptions) ([]byte, error) {
+	from, err := mail.ParseAddress(opts.From)
+	if err != nil {
+		return nil, fmt.Errorf("eml: From: %w", err)
+	}
+	var to []string
+	for _, addr := range opts.To {
+		a, err := mail.ParseAddress(addr)
+		if err != nil {
+			return nil, fmt.Errorf("eml: To: %w", err)
+		}
+		to = append(to, a.String())
+	}
+	date := opts.Date
+	if date.IsZero() {
+		date = h.now()
+	}
+	subject := opts.Subject
+	if subject == "" {
+		if subject, err = h.GenerateSubject(email); err != nil {
+			return nil, err
+		}
+	}
+
+	html, plain, err := h.Generate(email)
+	if err != nil {
+		return nil, err
+	}
+
+	random := opts.Rand
+	if random == nil {
+		random = rand.Reader
+	}
+	var boundary [30]byte
+	if _, err := io.ReadFull(random, boundary[:]); err != nil {
+		return nil, fmt.Errorf("eml: read MIME boundary: %w", err)
+	}
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	// The boundary is made of hexadecimal digits, which SetBoundary accepts
+	_ = w.SetBoundary(fmt.Sprintf("%x", boundary[:]))
+	for _, part := range []struct{ mediaType, content string }{{"text/plain", plain}, {"text/html", html}} {
+		pw, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.mediaType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(pw)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	header := func(key, value string) {
+		b.WriteString(key + ": " + value + "\r\n")
+	}
+	header("From", from.String())
+	if len(to) > 0 {
+		header("To", strings.Join(to, ",\r\n "))
+	}
+	// Encoded words are folded on their own lines, to keep long subjects under the line length limit
+	header("Subject", strings.ReplaceAll(mime.QEncoding.Encode("utf-8", subject), "?= =?", "?=\r\n =?"))
+	header("Date", date.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": w.Boundary()}))
+	b.WriteString("\r\n")
+	b.Write(body.Bytes())
+	return b.Bytes(), nil
+}
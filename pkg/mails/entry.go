@@ -0,0 +1,66 @@
+package hermes
+
+import (
+	"html/template"
+	"strings"
+)
+
+// markdownEntries returns the email with the values of the dictionary entries in markdown converted to HTMLValue,
+// following the MarkdownOptions of the engine with their line breaks kept. The HTML is then sanitized like the other
+// HTML values. Entries are copied, the given email is left untouched.
+func markdownEntries(email Email, h *Hermes) Email {
+	var copied []Entry
+	for i, entry := range email.Body.Dictionary {
+		if !entry.Markdown || entry.HTMLValue != "" {
+			continue
+		}
+		if copied == nil {
+			copied = append([]Entry(nil), email.Body.Dictionary...)
+		}
+		copied[i].HTMLValue = h.entryMarkdown(entry.Value)
+	}
+	if copied != nil {
+		email.Body.Dictionary = copied
+	}
+	return email
+}
+
+// entryMarkdown converts the value of an entry from markdown, a single paragraph being written inline
+func (h *Hermes) entryMarkdown(value string) template.HTML {
+	md := *h
+	if md.MarkdownOptions.Extensions == 0 {
+		md.MarkdownOptions.Extensions = MarkdownCommonExtensions
+	}
+	md.MarkdownOptions.Extensions |= MarkdownHardLineBreaks
+	out := strings.TrimSpace(string(md.MarkdownToHTML(Markdown(value))))
+	if inner, ok := strings.CutPrefix(out, "<p>"); ok && strings.HasSuffix(inner, "</p>") && !strings.Contains(inner, "<p>") {
+		out = strings.TrimSpace(strings.TrimSuffix(inner, "</p>"))
+	}
+	return template.HTML(out)
+}
+
+// entryHTML returns the value of the dictionary entry as HTML: its HTML value, or else its value escaped and isolated,
+// written in a monospace font when Monospace is set, and linked to its URL when set
+func entryHTML(entry Entry, textDirection TextDirection) template.HTML {
+	value := entry.HTMLValue
+	if value == "" {
+		value = isolate(entry.Value, entry.Bidi, textDirection)
+	}
+	if entry.Monospace {
+		value = `<code style="font-family: Consolas, monaco, monospace;">` + value + `</code>`
+	}
+	if entry.URL != "" {
+		value = `<a href="` + template.HTML(template.HTMLEscapeString(string(trustedURL(entry.URL)))) + `">` + value + `</a>`
+	}
+	return value
+}
+
+// entryText returns the value of the dictionary entry in plain text, on a single line, followed by its URL in
+// parentheses when set
+func entryText(entry Entry, textDirection TextDirection) (string, error) {
+	text, err := textCell(entry, textDirection)
+	if entry.URL != "" {
+		text += " (" + textCellEscaper.Replace(entry.URL) + ")"
+	}
+	return text, err
+}
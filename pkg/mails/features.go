@@ -0,0 +1,116 @@
+package hermes
+
+import "time"
+
+// FeatureExamples returns a minimal email per feature of Body, keyed by the JSON name of its field, e.g. "dictionary"
+// or "actions_layout", each one setting only that field, or the fields it needs, like the actions of ActionsLayout.
+// They document the features rendered by the real engine, e.g. on the preview server or as golden files, so that a
+// change to a single feature shows in isolation.
+// The blocks of "segmented_blocks" are for DefaultSegment, and only rendered by RenderForSegment.
+func FeatureExamples() map[string]Email {
+	start := time.Date(2024, time.March, 2, 23, 30, 0, 0, time.UTC)
+	previous := 112.0
+	return map[string]Email{
+		"name": {Body: Body{
+			Name: "Jon Snow",
+		}},
+		"intros": {Body: Body{
+			Intros: []string{"Welcome to Hermes! We're very excited to have you on board."},
+		}},
+		"dictionary": {Body: Body{
+			Dictionary: []Entry{
+				{Key: "Firstname", Value: "Jon"},
+				{Key: "Lastname", Value: "Snow"},
+				{Key: "Birthday", Value: "01/01/283"},
+			},
+		}},
+		"table": {Body: Body{
+			Table: Table{
+				Data: [][]Entry{
+					{{Key: "Item", Value: "Golang"}, {Key: "Price", Value: "$10.99"}},
+					{{Key: "Item", Value: "Hermes"}, {Key: "Price", Value: "$1.99"}},
+				},
+				Columns: Columns{CustomAlignment: map[string]string{"Price": "right"}},
+			},
+		}},
+		"tables": {Body: Body{
+			Tables: []Table{
+				{Title: "Items", Data: [][]Entry{{{Key: "Item", Value: "Hermes"}, {Key: "Price", Value: "$1.99"}}}},
+				{Title: "Taxes", Data: [][]Entry{{{Key: "Tax", Value: "VAT"}, {Key: "Amount", Value: "$0.40"}}}},
+			},
+		}},
+		"actions": {Body: Body{
+			Actions: []Action{{
+				Instructions: "To get started with Hermes, please click here:",
+				Button:       Button{Text: "Confirm your account", Link: "https://hermes-example.com/confirm?token=d9729feb74992cc3482b350163a1a010"},
+			}},
+		}},
+		"outros": {Body: Body{
+			Outros: []string{"Need help, or have questions? Just reply to this email, we'd love to help."},
+		}},
+		"greeting": {Body: Body{
+			Greeting: "Dear",
+		}},
+		"signature": {Body: Body{
+			Signature: "Thanks",
+		}},
+		"title": {Body: Body{
+			Title: "Welcome to Hermes",
+		}},
+		"free_markdown": {Body: Body{
+			FreeMarkdown: "Hermes supports **Markdown**:\n\n* lists\n* [links](https://hermes-example.com/)\n\n> and quotes",
+		}},
+		"preheader": {Body: Body{
+			Preheader: "Your account is ready, confirm it to get started.",
+		}},
+		"schedule": {Body: Body{
+			Schedule: []ScheduleEntry{{Label: "Maintenance", Start: start, End: start.Add(90 * time.Minute)}},
+		}},
+		"summary": {Body: Body{
+			Summary: &Summary{Sections: []SummarySection{
+				{Label: "Closed tickets", Value: 128, Previous: &previous, Highlights: []string{"Fastest week of the quarter"}},
+				{Label: "Revenue", Value: 1520.5, Format: FormatMoney},
+			}},
+		}},
+		"contact_instructions": {Body: Body{
+			ContactInstructions: &ContactInstructions{Email: "support@hermes-example.com", URL: "https://hermes-example.com/help"},
+		}},
+		"signature_block": {Body: Body{
+			SignatureBlock: &SignatureBlock{
+				Closing:  "Best regards",
+				Name:     "Jane Doe",
+				Title:    "Head of Support",
+				ImageURL: "https://hermes-example.com/jane.png",
+			},
+		}},
+		"calendar_event": {Body: Body{
+			CalendarEvent: &CalendarEvent{
+				Summary:       "Scheduled maintenance",
+				Start:         start,
+				End:           start.Add(90 * time.Minute),
+				Organizer:     "status@hermes-example.com",
+				Stamp:         start.Add(-24 * time.Hour),
+				QuickAddLinks: true,
+			},
+		}},
+		"segmented_blocks": {Body: Body{
+			SegmentedBlocks: map[string][]Block{
+				DefaultSegment: {{Intros: []string{"Upgrade to Pro to get unlimited projects."}}},
+				"pro":          {{Intros: []string{"Thanks for being a Pro customer!"}}},
+			},
+		}},
+		"table_of_contents": {Body: Body{
+			TableOfContents: true,
+			Dictionary:      []Entry{{Key: "Order", Value: "1234"}},
+			Tables:          []Table{{Title: "Items", Data: [][]Entry{{{Key: "Item", Value: "Hermes"}, {Key: "Price", Value: "$1.99"}}}}},
+		}},
+		"actions_layout": {Body: Body{
+			ActionsLayout: ActionsSteps,
+			Actions: []Action{
+				{Title: "Verify your email", Button: Button{Text: "Verify", Link: "https://hermes-example.com/verify"}},
+				{Title: "Install the app", Instructions: "Get it on your phone.", Button: Button{Text: "Install", Link: "https://hermes-example.com/app"}},
+				{Title: "Invite your team", Button: Button{Text: "Invite", Link: "https://hermes-example.com/team"}},
+			},
+		}},
+	}
+}
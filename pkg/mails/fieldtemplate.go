@@ -0,0 +1,36 @@
+package hermes
+
+import (
+	"fmt"
+	"strings"
+	texttemplate "text/template"
+)
+
+// FieldTemplate is the data of the fields executed as text templates, Branding.Copyright and Body.Greeting: the data
+// of the themes, and the current year in the time zone of the engine, e.g. "© {{ .Year }} {{ .Hermes.Brand.Name }}"
+type FieldTemplate struct {
+	Template
+	Year int
+}
+
+// executeField returns the value of the field executed as a text template against FieldTemplate, or the value itself
+// when it has no actions. Errors name the field, e.g. "Brand.Copyright: template: ...".
+func (h *Hermes) executeField(path, value string, email Email) (string, error) {
+	if !strings.Contains(value, "{{") {
+		return value, nil
+	}
+	funcs, err := h.funcs()
+	if err != nil {
+		return "", err
+	}
+	t, err := texttemplate.New(path).Funcs(texttemplate.FuncMap(funcs)).Parse(value)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	var b strings.Builder
+	data := FieldTemplate{Template: Template{*h, email}, Year: h.now().In(h.timeZone()).Year()}
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	return b.String(), nil
+}
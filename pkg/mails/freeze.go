@@ -0,0 +1,236 @@
+package hermes
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Output holds both renderings of an email
+type Output struct {
+	HTML      string
+	PlainText string
+	Warnings  Issues // Problems worked around while rendering, e.g. images given an alt text by AutoAltText
+	Subject   string // Subject of the email, see GenerateSubject. Empty in the outputs of Frozen.Instantiate.
+}
+
+// Frozen is an email rendered once with unique tokens in place of some of its fields.
+// Instantiate substitutes the real values without running the rendering pipeline again,
+// which makes it cheap to produce one email per recipient of a campaign.
+// A Frozen value is never modified after Freeze and is safe for concurrent use.
+type Frozen struct {
+	// AllowMarkup lets Instantiate accept values containing '<' or '>'.
+	// Values are HTML-escaped in the HTML output either way.
+	AllowMarkup bool
+
+	html   string
+	text   string
+	tokens map[string]string // placeholder path -> token
+	urls   map[string]bool   // Placeholders rendered in URL attributes, e.g. Body.Actions[0].Button.Link
+	strict bool              // StrictValidation of the engine: the values of URL placeholders must be absolute URLs
+	links  map[string]bool   // Placeholders which are the whole href of links, tracked like the other links
+	// Engine adding TrackingParams to the links and rewriting them with LinkRewriter, nil when it does not track them
+	tracker     *Hermes
+	unsubscribe *Unsubscribe // Unsubscribe links, which are not tracked
+}
+
+// urlAttr matches the URL attributes of the HTML up to the start of the token, their value quoted or not
+const urlAttr = `(?i)\s(?:href|src|srcset|background|action|formaction|poster|cite)\s*=\s*["']?[^"'\s>]*`
+
+// Freeze renders the email once, replacing every field listed in placeholders by a unique token.
+// Placeholders are field paths relative to the email, like "Body.Name" or "Body.Actions[0].Button.Link",
+// and must point to string fields.
+// With StrictValidation, the email is validated but for the placeholders, whose tokens are not valid values, e.g. of
+// links: Instantiate checks their values instead. Likewise, the tokens are not links the TrackLinks stage rewrites:
+// Instantiate adds TrackingParams to the values of links and rewrites them with LinkRewriter.
+func (h *Hermes) Freeze(email Email, placeholders []string) (Frozen, error) {
+	nonce := make([]byte, 6)
+	if _, err := rand.Read(nonce); err != nil {
+		return Frozen{}, err
+	}
+
+	frozen := Frozen{tokens: make(map[string]string, len(placeholders)), strict: h.StrictValidation}
+	email = deepCopy(reflect.ValueOf(email)).Interface().(Email)
+	for i, path := range placeholders {
+		if _, ok := frozen.tokens[path]; ok {
+			return Frozen{}, fmt.Errorf("placeholder %q is listed twice", path)
+		}
+		field, err := fieldByPath(reflect.ValueOf(&email).Elem(), path)
+		if err != nil {
+			return Frozen{}, err
+		}
+		// Only lowercase letters and digits, so that the token survives escaping, inlining and URL encoding
+		token := "hermesfrozen" + hex.EncodeToString(nonce) + "x" + strconv.Itoa(i) + "x"
+		field.SetString(token)
+		frozen.tokens[path] = token
+	}
+
+	engine := *h
+	engine.StrictValidation = false
+	if h.StrictValidation {
+		r, prepared, err := prepare(engine, email)
+		if err != nil {
+			return Frozen{}, err
+		}
+		var issues Issues
+		for _, issue := range r.validate(prepared) {
+			if _, ok := frozen.tokens[issue.Path]; !ok {
+				issues = append(issues, issue)
+			}
+		}
+		if issues.HasErrors() {
+			return Frozen{}, issues
+		}
+	}
+
+	var err error
+	frozen.html, frozen.text, err = engine.Generate(email)
+	if err != nil {
+		return Frozen{}, err
+	}
+
+	if engine.tracksLinks() {
+		frozen.tracker = &engine
+		frozen.unsubscribe = engine.UnsubscribeOf(email)
+	}
+	frozen.urls, frozen.links = map[string]bool{}, map[string]bool{}
+	for path, token := range frozen.tokens {
+		if !strings.Contains(frozen.html, token) && !strings.Contains(frozen.text, token) {
+			return Frozen{}, fmt.Errorf("placeholder %q is not rendered by the theme", path)
+		}
+		if regexp.MustCompile(urlAttr + token).MatchString(frozen.html) {
+			frozen.urls[path] = true
+		}
+		if strings.Contains(frozen.html, `href="`+token+`"`) {
+			frozen.links[path] = true
+		}
+	}
+	return frozen, nil
+}
+
+// Instantiate substitutes the given values, keyed by placeholder path, into the frozen email.
+// Every placeholder must be given a value. The values of placeholders rendered in URL attributes, e.g. the links of
+// buttons, are rejected when they run code, e.g. javascript: URLs, which GenerateHTML would not write either, and
+// when they are not absolute URLs with StrictValidation.
+func (f Frozen) Instantiate(values map[string]string) (Output, error) {
+	for path := range values {
+		if _, ok := f.tokens[path]; !ok {
+			return Output{}, fmt.Errorf("unknown placeholder %q", path)
+		}
+	}
+
+	htmlPairs := make([]string, 0, 2*len(f.tokens))
+	textPairs := make([]string, 0, 2*len(f.tokens))
+	for path, token := range f.tokens {
+		value, ok := values[path]
+		if !ok {
+			return Output{}, fmt.Errorf("missing value for placeholder %q", path)
+		}
+		if !f.AllowMarkup && strings.ContainsAny(value, "<>") {
+			return Output{}, fmt.Errorf("value of placeholder %q contains markup", path)
+		}
+		if f.urls[path] && trustedURL(value) == unsafeURL {
+			return Output{}, fmt.Errorf("value of placeholder %q is not a safe URL", path)
+		}
+		if u, err := url.Parse(value); f.urls[path] && f.strict && (err != nil || !u.IsAbs()) {
+			return Output{}, fmt.Errorf("value of placeholder %q is not an absolute URL", path)
+		}
+		text := value
+		if f.tracker != nil {
+			if f.links[path] {
+				// Listed first, so that the links are replaced before the other occurrences of the token
+				tracked := `href="` + html.EscapeString(f.tracker.trackLink(value)) + `"`
+				htmlPairs = append([]string{`href="` + token + `"`, tracked}, htmlPairs...)
+			}
+			// The URLs of the plain text version are all tracked, see trackTextLinks
+			text = f.tracker.trackTextLinks(value, f.unsubscribe)
+		}
+		htmlPairs = append(htmlPairs, token, html.EscapeString(value))
+		textPairs = append(textPairs, token, text)
+	}
+
+	return Output{
+		HTML:      strings.NewReplacer(htmlPairs...).Replace(f.html),
+		PlainText: strings.NewReplacer(textPairs...).Replace(f.text),
+	}, nil
+}
+
+// fieldByPath resolves a path like "Body.Actions[0].Button.Link" to a settable string field
+func fieldByPath(v reflect.Value, path string) (reflect.Value, error) {
+	for _, segment := range strings.Split(path, ".") {
+		name := segment
+		var indexes []string
+		if i := strings.IndexByte(segment, '['); i >= 0 {
+			name = segment[:i]
+			indexes = strings.Split(strings.TrimSuffix(segment[i+1:], "]"), "][")
+		}
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("invalid placeholder %q: %q is not a struct field", path, segment)
+		}
+		v = v.FieldByName(name)
+		if !v.IsValid() {
+			return reflect.Value{}, fmt.Errorf("invalid placeholder %q: unknown field %q", path, name)
+		}
+		for _, index := range indexes {
+			n, err := strconv.Atoi(index)
+			if err != nil || v.Kind() != reflect.Slice || n < 0 || n >= v.Len() {
+				return reflect.Value{}, fmt.Errorf("invalid placeholder %q: bad index in %q", path, segment)
+			}
+			v = v.Index(n)
+		}
+	}
+	if v.Kind() != reflect.String || !v.CanSet() {
+		return reflect.Value{}, fmt.Errorf("invalid placeholder %q: not a string field", path)
+	}
+	return v, nil
+}
+
+// deepCopy copies structs, slices, maps and pointers recursively so that the copy shares no memory with v
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Struct:
+		// Unexported fields, e.g. of time.Time, are copied as they are
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return c
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(deepCopy(v.Elem()))
+		return c
+	default:
+		return v
+	}
+}
@@ -0,0 +1,210 @@
+package hermes
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/Masterminds/sprig/v3"
+)
+
+// FuncPolicy selects the sprig functions available to the templates of themes
+type FuncPolicy int
+
+// Policies of template functions
+const (
+	FuncsSafe FuncPolicy = iota // Sprig functions, with FuncLimits enforced on repeat, regex*, until, untilStep and seq
+	FuncsFull                   // Raw sprig functions, for trusted themes only
+)
+
+// FuncLimits caps the resources used by the guarded sprig functions of the FuncsSafe policy.
+// Exceeding a limit fails the execution of the template. Zero values use the defaults.
+type FuncLimits struct {
+	MaxOutputSize int // Bytes produced by repeat (default to 1 MiB)
+	MaxIterations int // Items produced by until, untilStep and seq (default to 10000)
+	// Bytes of the pattern and inputs of regex functions (default to 64 KiB).
+	// Go regexps are RE2 and run in linear time, there is no catastrophic backtracking, but large inputs are still slow.
+	MaxRegexInput int
+}
+
+// Default limits of template functions
+const (
+	DefaultMaxOutputSize = 1 << 20
+	DefaultMaxIterations = 10000
+	DefaultMaxRegexInput = 64 << 10
+)
+
+func (l FuncLimits) withDefaults() FuncLimits {
+	if l.MaxOutputSize == 0 {
+		l.MaxOutputSize = DefaultMaxOutputSize
+	}
+	if l.MaxIterations == 0 {
+		l.MaxIterations = DefaultMaxIterations
+	}
+	if l.MaxRegexInput == 0 {
+		l.MaxRegexInput = DefaultMaxRegexInput
+	}
+	return l
+}
+
+// regexFuncs are the sprig functions taking a regex, all their string arguments count in MaxRegexInput
+var regexFuncs = []string{
+	"regexMatch", "mustRegexMatch",
+	"regexFindAll", "mustRegexFindAll",
+	"regexFind", "mustRegexFind",
+	"regexReplaceAll", "mustRegexReplaceAll",
+	"regexReplaceAllLiteral", "mustRegexReplaceAllLiteral",
+	"regexSplit", "mustRegexSplit",
+	"regexQuoteMeta",
+}
+
+// funcs returns the functions available to the templates, sprig ones following the policy
+func (p FuncPolicy) funcs(limits FuncLimits) template.FuncMap {
+	funcs := sprig.FuncMap()
+	if p != FuncsFull {
+		limits = limits.withDefaults()
+		for name, fn := range guardedFuncs(funcs, limits) {
+			funcs[name] = fn
+		}
+	}
+	for name, fn := range templateFuncs {
+		funcs[name] = fn
+	}
+	funcs["safe"] = func(s string) template.HTML { return template.HTML(s) }
+	return funcs
+}
+
+// funcs returns the functions available to the templates of the engine: those of its policy, then its TemplateFuncs.
+// Functions of the caller cannot replace the ones of hermes, which the built-in themes rely upon.
+func (h *Hermes) funcs() (template.FuncMap, error) {
+	funcs := h.FuncPolicy.funcs(h.FuncLimits)
+	for name, fn := range h.TemplateFuncs {
+		if _, ok := templateFuncs[name]; ok || name == "safe" {
+			return nil, fmt.Errorf("template function %q is reserved by hermes", name)
+		}
+		// template.Funcs panics on values which are not functions returning a value, and an optional error
+		v := reflect.ValueOf(fn)
+		if v.Kind() != reflect.Func || v.IsNil() {
+			return nil, fmt.Errorf("template function %q is not a function", name)
+		}
+		if out := v.Type().NumOut(); out == 0 || out > 2 || out == 2 && v.Type().Out(1) != reflect.TypeOf((*error)(nil)).Elem() {
+			return nil, fmt.Errorf("template function %q must return a value, and optionally an error", name)
+		}
+		funcs[name] = fn
+	}
+	return funcs, nil
+}
+
+func guardedFuncs(sprigFuncs template.FuncMap, limits FuncLimits) template.FuncMap {
+	until := sprigFuncs["until"].(func(int) []int)
+	untilStep := sprigFuncs["untilStep"].(func(int, int, int) []int)
+	seq := sprigFuncs["seq"].(func(...int) string)
+
+	guarded := template.FuncMap{
+		"repeat": func(count int, str string) (string, error) {
+			if count < 0 {
+				return "", fmt.Errorf("repeat: negative count %d", count)
+			}
+			if size := float64(count) * float64(len(str)); size > float64(limits.MaxOutputSize) {
+				return "", fmt.Errorf("repeat: output of %.0f bytes exceeds the limit of %d bytes", size, limits.MaxOutputSize)
+			}
+			return strings.Repeat(str, count), nil
+		},
+		"until": func(count int) ([]int, error) {
+			step := 1
+			if count < 0 {
+				step = -1
+			}
+			if err := checkIterations("until", 0, count, step, limits); err != nil {
+				return nil, err
+			}
+			return until(count), nil
+		},
+		"untilStep": func(start, stop, step int) ([]int, error) {
+			if err := checkIterations("untilStep", start, stop, step, limits); err != nil {
+				return nil, err
+			}
+			return untilStep(start, stop, step), nil
+		},
+		"seq": func(params ...int) (string, error) {
+			start, stop, step := seqRange(params)
+			if err := checkIterations("seq", start, stop, step, limits); err != nil {
+				return "", err
+			}
+			return seq(params...), nil
+		},
+	}
+	for _, name := range regexFuncs {
+		guarded[name] = guardRegexFunc(name, sprigFuncs[name], limits.MaxRegexInput)
+	}
+	return guarded
+}
+
+// checkIterations fails when untilStep(start, stop, step) produces more than MaxIterations items
+func checkIterations(name string, start, stop, step int, limits FuncLimits) error {
+	if step == 0 {
+		return nil
+	}
+	n := math.Ceil((float64(stop) - float64(start)) / float64(step))
+	if n > float64(limits.MaxIterations) {
+		return fmt.Errorf("%s: %.0f iterations exceed the limit of %d", name, n, limits.MaxIterations)
+	}
+	return nil
+}
+
+// seqRange returns the arguments of untilStep used by sprig's seq
+func seqRange(params []int) (start, stop, step int) {
+	switch len(params) {
+	case 1:
+		start, stop, step = 1, params[0], 1
+	case 2:
+		start, stop, step = params[0], params[1], 1
+	case 3:
+		start, stop, step = params[0], params[2], params[1]
+		if stop < start && step > 0 {
+			return 0, 0, 0
+		}
+	default:
+		return 0, 0, 0
+	}
+	increment := 1
+	if stop < start {
+		increment = -1
+		if len(params) < 3 {
+			step = -1
+		}
+	}
+	return start, stop + increment, step
+}
+
+// guardRegexFunc wraps a sprig regex function so that it fails when its string arguments exceed max bytes
+func guardRegexFunc(name string, fn interface{}, max int) interface{} {
+	v := reflect.ValueOf(fn)
+	t := v.Type()
+	in := make([]reflect.Type, t.NumIn())
+	for i := range in {
+		in[i] = t.In(i)
+	}
+	errorType := reflect.TypeOf((*error)(nil)).Elem()
+	out := []reflect.Type{t.Out(0), errorType}
+
+	return reflect.MakeFunc(reflect.FuncOf(in, out, t.IsVariadic()), func(args []reflect.Value) []reflect.Value {
+		size := 0
+		for _, arg := range args {
+			if arg.Kind() == reflect.String {
+				size += arg.Len()
+			}
+		}
+		if size > max {
+			err := fmt.Errorf("%s: input of %d bytes exceeds the limit of %d bytes", name, size, max)
+			return []reflect.Value{reflect.Zero(out[0]), reflect.ValueOf(&err).Elem()}
+		}
+		results := v.Call(args)
+		if len(results) == 1 {
+			results = append(results, reflect.Zero(errorType))
+		}
+		return results
+	}).Interface()
+}
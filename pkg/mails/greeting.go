@@ -0,0 +1,110 @@
+package hermes
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// IssueGreetingName is the code of the warning of greetings already containing the name, which is not repeated after
+// them unless Hermes.DisableGreetingNameDedupe is set
+const IssueGreetingName = "greeting_name_duplicate"
+
+// dedupeGreetingName returns the email of which the greeting no longer repeats the name, when the greeting already
+// contains it, e.g. "Dear Dr. Jane Smith" with the name "Jane Smith", see splitGreeting.
+func dedupeGreetingName(email Email) Email {
+	body := email.Body
+	if body.Name == "" || body.Title != "" {
+		return email
+	}
+	start, ok := indexName(body.Greeting, body.Name)
+	if !ok {
+		return email
+	}
+	email.warnings = append(email.warnings[:len(email.warnings):len(email.warnings)], Issue{
+		Code:     IssueGreetingName,
+		Severity: SeverityWarning,
+		Path:     "Body.Greeting",
+		Message:  fmt.Sprintf("greeting %q already contains the name %q, which is not repeated", body.Greeting, body.Name),
+	})
+	return splitGreeting(email, start)
+}
+
+// placeGreetingName returns the email of which the greeting, executed as a template, no longer repeats the name when
+// the template writes it, e.g. "{{ .Email.Body.Name }}さん、こんにちは", without warning since it is written on purpose
+func placeGreetingName(email Email) Email {
+	body := email.Body
+	if body.Name == "" || body.Title != "" {
+		return email
+	}
+	start := strings.Index(body.Greeting, body.Name)
+	if start < 0 {
+		return email
+	}
+	return splitGreeting(email, start)
+}
+
+// splitGreeting returns the email of which the greeting is split before the name, at the index in bytes, so that themes
+// render "{Greeting} {Name}," as written in the greeting
+func splitGreeting(email Email, start int) Email {
+	greeting := email.Body.Greeting
+	email.Body.Greeting = strings.TrimSpace(greeting[:start])
+	email.Body.Name = strings.TrimRight(strings.TrimSpace(greeting[start:]), ",")
+	return email
+}
+
+// indexName returns the index in bytes of the name in the greeting, compared case- and whitespace-insensitively.
+// The name must not be part of a longer word, e.g. "Ann" in "Dear Joanne", except in scripts written without spaces.
+func indexName(greeting, name string) (int, bool) {
+	g, offsets := foldSpaces(greeting)
+	n, _ := foldSpaces(name)
+	if len(n) == 0 {
+		return 0, false
+	}
+	for i := 0; i+len(n) <= len(g); i++ {
+		if !equalRunes(g[i:i+len(n)], n) {
+			continue
+		}
+		if i > 0 && joined(g[i-1], n[0]) || i+len(n) < len(g) && joined(g[i+len(n)], n[len(n)-1]) {
+			continue
+		}
+		return offsets[i], true
+	}
+	return 0, false
+}
+
+// foldSpaces returns the lower case runes of s with whitespace trimmed and collapsed to single spaces, and the index in
+// bytes in s of each of them
+func foldSpaces(s string) ([]rune, []int) {
+	var runes []rune
+	var offsets []int
+	space := false
+	for i, r := range s {
+		if unicode.IsSpace(r) {
+			space = len(runes) > 0
+			continue
+		}
+		if space {
+			runes, offsets = append(runes, ' '), append(offsets, i)
+			space = false
+		}
+		runes, offsets = append(runes, unicode.ToLower(r)), append(offsets, i)
+	}
+	return runes, offsets
+}
+
+func equalRunes(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// joined reports whether the runes are letters or digits of the same word, which scripts written without spaces,
+// like Chinese and Japanese, do not tell apart
+func joined(r, edge rune) bool {
+	word := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+	return word(r) && word(edge) && !unicode.In(edge, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Thai)
+}
@@ -0,0 +1,196 @@
+package hermes
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Hash returns the SHA-256 of the HTML and the plain text of the output, in hex, e.g. as the ETag of a preview.
+// It is computed on each call, as the fields of the output may change: keep it along with the output to reuse it.
+func (o Output) Hash() string {
+	h := sha256.New()
+	writeHashString(h, o.HTML)
+	writeHashString(h, o.PlainText)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Hash returns the SHA-256 of the content of the email, in hex, stable across runs and releases.
+// Zero fields are left out, so that nil and empty slices hash the same, as do emails of releases without a new field.
+// Map keys are sorted, and times hashed as the instants they are, whatever their location.
+func (e Email) Hash() string {
+	h := sha256.New()
+	writeHashValue(h, reflect.ValueOf(e))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// renderKey identifies the rendering of the email by the engine in a RenderCache. Functions of the engine, like Now and
+// AfterRender, are left out, as are the unexported fields of values.
+func renderKey(h Hermes, email Email) string {
+	sum := sha256.New()
+	h.RenderCache, h.Metrics = nil, nil
+	writeHashValue(sum, reflect.ValueOf(h))
+	return hex.EncodeToString(sum.Sum(nil)) + ":" + email.Hash()
+}
+
+var (
+	locationType = reflect.TypeOf((*time.Location)(nil))
+	themeType    = reflect.TypeOf((*Theme)(nil)).Elem()
+)
+
+// writeHashValue writes a canonical encoding of the value to the hash
+func writeHashValue(h hash.Hash, v reflect.Value) {
+	switch {
+	case v.Type() == timeType:
+		writeHashString(h, v.Interface().(time.Time).UTC().Format(time.RFC3339Nano))
+		return
+	case v.Type() == locationType:
+		writeHashString(h, v.Interface().(*time.Location).String())
+		return
+	case v.Type().Implements(themeType) && v.Kind() != reflect.Interface:
+		// Themes are identified by their templates, whatever their fields
+		theme := v.Interface().(Theme)
+		writeHashString(h, theme.Name())
+		writeHashString(h, theme.HTMLTemplate())
+		writeHashString(h, theme.PlainTextTemplate())
+		if t, ok := theme.(PlainTextTheme); ok {
+			writeHashString(h, t.TextTemplate())
+		}
+		return
+	}
+	switch v.Kind() {
+	case reflect.Interface:
+		writeHashString(h, v.Elem().Type().String())
+		writeHashValue(h, v.Elem())
+	case reflect.Ptr:
+		writeHashValue(h, v.Elem())
+	case reflect.Struct:
+		h.Write([]byte("{"))
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			kind := field.Type.Kind()
+			if field.PkgPath != "" || kind == reflect.Func || kind == reflect.Chan || isEmptyValue(v.Field(i)) {
+				continue
+			}
+			writeHashString(h, field.Name)
+			writeHashValue(h, v.Field(i))
+		}
+		h.Write([]byte("}"))
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			writeHashString(h, string(v.Bytes()))
+			return
+		}
+		h.Write([]byte("[" + strconv.Itoa(v.Len()) + ":"))
+		for i := 0; i < v.Len(); i++ {
+			writeHashValue(h, v.Index(i))
+		}
+		h.Write([]byte("]"))
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		h.Write([]byte("<" + strconv.Itoa(len(keys)) + ":"))
+		for _, key := range keys {
+			writeHashValue(h, key)
+			writeHashValue(h, v.MapIndex(key))
+		}
+		h.Write([]byte(">"))
+	case reflect.String:
+		writeHashString(h, v.String())
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+	default:
+		writeHashString(h, fmt.Sprint(v))
+	}
+}
+
+// isEmptyValue tells if the value is zero, or an empty slice or map
+func isEmptyValue(v reflect.Value) bool {
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Map {
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// writeHashString writes the string prefixed by its length, so that consecutive strings cannot be confused
+func writeHashString(h hash.Hash, s string) {
+	h.Write([]byte(strconv.Itoa(len(s)) + ":" + s))
+}
+
+// RenderCache holds the outputs of renderings, so that emails already rendered by an engine are not rendered again.
+// See Hermes.RenderCache. The clock of the engine is not part of the keys: relative times are those of the first
+// rendering of the email.
+type RenderCache interface {
+	Get(key string) (Output, bool)
+	Add(key string, out Output)
+}
+
+// DefaultRenderCacheSize is the number of outputs kept by a MemoryRenderCache of size 0
+const DefaultRenderCacheSize = 256
+
+// MemoryRenderCache is an in-memory RenderCache evicting the least recently used outputs once full.
+// It is safe for concurrent use.
+type MemoryRenderCache struct {
+	mu      sync.Mutex
+	size    int
+	outputs map[string]*list.Element
+	lru     *list.List // Most recently used outputs first
+}
+
+type renderCacheEntry struct {
+	key string
+	out Output
+}
+
+// NewMemoryRenderCache creates an in-memory render cache keeping up to size outputs (default to DefaultRenderCacheSize)
+func NewMemoryRenderCache(size int) *MemoryRenderCache {
+	if size <= 0 {
+		size = DefaultRenderCacheSize
+	}
+	return &MemoryRenderCache{size: size, outputs: map[string]*list.Element{}, lru: list.New()}
+}
+
+// Get returns the output cached under the key. Its warnings are a copy.
+func (c *MemoryRenderCache) Get(key string) (Output, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.outputs[key]
+	if !ok {
+		return Output{}, false
+	}
+	c.lru.MoveToFront(el)
+	out := el.Value.(*renderCacheEntry).out
+	out.Warnings = append(Issues(nil), out.Warnings...)
+	return out, true
+}
+
+// Add caches the output under the key, evicting the least recently used output when the cache is full
+func (c *MemoryRenderCache) Add(key string, out Output) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out.Warnings = append(Issues(nil), out.Warnings...)
+	if el, ok := c.outputs[key]; ok {
+		el.Value.(*renderCacheEntry).out = out
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.outputs[key] = c.lru.PushFront(&renderCacheEntry{key: key, out: out})
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.outputs, oldest.Value.(*renderCacheEntry).key)
+	}
+}
+
+// Len returns the number of outputs in the cache
+func (c *MemoryRenderCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
@@ -1,15 +1,21 @@
 package hermes
 
 import (
-	"bytes"
+	"context"
+	"fmt"
 	"html/template"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/Masterminds/sprig/v3"
 	"github.com/imdario/mergo"
 	"github.com/jaytaylor/html2text"
 	"github.com/russross/blackfriday/v2"
-	"github.com/unknowns24/hermes/pkg/themes"
-	"github.com/vanng822/go-premailer/premailer"
+	"github.com/unknowns24/hermes/pkg/core"
+	_ "github.com/unknowns24/hermes/pkg/themes" // Registers the built-in themes, among which the default one
 )
 
 // Hermes is an instance of the hermes email generator
@@ -17,37 +23,183 @@ type Hermes struct {
 	Theme              Theme
 	Brand              Branding
 	TextDirection      TextDirection
+	Locale             string // Locale of the emails, e.g. "fr-FR", given as the lang of HTML emails and selecting the default strings, see Localization
 	DisableCSSInlining bool
-}
+	CSSInliningOptions CSSInliningOptions // Options of the CSS inliner, see CSSInliningOptions for the safe ones
+	MinifyHTML         bool               // Minifies the HTML version after inlining its CSS, see Minify
+	PlainTextOptions   PlainTextOptions   // Options of the conversion of the plain text version from HTML, for themes which are not a PlainTextTheme
+	ClipSize           int                // Size of the HTML version in bytes beyond which a warning is returned, see Output.Warnings (default to GmailClipSize, negative to disable)
+	MarkdownOptions    MarkdownOptions    // Extensions and sanitization of FreeMarkdown, see Hermes.MarkdownToHTML
+	CustomCSS          string             // CSS added after the styles of the theme, e.g. to override colors
+	Sanitizer          Sanitizer          // Sanitizer of the HTML values of entries (default to DefaultSanitizer)
+	Pipeline           *Pipeline          // Stages rendering the emails (default to DefaultPipeline())
+	StrictParams       bool               // Fails on placeholders without a value in Email.Params, instead of keeping them
+	CompatLevel        int                // Pins default strings and theme markup to a past release to avoid output changes on upgrades (default to CompatLatest)
+	TimeZone           *time.Location     // Time zone of the displayed dates and times (default to UTC)
+	Now                func() time.Time   // Clock of relative times (default to time.Now)
+	FuncPolicy         FuncPolicy         // Sprig functions available to the templates of the theme (default to FuncsSafe)
+	FuncLimits         FuncLimits         // Limits of the guarded functions of FuncsSafe
+	TemplateFuncs      template.FuncMap   // Functions added to the templates of the theme, overriding the sprig ones, see Compile
+	DarkMode           DarkModeColors     // Palette of the theme in dark mode (default to DefaultDarkModeColors)
+	AfterRender        AfterRenderFunc    // Called after each successful GenerateContext, e.g. to audit renderings
+	AutoAltText        bool               // Gives an alt text derived from their context to the images without one, see Output.Warnings
+	FailOnHookError    bool               // Fails GenerateContext when AfterRender returns an error, instead of passing it to OnHookError
+	OnHookError        HookErrorFunc      // Receives the errors of AfterRender which do not fail GenerateContext (default to ignoring them)
+	StrictSegments     bool               // Fails RenderForSegment on segments without blocks, instead of using the DefaultSegment ones
+	RenderCache        RenderCache        // Outputs of GenerateContext by engine and email hash, see Email.Hash (default to no cache)
+	Metrics            MetricsSink        // Receives the durations, sizes, errors and cache lookups of renderings (default to NopMetrics)
+	StrictValidation   bool               // Fails generation on the errors of Email.Validate and Branding.Validate, instead of rendering broken HTML
+	TrackingParams     map[string]string  // Query parameters added to the http(s) links of the emails, e.g. utm_source, see AddQueryParams
+	LinkRewriter       LinkRewriterFunc   // Rewrites the http(s) links of the emails after TrackingParams are added, e.g. for a click tracker
+	// ClientProfile lists the clients the emails target, e.g. hermes.ClientAppleMail for an iOS app. SVG images are
+	// replaced by their PNG fallback unless every client renders SVG, see SVGSupport (default to Clients, all of them).
+	ClientProfile []string
+	Rasterizer    SVGRasterizer // Converts the SVG data: images without fallback to PNG when they are replaced (default to none)
+	// AllowedResourceHosts are the hosts the HTML emails may reference, e.g. "cdn.example.com" or "*.example.com". The
+	// resources of other hosts are warnings of the outputs, or errors failing generation with StrictValidation, see
+	// ExternalResources (default to any host).
+	AllowedResourceHosts []string
+	// DisableGreetingNameDedupe renders Body.Name after Body.Greeting even when the greeting already contains it, e.g.
+	// "Dear Dr. Jane Smith Jane Smith,", instead of rendering the name once with a warning, see IssueGreetingName
+	DisableGreetingNameDedupe bool
+	// AnnotateSources wraps the copy of the emails in elements naming where it comes from, for reviewers in previews,
+	// e.g. <span data-source="Body.Intros[1]">, and writes it in brackets before the copy in plain text. It must never
+	// be set for the emails sent, see preview.NewHandler.
+	AnnotateSources bool
 
-// Theme is an interface to implement when creating a new theme
-type Theme interface {
-	Name() string              // The name of the theme
-	HTMLTemplate() string      // The golang template for HTML emails
-	PlainTextTemplate() string // The golang templte for plain text emails (can be basic HTML)
+	templates *compiledTemplates // Theme templates parsed ahead of time by Compile
 }
 
+// Theme is an interface to implement when creating a new theme, see core.Theme
+type Theme = core.Theme
+
 // TextDirection of the text in HTML email
 type TextDirection string
 
+// Text directions
+const (
+	TDLeftToRight TextDirection = "ltr"
+	TDRightToLeft TextDirection = "rtl"
+)
+
+// Validate returns Issues with a ValidationError as underlying error when the direction is neither "ltr" nor "rtl", or nil
+func (d TextDirection) Validate() error {
+	if d == TDLeftToRight || d == TDRightToLeft {
+		return nil
+	}
+	return Issues{ValidationError{
+		Code:    CodeInvalidTextDirection,
+		Path:    "TextDirection",
+		Value:   string(d),
+		Message: `must be "ltr" or "rtl"`,
+	}.issue(SeverityError)}
+}
+
 var templateFuncs = template.FuncMap{
-	"url": func(s string) template.URL {
-		return template.URL(s)
+	"url": trustedURL,
+	"css": func(s string) template.CSS {
+		return template.CSS(s)
 	},
+	"align":        align,
+	"isolate":      isolate,
+	"isolateText":  isolateText,
+	"fragmentText": fragmentText,
+	"entryHTML":    entryHTML,
+	"entryText":    entryText,
+	"translate":    translate,
+	"weekday":      weekday,
+	"timeRange":    timeRange,
+	"relTime":      relTime,
+	"plural":       pluralFunc,
+	"maskLeft":     maskLeft,
+	"maskRight":    maskRight,
+	"maskEmail":    maskEmail,
+	"maskPhone":    maskPhone,
+	"textEntries":  textEntries,
+	"textTable":    textTable,
 }
 
 // Appears in header & footer of e-mails
 type Branding struct {
-	Name        string
-	Link        string // e.g. https://google.com
-	Logo        string // e.g. https://google.com/img/logo.png
-	Copyright   string // Copyright © 2024 Hermes. All rights reserved.
-	TroubleText string // TroubleText is the sentence at the end of the email for users having trouble with the button (default to `If you’re having trouble with the button '{ACTION}', copy and paste the URL below into your web browser.`)
+	Name        string    `json:"name,omitempty" yaml:"name,omitempty"`
+	Link        string    `json:"link,omitempty" yaml:"link,omitempty"`                 // e.g. https://google.com
+	Logo        string    `json:"logo,omitempty" yaml:"logo,omitempty"`                 // e.g. https://google.com/img/logo.png, see LogoImage
+	Copyright   string    `json:"copyright,omitempty" yaml:"copyright,omitempty"`       // Copyright © 2024 Hermes. All rights reserved.
+	TroubleText string    `json:"trouble_text,omitempty" yaml:"trouble_text,omitempty"` // TroubleText is the sentence at the end of the email for users having trouble with the button (default to `If you’re having trouble with the button '{ACTION}', copy and paste the URL below into your web browser.`)
+	WebFonts    []WebFont `json:"web_fonts,omitempty" yaml:"web_fonts,omitempty"`       // Fonts of the brand, displayed by the clients supporting them, see Branding.Validate
+	// SocialLinks, Address and UnsubscribeLink are written in the footer of the built-in themes, when set
+	SocialLinks     []SocialLink `json:"social_links,omitempty" yaml:"social_links,omitempty"`
+	Address         string       `json:"address,omitempty" yaml:"address,omitempty"`                   // Postal address of the sender, required by CAN-SPAM in commercial emails
+	UnsubscribeLink string       `json:"unsubscribe_link,omitempty" yaml:"unsubscribe_link,omitempty"` // e.g. https://google.com/unsubscribe?token=..., the default of Unsubscribe.URL
+	Unsubscribe     *Unsubscribe `json:"unsubscribe,omitempty" yaml:"unsubscribe,omitempty"`           // Default unsubscribe options of the emails, see Email.Unsubscribe
+	// LogoImage is the logo with its dimensions and alternative text, taking precedence over Logo when set
+	LogoImage Logo `json:"logo_image,omitempty" yaml:"logo_image,omitempty"`
+}
+
+// Logo is the image of the brand in the header. Its dimensions avoid the layout jumping while it loads.
+type Logo struct {
+	URL     string `json:"url,omitempty" yaml:"url,omitempty"`           // e.g. https://google.com/img/logo.png
+	DarkURL string `json:"dark_url,omitempty" yaml:"dark_url,omitempty"` // Image displayed instead in dark mode, e.g. a light logo
+	Width   int    `json:"width,omitempty" yaml:"width,omitempty"`       // Width in pixels, the logo being scaled down on narrow screens
+	Height  int    `json:"height,omitempty" yaml:"height,omitempty"`     // Height in pixels
+	Alt     string `json:"alt,omitempty" yaml:"alt,omitempty"`           // Alternative text (default to the name of the brand)
+	// FallbackPNG and DarkFallbackPNG replace URL and DarkURL when they are SVGs, for the clients not rendering SVG,
+	// see Hermes.ClientProfile
+	FallbackPNG     string `json:"fallback_png,omitempty" yaml:"fallback_png,omitempty"`
+	DarkFallbackPNG string `json:"dark_fallback_png,omitempty" yaml:"dark_fallback_png,omitempty"`
+}
+
+// EffectiveLogo returns the logo written in the header: LogoImage, or else Logo as its URL, with the default
+// alternative text. It is zero without logo.
+func (b Branding) EffectiveLogo() Logo {
+	logo := b.LogoImage
+	if logo == (Logo{}) {
+		logo.URL = b.Logo
+	}
+	if logo.URL == "" && logo.DarkURL == "" {
+		return Logo{}
+	}
+	if logo.Alt == "" {
+		logo.Alt = b.Name
+	}
+	return logo
+}
+
+// SocialLink is a profile of the brand on a social network, written as a linked icon in the footer
+type SocialLink struct {
+	Name    string `json:"name,omitempty" yaml:"name,omitempty"`         // e.g. GitHub, the alternative text of the icon, and the text of the link without icon
+	URL     string `json:"url,omitempty" yaml:"url,omitempty"`           // e.g. https://github.com/matcornic
+	IconURL string `json:"icon_url,omitempty" yaml:"icon_url,omitempty"` // Image of the icon, displayed at 24x24 pixels
+	// IconFallbackPNG replaces IconURL when it is an SVG, for the clients not rendering SVG, see Hermes.ClientProfile
+	IconFallbackPNG string `json:"icon_fallback_png,omitempty" yaml:"icon_fallback_png,omitempty"`
 }
 
+// DarkModeColors is the palette used by the theme when the client is in dark mode, see core.DarkModeColors
+type DarkModeColors = core.DarkModeColors
+
+// DefaultDarkModeColors is the palette in dark mode by default
+var DefaultDarkModeColors = core.DefaultDarkModeColors
+
 // Email is the email containing a body
 type Email struct {
-	Body Body
+	Body    Body              `json:"body,omitempty" yaml:"body,omitempty"`
+	Params  map[string]string `json:"params,omitempty" yaml:"params,omitempty"`   // Values of the {name} placeholders of button links, dictionary values and intros ({{ and }} are literal braces)
+	Subject string            `json:"subject,omitempty" yaml:"subject,omitempty"` // Optional subject, a template generated by GenerateSubject (default to Body.Title), see LintSubject
+	// Phrases are values of {name} placeholders varying with a count, formatted in the locale of the body they are
+	// written in, e.g. of each language variant. Params of the same name take precedence.
+	Phrases map[string]Phrase `json:"phrases,omitempty" yaml:"phrases,omitempty"`
+	// Attachments are files sent along with the email, see send.BuildMessage
+	Attachments []Attachment `json:"attachments,omitempty" yaml:"attachments,omitempty"`
+	// Unsubscribe overrides the fields of Branding.Unsubscribe, e.g. with a token of the recipient. Its link is written in
+	// the footer, and send.BuildMessage writes the List-Unsubscribe headers.
+	Unsubscribe *Unsubscribe `json:"unsubscribe,omitempty" yaml:"unsubscribe,omitempty"`
+	// LanguageVariants are rendered one after the other in place of Body, each in its own language, see LanguageVariant
+	LanguageVariants []LanguageVariant `json:"language_variants,omitempty" yaml:"language_variants,omitempty"`
+	// Brand overrides the Brand of the engine for this email, field by field, e.g. to send the emails of several tenants
+	// with one compiled engine, see Hermes.BrandOf
+	Brand *Branding `json:"brand,omitempty" yaml:"brand,omitempty"`
+
+	warnings Issues // Warnings of the preparation, e.g. of the greeting containing the name, set by prepare
 }
 
 // Markdown is a HTML template (a string) representing Markdown content
@@ -56,19 +208,38 @@ type Markdown template.HTML
 
 // Body is the body of the email, containing all interesting data
 type Body struct {
-	Name         string   // The name of the contacted person
-	Intros       []string // Intro sentences, first displayed in the email
-	Dictionary   []Entry  // A list of key+value (useful for displaying parameters/settings/personal info)
-	Table        Table    // Table is an table where you can put data (pricing grid, a bill, and so on)
-	Actions      []Action // Actions are a list of actions that the user will be able to execute via a button click
-	Outros       []string // Outro sentences, last displayed in the email
-	Greeting     string   // Greeting for the contacted person (default to 'Hi')
-	Signature    string   // Signature for the contacted person (default to 'Yours truly')
-	Title        string   // Title replaces the greeting+name when set
-	FreeMarkdown Markdown // Free markdown content that replaces all content other than header and footer
-}
-
-// ToHTML converts Markdown to HTML
+	Name         string   `json:"name,omitempty" yaml:"name,omitempty"`                   // The name of the contacted person
+	Intros       []string `json:"intros,omitempty" yaml:"intros,omitempty"`               // Intro sentences, first displayed in the email
+	Dictionary   []Entry  `json:"dictionary,omitempty" yaml:"dictionary,omitempty"`       // A list of key+value (useful for displaying parameters/settings/personal info)
+	Table        Table    `json:"table,omitempty" yaml:"table,omitempty"`                 // Table is an table where you can put data (pricing grid, a bill, and so on), displayed before Tables
+	Tables       []Table  `json:"tables,omitempty" yaml:"tables,omitempty"`               // Tables displayed one after the other (e.g. items, then taxes & fees)
+	Actions      []Action `json:"actions,omitempty" yaml:"actions,omitempty"`             // Actions are a list of actions that the user will be able to execute via a button click
+	Outros       []string `json:"outros,omitempty" yaml:"outros,omitempty"`               // Outro sentences, last displayed in the email
+	Greeting     string   `json:"greeting,omitempty" yaml:"greeting,omitempty"`           // Greeting for the contacted person (default to 'Hi')
+	Signature    string   `json:"signature,omitempty" yaml:"signature,omitempty"`         // Signature for the contacted person (default to 'Yours truly')
+	Title        string   `json:"title,omitempty" yaml:"title,omitempty"`                 // Title replaces the greeting+name when set
+	FreeMarkdown Markdown `json:"free_markdown,omitempty" yaml:"free_markdown,omitempty"` // Free markdown content that replaces all content other than header and footer
+	Preheader    string   `json:"preheader,omitempty" yaml:"preheader,omitempty"`         // Preview text displayed by email clients next to the subject, hidden in the email
+
+	Schedule            []ScheduleEntry      `json:"schedule,omitempty" yaml:"schedule,omitempty"`                         // Time ranges (e.g. maintenance windows), displayed in the time zone and locale of the engine
+	Summary             *Summary             `json:"summary,omitempty" yaml:"summary,omitempty"`                           // Metric cards with their change since the previous period, and highlights (e.g. a weekly digest)
+	ContactInstructions *ContactInstructions `json:"contact_instructions,omitempty" yaml:"contact_instructions,omitempty"` // How to reach you, displayed after the outros (useful when sending from a no-reply address)
+	SignatureBlock      *SignatureBlock      `json:"signature_block,omitempty" yaml:"signature_block,omitempty"`           // Sign-off with the name and the job title of the sender, replacing Signature
+	CalendarEvent       *CalendarEvent       `json:"calendar_event,omitempty" yaml:"calendar_event,omitempty"`             // Event invitation sent as an .ics attachment by send.BuildMessage (e.g. of a maintenance window)
+	SegmentedBlocks     map[string][]Block   `json:"segmented_blocks,omitempty" yaml:"segmented_blocks,omitempty"`         // Content by audience segment (e.g. "free", "pro"), merged by RenderForSegment
+	TableOfContents     bool                 `json:"table_of_contents,omitempty" yaml:"table_of_contents,omitempty"`       // Lists links to the titled sections after the intros, a numbered outline in plain text, see Body.Anchors
+	ActionsLayout       string               `json:"actions_layout,omitempty" yaml:"actions_layout,omitempty"`             // How the Default theme displays the actions: ActionsList, ActionsSteps or ActionsInline (default to ActionsList)
+}
+
+// ContactInstructions tell recipients how to reach you, instead of replying
+type ContactInstructions struct {
+	Text  string `json:"text,omitempty" yaml:"text,omitempty"`   // e.g. "Questions? Our support team is here to help." (default to "Questions? Contact us:")
+	Email string `json:"email,omitempty" yaml:"email,omitempty"` // e.g. support@example.com
+	URL   string `json:"url,omitempty" yaml:"url,omitempty"`     // e.g. https://example.com/help
+}
+
+// ToHTML converts Markdown to HTML with MarkdownCommonExtensions, without sanitizing it.
+// Use Hermes.MarkdownToHTML to follow the MarkdownOptions of an engine.
 func (c Markdown) ToHTML() template.HTML {
 	return template.HTML(blackfriday.Run([]byte(string(c))))
 }
@@ -77,35 +248,203 @@ func (c Markdown) ToHTML() template.HTML {
 // Allows using a slice of entries instead of a map
 // Because Golang maps are not ordered
 type Entry struct {
-	Key   string
-	Value string
+	Key       string        `json:"key,omitempty" yaml:"key,omitempty"`
+	Value     string        `json:"value,omitempty" yaml:"value,omitempty"`
+	HTMLValue template.HTML `json:"html_value,omitempty" yaml:"html_value,omitempty"` // HTML value replacing Value, always sanitized by the Sanitizer of the engine before being rendered
+	Bidi      string        `json:"bidi,omitempty" yaml:"bidi,omitempty"`             // Direction of the value: "auto", "ltr" or "rtl" (detected for URLs, emails, codes and numbers in RTL emails when empty)
+	Mask      string        `json:"mask,omitempty" yaml:"mask,omitempty"`             // Mask hiding part of Value when rendered, e.g. "left", "left:6" or "email", see MaskLeft
+	// URL, Markdown and Monospace format the values of the dictionary, they are ignored by the tables
+	URL       string `json:"url,omitempty" yaml:"url,omitempty"`             // Link of the value, written after it in parentheses in plain text
+	Markdown  bool   `json:"markdown,omitempty" yaml:"markdown,omitempty"`   // Converts Value from markdown, e.g. for links or addresses on several lines
+	Monospace bool   `json:"monospace,omitempty" yaml:"monospace,omitempty"` // Writes the value in a monospace font, e.g. for API keys
+}
+
+// DictionaryValue returns the value of the entry of the key in Dictionary, or an empty string, e.g. for subjects
+func (b Body) DictionaryValue(key string) string {
+	for _, entry := range b.Dictionary {
+		if entry.Key == key {
+			return entry.Value
+		}
+	}
+	return ""
+}
+
+// AllTables returns Table, when it has data or ShowEmpty is set, followed by Tables
+func (b Body) AllTables() []Table {
+	if len(b.Table.Data) == 0 && !b.Table.ShowEmpty {
+		return b.Tables
+	}
+	return append([]Table{b.Table}, b.Tables...)
+}
+
+// HasStackedTables reports whether a table of the body is stacked on small screens, and needs the CSS of ResponsiveStack
+func (b Body) HasStackedTables() bool {
+	for _, table := range b.AllTables() {
+		if table.ResponsiveMode == ResponsiveStack && len(table.Data) > 0 {
+			return true
+		}
+	}
+	return false
 }
 
 // Table is an table where you can put data (pricing grid, a bill, and so on)
 type Table struct {
-	Data    [][]Entry // Contains data
-	Columns Columns   // Contains meta-data for display purpose (width, alignement)
+	Title          string         `json:"title,omitempty" yaml:"title,omitempty"`                     // Optional title displayed above the table
+	Data           [][]Entry      `json:"data,omitempty" yaml:"data,omitempty"`                       // Contains data
+	Columns        Columns        `json:"columns,omitempty" yaml:"columns,omitempty"`                 // Contains meta-data for display purpose (width, alignement)
+	ResponsiveMode ResponsiveMode `json:"responsive_mode,omitempty" yaml:"responsive_mode,omitempty"` // Layout of the table on small screens (default to ResponsiveNone)
+	Footer         []Entry        `json:"footer,omitempty" yaml:"footer,omitempty"`                   // Optional totals, displayed in bold under the columns of the same keys, e.g. {Key: "Price", Value: "$12.98"}
+	Rows           []RowOptions   `json:"rows,omitempty" yaml:"rows,omitempty"`                       // Optional styles of the rows of Data, by index (e.g. a discount line in green)
+	ShowEmpty      bool           `json:"show_empty,omitempty" yaml:"show_empty,omitempty"`           // Displays the title and the EmptyTable string of the locale when Data is empty, instead of nothing
 }
 
+// RowOptions highlight a row of a table. Colors are hex, rgb() or named colors, other values are ignored.
+type RowOptions struct {
+	Color      string `json:"color,omitempty" yaml:"color,omitempty"`           // Text color, e.g. #1A7F45
+	Background string `json:"background,omitempty" yaml:"background,omitempty"` // Background color, e.g. #F2F4F6
+	Bold       bool   `json:"bold,omitempty" yaml:"bold,omitempty"`
+}
+
+var plainColor = regexp.MustCompile(`^(#[0-9a-fA-F]{3}|#[0-9a-fA-F]{6}|[a-zA-Z]+|rgb\(\s*\d{1,3}\s*,\s*\d{1,3}\s*,\s*\d{1,3}\s*\))$`)
+
+// RowStyle returns the inline CSS of the cells of the row of Data at the index, from Rows
+func (t Table) RowStyle(i int) template.CSS {
+	if i >= len(t.Rows) {
+		return ""
+	}
+	var decls []string
+	row := t.Rows[i]
+	if plainColor.MatchString(row.Color) {
+		decls = append(decls, "color:"+row.Color)
+	}
+	if plainColor.MatchString(row.Background) {
+		decls = append(decls, "background-color:"+row.Background)
+	}
+	if row.Bold {
+		decls = append(decls, "font-weight:bold")
+	}
+	return template.CSS(strings.Join(decls, ";"))
+}
+
+// FooterRow returns the entries of Footer in the order of the columns, with empty entries for the columns without total
+func (t Table) FooterRow() []Entry {
+	if len(t.Footer) == 0 || len(t.Data) == 0 {
+		return nil
+	}
+	row := make([]Entry, len(t.Data[0]))
+	for i, column := range t.Data[0] {
+		row[i] = Entry{Key: column.Key}
+		for _, entry := range t.Footer {
+			if entry.Key == column.Key {
+				row[i] = entry
+			}
+		}
+	}
+	return row
+}
+
+// ResponsiveMode is the layout of a table on small screens
+type ResponsiveMode string
+
+// Layouts of tables on small screens
+const (
+	ResponsiveNone  ResponsiveMode = ""      // Same layout as on desktop
+	ResponsiveStack ResponsiveMode = "stack" // Each row becomes a card of label/value pairs under 480px, with media queries kept by CSS inlining
+)
+
 // Columns contains meta-data for the different columns
 type Columns struct {
-	CustomWidth     map[string]string
-	CustomAlignment map[string]string
+	CustomWidth     map[string]string `json:"custom_width,omitempty" yaml:"custom_width,omitempty"`
+	CustomAlignment map[string]string `json:"custom_alignment,omitempty" yaml:"custom_alignment,omitempty"`
 }
 
 // Action is anything the user can act on (i.e., click on a button, view an invite code)
 type Action struct {
-	Instructions string
-	Button       Button
-	InviteCode   string
+	Title             string            `json:"title,omitempty" yaml:"title,omitempty"` // Title of the step, e.g. "Verify your email", displayed by the steps layout, see Body.ActionsLayout
+	Instructions      string            `json:"instructions,omitempty" yaml:"instructions,omitempty"`
+	Button            Button            `json:"button,omitempty" yaml:"button,omitempty"`
+	InviteCode        string            `json:"invite_code,omitempty" yaml:"invite_code,omitempty"`
+	InviteCodeOptions InviteCodeOptions `json:"invite_code_options,omitempty" yaml:"invite_code_options,omitempty"` // Grouping and styling of the invite code, the zero value rendering it as given
+	TroubleText       string            `json:"trouble_text,omitempty" yaml:"trouble_text,omitempty"`               // Overrides Branding.TroubleText for this action, {ACTION} is replaced by the button text as well
+	HideFallbackLink  bool              `json:"hide_fallback_link,omitempty" yaml:"hide_fallback_link,omitempty"`   // Hides the trouble text and the URL of the button, e.g. for mailto: links
+}
+
+// Layouts of the actions, see Body.ActionsLayout
+const (
+	ActionsList   = "list"   // One action after the other, their instructions above their button
+	ActionsSteps  = "steps"  // Numbered steps with their title, e.g. "Step 1: verify your email", "Step 2: install the app"
+	ActionsInline = "inline" // Small buttons side by side after the instructions, for short actions without invite code
+)
+
+// FallbackText returns the trouble text introducing the URL of the button at the end of the email, from the action or
+// else from the brand. It is empty when the URL is not displayed: without button, with HideFallbackLink, or with an invite code.
+func (a Action) FallbackText(brandTroubleText string) string {
+	if a.Button.Text == "" || a.HideFallbackLink || a.InviteCode != "" {
+		return ""
+	}
+	text := brandTroubleText
+	if a.TroubleText != "" {
+		text = a.TroubleText
+	}
+	return strings.ReplaceAll(text, "{ACTION}", a.Button.Text)
+}
+
+// InviteCodeOptions groups and styles an invite code, e.g. a one-time password displayed as "123 456"
+type InviteCodeOptions struct {
+	GroupSize       int    `json:"group_size,omitempty" yaml:"group_size,omitempty"`             // Characters per group, counted from the start of the code, 0 to not group it
+	Separator       string `json:"separator,omitempty" yaml:"separator,omitempty"`               // Between groups (default to a space)
+	FontSize        string `json:"font_size,omitempty" yaml:"font_size,omitempty"`               // e.g. 32px, overrides the size of the theme
+	BackgroundColor string `json:"background_color,omitempty" yaml:"background_color,omitempty"` // Overrides the background color of the theme
+	TextColor       string `json:"text_color,omitempty" yaml:"text_color,omitempty"`             // Overrides the text color of the theme
+}
+
+// GroupedInviteCode returns the invite code split in groups of InviteCodeOptions.GroupSize characters, as written in
+// the HTML and plain text emails
+func (a Action) GroupedInviteCode() string {
+	size := a.InviteCodeOptions.GroupSize
+	code := []rune(a.InviteCode)
+	if size <= 0 || len(code) <= size {
+		return a.InviteCode
+	}
+	separator := a.InviteCodeOptions.Separator
+	if separator == "" {
+		separator = " "
+	}
+	groups := make([]string, 0, (len(code)+size-1)/size)
+	for len(code) > size {
+		groups = append(groups, string(code[:size]))
+		code = code[size:]
+	}
+	groups = append(groups, string(code))
+	return strings.Join(groups, separator)
 }
 
 // Button defines an action to launch
 type Button struct {
-	Color     string
-	TextColor string
-	Text      string
-	Link      string
+	Color        string `json:"color,omitempty" yaml:"color,omitempty"`
+	TextColor    string `json:"text_color,omitempty" yaml:"text_color,omitempty"`
+	Text         string `json:"text,omitempty" yaml:"text,omitempty"`
+	Link         string `json:"link,omitempty" yaml:"link,omitempty"`
+	Width        int    `json:"width,omitempty" yaml:"width,omitempty"`                 // In pixels, from the length of Text by default
+	Height       int    `json:"height,omitempty" yaml:"height,omitempty"`               // In pixels, the one of the theme by default
+	BorderRadius int    `json:"border_radius,omitempty" yaml:"border_radius,omitempty"` // In pixels, the one of the theme by default
+}
+
+// ArcSize returns the arcsize of the VML roundrect drawing the button in Outlook, at the given width and height in
+// pixels: BorderRadius as a percentage of half the smaller side, e.g. "13%" for 3 pixels of a 45 pixels high button
+func (b Button) ArcSize(width, height int) string {
+	side := width
+	if height < side {
+		side = height
+	}
+	if side <= 0 || b.BorderRadius <= 0 {
+		return "0%"
+	}
+	percent := (b.BorderRadius*200 + side/2) / side
+	if percent > 100 {
+		percent = 100
+	}
+	return strconv.Itoa(percent) + "%"
 }
 
 // Template is the struct given to Golang templating
@@ -116,14 +455,24 @@ type Template struct {
 }
 
 func (e *Email) SetDefaultEmailValues() error {
+	return e.setDefaultEmailValuesAt(CompatLatest, DefaultLocale)
+}
+
+// setDefaultEmailValuesAt sets the default values of the compat level, in the locale
+func (e *Email) setDefaultEmailValuesAt(level int, locale string) error {
+	defaults, err := defaultsAt(level)
+	if err != nil {
+		return err
+	}
+	defaults = localize(defaults, level, locale)
 	// Default values of an email
 	defaultEmail := Email{
 		Body: Body{
 			Intros:     []string{},
 			Dictionary: []Entry{},
 			Outros:     []string{},
-			Signature:  "Yours truly",
-			Greeting:   "Hi",
+			Signature:  defaults.Signature,
+			Greeting:   defaults.Greeting,
 		},
 	}
 
@@ -134,84 +483,448 @@ func (e *Email) SetDefaultEmailValues() error {
 
 // default values of the engine
 func (h *Hermes) SetDefaultHermesValues() error {
+	defaults, err := defaultsAt(h.CompatLevel)
+	if err != nil {
+		return err
+	}
+	defaults = localize(defaults, h.CompatLevel, h.Locale)
+	// Invalid colors would be written as is in a stylesheet, fall back to the default ones
+	for _, color := range []*string{
+		&h.DarkMode.Background, &h.DarkMode.Content, &h.DarkMode.Text, &h.DarkMode.Heading,
+		&h.DarkMode.Link, &h.DarkMode.Button, &h.DarkMode.ButtonText,
+	} {
+		if !plainColor.MatchString(*color) {
+			*color = ""
+		}
+	}
+	if h.Theme == nil {
+		// The default theme is looked up rather than imported, so that themes can import the types of core
+		theme, ok := core.LookupTheme(core.DefaultThemeName)
+		if !ok {
+			return fmt.Errorf("theme %q is not registered", core.DefaultThemeName)
+		}
+		h.Theme = theme
+	}
 	defaultHermes := Hermes{
-		Theme:         new(themes.Default),
-		TextDirection: "ltr",
-		Brand: Branding{
-			Name:        "Hermes",
-			Copyright:   "Copyright © 2024 Hermes. All rights reserved.",
-			TroubleText: "If you’re having trouble with the button '{ACTION}', copy and paste the URL below into your web browser.",
-		},
+		TextDirection: textDirection(h.Locale),
+		Brand:         defaults.Brand,
+		DarkMode:      DefaultDarkModeColors,
 	}
 	// Merge the given hermes engine configuration with default one
 	// Default one overrides all zero values
-	err := mergo.Merge(h, defaultHermes)
+	err = mergo.Merge(h, defaultHermes)
 	if err != nil {
 		return err
 	}
-	return nil
+	// Any other direction would be written as is in the dir attributes, and silently ignored by clients
+	return h.TextDirection.Validate()
 }
 
 // GenerateHTML genera el cuerpo del correo electrónico en formato HTML para clientes modernos.
 func (h *Hermes) GenerateHTML(email Email) (string, error) {
-	err := h.SetDefaultHermesValues()
+	return h.GenerateHTMLContext(context.Background(), email)
+}
+
+// GenerateHTMLContext generates the HTML body of the email like GenerateHTML. It returns the error of the context as
+// soon as it is done: the context is checked between the stages of the pipeline, and CSS inlining is abandoned when it
+// is done while it runs.
+func (h *Hermes) GenerateHTMLContext(ctx context.Context, email Email) (string, error) {
+	return renderHTML(ctx, *h, email)
+}
+
+// GenerateHTMLWithWarnings generates the HTML body of the email like GenerateHTML, and returns the issues found by
+// Email.Validate and Branding.Validate along with the warnings of rendering, e.g. to display them in an editor.
+// Unless StrictValidation is set, emails with errors are rendered all the same.
+func (h *Hermes) GenerateHTMLWithWarnings(email Email) (string, Issues, error) {
+	r, email, err := prepare(*h, email)
 	if err != nil {
-		return "", err
+		return "", nil, err
+	}
+	html, warnings, err := r.generateHTMLWarnings(context.Background(), email)
+	if err != nil {
+		return "", nil, err
 	}
-	return h.generateTemplate(email, h.Theme.HTMLTemplate())
+	return html, append(r.validate(email), warnings...), nil
+}
+
+// GenerateHTMLRaw generates the HTML body of the email without inlining its CSS, whatever DisableCSSInlining, e.g. for
+// callers post-processing the HTML with their own inliner.
+func (h *Hermes) GenerateHTMLRaw(email Email) (string, error) {
+	raw := *h
+	raw.DisableCSSInlining = true
+	return RenderHTML(raw, email)
 }
 
 // GeneratePlainText genera el cuerpo del correo electrónico en formato de texto sin formato para clientes antiguos.
 func (h *Hermes) GeneratePlainText(email Email) (string, error) {
-	err := h.SetDefaultHermesValues()
+	return h.GeneratePlainTextContext(context.Background(), email)
+}
+
+// GeneratePlainTextContext generates the plain text body of the email like GeneratePlainText, and returns the error of
+// the context as soon as it is done, see GenerateHTMLContext.
+func (h *Hermes) GeneratePlainTextContext(ctx context.Context, email Email) (string, error) {
+	return renderPlainText(ctx, *h, email)
+}
+
+// Generate generates both the HTML and the plain text bodies of the email, e.g. for a multipart message.
+// Default values are applied once, and CSS is only inlined in the HTML body.
+func (h *Hermes) Generate(email Email) (html string, plain string, err error) {
+	return Render(*h, email)
+}
+
+// GenerateHTMLTo writes the HTML body of the email to w, e.g. for bulk campaigns.
+// When CSS inlining is disabled, the template of the theme is executed straight into w: wrap w in a bufio.Writer when
+// small writes are costly. Otherwise the output is buffered to be inlined, then written.
+// On template errors, w may hold part of the email.
+func (h *Hermes) GenerateHTMLTo(email Email, w io.Writer) error {
+	r, email, err := prepare(*h, email)
+	if err != nil {
+		return err
+	}
+	return r.pipeline().runTo(&Rendering{Hermes: r, Email: email, ctx: context.Background(), template: r.htmlTemplate()}, w)
+}
+
+// GeneratePlainTextTo writes the plain text body of the email to w.
+// The plain text is converted from the whole output of the template, or tidied for a PlainTextTheme, so only the copy
+// of the final text is spared.
+func (h *Hermes) GeneratePlainTextTo(email Email, w io.Writer) error {
+	r, email, err := prepare(*h, email)
 	if err != nil {
+		return err
+	}
+	text, err := r.generatePlainText(context.Background(), email)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, text)
+	return err
+}
+
+// RenderHTML generates the HTML body of the email.
+// The engine and the email are values: the default values are applied to copies, and the ones of the caller are never modified.
+func RenderHTML(h Hermes, email Email) (string, error) {
+	return renderHTML(context.Background(), h, email)
+}
+
+// RenderPlainText generates the plain text body of the email, without modifying the engine nor the email of the caller
+func RenderPlainText(h Hermes, email Email) (string, error) {
+	return renderPlainText(context.Background(), h, email)
+}
+
+// renderHTML generates the HTML body of the email, until the context is done
+func renderHTML(ctx context.Context, h Hermes, email Email) (string, error) {
+	if err := ctx.Err(); err != nil {
 		return "", err
 	}
-	template, err := h.generateTemplate(email, h.Theme.PlainTextTemplate())
+	r, email, err := prepare(h, email)
 	if err != nil {
 		return "", err
 	}
-	return html2text.FromString(template, html2text.Options{PrettyTables: true})
+	return r.generateHTML(ctx, email)
 }
 
-func (h *Hermes) generateTemplate(email Email, tplt string) (string, error) {
-	err := email.SetDefaultEmailValues()
+// renderPlainText generates the plain text body of the email, until the context is done
+func renderPlainText(ctx context.Context, h Hermes, email Email) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	r, email, err := prepare(h, email)
 	if err != nil {
 		return "", err
 	}
+	return r.generatePlainText(ctx, email)
+}
 
-	t, err := template.New("hermes").
-		Funcs(sprig.FuncMap()).
-		Funcs(templateFuncs).
-		Funcs(template.FuncMap{
-			"safe": func(s string) template.HTML { return template.HTML(s) },
-		}).
-		Parse(tplt)
+// Render generates both the HTML and the plain text bodies of the email, without modifying the engine nor the email of the caller
+func Render(h Hermes, email Email) (html string, plain string, err error) {
+	r, email, err := prepare(h, email)
 	if err != nil {
-		return "", err
+		return "", "", err
+	}
+	if html, err = r.generateHTML(context.Background(), email); err != nil {
+		return "", "", err
 	}
+	if plain, err = r.generatePlainText(context.Background(), email); err != nil {
+		return "", "", err
+	}
+	return html, plain, nil
+}
 
-	var b bytes.Buffer
-	err = t.Execute(&b, Template{*h, email})
+// prepare returns the engine and the email with their default values, and the parameters of the email expanded.
+// Slices of the email are copied before being written, so that generating emails never writes to the values of the caller,
+// and is safe for concurrent use.
+func prepare(h Hermes, email Email) (*Hermes, Email, error) {
+	r, email, err := prepareEmail(h, email)
 	if err != nil {
-		return "", err
+		countRenderError(h.metrics(), "prepare")
+	}
+	return r, email, err
+}
+
+func prepareEmail(h Hermes, email Email) (*Hermes, Email, error) {
+	if len(email.LanguageVariants) > 0 {
+		// The header and the footer are shared by the variants, in the locale of the first one
+		h.Locale = email.LanguageVariants[0].Tag
+	}
+	h.Brand = h.BrandOf(email)
+	if err := h.SetDefaultHermesValues(); err != nil {
+		return nil, Email{}, err
+	}
+	var err error
+	if len(email.LanguageVariants) > 0 {
+		email, err = prepareVariants(&h, email)
+	} else {
+		email, err = prepareBody(&h, email, h.Locale)
+	}
+	if err != nil {
+		return nil, Email{}, err
+	}
+	if h.Brand.Copyright, err = h.executeField("Brand.Copyright", h.Brand.Copyright, email); err != nil {
+		return nil, Email{}, err
+	}
+	email.Unsubscribe = h.UnsubscribeOf(email)
+	if h.StrictValidation {
+		if issues := h.validate(email); issues.HasErrors() {
+			return nil, Email{}, issues
+		}
+	}
+	// Themes write LogoImage, and custom ones may still write Logo
+	h.Brand.LogoImage = h.Brand.EffectiveLogo()
+	if err := h.prepareImages(); err != nil {
+		return nil, Email{}, err
 	}
+	h.Brand.Logo = h.Brand.LogoImage.URL
+	return &h, email, nil
+}
 
-	res := b.String()
-	if h.DisableCSSInlining {
-		return res, nil
+// prepareBody returns the email with the default values of the locale, its parameters expanded and its entries masked
+func prepareBody(h *Hermes, email Email, locale string) (Email, error) {
+	email = preferSignatureBlock(email)
+	if err := email.setDefaultEmailValuesAt(h.CompatLevel, locale); err != nil {
+		return Email{}, err
+	}
+	email = defaultSignatureClosing(email)
+	email, err := expandEmailParams(email, locale, h.StrictParams)
+	if err != nil {
+		return Email{}, err
 	}
+	if email, err = maskEmailEntries(email); err != nil {
+		return Email{}, err
+	}
+	greeting, err := h.executeField("Body.Greeting", email.Body.Greeting, email)
+	if err != nil {
+		return Email{}, err
+	}
+	switch templated := strings.Contains(email.Body.Greeting, "{{"); {
+	case h.DisableGreetingNameDedupe:
+		email.Body.Greeting = greeting
+	case templated:
+		email.Body.Greeting = greeting
+		email = placeGreetingName(email)
+	default:
+		email = dedupeGreetingName(email)
+	}
+	return email, nil
+}
 
-	// Inlining CSS
-	prem, err := premailer.NewPremailerFromString(res, premailer.NewOptions())
+// validate returns the issues of the branding and the email, prepared by prepare
+func (h *Hermes) validate(email Email) Issues {
+	var issues Issues
+	for _, err := range []error{h.Brand.Validate(), email.Validate()} {
+		if err != nil {
+			issues = append(issues, err.(Issues)...)
+		}
+	}
+	return issues
+}
+
+func (h *Hermes) generateHTML(ctx context.Context, email Email) (string, error) {
+	return h.render(ctx, email, h.htmlTemplate(), false)
+}
+
+func (h *Hermes) generateHTMLWarnings(ctx context.Context, email Email) (string, Issues, error) {
+	html, warnings, err := h.renderWarnings(ctx, email, h.htmlTemplate(), false)
 	if err != nil {
-		return "", err
+		return "", nil, err
+	}
+	if warning, ok := h.clipWarning(html); ok {
+		warnings = append(warnings, warning)
 	}
+	return html, warnings, nil
+}
 
-	html, err := prem.Transform()
+func (h *Hermes) generatePlainText(ctx context.Context, email Email) (string, error) {
+	tplt, native := h.plainTextTemplate()
+	r := &Rendering{Hermes: h, Email: email, PlainText: true, ctx: ctx, template: tplt, native: native}
+	text, err := h.pipeline().run(r)
 	if err != nil {
 		return "", err
 	}
+	if native {
+		text = tidyText(text)
+	} else if text, err = html2text.FromString(text, h.PlainTextOptions.html2textOptions()); err != nil {
+		countRenderError(h.metrics(), "plain_text")
+		return "", err
+	}
+	if r.sources != nil {
+		text = annotateText(text, r.sources)
+	}
+	if h.tracksLinks() && h.pipeline().index(StageTrackLinks) >= 0 {
+		text = h.trackTextLinks(text, email.Unsubscribe)
+	}
+	if h.pipeline().normalizes(h) {
+		text = NormalizeText(text)
+	}
+	return text, nil
+}
+
+// Compile applies the default values and parses the templates of the theme ahead of time.
+// It must not be called concurrently with the generation of emails.
+// TemplateFuncs are bound to the templates when they are parsed: set them before calling Compile. The templates of
+// engines with TemplateFuncs which are not compiled are parsed for each email.
+func (h *Hermes) Compile() error {
+	err := h.SetDefaultHermesValues()
+	if err != nil {
+		return err
+	}
+	c := compiledTemplates{
+		htmlSource: h.htmlTemplate(),
+		policy:     h.FuncPolicy,
+		limits:     h.FuncLimits,
+	}
+	c.textSource, c.textNative = h.plainTextTemplate()
+	funcs, err := h.funcs()
+	if err != nil {
+		return err
+	}
+	if c.html, err = parseTemplate(c.htmlSource, funcs); err != nil {
+		return err
+	}
+	if c.text, err = parseTextSource(c.textSource, c.textNative, funcs); err != nil {
+		return err
+	}
+	h.templates = &c
+	if h.Pipeline != nil {
+		return h.Pipeline.Validate()
+	}
+	return nil
+}
+
+// compiledTemplates holds the parsed templates of a theme, along with their sources and functions
+// so that they are not used anymore once the theme or the policy changes
+type compiledTemplates struct {
+	htmlSource, textSource string
+	textNative             bool // Whether textSource is a native text template, see PlainTextTheme
+	policy                 FuncPolicy
+	limits                 FuncLimits
+	html, text             executable
+}
+
+// executable is a parsed template: an html/template one, or a text/template one for native plain text templates
+type executable interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+func (c *compiledTemplates) lookup(tplt string, policy FuncPolicy, limits FuncLimits) executable {
+	switch {
+	case c == nil, policy != c.policy, limits != c.limits:
+		return nil
+	case tplt == c.htmlSource:
+		return c.html
+	case tplt == c.textSource:
+		return c.text
+	}
+	return nil
+}
 
-	return html, nil
+// templateCache holds the templates parsed for engines which are not compiled, by theme name and kind of template.
+// Only the latest template of a theme is kept: it is parsed again when the instance of the theme changes.
+var templateCache = struct {
+	sync.RWMutex
+	templates map[templateKey]*cachedTemplate
+}{templates: map[templateKey]*cachedTemplate{}}
+
+type templateKey struct {
+	theme     string
+	level     int // Compat level, whose templates differ
+	plainText bool
+	native    bool // Native text template, see PlainTextTheme
+}
+
+type cachedTemplate struct {
+	source   string
+	policy   FuncPolicy
+	limits   FuncLimits
+	template executable
+}
+
+// parsedTemplate returns the parsed template of the theme, parsing it on the first call only.
+// Parsed templates are safe for concurrent execution.
+func (h *Hermes) parsedTemplate(tplt string, plainText, native bool) (executable, error) {
+	if t := h.templates.lookup(tplt, h.FuncPolicy, h.FuncLimits); t != nil {
+		countCacheLookup(h.metrics(), "template", true)
+		return t, nil
+	}
+
+	if len(h.TemplateFuncs) > 0 {
+		countCacheLookup(h.metrics(), "template", false)
+		// Functions cannot be compared: templates with functions of the caller are only kept by Compile
+		funcs, err := h.funcs()
+		if err != nil {
+			return nil, err
+		}
+		return parseTextSource(tplt, native, funcs)
+	}
+
+	key := templateKey{theme: h.Theme.Name(), level: h.CompatLevel, plainText: plainText, native: native}
+	templateCache.RLock()
+	c := templateCache.templates[key]
+	templateCache.RUnlock()
+	hit := c != nil && c.source == tplt && c.policy == h.FuncPolicy && c.limits == h.FuncLimits
+	countCacheLookup(h.metrics(), "template", hit)
+	if hit {
+		return c.template, nil
+	}
+
+	t, err := parseTextSource(tplt, native, h.FuncPolicy.funcs(h.FuncLimits))
+	if err != nil {
+		return nil, err
+	}
+	templateCache.Lock()
+	templateCache.templates[key] = &cachedTemplate{source: tplt, policy: h.FuncPolicy, limits: h.FuncLimits, template: t}
+	templateCache.Unlock()
+	return t, nil
+}
+
+func parseTemplate(tplt string, funcs template.FuncMap) (*template.Template, error) {
+	return template.New("hermes").Funcs(funcs).Parse(tplt)
+}
+
+// parseTextSource parses the template with text/template when it is a native text template, and html/template otherwise
+func parseTextSource(tplt string, native bool, funcs template.FuncMap) (executable, error) {
+	if native {
+		return parseTextTemplate(tplt, funcs)
+	}
+	return parseTemplate(tplt, funcs)
+}
+
+// render runs the email, prepared by prepare, through the pipeline
+func (h *Hermes) render(ctx context.Context, email Email, tplt string, plainText bool) (string, error) {
+	html, _, err := h.renderWarnings(ctx, email, tplt, plainText)
+	return html, err
+}
+
+// renderWarnings renders the email like render, and returns the warnings of the stages
+func (h *Hermes) renderWarnings(ctx context.Context, email Email, tplt string, plainText bool) (string, Issues, error) {
+	r := &Rendering{Hermes: h, Email: email, PlainText: plainText, ctx: ctx, template: tplt}
+	html, err := h.pipeline().run(r)
+	return html, r.Warnings, err
+}
+
+// pipeline returns the pipeline of the engine, or the default one
+func (h *Hermes) pipeline() *Pipeline {
+	if h.Pipeline == nil {
+		return defaultPipeline
+	}
+	return h.Pipeline
 }
@@ -0,0 +1,110 @@
+package hermes
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// AfterRenderFunc is called by the context-aware generation functions after a successful rendering, e.g. to record it in
+// an audit service. It is given a copy of the email, the output as it is returned to the caller, and its statistics.
+type AfterRenderFunc func(ctx context.Context, email Email, out Output, stats RenderStats) error
+
+// HookErrorFunc receives the errors of the AfterRender hook which do not fail the generation, e.g. to log them
+type HookErrorFunc func(ctx context.Context, err error)
+
+// RenderStats describe a rendering, for AfterRender hooks and RenderStream
+type RenderStats struct {
+	Theme         string        // Name of the theme
+	HTMLSize      int           // Size of the HTML body, in bytes
+	PlainTextSize int           // Size of the plain text body, in bytes
+	HTMLHash      string        // SHA-256 of the HTML body, in hex
+	PlainTextHash string        // SHA-256 of the plain text body, in hex
+	Duration      time.Duration // Time spent generating both bodies
+}
+
+// GenerateContext generates both the HTML and the plain text bodies of the email, like Generate, then calls the
+// AfterRender hook of the engine. It stops with the error of the context when it is done.
+func (h *Hermes) GenerateContext(ctx context.Context, email Email) (Output, error) {
+	return RenderContext(ctx, *h, email)
+}
+
+// RenderContext generates both bodies of the email like Render, then calls the AfterRender hook of the engine.
+// The hook is not called when generation fails. Its error is given to OnHookError and ignored, unless FailOnHookError
+// is set.
+// Outputs found in the RenderCache of the engine are returned without rendering, and without calling the hook.
+func RenderContext(ctx context.Context, h Hermes, email Email) (Output, error) {
+	if err := ctx.Err(); err != nil {
+		return Output{}, err
+	}
+	var key string
+	if h.RenderCache != nil {
+		key = renderKey(h, email)
+		out, ok := h.RenderCache.Get(key)
+		countCacheLookup(h.metrics(), "render", ok)
+		if ok {
+			return out, nil
+		}
+	}
+	start := time.Now()
+	r, prepared, err := prepare(h, email)
+	if err != nil {
+		return Output{}, err
+	}
+	var out Output
+	if out.HTML, out.Warnings, err = r.generateHTMLWarnings(ctx, prepared); err != nil {
+		return Output{}, err
+	}
+	if err := ctx.Err(); err != nil {
+		return Output{}, err
+	}
+	if out.PlainText, err = r.generatePlainText(ctx, prepared); err != nil {
+		return Output{}, err
+	}
+	if out.Subject, err = r.subject(prepared); err != nil {
+		countRenderError(h.metrics(), "subject")
+		return Output{}, err
+	}
+	if h.RenderCache != nil {
+		h.RenderCache.Add(key, out)
+	}
+	if h.AfterRender == nil && h.Metrics == nil {
+		return out, nil
+	}
+
+	stats := newRenderStats(r.Theme.Name(), out, time.Since(start))
+	observeRender(h.metrics(), stats)
+	if h.AfterRender == nil {
+		return out, nil
+	}
+
+	// The hook gets its own copies of the email and the output: it cannot alter what is returned
+	copied := deepCopy(reflect.ValueOf(email)).Interface().(Email)
+	hookOut := out
+	hookOut.Warnings = append(Issues(nil), out.Warnings...)
+	if err := h.AfterRender(ctx, copied, hookOut, stats); err != nil {
+		if h.FailOnHookError {
+			return Output{}, fmt.Errorf("after render hook: %w", err)
+		}
+		if h.OnHookError != nil {
+			h.OnHookError(ctx, fmt.Errorf("after render hook: %w", err))
+		}
+	}
+	return out, nil
+}
+
+// newRenderStats returns the statistics of the output rendered with the theme
+func newRenderStats(theme string, out Output, d time.Duration) RenderStats {
+	htmlHash, textHash := sha256.Sum256([]byte(out.HTML)), sha256.Sum256([]byte(out.PlainText))
+	return RenderStats{
+		Theme:         theme,
+		HTMLSize:      len(out.HTML),
+		PlainTextSize: len(out.PlainText),
+		HTMLHash:      hex.EncodeToString(htmlHash[:]),
+		PlainTextHash: hex.EncodeToString(textHash[:]),
+		Duration:      d,
+	}
+}
@@ -0,0 +1,104 @@
+package hermes
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// Issue is a problem found while checking an email, with the path of the field at fault
+type Issue struct {
+	Code     string // Stable identifier of the kind of issue, e.g. "button_link_not_absolute"
+	Severity Severity
+	Path     string // Path of the field, e.g. Body.Actions[0].Button.Link
+	Message  string // Technical message, in English
+	Err      error  // Underlying error, if any, e.g. a ValidationError
+}
+
+func (i Issue) Error() string {
+	if i.Path == "" {
+		return i.Message
+	}
+	return i.Path + ": " + i.Message
+}
+
+// Unwrap returns the underlying error, for errors.Is and errors.As
+func (i Issue) Unwrap() error {
+	return i.Err
+}
+
+// Localize returns a message explaining the issue to end users, in the locale of the tag (e.g. "fr-FR").
+// Issues without translation are explained by their technical message.
+func (i Issue) Localize(tag string) string {
+	if v, ok := i.Err.(ValidationError); ok {
+		return v.Localize(tag)
+	}
+	return i.Message
+}
+
+// MarshalJSON encodes the issue for API responses, its underlying error as a string
+func (i Issue) MarshalJSON() ([]byte, error) {
+	issue := struct {
+		Code     string   `json:"code"`
+		Severity Severity `json:"severity"`
+		Path     string   `json:"path,omitempty"`
+		Message  string   `json:"message"`
+		Err      string   `json:"error,omitempty"`
+	}{i.Code, i.Severity, i.Path, i.Message, ""}
+	if i.Err != nil {
+		issue.Err = i.Err.Error()
+	}
+	return json.Marshal(issue)
+}
+
+// Issues are all the problems found while checking an email.
+// Functions return them as an error, which is nil when there are none.
+type Issues []Issue
+
+func (is Issues) Error() string {
+	messages := make([]string, len(is))
+	for i, issue := range is {
+		messages[i] = issue.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap returns the issues as errors, for errors.Is and errors.As
+func (is Issues) Unwrap() []error {
+	errs := make([]error, len(is))
+	for i, issue := range is {
+		errs[i] = issue
+	}
+	return errs
+}
+
+// Sort sorts the issues from the most to the least serious, then by path
+func (is Issues) Sort() {
+	sort.SliceStable(is, func(i, j int) bool {
+		if is[i].Severity != is[j].Severity {
+			return is[i].Severity > is[j].Severity
+		}
+		return is[i].Path < is[j].Path
+	})
+}
+
+// Filter returns the issues of the severity or more serious
+func (is Issues) Filter(severity Severity) Issues {
+	var filtered Issues
+	for _, issue := range is {
+		if issue.Severity >= severity {
+			filtered = append(filtered, issue)
+		}
+	}
+	return filtered
+}
+
+// HasErrors reports whether an issue has the SeverityError severity
+func (is Issues) HasErrors() bool {
+	for _, issue := range is {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package hermes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadOptions are the options of loading emails defined in JSON or YAML files, e.g. written by a content team.
+// Fields are named in snake case, e.g. free_markdown or invite_code_options.
+type LoadOptions struct {
+	Strict bool // Fails on unknown fields, e.g. misspelled ones, instead of ignoring them
+}
+
+// LoadEmailFromJSON returns the email defined by the JSON document of the reader, ignoring unknown fields
+func LoadEmailFromJSON(r io.Reader) (Email, error) {
+	return LoadOptions{}.LoadEmailFromJSON(r)
+}
+
+// LoadEmailFromYAML returns the email defined by the YAML document of the reader, ignoring unknown fields
+func LoadEmailFromYAML(r io.Reader) (Email, error) {
+	return LoadOptions{}.LoadEmailFromYAML(r)
+}
+
+// LoadEmailFromJSON returns the email defined by the JSON document of the reader.
+// json.Marshal writes emails back in the same format.
+func (o LoadOptions) LoadEmailFromJSON(r io.Reader) (Email, error) {
+	var email Email
+	d := json.NewDecoder(r)
+	if o.Strict {
+		d.DisallowUnknownFields()
+	}
+	if err := d.Decode(&email); err != nil {
+		return Email{}, fmt.Errorf("load email: %w", err)
+	}
+	if d.More() {
+		return Email{}, errors.New("load email: data after the JSON document")
+	}
+	return email, nil
+}
+
+// LoadEmailFromYAML returns the email defined by the YAML document of the reader.
+// yaml.Marshal writes emails back in the same format.
+func (o LoadOptions) LoadEmailFromYAML(r io.Reader) (Email, error) {
+	var email Email
+	d := yaml.NewDecoder(r)
+	d.KnownFields(o.Strict)
+	if err := d.Decode(&email); errors.Is(err, io.EOF) {
+		return Email{}, errors.New("load email: empty YAML document")
+	} else if err != nil {
+		return Email{}, fmt.Errorf("load email: %w", err)
+	}
+	return email, nil
+}
@@ -0,0 +1,338 @@
+package hermes
+
+import "strings"
+
+// DefaultLocale is the locale used when a string is not translated in the requested one
+const DefaultLocale = "en"
+
+// locales are the translations of the strings of hermes, by language.
+// Strings may hold placeholders, such as {FIELD} and {VALUE}, and time.* strings are layouts of the time package.
+var locales = map[string]map[string]string{
+	"en": {
+		"default.greeting":     "Hi",
+		"default.signature":    "Yours truly",
+		"default.copyright":    "Copyright © 2024 Hermes. All rights reserved.",
+		"default.trouble_text": "If you’re having trouble with the button '{ACTION}', copy and paste the URL below into your web browser.",
+
+		"language.name": "English",
+
+		"table.empty":        "No data",
+		"footer.unsubscribe": "Unsubscribe",
+
+		"contact.text":  "Questions? Contact us:",
+		"contact.email": "Email",
+		"contact.url":   "Help center",
+
+		"summary.unchanged": "No change",
+
+		"vcard.instructions": "Save {NAME} to your contacts:",
+		"vcard.button":       "Download contact card",
+
+		"weekday.0": "Sunday",
+		"weekday.1": "Monday",
+		"weekday.2": "Tuesday",
+		"weekday.3": "Wednesday",
+		"weekday.4": "Thursday",
+		"weekday.5": "Friday",
+		"weekday.6": "Saturday",
+
+		"time.hour":   "3PM",
+		"time.minute": "3:04PM",
+
+		"reltime.now":          "now",
+		"reltime.future":       "in {TIME}",
+		"reltime.past":         "{TIME} ago",
+		"reltime.minute.one":   "{N} minute",
+		"reltime.minute.other": "{N} minutes",
+		"reltime.hour.one":     "{N} hour",
+		"reltime.hour.other":   "{N} hours",
+		"reltime.day.one":      "{N} day",
+		"reltime.day.other":    "{N} days",
+
+		"shorttext.expires": "Expires {TIME}",
+		"calendar.add":      "Add to calendar",
+		"toc.details":       "Details",
+
+		"validation.entry_value_conflict":      "The entry {FIELD} has both a text value and an HTML value, only one of them can be set.",
+		"validation.button_link_not_absolute":  "The button link {FIELD} must be a complete address, starting with https:// (got \"{VALUE}\").",
+		"validation.no_reply_without_contact":  "Emails sent from {VALUE} cannot be answered: set a reply-to address, or contact instructions telling recipients how to reach you.",
+		"validation.web_font_not_https":        "The web font {FIELD} must be served over https (got \"{VALUE}\").",
+		"validation.too_many_web_fonts":        "Use at most 2 web font files: each of the {VALUE} files slows down the display of the email.",
+		"validation.invalid_text_direction":    "The text direction must be \"ltr\" or \"rtl\" (got \"{VALUE}\").",
+		"validation.button_link_missing":       "The button {FIELD} has a text but no link.",
+		"validation.table_column_mismatch":     "The row {FIELD} has {VALUE} columns, not as many as the first row of its table.",
+		"validation.invalid_logo_url":          "The logo {FIELD} must be a complete image address, starting with https:// (got \"{VALUE}\").",
+		"validation.invalid_mask":              "The mask {FIELD} must be left, right, email or phone, and can only hide a plain value (got \"{VALUE}\").",
+		"validation.invalid_language_variants": "The language variants {FIELD} must list at least one variant, each with its own tag (got \"{VALUE}\").",
+		"validation.brand_name_missing":        "The brand configuration must set {FIELD}, the name of the brand.",
+		"validation.brand_url_not_https":       "The brand configuration {FIELD} must be an absolute https URL (got \"{VALUE}\").",
+		"validation.unsafe_svg":                "The SVG image {FIELD} must not contain scripts nor load external resources.",
+		"validation.invalid_actions_layout":    "The layout {FIELD} must be list, steps or inline, and invite codes need the list or steps layout (got \"{VALUE}\").",
+		"validation.signature_conflict":        "Both {FIELD} and the signature block are set, only the signature block is displayed (got \"{VALUE}\").",
+	},
+	"es": {
+		"default.greeting":     "Hola",
+		"default.signature":    "Atentamente",
+		"default.copyright":    "Copyright © 2024 Hermes. Todos los derechos reservados.",
+		"default.trouble_text": "Si tienes problemas con el botón '{ACTION}', copia y pega la siguiente URL en tu navegador web.",
+
+		"language.name": "Español",
+
+		"table.empty":        "Sin datos",
+		"footer.unsubscribe": "Cancelar suscripción",
+
+		"contact.text":  "¿Preguntas? Contáctanos:",
+		"contact.email": "Correo",
+		"contact.url":   "Centro de ayuda",
+
+		"summary.unchanged": "Sin cambios",
+
+		"vcard.instructions": "Guarda a {NAME} en tus contactos:",
+		"vcard.button":       "Descargar tarjeta de contacto",
+
+		"weekday.0": "domingo",
+		"weekday.1": "lunes",
+		"weekday.2": "martes",
+		"weekday.3": "miércoles",
+		"weekday.4": "jueves",
+		"weekday.5": "viernes",
+		"weekday.6": "sábado",
+
+		"time.hour":   "15:04",
+		"time.minute": "15:04",
+
+		"reltime.now":          "ahora",
+		"reltime.future":       "en {TIME}",
+		"reltime.past":         "hace {TIME}",
+		"reltime.minute.one":   "{N} minuto",
+		"reltime.minute.other": "{N} minutos",
+		"reltime.hour.one":     "{N} hora",
+		"reltime.hour.other":   "{N} horas",
+		"reltime.day.one":      "{N} día",
+		"reltime.day.other":    "{N} días",
+
+		"shorttext.expires": "Caduca {TIME}",
+		"calendar.add":      "Añadir al calendario",
+		"toc.details":       "Detalles",
+
+		"validation.entry_value_conflict":      "La entrada {FIELD} tiene un valor de texto y un valor HTML, solo se puede definir uno de ellos.",
+		"validation.button_link_not_absolute":  "El enlace del botón {FIELD} debe ser una dirección completa, que empiece por https:// (se recibió \"{VALUE}\").",
+		"validation.no_reply_without_contact":  "No se puede responder a los correos enviados desde {VALUE}: define una dirección de respuesta, o instrucciones de contacto que indiquen cómo comunicarse contigo.",
+		"validation.web_font_not_https":        "La fuente web {FIELD} debe servirse por https (se recibió \"{VALUE}\").",
+		"validation.too_many_web_fonts":        "Usa como máximo 2 archivos de fuentes web: cada uno de los {VALUE} archivos ralentiza la visualización del correo.",
+		"validation.invalid_text_direction":    "La dirección del texto debe ser \"ltr\" o \"rtl\" (se recibió \"{VALUE}\").",
+		"validation.button_link_missing":       "El botón {FIELD} tiene un texto pero ningún enlace.",
+		"validation.table_column_mismatch":     "La fila {FIELD} tiene {VALUE} columnas, no tantas como la primera fila de su tabla.",
+		"validation.invalid_logo_url":          "El logo {FIELD} debe ser una dirección de imagen completa, que empiece por https:// (se recibió \"{VALUE}\").",
+		"validation.invalid_mask":              "La máscara {FIELD} debe ser left, right, email o phone, y solo puede ocultar un valor simple (se recibió \"{VALUE}\").",
+		"validation.invalid_language_variants": "Las variantes de idioma {FIELD} deben incluir al menos una variante, cada una con su propia etiqueta (se recibió \"{VALUE}\").",
+		"validation.brand_name_missing":        "La configuración de la marca debe definir {FIELD}, el nombre de la marca.",
+		"validation.brand_url_not_https":       "La configuración de la marca {FIELD} debe ser una URL https absoluta (se recibió \"{VALUE}\").",
+		"validation.unsafe_svg":                "La imagen SVG {FIELD} no debe contener scripts ni cargar recursos externos.",
+		"validation.invalid_actions_layout":    "El diseño {FIELD} debe ser list, steps o inline, y los códigos de invitación requieren el diseño list o steps (se recibió \"{VALUE}\").",
+		"validation.signature_conflict":        "{FIELD} y el bloque de firma están definidos, solo se muestra el bloque de firma (se recibió \"{VALUE}\").",
+	},
+	"fr": {
+		"default.greeting":     "Bonjour",
+		"default.signature":    "Cordialement",
+		"default.copyright":    "Copyright © 2024 Hermes. Tous droits réservés.",
+		"default.trouble_text": "Si vous rencontrez des difficultés avec le bouton « {ACTION} », copiez et collez l’URL ci-dessous dans votre navigateur web.",
+
+		"language.name": "Français",
+
+		"table.empty":        "Aucune donnée",
+		"footer.unsubscribe": "Se désabonner",
+
+		"contact.text":  "Des questions ? Contactez-nous :",
+		"contact.email": "E-mail",
+		"contact.url":   "Centre d'aide",
+
+		"summary.unchanged": "Aucun changement",
+
+		"vcard.instructions": "Enregistrez {NAME} dans vos contacts :",
+		"vcard.button":       "Télécharger la fiche contact",
+
+		"weekday.0": "dimanche",
+		"weekday.1": "lundi",
+		"weekday.2": "mardi",
+		"weekday.3": "mercredi",
+		"weekday.4": "jeudi",
+		"weekday.5": "vendredi",
+		"weekday.6": "samedi",
+
+		"time.hour":   "15:04",
+		"time.minute": "15:04",
+
+		"reltime.now":          "maintenant",
+		"reltime.future":       "dans {TIME}",
+		"reltime.past":         "il y a {TIME}",
+		"reltime.minute.one":   "{N} minute",
+		"reltime.minute.other": "{N} minutes",
+		"reltime.hour.one":     "{N} heure",
+		"reltime.hour.other":   "{N} heures",
+		"reltime.day.one":      "{N} jour",
+		"reltime.day.other":    "{N} jours",
+
+		"shorttext.expires": "Expire {TIME}",
+		"calendar.add":      "Ajouter au calendrier",
+		"toc.details":       "Détails",
+
+		"validation.entry_value_conflict":      "L'entrée {FIELD} a à la fois une valeur texte et une valeur HTML, une seule des deux peut être définie.",
+		"validation.button_link_not_absolute":  "Le lien du bouton {FIELD} doit être une adresse complète, commençant par https:// (reçu « {VALUE} »).",
+		"validation.no_reply_without_contact":  "Il est impossible de répondre aux e-mails envoyés depuis {VALUE} : définissez une adresse de réponse, ou des instructions de contact indiquant comment vous joindre.",
+		"validation.web_font_not_https":        "La police web {FIELD} doit être servie en https (reçu « {VALUE} »).",
+		"validation.too_many_web_fonts":        "Utilisez au plus 2 fichiers de polices web : chacun des {VALUE} fichiers ralentit l’affichage de l’e-mail.",
+		"validation.invalid_text_direction":    "La direction du texte doit être « ltr » ou « rtl » (reçu « {VALUE} »).",
+		"validation.button_link_missing":       "Le bouton {FIELD} a un texte mais pas de lien.",
+		"validation.table_column_mismatch":     "La ligne {FIELD} a {VALUE} colonnes, pas autant que la première ligne de son tableau.",
+		"validation.invalid_logo_url":          "Le logo {FIELD} doit être une adresse d’image complète, commençant par https:// (reçu « {VALUE} »).",
+		"validation.invalid_mask":              "Le masque {FIELD} doit être left, right, email ou phone, et ne peut masquer qu’une valeur simple (reçu « {VALUE} »).",
+		"validation.invalid_language_variants": "Les variantes de langue {FIELD} doivent comprendre au moins une variante, chacune avec sa propre étiquette (reçu « {VALUE} »).",
+		"validation.brand_name_missing":        "La configuration de la marque doit définir {FIELD}, le nom de la marque.",
+		"validation.brand_url_not_https":       "La configuration de la marque {FIELD} doit être une URL https absolue (reçu « {VALUE} »).",
+		"validation.unsafe_svg":                "L’image SVG {FIELD} ne doit contenir ni scripts ni ressources externes.",
+		"validation.invalid_actions_layout":    "La disposition {FIELD} doit être list, steps ou inline, et les codes d’invitation demandent la disposition list ou steps (reçu « {VALUE} »).",
+		"validation.signature_conflict":        "{FIELD} et le bloc de signature sont définis, seul le bloc de signature est affiché (reçu « {VALUE} »).",
+	},
+	"de": {
+		"default.greeting":     "Hallo",
+		"default.signature":    "Mit freundlichen Grüßen",
+		"default.copyright":    "Copyright © 2024 Hermes. Alle Rechte vorbehalten.",
+		"default.trouble_text": "Wenn Sie Probleme mit der Schaltfläche „{ACTION}“ haben, kopieren Sie die folgende URL und fügen Sie sie in Ihren Webbrowser ein.",
+
+		"language.name": "Deutsch",
+
+		"table.empty":        "Keine Daten",
+		"footer.unsubscribe": "Abmelden",
+
+		"contact.text":  "Fragen? Kontaktieren Sie uns:",
+		"contact.email": "E-Mail",
+		"contact.url":   "Hilfe-Center",
+
+		"summary.unchanged": "Unverändert",
+
+		"vcard.instructions": "Speichern Sie {NAME} in Ihren Kontakten:",
+		"vcard.button":       "Kontaktkarte herunterladen",
+
+		"weekday.0": "Sonntag",
+		"weekday.1": "Montag",
+		"weekday.2": "Dienstag",
+		"weekday.3": "Mittwoch",
+		"weekday.4": "Donnerstag",
+		"weekday.5": "Freitag",
+		"weekday.6": "Samstag",
+
+		"time.hour":   "15:04",
+		"time.minute": "15:04",
+
+		"reltime.now":          "jetzt",
+		"reltime.future":       "in {TIME}",
+		"reltime.past":         "vor {TIME}",
+		"reltime.minute.one":   "{N} Minute",
+		"reltime.minute.other": "{N} Minuten",
+		"reltime.hour.one":     "{N} Stunde",
+		"reltime.hour.other":   "{N} Stunden",
+		"reltime.day.one":      "{N} Tag",
+		"reltime.day.other":    "{N} Tagen",
+
+		"shorttext.expires": "Läuft {TIME} ab",
+		"calendar.add":      "Zum Kalender hinzufügen",
+		"toc.details":       "Details",
+
+		"validation.entry_value_conflict":      "Der Eintrag {FIELD} hat sowohl einen Textwert als auch einen HTML-Wert, nur einer von beiden darf gesetzt sein.",
+		"validation.button_link_not_absolute":  "Der Link der Schaltfläche {FIELD} muss eine vollständige Adresse sein, die mit https:// beginnt (erhalten: „{VALUE}“).",
+		"validation.no_reply_without_contact":  "Auf E-Mails von {VALUE} kann nicht geantwortet werden: Legen Sie eine Antwortadresse fest, oder Kontaktinformationen, die erklären, wie man Sie erreicht.",
+		"validation.web_font_not_https":        "Die Webschriftart {FIELD} muss über https bereitgestellt werden (erhalten: „{VALUE}“).",
+		"validation.too_many_web_fonts":        "Verwenden Sie höchstens 2 Webschriftdateien: Jede der {VALUE} Dateien verlangsamt die Anzeige der E-Mail.",
+		"validation.invalid_text_direction":    "Die Textrichtung muss „ltr“ oder „rtl“ sein (erhalten: „{VALUE}“).",
+		"validation.button_link_missing":       "Die Schaltfläche {FIELD} hat einen Text, aber keinen Link.",
+		"validation.table_column_mismatch":     "Die Zeile {FIELD} hat {VALUE} Spalten, nicht so viele wie die erste Zeile ihrer Tabelle.",
+		"validation.invalid_logo_url":          "Das Logo {FIELD} muss eine vollständige Bildadresse sein, die mit https:// beginnt (erhalten: „{VALUE}“).",
+		"validation.invalid_mask":              "Die Maske {FIELD} muss left, right, email oder phone sein und kann nur einen einfachen Wert verbergen (erhalten: „{VALUE}“).",
+		"validation.invalid_language_variants": "Die Sprachvarianten {FIELD} müssen mindestens eine Variante enthalten, jede mit einem eigenen Tag (erhalten: „{VALUE}“).",
+		"validation.brand_name_missing":        "Die Markenkonfiguration muss {FIELD} festlegen, den Namen der Marke.",
+		"validation.brand_url_not_https":       "Die Markenkonfiguration {FIELD} muss eine absolute https-URL sein (erhalten: „{VALUE}“).",
+		"validation.unsafe_svg":                "Das SVG-Bild {FIELD} darf keine Skripte enthalten und keine externen Ressourcen laden.",
+		"validation.invalid_actions_layout":    "Das Layout {FIELD} muss list, steps oder inline sein, und Einladungscodes benötigen das Layout list oder steps (erhalten: „{VALUE}“).",
+		"validation.signature_conflict":        "{FIELD} und der Signaturblock sind gesetzt, nur der Signaturblock wird angezeigt (erhalten: „{VALUE}“).",
+	},
+	"pt": {
+		"default.greeting":     "Olá",
+		"default.signature":    "Atenciosamente",
+		"default.copyright":    "Copyright © 2024 Hermes. Todos os direitos reservados.",
+		"default.trouble_text": "Se você estiver com problemas com o botão '{ACTION}', copie e cole a URL abaixo no seu navegador.",
+
+		"language.name": "Português",
+
+		"table.empty":        "Sem dados",
+		"footer.unsubscribe": "Cancelar inscrição",
+
+		"contact.text":  "Dúvidas? Fale conosco:",
+		"contact.email": "E-mail",
+		"contact.url":   "Central de ajuda",
+
+		"summary.unchanged": "Sem alteração",
+
+		"vcard.instructions": "Salve {NAME} nos seus contatos:",
+		"vcard.button":       "Baixar cartão de contato",
+
+		"weekday.0": "domingo",
+		"weekday.1": "segunda-feira",
+		"weekday.2": "terça-feira",
+		"weekday.3": "quarta-feira",
+		"weekday.4": "quinta-feira",
+		"weekday.5": "sexta-feira",
+		"weekday.6": "sábado",
+
+		"time.hour":   "15:04",
+		"time.minute": "15:04",
+
+		"reltime.now":          "agora",
+		"reltime.future":       "em {TIME}",
+		"reltime.past":         "há {TIME}",
+		"reltime.minute.one":   "{N} minuto",
+		"reltime.minute.other": "{N} minutos",
+		"reltime.hour.one":     "{N} hora",
+		"reltime.hour.other":   "{N} horas",
+		"reltime.day.one":      "{N} dia",
+		"reltime.day.other":    "{N} dias",
+
+		"shorttext.expires": "Expira {TIME}",
+		"calendar.add":      "Adicionar ao calendário",
+		"toc.details":       "Detalhes",
+
+		"validation.entry_value_conflict":      "A entrada {FIELD} tem um valor de texto e um valor HTML, apenas um deles pode ser definido.",
+		"validation.button_link_not_absolute":  "O link do botão {FIELD} deve ser um endereço completo, começando com https:// (recebido \"{VALUE}\").",
+		"validation.no_reply_without_contact":  "Não é possível responder aos e-mails enviados de {VALUE}: defina um endereço de resposta, ou instruções de contato explicando como falar com você.",
+		"validation.web_font_not_https":        "A fonte web {FIELD} deve ser servida por https (recebido \"{VALUE}\").",
+		"validation.too_many_web_fonts":        "Use no máximo 2 arquivos de fontes web: cada um dos {VALUE} arquivos deixa a exibição do e-mail mais lenta.",
+		"validation.invalid_text_direction":    "A direção do texto deve ser \"ltr\" ou \"rtl\" (recebido \"{VALUE}\").",
+		"validation.button_link_missing":       "O botão {FIELD} tem um texto, mas nenhum link.",
+		"validation.table_column_mismatch":     "A linha {FIELD} tem {VALUE} colunas, não tantas quanto a primeira linha da sua tabela.",
+		"validation.invalid_logo_url":          "O logo {FIELD} deve ser um endereço de imagem completo, começando com https:// (recebido \"{VALUE}\").",
+		"validation.invalid_mask":              "A máscara {FIELD} deve ser left, right, email ou phone, e só pode ocultar um valor simples (recebido \"{VALUE}\").",
+		"validation.invalid_language_variants": "As variantes de idioma {FIELD} devem incluir pelo menos uma variante, cada uma com a sua própria etiqueta (recebido \"{VALUE}\").",
+		"validation.brand_name_missing":        "A configuração da marca deve definir {FIELD}, o nome da marca.",
+		"validation.brand_url_not_https":       "A configuração da marca {FIELD} deve ser uma URL https absoluta (recebido \"{VALUE}\").",
+		"validation.unsafe_svg":                "A imagem SVG {FIELD} não deve conter scripts nem carregar recursos externos.",
+		"validation.invalid_actions_layout":    "O layout {FIELD} deve ser list, steps ou inline, e os códigos de convite exigem o layout list ou steps (recebido \"{VALUE}\").",
+		"validation.signature_conflict":        "{FIELD} e o bloco de assinatura estão definidos, apenas o bloco de assinatura é exibido (recebido \"{VALUE}\").",
+	},
+}
+
+// translate returns the string of the key in the locale, e.g. "fr-FR", falling back to its language then to DefaultLocale
+func translate(locale string, key string) string {
+	locale = normalizeLocale(locale)
+	language, _, _ := strings.Cut(locale, "-")
+	for _, l := range []string{locale, language, DefaultLocale} {
+		if s, ok := locales[l][key]; ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// normalizeLocale returns the locale in the form of the keys of locales, e.g. "fr-fr" for "fr_FR"
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+}
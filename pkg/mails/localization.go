@@ -0,0 +1,100 @@
+package hermes
+
+import "strings"
+
+// Localization are the default strings of a locale, applied to the emails and the engine when they are not set.
+// Built-in localizations are en, es, fr, de and pt, others can be added with RegisterLocale.
+type Localization struct {
+	Greeting    string // Default Body.Greeting, e.g. Hi
+	Signature   string // Default Body.Signature, e.g. Yours truly
+	Copyright   string // Default Brand.Copyright
+	TroubleText string // Default Brand.TroubleText, with the {ACTION} placeholder
+	EmptyTable  string // Displayed by the tables without data with ShowEmpty set, e.g. No data
+	Language    string // Name of the language in itself, labeling the language variants of emails, e.g. Nederlands
+	RTL         bool   // Written from right to left: the TextDirection of the engine is rtl unless set
+	// Plurals are the plural forms of phrases by key, e.g. "messages.new", written by Phrase and the plural template
+	// function. They are stored in the locale as key.category strings, e.g. "messages.new.few".
+	Plurals map[string]PluralForms
+}
+
+// Keys of the strings of localizations in locales
+const (
+	keyGreeting    = "default.greeting"
+	keySignature   = "default.signature"
+	keyCopyright   = "default.copyright"
+	keyTroubleText = "default.trouble_text"
+	keyEmptyTable  = "table.empty"
+	keyLanguage    = "language.name"
+	keyDirection   = "direction"
+)
+
+// rtlLanguages are the languages written from right to left, even without registered localization
+var rtlLanguages = map[string]bool{"ar": true, "fa": true, "he": true, "ur": true, "yi": true}
+
+// RegisterLocale adds or replaces the localization of the locale, e.g. "nl" or "nl-BE".
+// Empty strings fall back to the ones of the language, then of DefaultLocale.
+// It must be called before generating emails, e.g. in an init function, it is not safe for concurrent use.
+func RegisterLocale(locale string, l Localization) {
+	locale = normalizeLocale(locale)
+	bundle := locales[locale]
+	if bundle == nil {
+		bundle = map[string]string{}
+		locales[locale] = bundle
+	}
+	for key, value := range map[string]string{
+		keyGreeting:    l.Greeting,
+		keySignature:   l.Signature,
+		keyCopyright:   l.Copyright,
+		keyTroubleText: l.TroubleText,
+		keyEmptyTable:  l.EmptyTable,
+		keyLanguage:    l.Language,
+	} {
+		if value != "" {
+			bundle[key] = value
+		} else {
+			delete(bundle, key)
+		}
+	}
+	for key, forms := range l.Plurals {
+		for _, category := range pluralCategories {
+			if form := forms.get(category); form != "" {
+				bundle[key+"."+category] = form
+			} else {
+				delete(bundle, key+"."+category)
+			}
+		}
+	}
+	if l.RTL {
+		bundle[keyDirection] = "rtl"
+	} else {
+		bundle[keyDirection] = "ltr"
+	}
+}
+
+// localize returns the default values of the compat level in the locale.
+// Past compat levels pin the English strings of their release.
+func localize(defaults compatDefaults, level int, locale string) compatDefaults {
+	if level != CompatLatest {
+		return defaults
+	}
+	defaults.Greeting = translate(locale, keyGreeting)
+	defaults.Signature = translate(locale, keySignature)
+	defaults.Brand.Copyright = translate(locale, keyCopyright)
+	defaults.Brand.TroubleText = translate(locale, keyTroubleText)
+	return defaults
+}
+
+// textDirection returns the direction of the locale, from its localization or its language
+func textDirection(locale string) TextDirection {
+	locale = normalizeLocale(locale)
+	language, _, _ := strings.Cut(locale, "-")
+	for _, l := range []string{locale, language} {
+		if direction, ok := locales[l][keyDirection]; ok {
+			return TextDirection(direction)
+		}
+	}
+	if rtlLanguages[language] {
+		return "rtl"
+	}
+	return "ltr"
+}
@@ -0,0 +1,331 @@
+package hermes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// OutputManifest describes what a rendered email shows and links to, e.g. to review the changes of a template in a
+// pull request without reading the diff of its HTML. Its fields are declared, and so written in JSON, in sorted order.
+type OutputManifest struct {
+	Hash          string           `json:"hash"`          // See Output.Hash
+	HTMLSize      int              `json:"htmlSize"`      // In bytes
+	Images        []ManifestImage  `json:"images"`        // Sorted by source, section and alt text
+	Links         []ManifestLink   `json:"links"`         // Sorted by target, section and text
+	PlainTextSize int              `json:"plainTextSize"` // In bytes
+	Preheader     string           `json:"preheader"`     // Text displayed after the subject by email clients
+	Sections      ManifestSections `json:"sections"`
+	Subject       string           `json:"subject"`
+}
+
+// ManifestLink is a link of a rendered email
+type ManifestLink struct {
+	Href    string `json:"href"`
+	Section string `json:"section"` // Part of the email holding the link, e.g. "action", "footer", see ManifestSections
+	Text    string `json:"text"`    // Collapsed text of the anchor, or the alt text of its images
+}
+
+// ManifestImage is an image of a rendered email
+type ManifestImage struct {
+	Alt     string `json:"alt"`
+	Section string `json:"section"` // Part of the email holding the image, e.g. "header" for the logo
+	Src     string `json:"src"`
+}
+
+// ManifestSections tells which parts of the body a rendered email has
+type ManifestSections struct {
+	Actions         int  `json:"actions"` // Number of actions
+	Calendar        bool `json:"calendar"`
+	Contact         bool `json:"contact"`
+	Dictionary      bool `json:"dictionary"`
+	Intros          int  `json:"intros"` // Number of intro sentences
+	Markdown        bool `json:"markdown"`
+	Outros          int  `json:"outros"` // Number of outro sentences
+	Schedule        bool `json:"schedule"`
+	Summary         bool `json:"summary"`
+	TableOfContents bool `json:"tableOfContents"`
+	Tables          int  `json:"tables"` // Number of tables, those of the markdown left out
+	Unsubscribe     bool `json:"unsubscribe"`
+}
+
+// Manifest returns the manifest of the output, from a single parse of its HTML. It is the same for the same output
+// whatever the run and the version of Go, as are its JSON.
+// The section of the links and the images is given by the nearest marker of the themes, e.g. data-hermes="outro" or
+// class="email-footer", and is "body" without one.
+func (o Output) Manifest() OutputManifest {
+	m := OutputManifest{
+		Hash:          o.Hash(),
+		HTMLSize:      len(o.HTML),
+		Images:        []ManifestImage{},
+		Links:         []ManifestLink{},
+		PlainTextSize: len(o.PlainText),
+		Subject:       o.Subject,
+	}
+	doc, err := html.Parse(strings.NewReader(o.HTML))
+	if err != nil {
+		// html.Parse only fails on errors of its reader
+		return m
+	}
+	var walk func(*html.Node, string, bool)
+	walk = func(n *html.Node, section string, inMarkdown bool) {
+		if n.Type == html.ElementNode {
+			if s := manifestSection(n); s != "" {
+				section = s
+			}
+			marker, _ := attr(n, "data-hermes")
+			m.Sections.count(n, marker, inMarkdown)
+			inMarkdown = inMarkdown || marker == "markdown"
+			switch {
+			case n.Data == "a":
+				if href, ok := attr(n, "href"); ok {
+					m.Links = append(m.Links, ManifestLink{Href: href, Section: section, Text: anchorText(n)})
+				}
+			case n.Data == "img":
+				src, _ := attr(n, "src")
+				alt, _ := attr(n, "alt")
+				m.Images = append(m.Images, ManifestImage{Alt: alt, Section: section, Src: src})
+			case hasClass(n, "preheader") && m.Preheader == "":
+				m.Preheader = preheaderText(n)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, section, inMarkdown)
+		}
+	}
+	walk(doc, "body", false)
+
+	sort.Slice(m.Links, func(i, j int) bool {
+		a, b := m.Links[i], m.Links[j]
+		return compareStrings(a.Href, b.Href, a.Section, b.Section, a.Text, b.Text) < 0
+	})
+	sort.Slice(m.Images, func(i, j int) bool {
+		a, b := m.Images[i], m.Images[j]
+		return compareStrings(a.Src, b.Src, a.Section, b.Section, a.Alt, b.Alt) < 0
+	})
+	return m
+}
+
+// manifestSections are the sections of the manifest by class of the themes, for the parts without data-hermes markers
+var manifestSections = map[string]string{
+	"email-masthead":  "header",
+	"email-footer":    "footer",
+	"body-action":     "action",
+	"body-sub":        "trouble",
+	"body-dictionary": "dictionary",
+	"data-wrapper":    "table",
+}
+
+// manifestSection returns the section of the manifest starting at the element, or an empty string
+func manifestSection(n *html.Node) string {
+	if marker, ok := attr(n, "data-hermes"); ok && marker != "language-variant" && marker != "language-divider" {
+		return marker
+	}
+	classes, _ := attr(n, "class")
+	for _, class := range strings.Fields(classes) {
+		if section, ok := manifestSections[class]; ok {
+			return section
+		}
+	}
+	return ""
+}
+
+// count counts the element in the sections it belongs to
+func (s *ManifestSections) count(n *html.Node, marker string, inMarkdown bool) {
+	switch marker {
+	case "instructions":
+		s.Actions++
+	case "intro":
+		s.Intros++
+	case "outro":
+		s.Outros++
+	case "calendar":
+		s.Calendar = true
+	case "contact":
+		s.Contact = true
+	case "markdown":
+		s.Markdown = true
+	case "schedule":
+		s.Schedule = true
+	case "summary":
+		s.Summary = true
+	case "toc":
+		s.TableOfContents = true
+	case "unsubscribe":
+		s.Unsubscribe = true
+	}
+	if hasClass(n, "body-dictionary") {
+		s.Dictionary = true
+	}
+	if n.Data == "table" && hasClass(n, "data-table") && !inMarkdown {
+		s.Tables++
+	}
+}
+
+// anchorText returns the collapsed text of the anchor, or the alt texts of its images when it has none, e.g. for the
+// social links
+func anchorText(n *html.Node) string {
+	if text := collapsedText(n); text != "" {
+		return text
+	}
+	var alts []string
+	for _, img := range findNodes(n, func(c *html.Node) bool { return c.Data == "img" }) {
+		if alt, _ := attr(img, "alt"); alt != "" {
+			alts = append(alts, alt)
+		}
+	}
+	return strings.Join(alts, " ")
+}
+
+// preheaderText returns the text of the preheader, without the zero-width non-joiners and spaces padding it
+func preheaderText(n *html.Node) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(textContent(n), "\u200c", "")), " ")
+}
+
+// compareStrings compares the pairs of strings in order, like strings.Compare
+func compareStrings(pairs ...string) int {
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if c := strings.Compare(pairs[i], pairs[i+1]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// JSON returns the manifest as indented JSON, without escaping the HTML characters of its texts and URLs
+func (m OutputManifest) JSON() ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// ManifestChangeKind tells how an entry of a manifest changed, see CompareManifests
+type ManifestChangeKind string
+
+// Kinds of changes of manifests
+const (
+	ManifestAdded   ManifestChangeKind = "added"
+	ManifestRemoved ManifestChangeKind = "removed"
+	ManifestChanged ManifestChangeKind = "changed"
+)
+
+// ManifestChange is a difference between two manifests
+type ManifestChange struct {
+	Kind   ManifestChangeKind `json:"kind"`
+	Path   string             `json:"path"`             // e.g. "subject", "sections.actions", "links[footer https://example.com].text"
+	Before string             `json:"before,omitempty"` // Empty for added entries
+	After  string             `json:"after,omitempty"`  // Empty for removed entries
+}
+
+// String returns the change on one line, e.g. `changed subject: "Welcome" -> "Welcome aboard"`
+func (c ManifestChange) String() string {
+	switch c.Kind {
+	case ManifestAdded:
+		return fmt.Sprintf("%s %s: %q", c.Kind, c.Path, c.After)
+	case ManifestRemoved:
+		return fmt.Sprintf("%s %s: %q", c.Kind, c.Path, c.Before)
+	}
+	return fmt.Sprintf("%s %s: %q -> %q", c.Kind, c.Path, c.Before, c.After)
+}
+
+// CompareManifests returns the changes from manifest a to manifest b, sorted by path. Links are identified by their
+// section and target, images by their source: a link moved to another section is removed and added, while a link
+// whose text changed is changed. The hash and the sizes are left out, as any change changes them.
+func CompareManifests(a, b OutputManifest) []ManifestChange {
+	changes := []ManifestChange{}
+	compare := func(path, before, after string) {
+		if before != after {
+			changes = append(changes, ManifestChange{Kind: ManifestChanged, Path: path, Before: before, After: after})
+		}
+	}
+	compare("subject", a.Subject, b.Subject)
+	compare("preheader", a.Preheader, b.Preheader)
+	sa, sb := manifestSectionValues(a.Sections), manifestSectionValues(b.Sections)
+	for name, before := range sa {
+		compare("sections."+name, before, sb[name])
+	}
+
+	links := func(m OutputManifest) map[string]map[string]string {
+		entries := map[string]map[string]string{}
+		for _, link := range m.Links {
+			key := uniqueKey(entries, link.Section+" "+link.Href)
+			entries[key] = map[string]string{"text": link.Text}
+		}
+		return entries
+	}
+	images := func(m OutputManifest) map[string]map[string]string {
+		entries := map[string]map[string]string{}
+		for _, image := range m.Images {
+			key := uniqueKey(entries, image.Src)
+			entries[key] = map[string]string{"alt": image.Alt, "section": image.Section}
+		}
+		return entries
+	}
+	changes = append(changes, compareEntries("links", links(a), links(b))...)
+	changes = append(changes, compareEntries("images", images(a), images(b))...)
+
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// manifestSectionValues returns the sections of a manifest as strings, by JSON name
+func manifestSectionValues(s ManifestSections) map[string]string {
+	return map[string]string{
+		"actions":         strconv.Itoa(s.Actions),
+		"calendar":        strconv.FormatBool(s.Calendar),
+		"contact":         strconv.FormatBool(s.Contact),
+		"dictionary":      strconv.FormatBool(s.Dictionary),
+		"intros":          strconv.Itoa(s.Intros),
+		"markdown":        strconv.FormatBool(s.Markdown),
+		"outros":          strconv.Itoa(s.Outros),
+		"schedule":        strconv.FormatBool(s.Schedule),
+		"summary":         strconv.FormatBool(s.Summary),
+		"tableOfContents": strconv.FormatBool(s.TableOfContents),
+		"tables":          strconv.Itoa(s.Tables),
+		"unsubscribe":     strconv.FormatBool(s.Unsubscribe),
+	}
+}
+
+// uniqueKey returns the key, followed by its occurrence, e.g. "#2", when the entries already have it
+func uniqueKey(entries map[string]map[string]string, key string) string {
+	unique := key
+	for i := 2; entries[unique] != nil; i++ {
+		unique = key + " #" + strconv.Itoa(i)
+	}
+	return unique
+}
+
+// compareEntries returns the entries added, removed and changed from a to b, by key, under the path
+func compareEntries(path string, a, b map[string]map[string]string) []ManifestChange {
+	var changes []ManifestChange
+	for key, before := range a {
+		after, ok := b[key]
+		if !ok {
+			changes = append(changes, ManifestChange{Kind: ManifestRemoved, Path: path + "[" + key + "]", Before: key})
+			continue
+		}
+		for field, value := range before {
+			if after[field] != value {
+				changes = append(changes, ManifestChange{
+					Kind: ManifestChanged, Path: path + "[" + key + "]." + field, Before: value, After: after[field],
+				})
+			}
+		}
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			changes = append(changes, ManifestChange{Kind: ManifestAdded, Path: path + "[" + key + "]", After: key})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,97 @@
+package hermes
+
+import (
+	"html/template"
+
+	"github.com/jaytaylor/html2text"
+	"github.com/russross/blackfriday/v2"
+)
+
+// MarkdownExtensions are the syntax extensions of the markdown of FreeMarkdown
+type MarkdownExtensions int
+
+// Extensions of markdown
+const (
+	MarkdownTables         MarkdownExtensions = 1 << iota // Pipe tables
+	MarkdownStrikethrough                                 // ~~Deleted~~ text
+	MarkdownAutolinks                                     // Links on bare URLs
+	MarkdownFencedCode                                    // Code blocks between ``` lines
+	MarkdownHardLineBreaks                                // Line breaks within paragraphs kept as <br>
+
+	// MarkdownCommonExtensions are the extensions of Markdown.ToHTML
+	MarkdownCommonExtensions = MarkdownTables | MarkdownStrikethrough | MarkdownAutolinks | MarkdownFencedCode
+)
+
+// MarkdownOptions configure the conversion of FreeMarkdown to HTML
+type MarkdownOptions struct {
+	Extensions MarkdownExtensions // Syntax extensions (default to MarkdownCommonExtensions)
+	// Sanitize cleans the HTML converted from markdown, which passes raw HTML through, e.g. when it includes strings of
+	// users. It is off by default: markdown is trusted like the templates of themes.
+	Sanitize  bool
+	Sanitizer Sanitizer // Sanitizer of Sanitize (default to DefaultMarkdownSanitizer)
+}
+
+// DefaultMarkdownSanitizer keeps the HTML markdown converts to: the elements of DefaultSanitizer, headings, rules,
+// tables, definition lists and http(s) images. Scripts, styles, event handlers and other URLs are dropped.
+var DefaultMarkdownSanitizer Sanitizer = markdownSanitizer()
+
+func markdownSanitizer() allowlistSanitizer {
+	s := DefaultSanitizer.(allowlistSanitizer)
+	elements := map[string][]string{
+		"h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil, "hr": nil,
+		"table": nil, "thead": nil, "tbody": nil, "tfoot": nil, "tr": nil, "th": {"align"}, "td": {"align"},
+		"dl": nil, "dt": nil, "dd": nil,
+		"img": {"src", "alt", "title"},
+	}
+	for name, attributes := range s.elements {
+		elements[name] = attributes
+	}
+	s.elements = elements
+	s.urlAttributes = []string{"href", "src"}
+	return s
+}
+
+// blackfriday extensions that markdown always has, the others are given by MarkdownExtensions
+const markdownBaseExtensions = blackfriday.NoIntraEmphasis | blackfriday.SpaceHeadings | blackfriday.HeadingIDs |
+	blackfriday.BackslashLineBreak | blackfriday.DefinitionLists
+
+func (e MarkdownExtensions) blackfriday() blackfriday.Extensions {
+	if e == 0 {
+		e = MarkdownCommonExtensions
+	}
+	extensions := markdownBaseExtensions
+	for extension, flag := range map[MarkdownExtensions]blackfriday.Extensions{
+		MarkdownTables:         blackfriday.Tables,
+		MarkdownStrikethrough:  blackfriday.Strikethrough,
+		MarkdownAutolinks:      blackfriday.Autolink,
+		MarkdownFencedCode:     blackfriday.FencedCode,
+		MarkdownHardLineBreaks: blackfriday.HardLineBreak,
+	} {
+		if e&extension != 0 {
+			extensions |= flag
+		}
+	}
+	return extensions
+}
+
+// MarkdownToHTML converts the markdown to HTML following the MarkdownOptions of the engine.
+// Themes call it as {{ .Hermes.MarkdownToHTML .Email.Body.FreeMarkdown }}, Markdown.ToHTML ignoring the options.
+func (h Hermes) MarkdownToHTML(m Markdown) template.HTML {
+	options := h.MarkdownOptions
+	out := string(blackfriday.Run([]byte(string(m)), blackfriday.WithExtensions(options.Extensions.blackfriday())))
+	if options.Sanitize {
+		sanitizer := options.Sanitizer
+		if sanitizer == nil {
+			sanitizer = DefaultMarkdownSanitizer
+		}
+		out = sanitizer.Sanitize(out)
+	}
+	return template.HTML(out)
+}
+
+// MarkdownToText converts the markdown to plain text, through the HTML of MarkdownToHTML so that it is sanitized the
+// same way, with its tables drawn in ASCII. Native plain text templates call it as
+// {{ .Hermes.MarkdownToText .Email.Body.FreeMarkdown }}, see PlainTextTheme.
+func (h Hermes) MarkdownToText(m Markdown) (string, error) {
+	return html2text.FromString(string(h.MarkdownToHTML(m)), html2text.Options{PrettyTables: true})
+}
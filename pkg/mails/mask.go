@@ -0,0 +1,181 @@
+package hermes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Masks of Entry.Mask, hiding part of sensitive values such as card numbers, API keys or phone numbers
+const (
+	MaskLeft  = "left"  // Masks all but the last letters and digits, 4 by default or N with "left:N", e.g. "•••• •••• •••• 4242"
+	MaskRight = "right" // Masks all but the first letters and digits, 4 by default or N with "right:N", e.g. "sk_l•••••••••"
+	MaskEmail = "email" // Masks the local part of an address but its first letter or digit, e.g. "j••.••••@example.com"
+	MaskPhone = "phone" // Masks all but the last 4 digits, e.g. "+• (•••) •••-4567"
+)
+
+// MaskRune replaces the masked letters and digits. Values containing it, or an ellipsis, are already masked, and are
+// left as they are by the masks.
+const MaskRune = '•'
+
+// defaultMaskKeep is the number of letters or digits kept by the left and right masks without N
+const defaultMaskKeep = 4
+
+// maskLeft masks the letters and digits of s but the last n, keeping separators so that the value stays readable.
+// All of them are masked when s has no more than n, so that short values are never shown in full.
+func maskLeft(n int, s string) string {
+	return maskRunes(s, n, false, isLetterOrDigit)
+}
+
+// maskRight masks the letters and digits of s but the first n, all of them when s has no more than n
+func maskRight(n int, s string) string {
+	return maskRunes(s, n, true, isLetterOrDigit)
+}
+
+// maskEmail masks the local part of the address but its first letter or digit, the domain being kept.
+// Values which are not addresses are masked entirely.
+func maskEmail(s string) string {
+	if masked(s) {
+		return s
+	}
+	i := strings.LastIndex(s, "@")
+	if i < 0 {
+		return maskRunes(s, 0, true, isLetterOrDigit)
+	}
+	return maskRunes(s[:i], 1, true, isLetterOrDigit) + s[i:]
+}
+
+// maskPhone masks the digits of the phone number but the last 4
+func maskPhone(s string) string {
+	return maskRunes(s, defaultMaskKeep, false, unicode.IsDigit)
+}
+
+// maskRunes replaces the maskable runes of s by MaskRune, but the first or last keep ones when s has more than keep
+func maskRunes(s string, keep int, first bool, maskable func(rune) bool) string {
+	if masked(s) {
+		return s
+	}
+	runes := []rune(s)
+	count := 0
+	for _, r := range runes {
+		if maskable(r) {
+			count++
+		}
+	}
+	if keep < 0 || count <= keep {
+		keep = 0
+	}
+	seen := 0
+	for i, r := range runes {
+		if !maskable(r) {
+			continue
+		}
+		seen++
+		if first && seen <= keep || !first && seen > count-keep {
+			continue
+		}
+		runes[i] = MaskRune
+	}
+	return string(runes)
+}
+
+func masked(s string) bool {
+	return strings.ContainsRune(s, MaskRune) || strings.ContainsRune(s, '…')
+}
+
+func isLetterOrDigit(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// maskFunc returns the function of the mask of Entry.Mask, or false when it is unknown
+func maskFunc(mask string) (func(string) string, bool) {
+	name, n, hasN := strings.Cut(mask, ":")
+	keep := defaultMaskKeep
+	if hasN {
+		var err error
+		if keep, err = strconv.Atoi(n); err != nil || keep < 0 {
+			return nil, false
+		}
+	}
+	switch {
+	case name == MaskLeft:
+		return func(s string) string { return maskLeft(keep, s) }, true
+	case name == MaskRight:
+		return func(s string) string { return maskRight(keep, s) }, true
+	case name == MaskEmail && !hasN:
+		return maskEmail, true
+	case name == MaskPhone && !hasN:
+		return maskPhone, true
+	}
+	return nil, false
+}
+
+// checkMask returns why the mask of the entry cannot be applied, or an empty string
+func checkMask(entry Entry) string {
+	if entry.Mask == "" {
+		return ""
+	}
+	if _, ok := maskFunc(entry.Mask); !ok {
+		return fmt.Sprintf("unknown mask %q", entry.Mask)
+	}
+	if entry.HTMLValue != "" {
+		return "HTMLValue cannot be masked"
+	}
+	return ""
+}
+
+// maskEmailEntries returns the email of which the values of the entries with a mask, in the dictionary and the tables,
+// are masked. The lists of the body are copied only when an entry has a mask, those of the caller are never written.
+func maskEmailEntries(email Email) (Email, error) {
+	var err error
+	// mask returns the entries with their values masked, copied when one of them has a mask
+	mask := func(path string, entries []Entry) ([]Entry, bool) {
+		copied := false
+		for i, entry := range entries {
+			if entry.Mask == "" || err != nil {
+				continue
+			}
+			if message := checkMask(entry); message != "" {
+				err = fmt.Errorf("%s[%d].Mask: %s", path, i, message)
+				return entries, false
+			}
+			if !copied {
+				entries, copied = append([]Entry(nil), entries...), true
+			}
+			fn, _ := maskFunc(entry.Mask)
+			entries[i].Value = fn(entry.Value)
+		}
+		return entries, copied
+	}
+	maskTable := func(path string, table Table) Table {
+		rowsCopied := false
+		for i, row := range table.Data {
+			row, copied := mask(fmt.Sprintf("%s.Data[%d]", path, i), row)
+			if !copied {
+				continue
+			}
+			if !rowsCopied {
+				table.Data, rowsCopied = append([][]Entry(nil), table.Data...), true
+			}
+			table.Data[i] = row
+		}
+		table.Footer, _ = mask(path+".Footer", table.Footer)
+		return table
+	}
+
+	body := &email.Body
+	body.Dictionary, _ = mask("Body.Dictionary", body.Dictionary)
+	body.Table = maskTable("Body.Table", body.Table)
+	if len(body.Tables) > 0 {
+		tables := make([]Table, len(body.Tables))
+		for i, table := range body.Tables {
+			tables[i] = maskTable(fmt.Sprintf("Body.Tables[%d]", i), table)
+		}
+		body.Tables = tables
+	}
+	if err != nil {
+		return Email{}, err
+	}
+	return email, nil
+}
@@ -0,0 +1,53 @@
+package hermes
+
+// MetricsSink receives the metrics of the engine, its caches and senders, e.g. to export them to Prometheus, see
+// Hermes.Metrics. Series are identified by their name and labels, listed with the Metric constants.
+// Implementations must be safe for concurrent use.
+type MetricsSink interface {
+	Count(name string, labels map[string]string, delta float64)   // Adds delta to a counter
+	Observe(name string, labels map[string]string, value float64) // Records a value in a histogram
+}
+
+// Metrics of MetricsSink, and their labels
+const (
+	MetricRenderDuration = "hermes_render_duration_seconds" // Histogram of the renderings of RenderContext, by "theme"
+	MetricRenderErrors   = "hermes_render_errors_total"     // Counter of failed renderings, by "stage": a stage of the pipeline, "prepare", "plain_text" or "subject"
+	MetricOutputSize     = "hermes_output_size_bytes"       // Histogram of the bodies rendered by RenderContext, by "theme" and "part", "html" or "text"
+	MetricCacheLookups   = "hermes_cache_lookups_total"     // Counter of cache lookups, by "cache", "template", "render" or "engine", and "result", "hit" or "miss"
+	MetricSendAttempts   = "hermes_send_attempts_total"     // Counter of messages given to senders, by "sender" and "result", "sent" or "failed"
+)
+
+// NopMetrics is a MetricsSink discarding metrics, the default
+type NopMetrics struct{}
+
+func (NopMetrics) Count(string, map[string]string, float64)   {}
+func (NopMetrics) Observe(string, map[string]string, float64) {}
+
+// metrics returns the MetricsSink of the engine, or NopMetrics
+func (h *Hermes) metrics() MetricsSink {
+	if h.Metrics == nil {
+		return NopMetrics{}
+	}
+	return h.Metrics
+}
+
+// countCacheLookup counts a lookup of the cache in the sink
+func countCacheLookup(m MetricsSink, cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.Count(MetricCacheLookups, map[string]string{"cache": cache, "result": result}, 1)
+}
+
+// countRenderError counts a rendering failing at the stage in the sink
+func countRenderError(m MetricsSink, stage string) {
+	m.Count(MetricRenderErrors, map[string]string{"stage": stage}, 1)
+}
+
+// observeRender records the duration and the sizes of the rendering in the sink
+func observeRender(m MetricsSink, stats RenderStats) {
+	m.Observe(MetricRenderDuration, map[string]string{"theme": stats.Theme}, stats.Duration.Seconds())
+	m.Observe(MetricOutputSize, map[string]string{"theme": stats.Theme, "part": "html"}, float64(stats.HTMLSize))
+	m.Observe(MetricOutputSize, map[string]string{"theme": stats.Theme, "part": "text"}, float64(stats.PlainTextSize))
+}
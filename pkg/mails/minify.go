@@ -0,0 +1,181 @@
+package hermes
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// GmailClipSize is the size of the HTML body in bytes beyond which Gmail clips emails, hiding their end behind a
+// "View entire message" link
+const GmailClipSize = 102400
+
+// IssueHTMLTooLarge is the code of the warning of HTML bodies larger than Hermes.ClipSize, see Output.Warnings
+const IssueHTMLTooLarge = "html_too_large"
+
+// Size returns the size of the HTML body in bytes, the one Gmail compares to GmailClipSize
+func (o Output) Size() int {
+	return len(o.HTML)
+}
+
+// clipWarning returns the warning of an HTML body larger than ClipSize, if any
+func (h *Hermes) clipWarning(html string) (Issue, bool) {
+	size := h.ClipSize
+	if size == 0 {
+		size = GmailClipSize
+	}
+	if size < 0 || len(html) <= size {
+		return Issue{}, false
+	}
+	return Issue{
+		Code:     IssueHTMLTooLarge,
+		Severity: SeverityWarning,
+		Message:  fmt.Sprintf("HTML body is %d bytes, beyond %d bytes Gmail clips it: set MinifyHTML or shorten the email", len(html), size),
+	}, true
+}
+
+func minifyHTML(r *Rendering) error {
+	// The plain text version is converted from the HTML, where whitespace is collapsed anyway
+	if !r.Hermes.MinifyHTML || r.PlainText {
+		return nil
+	}
+	r.HTML = Minify(r.HTML)
+	return nil
+}
+
+// Minify minifies the HTML email: whitespace is collapsed, and dropped next to block elements, comments are removed
+// except the conditional comments of Outlook like <!--[if mso]>, and redundant attributes like type="text/css" are
+// removed.
+// The text of pre, textarea and script elements is kept as is, and the CSS of style elements is minified.
+func Minify(s string) string {
+	m := minifier{}
+	m.b.Grow(len(s))
+	z := html.NewTokenizer(strings.NewReader(s))
+	preformatted := 0  // Depth of the pre and textarea elements
+	var rawText string // Element of the text, among the raw text elements
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			// The end of the input, the tokenizer never fails on a string
+			return m.b.String()
+		}
+		raw := string(z.Raw())
+		switch tt {
+		case html.TextToken:
+			switch {
+			case rawText == "style":
+				m.write(minifyCSS(raw), false)
+			case rawText == "script" || preformatted > 0:
+				m.write(raw, false)
+			default:
+				m.writeText(raw)
+			}
+		case html.CommentToken:
+			// Conditional comments hold the markup of Outlook, whose whitespace is collapsed as well
+			if data := z.Token().Data; strings.HasPrefix(data, "[if") || strings.Contains(data, "[endif]") {
+				m.write(strings.Join(strings.FieldsFunc(raw, isHTMLSpace), " "), true)
+			}
+		case html.DoctypeToken:
+			m.write(raw, true)
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			token := z.Token()
+			switch token.Data {
+			case "pre", "textarea":
+				if tt == html.StartTagToken {
+					preformatted++
+				} else if tt == html.EndTagToken && preformatted > 0 {
+					preformatted--
+				}
+			}
+			rawText = ""
+			if tt == html.StartTagToken && (token.Data == "style" || token.Data == "script") {
+				rawText = token.Data
+			}
+			m.write(minifyTag(token, raw), blockElements[token.Data])
+		}
+	}
+}
+
+// minifier writes tokens with the whitespace between them collapsed
+type minifier struct {
+	b     strings.Builder
+	space bool // Whitespace was collapsed, to be written before the next token unless it is a block element
+	block bool // The last token written is a block element, after which whitespace is dropped
+}
+
+func (m *minifier) write(token string, block bool) {
+	if m.space && !block && !m.block && m.b.Len() > 0 {
+		m.b.WriteByte(' ')
+	}
+	m.b.WriteString(token)
+	m.space, m.block = false, block
+}
+
+func (m *minifier) writeText(text string) {
+	words := strings.FieldsFunc(text, isHTMLSpace)
+	if len(words) == 0 {
+		m.space = m.space || text != ""
+		return
+	}
+	m.space = m.space || isHTMLSpace(rune(text[0]))
+	m.write(strings.Join(words, " "), false)
+	m.space = isHTMLSpace(rune(text[len(text)-1]))
+}
+
+// isHTMLSpace reports whether the character is whitespace in HTML, unlike no-break spaces
+func isHTMLSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f'
+}
+
+// blockElements are the elements around which whitespace is not rendered
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "body": true, "br": true, "center": true,
+	"dd": true, "div": true, "dl": true, "dt": true, "figure": true, "footer": true, "form": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "head": true, "header": true, "hr": true, "html": true, "li": true,
+	"link": true, "meta": true, "nav": true, "ol": true, "p": true, "section": true, "style": true, "table": true,
+	"tbody": true, "td": true, "tfoot": true, "th": true, "thead": true, "title": true, "tr": true, "ul": true,
+}
+
+// minifyTag returns the tag without its redundant attributes, or as written when it has none
+func minifyTag(token html.Token, raw string) string {
+	attrs := token.Attr[:0:0]
+	for _, a := range token.Attr {
+		if !redundantAttribute(token.Data, a) {
+			attrs = append(attrs, a)
+		}
+	}
+	if len(attrs) == len(token.Attr) {
+		return raw
+	}
+	token.Attr = attrs
+	return token.String()
+}
+
+// redundantAttribute reports whether the attribute of the element has no effect
+func redundantAttribute(element string, a html.Attribute) bool {
+	switch a.Key {
+	case "class", "style", "id":
+		return strings.TrimSpace(a.Val) == ""
+	case "type":
+		return element == "style" && strings.EqualFold(a.Val, "text/css") || element == "script" && strings.EqualFold(a.Val, "text/javascript")
+	case "media":
+		return element == "style" && a.Val == "all"
+	case "rel":
+		return element == "style"
+	}
+	return false
+}
+
+var cssComment = regexp.MustCompile(`(?s)/\*.*?\*/`)
+
+// minifyCSS removes the comments and the whitespace of the CSS which are not needed
+func minifyCSS(css string) string {
+	css = cssComment.ReplaceAllString(css, "")
+	css = strings.Join(strings.FieldsFunc(css, isHTMLSpace), " ")
+	for _, c := range []string{"{", "}", ";"} {
+		css = strings.ReplaceAll(strings.ReplaceAll(css, " "+c, c), c+" ", c)
+	}
+	return strings.ReplaceAll(css, ";}", "}")
+}
@@ -0,0 +1,98 @@
+package hermes
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+// NormalizeStage normalizes the outputs, see Normalize. The plain text version is normalized once converted, with
+// NormalizeText. The outputs of the past compat levels are left as they were rendered by their release.
+var NormalizeStage = Stage{Name: StageNormalize, Run: normalizeOutput}
+
+// bom is the byte order mark, which pasted content often starts with
+const bom = "\uFEFF"
+
+// whitespace is trimmed at the end of the outputs, and at the end of the lines of plain text versions
+const whitespace = " \t\r\n"
+
+// lineBreaks converts the CRLF and CR line breaks to LF
+var lineBreaks = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// Normalize removes the byte order marks of the output, converts its line breaks to \n, and ends it with exactly one
+// line break, so that identical emails always render to the same bytes. Line breaks are converted to \r\n when the
+// message is written, by the MIME builders. An empty output stays empty.
+func Normalize(s string) string {
+	s = strings.TrimRight(lineBreaks.Replace(strings.ReplaceAll(s, bom, "")), whitespace)
+	if s == "" {
+		return ""
+	}
+	return s + "\n"
+}
+
+// NormalizeText normalizes the plain text output like Normalize, and removes the trailing whitespace of its lines,
+// which DKIM signatures with the simple canonicalization do not survive
+func NormalizeText(s string) string {
+	lines := strings.Split(Normalize(s), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func normalizeOutput(r *Rendering) error {
+	// The plain text version is normalized once converted, see generatePlainText
+	if r.PlainText || r.Hermes.CompatLevel != CompatLatest {
+		return nil
+	}
+	r.HTML = Normalize(r.HTML)
+	return nil
+}
+
+// normalizes reports whether the pipeline normalizes the outputs of the engine
+func (p *Pipeline) normalizes(h *Hermes) bool {
+	return h.CompatLevel == CompatLatest && p.index(StageNormalize) >= 0
+}
+
+// normalizer writes the output of a template normalized like Normalize, for the streamed renderings. The whitespace at
+// the end of each write, and a byte order mark it may cut, are held back until the next one: Close writes the final
+// line break.
+type normalizer struct {
+	w       io.Writer
+	pending []byte
+	written bool
+}
+
+func (n *normalizer) Write(p []byte) (int, error) {
+	data := append(n.pending, p...)
+	// An incomplete byte order mark is completed by the next write
+	incomplete := 0
+	switch {
+	case bytes.HasSuffix(data, []byte(bom[:2])):
+		incomplete = 2
+	case bytes.HasSuffix(data, []byte(bom[:1])):
+		incomplete = 1
+	}
+	complete := bytes.ReplaceAll(data[:len(data)-incomplete], []byte(bom), nil)
+	end := len(bytes.TrimRight(complete, whitespace))
+	n.pending = append(complete[end:len(complete):len(complete)], data[len(data)-incomplete:]...)
+	if end == 0 {
+		return len(p), nil
+	}
+	n.written = true
+	if _, err := io.WriteString(n.w, lineBreaks.Replace(string(complete[:end]))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close writes what is left of the output, and its final line break
+func (n *normalizer) Close() error {
+	rest := bytes.TrimRight(bytes.ReplaceAll(n.pending, []byte(bom), nil), whitespace)
+	n.pending = nil
+	if !n.written && len(rest) == 0 {
+		return nil
+	}
+	_, err := io.WriteString(n.w, lineBreaks.Replace(string(rest))+"\n")
+	return err
+}
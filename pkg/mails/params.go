@@ -0,0 +1,112 @@
+package hermes
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// expandEmailParams returns the email with the {name} placeholders of its button links, dictionary values
+// and intros replaced by its Params, and its Phrases formatted in the locale. Emails with neither are returned as they
+// are, {{ and }} included. Slices are copied, the given email is left untouched. Values are URL-encoded in links;
+// elsewhere they are HTML-escaped by the templates.
+func expandEmailParams(email Email, locale string, strict bool) (Email, error) {
+	params := email.Params
+	if len(email.Phrases) > 0 {
+		params = make(map[string]string, len(email.Phrases)+len(email.Params))
+		for name, phrase := range email.Phrases {
+			params[name] = phrase.Format(locale)
+		}
+		for name, value := range email.Params {
+			params[name] = value
+		}
+	}
+	if params == nil {
+		return email, nil
+	}
+	var err error
+	expand := func(path string, s string, escape func(string) string) string {
+		if err != nil {
+			return s
+		}
+		var expanded string
+		expanded, err = expandParams(s, params, escape, strict)
+		if err != nil {
+			err = fmt.Errorf("%s: %v", path, err)
+		}
+		return expanded
+	}
+
+	body := &email.Body
+	body.Intros = append([]string(nil), body.Intros...)
+	for i, intro := range body.Intros {
+		body.Intros[i] = expand(fmt.Sprintf("Body.Intros[%d]", i), intro, nil)
+	}
+	body.Dictionary = append([]Entry(nil), body.Dictionary...)
+	for i, entry := range body.Dictionary {
+		body.Dictionary[i].Value = expand(fmt.Sprintf("Body.Dictionary[%d].Value", i), entry.Value, nil)
+	}
+	body.Actions = append([]Action(nil), body.Actions...)
+	for i, action := range body.Actions {
+		body.Actions[i].Button.Link = expand(fmt.Sprintf("Body.Actions[%d].Button.Link", i), action.Button.Link, urlEscape)
+	}
+
+	return email, err
+}
+
+// urlEscape encodes a value for any part of a URL
+func urlEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// expandParams replaces the {name} placeholders of s with the escaped params.
+// {{ and }} are literal braces. Unknown placeholders are kept, or reported in strict mode.
+func expandParams(s string, params map[string]string, escape func(string) string, strict bool) (string, error) {
+	if !strings.ContainsAny(s, "{}") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c == '{' || c == '}') && i+1 < len(s) && s[i+1] == c {
+			b.WriteByte(c)
+			i++
+			continue
+		}
+		if c != '{' {
+			b.WriteByte(c)
+			continue
+		}
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 || !isParamName(s[i+1:i+end]) {
+			b.WriteByte(c)
+			continue
+		}
+		name := s[i+1 : i+end]
+		value, ok := params[name]
+		switch {
+		case ok && escape != nil:
+			b.WriteString(escape(value))
+		case ok:
+			b.WriteString(value)
+		case strict:
+			return "", fmt.Errorf("unresolved placeholder {%s}", name)
+		default:
+			b.WriteString(s[i : i+end+1])
+		}
+		i += end
+	}
+	return b.String(), nil
+}
+
+func isParamName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r == '_' || r == '-' || r == '.' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,450 @@
+package hermes
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ParseWarning is a part of an HTML email that ParseEmail could not map exactly to the Email structure
+type ParseWarning struct {
+	Path    string // Element path in the HTML document
+	Message string
+}
+
+// ErrNotHermesEmail is returned when parsing an HTML document that was not generated by a built-in theme
+var ErrNotHermesEmail = errors.New("not an email generated by hermes")
+
+// ParseEmail extracts, on a best-effort basis, the Email from an HTML email generated by a built-in theme.
+// Structures that cannot be recognized are kept as FreeMarkdown, with a warning.
+func ParseEmail(htmlEmail string) (Email, []ParseWarning, error) {
+	p, err := newEmailParser(htmlEmail)
+	if err != nil {
+		return Email{}, nil, err
+	}
+	p.parseBody()
+	return p.email, p.warnings, nil
+}
+
+// ParseBranding extracts the Branding from an HTML email generated by a built-in theme
+func ParseBranding(htmlEmail string) (Branding, error) {
+	p, err := newEmailParser(htmlEmail)
+	if err != nil {
+		return Branding{}, err
+	}
+	p.parseBody()
+	p.parseBranding()
+	return p.brand, nil
+}
+
+type emailParser struct {
+	doc      *html.Node
+	content  *html.Node
+	email    Email
+	brand    Branding
+	warnings []ParseWarning
+	unknown  bytes.Buffer
+}
+
+func newEmailParser(htmlEmail string) (*emailParser, error) {
+	doc, err := html.Parse(strings.NewReader(htmlEmail))
+	if err != nil {
+		return nil, err
+	}
+	content := findNode(doc, func(n *html.Node) bool { return hasClass(n, "content-cell") })
+	if content == nil {
+		return nil, ErrNotHermesEmail
+	}
+	return &emailParser{doc: doc, content: content}, nil
+}
+
+func (p *emailParser) warn(n *html.Node, format string, args ...interface{}) {
+	p.warnings = append(p.warnings, ParseWarning{Path: nodePath(n), Message: fmt.Sprintf(format, args...)})
+}
+
+func (p *emailParser) parseBody() {
+	body := &p.email.Body
+	for n := p.content.FirstChild; n != nil; n = n.NextSibling {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		marker, _ := attr(n, "data-hermes")
+		switch {
+		case n.Data == "h1":
+			if greeting, ok := attr(n, "data-hermes-greeting"); ok {
+				body.Greeting = greeting
+				name := strings.TrimPrefix(collapsedText(n), greeting)
+				body.Name = strings.TrimSpace(strings.TrimSuffix(name, ","))
+			} else {
+				body.Title = collapsedText(n)
+			}
+		case marker == "intro":
+			body.Intros = append(body.Intros, collapsedText(n))
+		case marker == "outro":
+			body.Outros = append(body.Outros, collapsedText(n))
+		case marker == "markdown":
+			p.warn(n, "free markdown content is kept as HTML")
+			body.FreeMarkdown = Markdown(strings.TrimSpace(innerHTML(n)))
+		case marker == "instructions":
+			body.Actions = append(body.Actions, Action{Instructions: collapsedText(n)})
+		case marker == "schedule":
+			p.warn(n, "schedule is ignored, its times are localized")
+		case marker == "summary":
+			p.warn(n, "summary is ignored, its values are formatted")
+		case hasClass(n, "summary-title"), hasClass(n, "summary-highlights"):
+			// Highlights of the summary, ignored along with it
+		case marker == "contact":
+			p.parseContact(n)
+		case marker == "signature":
+			p.parseSignature(n)
+		case n.Data == "dl" && hasClass(n, "body-dictionary"):
+			p.parseDictionary(n)
+		case n.Data == "table" && hasClass(n, "data-wrapper"):
+			p.parseTable(n)
+		case n.Data == "table" && hasClass(n, "body-step"):
+			p.parseStep(n)
+		case n.Data == "table" && hasClass(n, "body-action--inline"):
+			p.parseInlineActions(n)
+		case n.Data == "table" && hasClass(n, "body-action"):
+			p.parseAction(n)
+		case n.Data == "table" && hasClass(n, "body-sub"):
+			p.parseTroubleText(n)
+		default:
+			p.warn(n, "unknown structure <%s> is kept as free markdown", n.Data)
+			html.Render(&p.unknown, n)
+		}
+	}
+
+	if p.unknown.Len() > 0 {
+		body.FreeMarkdown = Markdown(strings.TrimSpace(string(body.FreeMarkdown) + "\n" + p.unknown.String()))
+	}
+}
+
+func (p *emailParser) parseContact(n *html.Node) {
+	contact := &ContactInstructions{}
+	if text := findNode(n, func(c *html.Node) bool { return c.Data == "span" }); text != nil {
+		contact.Text = collapsedText(text)
+	}
+	for _, a := range findNodes(n, func(c *html.Node) bool { return c.Data == "a" }) {
+		href, _ := attr(a, "href")
+		if strings.HasPrefix(href, "mailto:") {
+			contact.Email = strings.TrimPrefix(href, "mailto:")
+		} else {
+			contact.URL = href
+		}
+	}
+	p.email.Body.ContactInstructions = contact
+}
+
+func (p *emailParser) parseSignature(n *html.Node) {
+	if n.Data == "table" && hasClass(n, "signature-block") {
+		p.parseSignatureBlock(n)
+		return
+	}
+	var lines []string
+	var line strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == "br" {
+			lines = append(lines, line.String())
+			line.Reset()
+			continue
+		}
+		line.WriteString(textContent(c))
+	}
+	lines = append(lines, line.String())
+
+	p.email.Body.Signature = strings.TrimSuffix(strings.Join(strings.Fields(lines[0]), " "), ",")
+	if len(lines) > 1 {
+		p.brand.Name = strings.Join(strings.Fields(strings.Join(lines[1:], " ")), " ")
+	}
+}
+
+// parseSignatureBlock parses the block of SignatureBlock. The width of the image is kept unless it is the default one,
+// widths above MaxSignatureImageWidth are lost.
+func (p *emailParser) parseSignatureBlock(n *html.Node) {
+	block := &SignatureBlock{}
+	find := func(class string) *html.Node {
+		return findNode(n, func(n *html.Node) bool { return hasClass(n, class) })
+	}
+	if closing := find("signature-closing"); closing != nil {
+		block.Closing = strings.TrimSuffix(collapsedText(closing), ",")
+	}
+	if name := findNode(n, func(n *html.Node) bool { return n.Data == "strong" }); name != nil {
+		block.Name = collapsedText(name)
+	}
+	if title := find("signature-title"); title != nil {
+		block.Title = collapsedText(title)
+	}
+	if image := findNode(n, func(n *html.Node) bool { return n.Data == "img" }); image != nil {
+		block.ImageURL, _ = attr(image, "src")
+		width, _ := attr(image, "width")
+		if width, err := strconv.Atoi(width); err == nil && width != DefaultSignatureImageWidth {
+			block.ImageWidth = width
+		}
+	}
+	p.email.Body.SignatureBlock = block
+}
+
+func (p *emailParser) parseDictionary(n *html.Node) {
+	var entry *Entry
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.Type != html.ElementNode:
+		case c.Data == "dt":
+			p.email.Body.Dictionary = append(p.email.Body.Dictionary, Entry{Key: strings.TrimSuffix(collapsedText(c), ":")})
+			entry = &p.email.Body.Dictionary[len(p.email.Body.Dictionary)-1]
+		case c.Data == "dd" && entry != nil:
+			entry.Value, entry.HTMLValue = entryValue(c)
+			entry = nil
+		default:
+			p.warn(c, "unexpected <%s> in dictionary is ignored", c.Data)
+		}
+	}
+}
+
+func (p *emailParser) parseTable(n *html.Node) {
+	parsed := Table{}
+	if title := findNode(n, func(n *html.Node) bool { return hasClass(n, "data-title") }); title != nil {
+		parsed.Title = collapsedText(title)
+	}
+	table := findNode(n, func(n *html.Node) bool { return hasClass(n, "data-table") })
+	if table == nil {
+		if findNode(n, func(n *html.Node) bool { return hasClass(n, "data-empty") }) == nil {
+			p.warn(n, "table without data is ignored")
+			return
+		}
+		parsed.ShowEmpty = true
+		p.addTable(parsed)
+		return
+	}
+	var keys []string
+	columns := Columns{CustomWidth: map[string]string{}, CustomAlignment: map[string]string{}}
+	for _, tr := range findNodes(table, func(n *html.Node) bool { return n.Type == html.ElementNode && n.Data == "tr" }) {
+		var row []Entry
+		for c := tr.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			switch c.Data {
+			case "th":
+				key := collapsedText(c)
+				keys = append(keys, key)
+				if width, ok := attr(c, "width"); ok {
+					columns.CustomWidth[key] = width
+				}
+				if align := textAlign(c); align != "" && align != "left" {
+					columns.CustomAlignment[key] = align
+				}
+			case "td":
+				if len(row) >= len(keys) {
+					p.warn(c, "cell without column is ignored")
+					continue
+				}
+				value, htmlValue := entryValue(c)
+				row = append(row, Entry{Key: keys[len(row)], Value: value, HTMLValue: htmlValue})
+			}
+		}
+		if row != nil {
+			parsed.Data = append(parsed.Data, row)
+		}
+	}
+	if len(columns.CustomWidth) > 0 {
+		parsed.Columns.CustomWidth = columns.CustomWidth
+	}
+	if len(columns.CustomAlignment) > 0 {
+		parsed.Columns.CustomAlignment = columns.CustomAlignment
+	}
+
+	p.addTable(parsed)
+}
+
+// addTable adds the table to the body. The first table is Table, as for emails generated before Tables.
+func (p *emailParser) addTable(table Table) {
+	if len(p.email.Body.Table.Data) == 0 && !p.email.Body.Table.ShowEmpty && len(p.email.Body.Tables) == 0 {
+		p.email.Body.Table = table
+	} else {
+		p.email.Body.Tables = append(p.email.Body.Tables, table)
+	}
+}
+
+func (p *emailParser) parseAction(n *html.Node) {
+	actions := p.email.Body.Actions
+	if len(actions) == 0 {
+		p.warn(n, "action without instructions")
+		actions = append(actions, Action{})
+	}
+	action := &actions[len(actions)-1]
+	if button := findNode(n, func(n *html.Node) bool { return n.Data == "a" && hasClass(n, "button") }); button != nil {
+		action.Button.Text = collapsedText(button)
+		action.Button.Link, _ = attr(button, "href")
+		style, _ := attr(button, "style")
+		for _, decl := range parseDeclarations(style) {
+			switch decl.property {
+			case "background-color":
+				action.Button.Color = strings.ToUpper(decl.value)
+			case "color":
+				action.Button.TextColor = strings.ToUpper(decl.value)
+			}
+		}
+		// Colors from the stylesheets of the bundled themes are not part of the email
+		switch action.Button.Color {
+		case "#3869D4", "#4A4A4A", "#00948D":
+			action.Button.Color = ""
+		}
+		if action.Button.TextColor == "#FFFFFF" {
+			action.Button.TextColor = ""
+		}
+	}
+	if code := findNode(n, func(n *html.Node) bool { return hasClass(n, "invite-code") }); code != nil {
+		action.InviteCode = collapsedText(code)
+	}
+	p.email.Body.Actions = actions
+}
+
+// parseStep parses an action of the steps layout: its title, its instructions and its button
+func (p *emailParser) parseStep(n *html.Node) {
+	body := &p.email.Body
+	body.ActionsLayout = ActionsSteps
+	content := findNode(n, func(n *html.Node) bool { return hasClass(n, "step-content") })
+	if content == nil {
+		p.warn(n, "step without content")
+		return
+	}
+	var title string
+	if t := findNode(content, func(n *html.Node) bool { return hasClass(n, "step-title") }); t != nil {
+		title = collapsedText(t)
+	}
+	if instructions := findNode(content, func(n *html.Node) bool {
+		marker, _ := attr(n, "data-hermes")
+		return marker == "instructions"
+	}); instructions != nil {
+		body.Actions = append(body.Actions, Action{Instructions: collapsedText(instructions)})
+	}
+	if action := findNode(content, func(n *html.Node) bool { return n.Data == "table" && hasClass(n, "body-action") }); action != nil {
+		p.parseAction(action)
+	}
+	if len(body.Actions) > 0 {
+		body.Actions[len(body.Actions)-1].Title = title
+	}
+}
+
+// parseInlineActions parses the buttons of the inline layout, given in order to the actions of the instructions before
+// them. Actions without instructions cannot be told apart, their buttons go to the first actions.
+func (p *emailParser) parseInlineActions(n *html.Node) {
+	body := &p.email.Body
+	body.ActionsLayout = ActionsInline
+	buttons := findNodes(n, func(n *html.Node) bool { return n.Data == "a" && hasClass(n, "button") })
+	for i, button := range buttons {
+		if i == len(body.Actions) {
+			body.Actions = append(body.Actions, Action{})
+		}
+		body.Actions[i].Button.Text = collapsedText(button)
+		body.Actions[i].Button.Link, _ = attr(button, "href")
+	}
+}
+
+func (p *emailParser) parseTroubleText(n *html.Node) {
+	sub := findNode(n, func(n *html.Node) bool { return n.Data == "p" && hasClass(n, "sub") })
+	if sub == nil {
+		return
+	}
+	text := collapsedText(sub)
+	for _, action := range p.email.Body.Actions {
+		if action.Button.Text != "" && strings.Contains(text, action.Button.Text) {
+			p.brand.TroubleText = strings.Replace(text, action.Button.Text, "{ACTION}", 1)
+			return
+		}
+	}
+}
+
+func (p *emailParser) parseBranding() {
+	if masthead := findNode(p.doc, func(n *html.Node) bool { return hasClass(n, "email-masthead_name") }); masthead != nil {
+		p.brand.Link, _ = attr(masthead, "href")
+		if logo := findNode(masthead, func(n *html.Node) bool { return n.Data == "img" }); logo != nil {
+			p.brand.Logo, _ = attr(logo, "src")
+		} else if name := collapsedText(masthead); name != "" {
+			p.brand.Name = name
+		}
+	}
+	if footer := findNode(p.doc, func(n *html.Node) bool { return hasClass(n, "email-footer") }); footer != nil {
+		if copyright := findNode(footer, func(n *html.Node) bool { return n.Data == "p" }); copyright != nil {
+			p.brand.Copyright = collapsedText(copyright)
+		}
+	}
+}
+
+// textAlign returns the last text-align of the style attribute, which is the one applying after CSS inlining
+func textAlign(n *html.Node) string {
+	style, _ := attr(n, "style")
+	align := ""
+	for _, decl := range parseDeclarations(style) {
+		if decl.property == "text-align" {
+			align = decl.value
+		}
+	}
+	return align
+}
+
+func hasClass(n *html.Node, class string) bool {
+	classes, _ := attr(n, "class")
+	for _, c := range strings.Fields(classes) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+func findNode(n *html.Node, match func(*html.Node) bool) *html.Node {
+	if nodes := findNodes(n, match); len(nodes) > 0 {
+		return nodes[0]
+	}
+	return nil
+}
+
+// findNodes returns the element nodes matching in document order, without looking into matching nodes
+func findNodes(n *html.Node, match func(*html.Node) bool) []*html.Node {
+	var nodes []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && match(c) {
+			nodes = append(nodes, c)
+			continue
+		}
+		nodes = append(nodes, findNodes(c, match)...)
+	}
+	return nodes
+}
+
+// entryValue returns the value of a dictionary or table entry, as HTML when it holds markup other than bidi isolation
+func entryValue(n *html.Node) (string, template.HTML) {
+	markup := findNode(n, func(c *html.Node) bool { return c.Data != "bdi" })
+	if markup != nil {
+		return "", template.HTML(strings.TrimSpace(innerHTML(n)))
+	}
+	return collapsedText(n), ""
+}
+
+func collapsedText(n *html.Node) string {
+	return strings.Join(strings.Fields(textContent(n)), " ")
+}
+
+func innerHTML(n *html.Node) string {
+	var b bytes.Buffer
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		html.Render(&b, c)
+	}
+	return b.String()
+}
+
+func nodePath(n *html.Node) string {
+	var names []string
+	for ; n != nil && n.Type == html.ElementNode; n = n.Parent {
+		names = append([]string{nodeName(n)}, names...)
+	}
+	return strings.Join(names, " > ")
+}
@@ -0,0 +1,347 @@
+package hermes
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Names of the built-in stages
+const (
+	StageTemplateExecute = "TemplateExecute" // Executes the template of the theme, always first
+	StageInline          = "Inline"          // Inlines CSS in the HTML version, unless DisableCSSInlining is set
+	StageMinifyHTML      = "MinifyHTML"      // Minifies the HTML version when MinifyHTML is set
+	StageTrackLinks      = "TrackLinks"      // Adds TrackingParams to the links and rewrites them with LinkRewriter, when set
+	StageCheckResources  = "CheckResources"  // Checks the hosts of the external resources when AllowedResourceHosts is set
+	StageNormalize       = "Normalize"       // Normalizes the byte order marks, line breaks and trailing whitespace
+)
+
+// Pipeline is the ordered list of stages rendering an email, both its HTML and its plain text versions.
+// The plain text version is converted from the output of the pipeline, unless the theme is a PlainTextTheme.
+type Pipeline struct {
+	Stages []Stage
+	Stats  *PipelineStats // Collects the time spent in each stage when set
+}
+
+// Stage is a step of a Pipeline
+type Stage struct {
+	Name string
+	Run  func(r *Rendering) error
+}
+
+// Rendering is an email going through a Pipeline
+type Rendering struct {
+	Hermes    *Hermes
+	Email     Email  // Email with its default values
+	PlainText bool   // Whether the plain text version of the email is rendered
+	HTML      string // Output of the previous stages, to be updated by the stage
+	Warnings  Issues // Problems worked around by the stages, e.g. images given an alt text by AutoAltText
+
+	ctx      context.Context
+	template string
+	native   bool     // Whether template is a native text template, see PlainTextTheme
+	sources  []string // Sources of the values annotated by AnnotateSources, by index
+}
+
+// Context returns the context of the rendering, checked between stages. Long stages should stop when it is done.
+// It is never nil.
+func (r *Rendering) Context() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+	return r.ctx
+}
+
+// TemplateExecuteStage executes the template of the theme.
+// The HTML values of entries are sanitized here, so that no pipeline can skip it.
+var TemplateExecuteStage = Stage{Name: StageTemplateExecute, Run: executeTemplate}
+
+// InlineStage inlines CSS in the HTML version, unless DisableCSSInlining is set
+var InlineStage = Stage{Name: StageInline, Run: inlineCSS}
+
+// MinifyStage minifies the HTML version when MinifyHTML is set, see Minify
+var MinifyStage = Stage{Name: StageMinifyHTML, Run: minifyHTML}
+
+// DefaultPipeline returns the pipeline used when Hermes.Pipeline is not set
+func DefaultPipeline() *Pipeline {
+	return &Pipeline{Stages: []Stage{TemplateExecuteStage, InlineStage, MinifyStage, TrackLinksStage, CheckResourcesStage, NormalizeStage}}
+}
+
+var defaultPipeline = DefaultPipeline()
+
+// stageDependencies lists, by built-in stage, the built-in stages which must run before it when both are in a pipeline
+var stageDependencies = map[string][]string{
+	StageMinifyHTML:     {StageInline},                                   // Minifies the HTML as it is sent, with its styles inlined
+	StageCheckResources: {StageInline, StageTrackLinks},                  // Checks the URLs of inlined styles and rewritten links
+	StageNormalize:      {StageInline, StageMinifyHTML, StageTrackLinks}, // Normalizes the bytes the other stages write
+}
+
+// Validate checks that the stages can run in their order: TemplateExecute first, and the built-in stages in the order
+// of DefaultPipeline, e.g. MinifyHTML after Inline, and TrackLinks before CheckResources and Normalize. Custom stages can
+// be anywhere after TemplateExecute.
+func (p *Pipeline) Validate() error {
+	if len(p.Stages) == 0 || p.Stages[0].Name != StageTemplateExecute {
+		return fmt.Errorf("pipeline: %s must be the first stage", StageTemplateExecute)
+	}
+	names := map[string]bool{}
+	for i, s := range p.Stages {
+		switch {
+		case s.Name == "":
+			return fmt.Errorf("pipeline: stage %d has no name", i)
+		case s.Run == nil:
+			return fmt.Errorf("pipeline: stage %q has no Run function", s.Name)
+		case names[s.Name]:
+			return fmt.Errorf("pipeline: stage %q appears twice", s.Name)
+		}
+		names[s.Name] = true
+	}
+	for i, s := range p.Stages {
+		for _, dependency := range stageDependencies[s.Name] {
+			if p.index(dependency) > i {
+				return fmt.Errorf("pipeline: stage %q must run after %q", s.Name, dependency)
+			}
+		}
+	}
+	return nil
+}
+
+// InsertBefore inserts the stage before the one with the given name
+func (p *Pipeline) InsertBefore(name string, stage Stage) error {
+	i := p.index(name)
+	if i < 0 {
+		return fmt.Errorf("pipeline: unknown stage %q", name)
+	}
+	p.Stages = append(p.Stages[:i], append([]Stage{stage}, p.Stages[i:]...)...)
+	return nil
+}
+
+// InsertAfter inserts the stage after the one with the given name
+func (p *Pipeline) InsertAfter(name string, stage Stage) error {
+	i := p.index(name)
+	if i < 0 {
+		return fmt.Errorf("pipeline: unknown stage %q", name)
+	}
+	p.Stages = append(p.Stages[:i+1], append([]Stage{stage}, p.Stages[i+1:]...)...)
+	return nil
+}
+
+// Remove removes the stage with the given name
+func (p *Pipeline) Remove(name string) error {
+	i := p.index(name)
+	if i < 0 {
+		return fmt.Errorf("pipeline: unknown stage %q", name)
+	}
+	p.Stages = append(p.Stages[:i], p.Stages[i+1:]...)
+	return nil
+}
+
+func (p *Pipeline) index(name string) int {
+	for i, s := range p.Stages {
+		if s.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (p *Pipeline) run(r *Rendering) (string, error) {
+	if err := p.Validate(); err != nil {
+		return "", err
+	}
+	for _, s := range p.Stages {
+		if err := r.Context().Err(); err != nil {
+			return "", err
+		}
+		start := time.Now()
+		if err := s.Run(r); err != nil {
+			countRenderError(r.Hermes.metrics(), s.Name)
+			return "", err
+		}
+		if p.Stats != nil {
+			p.Stats.record(s.Name, time.Since(start))
+		}
+	}
+	return r.HTML, nil
+}
+
+// runTo runs the pipeline and writes its output to w.
+// When no stage changes the output of the template, i.e. with the built-in stages and CSS inlining disabled,
+// the template is executed straight into w instead of being buffered, normalized on the fly.
+func (p *Pipeline) runTo(r *Rendering, w io.Writer) error {
+	if !p.streams(r) {
+		html, err := p.run(r)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, html)
+		return err
+	}
+	if err := r.Context().Err(); err != nil {
+		return err
+	}
+	start := time.Now()
+	var n *normalizer
+	if p.normalizes(r.Hermes) {
+		n = &normalizer{w: w}
+		w = n
+	}
+	if err := r.execute(w); err != nil {
+		countRenderError(r.Hermes.metrics(), StageTemplateExecute)
+		return err
+	}
+	if n != nil {
+		if err := n.Close(); err != nil {
+			return err
+		}
+	}
+	if p.Stats != nil {
+		// Skipped stages are counted as well, so that the stats do not depend on the way emails are generated
+		p.Stats.record(StageTemplateExecute, time.Since(start))
+		for _, s := range p.Stages[1:] {
+			p.Stats.record(s.Name, 0)
+		}
+	}
+	return nil
+}
+
+// streams reports whether only the built-in stages run, and the Inline, MinifyHTML, TrackLinks and CheckResources ones
+// leave the output as is.
+// The Normalize stage is run by the writer of runTo.
+// Built-in stages are identified by their name.
+func (p *Pipeline) streams(r *Rendering) bool {
+	if p.Validate() != nil || (r.Hermes.AutoAltText && !r.PlainText) || r.Hermes.AnnotateSources {
+		return false
+	}
+	for _, s := range p.Stages[1:] {
+		switch {
+		case s.Name == StageInline && (r.Hermes.DisableCSSInlining || r.PlainText):
+		case s.Name == StageMinifyHTML && (!r.Hermes.MinifyHTML || r.PlainText):
+		case s.Name == StageTrackLinks && !r.Hermes.tracksLinks():
+		case s.Name == StageCheckResources && len(r.Hermes.AllowedResourceHosts) == 0:
+		case s.Name == StageNormalize:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// PipelineStats collects the time spent in each stage of a Pipeline. It is safe for concurrent use.
+type PipelineStats struct {
+	mu     sync.Mutex
+	stages map[string]StageStats
+}
+
+// StageStats are the counters of a stage
+type StageStats struct {
+	Runs     uint64
+	Duration time.Duration // Total time spent in the stage
+}
+
+// Stages returns the counters of the stages, by name
+func (s *PipelineStats) Stages() map[string]StageStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stages := make(map[string]StageStats, len(s.stages))
+	for name, stats := range s.stages {
+		stages[name] = stats
+	}
+	return stages
+}
+
+func (s *PipelineStats) record(name string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stages == nil {
+		s.stages = map[string]StageStats{}
+	}
+	stats := s.stages[name]
+	stats.Runs++
+	stats.Duration += d
+	s.stages[name] = stats
+}
+
+func executeTemplate(r *Rendering) error {
+	var b bytes.Buffer
+	if err := r.execute(&b); err != nil {
+		return err
+	}
+	r.HTML = b.String()
+	if r.sources != nil && !r.PlainText {
+		// The plain text version is annotated once converted, see generatePlainText
+		r.HTML = annotateHTML(r.HTML, r.sources)
+	}
+	if !r.PlainText {
+		r.Warnings = append(r.Warnings, r.Email.warnings...)
+	}
+	if r.Hermes.AutoAltText && !r.PlainText {
+		var warnings Issues
+		r.HTML, warnings = addAltTexts(r.HTML)
+		r.Warnings = append(r.Warnings, warnings...)
+	}
+	return nil
+}
+
+// execute executes the template of the theme into w
+func (r *Rendering) execute(w io.Writer) error {
+	// HTML values are rendered raw, they must never skip sanitization
+	email := sanitizeEntries(markdownEntries(r.Email, r.Hermes), r.Hermes.sanitizer())
+
+	t, err := r.Hermes.parsedTemplate(r.template, r.PlainText, r.native)
+	if err != nil {
+		return err
+	}
+	h := *r.Hermes
+	if h.AnnotateSources {
+		h.Brand, email, r.sources = annotateSources(h.Brand, email, h.CompatLevel, h.Locale)
+	}
+	return t.Execute(w, Template{h, email})
+}
+
+func inlineCSS(r *Rendering) error {
+	// The plain text version is converted from the HTML, its styles are dropped anyway
+	if r.Hermes.DisableCSSInlining || r.PlainText {
+		return nil
+	}
+
+	if r.Context().Done() == nil {
+		return inlineCSSNow(r)
+	}
+	// Premailer cannot be interrupted: it runs on its own, and is abandoned when the context is done
+	type result struct {
+		html string
+		err  error
+	}
+	done := make(chan result, 1)
+	html, opts := r.HTML, r.Hermes.CSSInliningOptions
+	go func() {
+		inlined, err := transformCSS(html, opts)
+		if err != nil {
+			err = diagnoseCSS(html, opts, err)
+		}
+		done <- result{inlined, err}
+	}()
+	select {
+	case res := <-done:
+		if res.err != nil {
+			return res.err
+		}
+		r.HTML = res.html
+		return nil
+	case <-r.Context().Done():
+		return r.Context().Err()
+	}
+}
+
+// inlineCSSNow inlines the CSS of the HTML version, for contexts which are never done
+func inlineCSSNow(r *Rendering) error {
+	html, err := transformCSS(r.HTML, r.Hermes.CSSInliningOptions)
+	if err != nil {
+		return diagnoseCSS(r.HTML, r.Hermes.CSSInliningOptions, err)
+	}
+	r.HTML = html
+	return nil
+}
@@ -0,0 +1,138 @@
+package hermes
+
+import (
+	"bytes"
+	"strings"
+	"text/tabwriter"
+	texttemplate "text/template"
+
+	"github.com/jaytaylor/html2text"
+)
+
+// PlainTextTheme is implemented by themes with a native plain text template: a text/template whose output is the plain
+// text version as is, without HTML escaping nor conversion from HTML, so that buttons, tables and long URLs are written
+// the way the theme wants them. Trailing spaces and runs of blank lines are removed from the output.
+//
+// The plain text version of other themes, or of themes returning an empty template, is converted from the HTML of
+// their PlainTextTemplate, see PlainTextOptions. So is the one of a VersionedTheme pinned to a past release by
+// Hermes.CompatLevel.
+type PlainTextTheme interface {
+	Theme
+	TextTemplate() string // The text/template of plain text emails, or an empty string to convert PlainTextTemplate
+}
+
+// PlainTextOptions tune the conversion of the plain text templates of themes from HTML, for the themes which are not
+// a PlainTextTheme
+type PlainTextOptions struct {
+	DisablePrettyTables bool // Writes the cells of tables one after the other, instead of drawing ASCII tables
+	OmitLinks           bool // Drops the URLs of links, keeping their text
+	TextOnly            bool // Drops the markers of headings, lists and emphasis
+}
+
+// html2textOptions returns the options of html2text
+func (o PlainTextOptions) html2textOptions() html2text.Options {
+	return html2text.Options{PrettyTables: !o.DisablePrettyTables, OmitLinks: o.OmitLinks, TextOnly: o.TextOnly}
+}
+
+// plainTextTemplate returns the plain text template of the theme at the compat level of the engine, and whether it is
+// a native text template, see PlainTextTheme
+func (h *Hermes) plainTextTemplate() (string, bool) {
+	versioned, isVersioned := h.Theme.(VersionedTheme)
+	if t, ok := h.Theme.(PlainTextTheme); ok && (!isVersioned || h.CompatLevel == CompatLatest) {
+		if text := t.TextTemplate(); text != "" {
+			return text, true
+		}
+	}
+	if isVersioned {
+		return versioned.PlainTextTemplateAt(h.CompatLevel), false
+	}
+	return h.Theme.PlainTextTemplate(), false
+}
+
+// parseTextTemplate parses a native plain text template, see PlainTextTheme
+func parseTextTemplate(tplt string, funcs texttemplate.FuncMap) (*texttemplate.Template, error) {
+	return texttemplate.New("hermes").Funcs(funcs).Parse(tplt)
+}
+
+// tidyText removes the trailing spaces of the lines of a native plain text version, and its runs of blank lines
+func tidyText(text string) string {
+	lines := strings.Split(text, "\n")
+	tidy := lines[:0]
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" && (len(tidy) == 0 || tidy[len(tidy)-1] == "") {
+			continue
+		}
+		tidy = append(tidy, line)
+	}
+	return strings.TrimRight(strings.Join(tidy, "\n"), "\n")
+}
+
+// textEntries writes the entries as lines of key and value, the values aligned, e.g. for the dictionary in plain text
+func textEntries(entries []Entry, textDirection TextDirection) (string, error) {
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 0, 1, ' ', 0)
+	for _, entry := range entries {
+		value, err := entryText(entry, textDirection)
+		if err != nil {
+			return "", err
+		}
+		w.Write([]byte(textCellEscaper.Replace(entry.Key) + ":\t" + value + "\n"))
+	}
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// textTable writes the table as aligned columns under their keys, followed by its footer under a rule
+func textTable(table Table, textDirection TextDirection) (string, error) {
+	if len(table.Data) == 0 {
+		return "", nil
+	}
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	var keys, rules []string
+	for _, column := range table.Data[0] {
+		key := textCellEscaper.Replace(column.Key)
+		keys = append(keys, key)
+		rules = append(rules, strings.Repeat("-", len([]rune(key))))
+	}
+	w.Write([]byte(strings.Join(keys, "\t") + "\n" + strings.Join(rules, "\t") + "\n"))
+	rows := table.Data
+	if footer := table.FooterRow(); footer != nil {
+		// The totals follow a rule, like the keys
+		rows = append(rows[:len(rows):len(rows)], nil, footer)
+	}
+	for _, row := range rows {
+		if row == nil {
+			w.Write([]byte(strings.Join(rules, "\t") + "\n"))
+			continue
+		}
+		cells := make([]string, len(row))
+		for i, entry := range row {
+			cell, err := textCell(entry, textDirection)
+			if err != nil {
+				return "", err
+			}
+			cells[i] = cell
+		}
+		w.Write([]byte(strings.Join(cells, "\t") + "\n"))
+	}
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// textCellEscaper replaces the characters breaking the lines and the columns of tabwriter
+var textCellEscaper = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ", "\t", " ")
+
+// textCell returns the value of the entry in plain text, on a single line
+func textCell(entry Entry, textDirection TextDirection) (string, error) {
+	if entry.HTMLValue != "" {
+		text, err := fragmentText(entry.HTMLValue)
+		return textCellEscaper.Replace(text), err
+	}
+	return textCellEscaper.Replace(isolateText(entry.Value, entry.Bidi, textDirection)), nil
+}
@@ -0,0 +1,170 @@
+package hermes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+// PluralForms are the forms of a phrase by CLDR plural category, e.g. One "{N} new message" and Other "{N} new messages".
+// {N} is replaced by the count. Languages use some of the categories only: English one and other, Polish one, few,
+// many and other, Arabic all of them, Japanese other. Missing forms fall back to Other.
+type PluralForms struct {
+	Zero  string `json:"zero,omitempty" yaml:"zero,omitempty"`
+	One   string `json:"one,omitempty" yaml:"one,omitempty"`
+	Two   string `json:"two,omitempty" yaml:"two,omitempty"`
+	Few   string `json:"few,omitempty" yaml:"few,omitempty"`
+	Many  string `json:"many,omitempty" yaml:"many,omitempty"`
+	Other string `json:"other,omitempty" yaml:"other,omitempty"`
+}
+
+// Phrase is a sentence varying with a count, e.g. "You have 3 new messages", given in the Phrases of an email to be
+// written in its fields like params, see Email.Phrases. Its form is selected by the CLDR plural rules of the locale.
+type Phrase struct {
+	Count int         `json:"count" yaml:"count"`
+	Key   string      `json:"key,omitempty" yaml:"key,omitempty"`     // Key of the plural forms of the locales, e.g. "messages.new", see Localization.Plurals
+	Forms PluralForms `json:"forms,omitempty" yaml:"forms,omitempty"` // Forms of the phrase, replacing the ones of Key which are set
+}
+
+// pluralCategories are the CLDR plural categories, as named in the keys of locales, e.g. "messages.new.few"
+var pluralCategories = map[plural.Form]string{
+	plural.Zero:  "zero",
+	plural.One:   "one",
+	plural.Two:   "two",
+	plural.Few:   "few",
+	plural.Many:  "many",
+	plural.Other: "other",
+}
+
+// Format returns the form of the phrase for its count in the locale, e.g. "pl", with {N} replaced by the count
+func (p Phrase) Format(locale string) string {
+	forms := p.Forms
+	if p.Key != "" {
+		forms = localizedPluralForms(locale, p.Key).merge(forms)
+	}
+	return strings.ReplaceAll(forms.form(pluralCategory(locale, p.Count)), "{N}", strconv.Itoa(p.Count))
+}
+
+// form returns the form of the category, or Other when it is not set
+func (f PluralForms) form(category plural.Form) string {
+	if form := f.get(pluralCategories[category]); form != "" {
+		return form
+	}
+	return f.Other
+}
+
+// get returns the form of the category, named as in the keys of locales, e.g. "few"
+func (f PluralForms) get(category string) string {
+	switch category {
+	case "zero":
+		return f.Zero
+	case "one":
+		return f.One
+	case "two":
+		return f.Two
+	case "few":
+		return f.Few
+	case "many":
+		return f.Many
+	case "other":
+		return f.Other
+	}
+	return ""
+}
+
+// merge returns the forms replaced by the ones of override which are set
+func (f PluralForms) merge(override PluralForms) PluralForms {
+	for _, pair := range []struct{ form, override *string }{
+		{&f.Zero, &override.Zero}, {&f.One, &override.One}, {&f.Two, &override.Two},
+		{&f.Few, &override.Few}, {&f.Many, &override.Many}, {&f.Other, &override.Other},
+	} {
+		if *pair.override != "" {
+			*pair.form = *pair.override
+		}
+	}
+	return f
+}
+
+// set sets the form of the category, named as in the keys of locales, e.g. "few"
+func (f *PluralForms) set(category string, form string) bool {
+	switch category {
+	case "zero":
+		f.Zero = form
+	case "one":
+		f.One = form
+	case "two":
+		f.Two = form
+	case "few":
+		f.Few = form
+	case "many":
+		f.Many = form
+	case "other":
+		f.Other = form
+	default:
+		return false
+	}
+	return true
+}
+
+// pluralCategory returns the CLDR plural category of the count in the locale (DefaultLocale when empty), Other for
+// unknown locales
+func pluralCategory(locale string, count int) plural.Form {
+	if locale == "" {
+		locale = DefaultLocale
+	}
+	tag, err := language.Parse(normalizeLocale(locale))
+	if err != nil {
+		return plural.Other
+	}
+	if count < 0 {
+		count = -count
+	}
+	return plural.Cardinal.MatchPlural(tag, count, 0, 0, 0, 0)
+}
+
+// localizedPluralForms returns the plural forms of the key in the locale, or in the first of its language and
+// DefaultLocale declaring some. Forms are not mixed between locales: the missing ones fall back to Other.
+func localizedPluralForms(locale string, key string) PluralForms {
+	locale = normalizeLocale(locale)
+	lang, _, _ := strings.Cut(locale, "-")
+	for _, l := range []string{locale, lang, DefaultLocale} {
+		var forms PluralForms
+		found := false
+		for _, category := range pluralCategories {
+			if form, ok := locales[l][key+"."+category]; ok {
+				forms.set(category, form)
+				found = true
+			}
+		}
+		if found {
+			return forms
+		}
+	}
+	return PluralForms{}
+}
+
+// pluralize returns the phrase of the count in the locale, from the forms of the key in locales
+func pluralize(locale string, key string, count int) string {
+	return Phrase{Count: count, Key: key}.Format(locale)
+}
+
+// pluralFunc is the plural template function: plural locale count key, or plural locale count "one=..." "other=...",
+// e.g. {{ plural .Hermes.Locale 3 "one={N} new message" "other={N} new messages" }}. Forms given as category=form pairs
+// replace the ones of the key, when both are given.
+func pluralFunc(locale string, count int, args ...string) (string, error) {
+	p := Phrase{Count: count}
+	for _, arg := range args {
+		category, form, ok := strings.Cut(arg, "=")
+		if !ok {
+			p.Key = arg
+			continue
+		}
+		if !p.Forms.set(category, form) {
+			return "", fmt.Errorf("plural: unknown plural category %q, want zero, one, two, few, many or other", category)
+		}
+	}
+	return p.Format(locale), nil
+}
@@ -0,0 +1,156 @@
+package hermes
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	"github.com/imdario/mergo"
+)
+
+// DefaultEnginePoolSize is the number of engines kept by an EnginePool unless resized
+const DefaultEnginePoolSize = 128
+
+// EnginePool caches engines derived from a base engine, one per brand and locale.
+// Engines are compiled once and shared, the least recently used ones being evicted when the pool is full.
+// Evicted engines stay usable by the renders holding them.
+// An EnginePool is safe for concurrent use.
+type EnginePool struct {
+	base Hermes
+
+	mu      sync.Mutex
+	size    int
+	engines map[poolKey]*list.Element
+	lru     *list.List // Most recently used engines first
+	stats   PoolStats
+}
+
+// PoolStats are the counters of an EnginePool, for monitoring
+type PoolStats struct {
+	Hits      uint64 // Engines found in the pool
+	Misses    uint64 // Engines built because they were not in the pool
+	Evictions uint64 // Engines evicted to make room for others
+	Engines   int    // Engines currently in the pool
+}
+
+// poolKey identifies an engine of the pool. Branding is not comparable, nor printable without the addresses of its
+// pointers, its JSON encoding is used instead.
+type poolKey struct {
+	brand  string
+	locale string
+}
+
+type poolEntry struct {
+	key    poolKey
+	brand  Branding
+	locale string
+	once   sync.Once
+	engine *Hermes
+	err    error // Error compiling the engine
+}
+
+// NewEnginePool creates a pool of engines derived from the base one
+func NewEnginePool(base Hermes) *EnginePool {
+	return &EnginePool{
+		base:    base,
+		size:    DefaultEnginePoolSize,
+		engines: map[poolKey]*list.Element{},
+		lru:     list.New(),
+	}
+}
+
+// Resize changes the maximum number of engines kept by the pool, evicting the least recently used ones if needed
+func (p *EnginePool) Resize(size int) {
+	if size < 1 {
+		size = 1
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.size = size
+	p.evict()
+}
+
+// Get returns the engine of the brand and locale, building it on first use.
+// Empty fields of the brand, and an empty locale, default to the ones of the base engine.
+// The returned engine is compiled and must not be modified. The error of its compilation, e.g. of the templates of
+// the theme, is returned on every use of the engine.
+func (p *EnginePool) Get(brand Branding, locale string) (*Hermes, error) {
+	if locale == "" {
+		locale = p.base.Locale
+	}
+	// Brands only hold strings and numbers, their encoding can't fail
+	encoded, _ := json.Marshal(brand)
+	key := poolKey{string(encoded), locale}
+
+	p.mu.Lock()
+	e, ok := p.engines[key]
+	countCacheLookup(p.base.metrics(), "engine", ok)
+	if ok {
+		p.stats.Hits++
+		p.lru.MoveToFront(e)
+	} else {
+		p.stats.Misses++
+		// The brand is copied, so that the caller can't change the pointers of the engine
+		brand = deepCopy(reflect.ValueOf(brand)).Interface().(Branding)
+		e = p.lru.PushFront(&poolEntry{key: key, brand: brand, locale: locale})
+		p.engines[key] = e
+		p.evict()
+	}
+	entry := e.Value.(*poolEntry)
+	p.mu.Unlock()
+
+	// Built outside of the lock, concurrent callers of the same engine wait for the first one
+	entry.once.Do(func() {
+		entry.engine, entry.err = p.build(entry.brand, entry.locale)
+	})
+	return entry.engine, entry.err
+}
+
+// WarmUp builds the engines of the brands, in the locale of the base engine, ahead of their first use.
+// It stops early with the error of the context when it is done, or with the first error compiling an engine.
+func (p *EnginePool) WarmUp(ctx context.Context, brands []Branding) error {
+	for _, brand := range brands {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := p.Get(brand, p.base.Locale); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stats returns the counters of the pool
+func (p *EnginePool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := p.stats
+	stats.Engines = p.lru.Len()
+	return stats
+}
+
+// evict removes the least recently used engines exceeding the size of the pool, p.mu must be held
+func (p *EnginePool) evict() {
+	for p.lru.Len() > p.size {
+		e := p.lru.Back()
+		p.lru.Remove(e)
+		delete(p.engines, e.Value.(*poolEntry).key)
+		p.stats.Evictions++
+	}
+}
+
+// build derives the engine of the brand and locale from the base engine
+func (p *EnginePool) build(brand Branding, locale string) (*Hermes, error) {
+	h := p.base
+	h.Brand = brand
+	h.Locale = locale
+	h.templates = nil
+	// Merging values of the same type can't fail
+	_ = mergo.Merge(&h.Brand, p.base.Brand)
+	if err := h.Compile(); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
@@ -0,0 +1,161 @@
+package hermes
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/unknowns24/hermes/pkg/core"
+)
+
+// NewPreviewHandler returns a handler serving the emails returned by lookup as rendered by the engine, e.g. to preview
+// them in a browser while designing them. The HTML body is served, or the plain text one with "?format=text".
+// "?theme=name" renders the email with the registered theme of the name instead, and "?format=widths" serves a page
+// showing it with every registered theme side by side, at the widths of their metadata (core.DefaultWidth otherwise).
+// Responses carry the hash of their body as ETag, so that browsers and proxies revalidate them with If-None-Match and
+// get 304 Not Modified while the email is unchanged. Set a RenderCache on the engine not to render them again. When the
+// engine sets AnnotateSources, the HTML body displays the source of the copy under the cursor.
+//
+// Lookup errors are served as 404 Not Found, and rendering errors as 500 Internal Server Error.
+func NewPreviewHandler(h Hermes, lookup func(r *http.Request) (Email, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		email, err := lookup(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		query := r.URL.Query()
+		if query.Get("format") == "widths" {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Header().Set("Cache-Control", "no-cache")
+			if r.Method != http.MethodHead {
+				writeWidthsPreview(w, r.URL)
+			}
+			return
+		}
+		engine := h
+		if name := query.Get("theme"); name != "" {
+			theme, ok := core.LookupTheme(name)
+			if !ok {
+				http.Error(w, fmt.Sprintf("unknown theme %q", name), http.StatusNotFound)
+				return
+			}
+			engine.Theme = theme
+		}
+		out, err := RenderContext(r.Context(), engine, email)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		body, contentType := out.HTML, "text/html; charset=utf-8"
+		if query.Get("format") == "text" {
+			body, contentType = out.PlainText, "text/plain; charset=utf-8"
+		} else if engine.AnnotateSources {
+			body = withSourceLabels(body)
+		}
+		// Both bodies are hashed, so that the ETag differs between formats of the same email
+		etag := `"` + Output{HTML: contentType, PlainText: body}.Hash() + `"`
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "no-cache")
+		if matchesETag(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		if r.Method == http.MethodHead {
+			return
+		}
+		w.Write([]byte(body))
+	})
+}
+
+// sourceLabels displays the source of the copy annotated by AnnotateSources when it is hovered. It is added to the
+// rendered email, after CSS inlining.
+const sourceLabels = `<style data-hermes-preview="sources">
+  [data-source] { position: relative; outline: 1px dashed rgba(56, 105, 212, 0.4); }
+  [data-source]:hover { outline: 1px solid #3869D4; }
+  [data-source]:hover::after { content: attr(data-source); position: absolute; left: 0; top: 100%; z-index: 1000;
+    padding: 2px 6px; border-radius: 3px; background: #3869D4; color: #FFF; font: 11px/1.4 monospace; white-space: nowrap; }
+</style>
+`
+
+// withSourceLabels returns the HTML with the style of sourceLabels at the end of its head
+func withSourceLabels(html string) string {
+	if i := strings.Index(strings.ToLower(html), "</head>"); i >= 0 {
+		return html[:i] + sourceLabels + html[i:]
+	}
+	return sourceLabels + html
+}
+
+// matchesETag tells if the If-None-Match header matches the ETag, weakly as RFC 9110 requires
+func matchesETag(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// previewFrame is an email rendered by a theme at a viewport width
+type previewFrame struct {
+	Width int
+	URL   string
+}
+
+var widthsPreview = template.Must(template.New("widths").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>Hermes preview</title>
+  <style>
+    body { margin: 20px; font-family: sans-serif; background: #F2F4F6; }
+    .frames { display: flex; gap: 20px; align-items: flex-start; overflow-x: auto; }
+    figure { margin: 0; }
+    iframe { height: 900px; border: 1px solid #CCC; background: #FFF; }
+  </style>
+</head>
+<body>
+{{ range $theme, $frames := . }}
+  <h2>{{ $theme }}</h2>
+  <div class="frames">
+  {{ range $frames }}
+    <figure>
+      <iframe src="{{ .URL }}" width="{{ .Width }}" title="{{ $theme }} at {{ .Width }}px"></iframe>
+      <figcaption>{{ .Width }}px</figcaption>
+    </figure>
+  {{ end }}
+  </div>
+{{ end }}
+</body>
+</html>
+`))
+
+// writeWidthsPreview writes the page showing the email of the URL with every registered theme at its widths
+func writeWidthsPreview(w io.Writer, u *url.URL) {
+	frames := map[string][]previewFrame{}
+	for _, name := range core.ThemeNames() {
+		widths := []int{core.DefaultWidth}
+		if metadata, ok := core.LookupMetadata(name); ok && len(metadata.Widths) > 0 {
+			widths = metadata.Widths
+		}
+		query := u.Query()
+		query.Del("format")
+		query.Set("theme", name)
+		src := url.URL{Path: u.Path, RawQuery: query.Encode()}
+		for _, width := range widths {
+			frames[name] = append(frames[name], previewFrame{Width: width, URL: src.String()})
+		}
+	}
+	// Writing to the response fails only when the client is gone
+	_ = widthsPreview.Execute(w, frames)
+}
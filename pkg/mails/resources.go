@@ -0,0 +1,266 @@
+package hermes
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Kinds of the external resources of an email, see ExternalResources
+const (
+	ResourceImage    = "image"    // Image loaded when the email is displayed, e.g. the logo or a background
+	ResourceFont     = "font"     // Style sheet or font file of the web fonts
+	ResourceAnchor   = "anchor"   // Link followed when the recipient clicks it
+	ResourceTracking = "tracking" // Image of a pixel or hidden, loaded to track the opening of the email
+)
+
+// IssueResourceHost is the code of the issues of the resources whose host is not in Hermes.AllowedResourceHosts
+const IssueResourceHost = "resource_host_not_allowed"
+
+// CheckResourcesStage checks the hosts of the external resources of the HTML version against
+// Hermes.AllowedResourceHosts, when set, see CheckResources. It comes after TrackLinks, whose rewriter may change them.
+var CheckResourcesStage = Stage{Name: StageCheckResources, Run: checkResources}
+
+// Resource is an external reference of an email, which clients contact when displaying it or when it is clicked
+type Resource struct {
+	URL     string `json:"url"`
+	Kind    string `json:"kind"`    // ResourceImage, ResourceFont, ResourceAnchor or ResourceTracking
+	Section string `json:"section"` // Part of the email holding the reference, like in the manifest, or "head"
+}
+
+// Host returns the host of the resource in lower case, without port, or an empty string for mailto: and anchors
+func (r Resource) Host() string {
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// cssImports matches the style sheets imported by CSS, e.g. @import url('https://fonts.googleapis.com/css2?...')
+var cssImports = regexp.MustCompile(`(?i)@import\s+(?:url\(\s*)?['"]?([^'")\s;]+)`)
+
+// fontFaces matches the @font-face rules of CSS, whose URLs are font files
+var fontFaces = regexp.MustCompile(`(?i)@font-face\s*{[^}]*}`)
+
+// ExternalResources returns the inventory of the external references of the HTML email, e.g. for security reviews:
+// images, style sheets and font files loaded by clients when displaying it, including the ones of the VML of Outlook,
+// and the links followed when clicking it. Links to anchors of the email, mailto: and tel: links are listed as
+// anchors, data: and cid: URIs are not external and left out, as are relative URLs.
+// Resources are deduplicated, and sorted by URL, kind and section. Their section is given like in Output.Manifest.
+func ExternalResources(doc string) []Resource {
+	root, err := html.Parse(strings.NewReader(doc))
+	if err != nil {
+		// html.Parse only fails on errors of its reader
+		return nil
+	}
+	seen := map[Resource]bool{}
+	resources := []Resource{}
+	add := func(link, kind, section string) {
+		link = strings.TrimSpace(link)
+		if !externalReference(link, kind) {
+			return
+		}
+		r := Resource{URL: link, Kind: kind, Section: section}
+		if !seen[r] {
+			seen[r] = true
+			resources = append(resources, r)
+		}
+	}
+	addCSS := func(css, section string) {
+		for _, m := range cssImports.FindAllStringSubmatch(css, -1) {
+			add(m[1], ResourceFont, section)
+		}
+		for _, face := range fontFaces.FindAllString(css, -1) {
+			for _, m := range cssURLs.FindAllStringSubmatch(face, -1) {
+				add(m[1], ResourceFont, section)
+			}
+		}
+		for _, m := range cssURLs.FindAllStringSubmatch(cssImports.ReplaceAllString(fontFaces.ReplaceAllString(css, ""), ""), -1) {
+			add(m[1], ResourceImage, section)
+		}
+	}
+
+	var walk func(*html.Node, string)
+	walk = func(n *html.Node, section string) {
+		switch n.Type {
+		case html.ElementNode:
+			if s := manifestSection(n); s != "" {
+				section = s
+			} else if n.DataAtom == atom.Head {
+				section = "head"
+			}
+			if style, ok := attr(n, "style"); ok {
+				addCSS(style, section)
+			}
+			if background, ok := attr(n, "background"); ok {
+				add(background, ResourceImage, section)
+			}
+			switch n.Data {
+			case "a", "area", "v:roundrect", "v:rect":
+				if href, ok := attr(n, "href"); ok {
+					add(href, ResourceAnchor, section)
+				}
+			case "img":
+				src, _ := attr(n, "src")
+				add(src, imageKind(n), section)
+				if srcset, ok := attr(n, "srcset"); ok {
+					for _, candidate := range strings.Split(srcset, ",") {
+						if fields := strings.Fields(candidate); len(fields) > 0 {
+							add(fields[0], imageKind(n), section)
+						}
+					}
+				}
+			case "link":
+				rel, _ := attr(n, "rel")
+				href, _ := attr(n, "href")
+				if strings.Contains(strings.ToLower(rel), "stylesheet") {
+					add(href, ResourceFont, section)
+				} else if strings.Contains(strings.ToLower(rel), "icon") {
+					add(href, ResourceImage, section)
+				}
+			case "style":
+				addCSS(textContent(n), section)
+			default:
+				// VML images and fills of Outlook, e.g. <v:fill src="...">
+				if strings.HasPrefix(n.Data, "v:") {
+					if src, ok := attr(n, "src"); ok {
+						add(src, ResourceImage, section)
+					}
+				}
+			}
+		case html.CommentNode:
+			// The markup of Outlook is in conditional comments, e.g. <!--[if mso]><v:roundrect href="..."><![endif]-->
+			if content, ok := conditionalComment(n.Data); ok {
+				nodes, err := html.ParseFragment(strings.NewReader(content), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+				if err == nil {
+					for _, c := range nodes {
+						walk(c, section)
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, section)
+		}
+	}
+	walk(root, "body")
+
+	sort.Slice(resources, func(i, j int) bool {
+		a, b := resources[i], resources[j]
+		return compareStrings(a.URL, b.URL, a.Kind, b.Kind, a.Section, b.Section) < 0
+	})
+	return resources
+}
+
+// externalReference reports whether the reference is external: an absolute http(s) URL, or for anchors a mailto: or
+// tel: link, or a link to an anchor of the email
+func externalReference(link, kind string) bool {
+	if link == "" {
+		return false
+	}
+	if kind == ResourceAnchor && (strings.HasPrefix(link, "#") || hasScheme(link, "mailto") || hasScheme(link, "tel")) {
+		return true
+	}
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return u.Scheme == "" || strings.EqualFold(u.Scheme, "http") || strings.EqualFold(u.Scheme, "https")
+}
+
+func hasScheme(link, scheme string) bool {
+	return len(link) > len(scheme) && strings.EqualFold(link[:len(scheme)+1], scheme+":")
+}
+
+// imageKind returns ResourceTracking for the images of at most a pixel, by their attributes or their style, and
+// ResourceImage for the others
+func imageKind(img *html.Node) string {
+	width, _ := attr(img, "width")
+	height, _ := attr(img, "height")
+	style, _ := attr(img, "style")
+	for _, declaration := range strings.Split(style, ";") {
+		property, value, _ := strings.Cut(declaration, ":")
+		switch strings.ToLower(strings.TrimSpace(property)) {
+		case "width":
+			width = value
+		case "height":
+			height = value
+		}
+	}
+	if pixelSize(width) && pixelSize(height) {
+		return ResourceTracking
+	}
+	return ResourceImage
+}
+
+// pixelSize reports whether the dimension, e.g. "1" or "0px", is at most a pixel
+func pixelSize(dimension string) bool {
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(dimension), "px"))
+	return err == nil && n <= 1
+}
+
+// conditionalComment returns the markup of a conditional comment of Outlook, e.g. "[if mso]>...<![endif]"
+func conditionalComment(data string) (string, bool) {
+	if !strings.HasPrefix(data, "[if") {
+		return "", false
+	}
+	_, content, ok := strings.Cut(data, ">")
+	if !ok {
+		return "", false
+	}
+	content, _, _ = strings.Cut(content, "<![endif]")
+	return content, true
+}
+
+// CheckResources returns the issues of the resources whose host is not allowed, with the given severity. Hosts are
+// compared without case nor port, and "*.example.com" allows the subdomains of example.com. Anchors of the email,
+// mailto: and tel: links have no host, and are never reported.
+func CheckResources(resources []Resource, allowedHosts []string, severity Severity) Issues {
+	var issues Issues
+	for _, r := range resources {
+		host := r.Host()
+		if host == "" || hostAllowed(host, allowedHosts) {
+			continue
+		}
+		issues = append(issues, Issue{
+			Code:     IssueResourceHost,
+			Severity: severity,
+			Message:  fmt.Sprintf("%s %s in %s: host %s is not allowed", r.Kind, r.URL, r.Section, host),
+		})
+	}
+	return issues
+}
+
+// hostAllowed reports whether the host is in the allowlist, or a subdomain of one of its "*." entries
+func hostAllowed(host string, allowed []string) bool {
+	for _, a := range allowed {
+		a = strings.ToLower(strings.TrimSpace(a))
+		if host == a || strings.HasPrefix(a, "*.") && strings.HasSuffix(host, a[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkResources(r *Rendering) error {
+	if len(r.Hermes.AllowedResourceHosts) == 0 || r.PlainText {
+		return nil
+	}
+	severity := SeverityWarning
+	if r.Hermes.StrictValidation {
+		severity = SeverityError
+	}
+	issues := CheckResources(ExternalResources(r.HTML), r.Hermes.AllowedResourceHosts, severity)
+	if issues.HasErrors() {
+		return issues
+	}
+	r.Warnings = append(r.Warnings, issues...)
+	return nil
+}
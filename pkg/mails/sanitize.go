@@ -0,0 +1,212 @@
+package hermes
+
+import (
+	"html/template"
+	"net/url"
+	"strings"
+
+	"github.com/jaytaylor/html2text"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Sanitizer cleans untrusted HTML before it is rendered.
+// A bluemonday policy (https://github.com/microcosm-cc/bluemonday) is a Sanitizer.
+type Sanitizer interface {
+	Sanitize(s string) string
+}
+
+// DefaultSanitizer keeps text formatting, lists and links, and drops everything else:
+// scripts, styles, images, forms, event handlers, and URLs other than http, https and mailto
+var DefaultSanitizer Sanitizer = allowlistSanitizer{
+	elements: map[string][]string{
+		"a":          {"href", "title"},
+		"abbr":       {"title"},
+		"b":          nil,
+		"blockquote": nil,
+		"br":         nil,
+		"code":       nil,
+		"del":        nil,
+		"em":         nil,
+		"i":          nil,
+		"ins":        nil,
+		"li":         nil,
+		"mark":       nil,
+		"ol":         nil,
+		"p":          nil,
+		"pre":        nil,
+		"s":          nil,
+		"small":      nil,
+		"span":       nil,
+		"strong":     nil,
+		"sub":        nil,
+		"sup":        nil,
+		"u":          nil,
+		"ul":         nil,
+	},
+	globalAttributes: []string{"dir", "lang"},
+	urlAttributes:    []string{"href"},
+	urlSchemes:       []string{"http", "https", "mailto"},
+}
+
+// sanitizer returns the sanitizer of the engine
+func (h *Hermes) sanitizer() Sanitizer {
+	if h.Sanitizer != nil {
+		return h.Sanitizer
+	}
+	return DefaultSanitizer
+}
+
+// sanitizeEntries returns the email with the HTML values of its entries sanitized.
+// Entries are copied, the given email is left untouched.
+func sanitizeEntries(email Email, s Sanitizer) Email {
+	sanitize := func(entries []Entry) []Entry {
+		copied := make([]Entry, len(entries))
+		for i, entry := range entries {
+			if entry.HTMLValue != "" {
+				entry.HTMLValue = template.HTML(s.Sanitize(string(entry.HTMLValue)))
+			}
+			copied[i] = entry
+		}
+		return copied
+	}
+
+	if email.Body.Dictionary != nil {
+		email.Body.Dictionary = sanitize(email.Body.Dictionary)
+	}
+	sanitizeTable := func(table Table) Table {
+		if table.Data != nil {
+			data := make([][]Entry, len(table.Data))
+			for i, row := range table.Data {
+				data[i] = sanitize(row)
+			}
+			table.Data = data
+		}
+		if table.Footer != nil {
+			table.Footer = sanitize(table.Footer)
+		}
+		return table
+	}
+
+	email.Body.Table = sanitizeTable(email.Body.Table)
+	if email.Body.Tables != nil {
+		tables := make([]Table, len(email.Body.Tables))
+		for i, table := range email.Body.Tables {
+			tables[i] = sanitizeTable(table)
+		}
+		email.Body.Tables = tables
+	}
+	return email
+}
+
+// fragmentText converts a HTML fragment to plain text
+func fragmentText(fragment template.HTML) (string, error) {
+	return html2text.FromString(string(fragment), html2text.Options{})
+}
+
+type allowlistSanitizer struct {
+	elements         map[string][]string // Allowed elements, with their allowed attributes
+	globalAttributes []string            // Attributes allowed on every element
+	urlAttributes    []string            // Attributes holding a URL, restricted to urlSchemes
+	urlSchemes       []string
+}
+
+// droppedWithContent are the elements removed along with their content, other unknown elements only lose their tags
+var droppedWithContent = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true,
+	"noscript": true, "template": true, "textarea": true, "select": true, "title": true,
+	"svg": true, "math": true,
+}
+
+// voidElements are the allowed elements without end tag
+var voidElements = map[string]bool{"br": true, "hr": true, "img": true}
+
+func (s allowlistSanitizer) Sanitize(fragment string) string {
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), context)
+	if err != nil {
+		return html.EscapeString(fragment)
+	}
+
+	var b strings.Builder
+	var render func(n *html.Node)
+	render = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			b.WriteString(html.EscapeString(n.Data))
+			return
+		case html.ElementNode:
+		default:
+			return // Comments, doctypes
+		}
+
+		if droppedWithContent[n.Data] {
+			return
+		}
+		attributes, allowed := s.elements[n.Data]
+		if allowed {
+			b.WriteString("<" + n.Data)
+			for _, a := range n.Attr {
+				if a.Namespace == "" && s.allowsAttribute(attributes, a) {
+					b.WriteString(" " + a.Key + `="` + html.EscapeString(a.Val) + `"`)
+				}
+			}
+			b.WriteString(">")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			render(c)
+		}
+		if allowed && !voidElements[n.Data] {
+			b.WriteString("</" + n.Data + ">")
+		}
+	}
+	for _, n := range nodes {
+		render(n)
+	}
+	return b.String()
+}
+
+func (s allowlistSanitizer) allowsAttribute(attributes []string, a html.Attribute) bool {
+	if !contains(attributes, a.Key) && !contains(s.globalAttributes, a.Key) {
+		return false
+	}
+	if !contains(s.urlAttributes, a.Key) {
+		return true
+	}
+	u, err := url.Parse(strings.TrimSpace(a.Val))
+	return err == nil && contains(s.urlSchemes, strings.ToLower(u.Scheme))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// unsafeURL replaces the URLs html/template would not write in attributes, the same value it uses
+const unsafeURL = "#ZgotmplZ"
+
+// trustedURL is the url func of templates. It marks the URL as safe, so that html/template only normalizes it in
+// attributes, the same way across Go releases, and schemes like tel: are kept. URLs running code are still replaced:
+// javascript:, vbscript:, and data: except images other than SVG.
+func trustedURL(s string) template.URL {
+	// Browsers ignore the whitespace and control characters of schemes
+	scheme := strings.ToLower(strings.Map(func(r rune) rune {
+		if r <= ' ' {
+			return -1
+		}
+		return r
+	}, s))
+	switch {
+	case strings.HasPrefix(scheme, "javascript:"), strings.HasPrefix(scheme, "vbscript:"):
+		return unsafeURL
+	case strings.HasPrefix(scheme, "data:"):
+		if !strings.HasPrefix(scheme, "data:image/") || strings.HasPrefix(scheme, "data:image/svg") {
+			return unsafeURL
+		}
+	}
+	return template.URL(s)
+}
@@ -0,0 +1,101 @@
+package hermes
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScheduleEntry is a labelled time range, e.g. a maintenance window
+type ScheduleEntry struct {
+	Label string    `json:"label,omitempty" yaml:"label,omitempty"`
+	Start time.Time `json:"start,omitempty" yaml:"start,omitempty"`
+	End   time.Time `json:"end,omitempty" yaml:"end,omitempty"` // Optional, only the start is displayed when zero
+}
+
+// timeZone returns the display time zone of the engine
+func (h Hermes) timeZone() *time.Location {
+	if h.TimeZone != nil {
+		return h.TimeZone
+	}
+	return time.UTC
+}
+
+// now returns the current time given by the clock of the engine
+func (h Hermes) now() time.Time {
+	if h.Now != nil {
+		return h.Now()
+	}
+	return time.Now()
+}
+
+// weekday returns the localized name of the day of t, in the display time zone of the engine
+func weekday(h Hermes, t time.Time) string {
+	return translate(h.Locale, "weekday."+strconv.Itoa(int(t.In(h.timeZone()).Weekday())))
+}
+
+// clockTime returns the localized time of day of t, which must be in the display time zone
+func clockTime(h Hermes, t time.Time) string {
+	if t.Minute() == 0 {
+		return t.Format(translate(h.Locale, "time.hour"))
+	}
+	return t.Format(translate(h.Locale, "time.minute"))
+}
+
+// timeRange returns the localized range from start to end in the display time zone of the engine,
+// e.g. "Saturday 2AM–3AM UTC". The weekday of the end is repeated for ranges across midnight,
+// and both zone abbreviations are given for ranges across a DST change.
+func timeRange(h Hermes, start, end time.Time) (string, error) {
+	zone := h.timeZone()
+	start = start.In(zone)
+	startZone, _ := start.Zone()
+	from := weekday(h, start) + " " + clockTime(h, start)
+	if end.IsZero() {
+		return from + " " + startZone, nil
+	}
+	if end.Before(start) {
+		return "", errors.New("time range ends before it starts")
+	}
+
+	end = end.In(zone)
+	endZone, _ := end.Zone()
+	if startZone != endZone {
+		from += " " + startZone
+	}
+	sy, sm, sd := start.Date()
+	ey, em, ed := end.Date()
+	if sy != ey || sm != em || sd != ed {
+		return from + " – " + weekday(h, end) + " " + clockTime(h, end) + " " + endZone, nil
+	}
+	return from + "–" + clockTime(h, end) + " " + endZone, nil
+}
+
+// relTime returns the localized time from now to t, given by the clock of the engine, e.g. "in 3 days" or "2 hours ago"
+func relTime(h Hermes, t time.Time) string {
+	d := t.Sub(h.now())
+	abs := d
+	if abs < 0 {
+		abs = -abs
+	}
+
+	var n int
+	var unit string
+	if minutes := int(math.Round(abs.Minutes())); minutes < 1 {
+		return translate(h.Locale, "reltime.now")
+	} else if minutes < 60 {
+		n, unit = minutes, "minute"
+	} else if hours := int(math.Round(abs.Hours())); hours < 24 {
+		n, unit = hours, "hour"
+	} else {
+		n, unit = int(math.Round(abs.Hours()/24)), "day"
+	}
+
+	amount := pluralize(h.Locale, "reltime."+unit, n)
+	key := "reltime.future"
+	if d < 0 {
+		key = "reltime.past"
+	}
+	return strings.ReplaceAll(translate(h.Locale, key), "{TIME}", amount)
+}
@@ -0,0 +1,70 @@
+package hermes
+
+import (
+	"context"
+	"fmt"
+)
+
+// DefaultSegment is the key of Body.SegmentedBlocks used for the segments without blocks
+const DefaultSegment = "default"
+
+// Block is content of the body displayed to a segment of the audience only, see Body.SegmentedBlocks
+type Block struct {
+	Intros     []string `json:"intros,omitempty" yaml:"intros,omitempty"`         // Displayed after the intros of the body
+	Dictionary []Entry  `json:"dictionary,omitempty" yaml:"dictionary,omitempty"` // Displayed after the dictionary of the body
+	Tables     []Table  `json:"tables,omitempty" yaml:"tables,omitempty"`         // Displayed after the tables of the body
+	Actions    []Action `json:"actions,omitempty" yaml:"actions,omitempty"`       // Displayed after the actions of the body
+	Outros     []string `json:"outros,omitempty" yaml:"outros,omitempty"`         // Displayed before the outros of the body
+}
+
+// RenderForSegment generates both bodies of the email for a segment of the audience (e.g. "free", "pro"), with the
+// blocks of the segment merged into the body. Segments without blocks get the DefaultSegment ones, unless
+// StrictSegments is set. The other generation functions ignore Body.SegmentedBlocks.
+func (h *Hermes) RenderForSegment(email Email, segment string) (Output, error) {
+	email, err := mergeSegment(email, segment, h.StrictSegments)
+	if err != nil {
+		return Output{}, err
+	}
+	r, email, err := prepare(*h, email)
+	if err != nil {
+		return Output{}, err
+	}
+	var out Output
+	if out.HTML, out.Warnings, err = r.generateHTMLWarnings(context.Background(), email); err != nil {
+		return Output{}, err
+	}
+	if out.PlainText, err = r.generatePlainText(context.Background(), email); err != nil {
+		return Output{}, err
+	}
+	if out.Subject, err = r.subject(email); err != nil {
+		return Output{}, err
+	}
+	return out, nil
+}
+
+// mergeSegment returns the email with the blocks of the segment merged into its body.
+// The lists of the body are copied, those of the caller are never written.
+func mergeSegment(email Email, segment string, strict bool) (Email, error) {
+	blocks, ok := email.Body.SegmentedBlocks[segment]
+	if !ok {
+		if strict {
+			return Email{}, fmt.Errorf("unknown segment %q", segment)
+		}
+		blocks = email.Body.SegmentedBlocks[DefaultSegment]
+	}
+	body := email.Body
+	body.SegmentedBlocks = nil
+	var outros []string
+	for _, block := range blocks {
+		body.Intros = append(body.Intros[:len(body.Intros):len(body.Intros)], block.Intros...)
+		body.Dictionary = append(body.Dictionary[:len(body.Dictionary):len(body.Dictionary)], block.Dictionary...)
+		body.Tables = append(body.Tables[:len(body.Tables):len(body.Tables)], block.Tables...)
+		body.Actions = append(body.Actions[:len(body.Actions):len(body.Actions)], block.Actions...)
+		outros = append(outros, block.Outros...)
+	}
+	if len(outros) > 0 {
+		body.Outros = append(outros, body.Outros...)
+	}
+	email.Body = body
+	return email, nil
+}
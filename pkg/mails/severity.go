@@ -0,0 +1,29 @@
+package hermes
+
+// Severity of an issue found while checking an email
+type Severity int
+
+// Severities, from the least to the most serious
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+// String returns the name of the severity
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// MarshalText encodes the severity as its name
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
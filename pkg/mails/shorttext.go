@@ -0,0 +1,133 @@
+package hermes
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultShortTextLength is the maximum length of GenerateShortText when none is given, that of a single SMS
+const DefaultShortTextLength = 160
+
+// ErrShortTextTooLong is returned by GenerateShortText when even the minimal content exceeds the maximum length
+var ErrShortTextTooLong = errors.New("short text: minimal content exceeds the maximum length")
+
+// ShortTextOptions configure GenerateShortText
+type ShortTextOptions struct {
+	MaxLength  int                              // Maximum length in characters (default to DefaultShortTextLength)
+	PreferURL  bool                             // Gives the URL of the button rather than the invite code when the action has both
+	ExpiresAt  time.Time                        // Expiry of the action, written as e.g. "Expires in 10 minutes" in the locale of the engine
+	ShortenURL func(url string) (string, error) // Shortens the URL of the button, e.g. with the link shortener of the SMS provider
+}
+
+// GenerateShortText generates a terse plain text of the email for SMS and push notifications, on a single line:
+// the brand name, the greeting (or the title), the first intro, the instructions and the invite code or the URL of the
+// first action, and the expiry.
+//
+// When the text is longer than MaxLength characters, the intro is shortened at a word boundary, then the greeting and
+// the instructions are dropped in turn, and the intro last. The brand name, the code or URL and the expiry are always
+// kept, as is the beginning of the intro of emails without action: ErrShortTextTooLong is returned when they do not fit.
+// The output only depends on the email, the engine and the options.
+func (h *Hermes) GenerateShortText(email Email, opts ShortTextOptions) (string, error) {
+	r, email, err := prepare(*h, email)
+	if err != nil {
+		return "", err
+	}
+	limit := opts.MaxLength
+	if limit <= 0 {
+		limit = DefaultShortTextLength
+	}
+
+	var s shortText
+	if r.Brand.Name != "" {
+		s.brand = r.Brand.Name + ":"
+	}
+	s.greeting = email.Body.Title
+	if s.greeting == "" {
+		s.greeting = strings.TrimSpace(email.Body.Greeting+" "+email.Body.Name) + ","
+	}
+	if len(email.Body.Intros) > 0 {
+		s.intro = strings.Join(strings.Fields(email.Body.Intros[0]), " ")
+	}
+	for _, action := range email.Body.Actions {
+		if action.InviteCode == "" && action.Button.Link == "" {
+			continue
+		}
+		s.instructions = strings.TrimSpace(action.Instructions)
+		s.action = action.GroupedInviteCode()
+		if action.Button.Link != "" && (s.action == "" || opts.PreferURL) {
+			s.action = action.Button.Link
+			if opts.ShortenURL != nil {
+				if s.action, err = opts.ShortenURL(action.Button.Link); err != nil {
+					return "", fmt.Errorf("short text: shorten %q: %w", action.Button.Link, err)
+				}
+			}
+		}
+		break
+	}
+	if !opts.ExpiresAt.IsZero() {
+		s.expiry = strings.ReplaceAll(translate(r.Locale, "shorttext.expires"), "{TIME}", relTime(*r, opts.ExpiresAt)) + "."
+	}
+
+	// Parts are dropped in turn, the intro being shortened as much as needed at each step
+	drops := []func(){func() {}, func() { s.greeting = "" }, func() { s.instructions = "" }}
+	if s.action != "" {
+		drops = append(drops, func() { s.intro = "" })
+	}
+	for _, drop := range drops {
+		drop()
+		if text := s.String(); utf8.RuneCountInString(text) <= limit {
+			return text, nil
+		}
+		if s.intro == "" {
+			continue
+		}
+		intro := s.intro
+		s.intro = ""
+		if fitted, ok := fitWords(intro, limit-utf8.RuneCountInString(s.String())-1); ok {
+			s.intro = fitted
+			return s.String(), nil
+		}
+		s.intro = intro
+	}
+	if words := strings.Fields(s.intro); len(words) > 0 {
+		s.intro = strings.TrimRight(words[0], ",;:.!?") + "…"
+	}
+	return "", fmt.Errorf("%w: %d characters at most, %d needed", ErrShortTextTooLong, limit, utf8.RuneCountInString(s.String()))
+}
+
+// shortText are the parts of the text of GenerateShortText
+type shortText struct {
+	brand, greeting, intro, instructions, action, expiry string
+}
+
+// String joins the parts which are set with spaces
+func (s shortText) String() string {
+	var parts []string
+	for _, part := range []string{s.brand, s.greeting, s.intro, s.instructions, s.action, s.expiry} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// fitWords returns the first words of the text followed by an ellipsis, as many as fit in the length in characters.
+// It reports false when not even the first word fits.
+func fitWords(text string, length int) (string, bool) {
+	words := strings.Fields(text)
+	fitted := ""
+	for _, word := range words {
+		next := strings.TrimSpace(fitted + " " + word)
+		if utf8.RuneCountInString(next)+1 > length {
+			break
+		}
+		fitted = next
+	}
+	if fitted == "" {
+		return "", false
+	}
+	return strings.TrimRight(fitted, ",;:.!?") + "…", true
+}
@@ -0,0 +1,60 @@
+package hermes
+
+// signatureConflict is the message of the CodeSignatureConflict warning
+const signatureConflict = "both Signature and SignatureBlock are set, SignatureBlock is displayed"
+
+// Widths of the image of a SignatureBlock, in pixels
+const (
+	DefaultSignatureImageWidth = 64  // e.g. for an avatar
+	MaxSignatureImageWidth     = 200 // e.g. for a handwritten signature
+)
+
+// SignatureBlock is the sign-off of the email: its closing phrase, the name and the job title of the sender, and an
+// optional avatar or handwritten signature. It replaces Body.Signature and the name of the brand under it.
+type SignatureBlock struct {
+	Closing    string `json:"closing,omitempty" yaml:"closing,omitempty"`         // e.g. "Best regards" (default to the default Body.Signature of the locale)
+	Name       string `json:"name,omitempty" yaml:"name,omitempty"`               // Name of the sender, e.g. "Jane Doe", written in bold
+	Title      string `json:"title,omitempty" yaml:"title,omitempty"`             // Job title of the sender, e.g. "Head of Support", muted
+	ImageURL   string `json:"image_url,omitempty" yaml:"image_url,omitempty"`     // Avatar or handwritten signature, e.g. https://example.com/jane.png
+	ImageWidth int    `json:"image_width,omitempty" yaml:"image_width,omitempty"` // Width of the image in pixels (default to DefaultSignatureImageWidth)
+}
+
+// ImageDisplayWidth returns the width the image is displayed at: ImageWidth, or DefaultSignatureImageWidth, at most
+// MaxSignatureImageWidth so that large images do not take over the email
+func (s SignatureBlock) ImageDisplayWidth() int {
+	switch {
+	case s.ImageWidth <= 0:
+		return DefaultSignatureImageWidth
+	case s.ImageWidth > MaxSignatureImageWidth:
+		return MaxSignatureImageWidth
+	}
+	return s.ImageWidth
+}
+
+// preferSignatureBlock returns the email of which the Signature is dropped, with a warning, when a SignatureBlock is set
+// as well. It is called before the default values are set, so that the closing of the block defaults to the one of
+// the locale rather than to the dropped Signature.
+func preferSignatureBlock(email Email) Email {
+	if email.Body.SignatureBlock == nil || email.Body.Signature == "" {
+		return email
+	}
+	email.warnings = append(email.warnings[:len(email.warnings):len(email.warnings)], ValidationError{
+		Code:    CodeSignatureConflict,
+		Path:    "Body.Signature",
+		Value:   email.Body.Signature,
+		Message: signatureConflict,
+	}.issue(SeverityWarning))
+	email.Body.Signature = ""
+	return email
+}
+
+// defaultSignatureClosing returns the email of which the SignatureBlock without closing phrase gets the default
+// signature. The block is copied, the one of the caller is never written.
+func defaultSignatureClosing(email Email) Email {
+	if block := email.Body.SignatureBlock; block != nil && block.Closing == "" {
+		copied := *block
+		copied.Closing = email.Body.Signature
+		email.Body.SignatureBlock = &copied
+	}
+	return email
+}
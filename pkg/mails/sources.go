@@ -0,0 +1,220 @@
+package hermes
+
+import (
+	"fmt"
+	"html"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// Runes delimiting the values annotated with their source by AnnotateSources, the index of the source being added to
+// them. They are in the private use planes, so that they never appear in the copy of emails.
+const (
+	sourceStart = 0xF0000  // Supplementary Private Use Area-A
+	sourceEnd   = 0x100000 // Supplementary Private Use Area-B
+	maxSources  = 0xFFFE
+)
+
+// unannotatedFields are the string fields which are not copy, by name whatever the struct holding them or by struct and
+// name: links, colors and options, values used as keys or transformed character by character, and fields which are not
+// rendered. The width of buttons is computed from the length of their text.
+var unannotatedFields = map[string]bool{
+	"Link": true, "URL": true, "DarkURL": true, "IconURL": true, "ImageURL": true, "FallbackPNG": true, "DarkFallbackPNG": true, "IconFallbackPNG": true, "Logo": true, "UnsubscribeLink": true, "Unsubscribe": true,
+	"Email": true, "Key": true, "Bidi": true, "Mask": true, "Format": true, "InviteCode": true,
+	"Color": true, "TextColor": true, "Background": true, "BackgroundColor": true, "FontSize": true, "Separator": true,
+	"Columns": true, "WebFonts": true, "CalendarEvent": true, "SegmentedBlocks": true, "ActionsLayout": true,
+	"Button.Text": true,
+}
+
+var (
+	stringType = reflect.TypeOf("")
+	entryType  = reflect.TypeOf(Entry{})
+)
+
+// sourceAnnotations are the sources of the values annotated in a rendering, by index
+type sourceAnnotations struct {
+	labels   []string
+	defaults map[string]localizedDefault // Localized default values of the fields, by path
+}
+
+// localizedDefault is the default value of a field in a locale, and the key of its localization
+type localizedDefault struct {
+	value, key string
+}
+
+// annotateSources returns copies of the brand and of the email, prepared by prepare, with the copy of the brand and of
+// the bodies delimited by the runes of their source, and the labels of the sources by index.
+// Every string field of Body is annotated unless it is one of unannotatedFields, so that new features of the body are
+// annotated as well.
+func annotateSources(brand Branding, email Email, level int, locale string) (Branding, Email, []string) {
+	s := sourceAnnotations{defaults: map[string]localizedDefault{}}
+	s.addDefaults("", level, locale)
+	brand = deepCopy(reflect.ValueOf(brand)).Interface().(Branding)
+	email = deepCopy(reflect.ValueOf(email)).Interface().(Email)
+
+	s.walk(reflect.ValueOf(&brand).Elem(), "Brand")
+	if len(email.LanguageVariants) == 0 {
+		s.walk(reflect.ValueOf(&email.Body).Elem(), "Body")
+		return brand, email, s.labels
+	}
+	for i, variant := range email.LanguageVariants {
+		prefix := fmt.Sprintf("LanguageVariants[%d].", i)
+		s.addDefaults(prefix, level, variant.Tag)
+		s.walk(reflect.ValueOf(&email.LanguageVariants[i].Body).Elem(), prefix+"Body")
+	}
+	// The body is the one of the first variant, see prepareVariants
+	email.Body = email.LanguageVariants[0].Body
+	return brand, email, s.labels
+}
+
+// addDefaults adds the localized default values of the locale, of the fields of the body of the prefix and of the brand
+func (s *sourceAnnotations) addDefaults(prefix string, level int, locale string) {
+	defaults, err := defaultsAt(level)
+	if err != nil || level != CompatLatest {
+		return
+	}
+	defaults = localize(defaults, level, locale)
+	s.defaults[prefix+"Body.Greeting"] = localizedDefault{defaults.Greeting, keyGreeting}
+	s.defaults[prefix+"Body.Signature"] = localizedDefault{defaults.Signature, keySignature}
+	if prefix == "" {
+		s.defaults["Brand.Copyright"] = localizedDefault{defaults.Brand.Copyright, keyCopyright}
+		s.defaults["Brand.TroubleText"] = localizedDefault{defaults.Brand.TroubleText, keyTroubleText}
+	}
+}
+
+// walk annotates the string fields of the value, which is addressable, path being its path from the email
+func (s *sourceAnnotations) walk(v reflect.Value, path string) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			s.walk(v.Elem(), path)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() || unannotatedFields[field.Name] || unannotatedFields[v.Type().Name()+"."+field.Name] {
+				continue
+			}
+			// Masked values are rendered character by character
+			if v.Type() == entryType && field.Name == "Value" && v.FieldByName("Mask").String() != "" {
+				continue
+			}
+			s.walk(v.Field(i), path+"."+field.Name)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			s.walk(v.Index(i), fmt.Sprintf("%s[%d]", path, i))
+		}
+	case reflect.String:
+		if v.Type() != stringType || v.String() == "" || len(s.labels) == maxSources {
+			return
+		}
+		label := path
+		if d, ok := s.defaults[path]; ok && d.value == v.String() {
+			label = "localized default: " + strings.TrimPrefix(d.key, "default.")
+		}
+		i := len(s.labels)
+		s.labels = append(s.labels, label)
+		v.SetString(string(rune(sourceStart+i)) + v.String() + string(rune(sourceEnd+i)))
+	}
+}
+
+// sourceIndex returns the index of the source of the rune, and whether it starts or ends its value, or -1
+func sourceIndex(r rune) (index int, start bool) {
+	switch {
+	case r >= sourceStart && r < sourceStart+maxSources:
+		return int(r - sourceStart), true
+	case r >= sourceEnd && r < sourceEnd+maxSources:
+		return int(r - sourceEnd), false
+	}
+	return -1, false
+}
+
+// annotateHTML replaces the annotated values of the HTML with span elements with their source in a data-source
+// attribute. The annotations of values written in tags, comments (e.g. the VML of Outlook) and elements which cannot
+// hold elements, like the title, are removed.
+func annotateHTML(s string, labels []string) string {
+	var b strings.Builder
+	var open []int // Sources of the span elements open
+	for i := 0; i < len(s); {
+		switch {
+		case strings.HasPrefix(s[i:], "<!--"):
+			end := len(s)
+			if j := strings.Index(s[i+4:], "-->"); j >= 0 {
+				end = i + 4 + j + 3
+			}
+			b.WriteString(stripSources(s[i:end]))
+			i = end
+		case s[i] == '<':
+			end := len(s)
+			if j := strings.IndexByte(s[i:], '>'); j >= 0 {
+				end = i + j + 1
+			}
+			tag := s[i:end]
+			b.WriteString(stripSources(tag))
+			i = end
+			if name := rawTextElement(tag); name != "" {
+				end = len(s)
+				if j := strings.Index(strings.ToLower(s[i:]), "</"+name); j >= 0 {
+					end = i + j
+				}
+				b.WriteString(stripSources(s[i:end]))
+				i = end
+			}
+		default:
+			r, size := utf8.DecodeRuneInString(s[i:])
+			index, start := sourceIndex(r)
+			switch {
+			case index < 0:
+				b.WriteString(s[i : i+size])
+			case start:
+				open = append(open, index)
+				b.WriteString(`<span data-source="` + html.EscapeString(labels[index]) + `">`)
+			case len(open) > 0 && open[len(open)-1] == index:
+				open = open[:len(open)-1]
+				b.WriteString("</span>")
+			}
+			i += size
+		}
+	}
+	return b.String()
+}
+
+// annotateText replaces the annotated values of the plain text with their source in brackets before them, e.g.
+// "[Body.Intros[0]] Welcome to Hermes!"
+func annotateText(s string, labels []string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch index, start := sourceIndex(r); {
+		case index < 0:
+			b.WriteRune(r)
+		case start:
+			b.WriteString("[" + labels[index] + "] ")
+		}
+	}
+	return b.String()
+}
+
+// stripSources removes the runes delimiting annotated values
+func stripSources(s string) string {
+	return strings.Map(func(r rune) rune {
+		if index, _ := sourceIndex(r); index >= 0 {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// rawTextElement returns the name of the element opened by the tag when it holds text only, e.g. "title", or ""
+func rawTextElement(tag string) string {
+	name := strings.ToLower(strings.TrimPrefix(tag, "<"))
+	if i := strings.IndexAny(name, " \t\r\n/>"); i >= 0 {
+		name = name[:i]
+	}
+	switch name {
+	case "title", "style", "script", "textarea":
+		return name
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package hermes
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RenderResult is the rendering of an email of RenderStream
+type RenderResult struct {
+	Index  int // Position of the email in the input channel, from 0, to reassemble the results in order
+	Output Output
+	Err    error
+	Stats  RenderStats // Statistics of the rendering, when it succeeds
+}
+
+// RenderStream renders the emails received from the channel with the given number of workers (at least 1), sending
+// a result per email to out as soon as it is rendered, in no particular order: RenderResult.Index tells the position of
+// its email. At most one output per worker is held at a time, whatever the number of emails.
+//
+// RenderStream returns once emails is closed and its results are sent, or once the context is done, and closes out in
+// both cases. When the context is done, the emails left in the channel are not read, and the results not yet received
+// are dropped: producers must stop on the context as well. Each rendering runs like RenderContext.
+func RenderStream(ctx context.Context, h Hermes, emails <-chan Email, out chan<- RenderResult, workers int) {
+	defer close(out)
+	if workers < 1 {
+		workers = 1
+	}
+	// Templates are parsed once for all the workers. When they do not parse, every rendering reports the error.
+	if h.templates == nil {
+		_ = h.Compile()
+	}
+
+	type job struct {
+		index int
+		email Email
+	}
+	jobs := make(chan job)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				start := time.Now()
+				result := RenderResult{Index: j.index}
+				result.Output, result.Err = RenderContext(ctx, h, j.email)
+				if result.Err == nil {
+					result.Stats = newRenderStats(h.Theme.Name(), result.Output, time.Since(start))
+				}
+				select {
+				case out <- result:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+
+	defer wg.Wait()
+	defer close(jobs)
+	for index := 0; ; index++ {
+		select {
+		case email, ok := <-emails:
+			if !ok {
+				return
+			}
+			select {
+			case jobs <- job{index, email}:
+			case <-ctx.Done():
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,351 @@
+package hermes
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StructMapper builds dictionaries and tables from Go structs, driven by `hermes` struct tags:
+//
+//	type Order struct {
+//		ID     string    `hermes:"label=Order ID"`
+//		Total  float64   `hermes:"format=money,align=right,width=15%"`
+//		Coupon string    `hermes:"omit_empty"`
+//		Date   time.Time `hermes:"format=datetime"`
+//		secret string    // unexported fields are skipped
+//		Notes  string    `hermes:"-"`
+//	}
+//
+// Fields keep their declaration order. Nested structs are flattened, their labels prefixed by the parent one.
+type StructMapper struct {
+	Separator string // Joins the labels of nested struct fields (default to " ")
+}
+
+// Formats of the `hermes` struct tag
+const (
+	FormatMoney    = "money"    // 1,234.50
+	FormatNumber   = "number"   // 1,234.5
+	FormatDate     = "date"     // January 2, 2006
+	FormatDateTime = "datetime" // January 2, 2006 15:04
+)
+
+// DictionaryFromStruct builds dictionary entries from the fields of a struct, or a pointer to a struct
+func DictionaryFromStruct(v interface{}) ([]Entry, error) {
+	return StructMapper{}.Dictionary(v)
+}
+
+// TableFromSlice builds a table from a slice of structs, or of pointers to structs, one row per element
+func TableFromSlice(v interface{}) (Table, error) {
+	return StructMapper{}.Table(v)
+}
+
+type structField struct {
+	label     string
+	index     []int
+	format    string
+	align     string
+	width     string
+	omitEmpty bool
+	path      string
+}
+
+// Dictionary builds dictionary entries from the fields of a struct, or a pointer to a struct
+func (m StructMapper) Dictionary(v interface{}) ([]Entry, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected a struct, got %T", v)
+	}
+	fields, err := m.fields(rv.Type(), nil, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []Entry{}
+	for _, f := range fields {
+		value, empty, err := f.value(rv)
+		if err != nil {
+			return nil, err
+		}
+		if empty && f.omitEmpty {
+			continue
+		}
+		entries = append(entries, Entry{Key: f.label, Value: value})
+	}
+	return entries, nil
+}
+
+// Table builds a table from a slice of structs, or of pointers to structs, one row per element.
+// In tables, omit_empty drops the columns that are empty on every row.
+func (m StructMapper) Table(v interface{}) (Table, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return Table{}, fmt.Errorf("expected a slice, got %T", v)
+	}
+	elem := rv.Type().Elem()
+	for elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return Table{}, fmt.Errorf("expected a slice of structs, got %T", v)
+	}
+	fields, err := m.fields(elem, nil, "", "")
+	if err != nil {
+		return Table{}, err
+	}
+
+	rows := make([][]Entry, rv.Len())
+	nonEmpty := make([]bool, len(fields))
+	for i := 0; i < rv.Len(); i++ {
+		row := rv.Index(i)
+		for row.Kind() == reflect.Ptr && !row.IsNil() {
+			row = row.Elem()
+		}
+		for j, f := range fields {
+			value := ""
+			empty := true
+			if row.Kind() == reflect.Struct {
+				if value, empty, err = f.value(row); err != nil {
+					return Table{}, fmt.Errorf("row %d: %v", i, err)
+				}
+			}
+			nonEmpty[j] = nonEmpty[j] || !empty
+			rows[i] = append(rows[i], Entry{Key: f.label, Value: value})
+		}
+	}
+
+	table := Table{}
+	for j, f := range fields {
+		if f.omitEmpty && !nonEmpty[j] {
+			continue
+		}
+		if f.width != "" {
+			if table.Columns.CustomWidth == nil {
+				table.Columns.CustomWidth = map[string]string{}
+			}
+			table.Columns.CustomWidth[f.label] = f.width
+		}
+		if f.align != "" {
+			if table.Columns.CustomAlignment == nil {
+				table.Columns.CustomAlignment = map[string]string{}
+			}
+			table.Columns.CustomAlignment[f.label] = f.align
+		}
+	}
+	for _, row := range rows {
+		var kept []Entry
+		for j, entry := range row {
+			if !fields[j].omitEmpty || nonEmpty[j] {
+				kept = append(kept, entry)
+			}
+		}
+		table.Data = append(table.Data, kept)
+	}
+	return table, nil
+}
+
+// fields lists the exported fields of a struct type, flattening nested structs
+func (m StructMapper) fields(t reflect.Type, index []int, labelPrefix, pathPrefix string) ([]structField, error) {
+	separator := m.Separator
+	if separator == "" {
+		separator = " "
+	}
+
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue // unexported
+		}
+		tag := sf.Tag.Get("hermes")
+		if tag == "-" {
+			continue
+		}
+		f := structField{
+			label: sf.Name,
+			index: append(append([]int{}, index...), i),
+			path:  pathPrefix + sf.Name,
+		}
+		for _, option := range strings.Split(tag, ",") {
+			key, value, _ := strings.Cut(strings.TrimSpace(option), "=")
+			switch key {
+			case "":
+			case "label":
+				f.label = value
+			case "format":
+				f.format = value
+			case "align":
+				f.align = value
+			case "width":
+				f.width = value
+			case "omit_empty":
+				f.omitEmpty = true
+			default:
+				return nil, fmt.Errorf("field %s: unknown tag option %q", f.path, key)
+			}
+		}
+
+		ft := sf.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && !isScalarStruct(ft) {
+			prefix := labelPrefix + f.label + separator
+			if sf.Anonymous && tag == "" {
+				prefix = labelPrefix
+			}
+			nested, err := m.fields(ft, f.index, prefix, f.path+".")
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, nested...)
+			continue
+		}
+		if !isSupportedType(sf.Type) {
+			return nil, fmt.Errorf("field %s: unsupported type %s", f.path, sf.Type)
+		}
+		f.label = labelPrefix + f.label
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// isScalarStruct tells if a struct type is displayed as a single value instead of being flattened
+func isScalarStruct(t reflect.Type) bool {
+	return t == timeType || t.Implements(stringerType)
+}
+
+func isSupportedType(t reflect.Type) bool {
+	if t.Implements(stringerType) {
+		return true
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		if t.Implements(stringerType) {
+			return true
+		}
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return t == timeType
+}
+
+// value returns the formatted value of the field in the struct, and whether it is empty
+func (f structField) value(v reflect.Value) (string, bool, error) {
+	for _, i := range f.index {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return "", true, nil
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", true, nil
+		}
+		if v.Type().Implements(stringerType) && !v.Type().Elem().Implements(stringerType) {
+			break // String() has a pointer receiver
+		}
+		v = v.Elem()
+	}
+	if v.Type() == timeType && v.IsZero() {
+		return "", true, nil
+	}
+	s, err := formatValue(f.format, v)
+	if err != nil {
+		return "", false, fmt.Errorf("field %s: %v", f.path, err)
+	}
+	return s, v.IsZero(), nil
+}
+
+func formatValue(format string, v reflect.Value) (string, error) {
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		switch format {
+		case "", FormatDate:
+			return t.Format("January 2, 2006"), nil
+		case FormatDateTime:
+			return t.Format("January 2, 2006 15:04"), nil
+		}
+		return "", fmt.Errorf("format %q does not apply to time.Time", format)
+	}
+
+	switch format {
+	case "":
+		if s, ok := v.Interface().(fmt.Stringer); ok {
+			return s.String(), nil
+		}
+		switch v.Kind() {
+		case reflect.Bool:
+			if v.Bool() {
+				return "Yes", nil
+			}
+			return "No", nil
+		case reflect.String:
+			return v.String(), nil
+		}
+		return fmt.Sprint(v.Interface()), nil
+	case FormatMoney, FormatNumber:
+		var s string
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			s = strconv.FormatInt(v.Int(), 10)
+			if format == FormatMoney {
+				s = strconv.FormatFloat(float64(v.Int()), 'f', 2, 64)
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			s = strconv.FormatUint(v.Uint(), 10)
+			if format == FormatMoney {
+				s = strconv.FormatFloat(float64(v.Uint()), 'f', 2, 64)
+			}
+		case reflect.Float32, reflect.Float64:
+			s = strconv.FormatFloat(v.Float(), 'f', -1, 64)
+			if format == FormatMoney {
+				s = strconv.FormatFloat(v.Float(), 'f', 2, 64)
+			}
+		default:
+			return "", fmt.Errorf("format %q needs a number, got %s", format, v.Type())
+		}
+		return groupThousands(s), nil
+	case FormatDate, FormatDateTime:
+		return "", fmt.Errorf("format %q needs a time.Time, got %s", format, v.Type())
+	}
+	return "", fmt.Errorf("unknown format %q", format)
+}
+
+// groupThousands adds thousands separators to a formatted number, like 1234567.5 -> 1,234,567.5
+func groupThousands(s string) string {
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	integer, decimals, hasDecimals := strings.Cut(s, ".")
+	var b strings.Builder
+	for i, r := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	if hasDecimals {
+		return sign + b.String() + "." + decimals
+	}
+	return sign + b.String()
+}
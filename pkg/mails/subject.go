@@ -0,0 +1,240 @@
+package hermes
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	texttemplate "text/template"
+	"unicode"
+)
+
+// HintCode identifies the kind of a Hint, it is stable across versions
+type HintCode string
+
+// Codes of hints
+const (
+	HintLength            HintCode = "length"                    // Length and estimated width, always given
+	HintEmptySubject      HintCode = "empty_subject"             // The subject is empty
+	HintTruncatedGmail    HintCode = "truncated_gmail"           // Gmail on desktop truncates the text
+	HintTruncatedIOS      HintCode = "truncated_ios"             // Mail on iPhone truncates the text
+	HintTooManyEmoji      HintCode = "too_many_emoji"            // More than 2 emoji
+	HintSpammyPunctuation HintCode = "spammy_punctuation"        // Repeated or excessive punctuation, e.g. "!!!" or "$$$"
+	HintAllCaps           HintCode = "all_caps"                  // Words in capitals, read as shouting
+	HintMissingPreheader  HintCode = "missing_preheader"         // Clients preview the beginning of the email instead
+	HintPreheaderRepeats  HintCode = "preheader_repeats_subject" // The preheader wastes the preview by repeating the subject
+)
+
+// Hint is a suggestion about the subject or the preheader of an email, found by LintSubject
+type Hint struct {
+	Code     HintCode `json:"code"`
+	Severity Severity `json:"severity"`
+	Path     string   `json:"path"` // Subject or Body.Preheader
+	Message  string   `json:"message"`
+	Preview  string   `json:"preview,omitempty"` // Text displayed by the client, for truncations
+}
+
+// previewLimits are the widths displayed by clients before truncating, by path
+var previewLimits = map[string][]struct {
+	code  HintCode
+	width int
+}{
+	"Subject":        {{HintTruncatedGmail, 70}, {HintTruncatedIOS, 41}},
+	"Body.Preheader": {{HintTruncatedGmail, 100}, {HintTruncatedIOS, 90}},
+}
+
+var spammyPunctuation = regexp.MustCompile(`[!?]{2,}|[$€£]{2,}`)
+
+// LintSubject returns hints about the subject and the preheader of an email: their length and estimated width, their
+// truncation by Gmail and iOS, emoji, spammy punctuation, capitals, and a preheader repeating the subject.
+// Widths count the characters of wide scripts (CJK, Hangul) and emoji as 2, and combining marks as 0.
+// Hints are in a stable order, subject first.
+func LintSubject(subject, preheader string) []Hint {
+	var hints []Hint
+	add := func(code HintCode, severity Severity, path, preview, format string, args ...interface{}) {
+		hints = append(hints, Hint{Code: code, Severity: severity, Path: path, Message: fmt.Sprintf(format, args...), Preview: preview})
+	}
+	lint := func(path, text string) {
+		width := textWidth(text)
+		add(HintLength, SeverityInfo, path, "", "%d characters, width %d", characterCount(text), width)
+		for _, limit := range previewLimits[path] {
+			if width > limit.width {
+				add(limit.code, SeverityInfo, path, truncateWidth(text, limit.width), "width %d exceeds %d", width, limit.width)
+			}
+		}
+		if n := emojiCount(text); n > 2 {
+			add(HintTooManyEmoji, SeverityWarning, path, "", "%d emoji, spam filters and readers dislike more than 2", n)
+		}
+		if match := spammyPunctuation.FindString(text); match != "" {
+			add(HintSpammyPunctuation, SeverityWarning, path, "", "%q looks like spam", match)
+		} else if n := strings.Count(text, "!"); n > 1 {
+			add(HintSpammyPunctuation, SeverityWarning, path, "", "%d exclamation marks look like spam", n)
+		}
+		if words := capsWords(text); len(words) >= 2 {
+			add(HintAllCaps, SeverityWarning, path, "", "%s read as shouting", strings.Join(words, " "))
+		}
+	}
+
+	if strings.TrimSpace(subject) == "" {
+		add(HintEmptySubject, SeverityWarning, "Subject", "", "the subject is empty")
+	} else {
+		lint("Subject", subject)
+	}
+	if strings.TrimSpace(preheader) == "" {
+		if strings.TrimSpace(subject) != "" {
+			add(HintMissingPreheader, SeverityInfo, "Body.Preheader", "", "clients preview the beginning of the email instead")
+		}
+		return hints
+	}
+	lint("Body.Preheader", preheader)
+	s, p := normalizeHintText(subject), normalizeHintText(preheader)
+	if s != "" && (strings.HasPrefix(p, s) || strings.Contains(s, p)) {
+		add(HintPreheaderRepeats, SeverityWarning, "Body.Preheader", "", "the preheader repeats the subject instead of completing it")
+	}
+	return hints
+}
+
+// normalizeHintText lowercases the text and keeps its letters and digits, for comparisons
+func normalizeHintText(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}), " ")
+}
+
+// capsWords returns the words of 4 letters or more written in capitals, acronyms are usually shorter
+func capsWords(s string) []string {
+	var words []string
+	for _, word := range strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		if len([]rune(word)) >= 4 && strings.ToUpper(word) == word && strings.ToLower(word) != word {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// isEmoji reports whether the rune is a pictograph displayed as emoji
+func isEmoji(r rune) bool {
+	return (r >= 0x1F300 && r <= 0x1FAFF && !isSkinTone(r)) || (r >= 0x2600 && r <= 0x27BF) || (r >= 0x1F000 && r <= 0x1F2FF)
+}
+
+func isSkinTone(r rune) bool {
+	return r >= 0x1F3FB && r <= 0x1F3FF
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// isZeroWidth reports whether the rune is not displayed by itself: combining marks, joiners, variation selectors and skin tones
+func isZeroWidth(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) || (r >= 0xFE00 && r <= 0xFE0F) || isSkinTone(r)
+}
+
+// isWide reports whether the rune is displayed on two columns, like CJK ideographs
+func isWide(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		(r >= 0x3000 && r <= 0x303F) || (r >= 0xFF01 && r <= 0xFF60) || (r >= 0xFFE0 && r <= 0xFFE6)
+}
+
+// glyphs splits the text into the units displayed by clients: a character with its combining marks, or an emoji sequence
+func glyphs(s string) [][]rune {
+	var out [][]rune
+	joined := false
+	for _, r := range s {
+		n := len(out)
+		switch {
+		case n > 0 && (isZeroWidth(r) || joined):
+			out[n-1] = append(out[n-1], r)
+		case n > 0 && isRegionalIndicator(r) && len(out[n-1]) == 1 && isRegionalIndicator(out[n-1][0]):
+			out[n-1] = append(out[n-1], r) // Flags are pairs of regional indicators
+		default:
+			out = append(out, []rune{r})
+		}
+		joined = r == 0x200D
+	}
+	return out
+}
+
+// glyphWidth returns the number of columns of the glyph
+func glyphWidth(g []rune) int {
+	if isEmoji(g[0]) || isRegionalIndicator(g[0]) || isWide(g[0]) {
+		return 2
+	}
+	if isZeroWidth(g[0]) {
+		return 0
+	}
+	return 1
+}
+
+// characterCount returns the number of characters of the text as perceived by readers
+func characterCount(s string) int {
+	return len(glyphs(s))
+}
+
+// textWidth returns the estimated width of the text, in columns of a Latin character
+func textWidth(s string) int {
+	width := 0
+	for _, g := range glyphs(s) {
+		width += glyphWidth(g)
+	}
+	return width
+}
+
+// emojiCount returns the number of emoji of the text, counting sequences like flags or families once
+func emojiCount(s string) int {
+	n := 0
+	for _, g := range glyphs(s) {
+		if isEmoji(g[0]) || isRegionalIndicator(g[0]) {
+			n++
+		}
+	}
+	return n
+}
+
+// truncateWidth returns the text as displayed by a client truncating it to the width, with an ellipsis
+func truncateWidth(s string, width int) string {
+	var b strings.Builder
+	used := 0
+	for _, g := range glyphs(s) {
+		if used+glyphWidth(g) > width-1 {
+			break
+		}
+		used += glyphWidth(g)
+		b.WriteString(string(g))
+	}
+	return strings.TrimRight(b.String(), " ") + "…"
+}
+
+// GenerateSubject generates the subject of the email: Email.Subject, or Body.Title when it is empty, executed as a
+// text template against the same data as the themes, e.g. "{{ .Email.Body.Name }}, your receipt for order
+// {{ .Email.Body.DictionaryValue "Order" }}". Whitespace is collapsed, so that the subject fits on one header line.
+func (h *Hermes) GenerateSubject(email Email) (string, error) {
+	engine, email, err := prepare(*h, email)
+	if err != nil {
+		return "", err
+	}
+	return engine.subject(email)
+}
+
+// subject generates the subject of the prepared email, see GenerateSubject
+func (h *Hermes) subject(email Email) (string, error) {
+	subject := email.Subject
+	if subject == "" {
+		subject = email.Body.Title
+	}
+	if !strings.Contains(subject, "{{") {
+		return strings.Join(strings.Fields(subject), " "), nil
+	}
+	funcs, err := h.funcs()
+	if err != nil {
+		return "", err
+	}
+	t, err := texttemplate.New("subject").Funcs(texttemplate.FuncMap(funcs)).Parse(subject)
+	if err != nil {
+		return "", fmt.Errorf("subject: %w", err)
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, Template{*h, email}); err != nil {
+		return "", fmt.Errorf("subject: %w", err)
+	}
+	return strings.Join(strings.Fields(b.String()), " "), nil
+}
@@ -0,0 +1,152 @@
+package hermes
+
+import (
+	"math"
+	"strconv"
+)
+
+// Summary is a digest of the activity of a period, e.g. a weekly report: metric cards, followed by their highlights.
+// It is built by chaining calls:
+//
+//	summary := new(hermes.Summary).
+//		Add("New members", 5).Compare(3).Highlight("Sansa joined the Winterfell team").
+//		Add("Incidents", 1).Compare(3).LowerIsBetter()
+type Summary struct {
+	Sections []SummarySection `json:"sections,omitempty" yaml:"sections,omitempty"`
+}
+
+// SummarySection is a metric of a Summary, with its highlights
+type SummarySection struct {
+	Label         string   `json:"label,omitempty" yaml:"label,omitempty"`                     // e.g. "Closed tickets"
+	Value         float64  `json:"value,omitempty" yaml:"value,omitempty"`                     // Value of the period
+	Previous      *float64 `json:"previous,omitempty" yaml:"previous,omitempty"`               // Value of the previous period, the change is not displayed when nil
+	Format        string   `json:"format,omitempty" yaml:"format,omitempty"`                   // FormatNumber (default) or FormatMoney
+	LowerIsBetter bool     `json:"lower_is_better,omitempty" yaml:"lower_is_better,omitempty"` // Decreases are good news, e.g. for incidents
+	Highlights    []string `json:"highlights,omitempty" yaml:"highlights,omitempty"`           // Bullet points, displayed under the metric cards
+}
+
+// Trends of a SummarySection
+const (
+	TrendUp   = "up"
+	TrendDown = "down"
+	TrendFlat = "flat"
+)
+
+// Add adds a metric to the summary. Following calls of Compare, Highlight, Format and LowerIsBetter apply to it.
+func (s *Summary) Add(label string, value float64) *Summary {
+	s.Sections = append(s.Sections, SummarySection{Label: label, Value: value})
+	return s
+}
+
+// Compare sets the value of the previous period of the last metric
+func (s *Summary) Compare(previous float64) *Summary {
+	if section := s.last(); section != nil {
+		section.Previous = &previous
+	}
+	return s
+}
+
+// Highlight adds bullet points to the last metric
+func (s *Summary) Highlight(highlights ...string) *Summary {
+	if section := s.last(); section != nil {
+		section.Highlights = append(section.Highlights, highlights...)
+	}
+	return s
+}
+
+// Format sets the format of the last metric, FormatNumber or FormatMoney
+func (s *Summary) Format(format string) *Summary {
+	if section := s.last(); section != nil {
+		section.Format = format
+	}
+	return s
+}
+
+// LowerIsBetter shows the decreases of the last metric as good news
+func (s *Summary) LowerIsBetter() *Summary {
+	if section := s.last(); section != nil {
+		section.LowerIsBetter = true
+	}
+	return s
+}
+
+func (s *Summary) last() *SummarySection {
+	if len(s.Sections) == 0 {
+		return nil
+	}
+	return &s.Sections[len(s.Sections)-1]
+}
+
+// Rows returns the sections by pairs, the cards of a row of the grid of themes
+func (s Summary) Rows() [][]SummarySection {
+	var rows [][]SummarySection
+	for i := 0; i < len(s.Sections); i += 2 {
+		rows = append(rows, s.Sections[i:min(i+2, len(s.Sections))])
+	}
+	return rows
+}
+
+// FormattedValue returns the value, e.g. 1,234 or 1,234.50 with FormatMoney
+func (s SummarySection) FormattedValue() string {
+	return formatMetric(s.Value, s.Format)
+}
+
+// Trend returns TrendUp, TrendDown or TrendFlat compared with the previous period, or "" without previous value
+func (s SummarySection) Trend() string {
+	switch {
+	case s.Previous == nil:
+		return ""
+	case s.Value > *s.Previous:
+		return TrendUp
+	case s.Value < *s.Previous:
+		return TrendDown
+	}
+	return TrendFlat
+}
+
+// Tone returns "good", "bad" or "neutral", whether the trend is good news
+func (s SummarySection) Tone() string {
+	trend := s.Trend()
+	switch {
+	case trend == TrendUp && !s.LowerIsBetter, trend == TrendDown && s.LowerIsBetter:
+		return "good"
+	case trend == TrendUp, trend == TrendDown:
+		return "bad"
+	}
+	return "neutral"
+}
+
+// Delta returns the change since the previous period, e.g. "▲ 2 (+67%)", or "" when there is none.
+// The percentage is left out when the previous value is zero, or when it rounds to zero.
+func (s SummarySection) Delta() string {
+	var arrow string
+	switch s.Trend() {
+	case TrendUp:
+		arrow = "▲ "
+	case TrendDown:
+		arrow = "▼ "
+	default:
+		return ""
+	}
+	delta := arrow + formatMetric(math.Abs(s.Value-*s.Previous), s.Format)
+	if *s.Previous == 0 {
+		return delta
+	}
+	percent := math.Round((s.Value - *s.Previous) / math.Abs(*s.Previous) * 100)
+	if percent == 0 {
+		return delta
+	}
+	sign := "+"
+	if percent < 0 {
+		sign = ""
+	}
+	return delta + " (" + sign + strconv.FormatFloat(percent, 'f', 0, 64) + "%)"
+}
+
+// formatMetric formats the value with thousands separators, with 2 decimals for money
+func formatMetric(v float64, format string) string {
+	if format == FormatMoney {
+		return groupThousands(strconv.FormatFloat(v, 'f', 2, 64))
+	}
+	return groupThousands(strconv.FormatFloat(v, 'f', -1, 64))
+}
@@ -0,0 +1,218 @@
+package hermes
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// SVGRasterizer converts SVG images to PNG for the clients not rendering SVG, see Hermes.Rasterizer.
+// Width and height are the dimensions the image is displayed at, 0 when unknown.
+type SVGRasterizer interface {
+	Rasterize(svg []byte, width, height int) (png []byte, err error)
+}
+
+// SVGSupport tells which clients of the CSS support dataset render SVG images. Gmail, Outlook and Yahoo do not.
+var SVGSupport = map[string]bool{ClientAppleMail: true}
+
+// isSVG tells if the image is an SVG: a data: URI of the image/svg+xml type, or a path ending with ".svg"
+func isSVG(image string) bool {
+	u, err := url.Parse(image)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Scheme, "data") {
+		return strings.HasPrefix(strings.ToLower(u.Opaque), "image/svg+xml")
+	}
+	return strings.HasSuffix(strings.ToLower(u.Path), ".svg")
+}
+
+// svgData returns the content of an SVG data: URI, base64 or percent-encoded
+func svgData(image string) ([]byte, error) {
+	header, data, ok := strings.Cut(image, ",")
+	if !ok {
+		return nil, errors.New("data: URI without content")
+	}
+	if strings.HasSuffix(strings.ToLower(header), ";base64") {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	s, err := url.PathUnescape(data)
+	return []byte(s), err
+}
+
+// cssURLs matches the url() references of styles, e.g. url('https://example.com/background.png'), which must point to
+// elements of the image in SVGs, e.g. url(#gradient)
+var cssURLs = regexp.MustCompile(`(?i)url\(\s*['"]?\s*([^'")\s]*)`)
+
+// ValidateSVG checks that the SVG image is safe to write in emails: without scripts, event handlers, embedded HTML or
+// entities, nor references to external resources, which clients rendering SVG would run or load. References to the
+// elements of the image, e.g. href="#icon", and embedded raster images are allowed.
+func ValidateSVG(svg []byte) error {
+	d := xml.NewDecoder(bytes.NewReader(svg))
+	d.Strict = false
+	checkReference := func(ref string) error {
+		ref = strings.TrimSpace(ref)
+		lower := strings.ToLower(ref)
+		if ref == "" || strings.HasPrefix(ref, "#") || strings.HasPrefix(lower, "data:image/") && !strings.HasPrefix(lower, "data:image/svg") {
+			return nil
+		}
+		return fmt.Errorf("svg: external reference %q", ref)
+	}
+	checkStyle := func(style string) error {
+		if strings.Contains(strings.ToLower(style), "@import") {
+			return errors.New("svg: style with @import")
+		}
+		for _, m := range cssURLs.FindAllStringSubmatch(style, -1) {
+			if err := checkReference(m[1]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	inStyle := false
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("svg: %w", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch strings.ToLower(t.Name.Local) {
+			case "script", "foreignobject", "iframe", "embed", "object":
+				return fmt.Errorf("svg: <%s> element", t.Name.Local)
+			}
+			inStyle = strings.EqualFold(t.Name.Local, "style")
+			for _, a := range t.Attr {
+				name := strings.ToLower(a.Name.Local)
+				switch {
+				case strings.HasPrefix(name, "on"):
+					return fmt.Errorf("svg: %s event handler", a.Name.Local)
+				case name == "href", name == "src":
+					if err := checkReference(a.Value); err != nil {
+						return err
+					}
+				case name == "style":
+					if err := checkStyle(a.Value); err != nil {
+						return err
+					}
+				}
+			}
+		case xml.EndElement:
+			inStyle = false
+		case xml.CharData:
+			if inStyle {
+				if err := checkStyle(string(t)); err != nil {
+					return err
+				}
+			}
+		case xml.ProcInst:
+			if t.Target == "xml-stylesheet" {
+				return errors.New("svg: external style sheet")
+			}
+		case xml.Directive:
+			if bytes.Contains(bytes.ToUpper(t), []byte("ENTITY")) {
+				return errors.New("svg: entity declaration")
+			}
+		}
+	}
+}
+
+// svgSupported tells if every client of the profile of the engine renders SVG images
+func (h *Hermes) svgSupported() bool {
+	clients := h.ClientProfile
+	if len(clients) == 0 {
+		clients = Clients
+	}
+	for _, client := range clients {
+		if !SVGSupport[client] {
+			return false
+		}
+	}
+	return true
+}
+
+// imageSource returns the source written for the image: itself unless it is an SVG, and the SVG only when every client
+// of the profile renders it and it is not a data: URI, which themes refuse. Otherwise it is the PNG fallback, or the SVG
+// data: URI rasterized by the Rasterizer of the engine. SVGs without fallback are written as they are.
+func (h *Hermes) imageSource(path, image, fallback string, width, height int) (string, error) {
+	if !isSVG(image) {
+		return image, nil
+	}
+	data := strings.HasPrefix(strings.ToLower(image), "data:")
+	switch {
+	case h.svgSupported() && !data:
+		return image, nil
+	case fallback != "":
+		return fallback, nil
+	case h.Rasterizer == nil || !data:
+		return image, nil
+	}
+	svg, err := svgData(image)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	if err := ValidateSVG(svg); err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	png, err := h.Rasterizer.Rasterize(svg, width, height)
+	if err != nil {
+		return "", fmt.Errorf("%s: rasterize: %w", path, err)
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(png), nil
+}
+
+// prepareImages replaces the SVG images of the brand of the prepared engine by their sources, see imageSource
+func (h *Hermes) prepareImages() error {
+	var err error
+	logo := &h.Brand.LogoImage
+	if logo.URL, err = h.imageSource("Brand.LogoImage.URL", logo.URL, logo.FallbackPNG, logo.Width, logo.Height); err != nil {
+		return err
+	}
+	if logo.DarkURL, err = h.imageSource("Brand.LogoImage.DarkURL", logo.DarkURL, logo.DarkFallbackPNG, logo.Width, logo.Height); err != nil {
+		return err
+	}
+	links := append([]SocialLink(nil), h.Brand.SocialLinks...)
+	for i, link := range links {
+		path := fmt.Sprintf("Brand.SocialLinks[%d].IconURL", i)
+		if links[i].IconURL, err = h.imageSource(path, link.IconURL, link.IconFallbackPNG, 24, 24); err != nil {
+			return err
+		}
+	}
+	if h.Brand.SocialLinks != nil {
+		h.Brand.SocialLinks = links
+	}
+	return nil
+}
+
+// unsafeSVGs returns the issues of the SVG data: images of the brand which do not pass ValidateSVG
+func (b Branding) unsafeSVGs() Issues {
+	images := []struct{ path, url string }{
+		{"Brand.Logo", b.Logo}, {"Brand.LogoImage.URL", b.LogoImage.URL}, {"Brand.LogoImage.DarkURL", b.LogoImage.DarkURL},
+	}
+	for i, link := range b.SocialLinks {
+		images = append(images, struct{ path, url string }{fmt.Sprintf("Brand.SocialLinks[%d].IconURL", i), link.IconURL})
+	}
+	var issues Issues
+	for _, image := range images {
+		if image.path == "Brand.LogoImage.URL" && image.url == b.Logo || !isSVG(image.url) || !strings.HasPrefix(strings.ToLower(image.url), "data:") {
+			continue
+		}
+		svg, err := svgData(image.url)
+		if err == nil {
+			err = ValidateSVG(svg)
+		}
+		if err != nil {
+			issues = append(issues, ValidationError{Code: CodeUnsafeSVG, Path: image.path, Message: err.Error()}.issue(SeverityError))
+		}
+	}
+	return issues
+}
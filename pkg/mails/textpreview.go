@@ -0,0 +1,251 @@
+package hermes
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// DefaultPreviewWidth is the width of the terminal of PreviewPlainText when none is given, that of most email clients
+// displaying plain text
+const DefaultPreviewWidth = 72
+
+// LineChange tells how a line of a TextPreview changed since the previous render
+type LineChange byte
+
+// Changes of lines
+const (
+	LineKept    LineChange = ' '
+	LineAdded   LineChange = '+'
+	LineRemoved LineChange = '-'
+)
+
+// Markers of the invisible characters in a TextPreview, each one column wide
+const (
+	MarkerTrailingSpace = '·' // Space or tab at the end of a line
+	MarkerTab           = '→' // Tab elsewhere
+	MarkerNoBreakSpace  = '⍽' // No-break spaces, U+00A0 and U+202F
+	MarkerRightToLeft   = '◂' // Right-to-left marks, embeddings, overrides and isolates
+	MarkerLeftToRight   = '▸' // Left-to-right marks, embeddings, overrides and isolates
+	MarkerInvisible     = '¦' // Other zero-width characters, e.g. first strong isolates and zero-width spaces
+)
+
+// TextPreview is the plain text body of an email as a reader sees it in a terminal of the given width
+type TextPreview struct {
+	Width int
+	Lines []PreviewLine
+}
+
+// PreviewLine is a line of a TextPreview
+type PreviewLine struct {
+	Number    int    // Number of the line in the text, or in the previous text for removed lines
+	Text      string // Line with its invisible characters replaced by markers
+	Columns   int    // Columns taken by the line in a terminal, tabs stopping every 8 columns
+	Overflow  bool   // Line wider than the terminal, wrapped by the reader
+	Wrap      int    // Offset in Text of the first character beyond the width of the terminal, when the line overflows
+	Invisible int    // Invisible characters replaced by markers
+	Change    LineChange
+}
+
+// PreviewPlainText annotates the plain text for a terminal of the width (default to DefaultPreviewWidth)
+func PreviewPlainText(text string, width int) TextPreview {
+	if width <= 0 {
+		width = DefaultPreviewWidth
+	}
+	p := TextPreview{Width: width}
+	for i, line := range splitLines(text) {
+		p.Lines = append(p.Lines, previewLine(line, i+1, width, LineKept))
+	}
+	return p
+}
+
+// DiffPlainText annotates the plain text like PreviewPlainText, with the lines added and removed since the previous text
+func DiffPlainText(previous, text string, width int) TextPreview {
+	if width <= 0 {
+		width = DefaultPreviewWidth
+	}
+	p := TextPreview{Width: width}
+	before, after := splitLines(previous), splitLines(text)
+	// Longest common subsequence of lines, texts of emails being short
+	lcs := make([][]int, len(before)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && before[i] == after[j]:
+			p.Lines = append(p.Lines, previewLine(after[j], j+1, width, LineKept))
+			i, j = i+1, j+1
+		case i < len(before) && (j == len(after) || lcs[i+1][j] >= lcs[i][j+1]):
+			p.Lines = append(p.Lines, previewLine(before[i], i+1, width, LineRemoved))
+			i++
+		default:
+			p.Lines = append(p.Lines, previewLine(after[j], j+1, width, LineAdded))
+			j++
+		}
+	}
+	return p
+}
+
+// Overflows returns the number of the lines of the text wider than the terminal
+func (p TextPreview) Overflows() int {
+	n := 0
+	for _, line := range p.Lines {
+		if line.Overflow && line.Change != LineRemoved {
+			n++
+		}
+	}
+	return n
+}
+
+// ANSI escape codes of Write
+const (
+	ansiReset    = "\x1b[0m"
+	ansiDim      = "\x1b[2m"
+	ansiRed      = "\x1b[31m"
+	ansiGreen    = "\x1b[32m"
+	ansiCyan     = "\x1b[36m"
+	ansiOverflow = "\x1b[41;97m" // White on red
+)
+
+// Write writes the preview under a column ruler, each line after a gutter with its change, a "!" when it overflows,
+// and its number. With color, ANSI escape codes highlight the overflowing columns, the markers and the changes;
+// without, the output is plain text.
+func (p TextPreview) Write(w io.Writer, color bool) error {
+	var b strings.Builder
+	paint := func(code, s string) string {
+		if !color || s == "" {
+			return s
+		}
+		return code + s + ansiReset
+	}
+
+	b.WriteString(strings.Repeat(" ", 9) + paint(ansiDim, ruler(p.Width)) + "\n")
+	for _, line := range p.Lines {
+		overflow, number := ' ', ""
+		if line.Overflow {
+			overflow = '!'
+		}
+		if line.Number > 0 {
+			number = strconv.Itoa(line.Number)
+		}
+		gutter := fmt.Sprintf("%c%c%4s | ", line.Change, overflow, number)
+
+		var text strings.Builder
+		for i, r := range line.Text {
+			switch {
+			case line.Overflow && i >= line.Wrap:
+				text.WriteString(paint(ansiOverflow, string(r)))
+			case isMarker(r):
+				text.WriteString(paint(ansiCyan, string(r)))
+			default:
+				text.WriteRune(r)
+			}
+		}
+		switch line.Change {
+		case LineAdded:
+			b.WriteString(paint(ansiGreen, gutter))
+		case LineRemoved:
+			b.WriteString(paint(ansiRed, gutter))
+		default:
+			b.WriteString(gutter)
+		}
+		b.WriteString(text.String() + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ruler returns a column ruler of the width, e.g. "----+----1----+----2"
+func ruler(width int) string {
+	var b strings.Builder
+	for column := 1; column <= width; column++ {
+		switch {
+		case column%10 == 0:
+			b.WriteString(strconv.Itoa(column / 10 % 10))
+		case column%5 == 0:
+			b.WriteByte('+')
+		default:
+			b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
+
+// splitLines returns the lines of the text, without the empty line after a final line break
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(strings.ReplaceAll(text, "\r\n", "\n"), "\n"), "\n")
+}
+
+// previewLine replaces the invisible characters of the line by markers and measures it
+func previewLine(line string, number, width int, change LineChange) PreviewLine {
+	l := PreviewLine{Number: number, Change: change}
+	trailing := len(strings.TrimRight(line, " \t"))
+	var b strings.Builder
+	for i, r := range line {
+		marker := invisibleMarker(r)
+		// Columns are those of the character, not of its marker: zero-width characters do not make lines overflow
+		switch marker {
+		case MarkerTab:
+			l.Columns += 8 - l.Columns%8
+		case MarkerRightToLeft, MarkerLeftToRight, MarkerInvisible:
+		default:
+			l.Columns += runewidth.RuneWidth(r)
+		}
+		if l.Columns > width && !l.Overflow {
+			l.Overflow, l.Wrap = true, b.Len()
+		}
+
+		if i >= trailing {
+			marker = MarkerTrailingSpace
+		}
+		if marker != 0 {
+			l.Invisible++
+			r = marker
+		}
+		b.WriteRune(r)
+	}
+	l.Text = b.String()
+	return l
+}
+
+// invisibleMarker returns the marker of the character, or 0 when it is visible
+func invisibleMarker(r rune) rune {
+	switch r {
+	case '\t':
+		return MarkerTab
+	case '\u00a0', '\u202f':
+		return MarkerNoBreakSpace
+	case '\u200f', '\u061c', '\u202b', '\u202e', '\u2067':
+		return MarkerRightToLeft
+	case '\u200e', '\u202a', '\u202d', '\u2066':
+		return MarkerLeftToRight
+	case '\u200b', '\u200c', '\u200d', '\u2060', '\u2068', '\u2069', '\u202c', '\ufeff', '\u00ad':
+		return MarkerInvisible
+	}
+	return 0
+}
+
+func isMarker(r rune) bool {
+	switch r {
+	case MarkerTrailingSpace, MarkerTab, MarkerNoBreakSpace, MarkerRightToLeft, MarkerLeftToRight, MarkerInvisible:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package hermes
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/unknowns24/hermes/pkg/core"
+)
+
+// Codes of the issues found by ValidateTheme
+const (
+	IssueThemeTemplate = "theme_template" // Template which does not parse
+	IssueThemeMetadata = "theme_metadata" // Metadata which the templates contradict
+)
+
+// layoutMediaQuery matches the media queries on the viewport, unlike the ones on the color scheme
+var layoutMediaQuery = regexp.MustCompile(`@media[^{]*(min|max)-(device-)?width`)
+
+// ValidateTheme checks that the templates of the theme parse, including the one of a PlainTextTheme, and that its
+// metadata, if it implements core.ThemeMetadata, is consistent with its HTML template: the media queries and dark
+// styles it claims to have are found in it, and it renders the data of this version.
+// It returns Issues listing the errors, and the warnings about features the metadata does not claim, or nil.
+func ValidateTheme(theme Theme) error {
+	var issues Issues
+
+	funcs, err := (&Hermes{}).funcs()
+	if err != nil {
+		return err
+	}
+	html := theme.HTMLTemplate()
+	if _, err := parseTemplate(html, funcs); err != nil {
+		issues = append(issues, Issue{Code: IssueThemeTemplate, Severity: SeverityError, Path: "HTMLTemplate", Message: err.Error(), Err: err})
+	}
+	if _, err := parseTemplate(theme.PlainTextTemplate(), funcs); err != nil {
+		issues = append(issues, Issue{Code: IssueThemeTemplate, Severity: SeverityError, Path: "PlainTextTemplate", Message: err.Error(), Err: err})
+	}
+	if t, ok := theme.(PlainTextTheme); ok {
+		if _, err := parseTextTemplate(t.TextTemplate(), funcs); err != nil {
+			issues = append(issues, Issue{Code: IssueThemeTemplate, Severity: SeverityError, Path: "TextTemplate", Message: err.Error(), Err: err})
+		}
+	}
+
+	if metadata, ok := core.MetadataOf(theme); ok {
+		issues = append(issues, checkMetadata(metadata, html)...)
+	}
+	if len(issues) > 0 {
+		return issues
+	}
+	return nil
+}
+
+// checkMetadata returns the issues of the metadata of a theme which the HTML template contradicts
+func checkMetadata(metadata core.Metadata, html string) Issues {
+	var issues Issues
+	add := func(severity Severity, path, message string) {
+		issues = append(issues, Issue{Code: IssueThemeMetadata, Severity: severity, Path: path, Message: message})
+	}
+	if len(metadata.Widths) == 0 {
+		add(SeverityError, "Metadata.Widths", "no width declared")
+	}
+	for i, width := range metadata.Widths {
+		if width <= 0 {
+			add(SeverityError, fmt.Sprintf("Metadata.Widths[%d]", i), fmt.Sprintf("width %d is not positive", width))
+		}
+	}
+
+	switch queries := layoutMediaQuery.MatchString(html); {
+	case metadata.MediaQueries && !queries:
+		add(SeverityError, "Metadata.MediaQueries", "claims media queries, the HTML template has none on the width")
+	case !metadata.MediaQueries && queries:
+		add(SeverityWarning, "Metadata.MediaQueries", "does not claim media queries, the HTML template has some on the width")
+	}
+
+	dark, outlook := strings.Contains(html, "prefers-color-scheme"), strings.Contains(html, "[data-ogsc]")
+	switch metadata.DarkMode {
+	case "", core.DarkModeNone:
+		if dark || outlook {
+			add(SeverityWarning, "Metadata.DarkMode", "does not claim dark mode support, the HTML template has dark styles")
+		}
+	case core.DarkModeMediaQuery, core.DarkModeFull:
+		if !dark {
+			add(SeverityError, "Metadata.DarkMode", "claims dark mode support, the HTML template has no prefers-color-scheme media query")
+		}
+		if metadata.DarkMode == core.DarkModeFull && !outlook {
+			add(SeverityError, "Metadata.DarkMode", "claims full dark mode support, the HTML template has no [data-ogsc] styles for Outlook.com")
+		}
+	default:
+		add(SeverityError, "Metadata.DarkMode", fmt.Sprintf("unknown dark mode support %q", metadata.DarkMode))
+	}
+
+	if metadata.MinDataVersion > core.DataVersion {
+		add(SeverityError, "Metadata.MinDataVersion",
+			fmt.Sprintf("requires data version %d, this version of hermes renders version %d", metadata.MinDataVersion, core.DataVersion))
+	}
+	return issues
+}
@@ -0,0 +1,95 @@
+package hermes
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Sections of the email given an anchor, see Anchor
+const (
+	AnchorDictionary = "dictionary" // Body.Dictionary, of the id "details"
+	AnchorHighlights = "highlights" // Highlights of a section of Body.Summary, titled by its label
+	AnchorTable      = "table"      // Table of Body.AllTables with a title
+)
+
+// Anchor is the id of a titled section of the email, which links of the table of contents point at.
+// Links percent-encode the ids of titles in other scripts than Latin, which browsers decode to find them.
+type Anchor struct {
+	ID      string // Slug of the title, e.g. "order-details", suffixed by a number when already used, e.g. "order-details-2"
+	Title   string // Empty for the dictionary, titled by the "toc.details" string of the locale
+	Section string // AnchorDictionary, AnchorHighlights or AnchorTable
+	Index   int    // Index of the summary section or of the table, 0 for the dictionary
+}
+
+// Anchors returns the anchors of the sections the themes render, in their order: the dictionary, the highlights of the
+// summary, then the tables with a title. Their ids only depend on the titles and their order, not on the locale.
+func (b Body) Anchors() []Anchor {
+	if b.FreeMarkdown != "" {
+		return nil
+	}
+	var anchors []Anchor
+	used := map[string]bool{}
+	if len(b.Dictionary) > 0 {
+		anchors = append(anchors, Anchor{ID: uniqueSlug("details", used), Section: AnchorDictionary})
+	}
+	add := func(title, section string, index int) {
+		anchors = append(anchors, Anchor{ID: uniqueSlug(title, used), Title: title, Section: section, Index: index})
+	}
+	if b.Summary != nil {
+		for i, section := range b.Summary.Sections {
+			if len(section.Highlights) > 0 {
+				add(section.Label, AnchorHighlights, i)
+			}
+		}
+	}
+	for i, table := range b.AllTables() {
+		if table.Title != "" && (len(table.Data) > 0 || table.ShowEmpty) {
+			add(table.Title, AnchorTable, i)
+		}
+	}
+	return anchors
+}
+
+// AnchorID returns the id of the section of the index, or an empty string when it has no anchor
+func (b Body) AnchorID(section string, index int) string {
+	for _, anchor := range b.Anchors() {
+		if anchor.Section == section && anchor.Index == index {
+			return anchor.ID
+		}
+	}
+	return ""
+}
+
+// uniqueSlug returns the slug of the title, suffixed by the first number from 2 making it unused, and marks it used
+func uniqueSlug(title string, used map[string]bool) string {
+	slug := slugify(title)
+	id := slug
+	for n := 2; used[id]; n++ {
+		id = slug + "-" + strconv.Itoa(n)
+	}
+	used[id] = true
+	return id
+}
+
+// slugify returns the letters, marks and digits of the title in lower case, in any script, their other runs replaced
+// by single hyphens, or "section" when it has none
+func slugify(title string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range title {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r) {
+			hyphen = b.Len() > 0
+			continue
+		}
+		if hyphen {
+			b.WriteByte('-')
+			hyphen = false
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	if b.Len() == 0 {
+		return "section"
+	}
+	return b.String()
+}
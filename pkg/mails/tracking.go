@@ -0,0 +1,138 @@
+package hermes
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// LinkRewriterFunc rewrites the absolute http(s) links of the emails, e.g. to redirect them through a click tracker,
+// see Hermes.LinkRewriter
+type LinkRewriterFunc func(link string) string
+
+// TrackLinksStage adds Hermes.TrackingParams to the links of the HTML version, and rewrites them with
+// Hermes.LinkRewriter. The URLs of the plain text version are rewritten once converted.
+var TrackLinksStage = Stage{Name: StageTrackLinks, Run: trackLinks}
+
+// AddQueryParams returns the link with the params added to its query, before its fragment. The parameters the link
+// already has are kept as they are, e.g. a utm_campaign chosen by the author of the email.
+func AddQueryParams(link string, params map[string]string) string {
+	rest, fragment, hasFragment := strings.Cut(link, "#")
+	base, query, _ := strings.Cut(rest, "?")
+	existing, _ := url.ParseQuery(query)
+	var added []string
+	for key, value := range params {
+		if _, ok := existing[key]; !ok {
+			added = append(added, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+	if len(added) == 0 {
+		return link
+	}
+	sort.Strings(added)
+	if query != "" && !strings.HasSuffix(query, "&") {
+		query += "&"
+	}
+	link = base + "?" + query + strings.Join(added, "&")
+	if hasFragment {
+		link += "#" + fragment
+	}
+	return link
+}
+
+// tracksLinks reports whether the links of the emails are rewritten
+func (h *Hermes) tracksLinks() bool {
+	return len(h.TrackingParams) > 0 || h.LinkRewriter != nil
+}
+
+// trackLink returns the link with the tracking parameters, rewritten by LinkRewriter. Only absolute http(s) links are
+// rewritten: mailto:, tel:, anchors and the references to attachments are returned as they are.
+func (h *Hermes) trackLink(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Host == "" || !strings.EqualFold(u.Scheme, "http") && !strings.EqualFold(u.Scheme, "https") {
+		return link
+	}
+	if len(h.TrackingParams) > 0 {
+		link = AddQueryParams(link, h.TrackingParams)
+	}
+	if h.LinkRewriter != nil {
+		link = h.LinkRewriter(link)
+	}
+	return link
+}
+
+// hrefAttr matches the href attributes of a tag, their value quoted or not
+var hrefAttr = regexp.MustCompile(`(?i)(\shref\s*=\s*)("[^"]*"|'[^']*'|[^\s"'>]+)`)
+
+// rewriteHrefs rewrites the values of the href attributes of the raw HTML
+func (h *Hermes) rewriteHrefs(raw string) string {
+	return hrefAttr.ReplaceAllStringFunc(raw, func(attr string) string {
+		m := hrefAttr.FindStringSubmatch(attr)
+		link := html.UnescapeString(strings.Trim(m[2], `"'`))
+		tracked := h.trackLink(link)
+		if tracked == link {
+			return attr
+		}
+		return m[1] + `"` + html.EscapeString(tracked) + `"`
+	})
+}
+
+func trackLinks(r *Rendering) error {
+	// The URLs of the plain text version are rewritten once converted, see generatePlainText
+	if !r.Hermes.tracksLinks() || r.PlainText {
+		return nil
+	}
+	// The tokens are copied as is, so that only the links change
+	var b strings.Builder
+	b.Grow(len(r.HTML))
+	z := html.NewTokenizer(strings.NewReader(r.HTML))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		raw := string(z.Raw())
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			// Unsubscribe links are left as they are, their tokens may be signed
+			if t := z.Token(); (t.Data == "a" || t.Data == "area") && attrValue(t, "data-hermes") != "unsubscribe" {
+				raw = r.Hermes.rewriteHrefs(raw)
+			}
+		case html.CommentToken:
+			// The VML buttons of Outlook are in conditional comments, e.g. <!--[if mso]><v:roundrect href="...">
+			if strings.HasPrefix(raw, "<!--[if") {
+				raw = r.Hermes.rewriteHrefs(raw)
+			}
+		}
+		b.WriteString(raw)
+	}
+	r.HTML = b.String()
+	return nil
+}
+
+func attrValue(t html.Token, key string) string {
+	for _, a := range t.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// textURLs matches the URLs of plain text, up to the whitespace, brackets or quotes around them
+var textURLs = regexp.MustCompile(`(?i)https?://[^\s<>"'()\[\]]+`)
+
+// trackTextLinks rewrites the URLs of the plain text version like the links of the HTML one, but the unsubscribe link
+func (h *Hermes) trackTextLinks(text string, unsubscribe *Unsubscribe) string {
+	return textURLs.ReplaceAllStringFunc(text, func(link string) string {
+		// Punctuation ends sentences rather than URLs
+		trimmed := strings.TrimRight(link, ".,;:!?")
+		if unsubscribe != nil && (trimmed == unsubscribe.URL || trimmed == unsubscribe.OneClickURL) {
+			return link
+		}
+		return h.trackLink(trimmed) + link[len(trimmed):]
+	})
+}
@@ -0,0 +1,45 @@
+package hermes
+
+// Unsubscribe are the ways for recipients to unsubscribe, which Gmail and Yahoo require from bulk senders
+type Unsubscribe struct {
+	URL         string `json:"url,omitempty" yaml:"url,omitempty"`                     // Page linked in the footer, e.g. https://hermes-example.com/unsubscribe?token=...
+	Mailto      string `json:"mailto,omitempty" yaml:"mailto,omitempty"`               // Address receiving unsubscribe requests, e.g. unsubscribe@hermes-example.com?subject=d9729feb
+	OneClickURL string `json:"one_click_url,omitempty" yaml:"one_click_url,omitempty"` // HTTPS URL receiving the one-click POST requests of RFC 8058, without confirmation page
+}
+
+// Link returns the link of the footer: URL, or else a mailto link to Mailto
+func (u Unsubscribe) Link() string {
+	if u.URL == "" && u.Mailto != "" {
+		return "mailto:" + u.Mailto
+	}
+	return u.URL
+}
+
+// UnsubscribeOf returns the unsubscribe options of the email: the fields of Email.Unsubscribe which are set, or else
+// the ones of Branding.Unsubscribe, with Branding.UnsubscribeLink as URL, of the branding of the email (see BrandOf).
+// It returns nil when none is set.
+func (h *Hermes) UnsubscribeOf(email Email) *Unsubscribe {
+	var u Unsubscribe
+	brand := h.BrandOf(email)
+	if brand.Unsubscribe != nil {
+		u = *brand.Unsubscribe
+	}
+	if u.URL == "" {
+		u.URL = brand.UnsubscribeLink
+	}
+	if e := email.Unsubscribe; e != nil {
+		if e.URL != "" {
+			u.URL = e.URL
+		}
+		if e.Mailto != "" {
+			u.Mailto = e.Mailto
+		}
+		if e.OneClickURL != "" {
+			u.OneClickURL = e.OneClickURL
+		}
+	}
+	if u == (Unsubscribe{}) {
+		return nil
+	}
+	return &u
+}
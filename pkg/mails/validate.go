@@ -0,0 +1,196 @@
+package hermes
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ValidationCode identifies the kind of a ValidationError, it is stable across versions
+type ValidationCode string
+
+// Codes of validation errors
+const (
+	CodeEntryValueConflict      ValidationCode = "entry_value_conflict"      // Entry sets both Value and HTMLValue
+	CodeButtonLinkNotAbsolute   ValidationCode = "button_link_not_absolute"  // Button link is not an absolute URL
+	CodeNoReplyWithoutContact   ValidationCode = "no_reply_without_contact"  // Sent from a no-reply address without a way to reach you
+	CodeWebFontNotHTTPS         ValidationCode = "web_font_not_https"        // Web font not served over https
+	CodeTooManyWebFonts         ValidationCode = "too_many_web_fonts"        // More than 2 web font files
+	CodeInvalidTextDirection    ValidationCode = "invalid_text_direction"    // Text direction other than ltr and rtl
+	CodeButtonLinkMissing       ValidationCode = "button_link_missing"       // Button with a text but no link
+	CodeTableColumnMismatch     ValidationCode = "table_column_mismatch"     // Table row without as many columns as the first one
+	CodeInvalidLogoURL          ValidationCode = "invalid_logo_url"          // Logo which is not an absolute URL nor an embedded image
+	CodeInvalidMask             ValidationCode = "invalid_mask"              // Entry with an unknown mask, or masking HTMLValue
+	CodeInvalidLanguageVariants ValidationCode = "invalid_language_variants" // No language variant, or a variant with an empty or duplicate tag
+	CodeBrandNameMissing        ValidationCode = "brand_name_missing"        // Brand configuration without name
+	CodeBrandURLNotHTTPS        ValidationCode = "brand_url_not_https"       // Brand configuration with a link or an image which is not an absolute https URL
+	CodeUnsafeSVG               ValidationCode = "unsafe_svg"                // SVG image with scripts or external references, see ValidateSVG
+	CodeInvalidActionsLayout    ValidationCode = "invalid_actions_layout"    // Unknown layout of the actions, or an invite code in the inline layout
+	CodeSignatureConflict       ValidationCode = "signature_conflict"        // Both Signature and SignatureBlock, of which the block is displayed (warning)
+)
+
+// ValidationCodes lists all the codes of validation errors
+var ValidationCodes = []ValidationCode{
+	CodeEntryValueConflict,
+	CodeButtonLinkNotAbsolute,
+	CodeNoReplyWithoutContact,
+	CodeWebFontNotHTTPS,
+	CodeTooManyWebFonts,
+	CodeInvalidTextDirection,
+	CodeButtonLinkMissing,
+	CodeTableColumnMismatch,
+	CodeInvalidLogoURL,
+	CodeInvalidMask,
+	CodeInvalidLanguageVariants,
+	CodeBrandNameMissing,
+	CodeBrandURLNotHTTPS,
+	CodeUnsafeSVG,
+	CodeInvalidActionsLayout,
+	CodeSignatureConflict,
+}
+
+// ValidationError is the underlying error of the issues found by Email.Validate, Branding.Validate,
+// TextDirection.Validate and ValidateSender
+type ValidationError struct {
+	Code    ValidationCode
+	Path    string // Path of the field, e.g. Body.Actions[0].Button.Link
+	Value   string // Offending value, if any
+	Message string // Technical message, in English
+}
+
+func (e ValidationError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// Localize returns a message explaining the error to end users, in the locale of the tag (e.g. "fr-FR").
+// It falls back to English when the locale is not available.
+func (e ValidationError) Localize(tag string) string {
+	return strings.NewReplacer("{FIELD}", e.Path, "{VALUE}", e.Value).Replace(translate(tag, "validation."+string(e.Code)))
+}
+
+// issue returns the error as an Issue of the severity
+func (e ValidationError) issue(severity Severity) Issue {
+	return Issue{Code: string(e.Code), Severity: severity, Path: e.Path, Message: e.Message, Err: e}
+}
+
+// Validate checks the email for mistakes that rendering does not report, and which render broken HTML: buttons without
+// link, table rows without as many columns as the first one, entries with two values...
+// It returns Issues listing all of them, with a ValidationError as underlying error, or nil.
+func (e Email) Validate() error {
+	var issues Issues
+	add := func(code ValidationCode, path, value, message string) {
+		issues = append(issues, ValidationError{Code: code, Path: path, Value: value, Message: message}.issue(SeverityError))
+	}
+	warn := func(code ValidationCode, path, value, message string) {
+		issues = append(issues, ValidationError{Code: code, Path: path, Value: value, Message: message}.issue(SeverityWarning))
+	}
+
+	checkEntry := func(path string, entry Entry) {
+		if entry.Value != "" && entry.HTMLValue != "" {
+			add(CodeEntryValueConflict, path, "", "sets both Value and HTMLValue")
+		}
+		if message := checkMask(entry); message != "" {
+			add(CodeInvalidMask, path+".Mask", entry.Mask, message)
+		}
+	}
+	checkTable := func(path string, table Table) {
+		for i, row := range table.Data {
+			if len(row) != len(table.Data[0]) {
+				add(CodeTableColumnMismatch, fmt.Sprintf("%s.Data[%d]", path, i), fmt.Sprint(len(row)),
+					fmt.Sprintf("has %d columns, expected %d", len(row), len(table.Data[0])))
+			}
+			for j, cell := range row {
+				checkEntry(fmt.Sprintf("%s.Data[%d][%d]", path, i, j), cell)
+			}
+		}
+		for i, entry := range table.Footer {
+			checkEntry(fmt.Sprintf("%s.Footer[%d]", path, i), entry)
+		}
+	}
+	checkBody := func(path string, body Body) {
+		for i, entry := range body.Dictionary {
+			checkEntry(fmt.Sprintf("%s.Dictionary[%d]", path, i), entry)
+		}
+		checkTable(path+".Table", body.Table)
+		for i, table := range body.Tables {
+			checkTable(fmt.Sprintf("%s.Tables[%d]", path, i), table)
+		}
+
+		if body.SignatureBlock != nil && body.Signature != "" {
+			warn(CodeSignatureConflict, path+".Signature", body.Signature, signatureConflict)
+		}
+
+		switch body.ActionsLayout {
+		case "", ActionsList, ActionsSteps, ActionsInline:
+		default:
+			add(CodeInvalidActionsLayout, path+".ActionsLayout", body.ActionsLayout, "must be list, steps or inline")
+		}
+		for i, action := range body.Actions {
+			if action.InviteCode != "" && body.ActionsLayout == ActionsInline {
+				add(CodeInvalidActionsLayout, fmt.Sprintf("%s.Actions[%d].InviteCode", path, i), body.ActionsLayout, "invite codes are not displayed by the inline layout")
+			}
+			switch {
+			case action.Button.Text == "":
+				continue
+			case action.Button.Link == "":
+				add(CodeButtonLinkMissing, fmt.Sprintf("%s.Actions[%d].Button.Link", path, i), "", "empty link with non-empty text")
+				continue
+			}
+			if u, err := url.Parse(action.Button.Link); err != nil || !u.IsAbs() {
+				add(CodeButtonLinkNotAbsolute, fmt.Sprintf("%s.Actions[%d].Button.Link", path, i), action.Button.Link, "must be an absolute URL")
+			}
+		}
+	}
+
+	if e.LanguageVariants == nil {
+		checkBody("Body", e.Body)
+	} else if len(e.LanguageVariants) == 0 {
+		add(CodeInvalidLanguageVariants, "LanguageVariants", "", "must list at least one variant")
+	}
+	seen := map[string]bool{}
+	for i, variant := range e.LanguageVariants {
+		path := fmt.Sprintf("LanguageVariants[%d]", i)
+		tag := normalizeLocale(variant.Tag)
+		switch {
+		case tag == "":
+			add(CodeInvalidLanguageVariants, path+".Tag", "", "empty tag")
+		case seen[tag]:
+			add(CodeInvalidLanguageVariants, path+".Tag", variant.Tag, "duplicate tag")
+		}
+		seen[tag] = true
+		checkBody(path+".Body", variant.Body)
+	}
+
+	if len(issues) > 0 {
+		return issues
+	}
+	return nil
+}
+
+// noReply matches the local part of no-reply addresses
+var noReply = regexp.MustCompile(`(?i)^(no|do-?not)[-_.]?reply([-_.+].*)?$`)
+
+// ValidateSender checks that recipients of the email sent from the address can reach you:
+// when sending from a no-reply address, a reply-to address or contact instructions should be set.
+// It returns Issues of the SeverityWarning severity, or nil.
+func ValidateSender(from, replyTo string, email Email) error {
+	address := from
+	if a, err := mail.ParseAddress(from); err == nil {
+		address = a.Address
+	}
+	local, _, _ := strings.Cut(address, "@")
+	if !noReply.MatchString(local) || replyTo != "" {
+		return nil
+	}
+	if c := email.Body.ContactInstructions; c != nil && (c.Email != "" || c.URL != "") {
+		return nil
+	}
+	return Issues{ValidationError{
+		Code:    CodeNoReplyWithoutContact,
+		Path:    "From",
+		Value:   from,
+		Message: "no-reply address without reply-to address nor contact instructions",
+	}.issue(SeverityWarning)}
+}
@@ -0,0 +1,92 @@
+package hermes
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LanguageVariant is the body of an email in a language, for recipients whose language is not known, e.g. in
+// English then in French. The variants of Email.LanguageVariants are rendered one after the other, separated by a
+// divider labeled with the names of their languages, such as "English / Français". Each one gets the default greeting
+// and signature of its locale, and its text direction; the header and the footer are shared, in the locale of the
+// first variant.
+type LanguageVariant struct {
+	Tag  string `json:"tag,omitempty" yaml:"tag,omitempty"` // Locale of the variant, e.g. "en" or "fr-CA"
+	Body Body   `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// prepareVariants returns the email of which each language variant is prepared in its locale, like the body of other
+// emails. Body is set to the first variant, e.g. for the subject and the preheader.
+func prepareVariants(h *Hermes, email Email) (Email, error) {
+	variants := make([]LanguageVariant, len(email.LanguageVariants))
+	for i, variant := range email.LanguageVariants {
+		e := email
+		e.Body = variant.Body
+		prepared, err := prepareBody(h, e, variant.Tag)
+		if err != nil {
+			return Email{}, fmt.Errorf("LanguageVariants[%d].%w", i, err)
+		}
+		// The variants are written alike, the first warning of each kind is enough
+		for _, warning := range prepared.warnings {
+			if !hasIssue(email.warnings, warning.Code) {
+				warning.Path = fmt.Sprintf("LanguageVariants[%d].%s", i, warning.Path)
+				email.warnings = append(email.warnings, warning)
+			}
+		}
+		variants[i] = LanguageVariant{Tag: variant.Tag, Body: prepared.Body}
+	}
+	email.Body = variants[0].Body
+	email.LanguageVariants = variants
+	return email, nil
+}
+
+// hasIssue reports whether one of the issues has the code
+func hasIssue(issues Issues, code string) bool {
+	for _, issue := range issues {
+		if issue.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Variants returns the data of the templates for each language variant of the email, with its body, and the locale
+// and the text direction of its tag. It returns the data itself when the email has no variants, so that themes can
+// range over it in any case.
+func (t Template) Variants() []Template {
+	if len(t.Email.LanguageVariants) == 0 {
+		return []Template{t}
+	}
+	variants := make([]Template, len(t.Email.LanguageVariants))
+	for i, variant := range t.Email.LanguageVariants {
+		v := t
+		v.Hermes.Locale = variant.Tag
+		v.Hermes.TextDirection = textDirection(variant.Tag)
+		v.Email.Body = variant.Body
+		variants[i] = v
+	}
+	return variants
+}
+
+// LanguagesLabel returns the label of the dividers between the language variants: the names of their languages, in
+// themselves, separated by slashes, e.g. "English / Français". Languages without localization are named by their tag.
+func (t Template) LanguagesLabel() string {
+	names := make([]string, len(t.Email.LanguageVariants))
+	for i, variant := range t.Email.LanguageVariants {
+		names[i] = languageName(variant.Tag)
+	}
+	return strings.Join(names, " / ")
+}
+
+// languageName returns the name of the language of the locale in itself, or the locale when it is unknown. Unlike
+// translate, it does not fall back to DefaultLocale.
+func languageName(locale string) string {
+	normalized := normalizeLocale(locale)
+	language, _, _ := strings.Cut(normalized, "-")
+	for _, l := range []string{normalized, language} {
+		if name := locales[l][keyLanguage]; name != "" {
+			return name
+		}
+	}
+	return locale
+}
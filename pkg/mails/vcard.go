@@ -0,0 +1,97 @@
+package hermes
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// VCard is a contact card, e.g. of your support team in an onboarding email.
+// It is sent as a .vcf attachment, along with an action linking to the same card hosted on your site for clients dropping attachments.
+type VCard struct {
+	Name  string // e.g. Hermes Support
+	Org   string
+	Email string
+	Phone string
+	URL   string
+}
+
+// String returns the card in vCard 3.0 format (RFC 2426): CRLF line endings, lines folded at 75 octets,
+// and backslashes, commas, semicolons and newlines of the values escaped
+func (v VCard) String() string {
+	var b strings.Builder
+	line := func(name, value string) {
+		if value != "" {
+			writeFolded(&b, name+":"+value)
+		}
+	}
+	line("BEGIN", "VCARD")
+	line("VERSION", "3.0")
+	// The name is structured as family name;given name;additional names;prefixes;suffixes
+	given, family := "", v.Name
+	if i := strings.LastIndex(v.Name, " "); i >= 0 {
+		given, family = v.Name[:i], v.Name[i+1:]
+	}
+	writeFolded(&b, "N:"+vcardEscape(family)+";"+vcardEscape(given)+";;;")
+	writeFolded(&b, "FN:"+vcardEscape(v.Name))
+	line("ORG", vcardEscape(v.Org))
+	line("EMAIL;TYPE=INTERNET", vcardEscape(v.Email))
+	line("TEL;TYPE=WORK,VOICE", vcardEscape(v.Phone))
+	line("URL", vcardEscape(v.URL))
+	line("END", "VCARD")
+	return b.String()
+}
+
+// Attachment returns the card as a .vcf attachment, named after the card
+func (v VCard) Attachment() Attachment {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ':
+			return '-'
+		case strings.ContainsRune(`/\:*?"<>|`, r), r < ' ':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(v.Name))
+	if name == "" {
+		name = "contact"
+	}
+	return Attachment{
+		Filename:    name + ".vcf",
+		ContentType: "text/vcard; charset=utf-8",
+		Data:        []byte(v.String()),
+	}
+}
+
+// Action returns the action to save the card, in the locale (e.g. "fr-FR").
+// Its button links to the card hosted at fallbackURL, for the clients not displaying the attachment.
+func (v VCard) Action(locale, fallbackURL string) Action {
+	return Action{
+		Instructions: strings.ReplaceAll(translate(locale, "vcard.instructions"), "{NAME}", v.Name),
+		Button: Button{
+			Text: translate(locale, "vcard.button"),
+			Link: fallbackURL,
+		},
+	}
+}
+
+var vcardEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+func vcardEscape(s string) string {
+	return vcardEscaper.Replace(s)
+}
+
+// writeFolded writes the content line, folded at 75 octets without splitting UTF-8 characters
+func writeFolded(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		i := limit
+		for i > 0 && !utf8.RuneStart(line[i]) {
+			i--
+		}
+		b.WriteString(line[:i] + "\r\n ")
+		line = line[i:]
+		// The space starting continuation lines counts
+		limit = 74
+	}
+	b.WriteString(line + "\r\n")
+}
@@ -0,0 +1,148 @@
+package hermes
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// WebFont is a font file of the brand, used by the clients supporting @font-face (e.g. Apple Mail, Mail on iOS).
+// Other clients display the fonts of the theme.
+type WebFont struct {
+	Family string `json:"family,omitempty" yaml:"family,omitempty"` // Name of the family, e.g. Inter
+	URL    string `json:"url,omitempty" yaml:"url,omitempty"`       // https URL of the file, in WOFF2, WOFF, TTF or OTF format
+	Weight string `json:"weight,omitempty" yaml:"weight,omitempty"` // e.g. 400 or bold (default to normal)
+	Style  string `json:"style,omitempty" yaml:"style,omitempty"`   // normal or italic (default to normal)
+}
+
+// maxWebFontFiles is the number of font files above which Branding.Validate warns, each one is downloaded by clients
+const maxWebFontFiles = 2
+
+var (
+	fontFamily = regexp.MustCompile(`^[\p{L}\p{N} _-]+$`)
+	fontWeight = regexp.MustCompile(`^(normal|bold|[1-9]00)$`)
+	fontStyle  = regexp.MustCompile(`^(normal|italic)$`)
+)
+
+var fontFormats = map[string]string{
+	".woff2": "woff2",
+	".woff":  "woff",
+	".ttf":   "truetype",
+	".otf":   "opentype",
+}
+
+// valid reports whether the font can be written in a stylesheet: its family and https URL are required
+func (f WebFont) valid() bool {
+	u, err := url.Parse(f.URL)
+	return err == nil && u.Scheme == "https" && u.Host != "" && !strings.ContainsAny(f.URL, `"'()\`) &&
+		fontFamily.MatchString(f.Family) &&
+		(f.Weight == "" || fontWeight.MatchString(f.Weight)) &&
+		(f.Style == "" || fontStyle.MatchString(f.Style))
+}
+
+// FontFaces returns the @font-face rules of the web fonts of the brand, for a style element kept out of CSS inlining.
+// Invalid fonts are skipped.
+func (b Branding) FontFaces() template.CSS {
+	var rules []string
+	for _, font := range b.WebFonts {
+		if !font.valid() {
+			continue
+		}
+		src := fmt.Sprintf("url('%s')", font.URL)
+		if u, _ := url.Parse(font.URL); fontFormats[strings.ToLower(path.Ext(u.Path))] != "" {
+			src += fmt.Sprintf(" format('%s')", fontFormats[strings.ToLower(path.Ext(u.Path))])
+		}
+		weight, style := font.Weight, font.Style
+		if weight == "" {
+			weight = "normal"
+		}
+		if style == "" {
+			style = "normal"
+		}
+		rules = append(rules, fmt.Sprintf("@font-face { font-family: '%s'; font-weight: %s; font-style: %s; src: %s; }",
+			font.Family, weight, style, src))
+	}
+	return template.CSS(strings.Join(rules, "\n"))
+}
+
+// FontStack returns the families of the web fonts of the brand followed by the stack of the theme, so that clients
+// without web fonts always have a fallback. The stack is returned as is when there are no valid web fonts.
+func (b Branding) FontStack(stack string) template.CSS {
+	var families []string
+	seen := map[string]bool{}
+	for _, font := range b.WebFonts {
+		if font.valid() && !seen[font.Family] {
+			seen[font.Family] = true
+			families = append(families, "'"+font.Family+"'")
+		}
+	}
+	return template.CSS(strings.Join(append(families, stack), ", "))
+}
+
+// Validate checks the branding for mistakes that rendering does not report: the logo must be an absolute URL or an
+// embedded image, web fonts must be served over https, and more than 2 font files slow down the display of the email.
+// It returns Issues listing all of them, with a ValidationError as underlying error, or nil.
+func (b Branding) Validate() error {
+	var issues Issues
+	for _, logo := range []struct{ path, url string }{
+		{"Brand.Logo", b.Logo}, {"Brand.LogoImage.URL", b.LogoImage.URL}, {"Brand.LogoImage.DarkURL", b.LogoImage.DarkURL},
+	} {
+		// Logo is a copy of LogoImage.URL in prepared brandings
+		if logo.path == "Brand.LogoImage.URL" && logo.url == b.Logo {
+			continue
+		}
+		if logo.url != "" && !validLogo(logo.url) {
+			issues = append(issues, ValidationError{
+				Code:    CodeInvalidLogoURL,
+				Path:    logo.path,
+				Value:   logo.url,
+				Message: "must be an absolute http(s) URL, a data: image or a cid: attachment",
+			}.issue(SeverityError))
+		}
+	}
+	issues = append(issues, b.unsafeSVGs()...)
+	files := map[string]bool{}
+	for i, font := range b.WebFonts {
+		if u, err := url.Parse(font.URL); err != nil || u.Scheme != "https" || u.Host == "" {
+			issues = append(issues, ValidationError{
+				Code:    CodeWebFontNotHTTPS,
+				Path:    fmt.Sprintf("Brand.WebFonts[%d].URL", i),
+				Value:   font.URL,
+				Message: "must be an https URL",
+			}.issue(SeverityError))
+		}
+		files[font.URL] = true
+	}
+	if len(files) > maxWebFontFiles {
+		issues = append(issues, ValidationError{
+			Code:    CodeTooManyWebFonts,
+			Path:    "Brand.WebFonts",
+			Value:   fmt.Sprint(len(files)),
+			Message: fmt.Sprintf("%d font files, more than %d slow down the display of the email", len(files), maxWebFontFiles),
+		}.issue(SeverityWarning))
+	}
+	if len(issues) > 0 {
+		return issues
+	}
+	return nil
+}
+
+// validLogo tells if the logo can be displayed by email clients: an absolute http(s) URL, an embedded image or an attachment
+func validLogo(logo string) bool {
+	u, err := url.Parse(logo)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return u.Host != ""
+	case "data":
+		return strings.HasPrefix(strings.ToLower(u.Opaque), "image/")
+	case "cid":
+		return u.Opaque != ""
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+// Package preview serves emails as rendered by an engine, to iterate on a theme or on emails in a browser.
+package preview
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+// Views of an email, the last element of its path
+const (
+	ViewHTML = "html" // HTML body, with CSS inlined unless disabled
+	ViewText = "txt"  // Plain text body
+	ViewRaw  = "raw"  // HTML body without inlined CSS, see Hermes.GenerateHTMLRaw
+)
+
+// Reloader is implemented by themes which read their templates again before each rendering, like themes.FileTheme
+type Reloader interface {
+	Reload() error
+}
+
+// Serve listens on the address, e.g. "localhost:8080", and serves the emails, see NewHandler
+func Serve(addr string, h hermes.Hermes, emails map[string]hermes.Email) error {
+	return http.ListenAndServe(addr, NewHandler(h, emails))
+}
+
+// NewHandler returns a handler serving an index of the emails at "/", and the views of each email at
+// "/emails/{name}/html", "/emails/{name}/txt" and "/emails/{name}/raw". The examples of hermes.FeatureExamples are
+// served the same way at "/features/{name}/html"..., to review each feature of the body in isolation.
+// Emails are rendered again on every request, the theme being reloaded first when it implements Reloader, so that
+// changes to its templates show by refreshing the page. "?inline=false" disables CSS inlining, "?dir=rtl" renders the
+// email right-to-left, and "?sources=true" sets AnnotateSources, labeling the copy with its source when hovered; the
+// links of the index keep these parameters. Views are served by hermes.NewPreviewHandler, so that "?theme=name",
+// "?format=widths" and the ETags of its responses work the same.
+//
+// Unknown emails are served as 404 Not Found, invalid parameters as 400 Bad Request, and reloading or rendering errors
+// as 500 Internal Server Error.
+func NewHandler(h hermes.Hermes, emails map[string]hermes.Email) http.Handler {
+	features := hermes.FeatureExamples()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		query := r.URL.Query()
+		engine, err := engineOf(h, query)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.URL.Path == "/" {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Header().Set("Cache-Control", "no-cache")
+			if r.Method != http.MethodHead {
+				// Writing to the response fails only when the client is gone
+				_, _ = w.Write([]byte(index(emails, features, query)))
+			}
+			return
+		}
+
+		served := emails
+		rest, ok := strings.CutPrefix(r.URL.Path, "/emails/")
+		if !ok {
+			served = features
+			rest, ok = strings.CutPrefix(r.URL.Path, "/features/")
+		}
+		i := strings.LastIndex(rest, "/")
+		if !ok || i < 0 {
+			http.NotFound(w, r)
+			return
+		}
+		name, view := rest[:i], rest[i+1:]
+		email, ok := served[name]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown email %q", name), http.StatusNotFound)
+			return
+		}
+		switch view {
+		case ViewHTML:
+		case ViewText:
+			query.Set("format", "text")
+		case ViewRaw:
+			engine.DisableCSSInlining = true
+		default:
+			http.Error(w, fmt.Sprintf("unknown view %q", view), http.StatusNotFound)
+			return
+		}
+		if reloader, ok := engine.Theme.(Reloader); ok {
+			if err := reloader.Reload(); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		r = r.Clone(r.Context())
+		r.URL.RawQuery = query.Encode()
+		hermes.NewPreviewHandler(engine, func(*http.Request) (hermes.Email, error) { return email, nil }).ServeHTTP(w, r)
+	})
+}
+
+// engineOf returns the engine with the parameters of the query applied
+func engineOf(h hermes.Hermes, query url.Values) (hermes.Hermes, error) {
+	if inline := query.Get("inline"); inline != "" {
+		b, err := strconv.ParseBool(inline)
+		if err != nil {
+			return h, fmt.Errorf("invalid inline %q: %w", inline, err)
+		}
+		h.DisableCSSInlining = !b
+	}
+	if sources := query.Get("sources"); sources != "" {
+		b, err := strconv.ParseBool(sources)
+		if err != nil {
+			return h, fmt.Errorf("invalid sources %q: %w", sources, err)
+		}
+		h.AnnotateSources = b
+	}
+	if dir := query.Get("dir"); dir != "" {
+		h.TextDirection = hermes.TextDirection(dir)
+		if err := h.TextDirection.Validate(); err != nil {
+			return h, err
+		}
+	}
+	return h, nil
+}
+
+// indexLink is a link of the index, to an email or to the index with other parameters
+type indexLink struct {
+	Label string
+	URL   string
+}
+
+type indexPage struct {
+	Emails   []indexEmail
+	Features []indexEmail
+	Toggles  []indexLink
+}
+
+type indexEmail struct {
+	Name  string
+	Views []indexLink
+}
+
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>Hermes preview</title>
+  <style>
+    body { margin: 20px; font-family: sans-serif; }
+    li { margin: 6px 0; }
+    a { margin-right: 10px; }
+  </style>
+</head>
+<body>
+  <h1>Emails</h1>
+  <p>{{ range .Toggles }}<a href="{{ .URL }}">{{ .Label }}</a>{{ end }}</p>
+  <ul>
+  {{ range .Emails }}
+    <li><strong>{{ .Name }}</strong> {{ range .Views }}<a href="{{ .URL }}">{{ .Label }}</a>{{ end }}</li>
+  {{ else }}
+    <li>No emails</li>
+  {{ end }}
+  </ul>
+  <h2>Features</h2>
+  <ul>
+  {{ range .Features }}
+    <li><strong>{{ .Name }}</strong> {{ range .Views }}<a href="{{ .URL }}">{{ .Label }}</a>{{ end }}</li>
+  {{ end }}
+  </ul>
+</body>
+</html>
+`))
+
+// index returns the page listing the emails and the features by name, linking to their views with the parameters of
+// the query
+func index(emails, features map[string]hermes.Email, query url.Values) string {
+	page := indexPage{Emails: indexEmails("/emails/", emails, query), Features: indexEmails("/features/", features, query)}
+	for _, toggle := range []struct{ label, key, value string }{
+		{"inlined CSS", "inline", "true"},
+		{"styled", "inline", "false"},
+		{"left-to-right", "dir", string(hermes.TDLeftToRight)},
+		{"right-to-left", "dir", string(hermes.TDRightToLeft)},
+		{"sources", "sources", "true"},
+		{"no sources", "sources", "false"},
+	} {
+		q := url.Values{}
+		for k, v := range query {
+			q[k] = v
+		}
+		q.Set(toggle.key, toggle.value)
+		u := url.URL{Path: "/", RawQuery: q.Encode()}
+		page.Toggles = append(page.Toggles, indexLink{Label: toggle.label, URL: u.String()})
+	}
+
+	var b strings.Builder
+	// The template only fails on writing, which a strings.Builder never does
+	_ = indexTemplate.Execute(&b, page)
+	return b.String()
+}
+
+// indexEmails returns the emails of the index sorted by name, linking to their views under the path
+func indexEmails(path string, emails map[string]hermes.Email, query url.Values) []indexEmail {
+	names := make([]string, 0, len(emails))
+	for name := range emails {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var list []indexEmail
+	for _, name := range names {
+		e := indexEmail{Name: name}
+		for _, view := range []string{ViewHTML, ViewText, ViewRaw} {
+			u := url.URL{Path: path + name + "/" + view, RawQuery: query.Encode()}
+			e.Views = append(e.Views, indexLink{Label: view, URL: u.String()})
+		}
+		widths := url.Values{"format": {"widths"}}
+		for k, v := range query {
+			widths[k] = v
+		}
+		u := url.URL{Path: path + name + "/" + ViewHTML, RawQuery: widths.Encode()}
+		e.Views = append(e.Views, indexLink{Label: "widths", URL: u.String()})
+		list = append(list, e)
+	}
+	return list
+}
@@ -0,0 +1,106 @@
+// Package prometheus exports the metrics of hermes to Prometheus, as a hermes.MetricsSink.
+// It is a module of its own, so that the core of hermes does not depend on the Prometheus client.
+package prometheus
+
+import (
+	"sort"
+	"sync"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+// Sink is a hermes.MetricsSink registering a counter or a histogram vector per metric on its first use, with the
+// labels of that use. It is safe for concurrent use.
+type Sink struct {
+	registerer prom.Registerer
+	buckets    map[string][]float64
+
+	mu         sync.Mutex
+	counters   map[string]*prom.CounterVec
+	histograms map[string]*prom.HistogramVec
+}
+
+// DefaultBuckets are the buckets of the histograms of hermes
+var DefaultBuckets = map[string][]float64{
+	hermes.MetricRenderDuration: {.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	hermes.MetricOutputSize:     prom.ExponentialBuckets(1024, 2, 10), // 1 KiB to 512 KiB
+}
+
+// NewSink returns a sink registering its metrics with the registerer, e.g. prometheus.DefaultRegisterer.
+// Histograms use DefaultBuckets, or the ones of prometheus.DefBuckets for other metrics.
+func NewSink(r prom.Registerer) *Sink {
+	return &Sink{
+		registerer: r,
+		buckets:    DefaultBuckets,
+		counters:   map[string]*prom.CounterVec{},
+		histograms: map[string]*prom.HistogramVec{},
+	}
+}
+
+// Count adds delta to the counter of the name
+func (s *Sink) Count(name string, labels map[string]string, delta float64) {
+	s.mu.Lock()
+	c, ok := s.counters[name]
+	if !ok {
+		c = prom.NewCounterVec(prom.CounterOpts{Name: name, Help: help(name)}, labelNames(labels))
+		c = register(s.registerer, c).(*prom.CounterVec)
+		s.counters[name] = c
+	}
+	s.mu.Unlock()
+	c.With(labels).Add(delta)
+}
+
+// Observe records the value in the histogram of the name
+func (s *Sink) Observe(name string, labels map[string]string, value float64) {
+	s.mu.Lock()
+	h, ok := s.histograms[name]
+	if !ok {
+		buckets := s.buckets[name]
+		if buckets == nil {
+			buckets = prom.DefBuckets
+		}
+		h = prom.NewHistogramVec(prom.HistogramOpts{Name: name, Help: help(name), Buckets: buckets}, labelNames(labels))
+		h = register(s.registerer, h).(*prom.HistogramVec)
+		s.histograms[name] = h
+	}
+	s.mu.Unlock()
+	h.With(labels).Observe(value)
+}
+
+// register registers the collector, or returns the one already registered under its name, e.g. by another sink
+func register(r prom.Registerer, c prom.Collector) prom.Collector {
+	if err := r.Register(c); err != nil {
+		if already, ok := err.(prom.AlreadyRegisteredError); ok {
+			return already.ExistingCollector
+		}
+		panic(err)
+	}
+	return c
+}
+
+// labelNames returns the names of the labels, sorted
+func labelNames(labels map[string]string) []string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func help(name string) string {
+	switch name {
+	case hermes.MetricRenderDuration:
+		return "Time spent rendering emails, by theme."
+	case hermes.MetricRenderErrors:
+		return "Renderings which failed, by stage."
+	case hermes.MetricOutputSize:
+		return "Size of the bodies rendered, by theme and part."
+	case hermes.MetricCacheLookups:
+		return "Lookups of the caches of hermes, by cache and result."
+	case hermes.MetricSendAttempts:
+		return "Messages given to senders, by sender and result."
+	}
+	return name
+}
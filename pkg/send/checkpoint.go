@@ -0,0 +1,102 @@
+package send
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// CheckpointStore holds the checkpoints of the campaigns of schedulers, by campaign ID
+type CheckpointStore interface {
+	// Load returns the checkpoint of the campaign, ok being false when there is none
+	Load(ctx context.Context, id string) (cp Checkpoint, ok bool, err error)
+	Save(ctx context.Context, id string, cp Checkpoint) error
+}
+
+var (
+	_ CheckpointStore = (*MemoryCheckpointStore)(nil)
+	_ CheckpointStore = FileCheckpointStore{}
+)
+
+// MemoryCheckpointStore is an in-memory CheckpointStore, safe for concurrent use
+type MemoryCheckpointStore struct {
+	mu          sync.Mutex
+	checkpoints map[string]Checkpoint
+}
+
+// NewMemoryCheckpointStore creates an empty in-memory checkpoint store
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{checkpoints: map[string]Checkpoint{}}
+}
+
+// Load returns the checkpoint of the campaign
+func (s *MemoryCheckpointStore) Load(_ context.Context, id string) (Checkpoint, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp, ok := s.checkpoints[id]
+	return cp, ok, nil
+}
+
+// Save replaces the checkpoint of the campaign
+func (s *MemoryCheckpointStore) Save(_ context.Context, id string, cp Checkpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkpoints[id] = cp
+	return nil
+}
+
+// FileCheckpointStore saves checkpoints as JSON files in a directory, one per campaign.
+// Files are replaced atomically, so that a crash while saving leaves the previous checkpoint.
+type FileCheckpointStore struct {
+	Dir string
+}
+
+// Load reads the checkpoint of the campaign from its file
+func (s FileCheckpointStore) Load(_ context.Context, id string) (Checkpoint, bool, error) {
+	data, err := os.ReadFile(s.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Checkpoint{}, false, nil
+	} else if err != nil {
+		return Checkpoint{}, false, err
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return Checkpoint{}, false, err
+	}
+	return cp, true, nil
+}
+
+// Save writes the checkpoint of the campaign to a temporary file, then renames it over the previous one
+func (s FileCheckpointStore) Save(_ context.Context, id string, cp Checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.Dir, ".checkpoint-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(id))
+}
+
+// path returns the file of the campaign, its ID escaped so that it stays in the directory
+func (s FileCheckpointStore) path(id string) string {
+	return filepath.Join(s.Dir, url.PathEscape(id)+".json")
+}
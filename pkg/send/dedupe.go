@@ -0,0 +1,207 @@
+package send
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+// DefaultDedupeTTL is the time during which DedupeSender suppresses the duplicates of a message by default
+const DefaultDedupeTTL = 24 * time.Hour
+
+// ErrDuplicateSuppressed is returned by DedupeSender for messages already sent within the TTL, which are not sent again
+var ErrDuplicateSuppressed = errors.New("send: duplicate message suppressed")
+
+// DedupeStore records the keys of the messages sent, by DedupeSender. Its methods must be safe for concurrent use.
+type DedupeStore interface {
+	// Reserve records the key for the TTL, ok being false when it is already recorded and not expired
+	Reserve(ctx context.Context, key string, ttl time.Duration) (ok bool, err error)
+	// Release forgets the key, e.g. of a message which failed to be sent, so that it can be sent again
+	Release(ctx context.Context, key string) error
+}
+
+var (
+	_ DedupeStore = (*MemoryDedupeStore)(nil)
+	_ DedupeStore = FileDedupeStore{}
+	_ Sender      = DedupeSender{}
+)
+
+// DedupeSender sends messages through Sender unless the same message was sent to the same recipients within TTL, e.g.
+// by an upstream retry, returning ErrDuplicateSuppressed instead. Messages are the same when they have the same
+// IdempotencyKey, or else the same subject and content, see Output.Hash.
+// A Scheduler counts the suppressed messages of its campaign in Progress, and goes on with the next recipients.
+type DedupeSender struct {
+	Sender Sender
+	Store  DedupeStore
+	TTL    time.Duration // Default to DefaultDedupeTTL
+}
+
+// Send sends the message unless it is a duplicate. The key of a message the sender fails to send is released.
+func (d DedupeSender) Send(ctx context.Context, msg Message) error {
+	key, err := dedupeKey(msg)
+	if err != nil {
+		return err
+	}
+	ok, err := d.Store.Reserve(ctx, key, durationOr(d.TTL, DefaultDedupeTTL))
+	if err != nil {
+		return fmt.Errorf("send: reserve dedupe key: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("%w: to %s", ErrDuplicateSuppressed, strings.Join(msg.To, ", "))
+	}
+	if err := d.Sender.Send(ctx, msg); err != nil {
+		if releaseErr := d.Store.Release(context.WithoutCancel(ctx), key); releaseErr != nil {
+			return errors.Join(err, fmt.Errorf("send: release dedupe key: %w", releaseErr))
+		}
+		return err
+	}
+	return nil
+}
+
+// dedupeKey returns the SHA-256 of the recipients of the envelope of the message, and of its IdempotencyKey or else of
+// its subject and content, in hex
+func dedupeKey(m Message) (string, error) {
+	recipients, err := m.Recipients()
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(recipients)
+	h := sha256.New()
+	for _, recipient := range recipients {
+		fmt.Fprintf(h, "%d:%s", len(recipient), recipient)
+	}
+	if m.IdempotencyKey != "" {
+		fmt.Fprintf(h, "key:%d:%s", len(m.IdempotencyKey), m.IdempotencyKey)
+	} else {
+		content := hermes.Output{HTML: m.HTML, PlainText: m.Text}.Hash()
+		fmt.Fprintf(h, "content:%d:%s%s", len(m.Subject), m.Subject, content)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// MemoryDedupeStore is an in-memory DedupeStore, safe for concurrent use. Expired keys are removed as keys are reserved.
+type MemoryDedupeStore struct {
+	Now func() time.Time // Clock of the TTLs (default to time.Now)
+
+	mu        sync.Mutex
+	expiries  map[string]time.Time
+	nextSweep time.Time
+}
+
+// NewMemoryDedupeStore creates an empty in-memory dedupe store
+func NewMemoryDedupeStore() *MemoryDedupeStore {
+	return &MemoryDedupeStore{expiries: map[string]time.Time{}}
+}
+
+// Reserve records the key until now plus the TTL, unless it is already recorded and not expired
+func (s *MemoryDedupeStore) Reserve(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	now := nowOr(s.Now)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !now.Before(s.nextSweep) {
+		for k, expiry := range s.expiries {
+			if !now.Before(expiry) {
+				delete(s.expiries, k)
+			}
+		}
+		s.nextSweep = now.Add(ttl)
+	}
+	if expiry, ok := s.expiries[key]; ok && now.Before(expiry) {
+		return false, nil
+	}
+	s.expiries[key] = now.Add(ttl)
+	return true, nil
+}
+
+// Release forgets the key
+func (s *MemoryDedupeStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.expiries, key)
+	return nil
+}
+
+// FileDedupeStore records keys as files of a directory holding their expiry, e.g. shared by the processes of a host.
+// Files are created atomically, so that a key is reserved by a single process, unless processes replace its expired file
+// at the same time. Expired files are not removed until their key is reserved again.
+type FileDedupeStore struct {
+	Dir string
+	Now func() time.Time // Clock of the TTLs (default to time.Now)
+}
+
+// Reserve creates the file of the key, holding its expiry, unless it exists and is not expired
+func (s FileDedupeStore) Reserve(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	now := nowOr(s.Now)
+	tmp, err := os.CreateTemp(s.Dir, ".dedupe-*")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(now.Add(ttl).UTC().Format(time.RFC3339Nano)); err != nil {
+		tmp.Close()
+		return false, err
+	}
+	if err := tmp.Close(); err != nil {
+		return false, err
+	}
+
+	// Linking fails when the file exists, unlike renaming: a single process gets the key
+	path := s.path(key)
+	for attempt := 0; attempt < 2; attempt++ {
+		err := os.Link(tmp.Name(), path)
+		if err == nil {
+			return true, nil
+		} else if !errors.Is(err, fs.ErrExist) {
+			return false, err
+		}
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // Released in the meantime
+		} else if err != nil {
+			return false, err
+		}
+		expiry, err := time.Parse(time.RFC3339Nano, string(data))
+		if err != nil {
+			return false, fmt.Errorf("send: dedupe file %s: %w", path, err)
+		}
+		if now.Before(expiry) {
+			return false, nil
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// Release removes the file of the key
+func (s FileDedupeStore) Release(_ context.Context, key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path returns the file of the key, escaped so that it stays in the directory
+func (s FileDedupeStore) path(key string) string {
+	return filepath.Join(s.Dir, url.PathEscape(key))
+}
+
+func nowOr(now func() time.Time) time.Time {
+	if now != nil {
+		return now()
+	}
+	return time.Now()
+}
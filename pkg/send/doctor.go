@@ -0,0 +1,293 @@
+package send
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+// Environment variables of the sender configuration read by ConfigFromEnv
+const (
+	EnvSMTPServer     = "HERMES_SMTP_SERVER"
+	EnvSMTPPort       = "HERMES_SMTP_PORT"
+	EnvSMTPUser       = "HERMES_SMTP_USER"
+	EnvSMTPPassword   = "HERMES_SMTP_PASSWORD"
+	EnvSenderEmail    = "HERMES_SENDER_EMAIL"
+	EnvSenderIdentity = "HERMES_SENDER_IDENTITY"
+	EnvTo             = "HERMES_TO"            // Comma-separated recipients
+	EnvDKIMSelector   = "HERMES_DKIM_SELECTOR" // Optional
+)
+
+// DefaultDNSTimeout is the timeout of each DNS lookup of Diagnose
+const DefaultDNSTimeout = 5 * time.Second
+
+// Resolver looks up the DNS records checked by Diagnose, e.g. a *net.Resolver
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// Config is the configuration of a sender checked by Diagnose, e.g. the one of the examples read by ConfigFromEnv
+type Config struct {
+	SMTP         SMTP
+	From         string   // Sender, e.g. Hermes <hello@hermes-example.com>
+	To           []string // Recipients
+	DKIMSelector string   // Selector of the DKIM key of the sender domain, e.g. "default", checked when set
+	Resolver     Resolver // Default to net.DefaultResolver
+	DNSTimeout   time.Duration
+}
+
+// ConfigFromEnv reads the configuration from the HERMES_* variables of getenv, e.g. os.Getenv.
+// Port 465 selects ImplicitTLS; an invalid port is read as 0, for Diagnose to report it.
+func ConfigFromEnv(getenv func(string) string) Config {
+	port, _ := strconv.Atoi(strings.TrimSpace(getenv(EnvSMTPPort)))
+	c := Config{
+		SMTP: SMTP{
+			Server:   strings.TrimSpace(getenv(EnvSMTPServer)),
+			Port:     port,
+			Username: getenv(EnvSMTPUser),
+			Password: getenv(EnvSMTPPassword),
+		},
+		DKIMSelector: strings.TrimSpace(getenv(EnvDKIMSelector)),
+	}
+	if port == 465 {
+		c.SMTP.TLS = ImplicitTLS
+	}
+	if address := strings.TrimSpace(getenv(EnvSenderEmail)); address != "" {
+		c.From = (&mail.Address{Name: getenv(EnvSenderIdentity), Address: address}).String()
+	}
+	for _, to := range strings.Split(getenv(EnvTo), ",") {
+		if to = strings.TrimSpace(to); to != "" {
+			c.To = append(c.To, to)
+		}
+	}
+	return c
+}
+
+// Checks of Diagnose
+const (
+	CheckConfig   = "config"        // Required fields are present and valid
+	CheckResolve  = "smtp.resolve"  // The SMTP server resolves
+	CheckConnect  = "smtp.connect"  // The SMTP port is reachable
+	CheckStartTLS = "smtp.starttls" // The SMTP server offers STARTTLS, and the connection upgrades
+	CheckAuth     = "smtp.auth"     // The credentials authenticate
+	CheckSPF      = "dns.spf"       // The sender domain has an SPF record
+	CheckDKIM     = "dns.dkim"      // The sender domain has a DKIM key for the selector
+	CheckDMARC    = "dns.dmarc"     // The sender domain has a DMARC policy
+)
+
+// Finding is the outcome of a check of Diagnose
+type Finding struct {
+	Check       string // e.g. CheckAuth
+	Passed      bool
+	Severity    hermes.Severity // SeverityError when a failure prevents sending, SeverityInfo for the DNS records
+	Message     string
+	Remediation string // How to fix a failure
+}
+
+// String returns the finding as a line, followed by its remediation on failures
+func (f Finding) String() string {
+	label := "ok"
+	if !f.Passed {
+		label = f.Severity.String()
+	}
+	s := fmt.Sprintf("%-7s %s: %s", label, f.Check, f.Message)
+	if !f.Passed && f.Remediation != "" {
+		s += "\n        " + f.Remediation
+	}
+	return s
+}
+
+// HasErrors reports whether a check failed with the SeverityError severity
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if !f.Passed && f.Severity == hermes.SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Diagnose checks the configuration without sending anything: the required fields, that the SMTP server resolves
+// and its port is reachable, that STARTTLS is offered, and that the credentials authenticate. The SPF, DKIM and DMARC
+// records of the sender domain are looked up too, their findings being informational only.
+// The SMTP checks stop at the first failure, the later ones depending on it.
+func Diagnose(ctx context.Context, c Config) []Finding {
+	findings := c.checkConfig()
+	if c.SMTP.Validate() == nil {
+		findings = append(findings, c.checkSMTP(ctx)...)
+	}
+	if from, err := mail.ParseAddress(c.From); err == nil {
+		findings = append(findings, c.checkDNS(ctx, from.Address[strings.LastIndex(from.Address, "@")+1:])...)
+	}
+	return findings
+}
+
+func (c Config) checkConfig() []Finding {
+	var findings []Finding
+	fail := func(message, remediation string) {
+		findings = append(findings, Finding{Check: CheckConfig, Severity: hermes.SeverityError, Message: message, Remediation: remediation})
+	}
+	s := c.SMTP
+	switch {
+	case s.Server == "":
+		fail("SMTP server is empty", "Set the host name of the SMTP server, e.g. "+EnvSMTPServer+"=smtp.gmail.com")
+	case hasPort(s.Server):
+		fail(fmt.Sprintf("SMTP server %q has a port", s.Server), "Give the port apart, in "+EnvSMTPPort)
+	}
+	if s.Port <= 0 || s.Port > 65535 {
+		fail(fmt.Sprintf("invalid SMTP port %d", s.Port), "Set the port of the SMTP server, usually "+EnvSMTPPort+"=587 for STARTTLS or 465 for TLS")
+	}
+	if s.TLS < StartTLS || s.TLS > NoTLS {
+		fail(fmt.Sprintf("unknown TLS mode %d", int(s.TLS)), "Use StartTLS, ImplicitTLS or NoTLS")
+	}
+	switch {
+	case s.Password != "" && s.Username == "":
+		fail("SMTP password without user", "Set the user of the SMTP account in "+EnvSMTPUser)
+	case s.Username != "" && s.Password == "":
+		fail("SMTP user without password", "Set the password of the SMTP account in "+EnvSMTPPassword)
+	}
+	if c.From == "" {
+		fail("sender is empty", "Set the address of the sender in "+EnvSenderEmail+", and optionally its name in "+EnvSenderIdentity)
+	} else if _, err := mail.ParseAddress(c.From); err != nil {
+		fail(fmt.Sprintf("sender %q: %v", c.From, err), "Set a valid address in "+EnvSenderEmail)
+	}
+	if len(c.To) == 0 {
+		fail("no recipients", "Set the comma-separated addresses of the recipients in "+EnvTo)
+	}
+	for _, to := range c.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			fail(fmt.Sprintf("recipient %q: %v", to, err), "Fix the addresses in "+EnvTo)
+		}
+	}
+	if len(findings) == 0 {
+		findings = append(findings, Finding{Check: CheckConfig, Passed: true, Message: "required fields are present"})
+	}
+	return findings
+}
+
+func (c Config) checkSMTP(ctx context.Context) []Finding {
+	s := c.SMTP
+	var findings []Finding
+	pass := func(check, message string) {
+		findings = append(findings, Finding{Check: check, Passed: true, Message: message})
+	}
+	fail := func(check, message, remediation string) []Finding {
+		return append(findings, Finding{Check: check, Severity: hermes.SeverityError, Message: message, Remediation: remediation})
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, durationOr(c.DNSTimeout, DefaultDNSTimeout))
+	addrs, err := c.resolver().LookupHost(lookupCtx, s.Server)
+	cancel()
+	if err != nil {
+		return fail(CheckResolve, err.Error(), "Check the spelling of the SMTP server in "+EnvSMTPServer+", and the DNS of this machine")
+	}
+	pass(CheckResolve, fmt.Sprintf("%s resolves to %s", s.Server, strings.Join(addrs, ", ")))
+
+	ctx, cancel = context.WithTimeout(ctx, durationOr(s.Timeout, DefaultSMTPTimeout))
+	defer cancel()
+	address := net.JoinHostPort(s.Server, strconv.Itoa(s.Port))
+	conn, err := s.dial(ctx)
+	if err != nil {
+		remediation := "Check the port in " + EnvSMTPPort + ", and that no firewall blocks outgoing connections to it"
+		if s.TLS == ImplicitTLS {
+			remediation = "Check that the server accepts TLS on this port, or use port 587 with STARTTLS"
+		}
+		return fail(CheckConnect, err.Error(), remediation)
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	client, err := smtp.NewClient(conn, s.Server)
+	if err != nil {
+		return fail(CheckConnect, fmt.Sprintf("%s does not greet as an SMTP server: %v", address, err), "Check the port in "+EnvSMTPPort)
+	}
+	defer client.Close()
+	pass(CheckConnect, address+" is reachable")
+
+	switch s.TLS {
+	case StartTLS:
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fail(CheckStartTLS, "SMTP server does not offer STARTTLS", "Use port 465 with TLS, or the port of the server offering STARTTLS, usually 587")
+		}
+		if err := client.StartTLS(s.tlsConfig()); err != nil {
+			return fail(CheckStartTLS, err.Error(), "Check the certificate of the server, which must be valid for "+s.Server)
+		}
+		pass(CheckStartTLS, "the connection is upgraded with STARTTLS")
+	case NoTLS:
+		findings = append(findings, Finding{Check: CheckStartTLS, Severity: hermes.SeverityWarning, Message: "the connection is not encrypted",
+			Remediation: "Use STARTTLS or TLS but with local relays"})
+	}
+
+	if s.Username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return fail(CheckAuth, "SMTP server does not offer authentication", "Remove the user from "+EnvSMTPUser+" for relays without authentication")
+		}
+		if err := client.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Server)); err != nil {
+			return fail(CheckAuth, err.Error(), "Check "+EnvSMTPUser+" and "+EnvSMTPPassword+"; providers like Gmail require an app password")
+		}
+		pass(CheckAuth, s.Username+" is authenticated")
+	}
+	_ = client.Quit()
+	return findings
+}
+
+func (c Config) checkDNS(ctx context.Context, domain string) []Finding {
+	// record returns the TXT record of the name starting with the tag, e.g. v=spf1
+	record := func(name, tag string) (string, error) {
+		ctx, cancel := context.WithTimeout(ctx, durationOr(c.DNSTimeout, DefaultDNSTimeout))
+		defer cancel()
+		records, err := c.resolver().LookupTXT(ctx, name)
+		if err != nil {
+			return "", err
+		}
+		for _, r := range records {
+			if strings.HasPrefix(strings.ToLower(r), strings.ToLower(tag)) {
+				return r, nil
+			}
+		}
+		if tag == "" {
+			return "", errors.New("no record")
+		}
+		return "", errors.New("no " + tag + " record")
+	}
+	check := func(check, name, tag, remediation string) Finding {
+		r, err := record(name, tag)
+		if err != nil {
+			return Finding{Check: check, Severity: hermes.SeverityInfo, Message: name + ": " + err.Error(), Remediation: remediation}
+		}
+		return Finding{Check: check, Passed: true, Message: name + ": " + r}
+	}
+
+	findings := []Finding{check(CheckSPF, domain, "v=spf1", "Publish an SPF record authorizing the SMTP server, or receivers may reject the emails")}
+	if c.DKIMSelector == "" {
+		findings = append(findings, Finding{Check: CheckDKIM, Severity: hermes.SeverityInfo, Message: "not checked without selector",
+			Remediation: "Set the selector of the DKIM key of " + domain + " in " + EnvDKIMSelector})
+	} else {
+		findings = append(findings, check(CheckDKIM, c.DKIMSelector+"._domainkey."+domain, "", "Publish the DKIM key of the selector given by your provider"))
+	}
+	return append(findings, check(CheckDMARC, "_dmarc."+domain, "v=DMARC1", "Publish a DMARC policy, e.g. v=DMARC1; p=none"))
+}
+
+// hasPort reports whether the server is given as host:port
+func hasPort(server string) bool {
+	_, _, err := net.SplitHostPort(server)
+	return err == nil
+}
+
+func (c Config) resolver() Resolver {
+	if c.Resolver == nil {
+		return net.DefaultResolver
+	}
+	return c.Resolver
+}
+
+var _ Resolver = (*net.Resolver)(nil)
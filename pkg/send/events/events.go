@@ -0,0 +1,135 @@
+// Package events ingests the bounce and complaint notifications of email providers,
+// so that addresses which must not be emailed anymore can be suppressed.
+package events
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventType is the type of a delivery event
+type EventType string
+
+// Types of events
+const (
+	EventDelivery    EventType = "delivery"    // Accepted by the recipient server
+	EventBounce      EventType = "bounce"      // Permanently rejected, the address must not be emailed anymore
+	EventSoftBounce  EventType = "soft_bounce" // Temporarily rejected (mailbox full, greylisting...)
+	EventComplaint   EventType = "complaint"   // Marked as spam by the recipient
+	EventUnsubscribe EventType = "unsubscribe" // Unsubscribed through the provider
+	EventDropped     EventType = "dropped"     // Not sent by the provider
+)
+
+// Event is a delivery event of a message, common to all providers
+type Event struct {
+	Type      EventType
+	Recipient string
+	MessageID string // ID of the message given by the provider
+	Timestamp time.Time
+	Reason    string // Diagnostic of the provider, if any
+}
+
+// Suppresses tells if the recipient of the event must not be emailed anymore
+func (e Event) Suppresses() bool {
+	switch e.Type {
+	case EventBounce, EventComplaint, EventUnsubscribe:
+		return true
+	}
+	return false
+}
+
+// SuppressionList holds the addresses that must not be emailed anymore.
+// Senders consult it before sending.
+type SuppressionList interface {
+	Suppress(ctx context.Context, e Event) error
+	IsSuppressed(ctx context.Context, address string) (bool, error)
+}
+
+// Sink receives the events parsed by the handlers
+type Sink func(ctx context.Context, events []Event) error
+
+// SuppressTo returns a Sink adding to the list the recipients of the events that suppress them
+func SuppressTo(list SuppressionList) Sink {
+	return func(ctx context.Context, events []Event) error {
+		for _, e := range events {
+			if !e.Suppresses() {
+				continue
+			}
+			if err := list.Suppress(ctx, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// MemorySuppressionList is an in-memory SuppressionList, safe for concurrent use
+type MemorySuppressionList struct {
+	mu        sync.RWMutex
+	addresses map[string]Event
+}
+
+// NewMemorySuppressionList creates an empty in-memory suppression list
+func NewMemorySuppressionList() *MemorySuppressionList {
+	return &MemorySuppressionList{addresses: map[string]Event{}}
+}
+
+// Suppress adds the recipient of the event to the list
+func (l *MemorySuppressionList) Suppress(_ context.Context, e Event) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.addresses[normalizeAddress(e.Recipient)] = e
+	return nil
+}
+
+// IsSuppressed tells if the address is in the list, ignoring case
+func (l *MemorySuppressionList) IsSuppressed(_ context.Context, address string) (bool, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	_, ok := l.addresses[normalizeAddress(address)]
+	return ok, nil
+}
+
+func normalizeAddress(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
+}
+
+// maxBodySize limits the size of webhook payloads
+const maxBodySize = 10 << 20
+
+// DefaultMaxAge is the default age over which signed payloads are rejected, so that captured ones can't be replayed
+const DefaultMaxAge = 5 * time.Minute
+
+// checkAge checks that a payload signed at the given time is not older (or newer, for skewed clocks) than maxAge.
+// A negative maxAge accepts payloads of any age.
+func checkAge(signed time.Time, maxAge time.Duration, now func() time.Time) error {
+	if maxAge < 0 {
+		return nil
+	}
+	if maxAge == 0 {
+		maxAge = DefaultMaxAge
+	}
+	current := time.Now()
+	if now != nil {
+		current = now()
+	}
+	if age := current.Sub(signed); age > maxAge || age < -maxAge {
+		return fmt.Errorf("payload signed at %s is outside of the accepted window of %s", signed.UTC().Format(time.RFC3339), maxAge)
+	}
+	return nil
+}
+
+// serve runs the sink with the events of a webhook request, answering with an error status the provider retries on
+func serve(w http.ResponseWriter, r *http.Request, sink Sink, events []Event) {
+	if len(events) > 0 {
+		if err := sink(r.Context(), events); err != nil {
+			http.Error(w, "could not handle events", http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
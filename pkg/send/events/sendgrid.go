@@ -0,0 +1,160 @@
+package events
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers of signed SendGrid event webhooks
+const (
+	SendGridSignatureHeader = "X-Twilio-Email-Event-Webhook-Signature"
+	SendGridTimestampHeader = "X-Twilio-Email-Event-Webhook-Timestamp"
+)
+
+type sendGridEvent struct {
+	Email       string `json:"email"`
+	Timestamp   int64  `json:"timestamp"`
+	Event       string `json:"event"`
+	Type        string `json:"type"` // "bounce" or "blocked" for bounces
+	SGMessageID string `json:"sg_message_id"`
+	Reason      string `json:"reason"`
+	Response    string `json:"response"`
+}
+
+// ParseSendGridEvents parses the body of a SendGrid event webhook.
+// Engagement events (opens, clicks...) give no event.
+func ParseSendGridEvents(body []byte) ([]Event, error) {
+	var sgEvents []sendGridEvent
+	if err := json.Unmarshal(body, &sgEvents); err != nil {
+		return nil, fmt.Errorf("malformed SendGrid events: %v", err)
+	}
+
+	var events []Event
+	for i, e := range sgEvents {
+		if e.Event == "" || e.Email == "" {
+			return nil, fmt.Errorf("malformed SendGrid events: event %d has no type or email", i)
+		}
+		event := Event{
+			Recipient: e.Email,
+			MessageID: e.SGMessageID,
+			Timestamp: time.Unix(e.Timestamp, 0).UTC(),
+			Reason:    e.Reason,
+		}
+		switch e.Event {
+		case "delivered":
+			event.Type = EventDelivery
+			event.Reason = e.Response
+		case "bounce":
+			event.Type = EventBounce
+			if e.Type == "blocked" {
+				event.Type = EventSoftBounce
+			}
+		case "deferred":
+			event.Type = EventSoftBounce
+			event.Reason = e.Response
+		case "dropped":
+			event.Type = EventDropped
+		case "spamreport":
+			event.Type = EventComplaint
+		case "unsubscribe", "group_unsubscribe":
+			event.Type = EventUnsubscribe
+		default:
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// ParseSendGridVerificationKey parses the verification key of signed event webhooks, as shown by SendGrid
+func ParseSendGridVerificationKey(key string) (*ecdsa.PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("malformed verification key: %v", err)
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("malformed verification key: %v", err)
+	}
+	ecdsaKey, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("malformed verification key: not an ECDSA key")
+	}
+	return ecdsaKey, nil
+}
+
+// VerifySendGridSignature checks the signature of a SendGrid event webhook, given with its timestamp in headers.
+// The age of the timestamp is not checked, see SendGridVerifier.
+func VerifySendGridSignature(key *ecdsa.PublicKey, signature, timestamp string, body []byte) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil || timestamp == "" {
+		return ErrInvalidSignature
+	}
+	digest := sha256.Sum256(append([]byte(timestamp), body...))
+	if !ecdsa.VerifyASN1(key, digest[:], sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// SendGridVerifier verifies the signatures of SendGrid event webhooks
+type SendGridVerifier struct {
+	Key    *ecdsa.PublicKey
+	MaxAge time.Duration    // Age over which webhooks are rejected (default to DefaultMaxAge, any age when negative)
+	Now    func() time.Time // Clock of MaxAge (default to time.Now)
+}
+
+// NewSendGridVerifier creates a verifier with the verification key of signed event webhooks, as shown by SendGrid
+func NewSendGridVerifier(verificationKey string) (*SendGridVerifier, error) {
+	key, err := ParseSendGridVerificationKey(verificationKey)
+	if err != nil {
+		return nil, err
+	}
+	return &SendGridVerifier{Key: key}, nil
+}
+
+// Verify checks the signature of a webhook, given with its timestamp in headers, and that it is not older than MaxAge
+func (v *SendGridVerifier) Verify(signature, timestamp string, body []byte) error {
+	if err := VerifySendGridSignature(v.Key, signature, timestamp, body); err != nil {
+		return err
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("malformed timestamp %q", timestamp)
+	}
+	return checkAge(time.Unix(seconds, 0), v.MaxAge, v.Now)
+}
+
+// NewSendGridHandler creates a handler for signed SendGrid event webhooks
+func NewSendGridHandler(verifier *SendGridVerifier, sink Sink) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		if err != nil {
+			http.Error(w, "could not read body", http.StatusBadRequest)
+			return
+		}
+		if err := verifier.Verify(r.Header.Get(SendGridSignatureHeader), r.Header.Get(SendGridTimestampHeader), body); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		events, err := ParseSendGridEvents(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		serve(w, r, sink, events)
+	})
+}
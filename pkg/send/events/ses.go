@@ -0,0 +1,340 @@
+package events
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SNSMessage is a message posted by Amazon SNS to an HTTP subscription
+type SNSMessage struct {
+	Type             string `json:"Type"`
+	MessageID        string `json:"MessageId"`
+	Token            string `json:"Token"`
+	TopicARN         string `json:"TopicArn"`
+	Subject          string `json:"Subject"`
+	Message          string `json:"Message"`
+	Timestamp        string `json:"Timestamp"`
+	SignatureVersion string `json:"SignatureVersion"`
+	Signature        string `json:"Signature"`
+	SigningCertURL   string `json:"SigningCertURL"`
+	SubscribeURL     string `json:"SubscribeURL"`
+}
+
+// Types of SNS messages
+const (
+	SNSNotification             = "Notification"
+	SNSSubscriptionConfirmation = "SubscriptionConfirmation"
+	SNSUnsubscribeConfirmation  = "UnsubscribeConfirmation"
+)
+
+// ErrInvalidSignature is returned when the signature of a webhook payload does not match
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// ParseSNSMessage parses the body of a request posted by Amazon SNS
+func ParseSNSMessage(body []byte) (SNSMessage, error) {
+	var m SNSMessage
+	if err := json.Unmarshal(body, &m); err != nil {
+		return SNSMessage{}, fmt.Errorf("malformed SNS message: %v", err)
+	}
+	switch m.Type {
+	case SNSNotification, SNSSubscriptionConfirmation, SNSUnsubscribeConfirmation:
+	default:
+		return SNSMessage{}, fmt.Errorf("malformed SNS message: unknown type %q", m.Type)
+	}
+	return m, nil
+}
+
+// signingString returns the string signed by SNS for the message
+func (m SNSMessage) signingString() string {
+	fields := []string{"Message", m.Message, "MessageId", m.MessageID}
+	if m.Type == SNSNotification {
+		if m.Subject != "" {
+			fields = append(fields, "Subject", m.Subject)
+		}
+		fields = append(fields, "Timestamp", m.Timestamp, "TopicArn", m.TopicARN, "Type", m.Type)
+	} else {
+		fields = append(fields, "SubscribeURL", m.SubscribeURL, "Timestamp", m.Timestamp, "Token", m.Token, "TopicArn", m.TopicARN, "Type", m.Type)
+	}
+	return strings.Join(fields, "\n") + "\n"
+}
+
+// snsHost matches the hosts SNS signing certificates and subscription URLs are served from
+var snsHost = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+func validSNSURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && u.Scheme == "https" && snsHost.MatchString(u.Host)
+}
+
+// SNSVerifier verifies the signatures of Amazon SNS messages.
+// Signing certificates are only fetched from SNS hosts, and cached.
+type SNSVerifier struct {
+	Client    *http.Client     // Fetches signing certificates and confirms subscriptions (default to http.DefaultClient)
+	TopicARNs []string         // Topics messages are accepted from, all topics when empty (not recommended)
+	MaxAge    time.Duration    // Age over which messages are rejected (default to DefaultMaxAge, any age when negative)
+	Now       func() time.Time // Clock of MaxAge (default to time.Now)
+
+	mu    sync.Mutex
+	certs map[string]*x509.Certificate
+}
+
+// Verify checks that the message was signed by SNS, comes from an accepted topic, and is not older than MaxAge
+func (v *SNSVerifier) Verify(m SNSMessage) error {
+	if len(v.TopicARNs) > 0 && !contains(v.TopicARNs, m.TopicARN) {
+		return fmt.Errorf("topic %q is not accepted", m.TopicARN)
+	}
+
+	var hash crypto.Hash
+	switch m.SignatureVersion {
+	case "1":
+		hash = crypto.SHA1
+	case "2":
+		hash = crypto.SHA256
+	default:
+		return fmt.Errorf("unsupported signature version %q", m.SignatureVersion)
+	}
+	signature, err := base64.StdEncoding.DecodeString(m.Signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	cert, err := v.certificate(m.SigningCertURL)
+	if err != nil {
+		return err
+	}
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return errors.New("signing certificate has no RSA key")
+	}
+
+	var digest []byte
+	if hash == crypto.SHA1 {
+		sum := sha1.Sum([]byte(m.signingString()))
+		digest = sum[:]
+	} else {
+		sum := sha256.Sum256([]byte(m.signingString()))
+		digest = sum[:]
+	}
+	if rsa.VerifyPKCS1v15(key, hash, digest, signature) != nil {
+		return ErrInvalidSignature
+	}
+
+	signed, err := time.Parse(time.RFC3339, m.Timestamp)
+	if err != nil {
+		return fmt.Errorf("malformed timestamp %q", m.Timestamp)
+	}
+	return checkAge(signed, v.MaxAge, v.Now)
+}
+
+func (v *SNSVerifier) client() *http.Client {
+	if v.Client != nil {
+		return v.Client
+	}
+	return http.DefaultClient
+}
+
+func (v *SNSVerifier) certificate(certURL string) (*x509.Certificate, error) {
+	if !validSNSURL(certURL) || !strings.HasSuffix(certURL, ".pem") {
+		return nil, fmt.Errorf("signing certificate URL %q is not an SNS one", certURL)
+	}
+
+	v.mu.Lock()
+	cert, ok := v.certs[certURL]
+	v.mu.Unlock()
+	if ok {
+		return cert, nil
+	}
+
+	resp, err := v.client().Get(certURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetching signing certificate: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching signing certificate: status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, fmt.Errorf("fetching signing certificate: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("signing certificate is not PEM encoded")
+	}
+	cert, err = x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing signing certificate: %v", err)
+	}
+
+	v.mu.Lock()
+	if v.certs == nil {
+		v.certs = map[string]*x509.Certificate{}
+	}
+	v.certs[certURL] = cert
+	v.mu.Unlock()
+	return cert, nil
+}
+
+// confirm confirms the subscription of the message, accepting only topics listed in TopicARNs
+func (v *SNSVerifier) confirm(m SNSMessage) error {
+	if !contains(v.TopicARNs, m.TopicARN) {
+		return fmt.Errorf("topic %q is not accepted for subscription", m.TopicARN)
+	}
+	if !validSNSURL(m.SubscribeURL) {
+		return fmt.Errorf("subscribe URL %q is not an SNS one", m.SubscribeURL)
+	}
+	resp, err := v.client().Get(m.SubscribeURL)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("confirming subscription: status %s", resp.Status)
+	}
+	return nil
+}
+
+type sesNotification struct {
+	NotificationType string `json:"notificationType"`
+	EventType        string `json:"eventType"` // Used instead of notificationType by configuration sets
+	Mail             struct {
+		MessageID string    `json:"messageId"`
+		Timestamp time.Time `json:"timestamp"`
+	} `json:"mail"`
+	Bounce *struct {
+		BounceType        string    `json:"bounceType"`
+		BounceSubType     string    `json:"bounceSubType"`
+		Timestamp         time.Time `json:"timestamp"`
+		BouncedRecipients []struct {
+			EmailAddress   string `json:"emailAddress"`
+			DiagnosticCode string `json:"diagnosticCode"`
+		} `json:"bouncedRecipients"`
+	} `json:"bounce"`
+	Complaint *struct {
+		ComplaintFeedbackType string    `json:"complaintFeedbackType"`
+		Timestamp             time.Time `json:"timestamp"`
+		ComplainedRecipients  []struct {
+			EmailAddress string `json:"emailAddress"`
+		} `json:"complainedRecipients"`
+	} `json:"complaint"`
+	Delivery *struct {
+		Timestamp    time.Time `json:"timestamp"`
+		Recipients   []string  `json:"recipients"`
+		SMTPResponse string    `json:"smtpResponse"`
+	} `json:"delivery"`
+}
+
+// ParseSESNotification parses an Amazon SES notification, the Message of an SNS notification.
+// Notifications other than bounces, complaints and deliveries give no event.
+func ParseSESNotification(message []byte) ([]Event, error) {
+	var n sesNotification
+	if err := json.Unmarshal(message, &n); err != nil {
+		return nil, fmt.Errorf("malformed SES notification: %v", err)
+	}
+	notificationType := n.NotificationType
+	if notificationType == "" {
+		notificationType = n.EventType
+	}
+
+	var events []Event
+	switch notificationType {
+	case "Bounce":
+		if n.Bounce == nil {
+			return nil, errors.New("malformed SES notification: bounce is missing")
+		}
+		eventType := EventSoftBounce
+		if n.Bounce.BounceType == "Permanent" {
+			eventType = EventBounce
+		}
+		for _, r := range n.Bounce.BouncedRecipients {
+			reason := r.DiagnosticCode
+			if reason == "" {
+				reason = n.Bounce.BounceType + " " + n.Bounce.BounceSubType
+			}
+			events = append(events, Event{Type: eventType, Recipient: r.EmailAddress, MessageID: n.Mail.MessageID, Timestamp: n.Bounce.Timestamp, Reason: reason})
+		}
+	case "Complaint":
+		if n.Complaint == nil {
+			return nil, errors.New("malformed SES notification: complaint is missing")
+		}
+		for _, r := range n.Complaint.ComplainedRecipients {
+			events = append(events, Event{Type: EventComplaint, Recipient: r.EmailAddress, MessageID: n.Mail.MessageID, Timestamp: n.Complaint.Timestamp, Reason: n.Complaint.ComplaintFeedbackType})
+		}
+	case "Delivery":
+		if n.Delivery == nil {
+			return nil, errors.New("malformed SES notification: delivery is missing")
+		}
+		for _, r := range n.Delivery.Recipients {
+			events = append(events, Event{Type: EventDelivery, Recipient: r, MessageID: n.Mail.MessageID, Timestamp: n.Delivery.Timestamp, Reason: n.Delivery.SMTPResponse})
+		}
+	case "":
+		return nil, errors.New("malformed SES notification: type is missing")
+	}
+	return events, nil
+}
+
+// NewSESHandler creates a handler for the Amazon SES notifications posted by SNS.
+// Messages are verified, and subscriptions to the topics of the verifier are confirmed.
+func NewSESHandler(verifier *SNSVerifier, sink Sink) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		if err != nil {
+			http.Error(w, "could not read body", http.StatusBadRequest)
+			return
+		}
+		m, err := ParseSNSMessage(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := verifier.Verify(m); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+
+		switch m.Type {
+		case SNSSubscriptionConfirmation:
+			if err := verifier.confirm(m); err != nil {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		case SNSUnsubscribeConfirmation:
+			w.WriteHeader(http.StatusOK)
+		default:
+			events, err := ParseSESNotification([]byte(m.Message))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			serve(w, r, sink, events)
+		}
+	})
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
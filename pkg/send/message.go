@@ -0,0 +1,484 @@
+// Package send builds the MIME messages of emails generated by hermes, and delivers them.
+// SMTP delivers them to an SMTP server, any other client or HTTP API accepting raw messages will do as well.
+package send
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/send/events"
+)
+
+// ContentPreference selects the parts of a message
+type ContentPreference int
+
+// Content preferences
+const (
+	Both     ContentPreference = iota // Plain text and HTML parts, as multipart/alternative
+	TextOnly                          // Plain text part only, for recipients on text-only clients
+	HTMLOnly                          // HTML part, along with a minimal plain text part unless ForceHTMLOnly is set
+)
+
+func (p ContentPreference) String() string {
+	switch p {
+	case Both:
+		return "Both"
+	case TextOnly:
+		return "TextOnly"
+	case HTMLOnly:
+		return "HTMLOnly"
+	}
+	return fmt.Sprintf("ContentPreference(%d)", int(p))
+}
+
+// Message is an email ready to be sent
+type Message struct {
+	From    string
+	To      []string
+	Cc      []string
+	Bcc     []string // Recipients of the envelope only, never written in the message
+	Subject string
+	HTML    string
+	Text    string
+	// Headers are added to the message as is, e.g. Reply-To or List-Unsubscribe.
+	// They cannot replace the headers written from the other fields.
+	Headers map[string]string
+
+	ContentPreference ContentPreference
+	// ForceHTMLOnly sends HTMLOnly messages without a plain text part.
+	// Pure HTML messages score badly with spam filters, only set it when you know better.
+	ForceHTMLOnly bool
+	// Attachments are sent as multipart/mixed, inline attachments along with the HTML part as multipart/related.
+	// Inline attachments are left out of TextOnly messages, which do not display them.
+	Attachments []hermes.Attachment
+	// Unsubscribe writes the List-Unsubscribe header of its mailto address and one-click URL, and the
+	// List-Unsubscribe-Post header of RFC 8058 when the one-click URL is set, which Gmail and Yahoo require from bulk
+	// senders
+	Unsubscribe *hermes.Unsubscribe
+	// IdempotencyKey identifies the message for DedupeSender instead of its subject and content, e.g. the ID of the
+	// order of a receipt
+	IdempotencyKey string
+	// Metadata is kept along with the message, and never written in it, e.g. the tenant of a message read from an outbox
+	Metadata map[string]string
+}
+
+// BuildMessage returns the message of the generated versions of the email, with its attachments, the invitation to its
+// calendar event and its unsubscribe options, if any. Subject defaults to the one of the email as is, or its title, From
+// and To are left to the caller. GenerateMessage generates the subject as well, for subjects with template actions, and
+// takes the unsubscribe options of the brand into account.
+func BuildMessage(email hermes.Email, html, text string) Message {
+	attachments := email.Attachments
+	if e := email.Body.CalendarEvent; e != nil {
+		attachments = append(attachments[:len(attachments):len(attachments)], e.Attachment())
+	}
+	subject := email.Subject
+	if subject == "" {
+		subject = email.Body.Title
+	}
+	return Message{Subject: subject, HTML: html, Text: text, Attachments: attachments, Unsubscribe: email.Unsubscribe}
+}
+
+// GenerateMessage generates the versions and the subject of the email with the engine, see Hermes.GenerateSubject, and
+// returns their message like BuildMessage
+func GenerateMessage(h *hermes.Hermes, email hermes.Email) (Message, error) {
+	html, text, err := h.Generate(email)
+	if err != nil {
+		return Message{}, err
+	}
+	subject, err := h.GenerateSubject(email)
+	if err != nil {
+		return Message{}, err
+	}
+	m := BuildMessage(email, html, text)
+	m.Subject = subject
+	m.Unsubscribe = h.UnsubscribeOf(email)
+	return m, nil
+}
+
+// ErrHTMLOnlyWithoutText is returned for HTMLOnly messages without plain text part, unless ForceHTMLOnly is set
+var ErrHTMLOnlyWithoutText = errors.New("send: HTMLOnly message without a plain text part, set ForceHTMLOnly to send it anyway")
+
+// reservedHeaders are written from the fields of the message
+var reservedHeaders = map[string]bool{
+	"From": true, "To": true, "Cc": true, "Bcc": true, "Subject": true, "Date": true, "Mime-Version": true,
+	"Content-Type": true, "Content-Transfer-Encoding": true,
+}
+
+// unsubscribeHeaders are written from the Unsubscribe field of the message, when set
+var unsubscribeHeaders = map[string]bool{"List-Unsubscribe": true, "List-Unsubscribe-Post": true}
+
+// Validate checks that the message has the parts required by its content preference, and that its addresses and
+// headers are valid
+func (m Message) Validate() error {
+	if _, err := parseAddress("From", m.From); err != nil {
+		return err
+	}
+	for _, list := range []struct {
+		field     string
+		addresses []string
+	}{{"To", m.To}, {"Cc", m.Cc}, {"Bcc", m.Bcc}} {
+		if _, err := parseAddresses(list.field, list.addresses); err != nil {
+			return err
+		}
+	}
+	for key, value := range m.Headers {
+		switch {
+		case key == "" || strings.ContainsAny(key, ": \t\r\n"):
+			return fmt.Errorf("send: invalid header name %q", key)
+		case reservedHeaders[textproto.CanonicalMIMEHeaderKey(key)]:
+			return fmt.Errorf("send: header %s is set from the fields of the message", key)
+		case m.Unsubscribe != nil && unsubscribeHeaders[textproto.CanonicalMIMEHeaderKey(key)]:
+			return fmt.Errorf("send: header %s is set from Unsubscribe", key)
+		case strings.ContainsAny(value, "\r\n"):
+			return fmt.Errorf("send: header %s has a line break", key)
+		}
+	}
+	if u := m.Unsubscribe; u != nil {
+		if strings.ContainsAny(u.Mailto+u.OneClickURL, "\r\n<>") {
+			return errors.New("send: unsubscribe address or URL with a line break or an angle bracket")
+		}
+		if u.OneClickURL != "" && !strings.HasPrefix(u.OneClickURL, "https://") {
+			return fmt.Errorf("send: one-click unsubscribe URL %q must start with https://", u.OneClickURL)
+		}
+	}
+
+	for _, a := range m.Attachments {
+		if a.Ref != "" && a.Data == nil {
+			return fmt.Errorf("send: attachment %s references %q, resolve it with ResolvingSender", a.Filename, a.Ref)
+		}
+	}
+
+	switch m.ContentPreference {
+	case Both:
+		if m.Text == "" || m.HTML == "" {
+			return errors.New("send: message without both plain text and HTML parts")
+		}
+	case TextOnly:
+		if m.Text == "" {
+			return errors.New("send: TextOnly message without a plain text part")
+		}
+	case HTMLOnly:
+		if m.HTML == "" {
+			return errors.New("send: HTMLOnly message without a HTML part")
+		}
+		if m.Text == "" && !m.ForceHTMLOnly {
+			return ErrHTMLOnlyWithoutText
+		}
+	default:
+		return fmt.Errorf("send: unknown content preference %d", int(m.ContentPreference))
+	}
+	return nil
+}
+
+// Recipients returns the addresses of the To, Cc and Bcc recipients, for the envelope of the message
+func (m Message) Recipients() ([]string, error) {
+	var addrs []string
+	for _, list := range [][]string{m.To, m.Cc, m.Bcc} {
+		for _, recipient := range list {
+			a, err := mail.ParseAddress(recipient)
+			if err != nil {
+				return nil, fmt.Errorf("send: recipient %q: %w", recipient, err)
+			}
+			addrs = append(addrs, a.Address)
+		}
+	}
+	return addrs, nil
+}
+
+// parseAddress parses an address of the field of a message, rejecting line breaks which would add headers
+func parseAddress(field, address string) (*mail.Address, error) {
+	if strings.ContainsAny(address, "\r\n") {
+		return nil, fmt.Errorf("send: %s address %q has a line break", field, address)
+	}
+	a, err := mail.ParseAddress(address)
+	if err != nil {
+		return nil, fmt.Errorf("send: %s address %q: %w", field, address, err)
+	}
+	return a, nil
+}
+
+// parseAddresses returns the encoded form of the addresses of the field of a message, see parseAddress
+func parseAddresses(field string, addresses []string) ([]string, error) {
+	encoded := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		a, err := parseAddress(field, address)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, a.String())
+	}
+	return encoded, nil
+}
+
+// MIMEOptions are the options of the MIME encoding of messages, see Message.BytesWith
+type MIMEOptions struct {
+	// Rand is the source of the boundaries of the multipart entities (default to crypto/rand), e.g. a seeded source
+	// writing the same bytes on each run, for byte-exact tests
+	Rand io.Reader
+	Date time.Time // Date header of the message (default to the current time)
+}
+
+// Bytes returns the message in MIME format, with the parts selected by its content preference and the attachments
+func (m Message) Bytes() ([]byte, error) {
+	return m.BytesWith(MIMEOptions{})
+}
+
+// BytesWith returns the message in MIME format like Bytes, with the options
+func (m Message) BytesWith(opts MIMEOptions) ([]byte, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	random := opts.Rand
+	if random == nil {
+		random = rand.Reader
+	}
+	body, err := m.body(random)
+	if err != nil {
+		return nil, err
+	}
+
+	// Addresses are written in their encoded form, Validate checked that they parse
+	from, _ := parseAddress("From", m.From)
+	to, _ := parseAddresses("To", m.To)
+	cc, _ := parseAddresses("Cc", m.Cc)
+	date := opts.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	var b bytes.Buffer
+	writeHeader(&b, "From", from.String())
+	if len(to) > 0 {
+		writeHeader(&b, "To", strings.Join(to, ", "))
+	}
+	if len(cc) > 0 {
+		writeHeader(&b, "Cc", strings.Join(cc, ", "))
+	}
+	writeHeader(&b, "Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	writeHeader(&b, "Date", date.Format(time.RFC1123Z))
+	keys := make([]string, 0, len(m.Headers))
+	for key := range m.Headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		writeHeader(&b, textproto.CanonicalMIMEHeaderKey(key), m.Headers[key])
+	}
+	if u := m.Unsubscribe; u != nil {
+		var uris []string
+		if u.Mailto != "" {
+			uris = append(uris, "<mailto:"+u.Mailto+">")
+		}
+		if u.OneClickURL != "" {
+			uris = append(uris, "<"+u.OneClickURL+">")
+		}
+		if len(uris) > 0 {
+			writeHeader(&b, "List-Unsubscribe", strings.Join(uris, ",\r\n "))
+		}
+		if u.OneClickURL != "" {
+			writeHeader(&b, "List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+		}
+	}
+	writeHeader(&b, "MIME-Version", "1.0")
+
+	for _, key := range []string{"Content-Type", "Content-Transfer-Encoding"} {
+		if value := body.header.Get(key); value != "" {
+			writeHeader(&b, key, value)
+		}
+	}
+	b.WriteString("\r\n")
+	b.Write(body.content)
+	return b.Bytes(), nil
+}
+
+// body returns the MIME entity of the content of the message: the parts selected by its content preference,
+// in a multipart/related entity with the inline attachments, in a multipart/mixed entity with the other attachments.
+// The boundaries are read from random.
+func (m Message) body(random io.Reader) (entity, error) {
+	var content entity
+	var err error
+	switch {
+	case m.ContentPreference == TextOnly:
+		content = textEntity("text/plain", m.Text)
+	case m.ContentPreference == HTMLOnly && m.ForceHTMLOnly:
+		content = textEntity("text/html", m.HTML)
+	default:
+		content, err = multipartEntity(random, "multipart/alternative", textEntity("text/plain", m.Text), textEntity("text/html", m.HTML))
+		if err != nil {
+			return entity{}, err
+		}
+	}
+
+	var inline, attached []entity
+	for _, a := range m.Attachments {
+		if !a.Inline {
+			attached = append(attached, attachmentEntity(a))
+		} else if m.ContentPreference != TextOnly {
+			inline = append(inline, attachmentEntity(a))
+		}
+	}
+	if len(inline) > 0 {
+		if content, err = multipartEntity(random, "multipart/related", append([]entity{content}, inline...)...); err != nil {
+			return entity{}, err
+		}
+	}
+	if len(attached) > 0 {
+		if content, err = multipartEntity(random, "multipart/mixed", append([]entity{content}, attached...)...); err != nil {
+			return entity{}, err
+		}
+	}
+	return content, nil
+}
+
+// entity is a MIME entity: its header, and its encoded content
+type entity struct {
+	header  textproto.MIMEHeader
+	content []byte
+}
+
+func textEntity(mediaType, content string) entity {
+	var b bytes.Buffer
+	// Writing to a bytes.Buffer does not fail
+	_ = writeQuotedPrintable(&b, content)
+	return entity{
+		header: textproto.MIMEHeader{
+			"Content-Type":              {mediaType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		},
+		content: b.Bytes(),
+	}
+}
+
+func multipartEntity(random io.Reader, mediaType string, parts ...entity) (entity, error) {
+	boundary, err := randomBoundary(random)
+	if err != nil {
+		return entity{}, err
+	}
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+	// The boundary is made of hexadecimal digits, which SetBoundary accepts
+	_ = w.SetBoundary(boundary)
+	for _, part := range parts {
+		// Writing to a bytes.Buffer does not fail
+		pw, _ := w.CreatePart(part.header)
+		_, _ = pw.Write(part.content)
+	}
+	_ = w.Close()
+	return entity{
+		header:  textproto.MIMEHeader{"Content-Type": {mime.FormatMediaType(mediaType, map[string]string{"boundary": w.Boundary()})}},
+		content: b.Bytes(),
+	}, nil
+}
+
+// randomBoundary returns a boundary of 30 bytes read from random, like the ones of multipart.NewWriter
+func randomBoundary(random io.Reader) (string, error) {
+	var buf [30]byte
+	if _, err := io.ReadFull(random, buf[:]); err != nil {
+		return "", fmt.Errorf("send: read MIME boundary: %w", err)
+	}
+	return fmt.Sprintf("%x", buf[:]), nil
+}
+
+func attachmentEntity(a hermes.Attachment) entity {
+	disposition := "attachment"
+	if a.Inline {
+		disposition = "inline"
+	}
+	// The content type may have parameters, e.g. text/calendar; method=REQUEST
+	mediaType, params, err := mime.ParseMediaType(a.MediaType())
+	if err != nil {
+		mediaType, params = "application/octet-stream", map[string]string{}
+	}
+	params["name"] = a.Filename
+	header := textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType(mediaType, params)},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename})},
+	}
+	if a.Inline {
+		header.Set("Content-ID", "<"+a.ContentID()+">")
+	}
+
+	var b bytes.Buffer
+	encoded := base64.StdEncoding.EncodeToString(a.Data)
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded)
+	return entity{header: header, content: b.Bytes()}
+}
+
+func writeHeader(b *bytes.Buffer, key, value string) {
+	b.WriteString(key + ": " + value + "\r\n")
+}
+
+// writeQuotedPrintable encodes the text content, its line breaks as \r\n: the outputs of hermes break lines with \n,
+// see hermes.Normalize
+func writeQuotedPrintable(w io.Writer, content string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(content)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// Campaign sends the same content to many recipients
+type Campaign struct {
+	From    string
+	Subject string
+	Content hermes.Output
+	// PreferenceResolver returns the content preference of a recipient, e.g. from the stats of your provider.
+	// All recipients get Both when it is not set.
+	PreferenceResolver func(addr string) ContentPreference
+	// Segments is the content by audience segment, e.g. rendered by Hermes.RenderForSegment. SegmentResolver returns
+	// the segment of a recipient, those without content in Segments get Content.
+	Segments        map[string]hermes.Output
+	SegmentResolver func(addr string) string
+	// Suppressions lists the addresses which must not be emailed anymore, e.g. filled with the bounces and complaints
+	// of events.SuppressTo. Schedulers do not send to the recipients it suppresses.
+	Suppressions events.SuppressionList
+}
+
+// IsSuppressed reports whether the recipient is in the Suppressions of the campaign
+func (c Campaign) IsSuppressed(ctx context.Context, to string) (bool, error) {
+	if c.Suppressions == nil {
+		return false, nil
+	}
+	return c.Suppressions.IsSuppressed(ctx, to)
+}
+
+// Message returns the message of the campaign to the recipient
+func (c Campaign) Message(to string) Message {
+	content := c.Content
+	if c.SegmentResolver != nil {
+		if segmented, ok := c.Segments[c.SegmentResolver(to)]; ok {
+			content = segmented
+		}
+	}
+	m := Message{
+		From:    c.From,
+		To:      []string{to},
+		Subject: c.Subject,
+		HTML:    content.HTML,
+		Text:    content.PlainText,
+	}
+	if c.PreferenceResolver != nil {
+		m.ContentPreference = c.PreferenceResolver(to)
+	}
+	return m
+}
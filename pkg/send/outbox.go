@@ -0,0 +1,235 @@
+package send
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+// outboxFormat and outboxVersion identify the serialized messages of MarshalBinary. Newer versions keep loading the
+// messages of the previous ones.
+const (
+	outboxFormat  = "hermes/send.Message"
+	outboxVersion = 1
+)
+
+// MaxInlineAttachmentSize is the size of the largest attachment data serialized by Message.MarshalBinary. Larger
+// attachments must be stored elsewhere and given a Ref, resolved at send time by ResolvingSender.
+var MaxInlineAttachmentSize = 1 << 20
+
+// ErrAttachmentTooLarge is returned by Message.MarshalBinary for attachments above MaxInlineAttachmentSize without Ref
+var ErrAttachmentTooLarge = errors.New("send: attachment too large to be serialized, store it elsewhere and set its Ref")
+
+// ErrCorruptMessage is returned by Message.UnmarshalBinary for data which is not a serialized message, or of which the
+// integrity hash does not match its content
+var ErrCorruptMessage = errors.New("send: corrupt serialized message")
+
+var _ Sender = ResolvingSender{}
+
+// outboxEnvelope is the serialized message: the JSON of its content, with the SHA-256 of that JSON in hex
+type outboxEnvelope struct {
+	Format  string          `json:"format"`
+	Version int             `json:"version"`
+	SHA256  string          `json:"sha256"`
+	Message json.RawMessage `json:"message"`
+}
+
+// outboxMessage is the content of a serialized message. Its fields are named independently of the ones of Message and
+// of the types of hermes, so that renaming them does not break the messages already serialized. Fields are only added,
+// never removed.
+type outboxMessage struct {
+	From              string             `json:"from,omitempty"`
+	To                []string           `json:"to,omitempty"`
+	Cc                []string           `json:"cc,omitempty"`
+	Bcc               []string           `json:"bcc,omitempty"`
+	Subject           string             `json:"subject,omitempty"`
+	HTML              string             `json:"html,omitempty"`
+	Text              string             `json:"text,omitempty"`
+	Headers           map[string]string  `json:"headers,omitempty"`
+	ContentPreference string             `json:"content_preference"`
+	ForceHTMLOnly     bool               `json:"force_html_only,omitempty"`
+	Attachments       []outboxAttachment `json:"attachments,omitempty"`
+	Unsubscribe       *outboxUnsubscribe `json:"unsubscribe,omitempty"`
+	IdempotencyKey    string             `json:"idempotency_key,omitempty"`
+	Metadata          map[string]string  `json:"metadata,omitempty"`
+}
+
+// outboxAttachment is a serialized hermes.Attachment
+type outboxAttachment struct {
+	Filename    string `json:"filename,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Data        []byte `json:"data,omitempty"`
+	Inline      bool   `json:"inline,omitempty"`
+	CID         string `json:"cid,omitempty"`
+	Ref         string `json:"ref,omitempty"`
+}
+
+// outboxUnsubscribe is a serialized hermes.Unsubscribe
+type outboxUnsubscribe struct {
+	URL         string `json:"url,omitempty"`
+	Mailto      string `json:"mailto,omitempty"`
+	OneClickURL string `json:"one_click_url,omitempty"`
+}
+
+// MarshalBinary serializes the message with everything needed to send it later without generating it again, e.g. to
+// write it to an outbox table in the transaction of a change, sent by a worker. The data of attachments with a Ref is
+// left out, others must not exceed MaxInlineAttachmentSize. The format is versioned JSON, with an integrity hash
+// checked by UnmarshalBinary.
+func (m Message) MarshalBinary() ([]byte, error) {
+	var attachments []outboxAttachment
+	for _, a := range m.Attachments {
+		switch {
+		case a.Ref != "":
+			a.Data = nil
+		case len(a.Data) > MaxInlineAttachmentSize:
+			return nil, fmt.Errorf("%w: %s is %d bytes, above %d bytes", ErrAttachmentTooLarge, a.Filename, len(a.Data), MaxInlineAttachmentSize)
+		}
+		attachments = append(attachments, outboxAttachment{
+			Filename:    a.Filename,
+			ContentType: a.ContentType,
+			Data:        a.Data,
+			Inline:      a.Inline,
+			CID:         a.CID,
+			Ref:         a.Ref,
+		})
+	}
+	var unsubscribe *outboxUnsubscribe
+	if u := m.Unsubscribe; u != nil {
+		unsubscribe = &outboxUnsubscribe{URL: u.URL, Mailto: u.Mailto, OneClickURL: u.OneClickURL}
+	}
+	content, err := json.Marshal(outboxMessage{
+		From:              m.From,
+		To:                m.To,
+		Cc:                m.Cc,
+		Bcc:               m.Bcc,
+		Subject:           m.Subject,
+		HTML:              m.HTML,
+		Text:              m.Text,
+		Headers:           m.Headers,
+		ContentPreference: m.ContentPreference.String(),
+		ForceHTMLOnly:     m.ForceHTMLOnly,
+		Attachments:       attachments,
+		Unsubscribe:       unsubscribe,
+		IdempotencyKey:    m.IdempotencyKey,
+		Metadata:          m.Metadata,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("send: serialize message: %w", err)
+	}
+	sum := sha256.Sum256(content)
+	return json.Marshal(outboxEnvelope{
+		Format:  outboxFormat,
+		Version: outboxVersion,
+		SHA256:  hex.EncodeToString(sum[:]),
+		Message: content,
+	})
+}
+
+// UnmarshalBinary loads a message serialized by MarshalBinary, of the current version or a previous one. It returns
+// ErrCorruptMessage when the data was modified since it was serialized, and an error for versions newer than the
+// one of this package. Attachments stored by reference are left to be resolved at send time.
+func (m *Message) UnmarshalBinary(data []byte) error {
+	var envelope outboxEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("%w: %v", ErrCorruptMessage, err)
+	}
+	if envelope.Format != outboxFormat {
+		return fmt.Errorf("%w: unknown format %q", ErrCorruptMessage, envelope.Format)
+	}
+	if envelope.Version < 1 || envelope.Version > outboxVersion {
+		return fmt.Errorf("send: serialized message of version %d, this version of hermes reads up to version %d", envelope.Version, outboxVersion)
+	}
+	sum := sha256.Sum256(envelope.Message)
+	if hex.EncodeToString(sum[:]) != envelope.SHA256 {
+		return fmt.Errorf("%w: integrity hash mismatch", ErrCorruptMessage)
+	}
+
+	var content outboxMessage
+	if err := json.Unmarshal(envelope.Message, &content); err != nil {
+		return fmt.Errorf("%w: %v", ErrCorruptMessage, err)
+	}
+	preference, err := parseContentPreference(content.ContentPreference)
+	if err != nil {
+		return err
+	}
+	var attachments []hermes.Attachment
+	for _, a := range content.Attachments {
+		attachments = append(attachments, hermes.Attachment{
+			Filename:    a.Filename,
+			ContentType: a.ContentType,
+			Data:        a.Data,
+			Inline:      a.Inline,
+			CID:         a.CID,
+			Ref:         a.Ref,
+		})
+	}
+	var unsubscribe *hermes.Unsubscribe
+	if u := content.Unsubscribe; u != nil {
+		unsubscribe = &hermes.Unsubscribe{URL: u.URL, Mailto: u.Mailto, OneClickURL: u.OneClickURL}
+	}
+	*m = Message{
+		From:              content.From,
+		To:                content.To,
+		Cc:                content.Cc,
+		Bcc:               content.Bcc,
+		Subject:           content.Subject,
+		HTML:              content.HTML,
+		Text:              content.Text,
+		Headers:           content.Headers,
+		ContentPreference: preference,
+		ForceHTMLOnly:     content.ForceHTMLOnly,
+		Attachments:       attachments,
+		Unsubscribe:       unsubscribe,
+		IdempotencyKey:    content.IdempotencyKey,
+		Metadata:          content.Metadata,
+	}
+	return nil
+}
+
+// parseContentPreference returns the content preference of its String
+func parseContentPreference(s string) (ContentPreference, error) {
+	for _, p := range []ContentPreference{Both, TextOnly, HTMLOnly} {
+		if p.String() == s {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("send: unknown content preference %q", s)
+}
+
+// ResolvingSender sends messages through Sender, once the data of their attachments stored by reference is resolved,
+// e.g. read from an object storage. The message given is left untouched.
+type ResolvingSender struct {
+	Sender Sender
+	// Resolve returns the data of the attachment of the reference, see hermes.Attachment.Ref
+	Resolve func(ctx context.Context, ref string) ([]byte, error)
+}
+
+// Send resolves the attachments of the message with a Ref and without Data, and sends it
+func (r ResolvingSender) Send(ctx context.Context, msg Message) error {
+	var attachments []hermes.Attachment
+	for i, a := range msg.Attachments {
+		if a.Ref == "" || a.Data != nil {
+			continue
+		}
+		if attachments == nil {
+			attachments = append([]hermes.Attachment(nil), msg.Attachments...)
+		}
+		data, err := r.Resolve(ctx, a.Ref)
+		if err != nil {
+			return fmt.Errorf("send: resolve attachment %s: %w", a.Filename, err)
+		}
+		if data == nil {
+			data = []byte{}
+		}
+		attachments[i].Data = data
+	}
+	if attachments != nil {
+		msg.Attachments = attachments
+	}
+	return r.Sender.Send(ctx, msg)
+}
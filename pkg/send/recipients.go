@@ -0,0 +1,334 @@
+package send
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Problems of the recipients found by ValidateRecipients
+const (
+	RecipientInvalid    = "invalid"    // The address is not valid, e.g. without @
+	RecipientTypo       = "typo"       // The domain is a common typo, e.g. gmial.com, see RecipientCheck.Suggestion
+	RecipientDisposable = "disposable" // The domain is a disposable email service, e.g. mailinator.com
+	RecipientNoMX       = "no_mx"      // The domain does not exist, or does not accept emails
+	// RecipientUnverified is the problem of the addresses whose MX lookup failed, e.g. on timeouts. They are not
+	// failures: the address may well be valid.
+	RecipientUnverified = "unverified"
+)
+
+// DefaultLookupConcurrency is the number of MX lookups run at once by ValidateRecipients by default
+const DefaultLookupConcurrency = 8
+
+// MXResolver looks up the mail exchangers of the domains checked by ValidateRecipients, e.g. a *net.Resolver.
+// Domains without MX records accept emails on their address records.
+type MXResolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+var _ MXResolver = (*net.Resolver)(nil)
+
+// CommonTypos are the misspellings of the domains of popular email services, and their correction
+var CommonTypos = map[string]string{
+	"gmial.com":   "gmail.com",
+	"gmai.com":    "gmail.com",
+	"gamil.com":   "gmail.com",
+	"gnail.com":   "gmail.com",
+	"gmaill.com":  "gmail.com",
+	"gmail.co":    "gmail.com",
+	"gmail.con":   "gmail.com",
+	"gmail.cm":    "gmail.com",
+	"hotmial.com": "hotmail.com",
+	"hotmal.com":  "hotmail.com",
+	"hotmai.com":  "hotmail.com",
+	"hotmil.com":  "hotmail.com",
+	"hotmail.co":  "hotmail.com",
+	"hotmail.con": "hotmail.com",
+	"outlok.com":  "outlook.com",
+	"outloo.com":  "outlook.com",
+	"outlook.co":  "outlook.com",
+	"yahooo.com":  "yahoo.com",
+	"yaho.com":    "yahoo.com",
+	"yhaoo.com":   "yahoo.com",
+	"yahoo.con":   "yahoo.com",
+	"iclod.com":   "icloud.com",
+	"icoud.com":   "icloud.com",
+	"icloud.co":   "icloud.com",
+}
+
+//go:embed disposable_domains.txt
+var disposableDomains string
+
+// DisposableDomains are the domains of disposable email services flagged by ValidateRecipients by default, from the
+// list embedded in this package
+var DisposableDomains = mustParseDomainList(disposableDomains)
+
+// RecipientOptions are the checks of ValidateRecipients
+type RecipientOptions struct {
+	// CheckMX looks up the MX records of the domains of the addresses, to flag the ones which do not accept emails.
+	// Each domain is looked up once, and lookups failing with a temporary error are retried.
+	CheckMX     bool
+	Resolver    MXResolver    // Default to net.DefaultResolver
+	Timeout     time.Duration // Timeout of each lookup (default to DefaultDNSTimeout)
+	Retries     int           // Retries of the lookups failing with a temporary error, e.g. a timeout
+	Concurrency int           // Lookups run at once (default to DefaultLookupConcurrency)
+	// Cache holds the results of the lookups, to share them across calls, e.g. for the lists of a day. The lookups of
+	// a call are never run twice for the same domain anyway.
+	Cache *MXCache
+
+	Typos             map[string]string // Misspelled domains and their correction (default to CommonTypos)
+	DisposableDomains map[string]bool   // Domains of disposable email services (default to DisposableDomains)
+}
+
+// RecipientCheck is the result of the checks of an address
+type RecipientCheck struct {
+	Address string // Address as given
+	// Problem is the problem found, e.g. RecipientTypo, or "" when the address passes the checks
+	Problem string
+	// Suggestion is the address with its domain corrected, for RecipientTypo, e.g. jon@gmail.com for jon@gmial.com
+	Suggestion string
+	Err        error // Error of the problem, e.g. of the parsing of the address, or of the lookup of its domain
+}
+
+// Failed reports whether the address failed the checks. Unverified addresses did not fail.
+func (c RecipientCheck) Failed() bool {
+	return c.Problem != "" && c.Problem != RecipientUnverified
+}
+
+// ValidateRecipients checks the addresses before sending to them, e.g. the imported lists of a campaign, and returns
+// their checks in the same order: their syntax, known typos of their domains, disposable email services, and with
+// CheckMX whether their domains accept emails. Lookups run concurrently, and stop when the context is done: the
+// addresses not looked up yet are then unverified, with the error of the context.
+func ValidateRecipients(ctx context.Context, addrs []string, opts RecipientOptions) []RecipientCheck {
+	checks := make([]RecipientCheck, len(addrs))
+	domains := map[string][]int{} // Indexes of the addresses to look up, by domain
+	for i, addr := range addrs {
+		var domain string
+		checks[i], domain = opts.check(addr)
+		if checks[i].Problem == "" && opts.CheckMX {
+			domains[domain] = append(domains[domain], i)
+		}
+	}
+	if len(domains) == 0 {
+		return checks
+	}
+
+	sem := make(chan struct{}, opts.concurrency())
+	var wg sync.WaitGroup
+	for domain, indexes := range domains {
+		wg.Add(1)
+		go func(domain string, indexes []int) {
+			defer wg.Done()
+			var err error
+			select {
+			case sem <- struct{}{}:
+				err = opts.lookup(ctx, domain)
+				<-sem
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+			if err == nil {
+				return
+			}
+			problem := RecipientUnverified
+			if errors.Is(err, errNoMX) {
+				problem = RecipientNoMX
+			}
+			// Each address is written by a single goroutine
+			for _, i := range indexes {
+				checks[i].Problem = problem
+				checks[i].Err = err
+			}
+		}(domain, indexes)
+	}
+	wg.Wait()
+	return checks
+}
+
+// check runs the checks of the address which do not need the network, and returns its domain in lower case
+func (o RecipientOptions) check(addr string) (RecipientCheck, string) {
+	c := RecipientCheck{Address: addr}
+	parsed, err := mail.ParseAddress(addr)
+	if err != nil {
+		c.Problem, c.Err = RecipientInvalid, err
+		return c, ""
+	}
+	at := strings.LastIndex(parsed.Address, "@")
+	local, domain := parsed.Address[:at], strings.ToLower(parsed.Address[at+1:])
+	if !strings.Contains(domain, ".") {
+		c.Problem, c.Err = RecipientInvalid, fmt.Errorf("domain %s without dot", domain)
+		return c, domain
+	}
+	typos := o.Typos
+	if typos == nil {
+		typos = CommonTypos
+	}
+	if correction, ok := typos[domain]; ok {
+		c.Problem, c.Suggestion = RecipientTypo, local+"@"+correction
+		c.Err = fmt.Errorf("%s is a typo of %s", domain, correction)
+		return c, domain
+	}
+	disposable := o.DisposableDomains
+	if disposable == nil {
+		disposable = DisposableDomains
+	}
+	for d := domain; d != ""; {
+		if disposable[d] {
+			c.Problem, c.Err = RecipientDisposable, fmt.Errorf("%s is a disposable email service", d)
+			return c, domain
+		}
+		_, d, _ = strings.Cut(d, ".")
+	}
+	return c, domain
+}
+
+// errNoMX is the error of the domains which do not accept emails
+var errNoMX = errors.New("domain does not accept emails")
+
+// lookup returns nil when the domain accepts emails, an error wrapping errNoMX when it does not, or the error of the
+// lookup, retried on temporary errors
+func (o RecipientOptions) lookup(ctx context.Context, domain string) error {
+	if e, ok := o.Cache.get(domain); ok {
+		return e.err
+	}
+	var err error
+	for attempt := 0; attempt <= o.Retries; attempt++ {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if err = o.lookupOnce(ctx, domain); !temporary(err) {
+			break
+		}
+	}
+	if !temporary(err) && ctx.Err() == nil {
+		o.Cache.add(domain, err)
+	}
+	return err
+}
+
+func (o RecipientOptions) lookupOnce(ctx context.Context, domain string) error {
+	ctx, cancel := context.WithTimeout(ctx, durationOr(o.Timeout, DefaultDNSTimeout))
+	defer cancel()
+	resolver := o.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	mxs, err := resolver.LookupMX(ctx, domain)
+	switch {
+	case err == nil && len(mxs) == 1 && (mxs[0].Host == "." || mxs[0].Host == ""):
+		// Null MX, RFC 7505
+		return fmt.Errorf("%w: %s has a null MX record", errNoMX, domain)
+	case err == nil && len(mxs) > 0:
+		return nil
+	case err != nil && !notFound(err):
+		return fmt.Errorf("lookup MX of %s: %w", domain, err)
+	}
+	// Without MX records, emails are delivered to the address records of the domain, RFC 5321
+	if _, err := resolver.LookupHost(ctx, domain); err != nil {
+		if notFound(err) {
+			return fmt.Errorf("%w: %s has no MX nor address records", errNoMX, domain)
+		}
+		return fmt.Errorf("lookup host %s: %w", domain, err)
+	}
+	return nil
+}
+
+func (o RecipientOptions) concurrency() int {
+	if o.Concurrency > 0 {
+		return o.Concurrency
+	}
+	return DefaultLookupConcurrency
+}
+
+// temporary reports whether the lookup error may not happen again, e.g. a timeout
+func temporary(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// notFound reports whether the lookup error is that the records do not exist
+func notFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// MXCache holds the results of the MX lookups of ValidateRecipients by domain. It is safe for concurrent use.
+// Temporary errors are not cached.
+type MXCache struct {
+	TTL time.Duration // Time results are kept for, forever when 0
+
+	mu      sync.Mutex
+	entries map[string]mxEntry
+}
+
+type mxEntry struct {
+	err     error
+	expires time.Time
+}
+
+func (c *MXCache) get(domain string) (mxEntry, bool) {
+	if c == nil {
+		return mxEntry{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[domain]
+	if !ok || (!e.expires.IsZero() && time.Now().After(e.expires)) {
+		return mxEntry{}, false
+	}
+	return e, true
+}
+
+func (c *MXCache) add(domain string, err error) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]mxEntry{}
+	}
+	e := mxEntry{err: err}
+	if c.TTL > 0 {
+		e.expires = time.Now().Add(c.TTL)
+	}
+	c.entries[domain] = e
+}
+
+// ParseDomainList reads a list of domains, one per line, e.g. an updated list of DisposableDomains. Blank lines and
+// lines starting with # are ignored.
+func ParseDomainList(r io.Reader) (map[string]bool, error) {
+	domains := map[string]bool{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains[strings.ToLower(line)] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("send: read domain list: %w", err)
+	}
+	return domains, nil
+}
+
+func mustParseDomainList(list string) map[string]bool {
+	domains, err := ParseDomainList(strings.NewReader(list))
+	if err != nil {
+		panic(err)
+	}
+	return domains
+}
@@ -0,0 +1,314 @@
+package send
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Scheduler sends a campaign within the sending quotas of a provider, e.g. the 50,000 messages a day of an SES account:
+// messages beyond a quota wait for the next day or hour. Its progress is saved in a CheckpointStore after each message,
+// so that a campaign stopped by a crash or a cancellation resumes where it stopped when run again with the same ID.
+//
+// A message whose sending was interrupted by a crash is never sent again: delivery is at most once. Messages suppressed
+// by a DedupeSender are counted in Progress, and do not stop the campaign. With Validate, the recipients failing the
+// checks of ValidateRecipients are skipped or quarantined instead of being sent to. The recipients in the Suppressions
+// of the campaign, e.g. after a bounce, are skipped as well, and counted in Progress.
+// Schedulers must not be copied once running, and a campaign must not be run by two schedulers at once.
+type Scheduler struct {
+	Sender   Sender
+	Campaign Campaign
+	// ID identifies the campaign in Checkpoints, e.g. its name
+	ID string
+	// DailyQuota and HourlyQuota limit the messages sent per day and per hour, unlimited when 0.
+	// Days and hours start at midnight and on the hour in Location, not 24 hours after the first message.
+	DailyQuota  int
+	HourlyQuota int
+	Location    *time.Location // Time zone of the days of DailyQuota (default to UTC)
+	// Checkpoints holds the progress of the campaigns (default to an in-memory store, which does not survive the process)
+	Checkpoints CheckpointStore
+	// Validate checks the recipients not sent to yet with ValidateRecipients when Run starts, when set. The ones failing
+	// the checks are not sent to, and do not count in the quotas: they are given to Quarantine when set, e.g. to be
+	// reviewed, and skipped otherwise. A Quarantine error stops the campaign like the errors of the sender.
+	Validate   *RecipientOptions
+	Quarantine func(ctx context.Context, check RecipientCheck) error
+
+	Now  func() time.Time                                 // Clock of the quotas (default to time.Now)
+	Wait func(ctx context.Context, d time.Duration) error // Waits for the next quota window (default to a timer)
+
+	mu       sync.Mutex
+	memory   *MemoryCheckpointStore
+	progress Progress
+}
+
+// Progress is the state of a scheduled campaign, for monitoring
+type Progress struct {
+	Total int // Recipients of the campaign
+	Sent  int // Recipients sent to, in the order of the list
+	// Suppressed are the recipients among Sent whose message was a duplicate, see ErrDuplicateSuppressed
+	Suppressed int
+	Skipped    int       // Recipients among Sent which failed the checks of Validate, and were not sent to
+	Excluded   int       // Recipients among Sent in the Suppressions of the campaign, which were not sent to
+	Waiting    time.Time // Start of the quota window the scheduler waits for, zero while it sends
+}
+
+// Batch is a part of the recipients of a campaign sent within the same quota window
+type Batch struct {
+	Start      time.Time // Start of the window, or the time of planning for the current one
+	Recipients []string
+}
+
+// Checkpoint is the progress of a campaign saved by a Scheduler
+type Checkpoint struct {
+	Recipients int       `json:"recipients"` // Number of recipients of the campaign
+	Sent       int       `json:"sent"`       // Recipients handled: the next message goes to the one at this index
+	Day        time.Time `json:"day"`        // Start of the day of DaySent
+	DaySent    int       `json:"day_sent"`
+	Hour       time.Time `json:"hour"` // Start of the hour of HourSent
+	HourSent   int       `json:"hour_sent"`
+	Suppressed int       `json:"suppressed"` // Messages among Sent suppressed as duplicates, which do not count in the quotas
+	Skipped    int       `json:"skipped"`    // Recipients among Sent which failed the checks of Validate
+	Excluded   int       `json:"excluded"`   // Recipients among Sent in the Suppressions of the campaign
+}
+
+// Progress returns the progress of the running campaign, or of the last one run. It is safe for concurrent use.
+func (s *Scheduler) Progress() Progress {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.progress
+}
+
+// Run sends the campaign to the recipients not sent to yet, waiting for the quotas when needed.
+// The recipients must be given in the same order when resuming.
+// It stops with the error of the context when it is done, or at the first error of the sender but ErrDuplicateSuppressed,
+// or of the suppression list, the checkpoint being saved in all cases so that the next run resumes with the first
+// recipient not sent to.
+func (s *Scheduler) Run(ctx context.Context, recipients []string) error {
+	if s.Sender == nil {
+		return errors.New("send: scheduler without sender")
+	}
+	store := s.store()
+	cp, err := s.load(ctx, store, recipients)
+	if err != nil {
+		return err
+	}
+	s.setProgress(cp, time.Time{})
+	failed := map[int]RecipientCheck{} // Checks failed by the recipients, by index
+	if s.Validate != nil {
+		for i, check := range ValidateRecipients(ctx, recipients[cp.Sent:], *s.Validate) {
+			if check.Failed() {
+				failed[cp.Sent+i] = check
+			}
+		}
+	}
+	for cp.Sent < len(recipients) {
+		if check, ok := failed[cp.Sent]; ok {
+			if err := s.skip(ctx, store, &cp, check); err != nil {
+				return s.stop(ctx, store, cp, err)
+			}
+			continue
+		}
+		// Checked right before sending, so that the bounces of the first messages suppress the next ones
+		if suppressed, err := s.Campaign.IsSuppressed(ctx, recipients[cp.Sent]); err != nil {
+			return s.stop(ctx, store, cp, fmt.Errorf("send: suppression of %s: %w", recipients[cp.Sent], err))
+		} else if suppressed {
+			if err := s.exclude(ctx, store, &cp); err != nil {
+				return s.stop(ctx, store, cp, err)
+			}
+			continue
+		}
+		now := s.now()
+		cp.roll(now, s.location())
+		if next := s.nextWindow(cp); !next.IsZero() {
+			s.setProgress(cp, next)
+			if err := s.wait(ctx, next.Sub(now)); err != nil {
+				return s.stop(ctx, store, cp, err)
+			}
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return s.stop(ctx, store, cp, err)
+		}
+
+		// The message is recorded as sent before sending it, so that it is not sent twice after a crash
+		to := recipients[cp.Sent]
+		cp.Sent++
+		cp.DaySent++
+		cp.HourSent++
+		if err := store.Save(ctx, s.ID, cp); err != nil {
+			return fmt.Errorf("send: save checkpoint of %q: %w", s.ID, err)
+		}
+		err := s.Sender.Send(ctx, s.Campaign.Message(to))
+		if errors.Is(err, ErrDuplicateSuppressed) {
+			cp.DaySent--
+			cp.HourSent--
+			cp.Suppressed++
+			if err := store.Save(ctx, s.ID, cp); err != nil {
+				return fmt.Errorf("send: save checkpoint of %q: %w", s.ID, err)
+			}
+		} else if err != nil {
+			cp.Sent--
+			cp.DaySent--
+			cp.HourSent--
+			return s.stop(ctx, store, cp, fmt.Errorf("send: message to %s: %w", to, err))
+		}
+		s.setProgress(cp, time.Time{})
+	}
+	return nil
+}
+
+// Plan partitions the recipients not sent to yet into the batches fitting the quotas, from now on.
+// The recipients are not validated: the ones failing the checks of Validate, or suppressed, are planned as well.
+func (s *Scheduler) Plan(ctx context.Context, recipients []string) ([]Batch, error) {
+	cp, err := s.load(ctx, s.store(), recipients)
+	if err != nil {
+		return nil, err
+	}
+	var batches []Batch
+	now := s.now()
+	for cp.Sent < len(recipients) {
+		cp.roll(now, s.location())
+		if next := s.nextWindow(cp); !next.IsZero() {
+			now = next
+			continue
+		}
+		n := len(recipients) - cp.Sent
+		if s.DailyQuota > 0 && s.DailyQuota-cp.DaySent < n {
+			n = s.DailyQuota - cp.DaySent
+		}
+		if s.HourlyQuota > 0 && s.HourlyQuota-cp.HourSent < n {
+			n = s.HourlyQuota - cp.HourSent
+		}
+		batches = append(batches, Batch{Start: now, Recipients: recipients[cp.Sent : cp.Sent+n]})
+		cp.Sent += n
+		cp.DaySent += n
+		cp.HourSent += n
+	}
+	return batches, nil
+}
+
+// load returns the checkpoint of the campaign, or a new one
+func (s *Scheduler) load(ctx context.Context, store CheckpointStore, recipients []string) (Checkpoint, error) {
+	if s.ID == "" {
+		return Checkpoint{}, errors.New("send: scheduler without campaign ID")
+	}
+	cp, ok, err := store.Load(ctx, s.ID)
+	switch {
+	case err != nil:
+		return Checkpoint{}, fmt.Errorf("send: load checkpoint of %q: %w", s.ID, err)
+	case !ok:
+		return Checkpoint{Recipients: len(recipients)}, nil
+	case cp.Recipients != len(recipients) || cp.Sent > len(recipients):
+		return Checkpoint{}, fmt.Errorf("send: checkpoint of %q is for %d recipients, not %d", s.ID, cp.Recipients, len(recipients))
+	}
+	return cp, nil
+}
+
+// skip quarantines the recipient which failed the checks when Quarantine is set, and records it as handled
+func (s *Scheduler) skip(ctx context.Context, store CheckpointStore, cp *Checkpoint, check RecipientCheck) error {
+	if s.Quarantine != nil {
+		if err := s.Quarantine(ctx, check); err != nil {
+			return fmt.Errorf("send: quarantine %s: %w", check.Address, err)
+		}
+	}
+	cp.Sent++
+	cp.Skipped++
+	if err := store.Save(ctx, s.ID, *cp); err != nil {
+		return fmt.Errorf("send: save checkpoint of %q: %w", s.ID, err)
+	}
+	s.setProgress(*cp, time.Time{})
+	return nil
+}
+
+// exclude records the suppressed recipient as handled, without counting it in the quotas
+func (s *Scheduler) exclude(ctx context.Context, store CheckpointStore, cp *Checkpoint) error {
+	cp.Sent++
+	cp.Excluded++
+	if err := store.Save(ctx, s.ID, *cp); err != nil {
+		return fmt.Errorf("send: save checkpoint of %q: %w", s.ID, err)
+	}
+	s.setProgress(*cp, time.Time{})
+	return nil
+}
+
+// stop saves the checkpoint, even when the context is done, and returns the error stopping the campaign
+func (s *Scheduler) stop(ctx context.Context, store CheckpointStore, cp Checkpoint, err error) error {
+	s.setProgress(cp, time.Time{})
+	if saveErr := store.Save(context.WithoutCancel(ctx), s.ID, cp); saveErr != nil {
+		return errors.Join(err, fmt.Errorf("send: save checkpoint of %q: %w", s.ID, saveErr))
+	}
+	return err
+}
+
+// nextWindow returns the start of the window the quotas let the next message be sent in, or zero for now
+func (s *Scheduler) nextWindow(cp Checkpoint) time.Time {
+	switch {
+	case s.DailyQuota > 0 && cp.DaySent >= s.DailyQuota:
+		return cp.Day.AddDate(0, 0, 1)
+	case s.HourlyQuota > 0 && cp.HourSent >= s.HourlyQuota:
+		return cp.Hour.Add(time.Hour)
+	}
+	return time.Time{}
+}
+
+// roll starts new quota windows when the day or the hour of the checkpoint is over
+func (cp *Checkpoint) roll(now time.Time, loc *time.Location) {
+	now = now.In(loc)
+	if day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc); !cp.Day.Equal(day) {
+		cp.Day, cp.DaySent = day, 0
+	}
+	// Hours start on the hour of the wall clock, e.g. at 30 past the hours of UTC in India, and are kept as instants:
+	// time.Date would map the hour repeated when clocks fall back to its first occurrence, an hour in the past
+	wall := time.Duration(now.Minute())*time.Minute + time.Duration(now.Second())*time.Second + time.Duration(now.Nanosecond())
+	if hour := now.Add(-wall); !cp.Hour.Equal(hour) {
+		cp.Hour, cp.HourSent = hour, 0
+	}
+}
+
+func (s *Scheduler) setProgress(cp Checkpoint, waiting time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.progress = Progress{Total: cp.Recipients, Sent: cp.Sent, Suppressed: cp.Suppressed, Skipped: cp.Skipped, Excluded: cp.Excluded, Waiting: waiting}
+}
+
+func (s *Scheduler) store() CheckpointStore {
+	if s.Checkpoints != nil {
+		return s.Checkpoints
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.memory == nil {
+		s.memory = NewMemoryCheckpointStore()
+	}
+	return s.memory
+}
+
+func (s *Scheduler) location() *time.Location {
+	if s.Location != nil {
+		return s.Location
+	}
+	return time.UTC
+}
+
+func (s *Scheduler) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+func (s *Scheduler) wait(ctx context.Context, d time.Duration) error {
+	if s.Wait != nil {
+		return s.Wait(ctx, d)
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
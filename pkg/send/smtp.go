@@ -0,0 +1,258 @@
+package send
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+// Sender sends messages, e.g. through SMTP or the API of a provider
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// TLSMode selects how connections to the SMTP server are encrypted
+type TLSMode int
+
+// TLS modes
+const (
+	StartTLS    TLSMode = iota // Upgrades the connection with STARTTLS, which the server must offer, usually on port 587 (default)
+	ImplicitTLS                // Connects over TLS, usually on port 465
+	NoTLS                      // Plain connection, for local relays and tests only
+)
+
+func (m TLSMode) String() string {
+	switch m {
+	case StartTLS:
+		return "StartTLS"
+	case ImplicitTLS:
+		return "ImplicitTLS"
+	case NoTLS:
+		return "NoTLS"
+	}
+	return fmt.Sprintf("TLSMode(%d)", int(m))
+}
+
+// Default timeouts of SMTP
+const (
+	DefaultDialTimeout = 10 * time.Second
+	DefaultSMTPTimeout = time.Minute
+)
+
+// SMTP is a Sender delivering messages to an SMTP server
+type SMTP struct {
+	Server    string
+	Port      int
+	Username  string // Authenticates with PLAIN auth when set, which net/smtp refuses over plain connections but to localhost
+	Password  string
+	TLS       TLSMode
+	TLSConfig *tls.Config // Default to verifying the certificate of Server
+
+	DialTimeout time.Duration // Timeout of the connection (default to DefaultDialTimeout)
+	Timeout     time.Duration // Timeout of the whole exchange, connection included (default to DefaultSMTPTimeout)
+
+	// OnTransmit is called with the exact bytes of each message accepted by the server, e.g. to archive them for
+	// compliance. raw is a copy, which the hook may keep but cannot use to change what was sent.
+	OnTransmit func(ctx context.Context, envelope Envelope, raw []byte)
+	// Tee returns the writer receiving the bytes of the message as they are sent, e.g. to stream them to object
+	// storage, or nil. Its content is complete only when Send succeeds; when writing to it fails, the message is not sent.
+	Tee func(ctx context.Context, envelope Envelope) io.Writer
+	// OnQuitError is called with the error ending the session once the server accepted the message, e.g. to log it.
+	// The message is sent: Send succeeds anyway, so that it is not sent again (default to ignoring the error).
+	OnQuitError func(ctx context.Context, envelope Envelope, err error)
+	// Rand is the source of the MIME boundaries of the messages (default to crypto/rand), see MIMEOptions
+	Rand io.Reader
+	Now  func() time.Time // Clock of the Date header of the messages (default to time.Now)
+	// Metrics counts the messages sent and failed, as hermes.MetricSendAttempts of the sender "smtp"
+	Metrics hermes.MetricsSink
+}
+
+// Envelope is the SMTP envelope of a message: the addresses given to MAIL FROM and RCPT TO, which differ from the
+// headers for Bcc recipients
+type Envelope struct {
+	From    string
+	To      []string
+	Attempt int // Number of the attempt at sending the message, from 1, see WithAttempt
+}
+
+type attemptKey struct{}
+
+// WithAttempt returns the context of the attempt at sending a message, from 1, for the Envelope given to
+// SMTP.OnTransmit and SMTP.Tee by the retry loops of callers
+func WithAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+// attempt returns the attempt of the context, 1 by default
+func attempt(ctx context.Context) int {
+	if n, ok := ctx.Value(attemptKey{}).(int); ok && n > 0 {
+		return n
+	}
+	return 1
+}
+
+var _ Sender = SMTP{}
+
+// Validate checks the configuration of the server
+func (s SMTP) Validate() error {
+	switch {
+	case s.Server == "":
+		return errors.New("send: SMTP server is empty")
+	case s.Port <= 0 || s.Port > 65535:
+		return fmt.Errorf("send: invalid SMTP port %d", s.Port)
+	case s.Password != "" && s.Username == "":
+		return errors.New("send: SMTP password without user")
+	case s.TLS < StartTLS || s.TLS > NoTLS:
+		return fmt.Errorf("send: unknown TLS mode %d", int(s.TLS))
+	}
+	return nil
+}
+
+// Send delivers the message to the server, to its To, Cc and Bcc recipients.
+// The exchange is aborted when the context is done.
+func (s SMTP) Send(ctx context.Context, msg Message) error {
+	err := s.sendMessage(ctx, msg)
+	if s.Metrics != nil {
+		result := "sent"
+		if err != nil {
+			result = "failed"
+		}
+		s.Metrics.Count(hermes.MetricSendAttempts, map[string]string{"sender": "smtp", "result": result}, 1)
+	}
+	return err
+}
+
+func (s SMTP) sendMessage(ctx context.Context, msg Message) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	if msg.From == "" {
+		return errors.New("send: message without sender")
+	}
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return fmt.Errorf("send: sender %q: %w", msg.From, err)
+	}
+	recipients, err := msg.Recipients()
+	if err != nil {
+		return err
+	}
+	if len(recipients) == 0 {
+		return errors.New("send: message without recipients")
+	}
+	raw, err := msg.BytesWith(MIMEOptions{Rand: s.Rand, Date: nowOr(s.Now)})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, durationOr(s.Timeout, DefaultSMTPTimeout))
+	defer cancel()
+	err = s.send(ctx, Envelope{From: from.Address, To: recipients, Attempt: attempt(ctx)}, raw)
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		return fmt.Errorf("send: %w", ctxErr)
+	}
+	return err
+}
+
+func (s SMTP) send(ctx context.Context, envelope Envelope, raw []byte) error {
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// Closing the connection aborts blocked reads and writes when the context is done, or times out
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, s.Server)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if s.TLS == StartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("send: SMTP server does not offer STARTTLS")
+		}
+		if err := c.StartTLS(s.tlsConfig()); err != nil {
+			return err
+		}
+	}
+	if s.Username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("send: SMTP server does not offer authentication")
+		}
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Server)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(envelope.From); err != nil {
+		return err
+	}
+	for _, rcpt := range envelope.To {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	var data io.Writer = w
+	if s.Tee != nil {
+		if tee := s.Tee(ctx, envelope); tee != nil {
+			// The message is written to the tee first, so that the server never gets bytes the tee did not
+			data = io.MultiWriter(tee, w)
+		}
+	}
+	if _, err := data.Write(raw); err != nil {
+		return err
+	}
+	// Closing the data writer ends the message, which the server accepts or rejects
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if s.OnTransmit != nil {
+		s.OnTransmit(ctx, envelope, bytes.Clone(raw))
+	}
+	if err := c.Quit(); err != nil && s.OnQuitError != nil {
+		s.OnQuitError(ctx, envelope, fmt.Errorf("send: quit: %w", err))
+	}
+	return nil
+}
+
+func (s SMTP) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: durationOr(s.DialTimeout, DefaultDialTimeout)}
+	addr := net.JoinHostPort(s.Server, strconv.Itoa(s.Port))
+	if s.TLS == ImplicitTLS {
+		return (&tls.Dialer{NetDialer: dialer, Config: s.tlsConfig()}).DialContext(ctx, "tcp", addr)
+	}
+	return dialer.DialContext(ctx, "tcp", addr)
+}
+
+func (s SMTP) tlsConfig() *tls.Config {
+	if s.TLSConfig == nil {
+		return &tls.Config{ServerName: s.Server}
+	}
+	config := s.TLSConfig.Clone()
+	if config.ServerName == "" {
+		config.ServerName = s.Server
+	}
+	return config
+}
+
+func durationOr(d, def time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+	return def
+}
@@ -0,0 +1,616 @@
+package themes
+
+import "github.com/unknowns24/hermes/pkg/core"
+
+// Corporate is a plain theme for enterprise branding: system fonts, 640px width,
+// left-aligned logo, square gray buttons and dense dictionaries and tables
+type Corporate struct{}
+
+// Name returns the name of the corporate theme
+func (dt *Corporate) Name() string {
+	return "corporate"
+}
+
+// HTMLTemplate returns a Golang template that will generate an HTML email.
+func (dt *Corporate) HTMLTemplate() string {
+	return `
+<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<html xmlns="http://www.w3.org/1999/xhtml" lang="{{ with .Hermes.Locale }}{{ . }}{{ else }}en{{ end }}">
+<head>
+  <meta name="viewport" content="width=device-width, initial-scale=1.0" />
+  <meta http-equiv="Content-Type" content="text/html; charset=UTF-8" />
+  <title>{{ .Hermes.Brand.Name }}</title>{{ with .Hermes.Brand.FontFaces }}
+  <style type="text/css" rel="stylesheet" media="all" data-premailer="ignore" data-hermes-css="fonts">{{ . }}</style>{{ end }}
+  <style type="text/css" rel="stylesheet" media="all">
+    /* Base ------------------------------ */
+    *:not(br):not(tr):not(html) {
+      font-family: {{ .Hermes.Brand.FontStack "-apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif" }};
+      -webkit-box-sizing: border-box;
+      box-sizing: border-box;
+    }
+    body {
+      width: 100% !important;
+      height: 100%;
+      margin: 0;
+      line-height: 1.4;
+      background-color: #FFFFFF;
+      color: #333333;
+      -webkit-text-size-adjust: none;
+    }
+    a {
+      color: #1A4F8B;
+    }
+    /* Layout ------------------------------ */
+    .email-wrapper {
+      width: 100%;
+      margin: 0;
+      padding: 0;
+      background-color: #FFFFFF;
+    }
+    .email-content {
+      width: 640px;
+      margin: 0;
+      padding: 0;
+    }
+    /* Masthead ----------------------- */
+    .email-masthead {
+      padding: 20px 24px 12px;
+      border-bottom: 1px solid #D0D0D0;
+    }
+    .email-masthead_name {
+      font-size: 16px;
+      font-weight: bold;
+      color: #333333;
+      text-decoration: none;
+    }
+    .email-logo {
+      max-height: 40px;
+      border: 0;
+    }
+    /* Body ------------------------------ */
+    .email-body {
+      width: 100%;
+      margin: 0;
+      padding: 0;
+    }
+    .email-body_inner {
+      width: 640px;
+      margin: 0;
+      padding: 0;
+    }
+    .email-footer {
+      width: 640px;
+      margin: 0;
+      padding: 0;
+      border-top: 1px solid #D0D0D0;
+    }
+    .email-footer p {
+      color: #888888;
+    }
+    .content-cell {
+      padding: 24px;
+    }
+    .body-action {
+      width: 100%;
+      margin: 20px 0;
+      padding: 0;
+    }
+    .body-dictionary {
+      width: 100%;
+      overflow: hidden;
+      margin: 12px 0;
+      padding: 0;
+      font-size: 14px;
+    }
+    .body-dictionary dt {
+      display: inline-block;
+      width: 35%;
+      margin: 0;
+      padding: 2px 0;
+      vertical-align: top;
+      color: #333333;
+      font-weight: bold;
+    }
+    .body-dictionary dd {
+      display: inline-block;
+      width: 64%;
+      margin: 0;
+      padding: 2px 0;
+      vertical-align: top;
+    }
+    .body-sub {
+      width: 100%;
+      margin-top: 16px;
+      padding-top: 16px;
+      border-top: 1px solid #D0D0D0;
+      table-layout: fixed;
+    }
+    .body-sub a {
+      word-break: break-all;
+    }
+    .language-divider {
+      margin: 16px 0;
+      padding-top: 16px;
+      border-top: 1px solid #D0D0D0;
+      color: #666666;
+      font-size: 12px;
+      text-align: center;
+    }
+    /* Type ------------------------------ */
+    h1 {
+      margin-top: 0;
+      color: #333333;
+      font-size: 18px;
+      font-weight: bold;
+    }
+    h3 {
+      margin: 0 0 6px;
+      color: #333333;
+      font-size: 14px;
+      font-weight: bold;
+    }
+    blockquote {
+      margin: 16px 0;
+      padding-left: 10px;
+      border-left: 3px solid #D0D0D0;
+    }
+    p {
+      margin-top: 0;
+      color: #333333;
+      font-size: 14px;
+      line-height: 1.5em;
+    }
+    p.sub {
+      font-size: 12px;
+      color: #666666;
+    }
+    /* Data table ------------------------------ */
+    .data-wrapper {
+      width: 100%;
+      margin: 0;
+      padding: 12px 0;
+    }
+    .data-table {
+      width: 100%;
+      margin: 0;
+      border-collapse: collapse;
+    }
+    .data-table th {
+      padding: 4px 6px;
+      border-bottom: 1px solid #333333;
+      color: #333333;
+      font-size: 12px;
+    }
+    .data-table th p {
+      margin: 0;
+      font-size: 12px;
+    }
+    .data-table td {
+      padding: 4px 6px;
+      border-bottom: 1px solid #E5E5E5;
+      color: #333333;
+      font-size: 13px;
+      line-height: 16px;
+    }
+    /* Summary ------------------------------ */
+    .summary {
+      width: 100%;
+      margin: 0 0 12px;
+      border-collapse: collapse;
+    }
+    .summary-card {
+      padding: 8px 10px;
+      border: 1px solid #D0D0D0;
+      vertical-align: top;
+    }
+    .summary-label {
+      margin: 0;
+      color: #666666;
+      font-size: 12px;
+    }
+    .summary-value {
+      margin: 2px 0;
+      font-size: 20px;
+      font-weight: bold;
+    }
+    .summary-delta {
+      margin: 0;
+      font-size: 12px;
+    }
+    .summary-delta--good {
+      color: #1E7B45;
+    }
+    .summary-delta--bad {
+      color: #B3261E;
+    }
+    .summary-delta--neutral {
+      color: #666666;
+    }
+    .summary-highlights {
+      margin: 0 0 12px;
+      padding-left: 18px;
+      font-size: 14px;
+    }
+    /* Schedule ------------------------------ */
+    .body-schedule {
+      width: 100%;
+      margin: 0 0 12px;
+      border-collapse: collapse;
+    }
+    .body-schedule td {
+      padding: 4px 6px;
+      border-bottom: 1px solid #E5E5E5;
+      font-size: 13px;
+    }
+    .body-schedule_label {
+      font-weight: bold;
+    }
+    /* Invite Code ------------------------------ */
+    .invite-code {
+      display: inline-block;
+      padding: 12px 24px;
+      font-family: Consolas, monaco, monospace;
+      font-size: 24px;
+      letter-spacing: 6px;
+      color: #333333;
+      background-color: #F0F0F0;
+      border: 1px solid #D0D0D0;
+    }
+    /* Buttons ------------------------------ */
+    .button {
+      display: inline-block;
+      background-color: #4A4A4A;
+      border-radius: 0;
+      color: #ffffff !important;
+      font-size: 14px;
+      line-height: 40px;
+      text-align: center;
+      text-decoration: none;
+      -webkit-text-size-adjust: none;
+      mso-hide: all;
+    }
+    /*Media Queries ------------------------------ */
+    @media only screen and (max-width: 660px) {
+      .email-content,
+      .email-body_inner,
+      .email-footer {
+        width: 100% !important;
+      }
+    }{{ if eq .Hermes.TextDirection "rtl" }}
+    /* Right-to-left: Gmail drops the dir attribute of the body, so the alignments are mirrored explicitly */
+    .content-cell {
+      text-align: right;
+    }
+    blockquote {
+      padding-left: 0;
+      padding-right: 10px;
+      border-left: 0;
+      border-right: 3px solid #D0D0D0;
+    }{{ end }}
+  </style>{{ if .Email.Body.HasStackedTables }}
+  <style type="text/css" rel="stylesheet" media="all" data-premailer="ignore" data-hermes-css="stack">
+    /* Stacked tables: Outlook ignores media queries and keeps the desktop layout */
+    @media only screen and (max-width: 480px) {
+      .data-table--stack th {
+        display: none !important;
+      }
+      .data-table--stack tr,
+      .data-table--stack td {
+        display: block !important;
+        width: 100% !important;
+        text-align: start !important;
+      }
+      .data-table--stack tr {
+        padding: 8px 0 !important;
+      }
+      .data-table--stack td {
+        padding: 2px 0 !important;
+      }
+      .data-table--stack td:before {
+        content: attr(data-label);
+        display: block;
+        font-weight: bold;
+      }
+    }
+  </style>{{ end }}{{ with .Hermes.CustomCSS }}
+  <style type="text/css" rel="stylesheet" media="all" data-hermes-css="custom">{{ css . }}</style>{{ end }}
+</head>
+<body dir="{{.Hermes.TextDirection}}">
+  {{ $start := align "start" .Hermes.TextDirection }}
+  <table class="email-wrapper" dir="{{.Hermes.TextDirection}}" width="100%" cellpadding="0" cellspacing="0">
+    <tr>
+      <td>
+        <table class="email-content" width="640" cellpadding="0" cellspacing="0">
+          <!-- Logo -->
+          <tr>
+            <td class="email-masthead" style="text-align:{{ $start }}">
+              <a class="email-masthead_name" href="{{ .Hermes.Brand.Link | url }}" target="_blank">
+                {{ if .Hermes.Brand.Logo }}{{ with .Hermes.Brand.LogoImage }}
+                  <img src="{{ .URL | url }}" class="email-logo" alt="{{ .Alt }}"{{ with .Width }} width="{{ . }}"{{ end }}{{ with .Height }} height="{{ . }}"{{ end }}{{ if or .Width .Height }} style="{{ with .Width }}width: 100%; max-width: {{ . }}px; height: auto; {{ end }}max-height: none;"{{ end }} />{{ end }}
+                {{ else }}
+                  {{ .Hermes.Brand.Name }}
+                {{ end }}
+              </a>
+            </td>
+          </tr>
+
+          <!-- Email Body -->
+          <tr>
+            <td class="email-body" width="100%">
+              <table class="email-body_inner" width="640" cellpadding="0" cellspacing="0">
+                <tr>
+                  <td class="content-cell">{{ if .Email.LanguageVariants }}{{ range $i, $variant := .Variants }}{{ if $i }}
+                    <div class="language-divider" role="separator" data-hermes="language-divider">{{ $.LanguagesLabel }}</div>{{ end }}
+                    <div lang="{{ $variant.Hermes.Locale }}" dir="{{ $variant.Hermes.TextDirection }}" data-hermes="language-variant">
+{{ template "body" $variant }}                    </div>{{ end }}
+{{ else }}
+{{ template "body" . }}{{ end }}                  </td>
+                </tr>
+              </table>
+            </td>
+          </tr>
+          <tr>
+            <td>
+              <table class="email-footer" width="640" cellpadding="0" cellspacing="0">
+                <tr>
+                  <td class="content-cell">{{ with .Hermes.Brand.SocialLinks }}
+                    <p class="sub" data-hermes="social">{{ range . }}
+                      <a href="{{ .URL | url }}" target="_blank">{{ if .IconURL }}<img src="{{ .IconURL | url }}" alt="{{ .Name }}" width="24" height="24" style="border:0;margin:0 4px" />{{ else }}{{ .Name }}{{ end }}</a>{{ end }}
+                    </p>{{ end }}
+                    <p class="sub">
+                      {{.Hermes.Brand.Copyright}}
+                    </p>{{ with .Hermes.Brand.Address }}
+                    <p class="sub" data-hermes="address">{{ . }}</p>{{ end }}{{ with .Email.Unsubscribe }}{{ with .Link }}
+                    <p class="sub"><a href="{{ . | url }}" data-hermes="unsubscribe">{{ translate $.Hermes.Locale "footer.unsubscribe" }}</a></p>{{ end }}{{ end }}
+                  </td>
+                </tr>
+              </table>
+            </td>
+          </tr>
+        </table>
+      </td>
+    </tr>
+  </table>
+</body>
+</html>
+{{ define "body" }}{{ $start := align "start" .Hermes.TextDirection }}                    <h1{{ if not .Email.Body.Title }} data-hermes-greeting="{{ .Email.Body.Greeting }}"{{ end }}>{{if .Email.Body.Title }}{{ .Email.Body.Title }}{{ else }}{{ .Email.Body.Greeting }} {{ .Email.Body.Name }},{{ end }}</h1>
+                    {{ range $line := .Email.Body.Intros }}
+                      <p data-hermes="intro">{{ $line }}</p>
+                    {{ end }}{{ if .Email.Body.TableOfContents }}{{ with .Email.Body.Anchors }}
+                    <ul class="toc" data-hermes="toc">{{ range . }}
+                      <li><a href="#{{ .ID }}">{{ if eq .Section "dictionary" }}{{ translate $.Hermes.Locale "toc.details" }}{{ else }}{{ .Title }}{{ end }}</a></li>{{ end }}
+                    </ul>{{ end }}{{ end }}
+                    {{ if (ne .Email.Body.FreeMarkdown "") }}
+                      <div data-hermes="markdown">
+                        {{ .Hermes.MarkdownToHTML .Email.Body.FreeMarkdown }}
+                      </div>
+                    {{ else }}
+
+                      {{ with .Email.Body.Dictionary }}
+                        <dl class="body-dictionary" id="{{ $.Email.Body.AnchorID "dictionary" 0 }}">
+                          {{ range $entry := . }}
+                            <dt>{{ $entry.Key }}:</dt>
+                            <dd>{{ entryHTML $entry $.Hermes.TextDirection }}</dd>
+                          {{ end }}
+                        </dl>
+                      {{ end }}
+
+                      <!-- Summary -->
+                      {{ with .Email.Body.Summary }}
+                        <table class="summary" data-hermes="summary" width="100%" cellpadding="0" cellspacing="0">
+                          {{ range $row := .Rows }}
+                            <tr>
+                              {{ range $section := $row }}
+                                <td class="summary-card" width="50%" style="text-align:{{ $start }}">
+                                  <p class="summary-label">{{ $section.Label }}</p>
+                                  <p class="summary-value">{{ $section.FormattedValue }}</p>
+                                  {{ with $section.Trend }}
+                                    <p class="summary-delta summary-delta--{{ $section.Tone }}">{{ if eq . "flat" }}{{ translate $.Hermes.Locale "summary.unchanged" }}{{ else }}{{ $section.Delta }}{{ end }}</p>
+                                  {{ end }}
+                                </td>
+                              {{ end }}
+                              {{ if eq (len $row) 1 }}
+                                <td width="50%"></td>
+                              {{ end }}
+                            </tr>
+                          {{ end }}
+                        </table>
+                        {{ range $i, $section := .Sections }}
+                          {{ with $section.Highlights }}
+                            <h3 class="summary-title" id="{{ $.Email.Body.AnchorID "highlights" $i }}">{{ $section.Label }}</h3>
+                            <ul class="summary-highlights">
+                              {{ range . }}
+                                <li>{{ . }}</li>
+                              {{ end }}
+                            </ul>
+                          {{ end }}
+                        {{ end }}
+                      {{ end }}
+
+                      <!-- Tables -->
+                      {{ range $t, $table := .Email.Body.AllTables }}
+                        {{ $data := $table.Data }}
+                        {{ $columns := $table.Columns }}
+                        {{ if gt (len $data) 0 }}
+                          <table class="data-wrapper" width="100%" cellpadding="0" cellspacing="0">
+                            {{ with $table.Title }}
+                              <tr>
+                                <td>
+                                  <h3 class="data-title" id="{{ $.Email.Body.AnchorID "table" $t }}">{{ . }}</h3>
+                                </td>
+                              </tr>
+                            {{ end }}
+                            <tr>
+                              <td>
+                                <table class="data-table{{ if eq $table.ResponsiveMode "stack" }} data-table--stack{{ end }}" width="100%" cellpadding="0" cellspacing="0">
+                                  <tr>
+                                    {{ range $entry := index $data 0 }}
+                                      <th{{ with index $columns.CustomWidth $entry.Key }} width="{{ . }}"{{ end }} style="text-align:{{ or (align (index $columns.CustomAlignment $entry.Key) $.Hermes.TextDirection) $start }}">
+                                        <p>{{ $entry.Key }}</p>
+                                      </th>
+                                    {{ end }}
+                                  </tr>
+                                  {{ range $row := $data }}
+                                    <tr>
+                                      {{ range $cell := $row }}
+                                        <td{{ if eq $table.ResponsiveMode "stack" }} data-label="{{ $cell.Key }}"{{ end }}{{ with index $columns.CustomAlignment $cell.Key }} style="text-align:{{ align . $.Hermes.TextDirection }}"{{ end }}>
+                                          {{ if $cell.HTMLValue }}{{ $cell.HTMLValue }}{{ else }}{{ isolate $cell.Value $cell.Bidi $.Hermes.TextDirection }}{{ end }}
+                                        </td>
+                                      {{ end }}
+                                    </tr>
+                                  {{ end }}
+                                </table>
+                              </td>
+                            </tr>
+                          </table>
+                        {{ else if $table.ShowEmpty }}
+                          <table class="data-wrapper" width="100%" cellpadding="0" cellspacing="0">
+                            {{ with $table.Title }}
+                              <tr>
+                                <td>
+                                  <h3 class="data-title" id="{{ $.Email.Body.AnchorID "table" $t }}">{{ . }}</h3>
+                                </td>
+                              </tr>
+                            {{ end }}
+                            <tr>
+                              <td>
+                                <p class="data-empty">{{ translate $.Hermes.Locale "table.empty" }}</p>
+                              </td>
+                            </tr>
+                          </table>
+                        {{ end }}
+                      {{ end }}
+
+                      <!-- Schedule -->
+                      {{ with .Email.Body.Schedule }}
+                        <table class="body-schedule" data-hermes="schedule" width="100%" cellpadding="0" cellspacing="0">
+                          {{ range $entry := . }}
+                            <tr>
+                              <td class="body-schedule_label">{{ $entry.Label }}</td>
+                              <td>{{ timeRange $.Hermes $entry.Start $entry.End }}</td>
+                            </tr>
+                          {{ end }}
+                        </table>
+                      {{ end }}{{ with .Email.Body.CalendarEvent }}{{ if .QuickAddLinks }}
+                        <p class="sub" data-hermes="calendar">{{ translate $.Hermes.Locale "calendar.add" }}: <a href="{{ .GoogleCalendarURL | url }}">Google Calendar</a> · <a href="{{ .OutlookURL | url }}">Outlook</a></p>
+                      {{ end }}{{ end }}
+
+                      <!-- Action -->
+                      {{ range $action := .Email.Body.Actions }}
+                        <p data-hermes="instructions">{{ $action.Instructions }}</p>
+                        {{ $width := add (mul (len $action.Button.Text) 9) 24 }}
+                        {{ if (lt $width 160) }}{{ $width = 160 }}{{ else if (gt $width 592) }}{{ $width = 592 }}{{ end }}{{ with $action.Button.Width }}{{ $width = . }}{{ end }}{{ $height := or $action.Button.Height 40 }}
+                        {{ $color := or $action.Button.Color "#4A4A4A" }}
+                        {{ $textColor := or $action.Button.TextColor "#FFFFFF" }}
+                        {{ safe "<!--[if mso]>" }}
+                          {{ if $action.Button.Text }}
+                            <div style="margin: 20px 0;v-text-anchor:middle">
+                              {{ if $action.Button.BorderRadius }}<v:roundrect arcsize="{{ $action.Button.ArcSize $width $height }}"{{ else }}<v:rect{{ end }} xmlns:v="urn:schemas-microsoft-com:vml"
+                                xmlns:w="urn:schemas-microsoft-com:office:word"
+                                href="{{ $action.Button.Link | url }}"
+                                style="height:{{ $height }}px;v-text-anchor:middle;width:{{ $width }}px;background-color:{{ $color }};"
+                                strokecolor="{{ $color }}" fillcolor="{{ $color }}">
+                                <w:anchorlock/>
+                                <center style="color: {{ $textColor }};font-size: 14px;text-align: center;font-family:sans-serif;font-weight:bold;">
+                                  {{ $action.Button.Text }}
+                                </center>
+                              {{ if $action.Button.BorderRadius }}</v:roundrect>{{ else }}</v:rect>{{ end }}
+                            </div>
+                          {{ end }}
+                          {{ if $action.InviteCode }}
+                            <div style="margin:20px 0;font-family:Consolas, monaco, monospace;font-size:24px;letter-spacing:6px;color:#333333">
+                              {{ isolate $action.GroupedInviteCode "" $.Hermes.TextDirection }}
+                            </div>
+                          {{ end }}
+                        {{ safe "<![endif]-->" }}
+                        {{ safe "<!--[if !mso]><!-- -->" }}
+                        <table class="body-action" width="100%" cellpadding="0" cellspacing="0">
+                          <tr>
+                            <td>
+                              {{ if $action.Button.Text }}
+                                <a href="{{ $action.Button.Link | url }}" class="button" style="{{ with $action.Button.Color }}background-color: {{ . }};{{ end }} {{ with $action.Button.TextColor }}color: {{ . }};{{ end }} width: {{ $width }}px;{{ with $action.Button.Height }} line-height: {{ . }}px;{{ end }}{{ with $action.Button.BorderRadius }} border-radius: {{ . }}px;{{ end }}" target="_blank">
+                                  {{ $action.Button.Text }}
+                                </a>
+                              {{ end }}
+                              {{ if $action.InviteCode }}
+                                <span class="invite-code">{{ isolate $action.GroupedInviteCode "" $.Hermes.TextDirection }}</span>
+                              {{ end }}
+                            </td>
+                          </tr>
+                        </table>
+                        {{ safe "<!--<![endif]-->" }}
+                      {{ end }}
+
+                    {{ end }}
+                    {{ range $line := .Email.Body.Outros }}
+                      <p data-hermes="outro">{{ $line }}</p>
+                    {{ end }}
+
+                    {{ with .Email.Body.ContactInstructions }}
+                      {{ if or .Text .Email .URL }}
+                        <p class="sub" data-hermes="contact">
+                          {{ if .Text }}<span data-hermes="contact-text">{{ .Text }}</span>{{ else }}{{ translate $.Hermes.Locale "contact.text" }}{{ end }}
+                          {{ with .Email }}
+                            <br />
+                            {{ translate $.Hermes.Locale "contact.email" }}: <a href="mailto:{{ . }}">{{ . }}</a>
+                          {{ end }}
+                          {{ with .URL }}
+                            <br />
+                            {{ translate $.Hermes.Locale "contact.url" }}: <a href="{{ . | url }}">{{ . }}</a>
+                          {{ end }}
+                        </p>
+                      {{ end }}
+                    {{ end }}
+
+                    {{ with .Email.Body.SignatureBlock }}{{ $signature := . }}<table class="signature-block" role="presentation" cellpadding="0" cellspacing="0" data-hermes="signature">
+                      <tr>{{ with .ImageURL }}
+                        <td class="signature-image" width="{{ $signature.ImageDisplayWidth }}" valign="top" style="padding: 0 15px 0 0;">
+                          <img src="{{ . | url }}" alt="{{ $signature.Name }}" width="{{ $signature.ImageDisplayWidth }}" style="display: block; width: {{ $signature.ImageDisplayWidth }}px; max-width: 100%; height: auto; border: 0;" />
+                        </td>{{ end }}
+                        <td valign="top" style="padding: 0;">
+                          <p class="signature-closing" style="margin-bottom: 5px;">{{ .Closing }},</p>
+                          <p class="signature-name" style="margin: 0;">{{ with .Name }}<strong>{{ . }}</strong>{{ end }}{{ with .Title }}{{ if $signature.Name }}<br />{{ end }}<span class="signature-title" style="color: #9BA2AB; font-size: 14px;">{{ . }}</span>{{ end }}</p>
+                        </td>
+                      </tr>
+                    </table>{{ else }}<p data-hermes="signature">
+                      {{.Email.Body.Signature}},
+                      <br />
+                      {{.Hermes.Brand.Name}}
+                    </p>{{ end }}
+
+                    {{ if (eq .Email.Body.FreeMarkdown "") }}
+                      {{ with .Email.Body.Actions }}
+                        <table class="body-sub">
+                          <tbody>
+                            {{ range $action := . }}
+                              {{ with $action.FallbackText $.Hermes.Brand.TroubleText }}
+                                <tr>
+                                  <td>
+                                    <p class="sub">{{ . }}</p>
+                                    <p class="sub"><a href="{{ $action.Button.Link | url }}">{{ $action.Button.Link }}</a></p>
+                                  </td>
+                                </tr>
+                              {{ end }}
+                            {{ end }}
+                          </tbody>
+                        </table>
+                      {{ end }}
+                    {{ end }}
+{{ end }}`
+}
+
+// PlainTextTemplate returns a Golang template that will generate an plain text email.
+// Plain text has no branding constraints, it is the one of the default theme.
+func (dt *Corporate) PlainTextTemplate() string {
+	return new(Default).PlainTextTemplate()
+}
+
+// TextTemplate returns the native plain text template of the default theme, see PlainTextTemplate
+func (dt *Corporate) TextTemplate() string {
+	return new(Default).TextTemplate()
+}
+
+// Metadata returns what the corporate theme was designed for: phones and its 640px width, without dark styles
+func (dt *Corporate) Metadata() Metadata {
+	return Metadata{Widths: []int{320, 660}, MediaQueries: true, DarkMode: core.DarkModeNone, MinDataVersion: 1}
+}
@@ -0,0 +1,170 @@
+package themes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template/parse"
+
+	"github.com/unknowns24/hermes/pkg/core"
+)
+
+// TextBlockPrefix prefixes the names of the overrides of the blocks of the plain text templates, e.g. "text:footer"
+const TextBlockPrefix = "text:"
+
+// Blocks of the templates of the Default theme, which NewCustomized can override. The plain text template has the same
+// blocks, but header and logo.
+const (
+	BlockHeader     = "header"     // Row of the logo, above the body
+	BlockLogo       = "logo"       // Image of the logo, or the name of the brand, in the header
+	BlockGreeting   = "greeting"   // Title, or greeting and name
+	BlockIntros     = "intros"     // Intro sentences
+	BlockDictionary = "dictionary" // List of key+value
+	BlockTable      = "table"      // Tables
+	BlockActions    = "actions"    // Instructions and buttons of the actions
+	BlockOutros     = "outros"     // Outro sentences
+	BlockSignature  = "signature"  // Signature and name of the brand
+	BlockFooter     = "footer"     // Row of the social links, copyright, address and unsubscribe link, below the body
+)
+
+// Customized is a theme whose blocks, defined with {{ block "name" . }}, are replaced by overrides, e.g. to change the
+// footer of the Default theme without forking its templates. The other parts of the base theme are rendered as they
+// are.
+type Customized struct {
+	base   core.Theme
+	html   string
+	text   string
+	native string // Native plain text template, see TextTemplate
+}
+
+// textTheme is a theme with a native plain text template, see hermes.PlainTextTheme
+type textTheme interface {
+	TextTemplate() string
+}
+
+// customizedMetadata is a Customized theme of a base implementing core.ThemeMetadata
+type customizedMetadata struct {
+	*Customized
+}
+
+// NewCustomized returns the base theme with its blocks replaced by the templates of the overrides, by block name, e.g.
+// BlockFooter. The templates are rendered with the data of the block, as {{ define "footer" }}...{{ end }} would. Keys
+// starting with TextBlockPrefix override the blocks of the native plain text template of the base, see TextTemplate, or
+// of its PlainTextTemplate when it has none, the others the ones of the HTML template.
+// Overriding a block which the template does not define, or a template which does not parse, is an error.
+//
+// The templates of the base are returned unchanged without overrides. Otherwise they are written again from their parse
+// trees, which renders the same output, without their comments.
+// The theme implements core.ThemeMetadata when the base does, with its metadata.
+func NewCustomized(base core.Theme, overrides map[string]string) (core.Theme, error) {
+	html, text := map[string]string{}, map[string]string{}
+	for name, override := range overrides {
+		if block, ok := strings.CutPrefix(name, TextBlockPrefix); ok {
+			text[block] = override
+		} else {
+			html[name] = override
+		}
+	}
+
+	t := &Customized{base: base}
+	var err error
+	if t.html, err = customize(base.HTMLTemplate(), html); err != nil {
+		return nil, fmt.Errorf("theme %s: HTML template: %w", base.Name(), err)
+	}
+	t.text = base.PlainTextTemplate()
+	if native, ok := base.(textTheme); ok && native.TextTemplate() != "" {
+		t.native, err = customize(native.TextTemplate(), text)
+	} else {
+		t.text, err = customize(t.text, text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("theme %s: plain text template: %w", base.Name(), err)
+	}
+	if _, ok := core.MetadataOf(base); ok {
+		return customizedMetadata{t}, nil
+	}
+	return t, nil
+}
+
+// customize returns the template with its blocks replaced by the overrides, or the template itself without overrides
+func customize(source string, overrides map[string]string) (string, error) {
+	if len(overrides) == 0 {
+		return source, nil
+	}
+	const main = "main"
+	trees, err := parseTrees(main, source)
+	if err != nil {
+		return "", err
+	}
+	var names []string
+	for name := range trees {
+		if name != main {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for block, override := range overrides {
+		if _, ok := trees[block]; !ok || block == main {
+			return "", fmt.Errorf("unknown block %q, the template defines %s", block, strings.Join(names, ", "))
+		}
+		if _, err := parseTrees(block, override); err != nil {
+			return "", fmt.Errorf("block %q: %w", block, err)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(trees[main].Root.String())
+	for _, name := range names {
+		fmt.Fprintf(&b, "{{define %q}}", name)
+		if override, ok := overrides[name]; ok {
+			b.WriteString(override)
+		} else {
+			b.WriteString(trees[name].Root.String())
+		}
+		b.WriteString("{{end}}")
+	}
+	if _, err := parseTrees(main, b.String()); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// parseTrees returns the parse trees of the template and of the templates it defines, by name. Functions are not
+// checked, they are defined by the hermes package.
+func parseTrees(name, source string) (map[string]*parse.Tree, error) {
+	trees := map[string]*parse.Tree{}
+	t := parse.New(name)
+	t.Mode = parse.SkipFuncCheck
+	if _, err := t.Parse(source, "", "", trees); err != nil {
+		return nil, err
+	}
+	return trees, nil
+}
+
+// Name returns the name of the base theme followed by "-customized", so that its templates are cached apart
+func (t *Customized) Name() string {
+	return t.base.Name() + "-customized"
+}
+
+// HTMLTemplate returns the HTML template of the base theme with its blocks overridden
+func (t *Customized) HTMLTemplate() string {
+	return t.html
+}
+
+// PlainTextTemplate returns the plain text template of the base theme, with its blocks overridden when the base has no
+// native plain text template
+func (t *Customized) PlainTextTemplate() string {
+	return t.text
+}
+
+// TextTemplate returns the native plain text template of the base theme with its blocks overridden, or an empty string
+// when the base has none
+func (t *Customized) TextTemplate() string {
+	return t.native
+}
+
+// Metadata returns the metadata of the base theme
+func (t customizedMetadata) Metadata() core.Metadata {
+	metadata, _ := core.MetadataOf(t.base)
+	return metadata
+}
@@ -1,5 +1,7 @@
 package themes
 
+import "github.com/unknowns24/hermes/pkg/core"
+
 // Default is the theme by default
 type Default struct{}
 
@@ -12,14 +14,18 @@ func (dt *Default) Name() string {
 func (dt *Default) HTMLTemplate() string {
 	return `
 <!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
-<html xmlns="http://www.w3.org/1999/xhtml">
+<html xmlns="http://www.w3.org/1999/xhtml" lang="{{ with .Hermes.Locale }}{{ . }}{{ else }}en{{ end }}">
 <head>
   <meta name="viewport" content="width=device-width, initial-scale=1.0" />
   <meta http-equiv="Content-Type" content="text/html; charset=UTF-8" />
+  <meta name="color-scheme" content="light dark" />
+  <meta name="supported-color-schemes" content="light dark" />
+  <title>{{ .Hermes.Brand.Name }}</title>{{ with .Hermes.Brand.FontFaces }}
+  <style type="text/css" rel="stylesheet" media="all" data-premailer="ignore" data-hermes-css="fonts">{{ . }}</style>{{ end }}
   <style type="text/css" rel="stylesheet" media="all">
     /* Base ------------------------------ */
     *:not(br):not(tr):not(html) {
-      font-family: Arial, 'Helvetica Neue', Helvetica, sans-serif;
+      font-family: {{ .Hermes.Brand.FontStack "Arial, 'Helvetica Neue', Helvetica, sans-serif" }};
       -webkit-box-sizing: border-box;
       box-sizing: border-box;
     }
@@ -29,7 +35,7 @@ func (dt *Default) HTMLTemplate() string {
       margin: 0;
       line-height: 1.4;
       background-color: #F2F4F6;
-      color: #74787E;
+      color: #6B6E76;
       -webkit-text-size-adjust: none;
     }
     a {
@@ -87,7 +93,7 @@ func (dt *Default) HTMLTemplate() string {
       text-align: center;
     }
     .email-footer p {
-      color: #AEAEAE;
+      color: #6B6E76;
     }
     .body-action {
       width: 100%;
@@ -122,6 +128,14 @@ func (dt *Default) HTMLTemplate() string {
     .body-sub a {
       word-break: break-all;
     }
+    .language-divider {
+      margin: 25px 0;
+      padding-top: 25px;
+      border-top: 1px solid #EDEFF2;
+      color: #AEAEAE;
+      font-size: 12px;
+      text-align: center;
+    }
     .content-cell {
       padding: 35px;
     }
@@ -154,7 +168,7 @@ func (dt *Default) HTMLTemplate() string {
     }
     blockquote p {
         font-size: 1.1rem;
-        color: #999;
+        color: #6B6E76;
     }
     blockquote cite {
         display: block;
@@ -171,7 +185,7 @@ func (dt *Default) HTMLTemplate() string {
     }
     p {
       margin-top: 0;
-      color: #74787E;
+      color: #6B6E76;
       font-size: 16px;
       line-height: 1.5em;
     }
@@ -191,12 +205,12 @@ func (dt *Default) HTMLTemplate() string {
     }
     th p {
       margin: 0;
-      color: #9BA2AB;
+      color: #6B6E76;
       font-size: 12px;
     }
     td {
       padding: 10px 5px;
-      color: #74787E;
+      color: #6B6E76;
       font-size: 15px;
       line-height: 18px;
     }
@@ -204,12 +218,71 @@ func (dt *Default) HTMLTemplate() string {
       align: center;
       padding: 0;
     }
+    /* Schedule ------------------------------ */
+    .body-schedule {
+      width: 100%;
+      margin: 0;
+      padding: 0 0 25px;
+    }
+    .body-schedule td {
+      padding: 5px;
+      color: #6B6E76;
+      font-size: 15px;
+    }
+    .body-schedule_label {
+      color: #000;
+      font-weight: bold;
+    }
+    /* Summary ------------------------------ */
+    .summary {
+      width: 100%;
+      margin: 0;
+      padding: 0 0 25px;
+    }
+    .summary-card {
+      padding: 15px;
+      background-color: #F2F4F6;
+      border: 4px solid #FFFFFF;
+      vertical-align: top;
+    }
+    .summary-label {
+      margin: 0;
+      color: #6B6E76;
+      font-size: 13px;
+    }
+    .summary-value {
+      margin: 5px 0;
+      color: #2F3133;
+      font-size: 24px;
+      font-weight: bold;
+      line-height: 1.2em;
+    }
+    .summary-delta {
+      margin: 0;
+      font-size: 13px;
+    }
+    .summary-delta--good {
+      color: #1A7F45;
+    }
+    .summary-delta--bad {
+      color: #C13F24;
+    }
+    .summary-delta--neutral {
+      color: #6B6E76;
+    }
+    .summary-highlights {
+      margin: 0 0 20px;
+      color: #6B6E76;
+    }
     /* Data table ------------------------------ */
     .data-wrapper {
       width: 100%;
       margin: 0;
       padding: 35px 0;
     }
+    .data-title {
+      margin: 0 0 10px;
+    }
     .data-table {
       width: 100%;
       margin: 0;
@@ -222,12 +295,12 @@ func (dt *Default) HTMLTemplate() string {
     }
     .data-table th p {
       margin: 0;
-      color: #9BA2AB;
+      color: #6B6E76;
       font-size: 12px;
     }
     .data-table td {
       padding: 10px 5px;
-      color: #74787E;
+      color: #6B6E76;
       font-size: 15px;
       line-height: 18px;
     }
@@ -270,66 +343,291 @@ func (dt *Default) HTMLTemplate() string {
       .button {
         width: 100% !important;
       }
+    }{{ if eq .Hermes.TextDirection "rtl" }}
+    /* Right-to-left: Gmail drops the dir attribute of the body, so the alignments are mirrored explicitly */
+    .content-cell,
+    .data-table th {
+      text-align: right;
     }
-  </style>
+    .align-right,
+    blockquote cite {
+      text-align: left;
+    }
+    blockquote {
+      padding-left: 0;
+      padding-right: 10px;
+      border-left: 0;
+      border-right: 10px solid #F0F2F4;
+    }{{ end }}
+  </style>{{ with .Hermes.DarkMode }}
+  <style type="text/css" rel="stylesheet" media="all" data-premailer="ignore" data-hermes-css="dark">
+    /* Dark mode: kept out of CSS inlining, [data-ogsc] and [data-ogsb] are set by Outlook.com */
+    :root {
+      color-scheme: light dark;
+      supported-color-schemes: light dark;
+    }
+    @media (prefers-color-scheme: dark) {
+      body,
+      .email-wrapper,
+      .email-masthead,
+      .email-footer {
+        background-color: {{ css .Background }} !important;
+      }
+      .email-body,
+      .email-body_inner,
+      .summary-card,
+      .invite-code {
+        background-color: {{ css .Content }} !important;
+        border-color: {{ css .Content }} !important;
+      }
+      p,
+      li,
+      td,
+      dd,
+      th p,
+      .data-table td,
+      .summary-label,
+      .summary-highlights {
+        color: {{ css .Text }} !important;
+      }
+      h1,
+      h2,
+      h3,
+      dt,
+      .email-masthead_name,
+      .summary-value,
+      .invite-code {
+        color: {{ css .Heading }} !important;
+      }
+      a:not(.button) {
+        color: {{ css .Link }} !important;
+      }{{ if $.Hermes.Brand.LogoImage.DarkURL }}
+      .email-logo--light {
+        display: none !important;
+      }
+      .email-logo--dark {
+        display: inline-block !important;
+      }{{ end }}
+      .summary-delta--good {
+        color: #5FD68F !important;
+      }
+      .summary-delta--bad {
+        color: #F28B76 !important;
+      }{{ with .Button }}
+      .button {
+        background-color: {{ css . }} !important;
+      }{{ end }}{{ with .ButtonText }}
+      .button {
+        color: {{ css . }} !important;
+      }{{ end }}
+    }
+    [data-ogsb] body,
+    [data-ogsb] .email-wrapper,
+    [data-ogsb] .email-masthead,
+    [data-ogsb] .email-footer {
+      background-color: {{ css .Background }} !important;
+    }
+    [data-ogsb] .email-body,
+    [data-ogsb] .email-body_inner,
+    [data-ogsb] .summary-card,
+    [data-ogsb] .invite-code {
+      background-color: {{ css .Content }} !important;
+    }
+    [data-ogsc] p,
+    [data-ogsc] li,
+    [data-ogsc] td,
+    [data-ogsc] dd,
+    [data-ogsc] .summary-label {
+      color: {{ css .Text }} !important;
+    }
+    [data-ogsc] h1,
+    [data-ogsc] h2,
+    [data-ogsc] h3,
+    [data-ogsc] dt,
+    [data-ogsc] .email-masthead_name,
+    [data-ogsc] .summary-value,
+    [data-ogsc] .invite-code {
+      color: {{ css .Heading }} !important;
+    }
+    [data-ogsc] a:not(.button) {
+      color: {{ css .Link }} !important;
+    }{{ if $.Hermes.Brand.LogoImage.DarkURL }}
+    [data-ogsc] .email-logo--light {
+      display: none !important;
+    }
+    [data-ogsc] .email-logo--dark {
+      display: inline-block !important;
+    }{{ end }}{{ with .Button }}
+    [data-ogsb] .button {
+      background-color: {{ css . }} !important;
+    }{{ end }}{{ with .ButtonText }}
+    [data-ogsc] .button {
+      color: {{ css . }} !important;
+    }{{ end }}
+  </style>{{ end }}{{ if .Email.Body.HasStackedTables }}
+  <style type="text/css" rel="stylesheet" media="all" data-premailer="ignore" data-hermes-css="stack">
+    /* Stacked tables: Outlook ignores media queries and keeps the desktop layout */
+    @media only screen and (max-width: 480px) {
+      .data-table--stack th {
+        display: none !important;
+      }
+      .data-table--stack tr,
+      .data-table--stack td {
+        display: block !important;
+        width: 100% !important;
+        text-align: start !important;
+      }
+      .data-table--stack tr {
+        padding: 8px 0 !important;
+      }
+      .data-table--stack td {
+        padding: 2px 0 !important;
+      }
+      .data-table--stack td:before {
+        content: attr(data-label);
+        display: block;
+        font-weight: bold;
+      }
+    }
+  </style>{{ end }}{{ with .Hermes.CustomCSS }}
+  <style type="text/css" rel="stylesheet" media="all" data-hermes-css="custom">{{ css . }}</style>{{ end }}
 </head>
-<body dir="{{.Hermes.TextDirection}}">
-  <table class="email-wrapper" width="100%" cellpadding="0" cellspacing="0">
+<body dir="{{.Hermes.TextDirection}}">{{ with .Email.Body.Preheader }}
+  <div class="preheader" style="display: none; max-height: 0; max-width: 0; overflow: hidden; mso-hide: all; font-size: 1px; line-height: 1px; opacity: 0;">{{ . }}&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;&zwnj;&nbsp;</div>{{ end }}
+  <table class="email-wrapper" role="presentation" dir="{{.Hermes.TextDirection}}" width="100%" cellpadding="0" cellspacing="0">
     <tr>
       <td class="content">
-        <table class="email-content" width="100%" cellpadding="0" cellspacing="0">
-          <!-- Logo -->
+        <table class="email-content" role="presentation" width="100%" cellpadding="0" cellspacing="0">
+          {{ block "header" . }}<!-- Logo -->
           <tr>
             <td class="email-masthead">
-              <a class="email-masthead_name" href="{{.Hermes.Brand.Link}}" target="_blank">
-                {{ if .Hermes.Brand.Logo }}
-                  <img src="{{.Hermes.Brand.Logo | url }}" class="email-logo" />
+              <a class="email-masthead_name" href="{{ .Hermes.Brand.Link | url }}" target="_blank">
+                {{ block "logo" . }}{{ if .Hermes.Brand.Logo }}{{ with .Hermes.Brand.LogoImage }}
+                  <img src="{{ .URL | url }}" class="email-logo{{ if .DarkURL }} email-logo--light{{ end }}" alt="{{ .Alt }}"{{ with .Width }} width="{{ . }}"{{ end }}{{ with .Height }} height="{{ . }}"{{ end }}{{ if or .Width .Height }} style="{{ with .Width }}width: 100%; max-width: {{ . }}px; height: auto; {{ end }}max-height: none;"{{ end }} />{{ if .DarkURL }}
+                  <img src="{{ .DarkURL | url }}" class="email-logo email-logo--dark" alt="{{ .Alt }}"{{ with .Width }} width="{{ . }}"{{ end }}{{ with .Height }} height="{{ . }}"{{ end }} style="display: none; mso-hide: all;{{ with .Width }} width: 100%; max-width: {{ . }}px; height: auto;{{ end }}{{ if or .Width .Height }} max-height: none;{{ end }}" />{{ end }}{{ end }}
                 {{ else }}
                   {{ .Hermes.Brand.Name }}
-                {{ end }}
+                {{ end }}{{ end }}
                 </a>
             </td>
-          </tr>
+          </tr>{{ end }}
 
           <!-- Email Body -->
           <tr>
             <td class="email-body" width="100%">
-              <table class="email-body_inner" align="center" width="570" cellpadding="0" cellspacing="0">
+              <table class="email-body_inner" role="presentation" align="center" width="570" cellpadding="0" cellspacing="0">
                 <!-- Body content -->
                 <tr>
-                  <td class="content-cell">
-                    <h1>{{if .Email.Body.Title }}{{ .Email.Body.Title }}{{ else }}{{ .Email.Body.Greeting }} {{ .Email.Body.Name }},{{ end }}</h1>
-                    {{ with .Email.Body.Intros }}
+                  <td class="content-cell">{{ if .Email.LanguageVariants }}{{ range $i, $variant := .Variants }}{{ if $i }}
+                    <div class="language-divider" role="separator" data-hermes="language-divider">{{ $.LanguagesLabel }}</div>{{ end }}
+                    <div lang="{{ $variant.Hermes.Locale }}" dir="{{ $variant.Hermes.TextDirection }}" data-hermes="language-variant">
+{{ template "body" $variant }}                    </div>{{ end }}
+{{ else }}
+{{ template "body" . }}{{ end }}                  </td>
+                </tr>
+              </table>
+            </td>
+          </tr>
+          {{ block "footer" . }}<tr>
+            <td>
+              <table class="email-footer" role="presentation" align="center" width="570" cellpadding="0" cellspacing="0">
+                <tr>
+                  <td class="content-cell">{{ with .Hermes.Brand.SocialLinks }}
+                    <p class="sub center" data-hermes="social">{{ range . }}
+                      <a href="{{ .URL | url }}" target="_blank">{{ if .IconURL }}<img src="{{ .IconURL | url }}" alt="{{ .Name }}" width="24" height="24" style="border:0;margin:0 4px" />{{ else }}{{ .Name }}{{ end }}</a>{{ end }}
+                    </p>{{ end }}
+                    <p class="sub center">
+                      {{.Hermes.Brand.Copyright}}
+                    </p>{{ with .Hermes.Brand.Address }}
+                    <p class="sub center" data-hermes="address">{{ . }}</p>{{ end }}{{ with .Email.Unsubscribe }}{{ with .Link }}
+                    <p class="sub center"><a href="{{ . | url }}" data-hermes="unsubscribe">{{ translate $.Hermes.Locale "footer.unsubscribe" }}</a></p>{{ end }}{{ end }}
+                  </td>
+                </tr>
+              </table>
+            </td>
+          </tr>{{ end }}
+        </table>
+      </td>
+    </tr>
+  </table>
+</body>
+</html>
+{{ define "body" }}                    {{ block "greeting" . }}<h1{{ if not .Email.Body.Title }} data-hermes-greeting="{{ .Email.Body.Greeting }}"{{ end }}>{{if .Email.Body.Title }}{{ .Email.Body.Title }}{{ else }}{{ .Email.Body.Greeting }} {{ .Email.Body.Name }},{{ end }}</h1>{{ end }}
+                    {{ block "intros" . }}{{ with .Email.Body.Intros }}
                         {{ if gt (len .) 0 }}
                           {{ range $line := . }}
-                            <p>{{ $line }}</p>
+                            <p data-hermes="intro">{{ $line }}</p>
                           {{ end }}
                         {{ end }}
-                    {{ end }}
+                    {{ end }}{{ end }}{{ if .Email.Body.TableOfContents }}{{ with .Email.Body.Anchors }}
+                    <ul class="toc" data-hermes="toc">{{ range . }}
+                      <li><a href="#{{ .ID }}">{{ if eq .Section "dictionary" }}{{ translate $.Hermes.Locale "toc.details" }}{{ else }}{{ .Title }}{{ end }}</a></li>{{ end }}
+                    </ul>{{ end }}{{ end }}
                     {{ if (ne .Email.Body.FreeMarkdown "") }}
-                      {{ .Email.Body.FreeMarkdown.ToHTML }}
+                      <div data-hermes="markdown">
+                        {{ .Hermes.MarkdownToHTML .Email.Body.FreeMarkdown }}
+                      </div>
                     {{ else }}
 
-                      {{ with .Email.Body.Dictionary }} 
+                      {{ block "dictionary" . }}{{ with .Email.Body.Dictionary }} 
                         {{ if gt (len .) 0 }}
-                          <dl class="body-dictionary">
+                          <dl class="body-dictionary" id="{{ $.Email.Body.AnchorID "dictionary" 0 }}">
                             {{ range $entry := . }}
                               <dt>{{ $entry.Key }}:</dt>
-                              <dd>{{ $entry.Value }}</dd>
+                              <dd>{{ entryHTML $entry $.Hermes.TextDirection }}</dd>
                             {{ end }}
                           </dl>
                         {{ end }}
+                      {{ end }}{{ end }}
+
+                      <!-- Summary -->
+                      {{ with .Email.Body.Summary }}
+                        <table class="summary" role="presentation" data-hermes="summary" width="100%" cellpadding="0" cellspacing="0">
+                          {{ range $row := .Rows }}
+                            <tr>
+                              {{ range $section := $row }}
+                                <td class="summary-card" width="50%">
+                                  <p class="summary-label">{{ $section.Label }}</p>
+                                  <p class="summary-value">{{ $section.FormattedValue }}</p>
+                                  {{ with $section.Trend }}
+                                    <p class="summary-delta summary-delta--{{ $section.Tone }}">{{ if eq . "flat" }}{{ translate $.Hermes.Locale "summary.unchanged" }}{{ else }}{{ $section.Delta }}{{ end }}</p>
+                                  {{ end }}
+                                </td>
+                              {{ end }}
+                              {{ if eq (len $row) 1 }}
+                                <td width="50%"></td>
+                              {{ end }}
+                            </tr>
+                          {{ end }}
+                        </table>
+                        {{ range $i, $section := .Sections }}
+                          {{ with $section.Highlights }}
+                            <h3 class="summary-title" id="{{ $.Email.Body.AnchorID "highlights" $i }}">{{ $section.Label }}</h3>
+                            <ul class="summary-highlights">
+                              {{ range . }}
+                                <li>{{ . }}</li>
+                              {{ end }}
+                            </ul>
+                          {{ end }}
+                        {{ end }}
                       {{ end }}
 
-                      <!-- Table -->
-                      {{ with .Email.Body.Table }}
-                        {{ $data := .Data }}
-                        {{ $columns := .Columns }}
+                      <!-- Tables -->
+                      {{ block "table" . }}{{ range $t, $table := .Email.Body.AllTables }}
+                        {{ $data := $table.Data }}
+                        {{ $columns := $table.Columns }}
                         {{ if gt (len $data) 0 }}
-                          <table class="data-wrapper" width="100%" cellpadding="0" cellspacing="0">
+                          <table class="data-wrapper" role="presentation" width="100%" cellpadding="0" cellspacing="0">
+                            {{ with $table.Title }}
+                              <tr>
+                                <td colspan="2">
+                                  <h3 class="data-title" id="{{ $.Email.Body.AnchorID "table" $t }}">{{ . }}</h3>
+                                </td>
+                              </tr>
+                            {{ end }}
                             <tr>
                               <td colspan="2">
-                                <table class="data-table" width="100%" cellpadding="0" cellspacing="0">
+                                <table class="data-table{{ if eq $table.ResponsiveMode "stack" }} data-table--stack{{ end }}" width="100%" cellpadding="0" cellspacing="0">
                                   <tr>
                                     {{ $col := index $data 0 }}
                                     {{ range $entry := $col }}
@@ -341,7 +639,7 @@ func (dt *Default) HTMLTemplate() string {
                                           {{ end }}
                                           {{ $align := index .CustomAlignment $entry.Key }}
                                           {{ with $align }}
-                                            style="text-align:{{ . }}"
+                                            style="text-align:{{ align . $.Hermes.TextDirection }}"
                                           {{ end }}
                                         {{ end }}
                                       >
@@ -349,45 +647,126 @@ func (dt *Default) HTMLTemplate() string {
                                       </th>
                                     {{ end }}
                                   </tr>
-                                  {{ range $row := $data }}
+                                  {{ range $i, $row := $data }}
                                     <tr>
                                       {{ range $cell := $row }}
-                                        <td
+                                        <td{{ if eq $table.ResponsiveMode "stack" }} data-label="{{ $cell.Key }}"{{ end }}
                                           {{ with $columns }}
                                             {{ $align := index .CustomAlignment $cell.Key }}
                                             {{ with $align }}
-                                              style="text-align:{{ . }}"
+                                              style="text-align:{{ align . $.Hermes.TextDirection }}{{ with $table.RowStyle $i }};{{ . }}{{ end }}"
                                             {{ end }}
-                                          {{ end }}
+                                          {{ end }}{{ if not (index $columns.CustomAlignment $cell.Key) }}{{ with $table.RowStyle $i }} style="{{ . }}"{{ end }}{{ end }}
                                         >
-                                          {{ $cell.Value }}
+                                          {{ if $cell.HTMLValue }}
+                                            {{ $cell.HTMLValue }}
+                                          {{ else }}
+                                            {{ isolate $cell.Value $cell.Bidi $.Hermes.TextDirection }}
+                                          {{ end }}
                                         </td>
                                       {{ end }}
                                     </tr>
-                                  {{ end }}
+                                  {{ end }}{{ with $table.FooterRow }}
+                                  <tfoot>
+                                    <tr>
+                                      {{ range $cell := . }}
+                                        <td{{ if eq $table.ResponsiveMode "stack" }} data-label="{{ $cell.Key }}"{{ end }} style="border-top: 2px solid #EDEFF2; font-weight: bold; color: #2F3133;{{ with index $columns.CustomAlignment $cell.Key }} text-align:{{ align . $.Hermes.TextDirection }};{{ end }}">
+                                          {{ if $cell.HTMLValue }}
+                                            {{ $cell.HTMLValue }}
+                                          {{ else }}
+                                            {{ isolate $cell.Value $cell.Bidi $.Hermes.TextDirection }}
+                                          {{ end }}
+                                        </td>
+                                      {{ end }}
+                                    </tr>
+                                  </tfoot>{{ end }}
                                 </table>
                               </td>
                             </tr>
                           </table>
+                        {{ else if $table.ShowEmpty }}
+                          <table class="data-wrapper" role="presentation" width="100%" cellpadding="0" cellspacing="0">
+                            {{ with $table.Title }}
+                              <tr>
+                                <td colspan="2">
+                                  <h3 class="data-title" id="{{ $.Email.Body.AnchorID "table" $t }}">{{ . }}</h3>
+                                </td>
+                              </tr>
+                            {{ end }}
+                            <tr>
+                              <td colspan="2">
+                                <p class="data-empty">{{ translate $.Hermes.Locale "table.empty" }}</p>
+                              </td>
+                            </tr>
+                          </table>
                         {{ end }}
-                      {{ end }}
+                      {{ end }}{{ end }}
+
+                      <!-- Schedule -->
+                      {{ with .Email.Body.Schedule }}
+                        <table class="body-schedule" role="presentation" data-hermes="schedule" width="100%" cellpadding="0" cellspacing="0">
+                          {{ range $entry := . }}
+                            <tr>
+                              <td class="body-schedule_label">{{ $entry.Label }}</td>
+                              <td>{{ timeRange $.Hermes $entry.Start $entry.End }}</td>
+                            </tr>
+                          {{ end }}
+                        </table>
+                      {{ end }}{{ with .Email.Body.CalendarEvent }}{{ if .QuickAddLinks }}
+                        <p class="sub" data-hermes="calendar">{{ translate $.Hermes.Locale "calendar.add" }}: <a href="{{ .GoogleCalendarURL | url }}">Google Calendar</a> · <a href="{{ .OutlookURL | url }}">Outlook</a></p>
+                      {{ end }}{{ end }}
 
                       <!-- Action -->
-                      {{ with .Email.Body.Actions }}
+                      {{ block "actions" . }}{{ with .Email.Body.Actions }}{{ if eq $.Email.Body.ActionsLayout "inline" }}
+                        {{ range $action := . }}{{ with $action.Instructions }}
+                          <p data-hermes="instructions">{{ . }}</p>
+                        {{ end }}{{ end }}
+                        <table class="body-action body-action--inline" role="presentation" align="center" width="100%" cellpadding="0" cellspacing="0">
+                          <tr>
+                            <td align="center">
+                              <table role="presentation" align="center" cellpadding="0" cellspacing="0">
+                                <tr>
+                                  {{ range $action := . }}{{ if $action.Button.Text }}
+                                    {{ $width := add (mul (len $action.Button.Text) 8) 30 }}{{ with $action.Button.Width }}{{ $width = . }}{{ end }}{{ $height := or $action.Button.Height 36 }}
+                                    <td style="padding: 0 5px 10px;">
+                                      {{ safe "<!--[if mso]>" }}
+                                        <v:roundrect xmlns:v="urn:schemas-microsoft-com:vml" xmlns:w="urn:schemas-microsoft-com:office:word" href="{{ $action.Button.Link | url }}" style="height:{{$height}}px;v-text-anchor:middle;width:{{$width}}px;" arcsize="{{ if $action.Button.BorderRadius }}{{ $action.Button.ArcSize $width $height }}{{ else }}10%{{ end }}" {{ if $action.Button.Color }}strokecolor="{{ $action.Button.Color }}" fillcolor="{{ $action.Button.Color }}"{{ else }}strokecolor="#3869D4" fillcolor="#3869D4"{{ end }}>
+                                          <w:anchorlock/>
+                                          <center style="color: {{ if $action.Button.TextColor }}{{ $action.Button.TextColor }}{{ else }}#FFFFFF{{ end }};font-size: 14px;text-align: center;font-family:sans-serif;font-weight:bold;">{{ $action.Button.Text }}</center>
+                                        </v:roundrect>
+                                      {{ safe "<![endif]-->" }}
+                                      {{ safe "<!--[if !mso]><!-- -->" }}
+                                        <a href="{{ $action.Button.Link | url }}" class="button button--inline" style="{{ with $action.Button.Color }}background-color: {{ . }};{{ end }} {{ with $action.Button.TextColor }}color: {{ . }};{{ end }} padding: 0 15px; font-size: 14px; line-height: {{$height}}px;{{ with $action.Button.BorderRadius }} border-radius: {{ . }}px;{{ end }}" target="_blank">{{ $action.Button.Text }}</a>
+                                      {{ safe "<!--<![endif]-->" }}
+                                    </td>
+                                  {{ end }}{{ end }}
+                                </tr>
+                              </table>
+                            </td>
+                          </tr>
+                        </table>
+                      {{ else }}{{ $steps := eq $.Email.Body.ActionsLayout "steps" }}
                         {{ if gt (len .) 0 }}
-                          {{ range $action := . }}
-                            <p>{{ $action.Instructions }}</p>
+                          {{ range $i, $action := . }}{{ if $steps }}
+                            <table class="body-step" role="presentation" width="100%" cellpadding="0" cellspacing="0" data-hermes="step">
+                              <tr>
+                                <td class="step-number" width="40" valign="top" style="padding-top: 2px;">
+                                  <div style="width: 28px; height: 28px; border-radius: 14px; background-color: #3869D4; color: #FFFFFF; font-size: 14px; font-weight: bold; line-height: 28px; text-align: center;">{{ add1 $i }}</div>
+                                </td>
+                                <td class="step-content" valign="top">
+                                  {{ with $action.Title }}<p class="step-title" style="margin-bottom: 5px; font-weight: bold;">{{ . }}</p>{{ end }}{{ end }}
+                            <p data-hermes="instructions">{{ $action.Instructions }}</p>
                             {{ $length := len $action.Button.Text }}
                             {{ $width := add (mul $length 9) 20 }}
-                            {{if (lt $width 200)}}{{$width = 200}}{{else if (gt $width 570)}}{{$width = 570}}{{else}}{{end}}
+                            {{if (lt $width 200)}}{{$width = 200}}{{else if (gt $width 570)}}{{$width = 570}}{{else}}{{end}}{{ with $action.Button.Width }}{{ $width = . }}{{ end }}{{ $height := or $action.Button.Height 45 }}{{ if and $steps (gt $width 490) }}{{ $width = 490 }}{{ end }}
                               {{safe "<!--[if mso]>" }}
                               {{ if $action.Button.Text }}
                                 <div style="margin: 30px auto;v-text-anchor:middle;text-align:center">
                                   <v:roundrect xmlns:v="urn:schemas-microsoft-com:vml" 
                                     xmlns:w="urn:schemas-microsoft-com:office:word" 
-                                    href="{{ $action.Button.Link }}" 
-                                    style="height:45px;v-text-anchor:middle;width:{{$width}}px;background-color:{{ if $action.Button.Color }}{{ $action.Button.Color }}{{ else }}#3869D4{{ end }};"
-                                    arcsize="10%" 
+                                    href="{{ $action.Button.Link | url }}" 
+                                    style="height:{{$height}}px;v-text-anchor:middle;width:{{$width}}px;background-color:{{ if $action.Button.Color }}{{ $action.Button.Color }}{{ else }}#3869D4{{ end }};"
+                                    arcsize="{{ if $action.Button.BorderRadius }}{{ $action.Button.ArcSize $width $height }}{{ else }}10%{{ end }}" 
                                     {{ if $action.Button.Color }}strokecolor="{{ $action.Button.Color }}" fillcolor="{{ $action.Button.Color }}"{{ else }}strokecolor="#3869D4" fillcolor="#3869D4"{{ end }}
                                     >
                                     <w:anchorlock/>
@@ -399,13 +778,13 @@ func (dt *Default) HTMLTemplate() string {
                               {{ end }}
                               {{ if $action.InviteCode }}
                                 <div style="margin-top:30px;margin-bottom:30px">
-                                  <table class="body-action" align="center" width="100%" cellpadding="0" cellspacing="0">
+                                  <table class="body-action" role="presentation" align="center" width="100%" cellpadding="0" cellspacing="0">
                                     <tr>
                                       <td align="center">
-                                        <table align="center" cellpadding="0" cellspacing="0" style="padding:0;text-align:center">
+                                        <table role="presentation" align="center" cellpadding="0" cellspacing="0" style="padding:0;text-align:center">
                                           <tr>
-                                            <td style="display:inline-block;border-radius:3px;font-family:Consolas, monaco, monospace;font-size:28px;text-align:center;letter-spacing:8px;color:#555;background-color:#eee;padding:20px">
-                                              {{ $action.InviteCode }}
+                                            {{ with $action.InviteCodeOptions }}<td style="display:inline-block;border-radius:3px;font-family:Consolas, monaco, monospace;font-size:{{ with .FontSize }}{{ . }}{{ else }}28px{{ end }};text-align:center;letter-spacing:8px;color:{{ with .TextColor }}{{ . }}{{ else }}#555{{ end }};background-color:{{ with .BackgroundColor }}{{ . }}{{ else }}#eee{{ end }};padding:20px">{{ end }}
+                                              {{ isolate $action.GroupedInviteCode "" $.Hermes.TextDirection }}
                                             </td>
                                           </tr>
                                         </table>
@@ -416,52 +795,81 @@ func (dt *Default) HTMLTemplate() string {
                               {{ end }}   
                               {{safe "<![endif]-->" }}
                               {{safe "<!--[if !mso]><!-- -->"}}
-                              <table class="body-action" align="center" width="100%" cellpadding="0" cellspacing="0">
+                              <table class="body-action" role="presentation" align="center" width="100%" cellpadding="0" cellspacing="0">
                                 <tr>
                                   <td align="center">
                                     <div>
                                       {{ if $action.Button.Text }}
-                                        <a href="{{ $action.Button.Link }}" class="button" style="{{ with $action.Button.Color }}background-color: {{ . }};{{ end }} {{ with $action.Button.TextColor }}color: {{ . }};{{ end }} width: {{$width}}px;" target="_blank">
+                                        <a href="{{ $action.Button.Link | url }}" class="button" style="{{ with $action.Button.Color }}background-color: {{ . }};{{ end }} {{ with $action.Button.TextColor }}color: {{ . }};{{ end }} width: {{$width}}px;{{ with $action.Button.Height }} line-height: {{ . }}px;{{ end }}{{ with $action.Button.BorderRadius }} border-radius: {{ . }}px;{{ end }}" target="_blank">
                                           {{ $action.Button.Text }}
                                         </a>
                                       {{end}}
                                       {{ if $action.InviteCode }}
-                                        <span class="invite-code">{{ $action.InviteCode }}</span>
+                                        <span class="invite-code"{{ with $action.InviteCodeOptions }}{{ if or .FontSize .BackgroundColor .TextColor }} style="{{ with .FontSize }}font-size: {{ . }};{{ end }}{{ with .BackgroundColor }}background-color: {{ . }};{{ end }}{{ with .TextColor }}color: {{ . }};{{ end }}"{{ end }}{{ end }}>{{ isolate $action.GroupedInviteCode "" $.Hermes.TextDirection }}</span>
                                       {{end}}
                                     </div>
                                   </td>
                                 </tr>
                               </table>
-                              {{safe "<![endif]-->" }}
+                              {{safe "<!--<![endif]-->" }}{{ if $steps }}
+                                </td>
+                              </tr>
+                            </table>{{ end }}
                           {{ end }}
                         {{ end }}
-                      {{ end }}
+                      {{ end }}{{ end }}{{ end }}
 
                     {{ end }}
-                    {{ with .Email.Body.Outros }} 
+                    {{ block "outros" . }}{{ with .Email.Body.Outros }} 
                         {{ if gt (len .) 0 }}
                           {{ range $line := . }}
-                            <p>{{ $line }}</p>
+                            <p data-hermes="outro">{{ $line }}</p>
                           {{ end }}
                         {{ end }}
+                      {{ end }}{{ end }}
+
+                    {{ with .Email.Body.ContactInstructions }}
+                      {{ if or .Text .Email .URL }}
+                        <p class="sub" data-hermes="contact">
+                          {{ if .Text }}<span data-hermes="contact-text">{{ .Text }}</span>{{ else }}{{ translate $.Hermes.Locale "contact.text" }}{{ end }}
+                          {{ with .Email }}
+                            <br />
+                            {{ translate $.Hermes.Locale "contact.email" }}: <a href="mailto:{{ . }}">{{ . }}</a>
+                          {{ end }}
+                          {{ with .URL }}
+                            <br />
+                            {{ translate $.Hermes.Locale "contact.url" }}: <a href="{{ . | url }}">{{ . }}</a>
+                          {{ end }}
+                        </p>
                       {{ end }}
+                    {{ end }}
 
-                    <p>
+                    {{ block "signature" . }}{{ with .Email.Body.SignatureBlock }}{{ $signature := . }}<table class="signature-block" role="presentation" cellpadding="0" cellspacing="0" data-hermes="signature">
+                      <tr>{{ with .ImageURL }}
+                        <td class="signature-image" width="{{ $signature.ImageDisplayWidth }}" valign="top" style="padding: 0 15px 0 0;">
+                          <img src="{{ . | url }}" alt="{{ $signature.Name }}" width="{{ $signature.ImageDisplayWidth }}" style="display: block; width: {{ $signature.ImageDisplayWidth }}px; max-width: 100%; height: auto; border: 0;" />
+                        </td>{{ end }}
+                        <td valign="top" style="padding: 0;">
+                          <p class="signature-closing" style="margin-bottom: 5px;">{{ .Closing }},</p>
+                          <p class="signature-name" style="margin: 0;">{{ with .Name }}<strong>{{ . }}</strong>{{ end }}{{ with .Title }}{{ if $signature.Name }}<br />{{ end }}<span class="signature-title" style="color: #9BA2AB; font-size: 14px;">{{ . }}</span>{{ end }}</p>
+                        </td>
+                      </tr>
+                    </table>{{ else }}<p data-hermes="signature">
                       {{.Email.Body.Signature}},
                       <br />
                       {{.Hermes.Brand.Name}}
-                    </p>
+                    </p>{{ end }}{{ end }}
 
                     {{ if (eq .Email.Body.FreeMarkdown "") }}
                       {{ with .Email.Body.Actions }} 
-                        <table class="body-sub">
+                        <table class="body-sub" role="presentation">
                           <tbody>
                               {{ range $action := . }}
-                                {{if $action.Button.Text}}
+                                {{ with $action.FallbackText $.Hermes.Brand.TroubleText }}
                                 <tr>
                                   <td>
-                                    <p class="sub">{{$.Hermes.Brand.TroubleText | replace "{ACTION}" $action.Button.Text}}</p>
-                                    <p class="sub"><a href="{{ $action.Button.Link }}">{{ $action.Button.Link }}</a></p>
+                                    <p class="sub">{{ . }}</p>
+                                    <p class="sub"><a href="{{ $action.Button.Link | url }}">{{ $action.Button.Link }}</a></p>
                                   </td>
                                 </tr>
                                 {{ end }}
@@ -470,55 +878,59 @@ func (dt *Default) HTMLTemplate() string {
                         </table>
                       {{ end }}
                     {{ end }}
-                  </td>
-                </tr>
-              </table>
-            </td>
-          </tr>
-          <tr>
-            <td>
-              <table class="email-footer" align="center" width="570" cellpadding="0" cellspacing="0">
-                <tr>
-                  <td class="content-cell">
-                    <p class="sub center">
-                      {{.Hermes.Brand.Copyright}}
-                    </p>
-                  </td>
-                </tr>
-              </table>
-            </td>
-          </tr>
-        </table>
-      </td>
-    </tr>
-  </table>
-</body>
-</html>
-`
+{{ end }}`
 }
 
 // PlainTextTemplate returns a Golang template that will generate an plain text email.
 func (dt *Default) PlainTextTemplate() string {
-	return `<h2>{{if .Email.Body.Title }}{{ .Email.Body.Title }}{{ else }}{{ .Email.Body.Greeting }} {{ .Email.Body.Name }},{{ end }}</h2>
-{{ with .Email.Body.Intros }}
+	return `{{ if .Email.LanguageVariants }}{{ range $i, $variant := .Variants }}{{ if $i }}
+<h3>{{ $.LanguagesLabel }}</h3>
+{{ end }}{{ template "body" $variant }}{{ end }}{{ else }}{{ template "body" . }}{{ end }}
+{{ block "footer" . }}<p>{{.Hermes.Brand.Copyright}}</p>{{ with .Hermes.Brand.SocialLinks }}
+<p>{{ range . }}{{ .Name }}: {{ .URL }}<br>{{ end }}</p>{{ end }}{{ with .Hermes.Brand.Address }}
+<p>{{ . }}</p>{{ end }}{{ with .Email.Unsubscribe }}{{ with .Link }}
+<p>{{ translate $.Hermes.Locale "footer.unsubscribe" }}: {{ . }}</p>{{ end }}{{ end }}{{ end }}
+{{ define "body" }}{{ block "greeting" . }}<h2>{{if .Email.Body.Title }}{{ .Email.Body.Title }}{{ else }}{{ .Email.Body.Greeting }} {{ .Email.Body.Name }},{{ end }}</h2>{{ end }}
+{{ block "intros" . }}{{ with .Email.Body.Intros }}
   {{ range $line := . }}
     <p>{{ $line }}</p>
   {{ end }}
-{{ end }}
+{{ end }}{{ end }}{{ if .Email.Body.TableOfContents }}{{ with .Email.Body.Anchors }}
+<p>{{ range $i, $anchor := . }}{{ add1 $i }}. {{ if eq $anchor.Section "dictionary" }}{{ translate $.Hermes.Locale "toc.details" }}{{ else }}{{ $anchor.Title }}{{ end }}<br>{{ end }}</p>{{ end }}{{ end }}
 {{ if (ne .Email.Body.FreeMarkdown "") }}
-  {{ .Email.Body.FreeMarkdown.ToHTML }}
+  {{ .Hermes.MarkdownToHTML .Email.Body.FreeMarkdown }}
 {{ else }}
-  {{ with .Email.Body.Dictionary }}
+  {{ block "dictionary" . }}{{ with .Email.Body.Dictionary }}
     <ul>
     {{ range $entry := . }}
-      <li>{{ $entry.Key }}: {{ $entry.Value }}</li>
+      <li>{{ $entry.Key }}: {{ entryText $entry $.Hermes.TextDirection }}</li>
     {{ end }}
     </ul>
+  {{ end }}{{ end }}
+  {{ with .Email.Body.Summary }}
+    <ul>
+    {{ range $section := .Sections }}
+      <li>{{ $section.Label }}: {{ $section.FormattedValue }}{{ with $section.Trend }} – {{ if eq . "flat" }}{{ translate $.Hermes.Locale "summary.unchanged" }}{{ else }}{{ $section.Delta }}{{ end }}{{ end }}</li>
+    {{ end }}
+    </ul>
+    {{ range $section := .Sections }}
+      {{ with $section.Highlights }}
+        <h3>{{ $section.Label }}</h3>
+        <ul>
+        {{ range . }}
+          <li>{{ . }}</li>
+        {{ end }}
+        </ul>
+      {{ end }}
+    {{ end }}
   {{ end }}
-  {{ with .Email.Body.Table }}
-    {{ $data := .Data }}
-    {{ $columns := .Columns }}
+  {{ block "table" . }}{{ range $table := .Email.Body.AllTables }}
+    {{ $data := $table.Data }}
+    {{ $columns := $table.Columns }}
     {{ if gt (len $data) 0 }}
+      {{ with $table.Title }}
+        <h3>{{ . }}</h3>
+      {{ end }}
       <table class="data-table" width="100%" cellpadding="0" cellspacing="0">
         <tr>
           {{ $col := index $data 0 }}
@@ -530,35 +942,146 @@ func (dt *Default) PlainTextTemplate() string {
           <tr>
             {{ range $cell := $row }}
               <td>
-                {{ $cell.Value }}
+                {{ if $cell.HTMLValue }}
+                  {{ fragmentText $cell.HTMLValue }}
+                {{ else }}
+                  {{ isolateText $cell.Value $cell.Bidi $.Hermes.TextDirection }}
+                {{ end }}
               </td>
             {{ end }}
           </tr>
-        {{ end }}
+        {{ end }}{{ with $table.FooterRow }}
+        <tfoot>
+          <tr>
+            {{ range $cell := . }}
+              <td>
+                {{ if $cell.HTMLValue }}
+                  {{ fragmentText $cell.HTMLValue }}
+                {{ else }}
+                  {{ isolateText $cell.Value $cell.Bidi $.Hermes.TextDirection }}
+                {{ end }}
+              </td>
+            {{ end }}
+          </tr>
+        </tfoot>{{ end }}
       </table>
+    {{ else if $table.ShowEmpty }}
+      {{ with $table.Title }}
+        <h3>{{ . }}</h3>
+      {{ end }}
+      <p>{{ translate $.Hermes.Locale "table.empty" }}</p>
     {{ end }}
+  {{ end }}{{ end }}
+  {{ with .Email.Body.Schedule }}
+    <p>
+      {{ range $entry := . }}
+        {{ $entry.Label }}: {{ timeRange $.Hermes $entry.Start $entry.End }}<br>
+      {{ end }}
+    </p>
   {{ end }}
-  {{ with .Email.Body.Actions }} 
+  {{ with .Email.Body.CalendarEvent }}{{ if .QuickAddLinks }}
+    <p>{{ translate $.Hermes.Locale "calendar.add" }}:<br>Google Calendar: {{ .GoogleCalendarURL }}<br>Outlook: {{ .OutlookURL }}</p>
+  {{ end }}{{ end }}
+  {{ block "actions" . }}{{ with .Email.Body.Actions }}{{ if eq $.Email.Body.ActionsLayout "steps" }}
+    <p>
+      {{ range $i, $action := . }}
+        {{ add1 $i }}.{{ $sep := " " }}{{ with $action.Title }}{{ $sep }}{{ . }}{{ $sep = " — " }}{{ end }}{{ with $action.Instructions }}{{ $sep }}{{ . }}{{ $sep = " — " }}{{ end }}{{ if $action.InviteCode }}{{ $sep }}{{ isolateText $action.GroupedInviteCode "" $.Hermes.TextDirection }}{{ $sep = " — " }}{{ end }}{{ with $action.Button.Link }}{{ $sep }}{{ . }}{{ end }}<br>
+      {{ end }}
+    </p>
+  {{ else }} 
     {{ range $action := . }}
       <p>
         {{ $action.Instructions }} 
         {{ if $action.InviteCode }}
-          {{ $action.InviteCode }}
+          {{ isolateText $action.GroupedInviteCode "" $.Hermes.TextDirection }}
         {{ end }}
         {{ if $action.Button.Link }}
           {{ $action.Button.Link }}
         {{ end }}
       </p> 
     {{ end }}
-  {{ end }}
+  {{ end }}{{ end }}{{ end }}
 {{ end }}
-{{ with .Email.Body.Outros }} 
+{{ block "outros" . }}{{ with .Email.Body.Outros }} 
   {{ range $line := . }}
     <p>{{ $line }}<p>
   {{ end }}
+{{ end }}{{ end }}
+{{ with .Email.Body.ContactInstructions }}
+  {{ if or .Text .Email .URL }}
+    <p>
+      {{ if .Text }}{{ .Text }}{{ else }}{{ translate $.Hermes.Locale "contact.text" }}{{ end }}
+      {{ with .Email }}<br>{{ translate $.Hermes.Locale "contact.email" }}: {{ . }}{{ end }}
+      {{ with .URL }}<br>{{ translate $.Hermes.Locale "contact.url" }}: {{ . }}{{ end }}
+    </p>
+  {{ end }}
 {{ end }}
-<p>{{.Email.Body.Signature}},<br>{{.Hermes.Brand.Name}} - {{.Hermes.Brand.Link}}</p>
+{{ block "signature" . }}{{ with .Email.Body.SignatureBlock }}<p>{{ .Closing }},{{ with .Name }}<br>{{ . }}{{ end }}{{ with .Title }}<br>{{ . }}{{ end }}</p>{{ else }}<p>{{.Email.Body.Signature}},<br>{{.Hermes.Brand.Name}} - {{.Hermes.Brand.Link}}</p>{{ end }}{{ end }}
+{{ end }}`
+}
+
+// TextTemplate returns a text/template generating the plain text email as is, without conversion from HTML: the
+// dictionary as aligned keys and values, tables as aligned columns, and actions as their instructions followed by
+// their link.
+func (dt *Default) TextTemplate() string {
+	return `{{ if .Email.LanguageVariants }}{{ range $i, $variant := .Variants }}{{ if $i }}
+
+--- {{ $.LanguagesLabel }} ---
+
+{{ end }}{{ template "body" $variant }}{{ end }}{{ else }}{{ template "body" . }}{{ end }}
+
+{{ block "footer" . }}{{ .Hermes.Brand.Copyright }}{{ range .Hermes.Brand.SocialLinks }}
+{{ .Name }}: {{ .URL }}{{ end }}{{ with .Hermes.Brand.Address }}
+{{ . }}{{ end }}{{ with .Email.Unsubscribe }}{{ with .Link }}
+{{ translate $.Hermes.Locale "footer.unsubscribe" }}: {{ . }}{{ end }}{{ end }}{{ end }}
+{{ define "body" }}{{ block "greeting" . }}{{ if .Email.Body.Title }}{{ .Email.Body.Title }}{{ else }}{{ .Email.Body.Greeting }} {{ .Email.Body.Name }},{{ end }}{{ end }}
+
+{{ block "intros" . }}{{ range .Email.Body.Intros }}{{ . }}
+
+{{ end }}{{ end }}{{ if .Email.Body.TableOfContents }}{{ range $i, $anchor := .Email.Body.Anchors }}{{ add1 $i }}. {{ if eq $anchor.Section "dictionary" }}{{ translate $.Hermes.Locale "toc.details" }}{{ else }}{{ $anchor.Title }}{{ end }}
+{{ end }}
+{{ end }}{{ if .Email.Body.FreeMarkdown }}{{ .Hermes.MarkdownToText .Email.Body.FreeMarkdown }}
+
+{{ else }}{{ block "dictionary" . }}{{ with .Email.Body.Dictionary }}{{ textEntries . $.Hermes.TextDirection }}
+
+{{ end }}{{ end }}{{ with .Email.Body.Summary }}{{ range $section := .Sections }}{{ $section.Label }}: {{ $section.FormattedValue }}{{ with $section.Trend }} – {{ if eq . "flat" }}{{ translate $.Hermes.Locale "summary.unchanged" }}{{ else }}{{ $section.Delta }}{{ end }}{{ end }}
+{{ end }}{{ range $section := .Sections }}{{ with $section.Highlights }}
+{{ $section.Label }}
+{{ range . }}* {{ . }}
+{{ end }}{{ end }}{{ end }}
+{{ end }}{{ block "table" . }}{{ range $table := .Email.Body.AllTables }}{{ if $table.Data }}{{ with $table.Title }}{{ . }}
+{{ end }}{{ textTable $table $.Hermes.TextDirection }}
+
+{{ else if $table.ShowEmpty }}{{ with $table.Title }}{{ . }}
+{{ end }}{{ translate $.Hermes.Locale "table.empty" }}
+
+{{ end }}{{ end }}{{ end }}{{ with .Email.Body.Schedule }}{{ range $entry := . }}{{ $entry.Label }}: {{ timeRange $.Hermes $entry.Start $entry.End }}
+{{ end }}
+{{ end }}{{ with .Email.Body.CalendarEvent }}{{ if .QuickAddLinks }}{{ translate $.Hermes.Locale "calendar.add" }}:
+  Google Calendar: {{ .GoogleCalendarURL }}
+  Outlook: {{ .OutlookURL }}
+
+{{ end }}{{ end }}{{ block "actions" . }}{{ if eq .Email.Body.ActionsLayout "steps" }}{{ range $i, $action := .Email.Body.Actions }}{{ add1 $i }}.{{ $sep := " " }}{{ with $action.Title }}{{ $sep }}{{ . }}{{ $sep = " — " }}{{ end }}{{ with $action.Instructions }}{{ $sep }}{{ . }}{{ $sep = " — " }}{{ end }}{{ if $action.InviteCode }}{{ $sep }}{{ isolateText $action.GroupedInviteCode "" $.Hermes.TextDirection }}{{ $sep = " — " }}{{ end }}{{ with $action.Button.Link }}{{ $sep }}{{ . }}{{ end }}
+{{ end }}{{ if .Email.Body.Actions }}
+{{ end }}{{ else }}{{ range $action := .Email.Body.Actions }}{{ $action.Instructions }}{{ if $action.InviteCode }}
+  {{ isolateText $action.GroupedInviteCode "" $.Hermes.TextDirection }}{{ end }}{{ if $action.Button.Link }}
+  {{ $action.Button.Link }}{{ end }}
+
+{{ end }}{{ end }}{{ end }}{{ end }}{{ block "outros" . }}{{ range .Email.Body.Outros }}{{ . }}
+
+{{ end }}{{ end }}{{ with .Email.Body.ContactInstructions }}{{ if or .Text .Email .URL }}{{ if .Text }}{{ .Text }}{{ else }}{{ translate $.Hermes.Locale "contact.text" }}{{ end }}{{ with .Email }}
+{{ translate $.Hermes.Locale "contact.email" }}: {{ . }}{{ end }}{{ with .URL }}
+{{ translate $.Hermes.Locale "contact.url" }}: {{ . }}{{ end }}
+
+{{ end }}{{ end }}{{ block "signature" . }}{{ with .Email.Body.SignatureBlock }}{{ .Closing }},{{ with .Name }}
+{{ . }}{{ end }}{{ with .Title }}
+{{ . }}{{ end }}{{ else }}{{ .Email.Body.Signature }},
+{{ .Hermes.Brand.Name }} - {{ .Hermes.Brand.Link }}{{ end }}{{ end }}
+{{ end }}`
+}
 
-<p>{{.Hermes.Brand.Copyright}}</p>
-`
+// Metadata returns what the default theme was designed for: phones and 600px desktop clients, with dark styles for
+// Outlook.com as well
+func (dt *Default) Metadata() Metadata {
+	return Metadata{Widths: []int{320, 600}, MediaQueries: true, DarkMode: core.DarkModeFull, MinDataVersion: 1}
 }
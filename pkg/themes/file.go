@@ -0,0 +1,94 @@
+package themes
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"text/template/parse"
+)
+
+// FileTheme is a theme whose templates are read from files, e.g. to iterate on a custom theme without rebuilding.
+// Reload reads them again, e.g. after editing them, and is safe to call while emails are generated.
+type FileTheme struct {
+	name     string
+	htmlPath string
+	textPath string
+	fsys     fs.FS // Nil for the files of the operating system
+
+	mu   sync.RWMutex
+	html string
+	text string
+}
+
+// NewFromFiles reads the HTML and plain text templates of the theme from files.
+// Missing files and templates which do not parse are reported here rather than when generating emails. The functions the
+// templates call are checked by the engine, e.g. by Hermes.Compile, as they may be TemplateFuncs of the engine.
+func NewFromFiles(name, htmlPath, textPath string) (*FileTheme, error) {
+	return newFileTheme(&FileTheme{name: name, htmlPath: htmlPath, textPath: textPath})
+}
+
+// NewFromFS reads the HTML and plain text templates of the theme from a file system, e.g. an embed.FS
+func NewFromFS(fsys fs.FS, name, htmlPath, textPath string) (*FileTheme, error) {
+	return newFileTheme(&FileTheme{name: name, htmlPath: htmlPath, textPath: textPath, fsys: fsys})
+}
+
+func newFileTheme(t *FileTheme) (*FileTheme, error) {
+	if err := t.Reload(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Reload reads the templates from the files again. The previous templates are kept when one cannot be read or parsed.
+func (t *FileTheme) Reload() error {
+	var templates [2]string
+	for i, path := range []string{t.htmlPath, t.textPath} {
+		b, err := t.readFile(path)
+		if err != nil {
+			return fmt.Errorf("theme %s: %w", t.name, err)
+		}
+		if err := checkSyntax(path, string(b)); err != nil {
+			return fmt.Errorf("theme %s: %w", t.name, err)
+		}
+		templates[i] = string(b)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.html, t.text = templates[0], templates[1]
+	return nil
+}
+
+// checkSyntax parses the template without checking the functions it calls, which only the engine knows
+func checkSyntax(name, text string) error {
+	tree := parse.New(name)
+	tree.Mode = parse.SkipFuncCheck
+	_, err := tree.Parse(text, "", "", map[string]*parse.Tree{})
+	return err
+}
+
+func (t *FileTheme) readFile(path string) ([]byte, error) {
+	if t.fsys == nil {
+		return os.ReadFile(path)
+	}
+	return fs.ReadFile(t.fsys, path)
+}
+
+// Name returns the name of the theme
+func (t *FileTheme) Name() string {
+	return t.name
+}
+
+// HTMLTemplate returns the template read from the HTML file
+func (t *FileTheme) HTMLTemplate() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.html
+}
+
+// PlainTextTemplate returns the template read from the plain text file
+func (t *FileTheme) PlainTextTemplate() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.text
+}
@@ -0,0 +1,736 @@
+package themes
+
+import "github.com/unknowns24/hermes/pkg/core"
+
+// Flat is a theme with solid colors, without shadows nor rounded corners, ported from upstream hermes
+type Flat struct{}
+
+// Name returns the name of the flat theme
+func (dt *Flat) Name() string {
+	return "flat"
+}
+
+// HTMLTemplate returns a Golang template that will generate an HTML email.
+func (dt *Flat) HTMLTemplate() string {
+	return `
+<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<html xmlns="http://www.w3.org/1999/xhtml" lang="{{ with .Hermes.Locale }}{{ . }}{{ else }}en{{ end }}">
+<head>
+  <meta name="viewport" content="width=device-width, initial-scale=1.0" />
+  <meta http-equiv="Content-Type" content="text/html; charset=UTF-8" />
+  <title>{{ .Hermes.Brand.Name }}</title>{{ with .Hermes.Brand.FontFaces }}
+  <style type="text/css" rel="stylesheet" media="all" data-premailer="ignore" data-hermes-css="fonts">{{ . }}</style>{{ end }}
+  <style type="text/css" rel="stylesheet" media="all">
+    /* Base ------------------------------ */
+    *:not(br):not(tr):not(html) {
+      font-family: {{ .Hermes.Brand.FontStack "Arial, 'Helvetica Neue', Helvetica, sans-serif" }};
+      -webkit-box-sizing: border-box;
+      box-sizing: border-box;
+    }
+    body {
+      width: 100% !important;
+      height: 100%;
+      margin: 0;
+      line-height: 1.4;
+      background-color: #ECEFF1;
+      color: #5F6B73;
+      -webkit-text-size-adjust: none;
+    }
+    a {
+      color: #00948D;
+    }
+    /* Layout ------------------------------ */
+    .email-wrapper {
+      width: 100%;
+      margin: 0;
+      padding: 0;
+      background-color: #ECEFF1;
+    }
+    .email-content {
+      width: 100%;
+      margin: 0;
+      padding: 0;
+    }
+    /* Masthead ----------------------- */
+    .email-masthead {
+      padding: 25px 0;
+      text-align: center;
+      background-color: #2C3E50;
+    }
+    .email-masthead_logo {
+      max-width: 400px;
+      border: 0;
+    }
+    .email-masthead_name {
+      font-size: 16px;
+      font-weight: bold;
+      color: #FFFFFF;
+      text-decoration: none;
+    }
+    .email-logo {
+      max-height: 50px;
+    }
+    /* Body ------------------------------ */
+    .email-body {
+      width: 100%;
+      margin: 0;
+      padding: 0;
+      background-color: #FFFFFF;
+    }
+    .email-body_inner {
+      width: 570px;
+      margin: 0 auto;
+      padding: 0;
+    }
+    .email-footer {
+      width: 570px;
+      margin: 0 auto;
+      padding: 0;
+      text-align: center;
+    }
+    .email-footer p {
+      color: #8A959C;
+    }
+    .body-action {
+      width: 100%;
+      margin: 30px auto;
+      padding: 0;
+      text-align: center;
+    }
+    .body-dictionary {
+      width: 100%;
+      overflow: hidden;
+      margin: 20px auto 10px;
+      padding: 0;
+    }
+    .body-dictionary dd {
+      margin: 0 0 10px 0;
+    }
+    .body-dictionary dt {
+      clear: both;
+      color: #000;
+      font-weight: bold;
+    }
+    .body-dictionary dd {
+      margin-left: 0;
+      margin-bottom: 10px;
+    }
+    .body-sub {
+      margin-top: 25px;
+      padding-top: 25px;
+      border-top: 1px solid #EDEFF2;
+      table-layout: fixed;
+    }
+    .body-sub a {
+      word-break: break-all;
+    }
+    .language-divider {
+      margin: 25px 0;
+      padding-top: 25px;
+      border-top: 1px solid #EDEFF2;
+      color: #AEAEAE;
+      font-size: 12px;
+      text-align: center;
+    }
+    .content-cell {
+      padding: 35px;
+    }
+    .align-right {
+      text-align: right;
+    }
+    /* Type ------------------------------ */
+    h1 {
+      margin-top: 0;
+      color: #2F3133;
+      font-size: 19px;
+      font-weight: bold;
+    }
+    h2 {
+      margin-top: 0;
+      color: #2F3133;
+      font-size: 16px;
+      font-weight: bold;
+    }
+    h3 {
+      margin-top: 0;
+      color: #2F3133;
+      font-size: 14px;
+      font-weight: bold;
+    }
+    blockquote {
+      margin: 25px 0;
+      padding-left: 10px;
+      border-left: 10px solid #F0F2F4;
+    }
+    blockquote p {
+        font-size: 1.1rem;
+        color: #999;
+    }
+    blockquote cite {
+        display: block;
+        text-align: right;
+        color: #666;
+        font-size: 1.2rem;
+    }
+    cite {
+      display: block;
+      font-size: 0.925rem; 
+    }
+    cite:before {
+      content: "\2014 \0020";
+    }
+    p {
+      margin-top: 0;
+      color: #74787E;
+      font-size: 16px;
+      line-height: 1.5em;
+    }
+    p.sub {
+      font-size: 12px;
+    }
+    p.center {
+      text-align: center;
+    }
+    table {
+      width: 100%;
+    }
+    th {
+      padding: 0px 5px;
+      padding-bottom: 8px;
+      border-bottom: 1px solid #EDEFF2;
+    }
+    th p {
+      margin: 0;
+      color: #9BA2AB;
+      font-size: 12px;
+    }
+    td {
+      padding: 10px 5px;
+      color: #74787E;
+      font-size: 15px;
+      line-height: 18px;
+    }
+    .content {
+      align: center;
+      padding: 0;
+    }
+    /* Schedule ------------------------------ */
+    .body-schedule {
+      width: 100%;
+      margin: 0;
+      padding: 0 0 25px;
+    }
+    .body-schedule td {
+      padding: 5px;
+      color: #74787E;
+      font-size: 15px;
+    }
+    .body-schedule_label {
+      color: #000;
+      font-weight: bold;
+    }
+    /* Summary ------------------------------ */
+    .summary {
+      width: 100%;
+      margin: 0;
+      padding: 0 0 25px;
+    }
+    .summary-card {
+      padding: 15px;
+      background-color: #ECEFF1;
+      border: 4px solid #FFFFFF;
+      vertical-align: top;
+    }
+    .summary-label {
+      margin: 0;
+      color: #74787E;
+      font-size: 13px;
+    }
+    .summary-value {
+      margin: 5px 0;
+      color: #2F3133;
+      font-size: 24px;
+      font-weight: bold;
+      line-height: 1.2em;
+    }
+    .summary-delta {
+      margin: 0;
+      font-size: 13px;
+    }
+    .summary-delta--good {
+      color: #22BC66;
+    }
+    .summary-delta--bad {
+      color: #DC4D2F;
+    }
+    .summary-delta--neutral {
+      color: #9BA2AB;
+    }
+    .summary-highlights {
+      margin: 0 0 20px;
+      color: #74787E;
+    }
+    /* Data table ------------------------------ */
+    .data-wrapper {
+      width: 100%;
+      margin: 0;
+      padding: 35px 0;
+    }
+    .data-title {
+      margin: 0 0 10px;
+    }
+    .data-table {
+      width: 100%;
+      margin: 0;
+    }
+    .data-table th {
+      text-align: left;
+      padding: 0px 5px;
+      padding-bottom: 8px;
+      border-bottom: 2px solid #2C3E50;
+    }
+    .data-table th p {
+      margin: 0;
+      color: #9BA2AB;
+      font-size: 12px;
+    }
+    .data-table td {
+      padding: 10px 5px;
+      border-bottom: 1px solid #ECEFF1;
+      color: #5F6B73;
+      font-size: 15px;
+      line-height: 18px;
+    }
+    /* Invite Code ------------------------------ */
+    .invite-code {
+      display: inline-block;
+      padding-top: 20px;
+      padding-right: 36px;
+      padding-bottom: 16px;
+      padding-left: 36px;
+      border-radius: 0;
+      font-family: Consolas, monaco, monospace;
+      font-size: 28px;
+      text-align: center;
+      letter-spacing: 8px;
+      color: #2C3E50;
+      background-color: #ECEFF1;
+    }
+    /* Buttons ------------------------------ */
+    .button {
+      display: inline-block;
+      background-color: #00948D;
+      border-radius: 0;
+      color: #ffffff !important;
+      font-size: 15px;
+      line-height: 45px;
+      text-align: center;
+      text-decoration: none;
+      -webkit-text-size-adjust: none;
+      mso-hide: all;
+    }
+    /*Media Queries ------------------------------ */
+    @media only screen and (max-width: 600px) {
+      .email-body_inner,
+      .email-footer {
+        width: 100% !important;
+      }
+    }
+    @media only screen and (max-width: 500px) {
+      .button {
+        width: 100% !important;
+      }
+    }{{ if eq .Hermes.TextDirection "rtl" }}
+    /* Right-to-left: Gmail drops the dir attribute of the body, so the alignments are mirrored explicitly */
+    .content-cell,
+    .data-table th {
+      text-align: right;
+    }
+    .align-right,
+    blockquote cite {
+      text-align: left;
+    }
+    blockquote {
+      padding-left: 0;
+      padding-right: 10px;
+      border-left: 0;
+      border-right: 10px solid #F0F2F4;
+    }{{ end }}
+  </style>{{ if .Email.Body.HasStackedTables }}
+  <style type="text/css" rel="stylesheet" media="all" data-premailer="ignore" data-hermes-css="stack">
+    /* Stacked tables: Outlook ignores media queries and keeps the desktop layout */
+    @media only screen and (max-width: 480px) {
+      .data-table--stack th {
+        display: none !important;
+      }
+      .data-table--stack tr,
+      .data-table--stack td {
+        display: block !important;
+        width: 100% !important;
+        text-align: start !important;
+      }
+      .data-table--stack tr {
+        padding: 8px 0 !important;
+      }
+      .data-table--stack td {
+        padding: 2px 0 !important;
+      }
+      .data-table--stack td:before {
+        content: attr(data-label);
+        display: block;
+        font-weight: bold;
+      }
+    }
+  </style>{{ end }}{{ with .Hermes.CustomCSS }}
+  <style type="text/css" rel="stylesheet" media="all" data-hermes-css="custom">{{ css . }}</style>{{ end }}
+</head>
+<body dir="{{.Hermes.TextDirection}}">
+  <table class="email-wrapper" dir="{{.Hermes.TextDirection}}" width="100%" cellpadding="0" cellspacing="0">
+    <tr>
+      <td class="content">
+        <table class="email-content" width="100%" cellpadding="0" cellspacing="0">
+          <!-- Logo -->
+          <tr>
+            <td class="email-masthead">
+              <a class="email-masthead_name" href="{{ .Hermes.Brand.Link | url }}" target="_blank">
+                {{ if .Hermes.Brand.Logo }}{{ with .Hermes.Brand.LogoImage }}
+                  <img src="{{ .URL | url }}" class="email-logo" alt="{{ .Alt }}"{{ with .Width }} width="{{ . }}"{{ end }}{{ with .Height }} height="{{ . }}"{{ end }}{{ if or .Width .Height }} style="{{ with .Width }}width: 100%; max-width: {{ . }}px; height: auto; {{ end }}max-height: none;"{{ end }} />{{ end }}
+                {{ else }}
+                  {{ .Hermes.Brand.Name }}
+                {{ end }}
+                </a>
+            </td>
+          </tr>
+
+          <!-- Email Body -->
+          <tr>
+            <td class="email-body" width="100%">
+              <table class="email-body_inner" align="center" width="570" cellpadding="0" cellspacing="0">
+                <!-- Body content -->
+                <tr>
+                  <td class="content-cell">{{ if .Email.LanguageVariants }}{{ range $i, $variant := .Variants }}{{ if $i }}
+                    <div class="language-divider" role="separator" data-hermes="language-divider">{{ $.LanguagesLabel }}</div>{{ end }}
+                    <div lang="{{ $variant.Hermes.Locale }}" dir="{{ $variant.Hermes.TextDirection }}" data-hermes="language-variant">
+{{ template "body" $variant }}                    </div>{{ end }}
+{{ else }}
+{{ template "body" . }}{{ end }}                  </td>
+                </tr>
+              </table>
+            </td>
+          </tr>
+          <tr>
+            <td>
+              <table class="email-footer" align="center" width="570" cellpadding="0" cellspacing="0">
+                <tr>
+                  <td class="content-cell">{{ with .Hermes.Brand.SocialLinks }}
+                    <p class="sub center" data-hermes="social">{{ range . }}
+                      <a href="{{ .URL | url }}" target="_blank">{{ if .IconURL }}<img src="{{ .IconURL | url }}" alt="{{ .Name }}" width="24" height="24" style="border:0;margin:0 4px" />{{ else }}{{ .Name }}{{ end }}</a>{{ end }}
+                    </p>{{ end }}
+                    <p class="sub center">
+                      {{.Hermes.Brand.Copyright}}
+                    </p>{{ with .Hermes.Brand.Address }}
+                    <p class="sub center" data-hermes="address">{{ . }}</p>{{ end }}{{ with .Email.Unsubscribe }}{{ with .Link }}
+                    <p class="sub center"><a href="{{ . | url }}" data-hermes="unsubscribe">{{ translate $.Hermes.Locale "footer.unsubscribe" }}</a></p>{{ end }}{{ end }}
+                  </td>
+                </tr>
+              </table>
+            </td>
+          </tr>
+        </table>
+      </td>
+    </tr>
+  </table>
+</body>
+</html>
+{{ define "body" }}                    <h1{{ if not .Email.Body.Title }} data-hermes-greeting="{{ .Email.Body.Greeting }}"{{ end }}>{{if .Email.Body.Title }}{{ .Email.Body.Title }}{{ else }}{{ .Email.Body.Greeting }} {{ .Email.Body.Name }},{{ end }}</h1>
+                    {{ with .Email.Body.Intros }}
+                        {{ if gt (len .) 0 }}
+                          {{ range $line := . }}
+                            <p data-hermes="intro">{{ $line }}</p>
+                          {{ end }}
+                        {{ end }}
+                    {{ end }}{{ if .Email.Body.TableOfContents }}{{ with .Email.Body.Anchors }}
+                    <ul class="toc" data-hermes="toc">{{ range . }}
+                      <li><a href="#{{ .ID }}">{{ if eq .Section "dictionary" }}{{ translate $.Hermes.Locale "toc.details" }}{{ else }}{{ .Title }}{{ end }}</a></li>{{ end }}
+                    </ul>{{ end }}{{ end }}
+                    {{ if (ne .Email.Body.FreeMarkdown "") }}
+                      <div data-hermes="markdown">
+                        {{ .Hermes.MarkdownToHTML .Email.Body.FreeMarkdown }}
+                      </div>
+                    {{ else }}
+
+                      {{ with .Email.Body.Dictionary }} 
+                        {{ if gt (len .) 0 }}
+                          <dl class="body-dictionary" id="{{ $.Email.Body.AnchorID "dictionary" 0 }}">
+                            {{ range $entry := . }}
+                              <dt>{{ $entry.Key }}:</dt>
+                              <dd>{{ entryHTML $entry $.Hermes.TextDirection }}</dd>
+                            {{ end }}
+                          </dl>
+                        {{ end }}
+                      {{ end }}
+
+                      <!-- Summary -->
+                      {{ with .Email.Body.Summary }}
+                        <table class="summary" data-hermes="summary" width="100%" cellpadding="0" cellspacing="0">
+                          {{ range $row := .Rows }}
+                            <tr>
+                              {{ range $section := $row }}
+                                <td class="summary-card" width="50%">
+                                  <p class="summary-label">{{ $section.Label }}</p>
+                                  <p class="summary-value">{{ $section.FormattedValue }}</p>
+                                  {{ with $section.Trend }}
+                                    <p class="summary-delta summary-delta--{{ $section.Tone }}">{{ if eq . "flat" }}{{ translate $.Hermes.Locale "summary.unchanged" }}{{ else }}{{ $section.Delta }}{{ end }}</p>
+                                  {{ end }}
+                                </td>
+                              {{ end }}
+                              {{ if eq (len $row) 1 }}
+                                <td width="50%"></td>
+                              {{ end }}
+                            </tr>
+                          {{ end }}
+                        </table>
+                        {{ range $i, $section := .Sections }}
+                          {{ with $section.Highlights }}
+                            <h3 class="summary-title" id="{{ $.Email.Body.AnchorID "highlights" $i }}">{{ $section.Label }}</h3>
+                            <ul class="summary-highlights">
+                              {{ range . }}
+                                <li>{{ . }}</li>
+                              {{ end }}
+                            </ul>
+                          {{ end }}
+                        {{ end }}
+                      {{ end }}
+
+                      <!-- Tables -->
+                      {{ range $t, $table := .Email.Body.AllTables }}
+                        {{ $data := $table.Data }}
+                        {{ $columns := $table.Columns }}
+                        {{ if gt (len $data) 0 }}
+                          <table class="data-wrapper" width="100%" cellpadding="0" cellspacing="0">
+                            {{ with $table.Title }}
+                              <tr>
+                                <td colspan="2">
+                                  <h3 class="data-title" id="{{ $.Email.Body.AnchorID "table" $t }}">{{ . }}</h3>
+                                </td>
+                              </tr>
+                            {{ end }}
+                            <tr>
+                              <td colspan="2">
+                                <table class="data-table{{ if eq $table.ResponsiveMode "stack" }} data-table--stack{{ end }}" width="100%" cellpadding="0" cellspacing="0">
+                                  <tr>
+                                    {{ $col := index $data 0 }}
+                                    {{ range $entry := $col }}
+                                      <th
+                                        {{ with $columns }}
+                                          {{ $width := index .CustomWidth $entry.Key }}
+                                          {{ with $width }}
+                                            width="{{ . }}"
+                                          {{ end }}
+                                          {{ $align := index .CustomAlignment $entry.Key }}
+                                          {{ with $align }}
+                                            style="text-align:{{ align . $.Hermes.TextDirection }}"
+                                          {{ end }}
+                                        {{ end }}
+                                      >
+                                        <p>{{ $entry.Key }}</p>
+                                      </th>
+                                    {{ end }}
+                                  </tr>
+                                  {{ range $row := $data }}
+                                    <tr>
+                                      {{ range $cell := $row }}
+                                        <td{{ if eq $table.ResponsiveMode "stack" }} data-label="{{ $cell.Key }}"{{ end }}
+                                          {{ with $columns }}
+                                            {{ $align := index .CustomAlignment $cell.Key }}
+                                            {{ with $align }}
+                                              style="text-align:{{ align . $.Hermes.TextDirection }}"
+                                            {{ end }}
+                                          {{ end }}
+                                        >
+                                          {{ if $cell.HTMLValue }}
+                                            {{ $cell.HTMLValue }}
+                                          {{ else }}
+                                            {{ isolate $cell.Value $cell.Bidi $.Hermes.TextDirection }}
+                                          {{ end }}
+                                        </td>
+                                      {{ end }}
+                                    </tr>
+                                  {{ end }}
+                                </table>
+                              </td>
+                            </tr>
+                          </table>
+                        {{ else if $table.ShowEmpty }}
+                          <table class="data-wrapper" width="100%" cellpadding="0" cellspacing="0">
+                            {{ with $table.Title }}
+                              <tr>
+                                <td colspan="2">
+                                  <h3 class="data-title" id="{{ $.Email.Body.AnchorID "table" $t }}">{{ . }}</h3>
+                                </td>
+                              </tr>
+                            {{ end }}
+                            <tr>
+                              <td colspan="2">
+                                <p class="data-empty">{{ translate $.Hermes.Locale "table.empty" }}</p>
+                              </td>
+                            </tr>
+                          </table>
+                        {{ end }}
+                      {{ end }}
+
+                      <!-- Schedule -->
+                      {{ with .Email.Body.Schedule }}
+                        <table class="body-schedule" data-hermes="schedule" width="100%" cellpadding="0" cellspacing="0">
+                          {{ range $entry := . }}
+                            <tr>
+                              <td class="body-schedule_label">{{ $entry.Label }}</td>
+                              <td>{{ timeRange $.Hermes $entry.Start $entry.End }}</td>
+                            </tr>
+                          {{ end }}
+                        </table>
+                      {{ end }}{{ with .Email.Body.CalendarEvent }}{{ if .QuickAddLinks }}
+                        <p data-hermes="calendar">{{ translate $.Hermes.Locale "calendar.add" }}: <a href="{{ .GoogleCalendarURL | url }}">Google Calendar</a> · <a href="{{ .OutlookURL | url }}">Outlook</a></p>
+                      {{ end }}{{ end }}
+
+                      <!-- Action -->
+                      {{ with .Email.Body.Actions }}
+                        {{ if gt (len .) 0 }}
+                          {{ range $action := . }}
+                            <p data-hermes="instructions">{{ $action.Instructions }}</p>
+                            {{ $length := len $action.Button.Text }}
+                            {{ $width := add (mul $length 9) 20 }}
+                            {{if (lt $width 200)}}{{$width = 200}}{{else if (gt $width 570)}}{{$width = 570}}{{else}}{{end}}{{ with $action.Button.Width }}{{ $width = . }}{{ end }}{{ $height := or $action.Button.Height 45 }}
+                              {{safe "<!--[if mso]>" }}
+                              {{ if $action.Button.Text }}
+                                <div style="margin: 30px auto;v-text-anchor:middle;text-align:center">
+                                  {{ if $action.Button.BorderRadius }}<v:roundrect arcsize="{{ $action.Button.ArcSize $width $height }}"{{ else }}<v:rect{{ end }} xmlns:v="urn:schemas-microsoft-com:vml" 
+                                    xmlns:w="urn:schemas-microsoft-com:office:word" 
+                                    href="{{ $action.Button.Link | url }}" 
+                                    style="height:{{$height}}px;v-text-anchor:middle;width:{{$width}}px;background-color:{{ if $action.Button.Color }}{{ $action.Button.Color }}{{ else }}#00948D{{ end }};"
+                                    {{ if $action.Button.Color }}strokecolor="{{ $action.Button.Color }}" fillcolor="{{ $action.Button.Color }}"{{ else }}strokecolor="#00948D" fillcolor="#00948D"{{ end }}
+                                    >
+                                    <w:anchorlock/>
+                                    <center style="color: {{ if $action.Button.TextColor }}{{ $action.Button.TextColor }}{{else}}#FFFFFF{{ end }};font-size: 15px;text-align: center;font-family:sans-serif;font-weight:bold;">
+                                      {{ $action.Button.Text }}
+                                    </center>
+                                  {{ if $action.Button.BorderRadius }}</v:roundrect>{{ else }}</v:rect>{{ end }}
+                                </div>
+                              {{ end }}
+                              {{ if $action.InviteCode }}
+                                <div style="margin-top:30px;margin-bottom:30px">
+                                  <table class="body-action" align="center" width="100%" cellpadding="0" cellspacing="0">
+                                    <tr>
+                                      <td align="center">
+                                        <table align="center" cellpadding="0" cellspacing="0" style="padding:0;text-align:center">
+                                          <tr>
+                                            <td style="display:inline-block;border-radius:0;font-family:Consolas, monaco, monospace;font-size:28px;text-align:center;letter-spacing:8px;color:#2C3E50;background-color:#ECEFF1;padding:20px">
+                                              {{ isolate $action.GroupedInviteCode "" $.Hermes.TextDirection }}
+                                            </td>
+                                          </tr>
+                                        </table>
+                                      </td>
+                                    </tr>
+                                  </table>
+                                </div>
+                              {{ end }}   
+                              {{safe "<![endif]-->" }}
+                              {{safe "<!--[if !mso]><!-- -->"}}
+                              <table class="body-action" align="center" width="100%" cellpadding="0" cellspacing="0">
+                                <tr>
+                                  <td align="center">
+                                    <div>
+                                      {{ if $action.Button.Text }}
+                                        <a href="{{ $action.Button.Link | url }}" class="button" style="{{ with $action.Button.Color }}background-color: {{ . }};{{ end }} {{ with $action.Button.TextColor }}color: {{ . }};{{ end }} width: {{$width}}px;{{ with $action.Button.Height }} line-height: {{ . }}px;{{ end }}{{ with $action.Button.BorderRadius }} border-radius: {{ . }}px;{{ end }}" target="_blank">
+                                          {{ $action.Button.Text }}
+                                        </a>
+                                      {{end}}
+                                      {{ if $action.InviteCode }}
+                                        <span class="invite-code">{{ isolate $action.GroupedInviteCode "" $.Hermes.TextDirection }}</span>
+                                      {{end}}
+                                    </div>
+                                  </td>
+                                </tr>
+                              </table>
+                              {{safe "<!--<![endif]-->" }}
+                          {{ end }}
+                        {{ end }}
+                      {{ end }}
+
+                    {{ end }}
+                    {{ with .Email.Body.Outros }} 
+                        {{ if gt (len .) 0 }}
+                          {{ range $line := . }}
+                            <p data-hermes="outro">{{ $line }}</p>
+                          {{ end }}
+                        {{ end }}
+                      {{ end }}
+
+                    {{ with .Email.Body.ContactInstructions }}
+                      {{ if or .Text .Email .URL }}
+                        <p class="sub" data-hermes="contact">
+                          {{ if .Text }}<span data-hermes="contact-text">{{ .Text }}</span>{{ else }}{{ translate $.Hermes.Locale "contact.text" }}{{ end }}
+                          {{ with .Email }}
+                            <br />
+                            {{ translate $.Hermes.Locale "contact.email" }}: <a href="mailto:{{ . }}">{{ . }}</a>
+                          {{ end }}
+                          {{ with .URL }}
+                            <br />
+                            {{ translate $.Hermes.Locale "contact.url" }}: <a href="{{ . | url }}">{{ . }}</a>
+                          {{ end }}
+                        </p>
+                      {{ end }}
+                    {{ end }}
+
+                    {{ with .Email.Body.SignatureBlock }}{{ $signature := . }}<table class="signature-block" role="presentation" cellpadding="0" cellspacing="0" data-hermes="signature">
+                      <tr>{{ with .ImageURL }}
+                        <td class="signature-image" width="{{ $signature.ImageDisplayWidth }}" valign="top" style="padding: 0 15px 0 0;">
+                          <img src="{{ . | url }}" alt="{{ $signature.Name }}" width="{{ $signature.ImageDisplayWidth }}" style="display: block; width: {{ $signature.ImageDisplayWidth }}px; max-width: 100%; height: auto; border: 0;" />
+                        </td>{{ end }}
+                        <td valign="top" style="padding: 0;">
+                          <p class="signature-closing" style="margin-bottom: 5px;">{{ .Closing }},</p>
+                          <p class="signature-name" style="margin: 0;">{{ with .Name }}<strong>{{ . }}</strong>{{ end }}{{ with .Title }}{{ if $signature.Name }}<br />{{ end }}<span class="signature-title" style="color: #9BA2AB; font-size: 14px;">{{ . }}</span>{{ end }}</p>
+                        </td>
+                      </tr>
+                    </table>{{ else }}<p data-hermes="signature">
+                      {{.Email.Body.Signature}},
+                      <br />
+                      {{.Hermes.Brand.Name}}
+                    </p>{{ end }}
+
+                    {{ if (eq .Email.Body.FreeMarkdown "") }}
+                      {{ with .Email.Body.Actions }} 
+                        <table class="body-sub">
+                          <tbody>
+                              {{ range $action := . }}
+                                {{ with $action.FallbackText $.Hermes.Brand.TroubleText }}
+                                <tr>
+                                  <td>
+                                    <p class="sub">{{ . }}</p>
+                                    <p class="sub"><a href="{{ $action.Button.Link | url }}">{{ $action.Button.Link }}</a></p>
+                                  </td>
+                                </tr>
+                                {{ end }}
+                              {{ end }}
+                          </tbody>
+                        </table>
+                      {{ end }}
+                    {{ end }}
+{{ end }}`
+}
+
+// PlainTextTemplate returns a Golang template that will generate an plain text email.
+// Plain text has no style: it is the one of the default theme.
+func (dt *Flat) PlainTextTemplate() string {
+	return new(Default).PlainTextTemplate()
+}
+
+// TextTemplate returns the native plain text template of the default theme, see PlainTextTemplate
+func (dt *Flat) TextTemplate() string {
+	return new(Default).TextTemplate()
+}
+
+// Metadata returns what the flat theme was designed for: phones and 600px desktop clients, without dark styles
+func (dt *Flat) Metadata() Metadata {
+	return Metadata{Widths: []int{320, 600}, MediaQueries: true, DarkMode: core.DarkModeNone, MinDataVersion: 1}
+}
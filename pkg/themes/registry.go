@@ -0,0 +1,41 @@
+package themes
+
+import "github.com/unknowns24/hermes/pkg/core"
+
+// Theme is a theme of the registry.
+//
+// Deprecated: use core.Theme, which it is an alias of.
+type Theme = core.Theme
+
+// Metadata describes what a theme was designed for, see core.Metadata
+type Metadata = core.Metadata
+
+// ThemeMetadata is implemented by themes describing what they were designed for, see core.ThemeMetadata
+type ThemeMetadata = core.ThemeMetadata
+
+// The built-in themes are registered when the package is loaded, which the hermes package does for its default theme
+func init() {
+	Register(new(Default))
+	Register(new(Corporate))
+	Register(new(Flat))
+}
+
+// Register adds the theme to the registry, replacing the theme of the same name
+func Register(theme Theme) {
+	core.RegisterTheme(theme)
+}
+
+// Lookup returns the registered theme of the name
+func Lookup(name string) (Theme, bool) {
+	return core.LookupTheme(name)
+}
+
+// Names returns the names of the registered themes, sorted
+func Names() []string {
+	return core.ThemeNames()
+}
+
+// LookupMetadata returns the metadata of the registered theme of the name, if it implements ThemeMetadata
+func LookupMetadata(name string) (Metadata, bool) {
+	return core.LookupMetadata(name)
+}
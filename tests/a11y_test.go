@@ -0,0 +1,107 @@
+package hermes
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/examples/matrix"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+func TestAuditAccessibility(t *testing.T) {
+	report := hermes.AuditAccessibility(`<html><head></head><body>
+<table><tr><td bgcolor="#FFFFFF">
+  <h2>Skipped</h2>
+  <img src="https://hermes-example.com/logo.png">
+  <img src="https://hermes-example.com/pixel.png" alt="">
+  <a href="https://hermes-example.com/"><img src="https://hermes-example.com/logo.png"></a>
+  <a href="https://hermes-example.com/help">Click here</a>
+  <a href="https://hermes-example.com/">Dashboard</a>
+  <p style="color: #AAAAAA">Pale</p>
+  <p style="color: #AAAAAA; font-size: 24px">Pale but large</p>
+  <p style="color: #AAAAAA; display: none">Hidden</p>
+  <p>Default color</p>
+</td></tr></table>
+</body></html>`)
+
+	var codes []string
+	for _, issue := range report.Issues {
+		codes = append(codes, issue.Code)
+		assert.NotEmpty(t, issue.Criterion, issue.Code)
+	}
+	assert.Equal(t, []string{
+		hermes.A11yMissingLang,
+		hermes.A11yMissingAlt,
+		hermes.A11yEmptyLink,
+		hermes.A11yMissingAlt,
+		hermes.A11yLowContrast,
+		hermes.A11yLayoutTable,
+		hermes.A11yHeadingSkipped,
+		hermes.A11yVagueLink,
+		hermes.A11yMissingTitle,
+	}, codes, "Errors first, then in document order")
+	assert.Equal(t, hermes.A11yFail, report.Status)
+	assert.Equal(t, map[hermes.Severity]int{hermes.SeverityInfo: 0, hermes.SeverityWarning: 4, hermes.SeverityError: 5}, report.Counts)
+	assert.Equal(t, "1.4.3", report.Issues[4].Criterion)
+	assert.Equal(t, "contrast of #AAAAAA on #FFFFFF is 2.32:1, below 4.5:1", report.Issues[4].Message)
+}
+
+func TestAuditAccessibility_Status(t *testing.T) {
+	report := hermes.AuditAccessibility(`<html lang="en"><head><title>Hermes</title></head><body><h1>Hi</h1></body></html>`)
+	assert.Equal(t, hermes.A11yPass, report.Status)
+	assert.Empty(t, report.Issues)
+
+	report = hermes.AuditAccessibility(`<html lang="en"><head><title>Hermes</title></head><body><p>Hi</p></body></html>`)
+	assert.Equal(t, hermes.A11yWarn, report.Status)
+}
+
+func TestAuditAccessibility_JSON(t *testing.T) {
+	report := hermes.AuditAccessibility(`<html><head><title>Hermes</title></head><body><h1>Hi</h1></body></html>`)
+	b, err := json.Marshal(report)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{
+		"status": "fail",
+		"counts": {"info": 0, "warning": 0, "error": 1},
+		"issues": [{
+			"code": "missing_lang",
+			"criterion": "3.1.1",
+			"severity": "error",
+			"path": "html",
+			"message": "the html element has no lang attribute, screen readers cannot pick the language"
+		}]
+	}`, string(b))
+}
+
+// TestAuditAccessibility_Default checks that the Default theme renders the examples without accessibility errors
+func TestAuditAccessibility_Default(t *testing.T) {
+	for _, cell := range matrix.Cells() {
+		if cell.Theme.Name() != "default" {
+			continue
+		}
+		t.Run(cell.String(), func(t *testing.T) {
+			html, _, err := cell.Render()
+			if !assert.Nil(t, err) {
+				return
+			}
+			report := hermes.AuditAccessibility(html)
+			assert.Zero(t, report.Counts[hermes.SeverityError], "%+v", report.Issues)
+			assert.Equal(t, report, hermes.AuditAccessibility(html), "Reports should be deterministic")
+		})
+	}
+}
+
+func TestAuditAccessibility_Themes(t *testing.T) {
+	for _, theme := range testedThemes {
+		h, email := (&SimpleExample{theme}).getExample()
+		h.Brand.Logo = "https://hermes-example.com/logo.png"
+		html, err := h.GenerateHTML(email)
+		assert.Nil(t, err)
+		for _, issue := range hermes.AuditAccessibility(html).Issues {
+			switch issue.Code {
+			case hermes.A11yMissingLang, hermes.A11yMissingTitle, hermes.A11yMissingAlt, hermes.A11yEmptyLink:
+				t.Errorf("%s: %s: %s", theme.Name(), issue.Path, issue.Message)
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package hermes
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+func onboardingEmail(layout string) hermes.Email {
+	return hermes.Email{Body: hermes.Body{
+		Name:          "Jon Snow",
+		ActionsLayout: layout,
+		Actions: []hermes.Action{
+			{Title: "Verify your email", Instructions: "Confirm your address.", Button: hermes.Button{Text: "Verify", Link: "https://example-hermes.com/verify"}},
+			{Title: "Install the app", Instructions: "Get it on your phone.", Button: hermes.Button{Text: "Install", Link: "https://example-hermes.com/app"}},
+			{Title: "Invite your team", Button: hermes.Button{Text: "Invite", Link: "https://example-hermes.com/team"}},
+		},
+	}}
+}
+
+func TestActionsLayout_Steps(t *testing.T) {
+	h := hermes.Hermes{Theme: new(themes.Default), Brand: hermes.Branding{Name: "Hermes", Link: "https://example-hermes.com/"}}
+	html, text, err := h.Generate(onboardingEmail(hermes.ActionsSteps))
+	assert.Nil(t, err)
+
+	assert.Equal(t, 3, strings.Count(html, `data-hermes="step"`))
+	for i, title := range []string{"Verify your email", "Install the app", "Invite your team"} {
+		assert.Contains(t, html, `>`+string(rune('1'+i))+`</div>`)
+		assert.Contains(t, html, `>`+title+`</p>`)
+	}
+	assert.Equal(t, 3, strings.Count(html, "<v:roundrect"), "Steps should keep the buttons of Outlook")
+	assert.Equal(t, 3, hermes.Output{HTML: html}.Manifest().Sections.Actions)
+
+	assert.Contains(t, text, "1. Verify your email — Confirm your address. — https://example-hermes.com/verify\n"+
+		"2. Install the app — Get it on your phone. — https://example-hermes.com/app\n"+
+		"3. Invite your team — https://example-hermes.com/team\n\n")
+
+	parsed, _, err := hermes.ParseEmail(html)
+	assert.Nil(t, err)
+	assert.Equal(t, hermes.ActionsSteps, parsed.Body.ActionsLayout)
+	assert.Equal(t, onboardingEmail("").Body.Actions, parsed.Body.Actions)
+}
+
+func TestActionsLayout_Inline(t *testing.T) {
+	h := hermes.Hermes{Theme: new(themes.Default), Brand: hermes.Branding{Name: "Hermes", Link: "https://example-hermes.com/"}}
+	email := onboardingEmail(hermes.ActionsInline)
+	html, text, err := h.Generate(email)
+	assert.Nil(t, err)
+
+	assert.Equal(t, 1, strings.Count(html, "body-action--inline"), "Buttons should be side by side in one table")
+	assert.Equal(t, 3, strings.Count(html, `class="button button--inline"`))
+	assert.Equal(t, 3, strings.Count(html, "<v:roundrect"))
+	assert.NotContains(t, html, "Verify your email", "Titles are displayed by the steps layout only")
+	assert.Contains(t, text, "Confirm your address.\n  https://example-hermes.com/verify\n")
+
+	parsed, _, err := hermes.ParseEmail(html)
+	assert.Nil(t, err)
+	assert.Equal(t, hermes.ActionsInline, parsed.Body.ActionsLayout)
+	for i, action := range parsed.Body.Actions {
+		assert.Equal(t, email.Body.Actions[i].Instructions, action.Instructions)
+		assert.Equal(t, email.Body.Actions[i].Button, action.Button)
+	}
+}
+
+func TestActionsLayout_List(t *testing.T) {
+	h := hermes.Hermes{Theme: new(themes.Default), Brand: hermes.Branding{Name: "Hermes", Link: "https://example-hermes.com/"}}
+	email := onboardingEmail("")
+	expected, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	email.Body.ActionsLayout = hermes.ActionsList
+	html, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, html)
+	assert.NotContains(t, html, "Verify your email")
+}
+
+func TestValidate_ActionsLayout(t *testing.T) {
+	for _, layout := range []string{"", hermes.ActionsList, hermes.ActionsSteps, hermes.ActionsInline} {
+		assert.Nil(t, onboardingEmail(layout).Validate(), layout)
+	}
+
+	email := onboardingEmail("grid")
+	var issues hermes.Issues
+	assert.True(t, errors.As(email.Validate(), &issues))
+	assert.Equal(t, "Body.ActionsLayout", issues[0].Path)
+	assert.Equal(t, string(hermes.CodeInvalidActionsLayout), issues[0].Code)
+
+	email = onboardingEmail(hermes.ActionsInline)
+	email.Body.Actions[1].InviteCode = "123456"
+	assert.True(t, errors.As(email.Validate(), &issues))
+	assert.Equal(t, "Body.Actions[1].InviteCode", issues[0].Path)
+}
@@ -0,0 +1,107 @@
+package hermes
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+// altTheme renders an image for each intro, after the instructions of the first action
+type altTheme struct{}
+
+func (altTheme) Name() string {
+	return "alt"
+}
+
+func (altTheme) HTMLTemplate() string {
+	return `<html><body>{{ with .Email.Body.Actions }}<p data-hermes="instructions">{{ (index . 0).Instructions }}</p>{{ end }}` +
+		`{{ range .Email.Body.Intros }}<img src="{{ . }}" />{{ end }}<img src="https://cdn.hermes-example.com/blue-mug.png" alt="" /></body></html>`
+}
+
+func (altTheme) PlainTextTemplate() string {
+	return ``
+}
+
+func TestAutoAltText_FileName(t *testing.T) {
+	tests := []struct {
+		src string
+		alt string
+	}{
+		{"https://cdn.hermes-example.com/products/blue-mug.png", "blue mug"},
+		{"https://cdn.hermes-example.com/products/caf%C3%A9_latte%20large.jpg", "café latte large"},
+		{"https://cdn.hermes-example.com/banners/summer-sale", "summer sale"},
+		{"https://cdn.hermes-example.com/v1.2/hero-banner?w=600", "hero banner"},
+		{"https://cdn.hermes-example.com/uploads/IMG_2041.JPG", "Scan the code at the counter"},
+		{"https://cdn.hermes-example.com/uploads/8f3e2b1c.png", "Scan the code at the counter"},
+		{"https://cdn.hermes-example.com/", "Scan the code at the counter"},
+	}
+	h := hermes.Hermes{Theme: altTheme{}, DisableCSSInlining: true, AutoAltText: true}
+	for _, test := range tests {
+		email := hermes.Email{Body: hermes.Body{
+			Intros:  []string{test.src},
+			Actions: []hermes.Action{{Instructions: "Scan the code at the counter"}},
+		}}
+		html, err := h.GenerateHTML(email)
+		assert.Nil(t, err)
+		assert.Contains(t, html, `<img alt="`+test.alt+`" src="`, test.src)
+		// Explicit alt texts are kept, even empty
+		assert.Contains(t, html, `<img src="https://cdn.hermes-example.com/blue-mug.png" alt="" />`, test.src)
+	}
+}
+
+func TestAutoAltText_NoContext(t *testing.T) {
+	h := hermes.Hermes{Theme: altTheme{}, DisableCSSInlining: true, AutoAltText: true}
+	email := hermes.Email{Body: hermes.Body{Intros: []string{"https://cdn.hermes-example.com/uploads/IMG_2041.JPG"}}}
+	out, err := h.GenerateContext(context.Background(), email)
+	assert.Nil(t, err)
+	assert.Contains(t, out.HTML, `<img src="https://cdn.hermes-example.com/uploads/IMG_2041.JPG" />`)
+	assert.Empty(t, out.Warnings)
+}
+
+// trustedHTML keeps HTML values as is, the default sanitizer drops images
+type trustedHTML struct{}
+
+func (trustedHTML) Sanitize(s string) string {
+	return s
+}
+
+func TestAutoAltText_ProductName(t *testing.T) {
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+	h.DisableCSSInlining = false
+	h.Sanitizer = trustedHTML{}
+	email.Body.Dictionary = []hermes.Entry{{Key: "Your avatar", HTMLValue: `<img src="https://cdn.hermes-example.com/avatars/42.png">`}}
+	email.Body.Table = hermes.Table{Data: [][]hermes.Entry{
+		{{Key: "Photo", HTMLValue: `<img src="https://cdn.hermes-example.com/products/mug-01.png">`}, {Key: "Item", Value: "Blue mug"}},
+		{{Key: "Photo", HTMLValue: `<img src="https://cdn.hermes-example.com/products/tee.png" alt="Hermes tee">`}, {Key: "Item", Value: "T-shirt"}},
+	}}
+
+	html, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.NotContains(t, html, `alt="Blue mug"`, "AutoAltText is opt-in")
+
+	h.AutoAltText = true
+	out, err := h.GenerateContext(context.Background(), email)
+	assert.Nil(t, err)
+	assert.Contains(t, out.HTML, `alt="Your avatar"`)
+	assert.Contains(t, out.HTML, `alt="Blue mug"`)
+	assert.Contains(t, out.HTML, `alt="Hermes tee"`)
+	assert.NotContains(t, out.HTML, `alt="T-shirt"`)
+	if assert.Len(t, out.Warnings, 2) {
+		assert.Equal(t, hermes.IssueAutoAltText, out.Warnings[0].Code)
+		assert.Equal(t, hermes.SeverityWarning, out.Warnings[0].Severity)
+		assert.Equal(t, `alt text "Your avatar" generated from the product name of https://cdn.hermes-example.com/avatars/42.png`, out.Warnings[0].Message)
+		assert.True(t, strings.HasSuffix(out.Warnings[1].Path, "> img"), out.Warnings[1].Path)
+	}
+	for _, issue := range hermes.AuditAccessibility(out.HTML).Issues {
+		assert.NotEqual(t, hermes.A11yMissingAlt, issue.Code, issue.Path)
+	}
+
+	// The derivation is deterministic
+	again, err := h.GenerateContext(context.Background(), email)
+	assert.Nil(t, err)
+	assert.Equal(t, out, again)
+}
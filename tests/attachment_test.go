@@ -0,0 +1,113 @@
+package hermes
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/send"
+)
+
+var (
+	logo    = hermes.Attachment{Filename: "logo.png", Data: []byte("\x89PNG logo"), Inline: true}
+	invoice = hermes.Attachment{Filename: "invoice.pdf", ContentType: "application/pdf", Data: bytes.Repeat([]byte("%PDF"), 40)}
+)
+
+func TestAttachment(t *testing.T) {
+	assert.Equal(t, "cid:logo.png", logo.URL())
+	assert.Equal(t, "image/png", logo.MediaType())
+	withCID := logo
+	withCID.CID = "brand-logo"
+	assert.Equal(t, "cid:brand-logo", withCID.URL())
+	assert.Equal(t, "application/pdf", invoice.MediaType())
+	assert.Equal(t, "application/octet-stream", hermes.Attachment{Filename: "notes"}.MediaType())
+}
+
+// mimeTree returns the structure of the MIME entity, one line per part indented by depth,
+// with the disposition, Content-ID and decoded content of attachments
+func mimeTree(t *testing.T, header textproto.MIMEHeader, body io.Reader, depth int) []string {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	assert.Nil(t, err)
+	line := strings.Repeat("  ", depth) + mediaType
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		if header.Get("Content-Transfer-Encoding") == "base64" {
+			data, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, body))
+			assert.Nil(t, err)
+			line += " " + header.Get("Content-Disposition") + " " + header.Get("Content-Id") + " " + string(data)
+		}
+		return []string{line}
+	}
+	lines := []string{line}
+	r := multipart.NewReader(body, params["boundary"])
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			return lines
+		}
+		assert.Nil(t, err)
+		lines = append(lines, mimeTree(t, p.Header, p, depth+1)...)
+	}
+}
+
+func messageTree(t *testing.T, m send.Message) []string {
+	raw, err := m.Bytes()
+	assert.Nil(t, err)
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	assert.Nil(t, err)
+	return mimeTree(t, textproto.MIMEHeader(msg.Header), msg.Body, 0)
+}
+
+func TestBuildMessage(t *testing.T) {
+	email := hermes.Email{Attachments: []hermes.Attachment{logo, invoice}}
+	m := send.BuildMessage(email, "<img src=\"cid:logo.png\">", "Your invoice")
+	m.From = "Hermes <hello@hermes-example.com>"
+	m.To = []string{"jon@snow.com"}
+	assert.Equal(t, []string{
+		"multipart/mixed",
+		"  multipart/related",
+		"    multipart/alternative",
+		"      text/plain",
+		"      text/html",
+		"    image/png inline; filename=logo.png <logo.png> \x89PNG logo",
+		"  application/pdf attachment; filename=invoice.pdf  " + strings.Repeat("%PDF", 40),
+	}, messageTree(t, m))
+
+	// Only inline attachments
+	m.Attachments = []hermes.Attachment{logo}
+	assert.Equal(t, []string{
+		"multipart/related",
+		"  multipart/alternative",
+		"    text/plain",
+		"    text/html",
+		"  image/png inline; filename=logo.png <logo.png> \x89PNG logo",
+	}, messageTree(t, m))
+
+	// Text-only clients do not display inline images
+	m.Attachments = []hermes.Attachment{logo, invoice}
+	m.ContentPreference = send.TextOnly
+	assert.Equal(t, []string{
+		"multipart/mixed",
+		"  text/plain",
+		"  application/pdf attachment; filename=invoice.pdf  " + strings.Repeat("%PDF", 40),
+	}, messageTree(t, m))
+}
+
+func TestInlineLogo(t *testing.T) {
+	for _, theme := range testedThemes {
+		h, email := (&SimpleExample{theme}).getExample()
+		h.DisableCSSInlining = false
+		h.Brand.Logo = logo.URL()
+		email.Attachments = []hermes.Attachment{logo}
+		r, err := h.GenerateHTML(email)
+		assert.Nil(t, err)
+		assert.Contains(t, r, `src="cid:logo.png"`, theme.Name())
+	}
+}
@@ -0,0 +1,73 @@
+package hermes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+func bidiExample(direction hermes.TextDirection) (hermes.Hermes, hermes.Email) {
+	h := hermes.Hermes{
+		TextDirection:      direction,
+		DisableCSSInlining: true,
+	}
+	email := hermes.Email{
+		Body: hermes.Body{
+			Name: "جون",
+			Dictionary: []hermes.Entry{
+				{Key: "رقم الحساب", Value: "AC-1029-XZ"},
+				{Key: "الموقع", Value: "https://hermes-example.com/account"},
+				{Key: "الاسم", Value: "جون سنو"},
+				{Key: "العنوان", Value: "شارع Baker 221B"},
+				{Key: "المعرف", Value: "جون", Bidi: hermes.BidiRTL},
+			},
+			Table: hermes.Table{
+				Data: [][]hermes.Entry{
+					{{Key: "المنتج", Value: "Golang"}, {Key: "السعر", Value: "$10.99"}},
+				},
+			},
+			Actions: []hermes.Action{
+				{Instructions: "رمز الدعوة:", InviteCode: "123456"},
+			},
+		},
+	}
+	return h, email
+}
+
+func TestBidi_HTMLIsolation(t *testing.T) {
+	h, email := bidiExample("rtl")
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+
+	assert.Contains(t, r, `<dd><bdi dir="ltr">AC-1029-XZ</bdi></dd>`, "Codes should be isolated as LTR")
+	assert.Contains(t, r, `<dd><bdi dir="ltr">https://hermes-example.com/account</bdi></dd>`, "URLs should be isolated as LTR")
+	assert.Contains(t, r, `<dd>جون سنو</dd>`, "RTL values should not be isolated")
+	assert.Contains(t, r, `<dd><bdi>شارع Baker 221B</bdi></dd>`, "Mixed values should be isolated with auto direction")
+	assert.Contains(t, r, `<dd><bdi dir="rtl">جون</bdi></dd>`, "Explicit bidi should be used")
+	assert.Contains(t, r, `<bdi dir="ltr">$10.99</bdi>`, "Table cells should be isolated")
+	assert.Contains(t, r, `<span class="invite-code"><bdi dir="ltr">123456</bdi></span>`, "Invite code should be isolated")
+}
+
+func TestBidi_PlainTextIsolation(t *testing.T) {
+	h, email := bidiExample("rtl")
+	r, err := h.GeneratePlainText(email)
+	assert.Nil(t, err)
+
+	assert.Contains(t, r, "رقم الحساب: \u2066AC-1029-XZ\u2069", "Codes should be isolated as LTR")
+	assert.Contains(t, r, "\u2066https://hermes-example.com/account\u2069", "URLs should be isolated as LTR")
+	assert.Regexp(t, "الاسم: +جون سنو\n", r, "RTL values should not be isolated")
+	assert.Contains(t, r, "\u2068شارع Baker 221B\u2069", "Mixed values should be isolated with first strong isolate")
+	assert.Contains(t, r, "\u2067جون\u2069", "Explicit bidi should be used")
+	assert.Contains(t, r, "\u2066123456\u2069", "Invite code should be isolated")
+}
+
+func TestBidi_NoIsolationInLTR(t *testing.T) {
+	h, email := bidiExample("ltr")
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+
+	assert.Contains(t, r, `<dd>AC-1029-XZ</dd>`, "Values of LTR emails should not be isolated")
+	assert.Contains(t, r, `<dd><bdi dir="rtl">جون</bdi></dd>`, "Explicit bidi should be used in LTR emails")
+	assert.NotContains(t, r, `<bdi dir="ltr">`)
+}
@@ -0,0 +1,208 @@
+package hermes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"gopkg.in/yaml.v3"
+)
+
+func brandingExample() hermes.Branding {
+	return hermes.Branding{
+		Name:        "Hermes",
+		Link:        "https://example-hermes.com/",
+		Logo:        "https://example-hermes.com/logo.png",
+		Copyright:   "Copyright © 2024 Hermes.",
+		TroubleText: "Cannot click {ACTION}? Open:",
+		WebFonts:    []hermes.WebFont{{Family: "Inter", URL: "https://example-hermes.com/inter.woff2", Weight: "400"}},
+		SocialLinks: []hermes.SocialLink{
+			{Name: "GitHub", URL: "https://github.com/matcornic/hermes", IconURL: "https://example-hermes.com/icons/github.png"},
+			{Name: "LinkedIn", URL: "https://www.linkedin.com/company/hermes"},
+		},
+		Address:         "Hermes Inc., 1 Market St, San Francisco, CA 94105",
+		UnsubscribeLink: "https://example-hermes.com/unsubscribe",
+		Unsubscribe:     &hermes.Unsubscribe{Mailto: "unsubscribe@example-hermes.com", OneClickURL: "https://example-hermes.com/one-click"},
+	}
+}
+
+func TestBrandingFromJSON(t *testing.T) {
+	data, err := json.Marshal(brandingExample())
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"social_links":[{"name":"GitHub","url":"https://github.com/matcornic/hermes","icon_url":`)
+	brand, err := hermes.BrandingFromJSON(bytes.NewReader(data))
+	assert.Nil(t, err)
+	assert.Equal(t, brandingExample(), brand, "Brandings should round trip through JSON")
+
+	data, err = yaml.Marshal(brandingExample())
+	assert.Nil(t, err)
+	brand, err = hermes.BrandingFromYAML(bytes.NewReader(data))
+	assert.Nil(t, err)
+	assert.Equal(t, brandingExample(), brand, "Brandings should round trip through YAML")
+
+	brand, err = hermes.BrandingFromJSON(strings.NewReader(`{"name": "Hermes", "colour": "#FFF"}`))
+	assert.Nil(t, err, "Unknown fields should be ignored")
+	assert.Equal(t, hermes.Branding{Name: "Hermes"}, brand)
+
+	for input, expected := range map[string]string{
+		`{"name": `:                  "load brand: unexpected EOF",
+		`{"name": "Hermes"} {}`:      "load brand: data after the JSON document",
+		`{"name": ["Hermes"]}`:       "load brand: json: cannot unmarshal array into Go struct field Branding.name of type string",
+		`{"social_links": {"a": 1}}`: "load brand: json: cannot unmarshal object into Go struct field Branding.social_links of type []hermes.SocialLink",
+	} {
+		_, err := hermes.BrandingFromJSON(strings.NewReader(input))
+		assert.EqualError(t, err, expected, input)
+	}
+	_, err = hermes.BrandingFromYAML(strings.NewReader(""))
+	assert.EqualError(t, err, "load brand: empty YAML document")
+}
+
+func TestBrandingFromJSON_Validation(t *testing.T) {
+	_, err := hermes.BrandingFromJSON(strings.NewReader(`{
+		"name": " ",
+		"link": "http://example-hermes.com/",
+		"logo": "http://example-hermes.com/logo.png",
+		"social_links": [{"name": "GitHub", "url": "https://github.com/matcornic"}, {"name": "X", "url": "x.com", "icon_url": "/x.png"}],
+		"unsubscribe": {"one_click_url": "http://example-hermes.com/one-click"}
+	}`))
+	var issues hermes.Issues
+	assert.True(t, errors.As(err, &issues))
+	var messages []string
+	for _, issue := range issues {
+		messages = append(messages, issue.Error())
+	}
+	assert.Equal(t, []string{
+		"name: empty name",
+		"link: must be an absolute https URL",
+		"logo: must be an absolute https URL, a data: image or a cid: attachment",
+		"social_links[1].url: must be an absolute https URL",
+		"social_links[1].icon_url: must be an absolute https URL",
+		"unsubscribe.one_click_url: must be an absolute https URL",
+	}, messages)
+	assert.Equal(t, string(hermes.CodeBrandNameMissing), issues[0].Code)
+	assert.Equal(t, string(hermes.CodeBrandURLNotHTTPS), issues[1].Code)
+	assert.Equal(t, `The brand configuration link must be an absolute https URL (got "http://example-hermes.com/").`, issues[1].Localize("en"))
+
+	for _, logo := range []string{"cid:logo", "data:image/png;base64,iVBORw0KGgo="} {
+		_, err = hermes.BrandingFromJSON(strings.NewReader(`{"name": "Hermes", "logo": "` + logo + `"}`))
+		assert.Nil(t, err, logo)
+	}
+}
+
+func TestBrandingFromEnv(t *testing.T) {
+	t.Setenv("HERMES_BRAND_NAME", "Hermes")
+	t.Setenv("HERMES_BRAND_LINK", "https://example-hermes.com/")
+	t.Setenv("HERMES_BRAND_LOGO", "https://example-hermes.com/logo.png")
+	t.Setenv("HERMES_BRAND_COPYRIGHT", "Copyright © 2024 Hermes.")
+	t.Setenv("HERMES_BRAND_TROUBLE_TEXT", "Cannot click {ACTION}? Open:")
+	t.Setenv("HERMES_BRAND_ADDRESS", "Hermes Inc., 1 Market St, San Francisco, CA 94105")
+	t.Setenv("HERMES_BRAND_UNSUBSCRIBE_LINK", "https://example-hermes.com/unsubscribe")
+	t.Setenv("HERMES_BRAND_WEB_FONTS", `[{"family": "Inter", "url": "https://example-hermes.com/inter.woff2", "weight": "400"}]`)
+	t.Setenv("HERMES_BRAND_SOCIAL_LINKS", `[{"name": "GitHub", "url": "https://github.com/matcornic/hermes", "icon_url": "https://example-hermes.com/icons/github.png"},
+		{"name": "LinkedIn", "url": "https://www.linkedin.com/company/hermes"}]`)
+	t.Setenv("HERMES_BRAND_UNSUBSCRIBE", `{"mailto": "unsubscribe@example-hermes.com", "one_click_url": "https://example-hermes.com/one-click"}`)
+	brand, err := hermes.BrandingFromEnv("HERMES_BRAND")
+	assert.Nil(t, err)
+	assert.Equal(t, brandingExample(), brand)
+	brand, err = hermes.BrandingFromEnv("HERMES_BRAND_")
+	assert.Nil(t, err)
+	assert.Equal(t, brandingExample(), brand, "The trailing underscore of the prefix should be optional")
+
+	t.Setenv("HERMES_BRAND_SOCIAL_LINKS", `[{"name": "GitHub", "url": "github.com"}]`)
+	t.Setenv("HERMES_BRAND_LINK", "example-hermes.com")
+	_, err = hermes.BrandingFromEnv("HERMES_BRAND")
+	assert.EqualError(t, err, "HERMES_BRAND_LINK: must be an absolute https URL; HERMES_BRAND_SOCIAL_LINKS[0].url: must be an absolute https URL")
+
+	t.Setenv("HERMES_BRAND_SOCIAL_LINKS", `[{"name": "GitHub"`)
+	_, err = hermes.BrandingFromEnv("HERMES_BRAND")
+	assert.EqualError(t, err, "load brand: HERMES_BRAND_SOCIAL_LINKS: unexpected end of JSON input")
+
+	_, err = hermes.BrandingFromEnv("OTHER_BRAND")
+	var issues hermes.Issues
+	assert.True(t, errors.As(err, &issues))
+	assert.Equal(t, "OTHER_BRAND_NAME", issues[0].Path)
+}
+
+func TestHermes_BrandOf(t *testing.T) {
+	h := hermes.Hermes{Brand: brandingExample()}
+	override := &hermes.Branding{
+		Name:        "Winterfell",
+		Logo:        "https://winterfell.example.com/logo.png",
+		Unsubscribe: &hermes.Unsubscribe{Mailto: "unsubscribe@winterfell.example.com"},
+	}
+	email := hermes.Email{Brand: override}
+
+	brand := h.BrandOf(email)
+	expected := brandingExample()
+	expected.Name = "Winterfell"
+	expected.Logo = "https://winterfell.example.com/logo.png"
+	expected.Unsubscribe = &hermes.Unsubscribe{Mailto: "unsubscribe@winterfell.example.com", OneClickURL: "https://example-hermes.com/one-click"}
+	assert.Equal(t, expected, brand)
+
+	assert.Equal(t, brandingExample(), h.Brand, "The brand of the engine should be left untouched")
+	assert.Equal(t, &hermes.Branding{
+		Name:        "Winterfell",
+		Logo:        "https://winterfell.example.com/logo.png",
+		Unsubscribe: &hermes.Unsubscribe{Mailto: "unsubscribe@winterfell.example.com"},
+	}, override, "The brand of the email should be left untouched")
+
+	override.SocialLinks = []hermes.SocialLink{{Name: "Raven", URL: "https://winterfell.example.com/raven"}}
+	assert.Equal(t, override.SocialLinks, h.BrandOf(email).SocialLinks, "Slices should be replaced, not merged")
+	assert.Equal(t, brandingExample(), h.BrandOf(hermes.Email{}))
+
+	u := h.UnsubscribeOf(email)
+	assert.Equal(t, &hermes.Unsubscribe{
+		URL:         "https://example-hermes.com/unsubscribe",
+		Mailto:      "unsubscribe@winterfell.example.com",
+		OneClickURL: "https://example-hermes.com/one-click",
+	}, u)
+}
+
+func TestHermes_BrandOfRender(t *testing.T) {
+	h, email := (&SimpleExample{}).getExample()
+	h.Brand.Copyright = "Copyright © Hermes-Test"
+	assert.Nil(t, h.Compile())
+
+	brands := []*hermes.Branding{
+		{Name: "Winterfell", Logo: "https://winterfell.example.com/logo.png"},
+		{Name: "Casterly Rock", Link: "https://casterly-rock.example.com/"},
+		nil,
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func(brand *hermes.Branding) {
+			defer wg.Done()
+			e := email
+			e.Brand = brand
+			r, err := h.GenerateHTML(e)
+			assert.Nil(t, err)
+			assert.Contains(t, r, "Copyright © Hermes-Test", "The copyright of the engine should be inherited")
+			for _, other := range brands {
+				switch {
+				case other == nil && brand == nil:
+					assert.Contains(t, r, `alt="HermesName"`)
+				case other == nil:
+					assert.NotContains(t, r, `alt="HermesName"`)
+				case other == brand:
+					assert.Contains(t, r, other.Name)
+				default:
+					assert.NotContains(t, r, other.Name, "Brands of concurrent renders should not bleed into each other")
+				}
+			}
+			if brand != nil && brand.Logo != "" {
+				assert.Contains(t, r, brand.Logo)
+			}
+			if brand != nil && brand.Link != "" {
+				assert.Contains(t, r, `href="`+brand.Link+`"`)
+			}
+		}(brands[i%len(brands)])
+	}
+	wg.Wait()
+	assert.Equal(t, "HermesName", h.Brand.Name, "The brand of the engine should be left untouched")
+}
@@ -0,0 +1,45 @@
+package hermes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+func TestButton_OutlookFallback(t *testing.T) {
+	for _, theme := range testedThemes {
+		h, email := (&SimpleExample{theme}).getExample()
+		email.Body.Actions[0].Button.TextColor = "#000000"
+		email.Body.Actions[0].Button.Width = 300
+		email.Body.Actions[0].Button.Height = 50
+		email.Body.Actions[0].Button.BorderRadius = 5
+
+		h.DisableCSSInlining = true
+		expected, err := h.GenerateHTML(email)
+		assert.Nil(t, err)
+		h.DisableCSSInlining = false
+		r, err := h.GenerateHTML(email)
+		assert.Nil(t, err)
+
+		for _, conditional := range []string{"<!--[if mso]>", "<![endif]-->", "<!--[if !mso]><!-- -->", "<!--<![endif]-->"} {
+			assert.NotZero(t, strings.Count(expected, conditional), "%s: %s", theme.Name(), conditional)
+			assert.Equal(t, strings.Count(expected, conditional), strings.Count(r, conditional), "%s: %s should survive CSS inlining", theme.Name(), conditional)
+		}
+		assert.Regexp(t, `<v:roundrect[^>]*arcsize="20%"`, r, theme.Name())
+		assert.Contains(t, r, "</v:roundrect>", theme.Name())
+		assert.Contains(t, r, "height:50px;v-text-anchor:middle;width:300px;background-color:#22BC66;", theme.Name())
+		assert.Contains(t, r, `strokecolor="#22BC66" fillcolor="#22BC66"`, theme.Name())
+		assert.Contains(t, r, "color: #000000;", theme.Name())
+		assert.Regexp(t, `class="button"[^>]*style="[^"]*width:300px;[^"]*line-height:50px;[^"]*border-radius:5px`, r, theme.Name())
+	}
+}
+
+func TestButton_ArcSize(t *testing.T) {
+	assert.Equal(t, "0%", hermes.Button{}.ArcSize(200, 45))
+	assert.Equal(t, "13%", hermes.Button{BorderRadius: 3}.ArcSize(200, 45))
+	assert.Equal(t, "13%", hermes.Button{BorderRadius: 3}.ArcSize(45, 200))
+	assert.Equal(t, "100%", hermes.Button{BorderRadius: 30}.ArcSize(200, 45))
+	assert.Equal(t, "0%", hermes.Button{BorderRadius: 3}.ArcSize(0, 45))
+}
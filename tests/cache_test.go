@@ -0,0 +1,51 @@
+package hermes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateCache_ThemeInstanceChanges(t *testing.T) {
+	h, email := funcsExample("first {{ len .Email.Body.Intros }}", "")
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Equal(t, "first 1\n", r)
+	r, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Equal(t, "first 1\n", r)
+
+	// Same theme name, another instance
+	h.Theme = funcsTheme("second {{ len .Email.Body.Intros }}")
+	r, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Equal(t, "second 1\n", r, "Cached template of the previous instance should not be used")
+
+	text, err := h.GeneratePlainText(email)
+	assert.Nil(t, err)
+	assert.Equal(t, "second 1\n", text)
+}
+
+func BenchmarkGenerateHTML(b *testing.B) {
+	h, email := (&SimpleExample{}).getExample()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := h.GenerateHTML(email); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGenerateHTML_Parallel(b *testing.B) {
+	h, email := (&SimpleExample{}).getExample()
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := h.GenerateHTML(email); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,150 @@
+package hermes
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/send"
+)
+
+func calendarExample(t *testing.T) hermes.CalendarEvent {
+	paris, err := time.LoadLocation("Europe/Paris")
+	assert.Nil(t, err)
+	return hermes.CalendarEvent{
+		Summary:       "Scheduled maintenance",
+		Description:   "Services A, B; and C will be unavailable.\nSee the status page.",
+		Start:         time.Date(2024, 3, 2, 2, 0, 0, 0, paris),
+		End:           time.Date(2024, 3, 2, 3, 30, 0, 0, paris),
+		Location:      "Online",
+		URL:           "https://status.hermes-example.com/maintenance?id=42",
+		Organizer:     "status@hermes-example.com",
+		OrganizerName: `Hermes "Status"`,
+		Stamp:         time.Date(2024, 2, 20, 9, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestCalendarEvent(t *testing.T) {
+	event := calendarExample(t)
+	ics := event.String()
+	properties := readVCard(t, ics)
+	uid := properties["UID"][0]
+	assert.Equal(t, map[string][]string{
+		"BEGIN":                        {"VEVENT"},
+		"VERSION":                      {"2.0"},
+		"PRODID":                       {"-//Hermes//Hermes//EN"},
+		"CALSCALE":                     {"GREGORIAN"},
+		"METHOD":                       {"REQUEST"},
+		"UID":                          {uid},
+		"SEQUENCE":                     {"0"},
+		"DTSTAMP":                      {"20240220T090000Z"},
+		"DTSTART":                      {"20240302T010000Z"},
+		"DTEND":                        {"20240302T023000Z"},
+		"SUMMARY":                      {"Scheduled maintenance"},
+		"DESCRIPTION":                  {"Services A, B; and C will be unavailable.\nSee the status page."},
+		"LOCATION":                     {"Online"},
+		"URL":                          {"https://status.hermes-example.com/maintenance?id=42"},
+		`ORGANIZER;CN="Hermes Status"`: {"mailto:status@hermes-example.com"},
+		"END":                          {"VCALENDAR"},
+	}, properties)
+	assert.Contains(t, strings.ReplaceAll(ics, "\r\n ", ""), `DESCRIPTION:Services A\, B\; and C will be unavailable.\nSee the status page.`)
+
+	// Components are nested
+	var nesting []string
+	for _, line := range strings.Split(ics, "\r\n") {
+		if strings.HasPrefix(line, "BEGIN:") || strings.HasPrefix(line, "END:") {
+			nesting = append(nesting, line)
+		}
+	}
+	assert.Equal(t, []string{"BEGIN:VCALENDAR", "BEGIN:VEVENT", "END:VEVENT", "END:VCALENDAR"}, nesting)
+
+	// The UID is stable, so that updates replace the event
+	assert.True(t, strings.HasSuffix(uid, "@hermes"))
+	moved := event
+	moved.End = moved.End.Add(time.Hour)
+	moved.Sequence = 1
+	assert.Equal(t, []string{uid}, readVCard(t, moved.String())["UID"])
+	assert.Equal(t, []string{"1"}, readVCard(t, moved.String())["SEQUENCE"])
+	event.UID = "maintenance-42@hermes-example.com"
+	assert.Equal(t, []string{"maintenance-42@hermes-example.com"}, readVCard(t, event.String())["UID"])
+
+	// Optional fields are left out
+	minimal := hermes.CalendarEvent{Summary: "Launch", Start: event.Start, UID: "launch", Stamp: event.Stamp}
+	assert.Equal(t, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Hermes//Hermes//EN\r\nCALSCALE:GREGORIAN\r\nMETHOD:REQUEST\r\n"+
+		"BEGIN:VEVENT\r\nUID:launch\r\nSEQUENCE:0\r\nDTSTAMP:20240220T090000Z\r\nDTSTART:20240302T010000Z\r\nSUMMARY:Launch\r\n"+
+		"END:VEVENT\r\nEND:VCALENDAR\r\n", minimal.String())
+}
+
+func TestCalendarEvent_Folding(t *testing.T) {
+	event := calendarExample(t)
+	event.Description = strings.Repeat("Fenêtre de maintenance, ", 12)
+	properties := readVCard(t, event.String())
+	assert.Equal(t, []string{event.Description}, properties["DESCRIPTION"], "Characters should not be split by folding")
+}
+
+func TestCalendarEvent_Attachment(t *testing.T) {
+	event := calendarExample(t)
+	a := event.Attachment()
+	assert.Equal(t, "invite.ics", a.Filename)
+	assert.Equal(t, event.String(), string(a.Data))
+
+	email := hermes.Email{Body: hermes.Body{CalendarEvent: &event}, Attachments: []hermes.Attachment{invoice}}
+	m := send.BuildMessage(email, "<p>Maintenance</p>", "Maintenance")
+	m.From, m.To = "Hermes <hello@hermes-example.com>", []string{"jon@snow.com"}
+	assert.Len(t, email.Attachments, 1, "The attachments of the email should not be modified")
+	assert.Equal(t, []string{
+		"multipart/mixed",
+		"  multipart/alternative",
+		"    text/plain",
+		"    text/html",
+		"  application/pdf attachment; filename=invoice.pdf  " + strings.Repeat("%PDF", 40),
+		"  text/calendar attachment; filename=invite.ics  " + event.String(),
+	}, messageTree(t, m))
+
+	raw, err := m.Bytes()
+	assert.Nil(t, err)
+	assert.Contains(t, string(raw), "Content-Type: text/calendar; charset=utf-8; method=REQUEST; name=invite.ics")
+	// Content types with parameters are kept
+	m.Attachments = []hermes.Attachment{hermes.VCard{Name: "Hermes Support"}.Attachment()}
+	raw, err = m.Bytes()
+	assert.Nil(t, err)
+	assert.Contains(t, string(raw), "Content-Type: text/vcard; charset=utf-8; name=Hermes-Support.vcf")
+}
+
+func TestCalendarEvent_QuickAddLinks(t *testing.T) {
+	event := calendarExample(t)
+	google, err := url.Parse(event.GoogleCalendarURL())
+	assert.Nil(t, err)
+	assert.Equal(t, "calendar.google.com", google.Host)
+	assert.Equal(t, "Scheduled maintenance", google.Query().Get("text"))
+	assert.Equal(t, "20240302T010000Z/20240302T023000Z", google.Query().Get("dates"))
+	assert.Equal(t, event.Description, google.Query().Get("details"))
+	outlook, err := url.Parse(event.OutlookURL())
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-03-02T01:00:00Z", outlook.Query().Get("startdt"))
+	assert.Equal(t, "2024-03-02T02:30:00Z", outlook.Query().Get("enddt"))
+	assert.Equal(t, "Online", outlook.Query().Get("location"))
+
+	for _, theme := range testedThemes {
+		h, email := (&SimpleExample{theme}).getExample()
+		email.Body.CalendarEvent = &event
+		r, err := h.GenerateHTML(email)
+		assert.Nil(t, err)
+		assert.NotContains(t, r, `data-hermes="calendar"`, "Links should be opt-in")
+
+		event.QuickAddLinks = true
+		h.Locale = "fr"
+		r, err = h.GenerateHTML(email)
+		assert.Nil(t, err)
+		assert.Contains(t, r, `data-hermes="calendar">Ajouter au calendrier`, theme.Name())
+		assert.Contains(t, r, `href="https://calendar.google.com/calendar/render?action=TEMPLATE&amp;dates=20240302T010000Z%2F20240302T023000Z`, theme.Name())
+		assert.Contains(t, r, `href="https://outlook.live.com/calendar/0/deeplink/compose?`, theme.Name())
+		text, err := h.GeneratePlainText(email)
+		assert.Nil(t, err)
+		assert.Contains(t, text, "Google Calendar: https://calendar.google.com/calendar/render?", theme.Name())
+		event.QuickAddLinks = false
+	}
+}
@@ -0,0 +1,158 @@
+package hermes
+
+import (
+	"bytes"
+	"errors"
+	"net/mail"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/examples/mails"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+// buildCLI builds the hermes command in a temporary directory and returns a function running it, which returns its exit
+// status, stdout and stderr
+func buildCLI(t *testing.T) func(args ...string) (int, string, string) {
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found:", err)
+	}
+	bin := filepath.Join(t.TempDir(), "hermes")
+	if out, err := exec.Command(goTool, "build", "-o", bin, "github.com/unknowns24/hermes/cmd/hermes").CombinedOutput(); err != nil {
+		t.Fatalf("build hermes: %v\n%s", err, out)
+	}
+	return func(args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		cmd := exec.Command(bin, args...)
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			return exit.ExitCode(), stdout.String(), stderr.String()
+		}
+		assert.Nil(t, err)
+		return 0, stdout.String(), stderr.String()
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	assert.Nil(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestCLI_Render(t *testing.T) {
+	run := buildCLI(t)
+	dir := t.TempDir()
+	brand := writeFile(t, dir, "brand.yaml", "name: Hermes\nlink: https://example-hermes.com/\ncopyright: Copyright © 2024 Hermes.\n")
+	h := hermes.Hermes{Theme: new(themes.Flat), Brand: hermes.Branding{Name: "Hermes", Link: "https://example-hermes.com/", Copyright: "Copyright © 2024 Hermes."}}
+	email := (&mails.Receipt{}).Email()
+
+	t.Run("html", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "out")
+		status, _, stderr := run("render", "--input", "testdata/load/receipt.yaml", "--theme", "flat", "--brand", brand, "--out", out)
+		assert.Equal(t, 0, status, stderr)
+		assert.Empty(t, stderr)
+		html, text, err := h.Generate(email)
+		assert.Nil(t, err)
+		got, err := os.ReadFile(filepath.Join(out, "receipt.html"))
+		assert.Nil(t, err)
+		assert.Equal(t, html, string(got))
+		got, err = os.ReadFile(filepath.Join(out, "receipt.txt"))
+		assert.Nil(t, err)
+		assert.Equal(t, text, string(got))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		input := writeFile(t, dir, "welcome.json", `{"body": {"name": "Jon Snow", "intros": ["Welcome to Hermes!"]}}`)
+		status, _, stderr := run("render", "--input", input, "--locale", "fr", "--out", dir)
+		assert.Equal(t, 0, status, stderr)
+		text, err := os.ReadFile(filepath.Join(dir, "welcome.txt"))
+		assert.Nil(t, err)
+		assert.Contains(t, string(text), "Bonjour Jon Snow,")
+	})
+
+	t.Run("eml", func(t *testing.T) {
+		out := t.TempDir()
+		status, _, stderr := run("render", "--input", "testdata/load/receipt.yaml", "--brand", brand, "--out", out,
+			"--format", "eml", "--from", "Hermes <hello@hermes-example.com>", "--to", "Jon Snow <jon@snow.com>, arya@stark.com")
+		assert.Equal(t, 0, status, stderr)
+		raw, err := os.ReadFile(filepath.Join(out, "receipt.eml"))
+		assert.Nil(t, err)
+		msg, err := mail.ReadMessage(bytes.NewReader(raw))
+		assert.Nil(t, err)
+		assert.Equal(t, `"Hermes" <hello@hermes-example.com>`, msg.Header.Get("From"))
+		assert.Equal(t, `"Jon Snow" <jon@snow.com>, <arya@stark.com>`, msg.Header.Get("To"))
+		assert.Equal(t, "Your Hermes receipt", msg.Header.Get("Subject"))
+		assert.Contains(t, msg.Header.Get("Content-Type"), "multipart/alternative")
+		_, err = os.Stat(filepath.Join(out, "receipt.html"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("brand env", func(t *testing.T) {
+		t.Setenv("HERMES_TEST_BRAND_NAME", "Hermes")
+		t.Setenv("HERMES_TEST_BRAND_LINK", "https://example-hermes.com/")
+		t.Setenv("HERMES_TEST_BRAND_COPYRIGHT", "Copyright © 2024 Hermes.")
+		out := t.TempDir()
+		status, _, stderr := run("render", "--input", "testdata/load/receipt.yaml", "--theme", "flat", "--brand-env", "HERMES_TEST_BRAND", "--out", out)
+		assert.Equal(t, 0, status, stderr)
+		html, _, err := h.Generate(email)
+		assert.Nil(t, err)
+		got, err := os.ReadFile(filepath.Join(out, "receipt.html"))
+		assert.Nil(t, err)
+		assert.Equal(t, html, string(got))
+	})
+
+	t.Run("theme directory", func(t *testing.T) {
+		out := t.TempDir()
+		status, _, stderr := run("render", "--input", "testdata/load/receipt.yaml", "--theme", "testdata/filetheme", "--out", out)
+		assert.Equal(t, 0, status, stderr)
+		html, err := os.ReadFile(filepath.Join(out, "receipt.html"))
+		assert.Nil(t, err)
+		assert.Contains(t, string(html), "<h1>Hi Jon Snow,</h1>")
+	})
+}
+
+func TestCLI_RenderErrors(t *testing.T) {
+	run := buildCLI(t)
+	dir := t.TempDir()
+	// Templates which parse, but fail when executed
+	failing := t.TempDir()
+	writeFile(t, failing, "theme.html", "{{ .Email.Body.Missing }}")
+	writeFile(t, failing, "theme.txt", "{{ .Email.Body.Missing }}")
+	tests := []struct {
+		name   string
+		args   []string
+		status int
+		stderr string
+	}{
+		{"usage", []string{"render"}, 2, "usage: "},
+		{"format", []string{"render", "--input", "testdata/load/receipt.yaml", "--format", "pdf"}, 2, "usage: "},
+		{"from", []string{"render", "--input", "testdata/load/receipt.yaml", "--format", "eml"}, 2, "hermes: --from: "},
+		{"missing input", []string{"render", "--input", filepath.Join(dir, "missing.yaml")}, 3, "hermes: parse: open "},
+		{"yaml", []string{"render", "--input", writeFile(t, dir, "broken.yaml", "body: [")}, 3, "hermes: parse: " + filepath.Join(dir, "broken.yaml") + ": load email: yaml: "},
+		{"json", []string{"render", "--input", writeFile(t, dir, "broken.json", `{"body": `)}, 3, "hermes: parse: " + filepath.Join(dir, "broken.json") + ": load email: "},
+		{"strict", []string{"render", "--strict", "--input", writeFile(t, dir, "unknown.yaml", "body:\n  nmae: Jon\n")}, 3, "field nmae not found"},
+		{"brand", []string{"render", "--input", "testdata/load/receipt.yaml", "--brand", writeFile(t, dir, "brand.yaml", "name: [")}, 3, "hermes: parse: "},
+		{"theme", []string{"render", "--input", "testdata/load/receipt.yaml", "--theme", "unknown"}, 3, `hermes: parse: unknown theme "unknown"`},
+		{"theme templates", []string{"render", "--input", "testdata/load/receipt.yaml", "--theme", dir}, 3, "hermes: parse: theme "},
+		{"validation", []string{"render", "--input", writeFile(t, dir, "invalid.yaml", "body:\n  actions:\n    - button:\n        text: Go\n        link: /relative\n")},
+			4, "hermes: validation: Body.Actions[0].Button.Link: must be an absolute URL\n"},
+		{"brand validation", []string{"render", "--input", "testdata/load/receipt.yaml", "--brand", writeFile(t, dir, "logo.yaml", "logo: logo.png\n")},
+			4, "hermes: validation: logo: must be an absolute https URL"},
+		{"brand env", []string{"render", "--input", "testdata/load/receipt.yaml", "--brand-env", "HERMES_TEST_MISSING_BRAND"},
+			4, "hermes: validation: HERMES_TEST_MISSING_BRAND_NAME: empty name\n"},
+		{"brand and brand env", []string{"render", "--input", "testdata/load/receipt.yaml", "--brand", "brand.yaml", "--brand-env", "HERMES_BRAND"}, 2, "usage: "},
+		{"render", []string{"render", "--input", "testdata/load/receipt.yaml", "--theme", failing}, 5, "hermes: render: "},
+	}
+	for _, test := range tests {
+		status, _, stderr := run(test.args...)
+		assert.Equal(t, test.status, status, "%s: %s", test.name, stderr)
+		assert.Contains(t, stderr, test.stderr, test.name)
+	}
+}
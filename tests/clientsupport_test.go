@@ -0,0 +1,86 @@
+package hermes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+func TestCheckClientSupport_InlineStyles(t *testing.T) {
+	html := `<html><body>
+	<div class="layout" style="display: flex; color: red">
+		<a href="#" style="padding: 10px; border-radius: 3px !important">Click</a>
+	</div>
+	</body></html>`
+
+	issues := hermes.CheckClientSupport(html, []string{hermes.ClientOutlook, hermes.ClientAppleMail})
+
+	assert.Equal(t, []hermes.SupportIssue{
+		{
+			Client:   hermes.ClientOutlook,
+			Property: "padding",
+			Value:    "10px",
+			Path:     "html > body > div.layout > a",
+			Severity: hermes.SeverityError,
+			Message:  `outlook does not render correctly "padding", put the padding on a table cell`,
+		},
+		{
+			Client:   hermes.ClientOutlook,
+			Property: "display",
+			Value:    "flex",
+			Path:     "html > body > div.layout",
+			Severity: hermes.SeverityWarning,
+			Message:  `outlook ignores "display: flex", use tables for layout`,
+		},
+		{
+			Client:   hermes.ClientOutlook,
+			Property: "border-radius",
+			Value:    "3px",
+			Path:     "html > body > div.layout > a",
+			Severity: hermes.SeverityWarning,
+			Message:  `outlook ignores "border-radius", corners will be square in Outlook`,
+		},
+	}, issues)
+}
+
+func TestCheckClientSupport_StyleBlocks(t *testing.T) {
+	html := `<html><head><style>
+	/* position: absolute; in a comment is not checked */
+	@import url("https://fonts.example.com/font.css");
+	@font-face { font-family: "Brand"; src: url("https://fonts.example.com/brand.woff2"); }
+	.hero { background-image: url("hero.png"); }
+	@media only screen and (max-width: 600px) {
+		.grid { display: grid; }
+	}
+	</style></head><body></body></html>`
+
+	issues := hermes.CheckClientSupport(html, []string{hermes.ClientGmail})
+
+	var found []string
+	for _, issue := range issues {
+		found = append(found, issue.Path+" "+issue.Property)
+	}
+	assert.ElementsMatch(t, []string{
+		`style: @import url("https://fonts.example.com/font.css") @import`,
+		`style: @font-face @font-face`,
+		`style: @media only screen and (max-width: 600px) .grid display`,
+	}, found)
+}
+
+func TestCheckClientSupport_DefaultTheme(t *testing.T) {
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+	h.DisableCSSInlining = false
+	html, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+
+	assert.Empty(t, hermes.CheckClientSupport(html, []string{hermes.ClientAppleMail}), "Apple Mail supports everything used by the default theme")
+
+	issues := hermes.CheckClientSupport(html, []string{hermes.ClientOutlook})
+	assert.NotEmpty(t, issues, "Outlook ignores media queries and rounded corners")
+	for _, issue := range issues {
+		assert.Equal(t, hermes.ClientOutlook, issue.Client)
+		assert.NotEmpty(t, issue.Path)
+	}
+}
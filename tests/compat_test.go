@@ -0,0 +1,141 @@
+package hermes
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/examples/mails"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+// compatExample uses every element of the first release
+func compatExample() (hermes.Hermes, hermes.Email) {
+	h := hermes.Hermes{
+		Brand: hermes.Branding{
+			Name: "Hermes",
+			Link: "https://example-hermes.com/",
+		},
+	}
+	email := hermes.Email{
+		Body: hermes.Body{
+			Name:   "Jon Snow",
+			Intros: []string{"Your order has been processed successfully."},
+			Dictionary: []hermes.Entry{
+				{Key: "Firstname", Value: "Jon"},
+				{Key: "Birthday", Value: "01/01/283"},
+			},
+			Table: hermes.Table{
+				Data: [][]hermes.Entry{
+					{{Key: "Item", Value: "Golang"}, {Key: "Price", Value: "$10.99"}},
+					{{Key: "Item", Value: "Hermes"}, {Key: "Price", Value: "$1.99"}},
+				},
+				Columns: hermes.Columns{
+					CustomWidth:     map[string]string{"Item": "20%"},
+					CustomAlignment: map[string]string{"Price": "right"},
+				},
+			},
+			Actions: []hermes.Action{
+				{
+					Instructions: "You can check the status of your order in your dashboard:",
+					Button:       hermes.Button{Text: "Go to Dashboard", Link: "https://hermes-example.com/dashboard"},
+				},
+				{
+					Instructions: "Your invite code:",
+					InviteCode:   "123456",
+				},
+			},
+			Outros: []string{"We thank you for your purchase."},
+		},
+	}
+	return h, email
+}
+
+func TestCompatLevel1_Golden(t *testing.T) {
+	h, email := compatExample()
+	h.CompatLevel = hermes.CompatLevel1
+
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	golden, err := os.ReadFile("testdata/compat/level1.html")
+	assert.Nil(t, err)
+	assert.Equal(t, string(golden), r)
+
+	text, err := h.GeneratePlainText(email)
+	assert.Nil(t, err)
+	golden, err = os.ReadFile("testdata/compat/level1.txt")
+	assert.Nil(t, err)
+	assert.Equal(t, string(golden), text)
+}
+
+func TestCompatLevel1_Strings(t *testing.T) {
+	h := hermes.Hermes{CompatLevel: hermes.CompatLevel1}
+	assert.Nil(t, h.SetDefaultHermesValues())
+	assert.Equal(t, hermes.Branding{
+		Name:        "Hermes",
+		Copyright:   "Copyright © 2024 Hermes. All rights reserved.",
+		TroubleText: "If you’re having trouble with the button '{ACTION}', copy and paste the URL below into your web browser.",
+	}, h.Brand, "The default strings of level 1 are the ones of the first release")
+
+	text, err := h.GeneratePlainText(hermes.Email{Body: hermes.Body{Name: "Jon Snow"}})
+	assert.Nil(t, err)
+	assert.Contains(t, text, "Hi Jon Snow,")
+	assert.Contains(t, text, "Yours truly,")
+}
+
+func TestCompatLatest_IsDefault(t *testing.T) {
+	h, email := compatExample()
+	latest, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+
+	h, _ = compatExample()
+	h.CompatLevel = hermes.CompatLatest
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Equal(t, latest, r, "Level 0 always means latest")
+
+	h, _ = compatExample()
+	h.CompatLevel = hermes.CompatLevel1
+	r, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.NotEqual(t, latest, r)
+}
+
+func TestCompatLevel_Unknown(t *testing.T) {
+	h, email := compatExample()
+	h.CompatLevel = 99
+	_, err := h.GenerateHTML(email)
+	assert.EqualError(t, err, "unknown compat level 99")
+}
+
+// TestCompatLevel1_Baseline checks that the examples render at level 1 to the bytes of the first release, in
+// testdata/compat/baseline
+func TestCompatLevel1_Baseline(t *testing.T) {
+	h := hermes.Hermes{
+		Theme:       new(themes.Default),
+		CompatLevel: hermes.CompatLevel1,
+		Brand: hermes.Branding{
+			Name: "Hermes",
+			Link: "https://example-hermes.com/",
+			Logo: "https://github.com/matcornic/hermes/blob/master/examples/gopher.png?raw=true",
+		},
+	}
+	for _, example := range []mails.Example{new(mails.Welcome), new(mails.Reset), new(mails.Maintenance), new(mails.Receipt), new(mails.InviteCode)} {
+		email := example.Email()
+		if example.Name() == "reset" {
+			email.Body.Actions[0].Button.Color = "#DC4D2F" // Color of the example in the first release, darkened since
+		}
+		r, err := h.GenerateHTML(email)
+		assert.Nil(t, err)
+		golden, err := os.ReadFile("testdata/compat/baseline/" + example.Name() + ".html")
+		assert.Nil(t, err)
+		assert.Equal(t, string(golden), r, example.Name())
+
+		text, err := h.GeneratePlainText(email)
+		assert.Nil(t, err)
+		golden, err = os.ReadFile("testdata/compat/baseline/" + example.Name() + ".txt")
+		assert.Nil(t, err)
+		assert.Equal(t, string(golden), text, example.Name())
+	}
+}
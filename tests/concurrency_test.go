@@ -0,0 +1,35 @@
+package hermes
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Run with -race: a single engine is shared by all the goroutines
+func TestHermes_ConcurrentGenerate(t *testing.T) {
+	h, email := (&SimpleExample{}).getExample()
+	original := h
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 200)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := h.GenerateHTML(email); err != nil {
+				errs <- err
+			}
+			if _, err := h.GeneratePlainText(email); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, original, h, "Generating emails should not modify the engine")
+}
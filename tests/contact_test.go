@@ -0,0 +1,60 @@
+package hermes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+func TestContactInstructions(t *testing.T) {
+	h, email := (&SimpleExample{}).getExample()
+	email.Body.ContactInstructions = &hermes.ContactInstructions{
+		Email: "support@hermes-example.com",
+		URL:   "https://hermes-example.com/help",
+	}
+
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, r, `<p class="sub" data-hermes="contact">`)
+	assert.Contains(t, r, "Questions? Contact us:")
+	assert.Contains(t, r, `Email: <a href="mailto:support@hermes-example.com">support@hermes-example.com</a>`)
+	assert.Contains(t, r, `Help center: <a href="https://hermes-example.com/help">https://hermes-example.com/help</a>`)
+
+	text, err := h.GeneratePlainText(email)
+	assert.Nil(t, err)
+	assert.Contains(t, text, "Email: support@hermes-example.com")
+	assert.Contains(t, text, "Help center: https://hermes-example.com/help")
+
+	parsed, _, err := hermes.ParseEmail(r)
+	assert.Nil(t, err)
+	assert.Equal(t, email.Body.ContactInstructions, parsed.Body.ContactInstructions)
+}
+
+func TestContactInstructions_Localized(t *testing.T) {
+	h, email := (&SimpleExample{}).getExample()
+	h.Locale = "fr-FR"
+	email.Body.ContactInstructions = &hermes.ContactInstructions{
+		Text:  "Une question ?",
+		Email: "support@hermes-example.com",
+	}
+
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, r, `<span data-hermes="contact-text">Une question ?</span>`)
+	assert.Contains(t, r, `E-mail: <a href="mailto:support@hermes-example.com">`)
+	assert.NotContains(t, r, "Centre d&#39;aide")
+}
+
+func TestContactInstructions_Empty(t *testing.T) {
+	h, email := (&SimpleExample{}).getExample()
+	email.Body.ContactInstructions = &hermes.ContactInstructions{}
+
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.NotContains(t, r, `data-hermes="contact"`)
+
+	text, err := h.GeneratePlainText(email)
+	assert.Nil(t, err)
+	assert.NotContains(t, text, "Contact us")
+}
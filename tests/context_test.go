@@ -0,0 +1,88 @@
+package hermes
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+// hugeEmail is an email of which the CSS inlining takes seconds
+func hugeEmail() hermes.Email {
+	rows := make([][]hermes.Entry, 20000)
+	for i := range rows {
+		rows[i] = []hermes.Entry{
+			{Key: "Item", Value: fmt.Sprintf("Item %d", i)},
+			{Key: "Description", Value: "A long description of the item, to make the email bigger"},
+			{Key: "Price", Value: fmt.Sprintf("$%d.99", i)},
+		}
+	}
+	return hermes.Email{Body: hermes.Body{Name: "Jon Snow", Table: hermes.Table{Data: rows}}}
+}
+
+func TestGenerateContext_Canceled(t *testing.T) {
+	h := hermes.Hermes{Theme: new(themes.Default)}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := h.GenerateHTMLContext(ctx, hermes.Email{Body: hermes.Body{Name: "Jon Snow"}})
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = h.GeneratePlainTextContext(ctx, hermes.Email{Body: hermes.Body{Name: "Jon Snow"}})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	html, err := h.GenerateHTMLContext(context.Background(), hermes.Email{Body: hermes.Body{Name: "Jon Snow"}})
+	assert.Nil(t, err)
+	expected, err := h.GenerateHTML(hermes.Email{Body: hermes.Body{Name: "Jon Snow"}})
+	assert.Nil(t, err)
+	assert.Equal(t, expected, html)
+}
+
+func TestGenerateContext_Deadline(t *testing.T) {
+	h := hermes.Hermes{Theme: new(themes.Default)}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := h.GenerateHTMLContext(ctx, hugeEmail())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+
+	// The context is done while the CSS is inlined
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	p := hermes.DefaultPipeline()
+	assert.Nil(t, p.InsertBefore(hermes.StageInline, hermes.Stage{Name: "Timer", Run: func(r *hermes.Rendering) error {
+		start = time.Now()
+		time.AfterFunc(50*time.Millisecond, cancel)
+		return nil
+	}}))
+	h.Pipeline = p
+	_, err = h.GenerateHTMLContext(ctx, hugeEmail())
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "CSS inlining should be abandoned once the context is done")
+}
+
+func TestGenerateContext_BetweenStages(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var ran []string
+	p := hermes.DefaultPipeline()
+	assert.Nil(t, p.InsertAfter(hermes.StageTemplateExecute, hermes.Stage{Name: "Cancel", Run: func(r *hermes.Rendering) error {
+		ran = append(ran, "Cancel")
+		cancel()
+		return nil
+	}}))
+	assert.Nil(t, p.InsertBefore(hermes.StageInline, hermes.Stage{Name: "Record", Run: func(r *hermes.Rendering) error {
+		ran = append(ran, "Record")
+		return nil
+	}}))
+	h := hermes.Hermes{Theme: new(themes.Default), Pipeline: p}
+
+	_, err := h.GenerateHTMLContext(ctx, hermes.Email{Body: hermes.Body{Name: "Jon Snow"}})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []string{"Cancel"}, ran, "The stages after the context is done should not run")
+}
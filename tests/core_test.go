@@ -0,0 +1,85 @@
+package hermes
+
+import (
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/pkg/core"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+// The types moved to core keep their identity under their former names: these do not compile otherwise
+var (
+	_ core.Theme    = hermes.Theme(nil)
+	_ hermes.Theme  = themes.Theme(nil)
+	_ themes.Theme  = core.Theme(nil)
+	_ *core.Theme   = (*hermes.Theme)(nil)
+	_ *themes.Theme = (*hermes.Theme)(nil)
+
+	_ *core.DarkModeColors              = (*hermes.DarkModeColors)(nil)
+	_ hermes.DarkModeColors             = core.DefaultDarkModeColors
+	_ []core.DarkModeColors             = []hermes.DarkModeColors{hermes.DefaultDarkModeColors}
+	_ func(hermes.Theme)                = themes.Register
+	_ func(core.Theme)                  = themes.Register
+	_ func() []string                   = themes.Names
+	_ func(string) (hermes.Theme, bool) = themes.Lookup
+)
+
+// consumerTheme is a theme written against the former packages
+type consumerTheme struct{ themes.Default }
+
+func (consumerTheme) Name() string { return "consumer" }
+
+func TestCore_FormerNames(t *testing.T) {
+	// Code written before core keeps compiling and running
+	var theme hermes.Theme = new(themes.Flat)
+	h := hermes.Hermes{
+		Theme:    theme,
+		DarkMode: hermes.DarkModeColors{Background: "#000000"},
+	}
+	_, email := (&SimpleExample{}).getExample()
+	_, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+
+	h.Theme = &consumerTheme{}
+	_, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+
+	found, ok := themes.Lookup("flat")
+	assert.True(t, ok)
+	registered, _ := core.LookupTheme("flat")
+	assert.Equal(t, registered, found)
+	assert.Equal(t, core.ThemeNames(), themes.Names())
+}
+
+func TestCore_DefaultTheme(t *testing.T) {
+	h := hermes.Hermes{}
+	assert.Nil(t, h.SetDefaultHermesValues())
+	theme, ok := core.LookupTheme(core.DefaultThemeName)
+	assert.True(t, ok)
+	assert.Equal(t, theme, h.Theme)
+	assert.Equal(t, new(themes.Default), h.Theme)
+	assert.Equal(t, core.DefaultDarkModeColors, h.DarkMode)
+}
+
+func TestCore_NoImportCycle(t *testing.T) {
+	// Themes and core may import core, never the engine which imports them
+	for _, dir := range []string{"../pkg/core", "../pkg/themes"} {
+		files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		assert.Nil(t, err)
+		assert.NotEmpty(t, files)
+		for _, file := range files {
+			f, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.ImportsOnly)
+			assert.Nil(t, err)
+			for _, spec := range f.Imports {
+				path, _ := strconv.Unquote(spec.Path.Value)
+				assert.NotEqual(t, "github.com/unknowns24/hermes/pkg/mails", path, file)
+			}
+		}
+	}
+}
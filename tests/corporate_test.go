@@ -0,0 +1,103 @@
+package hermes
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/examples/mails"
+	"github.com/unknowns24/hermes/pkg/hermestest"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+var update = flag.Bool("update", false, "update the golden files of the tests")
+
+// assertGolden compares the output to the golden file in testdata line by line, writing it instead with -update
+func assertGolden(t *testing.T, path string, output string) {
+	t.Helper()
+	hermestest.AssertGolden(t, filepath.Join("testdata", path), output)
+}
+
+func TestCorporate_Golden(t *testing.T) {
+	examples := []interface {
+		Email() hermes.Email
+		Name() string
+	}{
+		new(mails.Welcome),
+		new(mails.Reset),
+		new(mails.Maintenance),
+		new(mails.Receipt),
+		new(mails.InviteCode),
+	}
+	for _, direction := range []hermes.TextDirection{"ltr", "rtl"} {
+		for _, example := range examples {
+			h := hermes.Hermes{
+				Theme: new(themes.Corporate),
+				Brand: hermes.Branding{
+					Name: "Hermes",
+					Link: "https://example-hermes.com/",
+					Logo: "https://github.com/matcornic/hermes/blob/master/examples/gopher.png?raw=true",
+				},
+				TextDirection: direction,
+			}
+			name := fmt.Sprintf("corporate/%s.%s", example.Name(), direction)
+
+			r, err := h.GenerateHTML(example.Email())
+			assert.Nil(t, err)
+			assertGolden(t, name+".html", r)
+
+			text, err := h.GeneratePlainText(example.Email())
+			assert.Nil(t, err)
+			assertGolden(t, name+".txt", text)
+		}
+	}
+}
+
+func TestCorporate_Style(t *testing.T) {
+	h, email := (&SimpleExample{new(themes.Corporate)}).getExample()
+	h.DisableCSSInlining = false
+	email.Body.Actions = append(email.Body.Actions, hermes.Action{
+		Button: hermes.Button{Text: "Default color", Link: "https://hermes-example.com/"},
+	})
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, r, "background-color:#22BC66", "Button colors should be honored")
+	assert.Contains(t, r, "background-color:#4A4A4A", "Buttons should be gray by default")
+	assert.Contains(t, r, "border-radius:0", "Buttons should not have rounded corners")
+	assert.Contains(t, r, `width="640"`)
+	assert.Contains(t, r, "-apple-system")
+	assert.NotContains(t, r, "roundrect")
+
+	h.TextDirection = "rtl"
+	r, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, r, "text-align:right", "Logo should be on the start side")
+}
+
+func TestCorporate_Parse(t *testing.T) {
+	h, email := (&SimpleExample{new(themes.Corporate)}).getExample()
+	h.DisableCSSInlining = false
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+
+	parsed, _, err := hermes.ParseEmail(r)
+	assert.Nil(t, err)
+	assert.Equal(t, email.Body.Intros, parsed.Body.Intros)
+	assert.Equal(t, email.Body.Dictionary, parsed.Body.Dictionary)
+	assert.Equal(t, email.Body.Table.Data, parsed.Body.Table.Data)
+	assert.Equal(t, email.Body.Actions[0].Button, parsed.Body.Actions[0].Button)
+}
+
+func TestThemeRegistry(t *testing.T) {
+	assert.Equal(t, []string{"corporate", "default", "flat"}, themes.Names())
+	theme, ok := themes.Lookup("corporate")
+	assert.True(t, ok)
+	assert.Equal(t, new(themes.Corporate), theme)
+
+	var _ hermes.Theme = theme
+	_, ok = themes.Lookup("unknown")
+	assert.False(t, ok)
+}
@@ -0,0 +1,164 @@
+package hermes
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+// brokenCSS is a typo (an empty pseudo-class) that makes premailer panic
+const brokenCSS = ".button:{color: red}"
+
+// cssTheme is a theme with a single style element, and the custom CSS
+type cssTheme struct {
+	css string
+}
+
+func (t cssTheme) Name() string {
+	return "css"
+}
+
+func (t cssTheme) HTMLTemplate() string {
+	return `<html><head><style>` + t.css + `</style>{{ with .Hermes.CustomCSS }}<style data-hermes-css="custom">{{ css . }}</style>{{ end }}</head><body><p class="button">{{ .Email.Body.Name }}</p></body></html>`
+}
+
+func (t cssTheme) PlainTextTemplate() string {
+	return `{{ .Email.Body.Name }}`
+}
+
+func TestCustomCSS(t *testing.T) {
+	for _, theme := range testedThemes {
+		h, email := (&SimpleExample{theme}).getExample()
+		h.CustomCSS = ".email-footer { letter-spacing: 2px; }"
+		h.DisableCSSInlining = true
+		r, err := h.GenerateHTML(email)
+		assert.Nil(t, err)
+		assert.Contains(t, r, `<style type="text/css" rel="stylesheet" media="all" data-hermes-css="custom">.email-footer { letter-spacing: 2px; }</style>`, theme.Name())
+
+		h.DisableCSSInlining = false
+		r, err = h.GenerateHTML(email)
+		assert.Nil(t, err)
+		assert.Regexp(t, `class="email-footer"[^>]*style="[^"]*letter-spacing:2px`, r, theme.Name())
+	}
+}
+
+func TestCSSInline_BrokenCustomCSS(t *testing.T) {
+	h, email := (&SimpleExample{}).getExample()
+	h.DisableCSSInlining = false
+	h.CustomCSS = ".button { color: #FF0000; }\n" + brokenCSS + "\n.email-footer { color: #00FF00; }"
+	_, err := h.GenerateHTML(email)
+	var inlineErr hermes.ErrCSSInline
+	if assert.True(t, errors.As(err, &inlineErr), "%v", err) {
+		assert.Equal(t, hermes.CSSSourceCustom, inlineErr.Source)
+		assert.Equal(t, brokenCSS, inlineErr.Rule)
+		assert.Contains(t, inlineErr.Err.Error(), "premailer")
+		assert.Contains(t, err.Error(), `inline CSS of custom styles at rule ".button:{color: red}": premailer: `)
+	}
+
+	// The plain text version does not inline CSS
+	_, err = h.GeneratePlainText(email)
+	assert.Nil(t, err)
+	h.DisableCSSInlining = true
+	_, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+}
+
+func TestCSSInline_SingleSource(t *testing.T) {
+	h, email := (&SimpleExample{cssTheme{"p { margin: 0 }\n" + brokenCSS}}).getExample()
+	h.DisableCSSInlining = false
+	_, err := h.GenerateHTML(email)
+	var inlineErr hermes.ErrCSSInline
+	if assert.True(t, errors.As(err, &inlineErr), "%v", err) {
+		assert.Equal(t, hermes.CSSSourceTheme, inlineErr.Source)
+		assert.Equal(t, brokenCSS, inlineErr.Rule)
+	}
+}
+
+func TestCSSInline_SeveralCulprits(t *testing.T) {
+	// Removing a single source does not fix the inlining, the culprit is unknown
+	h, email := (&SimpleExample{cssTheme{brokenCSS}}).getExample()
+	h.DisableCSSInlining = false
+	h.CustomCSS = brokenCSS
+	_, err := h.GenerateHTML(email)
+	var inlineErr hermes.ErrCSSInline
+	if assert.True(t, errors.As(err, &inlineErr), "%v", err) {
+		assert.Equal(t, "", inlineErr.Source)
+		assert.Equal(t, "", inlineErr.Rule)
+		assert.Regexp(t, `^inline CSS: premailer: `, err.Error())
+	}
+}
+
+// severalStylesTheme has several style elements, some of which premailer does not inline
+type severalStylesTheme struct {
+	cssTheme
+}
+
+func (t severalStylesTheme) HTMLTemplate() string {
+	return `<html><head><style>p { margin: 0 }</style><style media="print">p { color: black }</style>` +
+		`<style data-premailer="ignore">.button:hover { color: blue }</style><!--[if mso]><style>p { padding: 1px }</style><![endif]-->` +
+		`{{ with .Hermes.CustomCSS }}<style data-hermes-css="custom">{{ css . }}</style>{{ end }}</head>` +
+		`<body><style>.button { font-weight: bold }</style><p class="button">{{ .Email.Body.Name }}</p></body></html>`
+}
+
+// TestCSSInline_SeveralStyles inlines the style elements as one, premailer racing on several (see go test -race)
+func TestCSSInline_SeveralStyles(t *testing.T) {
+	h := hermes.Hermes{Theme: severalStylesTheme{}, CustomCSS: ".button { color: red }"}
+	r, err := h.GenerateHTML(hermes.Email{Body: hermes.Body{Name: "Jon"}})
+	assert.Nil(t, err)
+	assert.Contains(t, r, `<p class="button" style="margin:0;color:red;font-weight:bold">`)
+	assert.Contains(t, r, `<style media="print">p { color: black }</style>`)
+	assert.Contains(t, r, `<style data-premailer="ignore">.button:hover { color: blue }</style>`)
+	assert.NotContains(t, r, "padding:1px", "Styles in comments should not be merged")
+}
+
+func TestCSSInliningOptions(t *testing.T) {
+	h := hermes.Hermes{Theme: cssTheme{".button { color: red !important; }"}}
+	email := hermes.Email{Body: hermes.Body{Name: "Jon"}}
+
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, r, `<p class="button" style="color:red">`)
+
+	h.CSSInliningOptions = hermes.CSSInliningOptions{KeepBangImportant: true}
+	r, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, r, `<p class="button" style="color:red !important">`)
+
+	h.CSSInliningOptions = hermes.CSSInliningOptions{RemoveClasses: true}
+	r, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, r, `<p style="color:red">`)
+}
+
+func TestCSSInliningOptions_SkipCSSToAttributes(t *testing.T) {
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+	h.DisableCSSInlining = false
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, r, `target="_blank" width="200">`)
+
+	h.CSSInliningOptions.SkipCSSToAttributes = true
+	r, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.NotContains(t, r, `target="_blank" width="200">`)
+}
+
+func TestGenerateHTMLRaw(t *testing.T) {
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+	h.DisableCSSInlining = false
+	raw, err := h.GenerateHTMLRaw(email)
+	assert.Nil(t, err)
+	inlined, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, raw, `<table class="email-wrapper" role="presentation" dir="ltr" width="100%" cellpadding="0" cellspacing="0">`)
+	assert.NotEqual(t, raw, inlined)
+	assert.False(t, h.DisableCSSInlining)
+
+	h.DisableCSSInlining = true
+	disabled, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Equal(t, disabled, raw)
+}
@@ -0,0 +1,95 @@
+package hermes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/examples/mails"
+	"github.com/unknowns24/hermes/examples/matrix"
+	"github.com/unknowns24/hermes/pkg/core"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+const customFooter = `<tr><td class="content-cell" data-hermes="custom-footer">{{ .Hermes.Brand.Name }} · {{ .Hermes.Brand.Address }}</td></tr>`
+
+func TestNewCustomized_NoOverrides(t *testing.T) {
+	theme, err := themes.NewCustomized(new(themes.Default), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "default-customized", theme.Name())
+	assert.Equal(t, new(themes.Default).HTMLTemplate(), theme.HTMLTemplate())
+	assert.Equal(t, new(themes.Default).PlainTextTemplate(), theme.PlainTextTemplate())
+	assert.Equal(t, new(themes.Default).TextTemplate(), theme.(hermes.PlainTextTheme).TextTemplate())
+	metadata, ok := core.MetadataOf(theme)
+	assert.True(t, ok, "The metadata of the base should be kept")
+	assert.Equal(t, new(themes.Default).Metadata(), metadata)
+}
+
+func TestNewCustomized_SameBlocks(t *testing.T) {
+	// Overriding blocks with their own templates writes the templates again, which should render the same emails
+	theme, err := themes.NewCustomized(new(themes.Default), map[string]string{
+		themes.BlockGreeting:                           `<h1{{ if not .Email.Body.Title }} data-hermes-greeting="{{ .Email.Body.Greeting }}"{{ end }}>{{if .Email.Body.Title }}{{ .Email.Body.Title }}{{ else }}{{ .Email.Body.Greeting }} {{ .Email.Body.Name }},{{ end }}</h1>`,
+		themes.TextBlockPrefix + themes.BlockSignature: "{{ .Email.Body.Signature }},\n{{ .Hermes.Brand.Name }} - {{ .Hermes.Brand.Link }}",
+	})
+	assert.Nil(t, err)
+	assert.NotEqual(t, new(themes.Default).HTMLTemplate(), theme.HTMLTemplate())
+	assert.NotEqual(t, new(themes.Default).TextTemplate(), theme.(hermes.PlainTextTheme).TextTemplate())
+	for _, example := range mails.All() {
+		h := hermes.Hermes{Theme: new(themes.Default), Brand: matrix.Brand}
+		html, text, err := h.Generate(example.Email())
+		assert.Nil(t, err, example.Name())
+		h.Theme = theme
+		customizedHTML, customizedText, err := h.Generate(example.Email())
+		assert.Nil(t, err, example.Name())
+		assert.Equal(t, html, customizedHTML, example.Name())
+		assert.Equal(t, text, customizedText, example.Name())
+	}
+}
+
+func TestNewCustomized_Footer(t *testing.T) {
+	theme, err := themes.NewCustomized(new(themes.Default), map[string]string{themes.BlockFooter: customFooter})
+	assert.Nil(t, err)
+	for _, example := range mails.All() {
+		h := hermes.Hermes{Theme: new(themes.Default), Brand: brandingExample(), DisableCSSInlining: true}
+		html, text, err := h.Generate(example.Email())
+		assert.Nil(t, err, example.Name())
+		h.Theme = theme
+		customizedHTML, customizedText, err := h.Generate(example.Email())
+		assert.Nil(t, err, example.Name())
+
+		// Only the row of the footer should change
+		start := strings.LastIndex(html[:strings.Index(html, `<table class="email-footer"`)], "<tr>")
+		end := strings.Index(html, "\n        </table>\n      </td>\n    </tr>\n  </table>\n</body>")
+		assert.True(t, start > 0 && end > start, example.Name())
+		expected := html[:start] +
+			`<tr><td class="content-cell" data-hermes="custom-footer">Hermes · Hermes Inc., 1 Market St, San Francisco, CA 94105</td></tr>` +
+			html[end:]
+		assert.Equal(t, expected, customizedHTML, example.Name())
+		assert.Equal(t, text, customizedText, "The plain text footer should not be overridden")
+	}
+}
+
+func TestNewCustomized_TextFooter(t *testing.T) {
+	theme, err := themes.NewCustomized(new(themes.Default), map[string]string{
+		themes.TextBlockPrefix + themes.BlockFooter: "{{ .Hermes.Brand.Name }} · {{ .Hermes.Brand.Address }}",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, new(themes.Default).HTMLTemplate(), theme.HTMLTemplate())
+	h := hermes.Hermes{Theme: theme, Brand: brandingExample()}
+	text, err := h.GeneratePlainText(new(mails.Welcome).Email())
+	assert.Nil(t, err)
+	assert.Contains(t, text, "Hermes · Hermes Inc., 1 Market St, San Francisco, CA 94105")
+	assert.NotContains(t, text, "Copyright © 2024 Hermes.")
+}
+
+func TestNewCustomized_Errors(t *testing.T) {
+	_, err := themes.NewCustomized(new(themes.Default), map[string]string{"headline": "<h1>Hello</h1>"})
+	assert.ErrorContains(t, err, `theme default: HTML template: unknown block "headline", the template defines actions, body, dictionary, footer, greeting, header, intros, logo, outros, signature, table`)
+	_, err = themes.NewCustomized(new(themes.Default), map[string]string{themes.TextBlockPrefix + themes.BlockLogo: "Hermes"})
+	assert.ErrorContains(t, err, `theme default: plain text template: unknown block "logo"`)
+	_, err = themes.NewCustomized(new(themes.Default), map[string]string{themes.BlockFooter: "{{ if .Hermes.Brand.Name }}"})
+	assert.ErrorContains(t, err, `theme default: HTML template: block "footer": `)
+	_, err = themes.NewCustomized(new(themes.Default), map[string]string{themes.BlockFooter: "{{ end }}"})
+	assert.ErrorContains(t, err, `block "footer": `)
+}
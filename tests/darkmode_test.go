@@ -0,0 +1,53 @@
+package hermes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+func TestDarkMode_SurvivesInlining(t *testing.T) {
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+	h.DisableCSSInlining = false
+
+	html, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	head := html[:strings.Index(html, "</head>")]
+	assert.Contains(t, head, `<meta name="color-scheme" content="light dark"/>`)
+	assert.Contains(t, head, "@media (prefers-color-scheme: dark)")
+	assert.Contains(t, head, "background-color: #1E1F22 !important;")
+	assert.Contains(t, head, "[data-ogsc] h1")
+	assert.Contains(t, head, "[data-ogsb] .email-body")
+	// Rules of the dark mode are not inlined in the elements
+	assert.NotContains(t, html[len(head):], "#1E1F22")
+}
+
+func TestDarkMode_Palette(t *testing.T) {
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+	h.DarkMode = hermes.DarkModeColors{
+		Background: "#000000",
+		Text:       "rgb(230, 230, 230)",
+		Link:       "red;} body { display: none",
+		Button:     "#FF6600",
+	}
+
+	html, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, html, "background-color: #000000 !important;")
+	assert.Contains(t, html, "color: rgb(230, 230, 230) !important;")
+	assert.Contains(t, html, "background-color: #FF6600 !important;")
+	// Missing and invalid colors fall back to the default palette
+	assert.Contains(t, html, "background-color: "+hermes.DefaultDarkModeColors.Content+" !important;")
+	assert.Contains(t, html, "color: "+hermes.DefaultDarkModeColors.Link+" !important;")
+	assert.NotContains(t, html, "display: none")
+}
+
+func TestDarkMode_ButtonsKeepTheirColors(t *testing.T) {
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+	html, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.NotContains(t, html, ".button {\n        background-color")
+}
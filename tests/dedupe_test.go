@@ -0,0 +1,192 @@
+package hermes
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/pkg/send"
+)
+
+// countingSender counts the messages sent, safely for concurrent use, failing while fail is set
+type countingSender struct {
+	sent atomic.Int32
+	fail atomic.Bool
+}
+
+func (s *countingSender) Send(context.Context, send.Message) error {
+	if s.fail.Load() {
+		return errors.New("mailbox unavailable")
+	}
+	s.sent.Add(1)
+	return nil
+}
+
+// dedupeStores returns the stores tested, of which the TTLs follow the clock
+func dedupeStores(t *testing.T, clock *fakeClock) map[string]send.DedupeStore {
+	memory := send.NewMemoryDedupeStore()
+	memory.Now = clock.Now
+	return map[string]send.DedupeStore{
+		"memory": memory,
+		"file":   send.FileDedupeStore{Dir: t.TempDir(), Now: clock.Now},
+	}
+}
+
+func TestDedupeSender_Concurrent(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)}
+	for name, store := range dedupeStores(t, clock) {
+		t.Run(name, func(t *testing.T) {
+			sender := &countingSender{}
+			d := send.DedupeSender{Sender: sender, Store: store}
+			var suppressed atomic.Int32
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					err := d.Send(context.Background(), sendExample(send.Both))
+					if errors.Is(err, send.ErrDuplicateSuppressed) {
+						suppressed.Add(1)
+					} else {
+						assert.Nil(t, err)
+					}
+				}()
+			}
+			wg.Wait()
+			assert.Equal(t, int32(1), sender.sent.Load())
+			assert.Equal(t, int32(19), suppressed.Load())
+		})
+	}
+}
+
+func TestDedupeSender_TTL(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)}
+	for name, store := range dedupeStores(t, clock) {
+		t.Run(name, func(t *testing.T) {
+			sender := &countingSender{}
+			d := send.DedupeSender{Sender: sender, Store: store, TTL: time.Hour}
+			ctx := context.Background()
+			m := sendExample(send.Both)
+			assert.Nil(t, d.Send(ctx, m))
+			err := d.Send(ctx, m)
+			assert.True(t, errors.Is(err, send.ErrDuplicateSuppressed))
+			assert.EqualError(t, err, "send: duplicate message suppressed: to jon@snow.com")
+
+			clock.Wait(ctx, 59*time.Minute)
+			assert.True(t, errors.Is(d.Send(ctx, m), send.ErrDuplicateSuppressed))
+			clock.Wait(ctx, time.Minute)
+			assert.Nil(t, d.Send(ctx, m), "The key should expire after the TTL")
+			assert.True(t, errors.Is(d.Send(ctx, m), send.ErrDuplicateSuppressed))
+			assert.Equal(t, int32(2), sender.sent.Load())
+		})
+	}
+}
+
+func TestDedupeSender_Keys(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)}
+	for name, store := range dedupeStores(t, clock) {
+		t.Run(name, func(t *testing.T) {
+			sender := &countingSender{}
+			d := send.DedupeSender{Sender: sender, Store: store}
+			ctx := context.Background()
+			assert.Nil(t, d.Send(ctx, sendExample(send.Both)))
+
+			// Other recipients, subjects or contents are other messages
+			m := sendExample(send.Both)
+			m.To = []string{"arya@stark.com"}
+			assert.Nil(t, d.Send(ctx, m))
+			m = sendExample(send.Both)
+			m.Subject = "Your order has shipped"
+			assert.Nil(t, d.Send(ctx, m))
+			m = sendExample(send.Both)
+			m.Text = "Your order has been cancelled."
+			assert.Nil(t, d.Send(ctx, m))
+
+			// Recipients are compared as a set of addresses, whatever their display names and fields
+			m = sendExample(send.Both)
+			m.To = []string{"Jon Snow <jon@snow.com>"}
+			assert.True(t, errors.Is(d.Send(ctx, m), send.ErrDuplicateSuppressed))
+
+			// Idempotency keys replace the subject and content
+			m = sendExample(send.Both)
+			m.IdempotencyKey = "order-1234"
+			assert.Nil(t, d.Send(ctx, m))
+			m.Subject = "Your order (resent)"
+			m.HTML = "<p>Your order has been processed.</p>"
+			assert.True(t, errors.Is(d.Send(ctx, m), send.ErrDuplicateSuppressed))
+			m.IdempotencyKey = "order-1235"
+			assert.Nil(t, d.Send(ctx, m))
+			assert.Equal(t, int32(6), sender.sent.Load())
+		})
+	}
+}
+
+func TestDedupeSender_Failure(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)}
+	for name, store := range dedupeStores(t, clock) {
+		t.Run(name, func(t *testing.T) {
+			sender := &countingSender{}
+			sender.fail.Store(true)
+			d := send.DedupeSender{Sender: sender, Store: store}
+			ctx := context.Background()
+			m := sendExample(send.Both)
+			err := d.Send(ctx, m)
+			assert.EqualError(t, err, "mailbox unavailable")
+			assert.False(t, errors.Is(err, send.ErrDuplicateSuppressed))
+
+			sender.fail.Store(false)
+			assert.Nil(t, d.Send(ctx, m), "The key of a failed message should be released")
+			assert.Equal(t, int32(1), sender.sent.Load())
+
+			m.To = []string{"not an address"}
+			assert.NotNil(t, d.Send(ctx, m))
+			assert.Equal(t, int32(1), sender.sent.Load())
+		})
+	}
+}
+
+func TestFileDedupeStore_Errors(t *testing.T) {
+	dir := t.TempDir()
+	store := send.FileDedupeStore{Dir: dir}
+	ctx := context.Background()
+	ok, err := store.Reserve(ctx, "../key", time.Hour)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	entries, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1, "Keys should stay in the directory")
+	assert.Nil(t, store.Release(ctx, "../key"))
+	assert.Nil(t, store.Release(ctx, "../key"), "Releasing twice should not fail")
+
+	_, err = send.FileDedupeStore{Dir: dir + "/missing"}.Reserve(ctx, "key", time.Hour)
+	assert.NotNil(t, err)
+	d := send.DedupeSender{Sender: &countingSender{}, Store: send.FileDedupeStore{Dir: dir + "/missing"}}
+	assert.ErrorContains(t, d.Send(ctx, sendExample(send.Both)), "send: reserve dedupe key: ")
+}
+
+func TestScheduler_Suppressed(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)}
+	store := send.NewMemoryCheckpointStore()
+	sender := &recordingSender{clock: clock}
+	dedupe := send.NewMemoryDedupeStore()
+	dedupe.Now = clock.Now
+	s := schedulerExample(clock, send.DedupeSender{Sender: sender, Store: dedupe}, store)
+	s.HourlyQuota = 3
+
+	// The duplicate recipient is suppressed, without counting in the quota
+	recipients := []string{"user0@example.com", "user1@example.com", "user0@example.com", "user2@example.com"}
+	assert.Nil(t, s.Run(context.Background(), recipients))
+	assert.Equal(t, []string{"user0@example.com", "user1@example.com", "user2@example.com"}, sender.sent)
+	assert.Equal(t, send.Progress{Total: 4, Sent: 4, Suppressed: 1}, s.Progress())
+	for _, sent := range sender.times {
+		assert.Equal(t, clock.now.Hour(), sent.Hour(), "All messages should be sent within the hourly quota")
+	}
+	cp, _, _ := store.Load(context.Background(), "spring-sale")
+	assert.Equal(t, 1, cp.Suppressed)
+	assert.Equal(t, 3, cp.HourSent)
+}
@@ -0,0 +1,157 @@
+package hermes
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/send"
+)
+
+// fakeResolver answers the DNS lookups of Diagnose from its records
+type fakeResolver map[string][]string
+
+func (r fakeResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	if host == "127.0.0.1" {
+		return []string{host}, nil
+	}
+	return nil, errors.New("lookup " + host + ": no such host")
+}
+
+func (r fakeResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	if records, ok := r[name]; ok {
+		return records, nil
+	}
+	return nil, errors.New("lookup " + name + ": no such host")
+}
+
+// diagnosis returns the findings by check, asserting each check is reported once
+func diagnosis(t *testing.T, findings []send.Finding) map[string]send.Finding {
+	byCheck := map[string]send.Finding{}
+	for _, f := range findings {
+		_, seen := byCheck[f.Check]
+		assert.False(t, seen, f.Check)
+		byCheck[f.Check] = f
+	}
+	return byCheck
+}
+
+func doctorConfig(s send.SMTP) send.Config {
+	return send.Config{
+		SMTP:         s,
+		From:         "Hermes <hello@hermes-example.com>",
+		To:           []string{"jon@snow.com"},
+		DKIMSelector: "mail",
+		Resolver: fakeResolver{
+			"hermes-example.com":                 {"google-site-verification=x", "v=spf1 include:_spf.google.com ~all"},
+			"mail._domainkey.hermes-example.com": {"k=rsa; p=MIGfMA0"},
+			"_dmarc.hermes-example.com":          {"v=DMARC1; p=none"},
+		},
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	f, s := startFakeSMTP(t, send.StartTLS)
+	s.Username, s.Password = "hermes", "secret"
+	findings := send.Diagnose(context.Background(), doctorConfig(s))
+
+	assert.False(t, send.HasErrors(findings))
+	byCheck := diagnosis(t, findings)
+	for _, check := range []string{send.CheckConfig, send.CheckResolve, send.CheckConnect, send.CheckStartTLS, send.CheckAuth, send.CheckSPF, send.CheckDKIM, send.CheckDMARC} {
+		assert.True(t, byCheck[check].Passed, "%s: %v", check, byCheck[check])
+	}
+	assert.Equal(t, "hermes-example.com: v=spf1 include:_spf.google.com ~all", byCheck[send.CheckSPF].Message)
+	assert.Empty(t, f.received(), "Diagnose should not send anything")
+}
+
+func TestDiagnose_SMTPFailures(t *testing.T) {
+	_, s := startFakeSMTP(t, send.NoTLS)
+	s.TLS = send.StartTLS
+	byCheck := diagnosis(t, send.Diagnose(context.Background(), doctorConfig(s)))
+	assert.True(t, byCheck[send.CheckConnect].Passed)
+	assert.Equal(t, "SMTP server does not offer STARTTLS", byCheck[send.CheckStartTLS].Message)
+	assert.Equal(t, hermes.SeverityError, byCheck[send.CheckStartTLS].Severity)
+	assert.NotEmpty(t, byCheck[send.CheckStartTLS].Remediation)
+
+	s.TLS = send.NoTLS
+	byCheck = diagnosis(t, send.Diagnose(context.Background(), doctorConfig(s)))
+	assert.Equal(t, hermes.SeverityWarning, byCheck[send.CheckStartTLS].Severity, "Plain connections are not an error")
+
+	// Nothing listens on the port anymore
+	closed, s := startFakeSMTP(t, send.NoTLS)
+	closed.ln.Close()
+	findings := send.Diagnose(context.Background(), doctorConfig(s))
+	assert.True(t, send.HasErrors(findings))
+	byCheck = diagnosis(t, findings)
+	assert.False(t, byCheck[send.CheckConnect].Passed)
+	assert.NotContains(t, byCheck, send.CheckStartTLS, "Later SMTP checks depend on the connection")
+	assert.True(t, byCheck[send.CheckSPF].Passed, "DNS records are checked anyway")
+
+	s.Server = "smtp.hermes-example.invalid"
+	byCheck = diagnosis(t, send.Diagnose(context.Background(), doctorConfig(s)))
+	assert.False(t, byCheck[send.CheckResolve].Passed)
+	assert.NotContains(t, byCheck, send.CheckConnect)
+}
+
+func TestDiagnose_Config(t *testing.T) {
+	findings := send.Diagnose(context.Background(), send.Config{SMTP: send.SMTP{Username: "hermes"}, To: []string{"jon"}, Resolver: fakeResolver{}})
+	assert.True(t, send.HasErrors(findings))
+	var messages []string
+	for _, f := range findings {
+		assert.Equal(t, send.CheckConfig, f.Check)
+		assert.NotEmpty(t, f.Remediation, f.Message)
+		messages = append(messages, f.Message)
+	}
+	assert.Equal(t, []string{
+		"SMTP server is empty",
+		"invalid SMTP port 0",
+		"SMTP user without password",
+		"sender is empty",
+		`recipient "jon": mail: missing '@' or angle-addr`,
+	}, messages, "Network checks need a valid configuration")
+
+	_, s := startFakeSMTP(t, send.NoTLS)
+	config := doctorConfig(s)
+	config.From = "hello@unknown.example"
+	config.Resolver = fakeResolver{"unknown.example": {"v=spf1 -all"}}
+	findings = send.Diagnose(context.Background(), config)
+	assert.False(t, send.HasErrors(findings), "Missing DNS records do not prevent sending")
+	byCheck := diagnosis(t, findings)
+	for _, check := range []string{send.CheckDKIM, send.CheckDMARC} {
+		assert.False(t, byCheck[check].Passed, check)
+		assert.Equal(t, hermes.SeverityInfo, byCheck[check].Severity, "%s: DNS records are informational only", check)
+	}
+	assert.Contains(t, byCheck[send.CheckSPF].Message, "v=spf1")
+	config.DKIMSelector = ""
+	byCheck = diagnosis(t, send.Diagnose(context.Background(), config))
+	assert.Equal(t, "not checked without selector", byCheck[send.CheckDKIM].Message)
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	env := map[string]string{
+		send.EnvSMTPServer:     " smtp.hermes-example.com ",
+		send.EnvSMTPPort:       "465",
+		send.EnvSMTPUser:       "hermes",
+		send.EnvSMTPPassword:   "secret",
+		send.EnvSenderEmail:    "hello@hermes-example.com",
+		send.EnvSenderIdentity: "Hermes",
+		send.EnvTo:             "jon@snow.com, arya@stark.com,",
+	}
+	config := send.ConfigFromEnv(func(key string) string { return env[key] })
+	assert.Equal(t, send.SMTP{Server: "smtp.hermes-example.com", Port: 465, Username: "hermes", Password: "secret", TLS: send.ImplicitTLS}, config.SMTP)
+	assert.Equal(t, `"Hermes" <hello@hermes-example.com>`, config.From)
+	assert.Equal(t, []string{"jon@snow.com", "arya@stark.com"}, config.To)
+
+	env[send.EnvSMTPPort] = "submission"
+	config = send.ConfigFromEnv(func(key string) string { return env[key] })
+	assert.Equal(t, 0, config.SMTP.Port)
+	assert.Equal(t, send.StartTLS, config.SMTP.TLS)
+
+	finding := send.Finding{Check: send.CheckAuth, Severity: hermes.SeverityError, Message: "535 denied", Remediation: "Check the password"}
+	assert.Equal(t, "error   smtp.auth: 535 denied\n        Check the password", finding.String())
+	finding = send.Finding{Check: send.CheckAuth, Passed: true, Message: "hermes is authenticated", Remediation: "unused"}
+	assert.True(t, strings.HasPrefix(finding.String(), "ok      smtp.auth: "))
+}
@@ -0,0 +1,90 @@
+package hermes
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+func TestGenerateEML(t *testing.T) {
+	h, email := (&SimpleExample{}).getExample()
+	h.DisableCSSInlining = false
+	// Inlined HTML and long intros make lines longer than allowed by SMTP
+	email.Body.Intros = append(email.Body.Intros, strings.Repeat("Winter is coming. ", 100))
+	subject := strings.Repeat("Votre commande a été traitée ", 5)
+	date := time.Date(2024, 3, 1, 9, 30, 0, 0, time.FixedZone("CET", 3600))
+	raw, err := h.GenerateEML(email, hermes.EMLOptions{
+		From:    "Hermès <hello@hermes-example.com>",
+		To:      []string{"jon@snow.com", "Arya Stark <arya@stark.com>"},
+		Subject: subject,
+		Date:    date,
+	})
+	assert.Nil(t, err)
+
+	for _, line := range strings.Split(string(raw), "\r\n") {
+		assert.LessOrEqual(t, len(line), 998, "Line too long for SMTP: %.80q", line)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	assert.Nil(t, err)
+	from, err := msg.Header.AddressList("From")
+	assert.Nil(t, err)
+	assert.Equal(t, []*mail.Address{{Name: "Hermès", Address: "hello@hermes-example.com"}}, from)
+	to, err := msg.Header.AddressList("To")
+	assert.Nil(t, err)
+	assert.Equal(t, []*mail.Address{{Address: "jon@snow.com"}, {Name: "Arya Stark", Address: "arya@stark.com"}}, to)
+	decoded, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	assert.Nil(t, err)
+	assert.Equal(t, subject, decoded)
+	sent, err := msg.Header.Date()
+	assert.Nil(t, err)
+	assert.True(t, date.Equal(sent))
+	assert.Equal(t, "1.0", msg.Header.Get("MIME-Version"))
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	assert.Nil(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+	html, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	text, err := h.GeneratePlainText(email)
+	assert.Nil(t, err)
+	var found []string
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		// The reader decodes quoted-printable parts, whose line breaks are CRLF
+		body, err := io.ReadAll(p)
+		assert.Nil(t, err)
+		found = append(found, p.Header.Get("Content-Type"), strings.ReplaceAll(string(body), "\r\n", "\n"))
+	}
+	assert.Equal(t, []string{"text/plain; charset=utf-8", text, "text/html; charset=utf-8", html}, found)
+}
+
+func TestGenerateEML_Defaults(t *testing.T) {
+	h, email := (&SimpleExample{}).getExample()
+	now := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	h.Now = func() time.Time { return now }
+	raw, err := h.GenerateEML(email, hermes.EMLOptions{From: "hello@hermes-example.com"})
+	assert.Nil(t, err)
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	assert.Nil(t, err)
+	assert.Equal(t, "Fri, 01 Mar 2024 09:30:00 +0000", msg.Header.Get("Date"))
+	assert.Equal(t, "", msg.Header.Get("To"))
+
+	_, err = h.GenerateEML(email, hermes.EMLOptions{})
+	assert.ErrorContains(t, err, "eml: From: ")
+	_, err = h.GenerateEML(email, hermes.EMLOptions{From: "hello@hermes-example.com", To: []string{"jon"}})
+	assert.ErrorContains(t, err, "eml: To: ")
+}
@@ -0,0 +1,95 @@
+package hermes
+
+import (
+	"context"
+	"io"
+	mathrand "math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/send"
+)
+
+// messageDate is the date of the messages of byte-exact tests
+var messageDate = time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+
+// seeded returns a source of random bytes writing the same bytes on each run, for byte-exact messages
+func seeded() io.Reader {
+	return mathrand.New(mathrand.NewSource(1))
+}
+
+func TestMessage_Golden(t *testing.T) {
+	for name, m := range map[string]send.Message{
+		"both":      sendExample(send.Both),
+		"text_only": sendExample(send.TextOnly),
+		"html_only": func() send.Message {
+			m := sendExample(send.HTMLOnly)
+			m.Text, m.ForceHTMLOnly = "", true
+			return m
+		}(),
+		"attachments": func() send.Message {
+			m := sendExample(send.Both)
+			m.Cc = []string{"Arya Stark <arya@stark.com>"}
+			m.Headers = map[string]string{"Reply-To": "support@hermes-example.com"}
+			m.Unsubscribe = &hermes.Unsubscribe{Mailto: "unsubscribe@hermes-example.com", OneClickURL: "https://hermes-example.com/one-click"}
+			m.Attachments = []hermes.Attachment{
+				{Filename: "logo.png", ContentType: "image/png", Data: []byte("\x89PNG\r\n\x1a\n"), Inline: true},
+				{Filename: "invoice.pdf", ContentType: "application/pdf", Data: []byte(strings.Repeat("%PDF-1.7 ", 20))},
+			}
+			return m
+		}(),
+	} {
+		raw, err := m.BytesWith(send.MIMEOptions{Rand: seeded(), Date: messageDate})
+		assert.Nil(t, err, name)
+		assertGolden(t, "mime/"+name+".eml", string(raw))
+		again, err := m.BytesWith(send.MIMEOptions{Rand: seeded(), Date: messageDate})
+		assert.Nil(t, err, name)
+		assert.Equal(t, raw, again, "Messages should be the same for the same source")
+	}
+
+	raw, err := sendExample(send.Both).Bytes()
+	assert.Nil(t, err)
+	again, err := sendExample(send.Both).Bytes()
+	assert.Nil(t, err)
+	assert.NotEqual(t, raw, again, "Boundaries should be random by default")
+
+	_, err = sendExample(send.Both).BytesWith(send.MIMEOptions{Rand: strings.NewReader("short")})
+	assert.EqualError(t, err, "send: read MIME boundary: unexpected EOF")
+	raw, err = sendExample(send.TextOnly).BytesWith(send.MIMEOptions{Rand: strings.NewReader("")})
+	assert.Nil(t, err, "Messages without multipart entities should not read the source")
+	assert.NotEmpty(t, raw)
+}
+
+func TestGenerateEML_Golden(t *testing.T) {
+	h, email := (&SimpleExample{}).getExample()
+	opts := hermes.EMLOptions{
+		From: "Hermes <hello@hermes-example.com>",
+		To:   []string{"jon@snow.com"},
+		Date: time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC),
+		Rand: seeded(),
+	}
+	raw, err := h.GenerateEML(email, opts)
+	assert.Nil(t, err)
+	assertGolden(t, "mime/generated.eml", string(raw))
+
+	opts.Rand = strings.NewReader("")
+	_, err = h.GenerateEML(email, opts)
+	assert.EqualError(t, err, "eml: read MIME boundary: EOF")
+}
+
+func TestSMTP_Rand(t *testing.T) {
+	f, s := startFakeSMTP(t, send.NoTLS)
+	s.Rand = seeded()
+	s.Now = func() time.Time { return messageDate }
+	m := smtpMessage()
+	assert.Nil(t, s.Send(context.Background(), m))
+
+	expected, err := m.BytesWith(send.MIMEOptions{Rand: seeded(), Date: messageDate})
+	assert.Nil(t, err)
+	received := f.received()
+	assert.Len(t, received, 1)
+	assert.Equal(t, wire(expected), received[0].Data, "The server should receive the message written from the source")
+}
@@ -0,0 +1,333 @@
+package hermes
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/pkg/send/events"
+)
+
+const (
+	snsTopic   = "arn:aws:sns:us-east-1:123456789012:ses-notifications"
+	snsCertURL = "https://sns.us-east-1.amazonaws.com/SimpleNotificationService-0000000000000000000000.pem"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	data, err := os.ReadFile("testdata/events/" + name)
+	assert.Nil(t, err)
+	return data
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// snsSigner signs SNS messages, and serves its certificate and subscribe URLs as SNS would
+type snsSigner struct {
+	key       *rsa.PrivateKey
+	cert      []byte
+	requested []string
+}
+
+func newSNSSigner(t *testing.T) *snsSigner {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sns.amazonaws.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	return &snsSigner{key: key, cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+func (s *snsSigner) client() *http.Client {
+	return &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		s.requested = append(s.requested, r.URL.String())
+		body := "confirmed"
+		if r.URL.String() == snsCertURL {
+			body = string(s.cert)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: http.Header{}}, nil
+	})}
+}
+
+func (s *snsSigner) sign(t *testing.T, m events.SNSMessage) []byte {
+	m.SignatureVersion = "2"
+	m.SigningCertURL = snsCertURL
+	var fields []string
+	if m.Type == events.SNSNotification {
+		fields = []string{"Message", m.Message, "MessageId", m.MessageID, "Timestamp", m.Timestamp, "TopicArn", m.TopicARN, "Type", m.Type}
+	} else {
+		fields = []string{"Message", m.Message, "MessageId", m.MessageID, "SubscribeURL", m.SubscribeURL, "Timestamp", m.Timestamp, "Token", m.Token, "TopicArn", m.TopicARN, "Type", m.Type}
+	}
+	digest := sha256.Sum256([]byte(strings.Join(fields, "\n") + "\n"))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	assert.Nil(t, err)
+	m.Signature = base64.StdEncoding.EncodeToString(signature)
+
+	body, err := json.Marshal(m)
+	assert.Nil(t, err)
+	return body
+}
+
+func snsNotification(message []byte) events.SNSMessage {
+	return events.SNSMessage{
+		Type:      events.SNSNotification,
+		MessageID: "22b80b92-fdea-4c2c-8f9d-bdfb0c7bf324",
+		TopicARN:  snsTopic,
+		Message:   string(message),
+		Timestamp: "2016-01-27T14:59:38.237Z",
+	}
+}
+
+func post(h http.Handler, body []byte, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(body))
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestParseSESNotification(t *testing.T) {
+	bounces, err := events.ParseSESNotification(readFixture(t, "ses_bounce.json"))
+	assert.Nil(t, err)
+	timestamp := time.Date(2016, time.January, 27, 14, 59, 38, 237000000, time.UTC)
+	assert.Equal(t, []events.Event{
+		{Type: events.EventBounce, Recipient: "jane@example.com", MessageID: "00000138111222aa-33322211-cccc-cccc-cccc-ddddaaaa0680-000000", Timestamp: timestamp, Reason: "smtp; 550 5.1.1 user unknown"},
+		{Type: events.EventBounce, Recipient: "richard@example.com", MessageID: "00000138111222aa-33322211-cccc-cccc-cccc-ddddaaaa0680-000000", Timestamp: timestamp, Reason: "Permanent General"},
+	}, bounces)
+
+	complaints, err := events.ParseSESNotification(readFixture(t, "ses_complaint.json"))
+	assert.Nil(t, err)
+	assert.Len(t, complaints, 1)
+	assert.Equal(t, events.EventComplaint, complaints[0].Type)
+	assert.Equal(t, "recipient@example.com", complaints[0].Recipient)
+	assert.Equal(t, "abuse", complaints[0].Reason)
+
+	_, err = events.ParseSESNotification([]byte(`{"notificationType": "Bounce"}`))
+	assert.EqualError(t, err, "malformed SES notification: bounce is missing")
+	_, err = events.ParseSESNotification([]byte(`{"mail": {}}`))
+	assert.EqualError(t, err, "malformed SES notification: type is missing")
+	_, err = events.ParseSESNotification([]byte(`not json`))
+	assert.NotNil(t, err)
+
+	others, err := events.ParseSESNotification([]byte(`{"notificationType": "Received"}`))
+	assert.Nil(t, err)
+	assert.Empty(t, others)
+}
+
+func TestParseSendGridEvents(t *testing.T) {
+	parsed, err := events.ParseSendGridEvents(readFixture(t, "sendgrid.json"))
+	assert.Nil(t, err)
+
+	var types []events.EventType
+	for _, e := range parsed {
+		types = append(types, e.Type)
+	}
+	assert.Equal(t, []events.EventType{events.EventDelivery, events.EventBounce, events.EventSoftBounce, events.EventComplaint, events.EventUnsubscribe}, types)
+	assert.Equal(t, events.Event{
+		Type:      events.EventBounce,
+		Recipient: "bounced@test.com",
+		MessageID: "14c5d75ce93.dfd.64b469.filter0001.16648.5515E0B88.000000000000000000001",
+		Timestamp: time.Unix(1513299572, 0).UTC(),
+		Reason:    "500 unknown recipient",
+	}, parsed[1])
+
+	_, err = events.ParseSendGridEvents([]byte(`{"event": "bounce"}`))
+	assert.NotNil(t, err)
+	_, err = events.ParseSendGridEvents([]byte(`[{"event": "bounce"}]`))
+	assert.EqualError(t, err, "malformed SendGrid events: event 0 has no type or email")
+}
+
+func TestSESHandler(t *testing.T) {
+	signer := newSNSSigner(t)
+	list := events.NewMemorySuppressionList()
+	verifier := &events.SNSVerifier{Client: signer.client(), TopicARNs: []string{snsTopic}, Now: func() time.Time {
+		return time.Date(2016, 1, 27, 15, 2, 0, 0, time.UTC)
+	}}
+	handler := events.NewSESHandler(verifier, events.SuppressTo(list))
+	ctx := context.Background()
+
+	w := post(handler, signer.sign(t, snsNotification(readFixture(t, "ses_bounce.json"))), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	suppressed, _ := list.IsSuppressed(ctx, "Jane@Example.com")
+	assert.True(t, suppressed)
+	suppressed, _ = list.IsSuppressed(ctx, "mary@example.com")
+	assert.False(t, suppressed)
+
+	// Tampered message
+	tampered := strings.Replace(string(signer.sign(t, snsNotification(readFixture(t, "ses_complaint.json")))), "recipient@example.com", "victim@example.com", 1)
+	w = post(handler, []byte(tampered), nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	suppressed, _ = list.IsSuppressed(ctx, "victim@example.com")
+	assert.False(t, suppressed)
+
+	// Other topic
+	other := snsNotification(readFixture(t, "ses_complaint.json"))
+	other.TopicARN = "arn:aws:sns:us-east-1:999999999999:attacker"
+	w = post(handler, signer.sign(t, other), nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Certificate outside of SNS
+	var m events.SNSMessage
+	assert.Nil(t, json.Unmarshal(signer.sign(t, snsNotification(readFixture(t, "ses_complaint.json"))), &m))
+	m.SigningCertURL = "https://attacker.example.com/sns.amazonaws.com.pem"
+	body, _ := json.Marshal(m)
+	w = post(handler, body, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Malformed payloads
+	assert.Equal(t, http.StatusBadRequest, post(handler, []byte("{"), nil).Code)
+	assert.Equal(t, http.StatusBadRequest, post(handler, []byte(`{"Type": "Unknown"}`), nil).Code)
+	assert.Equal(t, http.StatusBadRequest, post(handler, signer.sign(t, snsNotification([]byte("not json"))), nil).Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/webhooks", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	// Subscription confirmation
+	subscribeURL := "https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription&TopicArn=" + snsTopic + "&Token=token"
+	w = post(handler, signer.sign(t, events.SNSMessage{
+		Type:         events.SNSSubscriptionConfirmation,
+		MessageID:    "165545c9-2a5c-472c-8df2-7ff2be2b3b1b",
+		Token:        "token",
+		TopicARN:     snsTopic,
+		Message:      "You have chosen to subscribe to the topic",
+		SubscribeURL: subscribeURL,
+		Timestamp:    "2016-01-27T15:01:04.751Z",
+	}), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, signer.requested, subscribeURL)
+}
+
+func TestSNSVerifier_MaxAge(t *testing.T) {
+	signer := newSNSSigner(t)
+	verifier := &events.SNSVerifier{Client: signer.client(), TopicARNs: []string{snsTopic}, Now: func() time.Time {
+		return time.Date(2016, 1, 27, 15, 5, 0, 0, time.UTC)
+	}}
+	verify := func(timestamp string) error {
+		m := snsNotification(readFixture(t, "ses_bounce.json"))
+		m.Timestamp = timestamp
+		parsed, err := events.ParseSNSMessage(signer.sign(t, m))
+		assert.Nil(t, err)
+		return verifier.Verify(parsed)
+	}
+
+	assert.Nil(t, verify("2016-01-27T15:00:01.000Z"))
+	assert.EqualError(t, verify("2016-01-27T14:59:38.237Z"), "payload signed at 2016-01-27T14:59:38Z is outside of the accepted window of 5m0s", "Captured messages should not be replayed")
+	assert.NotNil(t, verify("2016-01-27T15:10:01.000Z"), "Messages from the future should be rejected")
+	assert.EqualError(t, verify("yesterday"), `malformed timestamp "yesterday"`)
+
+	verifier.MaxAge = time.Minute
+	assert.NotNil(t, verify("2016-01-27T15:03:00.000Z"))
+	verifier.MaxAge = -1
+	assert.Nil(t, verify("2012-04-26T20:45:04.751Z"))
+}
+
+func TestSendGridHandler(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.Nil(t, err)
+
+	list := events.NewMemorySuppressionList()
+	verifier, err := events.NewSendGridVerifier(base64.StdEncoding.EncodeToString(der))
+	assert.Nil(t, err)
+	verifier.Now = func() time.Time { return time.Unix(1513299635, 0) }
+	handler := events.NewSendGridHandler(verifier, events.SuppressTo(list))
+	ctx := context.Background()
+
+	body := readFixture(t, "sendgrid.json")
+	timestamp := "1513299575"
+	digest := sha256.Sum256(append([]byte(timestamp), body...))
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	assert.Nil(t, err)
+	header := http.Header{
+		events.SendGridSignatureHeader: {base64.StdEncoding.EncodeToString(signature)},
+		events.SendGridTimestampHeader: {timestamp},
+	}
+
+	w := post(handler, body, header)
+	assert.Equal(t, http.StatusOK, w.Code)
+	for address, expected := range map[string]bool{
+		"bounced@test.com":      true,
+		"spam@test.com":         true,
+		"unsubscribed@test.com": true,
+		"blocked@test.com":      false,
+		"example@test.com":      false,
+	} {
+		suppressed, err := list.IsSuppressed(ctx, address)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, suppressed, address)
+	}
+
+	header.Set(events.SendGridTimestampHeader, "1513299576")
+	assert.Equal(t, http.StatusForbidden, post(handler, body, header).Code, "Timestamp is signed")
+	assert.Equal(t, http.StatusForbidden, post(handler, body, nil).Code)
+
+	_, err = events.NewSendGridVerifier("not a key")
+	assert.NotNil(t, err)
+}
+
+func TestSendGridVerifier_MaxAge(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	verifier := &events.SendGridVerifier{Key: &key.PublicKey, Now: func() time.Time { return time.Unix(1513299575, 0) }}
+	body := readFixture(t, "sendgrid.json")
+	verify := func(timestamp string) error {
+		digest := sha256.Sum256(append([]byte(timestamp), body...))
+		signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+		assert.Nil(t, err)
+		return verifier.Verify(base64.StdEncoding.EncodeToString(signature), timestamp, body)
+	}
+
+	assert.Nil(t, verify("1513299300"))
+	assert.EqualError(t, verify("1513299000"), "payload signed at 2017-12-15T00:50:00Z is outside of the accepted window of 5m0s", "Captured webhooks should not be replayed")
+	assert.NotNil(t, verify("1513299900"), "Webhooks from the future should be rejected")
+	assert.EqualError(t, verify("soon"), `malformed timestamp "soon"`)
+
+	verifier.MaxAge = -1
+	assert.Nil(t, verify("1"))
+}
+
+func TestSink_Errors(t *testing.T) {
+	failing := func(ctx context.Context, e []events.Event) error { return io.ErrUnexpectedEOF }
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	handler := events.NewSendGridHandler(&events.SendGridVerifier{Key: &key.PublicKey, MaxAge: -1}, failing)
+
+	body := []byte(`[{"email": "a@test.com", "event": "bounce", "timestamp": 1}]`)
+	digest := sha256.Sum256(append([]byte("1"), body...))
+	signature, _ := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	w := post(handler, body, http.Header{
+		events.SendGridSignatureHeader: {base64.StdEncoding.EncodeToString(signature)},
+		events.SendGridTimestampHeader: {"1"},
+	})
+	assert.Equal(t, http.StatusInternalServerError, w.Code, "Providers should retry when events can't be handled")
+}
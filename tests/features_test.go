@@ -0,0 +1,152 @@
+package hermes
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/preview"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+// featureDependencies are the fields the examples of a feature need besides its own
+var featureDependencies = map[string][]string{
+	"actions_layout":    {"actions"},
+	"table_of_contents": {"dictionary", "tables"},
+}
+
+// bodyFeatures returns the exported fields of Body by the JSON name of the feature
+func bodyFeatures() map[string]reflect.StructField {
+	features := map[string]reflect.StructField{}
+	body := reflect.TypeOf(hermes.Body{})
+	for i := 0; i < body.NumField(); i++ {
+		if field := body.Field(i); field.IsExported() {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			features[name] = field
+		}
+	}
+	return features
+}
+
+func TestFeatureExamples_Complete(t *testing.T) {
+	features := bodyFeatures()
+	examples := hermes.FeatureExamples()
+	for name, field := range features {
+		assert.Contains(t, examples, name, "Body.%s has no example in FeatureExamples", field.Name)
+	}
+	for name := range examples {
+		assert.Contains(t, features, name, "FeatureExamples has an example of %q, which is not a field of Body", name)
+	}
+}
+
+func TestFeatureExamples_Isolated(t *testing.T) {
+	features := bodyFeatures()
+	for name, email := range hermes.FeatureExamples() {
+		allowed := append([]string{name}, featureDependencies[name]...)
+		body := reflect.ValueOf(email.Body)
+		for feature, field := range features {
+			set := !body.FieldByIndex(field.Index).IsZero()
+			if contains(allowed, feature) {
+				assert.True(t, set, "The example of %q should set Body.%s", name, field.Name)
+			} else {
+				assert.False(t, set, "The example of %q should only set its feature, not Body.%s", name, field.Name)
+			}
+		}
+		email.Body = hermes.Body{}
+		assert.Equal(t, hermes.Email{}, email, "The example of %q should only set its body", name)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestFeatureExamples_Render(t *testing.T) {
+	for name, email := range hermes.FeatureExamples() {
+		assert.Nil(t, email.Validate(), name)
+		for _, theme := range testedThemes {
+			h := hermes.Hermes{Theme: theme}
+			_, err := h.RenderForSegment(email, hermes.DefaultSegment)
+			assert.Nil(t, err, theme.Name()+"/"+name)
+		}
+	}
+}
+
+// TestGolden_Features renders every feature example with every theme, so that a change to a feature shows in its own
+// golden files
+func TestGolden_Features(t *testing.T) {
+	for _, theme := range testedThemes {
+		for name, email := range hermes.FeatureExamples() {
+			t.Run(theme.Name()+"/"+name, func(t *testing.T) {
+				h := hermes.Hermes{Theme: theme, Brand: hermes.Branding{Name: "Hermes", Link: "https://example-hermes.com/"}}
+				out, err := h.RenderForSegment(email, hermes.DefaultSegment)
+				assert.Nil(t, err)
+				path := filepath.Join("golden", theme.Name(), "features", name)
+				assertGolden(t, path+".html", out.HTML)
+				assertGolden(t, path+".txt", out.PlainText)
+			})
+		}
+	}
+}
+
+func TestPreviewServer_Features(t *testing.T) {
+	h := hermes.Hermes{Theme: new(themes.Default)}
+	handler := preview.NewHandler(h, map[string]hermes.Email{})
+
+	w := previewGet(handler, http.MethodGet, "/")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "<li>No emails</li>")
+	assert.Contains(t, w.Body.String(), `<strong>actions</strong> <a href="/features/actions/html">html</a>`)
+
+	examples := hermes.FeatureExamples()
+	names := make([]string, 0, len(examples))
+	for name := range examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	assert.Less(t, strings.Index(w.Body.String(), "/features/"+names[0]+"/"), strings.Index(w.Body.String(), "/features/"+names[1]+"/"), "Features should be sorted")
+
+	expected, err := h.GeneratePlainText(examples["dictionary"])
+	assert.Nil(t, err)
+	w = previewGet(handler, http.MethodGet, "/features/dictionary/txt")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, expected, w.Body.String())
+
+	assert.Equal(t, http.StatusNotFound, previewGet(handler, http.MethodGet, "/features/unknown/html").Code)
+	assert.Equal(t, http.StatusNotFound, previewGet(handler, http.MethodGet, "/emails/dictionary/html").Code)
+}
+
+func TestCLI_Features(t *testing.T) {
+	run := buildCLI(t)
+	out := t.TempDir()
+	status, _, stderr := run("features", "--theme", "flat", "--out", out)
+	assert.Equal(t, 0, status, stderr)
+
+	h := hermes.Hermes{Theme: new(themes.Flat)}
+	for name, email := range hermes.FeatureExamples() {
+		expected, err := h.RenderForSegment(email, hermes.DefaultSegment)
+		assert.Nil(t, err)
+		html, err := os.ReadFile(filepath.Join(out, "features", name+".html"))
+		assert.Nil(t, err)
+		assert.Equal(t, expected.HTML, string(html), name)
+		text, err := os.ReadFile(filepath.Join(out, "features", name+".txt"))
+		assert.Nil(t, err)
+		assert.Equal(t, expected.PlainText, string(text), name)
+	}
+
+	status, _, _ = run("features", "--theme", "unknown")
+	assert.Equal(t, 3, status)
+	status, _, _ = run("features", "extra")
+	assert.Equal(t, 2, status)
+}
@@ -0,0 +1,71 @@
+package hermes
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+func TestFieldTemplate_Copyright(t *testing.T) {
+	h := hermes.Hermes{
+		Theme:    new(themes.Default),
+		Brand:    hermes.Branding{Name: "Hermes", Copyright: "© {{ .Year }} {{ .Hermes.Brand.Name }}, for {{ .Email.Body.Name }}"},
+		Now:      func() time.Time { return time.Date(2024, 12, 31, 23, 30, 0, 0, time.UTC) },
+		TimeZone: time.FixedZone("JST", 9*60*60),
+	}
+	html, text, err := h.Generate(hermes.Email{Body: hermes.Body{Name: "Jon Snow"}})
+	assert.Nil(t, err)
+	assert.Contains(t, html, "© 2025 Hermes, for Jon Snow", "The year should be the one of the time zone of the engine")
+	assert.Contains(t, text, "© 2025 Hermes, for Jon Snow")
+	assert.NotContains(t, html, "{{")
+
+	h.Brand.Copyright = "Copyright © 2024 {Hermes} & co"
+	html, err = h.GenerateHTML(hermes.Email{Body: hermes.Body{Name: "Jon Snow"}})
+	assert.Nil(t, err)
+	assert.Contains(t, html, "Copyright © 2024 {Hermes} &amp; co", "Values without actions should be kept as is")
+}
+
+func TestFieldTemplate_Greeting(t *testing.T) {
+	h := hermes.Hermes{Theme: new(themes.Default), DisableCSSInlining: true}
+	email := hermes.Email{Body: hermes.Body{Name: "ジョン", Greeting: "{{ .Email.Body.Name }}さん、こんにちは"}}
+	html, warnings, err := h.GenerateHTMLWithWarnings(email)
+	assert.Nil(t, err)
+	assert.Contains(t, html, "ジョンさん、こんにちは,</h1>")
+	assert.Equal(t, 1, strings.Count(html, "ジョン"), "The name written by the template should not be repeated")
+	assert.Empty(t, warnings)
+
+	email.Body = hermes.Body{Name: "Jon", Greeting: "{{ if eq .Email.Body.Name \"Jon\" }}Howdy{{ else }}Hello{{ end }}"}
+	html, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, html, ">Howdy Jon,</h1>")
+
+	h.DisableGreetingNameDedupe = true
+	email.Body = hermes.Body{Name: "Jon", Greeting: "Dear {{ .Email.Body.Name }}"}
+	html, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, html, ">Dear Jon Jon,</h1>")
+}
+
+func TestFieldTemplate_Errors(t *testing.T) {
+	h := hermes.Hermes{Theme: new(themes.Default), Brand: hermes.Branding{Name: "Hermes", Copyright: "© {{ .Yaer }}"}}
+	_, err := h.GenerateHTML(hermes.Email{Body: hermes.Body{Name: "Jon Snow"}})
+	assert.NotNil(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "Brand.Copyright: template: Brand.Copyright:1:6: executing"), err.Error())
+	assert.Contains(t, err.Error(), "can't evaluate field Yaer")
+
+	h.Brand.Copyright = ""
+	_, err = h.GeneratePlainText(hermes.Email{Body: hermes.Body{Name: "Jon Snow", Greeting: "Hi {{ .Email.Body.Name "}})
+	assert.NotNil(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "Body.Greeting: template: Body.Greeting:1: "), err.Error())
+
+	_, err = h.GenerateHTML(hermes.Email{LanguageVariants: []hermes.LanguageVariant{
+		{Tag: "en", Body: hermes.Body{Name: "Jon"}},
+		{Tag: "fr", Body: hermes.Body{Name: "Jon", Greeting: "{{ .Unknown }}"}},
+	}})
+	assert.NotNil(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "LanguageVariants[1].Body.Greeting: template:"), err.Error())
+}
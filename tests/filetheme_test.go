@@ -0,0 +1,109 @@
+package hermes
+
+import (
+	"embed"
+	"html/template"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+//go:embed testdata/filetheme
+var fileThemes embed.FS
+
+func TestFileTheme(t *testing.T) {
+	theme, err := themes.NewFromFiles("files", "testdata/filetheme/theme.html", "testdata/filetheme/theme.txt")
+	assert.Nil(t, err)
+	assert.Equal(t, "files", theme.Name())
+
+	h, email := (&SimpleExample{theme}).getExample()
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, r, "<h1>Hi Jon Snow,</h1>")
+	assert.Contains(t, r, "CONFIRM YOUR ACCOUNT", "Sprig functions should be available")
+
+	text, err := h.GeneratePlainText(email)
+	assert.Nil(t, err)
+	assert.Contains(t, text, "Hi Jon Snow,")
+	assert.Contains(t, text, "https://hermes-example.com/confirm?token=d9729feb74992cc3482b350163a1a010")
+}
+
+func TestFileTheme_FS(t *testing.T) {
+	embedded, err := themes.NewFromFS(fileThemes, "embedded", "testdata/filetheme/theme.html", "testdata/filetheme/theme.txt")
+	assert.Nil(t, err)
+	fromFiles, err := themes.NewFromFiles("embedded", "testdata/filetheme/theme.html", "testdata/filetheme/theme.txt")
+	assert.Nil(t, err)
+	assert.Equal(t, fromFiles.HTMLTemplate(), embedded.HTMLTemplate())
+	assert.Equal(t, fromFiles.PlainTextTemplate(), embedded.PlainTextTemplate())
+
+	mapFS := fstest.MapFS{
+		"email.html": {Data: []byte(`<p>{{ .Email.Body.Name }}</p>`)},
+		"email.txt":  {Data: []byte(`{{ .Email.Body.Name }}`)},
+	}
+	theme, err := themes.NewFromFS(mapFS, "map", "email.html", "email.txt")
+	assert.Nil(t, err)
+	h := hermes.Hermes{Theme: theme, DisableCSSInlining: true}
+	r, err := h.GenerateHTML(hermes.Email{Body: hermes.Body{Name: "Jon"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "<p>Jon</p>\n", r)
+}
+
+func TestFileTheme_Errors(t *testing.T) {
+	_, err := themes.NewFromFiles("missing", "testdata/filetheme/missing.html", "testdata/filetheme/theme.txt")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.EqualError(t, err, "theme missing: open testdata/filetheme/missing.html: no such file or directory")
+
+	_, err = themes.NewFromFiles("missing", "testdata/filetheme/theme.html", "testdata/filetheme/missing.txt")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	_, err = themes.NewFromFS(fstest.MapFS{
+		"email.html": {Data: []byte(`<p>{{ .Email.Body.Name }}</p>`)},
+		"email.txt":  {Data: []byte(`{{ if .Email.Body.Name }}`)},
+	}, "unclosed", "email.html", "email.txt")
+	assert.EqualError(t, err, "theme unclosed: template: email.txt:1: unexpected EOF")
+}
+
+func TestFileTheme_TemplateFuncs(t *testing.T) {
+	theme, err := themes.NewFromFiles("shouting", "testdata/filetheme/shout.html", "testdata/filetheme/theme.txt")
+	assert.Nil(t, err, "Functions should be checked by the engine, which may define them")
+
+	h := hermes.Hermes{Theme: theme, DisableCSSInlining: true}
+	assert.EqualError(t, h.Compile(), `template: hermes:1: function "shout" not defined`)
+
+	h.TemplateFuncs = template.FuncMap{"shout": strings.ToUpper}
+	assert.Nil(t, h.Compile())
+	r, err := h.GenerateHTML(hermes.Email{Body: hermes.Body{Name: "Jon"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "<p>JON</p>\n", r)
+}
+
+func TestFileTheme_Reload(t *testing.T) {
+	mapFS := fstest.MapFS{
+		"email.html": {Data: []byte(`<p>{{ .Email.Body.Name }}</p>`)},
+		"email.txt":  {Data: []byte(`{{ .Email.Body.Name }}`)},
+	}
+	theme, err := themes.NewFromFS(mapFS, "map", "email.html", "email.txt")
+	assert.Nil(t, err)
+	h := hermes.Hermes{Theme: theme, DisableCSSInlining: true}
+	email := hermes.Email{Body: hermes.Body{Name: "Jon"}}
+
+	mapFS["email.html"] = &fstest.MapFile{Data: []byte(`<h1>{{ .Email.Body.Name }}</h1>`)}
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Equal(t, "<p>Jon</p>\n", r, "Templates should only be read again by Reload")
+	assert.Nil(t, theme.Reload())
+	r, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Equal(t, "<h1>Jon</h1>\n", r)
+
+	mapFS["email.html"] = &fstest.MapFile{Data: []byte(`<h1>{{ .Email.Body.Name }</h1>`)}
+	assert.ErrorContains(t, theme.Reload(), "theme map: template: email.html:1: ")
+	r, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Equal(t, "<h1>Jon</h1>\n", r, "The previous templates should be kept")
+}
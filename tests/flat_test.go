@@ -0,0 +1,58 @@
+package hermes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/examples/mails"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+func TestFlat_Golden(t *testing.T) {
+	examples := []mails.Example{
+		new(mails.Welcome),
+		new(mails.Reset),
+		new(mails.Maintenance),
+		new(mails.Receipt),
+		new(mails.InviteCode),
+	}
+	for _, example := range examples {
+		h := hermes.Hermes{
+			Theme: new(themes.Flat),
+			Brand: hermes.Branding{
+				Name:      "Hermes",
+				Link:      "https://example-hermes.com/",
+				Logo:      "https://github.com/matcornic/hermes/blob/master/examples/gopher.png?raw=true",
+				Copyright: "Copyright © Hermes-Test",
+			},
+		}
+		html, text, err := h.Generate(example.Email())
+		assert.Nil(t, err)
+		assertGolden(t, "flat/"+example.Name()+".html", html)
+		assertGolden(t, "flat/"+example.Name()+".txt", text)
+	}
+}
+
+func TestFlat_Style(t *testing.T) {
+	h, email := (&SimpleExample{new(themes.Flat)}).getExample()
+	h.DisableCSSInlining = false
+	email.Body.Actions = append(email.Body.Actions, hermes.Action{
+		Button: hermes.Button{Text: "Default color", Link: "https://hermes-example.com/"},
+	})
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, r, "background-color:#22BC66", "Button colors should be honored")
+	assert.Contains(t, r, "background-color:#00948D", "Buttons should be solid teal by default")
+	assert.Contains(t, r, "border-radius:0")
+	assert.Contains(t, r, "background-color:#2C3E50", "Masthead should be a solid band")
+	assert.NotContains(t, r, "text-shadow")
+	assert.NotContains(t, r, "roundrect")
+
+	parsed, _, err := hermes.ParseEmail(r)
+	assert.Nil(t, err)
+	assert.Equal(t, email.Body.Dictionary, parsed.Body.Dictionary)
+	assert.Equal(t, email.Body.Table.Data, parsed.Body.Table.Data)
+	assert.Equal(t, email.Body.Actions[0].Button, parsed.Body.Actions[0].Button)
+	assert.Equal(t, email.Body.Actions[1].Button, parsed.Body.Actions[1].Button)
+}
@@ -0,0 +1,70 @@
+package hermes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+func footerExample(theme hermes.Theme) (hermes.Hermes, hermes.Email) {
+	h, email := (&SimpleExample{theme}).getExample()
+	h.DisableCSSInlining = false
+	h.Brand.SocialLinks = []hermes.SocialLink{
+		{Name: "GitHub", URL: "https://github.com/matcornic/hermes", IconURL: "https://hermes-example.com/icons/github.png"},
+		{Name: "LinkedIn", URL: "https://www.linkedin.com/company/hermes"},
+	}
+	h.Brand.Address = "Hermes Inc., 1 Market St, San Francisco, CA 94105"
+	h.Brand.UnsubscribeLink = "https://hermes-example.com/unsubscribe?token=d9729feb&list=news"
+	return h, email
+}
+
+func TestFooter(t *testing.T) {
+	for _, theme := range testedThemes {
+		h, email := footerExample(theme)
+		html, text, err := h.Generate(email)
+		assert.Nil(t, err, theme.Name())
+
+		links := attributeValues(t, html, "href")["a"]
+		assert.Contains(t, links, "https://github.com/matcornic/hermes", theme.Name())
+		assert.Contains(t, links, "https://www.linkedin.com/company/hermes", theme.Name())
+		assert.Contains(t, links, "https://hermes-example.com/unsubscribe?token=d9729feb&list=news", theme.Name())
+		assert.Contains(t, attributeValues(t, html, "src")["img"], "https://hermes-example.com/icons/github.png", theme.Name())
+		assert.Contains(t, html, `alt="GitHub"`, theme.Name())
+		assert.Regexp(t, `target="_blank"[^>]*>LinkedIn</a>`, html, "Links without icon should show their name, with %s", theme.Name())
+		assert.Regexp(t, `data-hermes="address"[^>]*>Hermes Inc., 1 Market St, San Francisco, CA 94105</p>`, html, theme.Name())
+		assert.Regexp(t, `data-hermes="unsubscribe"[^>]*>Unsubscribe</a>`, html, theme.Name())
+
+		assert.Contains(t, text, "GitHub: https://github.com/matcornic/hermes", theme.Name())
+		assert.Contains(t, text, "LinkedIn: https://www.linkedin.com/company/hermes", theme.Name())
+		assert.Contains(t, text, "Hermes Inc., 1 Market St, San Francisco, CA 94105", theme.Name())
+		assert.Contains(t, text, "Unsubscribe: https://hermes-example.com/unsubscribe?token=d9729feb&list=news", theme.Name())
+		assert.NotContains(t, text, "github.png", "Icons should not be in plain text, with %s", theme.Name())
+	}
+}
+
+func TestFooter_Empty(t *testing.T) {
+	for _, theme := range testedThemes {
+		h, email := footerExample(theme)
+		withFooter, err := h.GenerateHTML(email)
+		assert.Nil(t, err, theme.Name())
+		assert.Contains(t, withFooter, `data-hermes="social"`, theme.Name())
+
+		h.Brand.SocialLinks, h.Brand.Address, h.Brand.UnsubscribeLink = nil, "", ""
+		html, text, err := h.Generate(email)
+		assert.Nil(t, err, theme.Name())
+		assert.NotContains(t, html, `data-hermes="social"`, theme.Name())
+		assert.NotContains(t, html, `data-hermes="address"`, theme.Name())
+		assert.NotContains(t, html, `data-hermes="unsubscribe"`, theme.Name())
+		assert.NotContains(t, text, "Unsubscribe", theme.Name())
+	}
+}
+
+func TestFooter_Locale(t *testing.T) {
+	h, email := footerExample(testedThemes[0])
+	h.Locale = "es"
+	html, text, err := h.Generate(email)
+	assert.Nil(t, err)
+	assert.Regexp(t, `data-hermes="unsubscribe"[^>]*>Cancelar suscripción</a>`, html)
+	assert.Contains(t, text, "Cancelar suscripción: https://hermes-example.com/unsubscribe")
+}
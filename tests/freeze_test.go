@@ -0,0 +1,169 @@
+package hermes
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+var frozenPlaceholders = []string{"Body.Name", "Body.Actions[0].Button.Link"}
+
+func TestFreeze_Instantiate(t *testing.T) {
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+
+	frozen, err := h.Freeze(email, frozenPlaceholders)
+	assert.Nil(t, err)
+
+	out, err := frozen.Instantiate(map[string]string{
+		"Body.Name":                   "Arya Stark & co",
+		"Body.Actions[0].Button.Link": "https://hermes-example.com/confirm?token=abc&user=arya",
+	})
+	assert.Nil(t, err)
+
+	assert.Contains(t, out.HTML, "Hi Arya Stark &amp; co", "Name should be substituted and escaped in HTML")
+	assert.Contains(t, out.HTML, `href="https://hermes-example.com/confirm?token=abc&amp;user=arya"`, "Link should be substituted in HTML")
+	assert.Contains(t, out.PlainText, "Hi Arya Stark & co", "Name should be substituted as is in plain text")
+	assert.Contains(t, out.PlainText, "https://hermes-example.com/confirm?token=abc&user=arya", "Link should be substituted as is in plain text")
+	assert.NotContains(t, out.HTML, "hermesfrozen", "No token should be left in HTML")
+	assert.NotContains(t, out.PlainText, "hermesfrozen", "No token should be left in plain text")
+
+	// The original email is untouched
+	assert.Equal(t, "Jon Snow", email.Body.Name)
+	assert.Equal(t, "https://hermes-example.com/confirm?token=d9729feb74992cc3482b350163a1a010", email.Body.Actions[0].Button.Link)
+}
+
+func TestFreeze_InvalidPlaceholders(t *testing.T) {
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+
+	_, err := h.Freeze(email, []string{"Body.Unknown"})
+	assert.EqualError(t, err, `invalid placeholder "Body.Unknown": unknown field "Unknown"`)
+
+	_, err = h.Freeze(email, []string{"Body.Actions[3].Button.Link"})
+	assert.Error(t, err, "Out of range index should be rejected")
+
+	_, err = h.Freeze(email, []string{"Body.Intros"})
+	assert.Error(t, err, "Non string fields should be rejected")
+
+	_, err = h.Freeze(email, []string{"Body.Name", "Body.Name"})
+	assert.Error(t, err, "Duplicated placeholders should be rejected")
+}
+
+func TestFrozen_InstantiateValidation(t *testing.T) {
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+
+	frozen, err := h.Freeze(email, frozenPlaceholders)
+	assert.Nil(t, err)
+
+	_, err = frozen.Instantiate(map[string]string{"Body.Name": "Arya"})
+	assert.EqualError(t, err, `missing value for placeholder "Body.Actions[0].Button.Link"`)
+
+	_, err = frozen.Instantiate(map[string]string{"Body.Name": "Arya", "Body.Actions[0].Button.Link": "x", "Body.Title": "x"})
+	assert.EqualError(t, err, `unknown placeholder "Body.Title"`)
+
+	values := map[string]string{"Body.Name": "<b>Arya</b>", "Body.Actions[0].Button.Link": "x"}
+	_, err = frozen.Instantiate(values)
+	assert.EqualError(t, err, `value of placeholder "Body.Name" contains markup`)
+
+	frozen.AllowMarkup = true
+	out, err := frozen.Instantiate(values)
+	assert.Nil(t, err)
+	assert.Contains(t, out.HTML, "&lt;b&gt;Arya&lt;/b&gt;", "Allowed markup should still be escaped in HTML")
+}
+
+func TestFrozen_InstantiateUnsafeURL(t *testing.T) {
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+	frozen, err := h.Freeze(email, frozenPlaceholders)
+	assert.Nil(t, err)
+
+	for _, link := range []string{"javascript:alert(1)", " JavaScript:alert(1)", "data:text/html;base64,PHNjcmlwdD4=", "data:image/svg+xml;base64,PHN2Zz4="} {
+		_, err = frozen.Instantiate(map[string]string{"Body.Name": "Arya", "Body.Actions[0].Button.Link": link})
+		assert.EqualError(t, err, `value of placeholder "Body.Actions[0].Button.Link" is not a safe URL`, link)
+	}
+
+	// Only the values of URLs are checked
+	for _, link := range []string{"mailto:arya@example.com", "data:image/png;base64,iVBORw0KGgo=", "https://hermes-example.com/"} {
+		_, err = frozen.Instantiate(map[string]string{"Body.Name": "Arya", "Body.Actions[0].Button.Link": link})
+		assert.Nil(t, err, link)
+	}
+	_, err = frozen.Instantiate(map[string]string{"Body.Name": "javascript:alert(1)", "Body.Actions[0].Button.Link": "https://hermes-example.com/"})
+	assert.Nil(t, err)
+}
+
+func TestFreeze_StrictValidation(t *testing.T) {
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+	h.StrictValidation = true
+
+	frozen, err := h.Freeze(email, frozenPlaceholders)
+	assert.Nil(t, err, "The tokens of the placeholders should not be validated")
+	out, err := frozen.Instantiate(map[string]string{"Body.Name": "Arya", "Body.Actions[0].Button.Link": "https://hermes-example.com/arya"})
+	assert.Nil(t, err)
+	assert.Contains(t, out.HTML, `href="https://hermes-example.com/arya"`)
+	_, err = frozen.Instantiate(map[string]string{"Body.Name": "Arya", "Body.Actions[0].Button.Link": "/arya"})
+	assert.EqualError(t, err, `value of placeholder "Body.Actions[0].Button.Link" is not an absolute URL`)
+
+	// The other fields are still validated
+	email.Body.Actions[0].Button.Link = "/confirm"
+	_, err = h.Freeze(email, []string{"Body.Name"})
+	assert.ErrorContains(t, err, "Body.Actions[0].Button.Link: must be an absolute URL")
+
+	h.StrictValidation = false
+	frozen, err = h.Freeze(email, frozenPlaceholders)
+	assert.Nil(t, err)
+	_, err = frozen.Instantiate(map[string]string{"Body.Name": "Arya", "Body.Actions[0].Button.Link": "/arya"})
+	assert.Nil(t, err, "Relative links are only rejected with StrictValidation")
+}
+
+func TestFrozen_InstantiateTracking(t *testing.T) {
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+	h.TrackingParams = map[string]string{"utm_source": "newsletter"}
+	h.LinkRewriter = func(link string) string {
+		return "https://click.hermes-example.com/?u=" + url.QueryEscape(link)
+	}
+	frozen, err := h.Freeze(email, frozenPlaceholders)
+	assert.Nil(t, err)
+	out, err := frozen.Instantiate(map[string]string{"Body.Name": "Arya", "Body.Actions[0].Button.Link": "https://hermes-example.com/arya?id=1"})
+	assert.Nil(t, err)
+
+	email.Body.Name = "Arya"
+	email.Body.Actions[0].Button.Link = "https://hermes-example.com/arya?id=1"
+	html, text, err := h.Generate(email)
+	assert.Nil(t, err)
+	assert.Equal(t, html, out.HTML, "The links should be tracked like the ones of GenerateHTML")
+	assert.Equal(t, text, out.PlainText)
+	assert.Contains(t, out.HTML, `href="https://click.hermes-example.com/?u=https%3A%2F%2Fhermes-example.com%2Farya%3Fid%3D1%26utm_source%3Dnewsletter"`)
+}
+
+func BenchmarkGeneratePerRecipient(b *testing.B) {
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+	h.DisableCSSInlining = false
+	for i := 0; i < b.N; i++ {
+		email.Body.Name = "Arya Stark"
+		if _, err := h.GenerateHTML(email); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := h.GeneratePlainText(email); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFrozenInstantiate(b *testing.B) {
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+	h.DisableCSSInlining = false
+	frozen, err := h.Freeze(email, frozenPlaceholders)
+	if err != nil {
+		b.Fatal(err)
+	}
+	values := map[string]string{
+		"Body.Name":                   "Arya Stark",
+		"Body.Actions[0].Button.Link": "https://hermes-example.com/confirm?token=abc",
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := frozen.Instantiate(values); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package hermes
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+// funcsTheme is a tenant theme made of a single template
+type funcsTheme string
+
+func (funcsTheme) Name() string                { return "funcs" }
+func (t funcsTheme) HTMLTemplate() string      { return string(t) }
+func (t funcsTheme) PlainTextTemplate() string { return string(t) }
+
+func funcsExample(theme string, intro string) (hermes.Hermes, hermes.Email) {
+	h := hermes.Hermes{Theme: funcsTheme(theme), DisableCSSInlining: true}
+	email := hermes.Email{Body: hermes.Body{Intros: []string{intro}}}
+	return h, email
+}
+
+func TestFuncs_RepeatCapped(t *testing.T) {
+	h, email := funcsExample(`{{ repeat 100000000 "x" }}`, "")
+	start := time.Now()
+	_, err := h.GenerateHTML(email)
+	assert.ErrorContains(t, err, "repeat: output of 100000000 bytes exceeds the limit of 1048576 bytes")
+	assert.Less(t, time.Since(start), time.Second, "Should fail fast")
+
+	h, email = funcsExample(`{{ repeat 3 "ab" }}`, "")
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Equal(t, "ababab\n", r)
+
+	h.FuncLimits.MaxOutputSize = 4
+	_, err = h.GenerateHTML(email)
+	assert.ErrorContains(t, err, "repeat: output of 6 bytes exceeds the limit of 4 bytes")
+
+	h.FuncPolicy = hermes.FuncsFull
+	r, err = h.GenerateHTML(email)
+	assert.Nil(t, err, "Full policy keeps raw sprig functions")
+	assert.Equal(t, "ababab\n", r)
+}
+
+func TestFuncs_RegexInputCapped(t *testing.T) {
+	theme := `{{ regexReplaceAll "(a+)+b" (index .Email.Body.Intros 0) "x" }}`
+	h, email := funcsExample(theme, strings.Repeat("a", 100000))
+	start := time.Now()
+	_, err := h.GenerateHTML(email)
+	assert.ErrorContains(t, err, "regexReplaceAll: input of 100007 bytes exceeds the limit of 65536 bytes")
+	assert.Less(t, time.Since(start), time.Second, "Should fail fast")
+
+	h, email = funcsExample(theme, "aaab-ab")
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Equal(t, "x-x\n", r)
+
+	h, email = funcsExample(`{{ mustRegexFind "[" "a" }}`, "")
+	_, err = h.GenerateHTML(email)
+	assert.ErrorContains(t, err, "missing closing ]", "Errors of must functions are kept")
+}
+
+func TestFuncs_IterationsCapped(t *testing.T) {
+	for theme, message := range map[string]string{
+		`{{ range until 1000000000 }}{{ end }}`:         "until: 1000000000 iterations exceed the limit of 10000",
+		`{{ range untilStep 0 1000000000 2 }}{{ end }}`: "untilStep: 500000000 iterations exceed the limit of 10000",
+		`{{ seq -1000000000 }}`:                         "seq: 1000000002 iterations exceed the limit of 10000",
+		`{{ seq 0 1 1000000000 }}`:                      "seq: 1000000001 iterations exceed the limit of 10000",
+	} {
+		h, email := funcsExample(theme, "")
+		_, err := h.GenerateHTML(email)
+		assert.ErrorContains(t, err, message)
+	}
+
+	h, email := funcsExample(`{{ seq 3 }}|{{ seq 5 -2 1 }}|{{ until 3 }}`, "")
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Equal(t, "1 2 3|5 3 1|[0 1 2]\n", r)
+}
+
+func TestFuncs_CompiledPolicy(t *testing.T) {
+	h, email := funcsExample(`{{ repeat 3 "ab" }}`, "")
+	h.FuncLimits.MaxOutputSize = 4
+	assert.Nil(t, h.Compile())
+	_, err := h.GenerateHTML(email)
+	assert.NotNil(t, err)
+
+	h.FuncPolicy = hermes.FuncsFull
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err, "Templates compiled with another policy should not be used")
+	assert.Equal(t, "ababab\n", r)
+}
+
+func TestFuncs_TemplateFuncs(t *testing.T) {
+	messages := map[string]string{"total": "Total"}
+	h, email := funcsExample(`{{ t "total" }}: {{ currency 1099 "EUR" }} {{ upper "ok" }}`, "")
+	h.TemplateFuncs = template.FuncMap{
+		"currency": func(cents int, code string) string { return fmt.Sprintf("%d.%02d %s", cents/100, cents%100, code) },
+		"t":        func(key string) string { return messages[key] },
+		// Overrides sprig
+		"upper": func(s string) string { return "UPPER(" + s + ")" },
+	}
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Equal(t, "Total: 10.99 EUR UPPER(ok)\n", r)
+	text, err := h.GeneratePlainText(email)
+	assert.Nil(t, err)
+	assert.Equal(t, "Total: 10.99 EUR UPPER(ok)\n", text)
+
+	// Functions are not shared through the cache of templates
+	messages = map[string]string{"total": "Gesamt"}
+	other := h
+	other.TemplateFuncs = template.FuncMap{"currency": h.TemplateFuncs["currency"], "t": func(key string) string { return messages[key] }}
+	r, err = other.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Equal(t, "Gesamt: 10.99 EUR OK\n", r)
+	_, err = (&hermes.Hermes{Theme: h.Theme}).GenerateHTML(email)
+	assert.ErrorContains(t, err, `function "t" not defined`)
+
+	assert.Nil(t, h.Compile())
+	r, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Equal(t, "Gesamt: 10.99 EUR UPPER(ok)\n", r)
+}
+
+func TestFuncs_TemplateFuncsErrors(t *testing.T) {
+	for name, test := range map[string]struct {
+		fn  interface{}
+		err string
+	}{
+		"url":    {func(s string) string { return s }, `template function "url" is reserved by hermes`},
+		"safe":   {func(s string) string { return s }, `template function "safe" is reserved by hermes`},
+		"nil":    {nil, `template function "nil" is not a function`},
+		"nilFn":  {(func() string)(nil), `template function "nilFn" is not a function`},
+		"string": {"currency", `template function "string" is not a function`},
+		"void":   {func() {}, `template function "void" must return a value, and optionally an error`},
+		"pair":   {func() (string, string) { return "", "" }, `template function "pair" must return a value, and optionally an error`},
+	} {
+		h, email := funcsExample(`{{ "ok" }}`, "")
+		h.TemplateFuncs = template.FuncMap{name: test.fn}
+		_, err := h.GenerateHTML(email)
+		assert.EqualError(t, err, test.err, name)
+		assert.EqualError(t, h.Compile(), test.err, name)
+	}
+
+	h, email := funcsExample(`{{ check }}`, "")
+	h.TemplateFuncs = template.FuncMap{"check": func() (string, error) { return "", errors.New("failed") }}
+	_, err := h.GenerateHTML(email)
+	assert.ErrorContains(t, err, "failed", "Functions may return an error")
+}
@@ -0,0 +1,56 @@
+package hermes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+func TestGenerate_SameAsSeparateCalls(t *testing.T) {
+	for _, theme := range testedThemes {
+		h, email := (&SimpleExample{theme}).getExample()
+		h.DisableCSSInlining = false
+
+		expectedHTML, err := h.GenerateHTML(email)
+		assert.Nil(t, err)
+		expectedText, err := h.GeneratePlainText(email)
+		assert.Nil(t, err)
+
+		html, text, err := h.Generate(email)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedHTML, html, theme.Name())
+		assert.Equal(t, expectedText, text, theme.Name())
+	}
+}
+
+func TestGenerate_RendersEachVersionOnce(t *testing.T) {
+	h, email := (&SimpleExample{}).getExample()
+	h.Pipeline = hermes.DefaultPipeline()
+	h.Pipeline.Stats = &hermes.PipelineStats{}
+
+	var formats []bool
+	assert.Nil(t, h.Pipeline.InsertAfter(hermes.StageTemplateExecute, hermes.Stage{
+		Name: "Record",
+		Run: func(r *hermes.Rendering) error {
+			formats = append(formats, r.PlainText)
+			return nil
+		},
+	}))
+
+	_, _, err := h.Generate(email)
+	assert.Nil(t, err)
+	assert.Equal(t, []bool{false, true}, formats)
+	assert.Equal(t, uint64(2), h.Pipeline.Stats.Stages()[hermes.StageTemplateExecute].Runs)
+}
+
+func TestGenerate_Error(t *testing.T) {
+	h, email := paramsExample()
+	h.StrictParams = true
+	email.Body.Dictionary[0].Value = "{plan} until {expiry}"
+
+	html, text, err := h.Generate(email)
+	assert.EqualError(t, err, "Body.Dictionary[0].Value: unresolved placeholder {expiry}")
+	assert.Empty(t, html)
+	assert.Empty(t, text)
+}
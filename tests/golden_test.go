@@ -0,0 +1,83 @@
+package hermes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/examples/mails"
+	"github.com/unknowns24/hermes/pkg/hermestest"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+// TestGolden renders every example with every theme, run with -update to write the golden files again
+func TestGolden(t *testing.T) {
+	examples := []interface {
+		Email() hermes.Email
+		Name() string
+	}{
+		new(mails.Welcome),
+		new(mails.Reset),
+		new(mails.Maintenance),
+		new(mails.Receipt),
+		new(mails.InviteCode),
+	}
+	for _, theme := range testedThemes {
+		for _, example := range examples {
+			t.Run(theme.Name()+"/"+example.Name(), func(t *testing.T) {
+				h := hermes.Hermes{
+					Theme: theme,
+					Brand: hermes.Branding{
+						Name: "Hermes",
+						Link: "https://example-hermes.com/",
+						Logo: "https://github.com/matcornic/hermes/blob/master/examples/gopher.png?raw=true",
+					},
+				}
+				hermestest.AssertRender(t, h, example.Email(), filepath.Join("testdata", "golden", theme.Name(), example.Name()))
+			})
+		}
+	}
+}
+
+func TestAssertRender(t *testing.T) {
+	if *update {
+		t.Skip("AssertRender is tested without -update")
+	}
+	dir := t.TempDir()
+	h, email := (&SimpleExample{new(themes.Flat)}).getExample()
+	path := filepath.Join(dir, "custom", "welcome")
+
+	r := &recorder{TB: t}
+	assert.False(t, hermestest.AssertRender(r, h, email, path))
+	assert.Len(t, r.errors, 2)
+	assert.Contains(t, r.errors[0], "run the tests with -update to write it")
+
+	hermestest.Update = true
+	r = &recorder{TB: t}
+	assert.True(t, hermestest.AssertRender(r, h, email, path))
+	hermestest.Update = false
+	assert.FileExists(t, path+".html")
+	assert.FileExists(t, path+".txt")
+	assert.True(t, hermestest.AssertRender(t, h, email, path))
+
+	email.Body.Intros = append(email.Body.Intros, "Winter is coming.")
+	r = &recorder{TB: t}
+	assert.False(t, hermestest.AssertRender(r, h, email, path))
+	assert.Len(t, r.errors, 2)
+	assert.Contains(t, r.errors[1], "Output differs from "+path+".txt")
+	assert.Contains(t, r.errors[1], "\n+Winter is coming.\n")
+	assert.NotContains(t, r.errors[1], "Copyright", "Only the lines around the changes should be reported")
+
+	golden, err := os.ReadFile(path + ".txt")
+	assert.Nil(t, err)
+	assert.NotContains(t, string(golden), "Winter", "Golden files should not be written without Update")
+}
+
+func TestDiff(t *testing.T) {
+	assert.Equal(t, "", hermestest.Diff("a\nb\n", "a\nb\n"))
+	assert.Equal(t, "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n", hermestest.Diff("a\nb\nc", "a\nB\nc"))
+	assert.Equal(t, "@@ -2,7 +2,6 @@\n 2\n 3\n 4\n-5\n 6\n 7\n 8\n@@ -13,3 +12,4 @@\n 13\n 14\n 15\n+16\n",
+		hermestest.Diff("1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15", "1\n2\n3\n4\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16"))
+}
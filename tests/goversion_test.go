@@ -0,0 +1,7 @@
+//go:build go1.21
+
+package hermes
+
+// supportedGoVersion is the oldest Go release hermes supports, its tests do not build with older ones.
+// The send package relies on context.AfterFunc and context.WithoutCancel, added in Go 1.21.
+const supportedGoVersion = "go1.21"
@@ -0,0 +1,74 @@
+package hermes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+func TestGreetingName(t *testing.T) {
+	tests := []struct {
+		name     string
+		greeting string
+		expected string
+		deduped  bool
+	}{
+		{"Jane Smith", "Dear Dr. Jane Smith", "Dear Dr. Jane Smith,", true},
+		{"Jane Smith", "Dear Dr. Jane Smith,", "Dear Dr. Jane Smith,", true},
+		{"Jane Smith", "dear dr.  JANE\n  smith", "dear dr. JANE smith,", true},
+		{" jane  smith ", "Hello Jane Smith and team", "Hello Jane Smith and team,", true},
+		{"Jane Smith", "Jane Smith, welcome", "Jane Smith, welcome,", true},
+		{"Jane Smith", "Dear Jane", "Dear Jane Jane Smith,", false},
+		{"Ann", "Dear Joanne", "Dear Joanne Ann,", false},
+		{"Jane Smith", "Hi", "Hi Jane Smith,", false},
+		{"Иван Петров", "Уважаемый ИВАН ПЕТРОВ", "Уважаемый ИВАН ПЕТРОВ,", true},
+		{"山田太郎", "山田太郎様", "山田太郎様,", true},
+		{"Ελένη", "Αγαπητή ελένη", "Αγαπητή ελένη,", true},
+	}
+	for _, test := range tests {
+		h := hermes.Hermes{Theme: funcsTheme("{{ .Email.Body.Greeting }} {{ .Email.Body.Name }},"), DisableCSSInlining: true}
+		email := hermes.Email{Body: hermes.Body{Name: test.name, Greeting: test.greeting}}
+		html, warnings, err := h.GenerateHTMLWithWarnings(email)
+		assert.Nil(t, err)
+		assert.Equal(t, test.expected, strings.Join(strings.Fields(html), " "), test.greeting)
+		assert.Equal(t, hermes.Email{Body: hermes.Body{Name: test.name, Greeting: test.greeting}}, email, "The email should not be changed")
+		if test.deduped {
+			assert.Len(t, warnings, 1, test.greeting)
+			assert.Equal(t, hermes.IssueGreetingName, warnings[0].Code)
+			assert.Equal(t, hermes.SeverityWarning, warnings[0].Severity)
+			assert.Equal(t, "Body.Greeting", warnings[0].Path)
+		} else {
+			assert.Empty(t, warnings, test.greeting)
+		}
+	}
+}
+
+func TestGreetingName_Themes(t *testing.T) {
+	for _, theme := range testedThemes {
+		h, email := (&SimpleExample{theme}).getExample()
+		email.Body.Greeting = "Dear Dr. Jon Snow"
+		html, warnings, err := h.GenerateHTMLWithWarnings(email)
+		assert.Nil(t, err)
+		assert.Contains(t, html, `data-hermes-greeting="Dear Dr.">Dear Dr. Jon Snow,</h1>`, theme.Name())
+		assert.Len(t, warnings, 1)
+		text, err := h.GeneratePlainText(email)
+		assert.Nil(t, err)
+		assert.Contains(t, text, "Dear Dr. Jon Snow,\n", theme.Name())
+		assert.NotContains(t, text, "Jon Snow Jon Snow", theme.Name())
+
+		h.DisableGreetingNameDedupe = true
+		html, warnings, err = h.GenerateHTMLWithWarnings(email)
+		assert.Nil(t, err)
+		assert.Contains(t, html, ">Dear Dr. Jon Snow Jon Snow,</h1>", theme.Name())
+		assert.Empty(t, warnings)
+
+		// Titles replace the greeting
+		h.DisableGreetingNameDedupe = false
+		email.Body.Title = "Welcome Jon Snow"
+		_, warnings, err = h.GenerateHTMLWithWarnings(email)
+		assert.Nil(t, err)
+		assert.Empty(t, warnings)
+	}
+}
@@ -0,0 +1,163 @@
+package hermes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/examples/mails"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+func TestHash_Golden(t *testing.T) {
+	// Hashes must not change across releases, or caches and ETags keyed by them would be invalidated on upgrades
+	var hashes strings.Builder
+	for _, example := range mails.All() {
+		hashes.WriteString(example.Name() + " " + example.Email().Hash() + "\n")
+	}
+	assertGolden(t, "hash/emails.txt", hashes.String())
+}
+
+func TestHash_Stable(t *testing.T) {
+	for _, theme := range testedThemes {
+		h, email := (&SimpleExample{theme}).getExample()
+		first, err := h.GenerateContext(context.Background(), email)
+		assert.Nil(t, err)
+		h, email = (&SimpleExample{theme}).getExample()
+		second, err := h.GenerateContext(context.Background(), email)
+		assert.Nil(t, err)
+		assert.Equal(t, first.Hash(), second.Hash(), theme.Name())
+		assert.Equal(t, email.Hash(), email.Hash(), theme.Name())
+		assert.Len(t, first.Hash(), 64)
+
+		second.PlainText += " "
+		assert.NotEqual(t, first.Hash(), second.Hash(), theme.Name())
+	}
+}
+
+func TestHash_Email(t *testing.T) {
+	_, email := (&SimpleExample{}).getExample()
+	hash := email.Hash()
+
+	email.Body.Tables = []hermes.Table{}
+	email.Params = map[string]string{}
+	assert.Equal(t, hash, email.Hash(), "Nil and empty values should hash the same")
+
+	email.Params = map[string]string{"a": "1", "b": "2", "c": "3"}
+	hash = email.Hash()
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, hash, email.Hash(), "Map order should not change the hash")
+	}
+	email.Params["a"] = "12"
+	email.Params["b"] = ""
+	assert.NotEqual(t, hash, email.Hash(), "Strings should not run into each other")
+
+	email.Body.Intros = []string{"ab", "c"}
+	hash = email.Hash()
+	email.Body.Intros = []string{"a", "bc"}
+	assert.NotEqual(t, hash, email.Hash())
+}
+
+func TestRenderCache(t *testing.T) {
+	renders := 0
+	h, email := (&SimpleExample{testedThemes[0]}).getExample()
+	h.RenderCache = hermes.NewMemoryRenderCache(1)
+	h.AfterRender = func(context.Context, hermes.Email, hermes.Output, hermes.RenderStats) error {
+		renders++
+		return nil
+	}
+
+	first, err := h.GenerateContext(context.Background(), email)
+	assert.Nil(t, err)
+	cached, err := h.GenerateContext(context.Background(), email)
+	assert.Nil(t, err)
+	assert.Equal(t, first, cached)
+	assert.Equal(t, 1, renders, "Cached outputs should not be rendered again")
+
+	// Another engine sharing the cache renders the email again
+	other := h
+	other.Theme = testedThemes[1]
+	out, err := other.GenerateContext(context.Background(), email)
+	assert.Nil(t, err)
+	assert.NotEqual(t, first.HTML, out.HTML)
+	assert.Equal(t, 2, renders)
+	assert.Equal(t, 1, h.RenderCache.(*hermes.MemoryRenderCache).Len())
+
+	// The first output was evicted
+	email.Body.Name = "Jane"
+	_, err = h.GenerateContext(context.Background(), email)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, renders)
+}
+
+func TestPreviewHandler(t *testing.T) {
+	h, email := (&SimpleExample{testedThemes[0]}).getExample()
+	h.RenderCache = hermes.NewMemoryRenderCache(0)
+	handler := hermes.NewPreviewHandler(h, func(r *http.Request) (hermes.Email, error) {
+		if r.URL.Path != "/welcome" {
+			return hermes.Email{}, errors.New("unknown email")
+		}
+		return email, nil
+	})
+	get := func(target string, etag string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		if etag != "" {
+			r.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := get("/welcome", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+	assert.Contains(t, w.Body.String(), "<html")
+	etag := w.Header().Get("ETag")
+	assert.Regexp(t, `^"[0-9a-f]{64}"$`, etag)
+
+	for _, header := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+		w = get("/welcome", header)
+		assert.Equal(t, http.StatusNotModified, w.Code, header)
+		assert.Empty(t, w.Body.String(), header)
+		assert.Equal(t, etag, w.Header().Get("ETag"), header)
+	}
+
+	w = get("/welcome?format=text", etag)
+	assert.Equal(t, http.StatusOK, w.Code, "The text body should have its own ETag")
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.NotContains(t, w.Body.String(), "<html")
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+
+	// A changed email gets a new ETag
+	email.Body.Name = "Jane"
+	w = get("/welcome", etag)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+
+	assert.Equal(t, http.StatusNotFound, get("/unknown", "").Code)
+	h.Theme = funcsTheme("{{ fail }")
+	broken := hermes.NewPreviewHandler(h, func(*http.Request) (hermes.Email, error) { return email, nil })
+	w = httptest.NewRecorder()
+	broken.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	w = httptest.NewRecorder()
+	broken.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestHash_Times(t *testing.T) {
+	at := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	email := hermes.Email{Body: hermes.Body{Schedule: []hermes.ScheduleEntry{{Label: "Maintenance", Start: at}}}}
+	hash := email.Hash()
+	email.Body.Schedule[0].Start = at.In(time.FixedZone("UTC-3", -3*3600))
+	assert.Equal(t, hash, email.Hash(), "Times should be hashed as instants")
+	email.Body.Schedule[0].Start = at.Add(time.Minute)
+	assert.NotEqual(t, hash, email.Hash())
+}
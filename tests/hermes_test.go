@@ -11,6 +11,8 @@ import (
 var testedThemes = []hermes.Theme{
 	// Insert your new theme here
 	new(themes.Default),
+	new(themes.Corporate),
+	new(themes.Flat),
 }
 
 /////////////////////////////////////////////////////
@@ -134,17 +136,11 @@ func (ed *SimpleExample) assertPlainTextContent(t *testing.T, r string) {
 	assert.Contains(t, r, "Welcome to Hermes", "Intro: Should have intro")
 	assert.Contains(t, r, "Birthday", "Dictionary: Should have dictionary")
 	assert.Contains(t, r, "Open source", "Table: Should have table content")
-	assert.Contains(t, r, `+--------+--------------------------------+--------+
-|  ITEM  |          DESCRIPTION           | PRICE  |
-+--------+--------------------------------+--------+
-| Golang | Open source programming        | $10.99 |
-|        | language that makes it easy    |        |
-|        | to build simple, reliable, and |        |
-|        | efficient software             |        |
-| Hermes | Programmatically create        | $1.99  |
-|        | beautiful e-mails using        |        |
-|        | Golang.                        |        |
-+--------+--------------------------------+--------`, "Table: Should have pretty table content")
+	assert.Contains(t, r, `Item    Description                                                                                            Price
+----    -----------                                                                                            -----
+Golang  Open source programming language that makes it easy to build simple, reliable, and efficient software  $10.99
+Hermes  Programmatically create beautiful e-mails using Golang.                                                $1.99`,
+		"Table: Should have aligned table content")
 	assert.Contains(t, r, "started with Hermes", "Action: Should have instruction")
 	assert.NotContains(t, r, "Confirm your account", "Action: Should not have button of action in plain text")
 	assert.NotContains(t, r, "#22BC66", "Action: Button should not have color in plain text")
@@ -484,9 +480,10 @@ func TestHermes_TextDirectionAsDefault(t *testing.T) {
 		},
 	}
 
-	_, err := h.GenerateHTML(email)
+	r, err := h.GenerateHTML(email)
 	assert.Nil(t, err)
-	assert.Equal(t, h.Theme.Name(), "default")
+	assert.Contains(t, r, `<body dir="ltr">`)
+	assert.Nil(t, h.Theme, "Default values should not be written to the engine")
 }
 
 func TestHermes_Default(t *testing.T) {
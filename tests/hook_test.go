@@ -0,0 +1,113 @@
+package hermes
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+func TestAfterRender(t *testing.T) {
+	h, email := (&SimpleExample{}).getExample()
+	html, text, err := h.Generate(email)
+	assert.Nil(t, err)
+
+	type ctxKey struct{}
+	calls := 0
+	h.AfterRender = func(ctx context.Context, e hermes.Email, out hermes.Output, stats hermes.RenderStats) error {
+		calls++
+		assert.Equal(t, "jon@snow.com", ctx.Value(ctxKey{}), "Hooks get the context of the caller")
+		assert.Equal(t, email.Body.Name, e.Body.Name)
+		assert.Equal(t, html, out.HTML)
+		assert.Equal(t, text, out.PlainText)
+		assert.Equal(t, "default", stats.Theme)
+		assert.Equal(t, len(html), stats.HTMLSize)
+		assert.Equal(t, len(text), stats.PlainTextSize)
+		sum := sha256.Sum256([]byte(html))
+		assert.Equal(t, hex.EncodeToString(sum[:]), stats.HTMLHash)
+		assert.Len(t, stats.PlainTextHash, 64)
+		assert.Positive(t, stats.Duration)
+
+		// Changes of the hook are not seen by the caller
+		e.Body.Intros[0] = "Changed by the hook"
+		out.HTML = "Changed by the hook"
+		return nil
+	}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "jon@snow.com")
+	out, err := h.GenerateContext(ctx, email)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, html, out.HTML)
+	assert.Equal(t, text, out.PlainText)
+	assert.NotEqual(t, "Changed by the hook", email.Body.Intros[0])
+}
+
+func TestAfterRender_HookError(t *testing.T) {
+	failure := errors.New("audit service unavailable")
+	h, email := (&SimpleExample{}).getExample()
+	h.AfterRender = func(context.Context, hermes.Email, hermes.Output, hermes.RenderStats) error {
+		return failure
+	}
+
+	// Ignored by default, without writing to the global logger
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	out, err := h.GenerateContext(context.Background(), email)
+	log.SetOutput(os.Stderr)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, out.HTML)
+	assert.NotEmpty(t, out.PlainText)
+	assert.Empty(t, logs.String())
+
+	// Given to the callback of the caller
+	type ctxKey struct{}
+	var reported []error
+	h.OnHookError = func(ctx context.Context, err error) {
+		assert.Equal(t, "jon@snow.com", ctx.Value(ctxKey{}))
+		reported = append(reported, err)
+	}
+	out, err = h.GenerateContext(context.WithValue(context.Background(), ctxKey{}, "jon@snow.com"), email)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, out.HTML)
+	if assert.Len(t, reported, 1) {
+		assert.ErrorIs(t, reported[0], failure)
+		assert.EqualError(t, reported[0], "after render hook: audit service unavailable")
+	}
+
+	// Failing the generation when requested
+	h.FailOnHookError = true
+	out, err = h.GenerateContext(context.Background(), email)
+	assert.ErrorIs(t, err, failure)
+	assert.Equal(t, hermes.Output{}, out)
+	assert.Len(t, reported, 1, "Errors failing the generation should not be reported")
+}
+
+func TestAfterRender_NotCalledOnFailure(t *testing.T) {
+	called := false
+	h, email := (&SimpleExample{}).getExample()
+	h.AfterRender = func(context.Context, hermes.Email, hermes.Output, hermes.RenderStats) error {
+		called = true
+		return nil
+	}
+
+	h.StrictParams = true
+	email.Params = map[string]string{"name": "Jon"}
+	email.Body.Intros = []string{"Hello {name}, {missing}"}
+	_, err := h.GenerateContext(context.Background(), email)
+	assert.NotNil(t, err)
+
+	h.StrictParams = false
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = h.GenerateContext(ctx, email)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	assert.False(t, called)
+}
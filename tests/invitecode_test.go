@@ -0,0 +1,66 @@
+package hermes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+func TestAction_GroupedInviteCode(t *testing.T) {
+	for _, c := range []struct {
+		code      string
+		options   hermes.InviteCodeOptions
+		formatted string
+	}{
+		{"123456", hermes.InviteCodeOptions{}, "123456"},
+		{"123456", hermes.InviteCodeOptions{GroupSize: 3}, "123 456"},
+		{"ABCDEFGH", hermes.InviteCodeOptions{GroupSize: 4, Separator: "-"}, "ABCD-EFGH"},
+		{"1234567", hermes.InviteCodeOptions{GroupSize: 3}, "123 456 7"},
+		{"123", hermes.InviteCodeOptions{GroupSize: 3}, "123"},
+		{"ÄÖÜäöü", hermes.InviteCodeOptions{GroupSize: 2, Separator: "·"}, "ÄÖ·Üä·öü"},
+	} {
+		action := hermes.Action{InviteCode: c.code, InviteCodeOptions: c.options}
+		assert.Equal(t, c.formatted, action.GroupedInviteCode(), c.code)
+	}
+}
+
+func TestThemeWithInviteCodeOptions(t *testing.T) {
+	for _, theme := range testedThemes {
+		h, email := (&WithInviteCode{theme}).getExample()
+		email.Body.Actions[0].InviteCodeOptions = hermes.InviteCodeOptions{GroupSize: 3, Separator: "-"}
+		html, err := h.GenerateHTML(email)
+		assert.Nil(t, err, theme.Name())
+		assert.Contains(t, html, "123-456", theme.Name())
+		text, err := h.GeneratePlainText(email)
+		assert.Nil(t, err, theme.Name())
+		assert.Contains(t, text, "123-456", theme.Name())
+	}
+
+	h, email := (&WithInviteCode{testedThemes[0]}).getExample()
+	html, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, html, `<span class="invite-code">123456</span>`, "Codes without options should render as before")
+	assert.Contains(t, html, "font-size:28px;text-align:center;letter-spacing:8px;color:#555;background-color:#eee;")
+
+	email.Body.Actions[0].InviteCodeOptions = hermes.InviteCodeOptions{FontSize: "32px", BackgroundColor: "#000000", TextColor: "#FFFFFF"}
+	html, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, html, `<span class="invite-code" style="font-size: 32px;background-color: #000000;color: #FFFFFF;">123456</span>`)
+	assert.Contains(t, html, "font-size:32px;text-align:center;letter-spacing:8px;color:#FFFFFF;background-color:#000000;", "Outlook should get the styles too")
+}
+
+func TestThemeWithInviteCode_Escaped(t *testing.T) {
+	for _, theme := range testedThemes {
+		h, email := (&WithInviteCode{theme}).getExample()
+		email.Body.Actions[0].InviteCode = `<b>1&2"</b>`
+		email.Body.Actions[0].InviteCodeOptions.GroupSize = 4
+		html, err := h.GenerateHTML(email)
+		assert.Nil(t, err, theme.Name())
+		assert.NotContains(t, html, "<b>1", theme.Name())
+		assert.Contains(t, html, "&lt;b&gt;1 &amp;2&#34;&lt; /b&gt;", theme.Name())
+		text, err := h.GeneratePlainText(email)
+		assert.Nil(t, err, theme.Name())
+		assert.Contains(t, text, `<b>1 &2"< /b>`, "%s: plain text should read as the code", theme.Name())
+	}
+}
@@ -0,0 +1,64 @@
+package hermes
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+var exampleIssues = hermes.Issues{
+	{Code: "b", Severity: hermes.SeverityWarning, Path: "Body.Outros[0]", Message: "warning"},
+	{Code: "c", Severity: hermes.SeverityInfo, Path: "Body.Intros[0]", Message: "info"},
+	{Code: "a", Severity: hermes.SeverityError, Path: "Body.Name", Message: "error", Err: io.ErrUnexpectedEOF},
+	{Code: "d", Severity: hermes.SeverityWarning, Path: "Body.Intros[1]", Message: "warning"},
+}
+
+func TestIssues_Errors(t *testing.T) {
+	var err error = exampleIssues
+	assert.EqualError(t, err, "Body.Outros[0]: warning; Body.Intros[0]: info; Body.Name: error; Body.Intros[1]: warning")
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF), "Contained errors should be found")
+
+	var issue hermes.Issue
+	assert.True(t, errors.As(err, &issue))
+	assert.Equal(t, "b", issue.Code)
+
+	_, email := (&SimpleExample{}).getExample()
+	email.Body.Actions[0].Button.Link = "/confirm"
+	var v hermes.ValidationError
+	assert.True(t, errors.As(email.Validate(), &v))
+	assert.Equal(t, "/confirm", v.Value)
+	assert.Equal(t, v.Localize("de"), email.Validate().(hermes.Issues)[0].Localize("de"))
+}
+
+func TestIssues_Sort(t *testing.T) {
+	issues := append(hermes.Issues{}, exampleIssues...)
+	issues.Sort()
+	var codes []string
+	for _, issue := range issues {
+		codes = append(codes, issue.Code)
+	}
+	assert.Equal(t, []string{"a", "d", "b", "c"}, codes)
+}
+
+func TestIssues_Filter(t *testing.T) {
+	assert.Len(t, exampleIssues.Filter(hermes.SeverityInfo), 4)
+	assert.Len(t, exampleIssues.Filter(hermes.SeverityWarning), 3)
+	assert.Len(t, exampleIssues.Filter(hermes.SeverityError), 1)
+
+	assert.True(t, exampleIssues.HasErrors())
+	assert.False(t, exampleIssues.Filter(hermes.SeverityError)[:0].HasErrors())
+	assert.False(t, exampleIssues[:2].HasErrors())
+}
+
+func TestIssues_JSON(t *testing.T) {
+	b, err := json.Marshal(exampleIssues[1:3])
+	assert.Nil(t, err)
+	assert.JSONEq(t, `[
+		{"code": "c", "severity": "info", "path": "Body.Intros[0]", "message": "info"},
+		{"code": "a", "severity": "error", "path": "Body.Name", "message": "error", "error": "unexpected EOF"}
+	]`, string(b))
+}
@@ -0,0 +1,112 @@
+package hermes
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/examples/mails"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"gopkg.in/yaml.v3"
+)
+
+func TestLoadEmailFromYAML_Receipt(t *testing.T) {
+	f, err := os.Open("testdata/load/receipt.yaml")
+	assert.Nil(t, err)
+	defer f.Close()
+	loaded, err := hermes.LoadOptions{Strict: true}.LoadEmailFromYAML(f)
+	assert.Nil(t, err)
+	expected := new(mails.Receipt).Email()
+	assert.Equal(t, expected, loaded)
+
+	for _, theme := range testedThemes {
+		h := hermes.Hermes{Theme: theme, Brand: hermes.Branding{Name: "Hermes"}}
+		html, text, err := h.Generate(loaded)
+		assert.Nil(t, err)
+		expectedHTML, expectedText, err := h.Generate(expected)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedHTML, html, theme.Name())
+		assert.Equal(t, expectedText, text, theme.Name())
+	}
+}
+
+// loadExample is an email using the fields of every type of the body
+func loadExample() hermes.Email {
+	previous := 3.0
+	start := time.Date(2024, 5, 1, 22, 0, 0, 0, time.UTC)
+	email := tocExample()
+	email.Subject = "{{ .Email.Body.Name }}, your report"
+	email.Params = map[string]string{"token": "d9729feb"}
+	email.Attachments = []hermes.Attachment{{Filename: "invoice.pdf", Data: []byte("%PDF-1.4")}}
+	email.Unsubscribe = &hermes.Unsubscribe{OneClickURL: "https://hermes-example.com/one-click?token={token}"}
+	email.Body.Summary.Sections[0].Previous = &previous
+	email.Body.Dictionary[0].HTMLValue = "<b>May</b> 2024"
+	email.Body.Tables[0].Rows = []hermes.RowOptions{{Color: "#1A7F45", Bold: true}}
+	email.Body.Tables[0].ResponsiveMode = hermes.ResponsiveStack
+	email.Body.Actions = []hermes.Action{{
+		Instructions:      "Use this code:",
+		InviteCode:        "123456",
+		InviteCodeOptions: hermes.InviteCodeOptions{GroupSize: 3},
+		Button:            hermes.Button{Text: "Open", Link: "https://hermes-example.com", Color: "#22BC66"},
+	}}
+	email.Body.Schedule = []hermes.ScheduleEntry{{Label: "Maintenance", Start: start, End: start.Add(2 * time.Hour)}}
+	email.Body.CalendarEvent = &hermes.CalendarEvent{Summary: "Maintenance", Start: start, QuickAddLinks: true}
+	email.Body.ContactInstructions = &hermes.ContactInstructions{Email: "support@hermes-example.com"}
+	email.Body.SegmentedBlocks = map[string][]hermes.Block{"pro": {{Intros: []string{"Thanks for being a pro."}}}}
+	return email
+}
+
+func TestLoadEmail_RoundTrip(t *testing.T) {
+	email := loadExample()
+
+	data, err := json.Marshal(email)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"table_of_contents":true`)
+	loaded, err := hermes.LoadOptions{Strict: true}.LoadEmailFromJSON(bytes.NewReader(data))
+	assert.Nil(t, err)
+	assert.Equal(t, email, loaded)
+
+	data, err = yaml.Marshal(email)
+	assert.Nil(t, err)
+	loaded, err = hermes.LoadOptions{Strict: true}.LoadEmailFromYAML(bytes.NewReader(data))
+	assert.Nil(t, err)
+	assert.Equal(t, email, loaded)
+}
+
+func TestLoadEmail_Markdown(t *testing.T) {
+	email, err := hermes.LoadEmailFromYAML(strings.NewReader("body:\n  free_markdown: |\n    # Hello\n\n    Read the **news**.\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, hermes.Markdown("# Hello\n\nRead the **news**.\n"), email.Body.FreeMarkdown)
+	email, err = hermes.LoadEmailFromJSON(strings.NewReader(`{"body": {"free_markdown": "Read the **news**."}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, hermes.Markdown("Read the **news**."), email.Body.FreeMarkdown)
+}
+
+func TestLoadEmail_Strict(t *testing.T) {
+	const yamlDoc = "body:\n  name: Jon\n  intro: Misspelled\n"
+	const jsonDoc = `{"body": {"name": "Jon", "intro": "Misspelled"}}`
+
+	email, err := hermes.LoadEmailFromYAML(strings.NewReader(yamlDoc))
+	assert.Nil(t, err, "Unknown fields are ignored by default")
+	assert.Equal(t, "Jon", email.Body.Name)
+	email, err = hermes.LoadEmailFromJSON(strings.NewReader(jsonDoc))
+	assert.Nil(t, err)
+	assert.Equal(t, "Jon", email.Body.Name)
+
+	strict := hermes.LoadOptions{Strict: true}
+	_, err = strict.LoadEmailFromYAML(strings.NewReader(yamlDoc))
+	assert.ErrorContains(t, err, "intro")
+	_, err = strict.LoadEmailFromJSON(strings.NewReader(jsonDoc))
+	assert.ErrorContains(t, err, "intro")
+
+	_, err = hermes.LoadEmailFromYAML(strings.NewReader(""))
+	assert.ErrorContains(t, err, "empty")
+	_, err = hermes.LoadEmailFromJSON(strings.NewReader(`{"body": {}} {"body": {}}`))
+	assert.ErrorContains(t, err, "after the JSON document")
+	_, err = hermes.LoadEmailFromJSON(strings.NewReader(`{"body": {"intros": "not a list"}}`))
+	assert.NotNil(t, err)
+}
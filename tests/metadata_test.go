@@ -0,0 +1,121 @@
+package hermes
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/pkg/core"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+// metadataTheme is a theme of the HTML template and metadata
+type metadataTheme struct {
+	html     string
+	metadata themes.Metadata
+}
+
+func (t metadataTheme) Name() string              { return "metadata" }
+func (t metadataTheme) HTMLTemplate() string      { return t.html }
+func (t metadataTheme) PlainTextTemplate() string { return "{{ .Email.Body.Name }}" }
+func (t metadataTheme) Metadata() themes.Metadata { return t.metadata }
+
+func TestThemeMetadata(t *testing.T) {
+	for _, theme := range testedThemes {
+		metadata, ok := theme.(themes.ThemeMetadata)
+		assert.True(t, ok, "%s should implement ThemeMetadata", theme.Name())
+		assert.NotEmpty(t, metadata.Metadata().Widths, theme.Name())
+		assert.Nil(t, hermes.ValidateTheme(theme), theme.Name())
+	}
+	metadata, ok := themes.LookupMetadata("default")
+	assert.True(t, ok)
+	assert.Equal(t, themes.Metadata{Widths: []int{320, 600}, MediaQueries: true, DarkMode: core.DarkModeFull, MinDataVersion: 1}, metadata)
+	_, ok = themes.LookupMetadata("unknown")
+	assert.False(t, ok)
+	_, ok = core.MetadataOf(funcsTheme(""))
+	assert.False(t, ok, "Themes without metadata should be reported")
+}
+
+func TestValidateTheme(t *testing.T) {
+	const responsive = `<style>@media only screen and (max-width: 600px) { .body { width: 100% } }</style>`
+	const dark = `<style>@media (prefers-color-scheme: dark) { body { color: #FFF } }</style>`
+	tests := []struct {
+		name     string
+		theme    metadataTheme
+		expected []string // Severities and paths of the issues
+	}{
+		{"consistent", metadataTheme{responsive + dark, themes.Metadata{Widths: []int{320, 600}, MediaQueries: true, DarkMode: core.DarkModeMediaQuery}}, nil},
+		{"claimed media queries", metadataTheme{dark, themes.Metadata{Widths: []int{600}, MediaQueries: true, DarkMode: core.DarkModeMediaQuery}},
+			[]string{"error Metadata.MediaQueries"}},
+		{"unclaimed media queries", metadataTheme{responsive, themes.Metadata{Widths: []int{600}}},
+			[]string{"warning Metadata.MediaQueries"}},
+		{"dark styles only", metadataTheme{dark, themes.Metadata{Widths: []int{600}}}, []string{"warning Metadata.DarkMode"}},
+		{"claimed dark mode", metadataTheme{responsive, themes.Metadata{Widths: []int{600}, MediaQueries: true, DarkMode: core.DarkModeFull}},
+			[]string{"error Metadata.DarkMode", "error Metadata.DarkMode"}},
+		{"claimed Outlook.com dark mode", metadataTheme{dark, themes.Metadata{Widths: []int{600}, DarkMode: core.DarkModeFull}},
+			[]string{"error Metadata.DarkMode"}},
+		{"unknown dark mode", metadataTheme{"", themes.Metadata{Widths: []int{600}, DarkMode: "auto"}}, []string{"error Metadata.DarkMode"}},
+		{"widths", metadataTheme{"", themes.Metadata{}}, []string{"error Metadata.Widths"}},
+		{"negative width", metadataTheme{"", themes.Metadata{Widths: []int{600, -1}}}, []string{"error Metadata.Widths[1]"}},
+		{"data version", metadataTheme{"", themes.Metadata{Widths: []int{600}, MinDataVersion: core.DataVersion + 1}},
+			[]string{"error Metadata.MinDataVersion"}},
+		{"template", metadataTheme{"{{ .Email", themes.Metadata{Widths: []int{600}}}, []string{"error HTMLTemplate"}},
+	}
+	for _, test := range tests {
+		err := hermes.ValidateTheme(test.theme)
+		var issues hermes.Issues
+		if err != nil {
+			assert.True(t, errors.As(err, &issues), test.name)
+		}
+		var actual []string
+		for _, issue := range issues {
+			actual = append(actual, issue.Severity.String()+" "+issue.Path)
+		}
+		assert.Equal(t, test.expected, actual, test.name)
+	}
+	err := hermes.ValidateTheme(metadataTheme{"{{ .Email", themes.Metadata{Widths: []int{600}}})
+	assert.Equal(t, hermes.IssueThemeTemplate, err.(hermes.Issues)[0].Code)
+	err = hermes.ValidateTheme(metadataTheme{"", themes.Metadata{}})
+	assert.Equal(t, hermes.IssueThemeMetadata, err.(hermes.Issues)[0].Code)
+}
+
+func TestPreviewHandler_Widths(t *testing.T) {
+	h, email := (&SimpleExample{testedThemes[0]}).getExample()
+	handler := hermes.NewPreviewHandler(h, func(r *http.Request) (hermes.Email, error) {
+		if r.URL.Path != "/welcome" {
+			return hermes.Email{}, errors.New("unknown email")
+		}
+		return email, nil
+	})
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	w := get("/welcome?format=widths&lang=fr")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	for _, frame := range []string{
+		`<iframe src="/welcome?lang=fr&amp;theme=corporate" width="660"`,
+		`<iframe src="/welcome?lang=fr&amp;theme=default" width="320"`,
+		`<iframe src="/welcome?lang=fr&amp;theme=default" width="600"`,
+		`<iframe src="/welcome?lang=fr&amp;theme=flat" width="320"`,
+	} {
+		assert.Contains(t, w.Body.String(), frame)
+	}
+	assert.Equal(t, http.StatusNotFound, get("/unknown?format=widths").Code)
+
+	flat := h
+	flat.Theme = new(themes.Flat)
+	expected, err := flat.GenerateHTML(email)
+	assert.Nil(t, err)
+	w = get("/welcome?theme=flat")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, expected, w.Body.String(), "The theme of the query should render the email")
+	assert.NotEqual(t, expected, get("/welcome").Body.String())
+	assert.Equal(t, http.StatusNotFound, get("/welcome?theme=unknown").Code)
+}
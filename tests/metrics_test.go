@@ -0,0 +1,104 @@
+package hermes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/send"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+// fakeMetrics records the counters and the observations of each series, identified as name{label=value,...}
+type fakeMetrics struct {
+	mu           sync.Mutex
+	counters     map[string]float64
+	observations map[string][]float64
+}
+
+func newFakeMetrics() *fakeMetrics {
+	return &fakeMetrics{counters: map[string]float64{}, observations: map[string][]float64{}}
+}
+
+func series(name string, labels map[string]string) string {
+	var pairs []string
+	for name, value := range labels {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return fmt.Sprintf("%s{%s}", name, strings.Join(pairs, ","))
+}
+
+func (m *fakeMetrics) Count(name string, labels map[string]string, delta float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[series(name, labels)] += delta
+}
+
+func (m *fakeMetrics) Observe(name string, labels map[string]string, value float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.observations[series(name, labels)] = append(m.observations[series(name, labels)], value)
+}
+
+func TestMetrics_Render(t *testing.T) {
+	metrics := newFakeMetrics()
+	h := hermes.Hermes{Theme: new(themes.Flat), Metrics: metrics, RenderCache: hermes.NewMemoryRenderCache(10)}
+	email := hermes.Email{Body: hermes.Body{Name: "Jon Snow"}}
+	out, err := h.GenerateContext(context.Background(), email)
+	assert.Nil(t, err)
+	_, err = h.GenerateContext(context.Background(), email)
+	assert.Nil(t, err)
+
+	assert.Equal(t, float64(1), metrics.counters["hermes_cache_lookups_total{cache=render,result=miss}"])
+	assert.Equal(t, float64(1), metrics.counters["hermes_cache_lookups_total{cache=render,result=hit}"])
+	assert.Equal(t, float64(2), metrics.counters["hermes_cache_lookups_total{cache=template,result=hit}"]+
+		metrics.counters["hermes_cache_lookups_total{cache=template,result=miss}"], "Both templates should be looked up once")
+	assert.Len(t, metrics.observations, 3)
+	assert.Len(t, metrics.observations["hermes_render_duration_seconds{theme=flat}"], 1, "Cached outputs should not be observed")
+	assert.Equal(t, []float64{float64(len(out.HTML))}, metrics.observations["hermes_output_size_bytes{part=html,theme=flat}"])
+	assert.Equal(t, []float64{float64(len(out.PlainText))}, metrics.observations["hermes_output_size_bytes{part=text,theme=flat}"])
+
+	metrics = newFakeMetrics()
+	h = hermes.Hermes{Theme: new(themes.Default), Metrics: metrics, Pipeline: hermes.DefaultPipeline()}
+	assert.Nil(t, h.Pipeline.InsertAfter(hermes.StageInline, hermes.Stage{Name: "Track", Run: func(r *hermes.Rendering) error {
+		return fmt.Errorf("tracking unavailable")
+	}}))
+	_, err = h.GenerateHTML(email)
+	assert.EqualError(t, err, "tracking unavailable")
+	_, err = h.GenerateHTML(hermes.Email{Body: hermes.Body{Name: "Jon", Greeting: "{{ .Unknown }}"}})
+	assert.NotNil(t, err)
+	assert.Equal(t, float64(1), metrics.counters["hermes_render_errors_total{stage=Track}"])
+	assert.Equal(t, float64(1), metrics.counters["hermes_render_errors_total{stage=prepare}"])
+	assert.Empty(t, metrics.observations, "Failed renderings should not be observed")
+}
+
+func TestMetrics_EnginePool(t *testing.T) {
+	metrics := newFakeMetrics()
+	base := poolBase()
+	base.Metrics = metrics
+	pool := hermes.NewEnginePool(base)
+	get(pool, hermes.Branding{Name: "Stark"}, "")
+	get(pool, hermes.Branding{Name: "Stark"}, "")
+	assert.Equal(t, float64(1), metrics.counters["hermes_cache_lookups_total{cache=engine,result=miss}"])
+	assert.Equal(t, float64(1), metrics.counters["hermes_cache_lookups_total{cache=engine,result=hit}"])
+}
+
+func TestMetrics_Send(t *testing.T) {
+	metrics := newFakeMetrics()
+	f, s := startFakeSMTP(t, send.NoTLS)
+	s.Metrics = metrics
+	assert.Nil(t, s.Send(context.Background(), smtpMessage()))
+	f.reject.Store("sansa@stark.com")
+	assert.NotNil(t, s.Send(context.Background(), smtpMessage()))
+
+	assert.Equal(t, map[string]float64{
+		"hermes_send_attempts_total{result=sent,sender=smtp}":   1,
+		"hermes_send_attempts_total{result=failed,sender=smtp}": 1,
+	}, metrics.counters)
+}
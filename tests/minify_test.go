@@ -0,0 +1,125 @@
+package hermes
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/examples/mails"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"golang.org/x/net/html"
+)
+
+// visibleText returns the text of the HTML as displayed, its whitespace collapsed
+func visibleText(t *testing.T, s string) string {
+	doc, err := html.Parse(strings.NewReader(s))
+	assert.Nil(t, err)
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+		case n.Type == html.ElementNode && (n.Data == "style" || n.Data == "head"):
+			return
+		case n.Type == html.ElementNode:
+			b.WriteString(" ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+func TestMinifyHTML(t *testing.T) {
+	for _, theme := range testedThemes {
+		for _, example := range mails.All() {
+			name := theme.Name() + "/" + example.Name()
+			h := hermes.Hermes{Theme: theme, Brand: hermes.Branding{Name: "Hermes", Link: "https://hermes-example.com"}}
+			expected, err := h.GenerateHTML(example.Email())
+			assert.Nil(t, err, name)
+			h.MinifyHTML = true
+			minified, err := h.GenerateHTML(example.Email())
+			assert.Nil(t, err, name)
+
+			assert.Less(t, len(minified), len(expected)*9/10, name)
+			assert.Equal(t, visibleText(t, expected), visibleText(t, minified), "%s: minified emails should read the same", name)
+			for _, conditional := range []string{"<!--[if mso]>", "<![endif]-->", "<!--[if !mso]><!-- -->", "<!--<![endif]-->"} {
+				assert.Equal(t, strings.Count(expected, conditional), strings.Count(minified, conditional), "%s: %s should survive minification", name, conditional)
+			}
+			text, err := h.GeneratePlainText(example.Email())
+			assert.Nil(t, err, name)
+			h.MinifyHTML = false
+			expectedText, err := h.GeneratePlainText(example.Email())
+			assert.Nil(t, err, name)
+			assert.Equal(t, expectedText, text, "%s: plain text should not be minified", name)
+		}
+	}
+}
+
+func TestMinify(t *testing.T) {
+	assert.Equal(t,
+		`<html><head><style>p{color: red}a{color: blue}</style></head><body><p>Hello <b>Jon</b> Snow,&nbsp; welcome</p><pre>  keep
+  this</pre><!--[if mso]> <table> <tr><td>Outlook</td></tr> </table> <![endif]--><span class="x">a</span> <span>b</span></body></html>`,
+		hermes.Minify(`<html>
+  <head>
+    <style type="text/css" rel="stylesheet" media="all">
+      /* Base */
+      p { color: red; }
+      a {
+        color: blue;
+      }
+    </style>
+  </head>
+  <body>
+    <!-- Content -->
+    <p>
+      Hello  <b>Jon</b>
+      Snow,&nbsp; welcome
+    </p>
+    <pre>  keep
+  this</pre>
+    <!--[if mso]>
+      <table>
+        <tr><td>Outlook</td></tr>
+      </table>
+    <![endif]-->
+    <span class="x" style=" ">a</span>
+    <span id="">b</span>
+  </body>
+</html>`))
+	assert.Equal(t, "a  b", hermes.Minify("a  b"), "No-break spaces are not whitespace")
+}
+
+func TestGmailClipping(t *testing.T) {
+	h, email := (&SimpleExample{testedThemes[0]}).getExample()
+	out, err := h.GenerateContext(context.Background(), email)
+	assert.Nil(t, err)
+	assert.Equal(t, len(out.HTML), out.Size())
+	assert.Empty(t, out.Warnings)
+
+	h.ClipSize = out.Size() - 1
+	out, err = h.GenerateContext(context.Background(), email)
+	assert.Nil(t, err)
+	if assert.Len(t, out.Warnings, 1) {
+		assert.Equal(t, hermes.IssueHTMLTooLarge, out.Warnings[0].Code)
+		assert.Equal(t, hermes.SeverityWarning, out.Warnings[0].Severity)
+	}
+	_, warnings, err := h.GenerateHTMLWithWarnings(email)
+	assert.Nil(t, err)
+	assert.Contains(t, warnings.Error(), "Gmail clips it")
+
+	h.MinifyHTML = true
+	out, err = h.GenerateContext(context.Background(), email)
+	assert.Nil(t, err)
+	assert.Empty(t, out.Warnings, "Minified emails should fit")
+
+	h.MinifyHTML, h.ClipSize = false, -1
+	out, err = h.GenerateContext(context.Background(), email)
+	assert.Nil(t, err)
+	assert.Empty(t, out.Warnings)
+	assert.Equal(t, 102400, hermes.GmailClipSize)
+}
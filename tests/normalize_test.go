@@ -0,0 +1,97 @@
+package hermes
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/send"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "", hermes.Normalize(""))
+	assert.Equal(t, "", hermes.Normalize(" \r\n\uFEFF\n"))
+	assert.Equal(t, "a\nb\nc\n", hermes.Normalize("\uFEFFa\r\nb\rc"))
+	assert.Equal(t, "a \nb\n", hermes.Normalize("a \r\nb\n\n\n"), "Normalize should only trim the end of the output")
+	assert.Equal(t, "a\n\nb\n", hermes.NormalizeText("a \t\r\n \r\nb  \r\n"))
+}
+
+func TestNormalize_Idempotent(t *testing.T) {
+	for _, normalize := range []func(string) string{hermes.Normalize, hermes.NormalizeText} {
+		assert.Nil(t, quick.Check(func(s string) bool {
+			once := normalize(s)
+			return normalize(once) == once && !strings.ContainsAny(once, "\r\uFEFF") && (once == "" || strings.HasSuffix(once, "\n"))
+		}, nil))
+		assert.Nil(t, quick.Check(func(parts []string) bool {
+			s := strings.Join(parts, "\r\n \uFEFF\t\r")
+			return normalize(normalize(s)) == normalize(s)
+		}, nil))
+	}
+}
+
+func TestNormalize_Outputs(t *testing.T) {
+	h := hermes.Hermes{Theme: new(themes.Flat)}
+	pasted := hermes.Email{Body: hermes.Body{Name: "\uFEFFJon Snow", Intros: []string{"Winter is coming.  \r\nBe ready."}}}
+	html, text, err := h.Generate(pasted)
+	assert.Nil(t, err)
+	for _, out := range []string{html, text} {
+		assert.NotContains(t, out, "\r")
+		assert.NotContains(t, out, "\uFEFF")
+		assert.True(t, strings.HasSuffix(out, ">\n") || strings.HasSuffix(out, ".\n"), "Outputs should end with one line break")
+	}
+	for _, line := range strings.Split(text, "\n") {
+		assert.Equal(t, strings.TrimRight(line, " \t"), line, "Lines of the plain text should not end with whitespace")
+	}
+
+	h.Pipeline = hermes.DefaultPipeline()
+	assert.Nil(t, h.Pipeline.Remove(hermes.StageNormalize))
+	_, text, err = h.Generate(pasted)
+	assert.Nil(t, err)
+	assert.False(t, strings.HasSuffix(text, "\n"), "Outputs should be left as they are without the Normalize stage")
+}
+
+func TestNormalize_Streamed(t *testing.T) {
+	assert.Nil(t, quick.Check(func(name string, intros []string) bool {
+		h := hermes.Hermes{Theme: funcsTheme("\uFEFF {{ .Email.Body.Name }}\r\n{{ range .Email.Body.Intros }}{{ . }} \r{{ end }}\t\n"), DisableCSSInlining: true}
+		email := hermes.Email{Body: hermes.Body{Name: name, Intros: intros}}
+		html, err := h.GenerateHTML(email)
+		if err != nil {
+			return false
+		}
+		var b bytes.Buffer
+		err = h.GenerateHTMLTo(email, &b)
+		return err == nil && b.String() == html
+	}, nil), "Streamed outputs should be normalized like buffered ones")
+}
+
+// dkimBodyHash returns the body hash of the message with the simple canonicalization of DKIM, see RFC 6376 3.4.3
+func dkimBodyHash(message []byte) string {
+	_, body, _ := bytes.Cut(message, []byte("\r\n\r\n"))
+	body = append(bytes.TrimRight(body, "\r\n"), "\r\n"...)
+	hash := sha256.Sum256(body)
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+func TestNormalize_DKIMBodyHash(t *testing.T) {
+	h := hermes.Hermes{Theme: new(themes.Default), Brand: hermes.Branding{Name: "Hermes", Link: "https://example-hermes.com/"}}
+	hash := func(email hermes.Email) string {
+		m, err := send.GenerateMessage(&h, email)
+		assert.Nil(t, err)
+		m.From, m.To = "hermes@example-hermes.com", []string{"jon@snow.com"}
+		data, err := m.BytesWith(send.MIMEOptions{Rand: seeded()})
+		assert.Nil(t, err)
+		assert.NotRegexp(t, "[^\r]\n", string(data), "Line breaks should be CRLF on the wire")
+		return dkimBodyHash(data)
+	}
+	email := hermes.Email{Body: hermes.Body{Name: "Jon Snow", Intros: []string{"Winter is coming.\nBe ready."}}}
+	expected := hash(email)
+	assert.Equal(t, expected, hash(email), "Identical emails should have the same body hash")
+	pasted := hermes.Email{Body: hermes.Body{Name: "\uFEFFJon Snow", Intros: []string{"Winter is coming.\r\nBe ready."}}}
+	assert.Equal(t, expected, hash(pasted), "Pasted byte order marks and CRLF line breaks should not change the body hash")
+}
@@ -0,0 +1,54 @@
+package hermes
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/examples/matrix"
+	"github.com/unknowns24/hermes/pkg/hermestest"
+)
+
+// TestReadableWithoutCSS checks the reading order of every cell of the matrix, for gateways stripping all styles
+func TestReadableWithoutCSS(t *testing.T) {
+	for _, cell := range matrix.Cells() {
+		t.Run(cell.String(), func(t *testing.T) {
+			html, _, err := cell.Render()
+			if assert.Nil(t, err) {
+				hermestest.AssertReadableWithoutCSS(t, cell.Hermes(), cell.Example.Email(), html)
+			}
+		})
+	}
+}
+
+// recorder records the errors of an assertion expected to fail
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestReadableWithoutCSS_FooterFirst(t *testing.T) {
+	h, email := (&SimpleExample{}).getExample()
+	html, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+
+	// A footer laid out at the bottom by the styles, but written first
+	copyright := h.Brand.Copyright
+	start := strings.Index(html, "<body")
+	body := start + strings.Index(html[start:], ">") + 1
+	moved := html[:body] + "<p>" + copyright + "</p>" + strings.ReplaceAll(html[body:], copyright, "")
+	r := &recorder{TB: t}
+	assert.False(t, hermestest.AssertReadableWithoutCSS(r, h, email, moved))
+	assert.Len(t, r.errors, 1)
+
+	r = &recorder{TB: t}
+	assert.True(t, hermestest.AssertReadableWithoutCSS(r, h, email, html))
+	assert.Empty(t, r.errors)
+}
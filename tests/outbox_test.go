@@ -0,0 +1,164 @@
+package hermes
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/send"
+)
+
+// outboxExample is a message with every field set
+func outboxExample() send.Message {
+	m := sendExample(send.HTMLOnly)
+	m.Cc = []string{"arya@stark.com"}
+	m.Bcc = []string{"archive@hermes-example.com"}
+	m.HTML = `<p>Your order <a href="https://hermes-example.com/orders/42?a=1&b=2">#42</a> has been processed.</p>`
+	m.Headers = map[string]string{"Reply-To": "support@hermes-example.com"}
+	m.ForceHTMLOnly = true
+	m.Attachments = []hermes.Attachment{
+		{Filename: "logo.png", Data: []byte{0x89, 'P', 'N', 'G'}, Inline: true, CID: "logo"},
+		{Filename: "invoice.pdf", ContentType: "application/pdf", Ref: "s3://invoices/42.pdf"},
+	}
+	m.Unsubscribe = &hermes.Unsubscribe{Mailto: "unsubscribe@hermes-example.com", OneClickURL: "https://hermes-example.com/unsubscribe"}
+	m.IdempotencyKey = "order-42"
+	m.Metadata = map[string]string{"tenant": "winterfell"}
+	return m
+}
+
+// resolvedSender records the messages sent
+type resolvedSender struct{ sent []send.Message }
+
+func (s *resolvedSender) Send(_ context.Context, msg send.Message) error {
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func TestMessage_MarshalBinary(t *testing.T) {
+	m := outboxExample()
+	data, err := m.MarshalBinary()
+	assert.Nil(t, err)
+
+	var loaded send.Message
+	assert.Nil(t, loaded.UnmarshalBinary(data))
+	expected := outboxExample()
+	expected.Attachments[1].Data = nil
+	assert.Equal(t, expected, loaded)
+
+	_, err = loaded.Bytes()
+	assert.ErrorContains(t, err, `send: attachment invoice.pdf references "s3://invoices/42.pdf", resolve it with ResolvingSender`)
+
+	again, err := loaded.MarshalBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, data, again, "Serialization should be stable")
+
+	for _, preference := range []send.ContentPreference{send.Both, send.TextOnly} {
+		m := sendExample(preference)
+		data, err := m.MarshalBinary()
+		assert.Nil(t, err)
+		var loaded send.Message
+		assert.Nil(t, loaded.UnmarshalBinary(data))
+		assert.Equal(t, m, loaded, preference.String())
+	}
+}
+
+func TestMessage_MarshalBinaryAttachmentSize(t *testing.T) {
+	m := sendExample(send.Both)
+	m.Attachments = []hermes.Attachment{{Filename: "video.mp4", Data: make([]byte, send.MaxInlineAttachmentSize+1)}}
+	_, err := m.MarshalBinary()
+	assert.True(t, errors.Is(err, send.ErrAttachmentTooLarge), "%v", err)
+	assert.ErrorContains(t, err, "video.mp4 is 1048577 bytes, above 1048576 bytes")
+
+	m.Attachments[0].Ref = "s3://videos/1.mp4"
+	data, err := m.MarshalBinary()
+	assert.Nil(t, err)
+	assert.Less(t, len(data), 1024, "Attachments stored by reference should be left out")
+
+	m.Attachments[0] = hermes.Attachment{Filename: "small.txt", Data: make([]byte, send.MaxInlineAttachmentSize)}
+	_, err = m.MarshalBinary()
+	assert.Nil(t, err)
+}
+
+func TestMessage_UnmarshalBinaryCorrupt(t *testing.T) {
+	data, err := outboxExample().MarshalBinary()
+	assert.Nil(t, err)
+
+	for name, corrupt := range map[string][]byte{
+		"content":   bytes.Replace(data, []byte("processed"), []byte("cancelled"), 1),
+		"hash":      bytes.Replace(data, []byte(`"sha256":"`), []byte(`"sha256":"0`), 1),
+		"truncated": data[:len(data)/2],
+		"format":    bytes.Replace(data, []byte("hermes/send.Message"), []byte("other"), 1),
+		"empty":     nil,
+	} {
+		var m send.Message
+		err := m.UnmarshalBinary(corrupt)
+		assert.True(t, errors.Is(err, send.ErrCorruptMessage), "%s: %v", name, err)
+	}
+
+	var m send.Message
+	err = m.UnmarshalBinary(bytes.Replace(data, []byte(`"version":1`), []byte(`"version":99`), 1))
+	assert.ErrorContains(t, err, "send: serialized message of version 99, this version of hermes reads up to version 1")
+	assert.False(t, errors.Is(err, send.ErrCorruptMessage))
+}
+
+// TestMessage_UnmarshalBinaryPreviousVersions loads the messages of outboxExample serialized by each released version
+// of the format. Their files must never be updated: add the file of the new version along with them.
+func TestMessage_UnmarshalBinaryPreviousVersions(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "outbox", "v1.json"))
+	assert.Nil(t, err)
+
+	var loaded send.Message
+	assert.Nil(t, loaded.UnmarshalBinary(data))
+	expected := outboxExample()
+	expected.Attachments[1].Data = nil
+	assert.Equal(t, expected, loaded)
+}
+
+// TestMessage_MarshalBinaryFixture checks that messages are written as in testdata/outbox/v1.json, the format of the
+// current version, whatever the fields of Message and of the types of hermes are named
+func TestMessage_MarshalBinaryFixture(t *testing.T) {
+	fixture, err := os.ReadFile(filepath.Join("testdata", "outbox", "v1.json"))
+	assert.Nil(t, err)
+
+	data, err := outboxExample().MarshalBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, string(fixture), string(data))
+
+	var loaded send.Message
+	assert.Nil(t, loaded.UnmarshalBinary(fixture))
+	again, err := loaded.MarshalBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, string(fixture), string(again), "Loaded messages should be written back as they were")
+}
+
+func TestResolvingSender(t *testing.T) {
+	data, err := outboxExample().MarshalBinary()
+	assert.Nil(t, err)
+	var m send.Message
+	assert.Nil(t, m.UnmarshalBinary(data))
+
+	sender := &resolvedSender{}
+	var refs []string
+	r := send.ResolvingSender{Sender: sender, Resolve: func(_ context.Context, ref string) ([]byte, error) {
+		refs = append(refs, ref)
+		return []byte("%PDF"), nil
+	}}
+	assert.Nil(t, r.Send(context.Background(), m))
+	assert.Equal(t, []string{"s3://invoices/42.pdf"}, refs, "Only attachments without data should be resolved")
+	if assert.Len(t, sender.sent, 1) {
+		assert.Equal(t, []byte("%PDF"), sender.sent[0].Attachments[1].Data)
+		_, err := sender.sent[0].Bytes()
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, m.Attachments[1].Data, "The message given should be left untouched")
+
+	r.Resolve = func(context.Context, string) ([]byte, error) { return nil, errors.New("not found") }
+	err = r.Send(context.Background(), m)
+	assert.EqualError(t, err, "send: resolve attachment invoice.pdf: not found")
+	assert.Len(t, sender.sent, 1)
+}
@@ -0,0 +1,88 @@
+package hermes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+func paramsExample() (hermes.Hermes, hermes.Email) {
+	h, email := (&SimpleExample{}).getExample()
+	email.Body.Intros = []string{"Welcome {name}! Use {{braces}} to write {{literal}}."}
+	email.Body.Dictionary = []hermes.Entry{{Key: "Plan", Value: "{plan}"}}
+	email.Body.Actions[0].Button.Link = "https://app.example.com/reset?token={token}&user={name}"
+	email.Params = map[string]string{
+		"name":  "Jon & <Snow>",
+		"plan":  "Pro",
+		"token": "a+b/c=d&e?f#g h%",
+	}
+	return h, email
+}
+
+func TestParams(t *testing.T) {
+	h, email := paramsExample()
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+
+	assert.Contains(t, r, "Welcome Jon &amp; &lt;Snow&gt;! Use {braces} to write {literal}.", "Values should be HTML-escaped")
+	assert.Contains(t, r, "<dd>Pro</dd>")
+	assert.Contains(t, r, `href="https://app.example.com/reset?token=a%2Bb%2Fc%3Dd%26e%3Ff%23g%20h%25&amp;user=Jon%20%26%20%3CSnow%3E"`, "Values should be URL-encoded in links")
+
+	assert.Equal(t, "https://app.example.com/reset?token={token}&user={name}", email.Body.Actions[0].Button.Link, "Email should not be modified")
+	assert.Equal(t, "{plan}", email.Body.Dictionary[0].Value)
+
+	text, err := h.GeneratePlainText(email)
+	assert.Nil(t, err)
+	assert.Contains(t, text, "Welcome Jon & <Snow>!")
+}
+
+func TestParams_Unresolved(t *testing.T) {
+	h, email := paramsExample()
+	email.Body.Dictionary[0].Value = "{plan} until {expiry}"
+
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, r, "<dd>Pro until {expiry}</dd>", "Unknown placeholders should be kept")
+
+	h.StrictParams = true
+	_, err = h.GenerateHTML(email)
+	assert.EqualError(t, err, "Body.Dictionary[0].Value: unresolved placeholder {expiry}")
+}
+
+func TestParams_NotSet(t *testing.T) {
+	h, email := (&SimpleExample{}).getExample()
+	h.StrictParams = true
+	email.Body.Intros = []string{"Use {{braces}} and {name}"}
+
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, r, "Use {{braces}} and {name}", "Emails without params should be left untouched")
+	text, err := h.GeneratePlainText(email)
+	assert.Nil(t, err)
+	assert.Contains(t, text, "Use {{braces}} and {name}")
+}
+
+func TestParams_Empty(t *testing.T) {
+	for name, params := range map[string]map[string]string{"empty": {}, "unused": {"other": "value"}} {
+		h, email := (&SimpleExample{}).getExample()
+		email.Params = params
+		email.Body.Intros = []string{"Use {{braces}} and {name}"}
+		email.Body.Dictionary[0].Value = "{{literal}}"
+		email.Body.Actions[0].Button.Link = "https://example.com/{{id}}"
+
+		r, err := h.GenerateHTML(email)
+		assert.Nil(t, err, name)
+		assert.Contains(t, r, "Use {braces} and {name}", "Braces should be unescaped once params are set: "+name)
+		assert.Contains(t, r, "<dd>{literal}</dd>", name)
+		assert.Contains(t, r, `href="https://example.com/%7bid%7d"`, name)
+
+		text, err := h.GeneratePlainText(email)
+		assert.Nil(t, err, name)
+		assert.Contains(t, text, "Use {braces} and {name}", name)
+
+		h.StrictParams = true
+		_, err = h.GenerateHTML(email)
+		assert.EqualError(t, err, "Body.Intros[0]: unresolved placeholder {name}", name)
+	}
+}
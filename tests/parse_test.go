@@ -0,0 +1,142 @@
+package hermes
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/examples/mails"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+type example interface {
+	Email() hermes.Email
+	Name() string
+}
+
+var examples = []example{
+	new(mails.Welcome),
+	new(mails.Reset),
+	new(mails.Maintenance),
+	new(mails.Receipt),
+	new(mails.InviteCode),
+}
+
+var tags = regexp.MustCompile(`<[^>]*>`)
+
+// normalizeMarkdown keeps only the text of free markdown, which is parsed back as HTML
+func normalizeMarkdown(email hermes.Email) hermes.Email {
+	email.Body.FreeMarkdown = hermes.Markdown(strings.Join(strings.Fields(tags.ReplaceAllString(string(email.Body.FreeMarkdown), " ")), " "))
+	return email
+}
+
+func TestParseEmail_RoundTrip(t *testing.T) {
+	for _, theme := range testedThemes {
+		for _, inlining := range []bool{false, true} {
+			for _, ex := range examples {
+				name := theme.Name() + "/" + ex.Name()
+				h := hermes.Hermes{
+					Theme: theme,
+					Brand: hermes.Branding{
+						Name:      "Hermes",
+						Link:      "https://example-hermes.com/",
+						Copyright: "Copyright © Hermes-Test",
+					},
+					DisableCSSInlining: !inlining,
+				}
+				email := ex.Email()
+				html, err := h.GenerateHTML(email)
+				assert.Nil(t, err, name)
+
+				parsed, _, err := hermes.ParseEmail(html)
+				assert.Nil(t, err, name)
+				brand, err := hermes.ParseBranding(html)
+				assert.Nil(t, err, name)
+				assert.Equal(t, h.Brand.Name, brand.Name, name)
+				assert.Equal(t, h.Brand.Link, brand.Link, name)
+				assert.Equal(t, h.Brand.Copyright, brand.Copyright, name)
+
+				// The parsed email is the original one
+				assert.Equal(t, email.Body.Name, parsed.Body.Name, name)
+				assert.Equal(t, email.Body.Intros, parsed.Body.Intros, name)
+				assert.Equal(t, email.Body.Dictionary, parsed.Body.Dictionary, name)
+				assert.Equal(t, email.Body.Actions, parsed.Body.Actions, name)
+				assert.Equal(t, email.Body.Outros, parsed.Body.Outros, name)
+
+				// Regenerating the parsed email gives the same email
+				h2 := hermes.Hermes{Theme: theme, Brand: brand, DisableCSSInlining: !inlining}
+				html2, err := h2.GenerateHTML(parsed)
+				assert.Nil(t, err, name)
+				reparsed, _, err := hermes.ParseEmail(html2)
+				assert.Nil(t, err, name)
+				assert.Equal(t, normalizeMarkdown(parsed), normalizeMarkdown(reparsed), name)
+			}
+		}
+	}
+}
+
+func TestParseEmail_Content(t *testing.T) {
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+	html, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+
+	parsed, warnings, err := hermes.ParseEmail(html)
+	assert.Nil(t, err)
+	assert.Empty(t, warnings)
+
+	assert.Equal(t, "Hi", parsed.Body.Greeting)
+	assert.Equal(t, email.Body.Name, parsed.Body.Name)
+	assert.Equal(t, email.Body.Intros, parsed.Body.Intros)
+	assert.Equal(t, email.Body.Dictionary, parsed.Body.Dictionary)
+	assert.Equal(t, email.Body.Table, parsed.Body.Table)
+	assert.Equal(t, email.Body.Actions, parsed.Body.Actions)
+	assert.Equal(t, email.Body.Outros, parsed.Body.Outros)
+	assert.Equal(t, "Yours truly", parsed.Body.Signature)
+
+	brand, err := hermes.ParseBranding(html)
+	assert.Nil(t, err)
+	assert.Equal(t, h.Brand.Logo, brand.Logo)
+	assert.Equal(t, "If you’re having trouble with the button '{ACTION}', copy and paste the URL below into your web browser.", brand.TroubleText)
+}
+
+func TestParseEmail_TitleAndInviteCode(t *testing.T) {
+	h := hermes.Hermes{DisableCSSInlining: true}
+	email := hermes.Email{
+		Body: hermes.Body{
+			Title: "A new e-mail",
+			Actions: []hermes.Action{
+				{Instructions: "Here is your invite code:", InviteCode: "123456"},
+			},
+		},
+	}
+	html, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+
+	parsed, _, err := hermes.ParseEmail(html)
+	assert.Nil(t, err)
+	assert.Equal(t, "A new e-mail", parsed.Body.Title)
+	assert.Empty(t, parsed.Body.Name)
+	assert.Equal(t, email.Body.Actions, parsed.Body.Actions)
+}
+
+func TestParseEmail_UnknownStructures(t *testing.T) {
+	html := `<html><body><table><tr><td class="content-cell">
+		<h1 data-hermes-greeting="Hello">Hello Arya,</h1>
+		<blockquote>Not a man</blockquote>
+		<p data-hermes="outro">Bye</p>
+	</td></tr></table></body></html>`
+
+	parsed, warnings, err := hermes.ParseEmail(html)
+	assert.Nil(t, err)
+	assert.Equal(t, "Hello", parsed.Body.Greeting)
+	assert.Equal(t, "Arya", parsed.Body.Name)
+	assert.Equal(t, []string{"Bye"}, parsed.Body.Outros)
+	assert.Equal(t, hermes.Markdown("<blockquote>Not a man</blockquote>"), parsed.Body.FreeMarkdown)
+	assert.Len(t, warnings, 1)
+	assert.Equal(t, "html > body > table > tbody > tr > td.content-cell > blockquote", warnings[0].Path)
+
+	_, _, err = hermes.ParseEmail("<html><body><p>Hello</p></body></html>")
+	assert.Equal(t, hermes.ErrNotHermesEmail, err)
+}
@@ -0,0 +1,119 @@
+package hermes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+func TestPipeline_DefaultIsUnchanged(t *testing.T) {
+	h, email := (&SimpleExample{}).getExample()
+	h.DisableCSSInlining = false
+	expected, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+
+	h.Pipeline = hermes.DefaultPipeline()
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, r)
+}
+
+func TestPipeline_CustomStages(t *testing.T) {
+	h, email := (&SimpleExample{}).getExample()
+	h.DisableCSSInlining = false
+	h.Pipeline = hermes.DefaultPipeline()
+	h.Pipeline.Stats = &hermes.PipelineStats{}
+
+	var formats []bool
+	assert.Nil(t, h.Pipeline.InsertAfter(hermes.StageInline, hermes.Stage{
+		Name: "Shout",
+		Run: func(r *hermes.Rendering) error {
+			formats = append(formats, r.PlainText)
+			r.HTML = strings.ReplaceAll(r.HTML, "Jon Snow", "JON SNOW")
+			return nil
+		},
+	}))
+	assert.Nil(t, h.Pipeline.Remove(hermes.StageInline))
+
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, r, "JON SNOW")
+	assert.Contains(t, r, "<style", "CSS should not be inlined")
+
+	text, err := h.GeneratePlainText(email)
+	assert.Nil(t, err)
+	assert.Contains(t, text, "JON SNOW")
+	assert.Equal(t, []bool{false, true}, formats)
+
+	stats := h.Pipeline.Stats.Stages()
+	assert.Equal(t, uint64(2), stats[hermes.StageTemplateExecute].Runs)
+	assert.Equal(t, uint64(2), stats["Shout"].Runs)
+	assert.NotContains(t, stats, hermes.StageInline)
+}
+
+func TestPipeline_Validate(t *testing.T) {
+	noop := func(r *hermes.Rendering) error { return nil }
+	tests := []struct {
+		stages []hermes.Stage
+		err    string
+	}{
+		{[]hermes.Stage{hermes.InlineStage, hermes.TemplateExecuteStage}, "pipeline: TemplateExecute must be the first stage"},
+		{[]hermes.Stage{{Name: "Pixel", Run: noop}, hermes.TemplateExecuteStage}, "pipeline: TemplateExecute must be the first stage"},
+		{nil, "pipeline: TemplateExecute must be the first stage"},
+		{[]hermes.Stage{hermes.TemplateExecuteStage, {Run: noop}}, "pipeline: stage 1 has no name"},
+		{[]hermes.Stage{hermes.TemplateExecuteStage, {Name: "Minify"}}, `pipeline: stage "Minify" has no Run function`},
+		{[]hermes.Stage{hermes.TemplateExecuteStage, hermes.InlineStage, hermes.InlineStage}, `pipeline: stage "Inline" appears twice`},
+	}
+	for _, test := range tests {
+		p := hermes.Pipeline{Stages: test.stages}
+		assert.EqualError(t, p.Validate(), test.err)
+	}
+
+	h, email := (&SimpleExample{}).getExample()
+	h.Pipeline = &hermes.Pipeline{Stages: []hermes.Stage{hermes.InlineStage}}
+	_, err := h.GenerateHTML(email)
+	assert.EqualError(t, err, "pipeline: TemplateExecute must be the first stage")
+	assert.EqualError(t, h.Compile(), "pipeline: TemplateExecute must be the first stage")
+
+	assert.EqualError(t, hermes.DefaultPipeline().Remove("Minify"), `pipeline: unknown stage "Minify"`)
+}
+
+func TestPipeline_ValidateOrder(t *testing.T) {
+	noop := hermes.Stage{Name: "Pixel", Run: func(r *hermes.Rendering) error { return nil }}
+	tests := []struct {
+		stages []hermes.Stage
+		err    string
+	}{
+		{[]hermes.Stage{hermes.TemplateExecuteStage, hermes.MinifyStage, hermes.InlineStage}, `pipeline: stage "MinifyHTML" must run after "Inline"`},
+		{[]hermes.Stage{hermes.TemplateExecuteStage, hermes.CheckResourcesStage, hermes.TrackLinksStage}, `pipeline: stage "CheckResources" must run after "TrackLinks"`},
+		{[]hermes.Stage{hermes.TemplateExecuteStage, hermes.CheckResourcesStage, hermes.InlineStage}, `pipeline: stage "CheckResources" must run after "Inline"`},
+		{[]hermes.Stage{hermes.TemplateExecuteStage, hermes.NormalizeStage, hermes.TrackLinksStage}, `pipeline: stage "Normalize" must run after "TrackLinks"`},
+		{[]hermes.Stage{hermes.TemplateExecuteStage, hermes.InlineStage, hermes.NormalizeStage, noop, hermes.MinifyStage}, `pipeline: stage "Normalize" must run after "MinifyHTML"`},
+		{[]hermes.Stage{hermes.TemplateExecuteStage, hermes.NormalizeStage, hermes.InlineStage, hermes.MinifyStage}, `pipeline: stage "Normalize" must run after "Inline"`},
+	}
+	for _, test := range tests {
+		p := hermes.Pipeline{Stages: test.stages}
+		assert.EqualError(t, p.Validate(), test.err)
+	}
+
+	for _, valid := range [][]hermes.Stage{
+		hermes.DefaultPipeline().Stages,
+		{hermes.TemplateExecuteStage, hermes.TrackLinksStage, hermes.InlineStage, hermes.MinifyStage, hermes.CheckResourcesStage, hermes.NormalizeStage},
+		{hermes.TemplateExecuteStage, hermes.NormalizeStage, hermes.CheckResourcesStage},
+		{hermes.TemplateExecuteStage, hermes.MinifyStage, noop},
+		{hermes.TemplateExecuteStage, noop, hermes.InlineStage, hermes.NormalizeStage},
+	} {
+		p := hermes.Pipeline{Stages: valid}
+		assert.Nil(t, p.Validate(), "Stages out of the dependencies can be removed or moved")
+	}
+
+	p := hermes.DefaultPipeline()
+	assert.Nil(t, p.Remove(hermes.StageNormalize))
+	assert.Nil(t, p.InsertBefore(hermes.StageInline, hermes.NormalizeStage))
+	h, email := (&SimpleExample{}).getExample()
+	h.Pipeline = p
+	_, err := h.GenerateHTML(email)
+	assert.EqualError(t, err, `pipeline: stage "Normalize" must run after "Inline"`)
+}
@@ -0,0 +1,93 @@
+package hermes
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+// convertedTheme is the default theme without its native plain text template, converted from HTML
+type convertedTheme struct{}
+
+func (t convertedTheme) Name() string              { return "converted" }
+func (t convertedTheme) HTMLTemplate() string      { return new(themes.Default).HTMLTemplate() }
+func (t convertedTheme) PlainTextTemplate() string { return new(themes.Default).PlainTextTemplate() }
+
+// invalidTextTheme is a theme whose native plain text template does not parse
+type invalidTextTheme struct{ convertedTheme }
+
+func (t invalidTextTheme) TextTemplate() string { return "{{ if .Email.Body.Name }}" }
+
+func TestPlainText_Native(t *testing.T) {
+	for _, theme := range testedThemes {
+		_, ok := theme.(hermes.PlainTextTheme)
+		assert.True(t, ok, "%s should have a native plain text template", theme.Name())
+
+		h, email := (&SimpleExample{theme}).getExample()
+		text, err := h.GeneratePlainText(email)
+		assert.Nil(t, err, theme.Name())
+		assert.Contains(t, text, "Firstname: Jon\nLastname:  Snow\nBirthday:  01/01/283", "%s: entries should be aligned", theme.Name())
+		assert.Contains(t, text, "To get started with Hermes, please click here:\n  https://hermes-example.com/confirm?token=d9729feb74992cc3482b350163a1a010",
+			"%s: links should be on their own line, unwrapped", theme.Name())
+		assert.Contains(t, text, "Golang  Open source programming language", theme.Name())
+		assert.NotContains(t, text, "( https://", theme.Name())
+		assert.NotContains(t, text, "<", "%s: nothing should be converted from HTML", theme.Name())
+		assert.NotRegexp(t, `(?m) +$`, text, "%s: lines should have no trailing spaces", theme.Name())
+		assert.NotContains(t, text, "\n\n\n", "%s: blank lines should not be repeated", theme.Name())
+	}
+}
+
+func TestPlainText_NotEscaped(t *testing.T) {
+	h := hermes.Hermes{}
+	text, err := h.GeneratePlainText(hermes.Email{Body: hermes.Body{
+		Name:       "Jon & <Co>",
+		Dictionary: []hermes.Entry{{Key: "Note", Value: "A\nB\tC"}},
+	}})
+	assert.Nil(t, err)
+	assert.Contains(t, text, "Hi Jon & <Co>,", "Plain text should not be HTML escaped")
+	assert.Contains(t, text, "Note: A B C", "Values should hold on their line")
+}
+
+func TestPlainText_Converted(t *testing.T) {
+	h, email := (&SimpleExample{convertedTheme{}}).getExample()
+	text, err := h.GeneratePlainText(email)
+	assert.Nil(t, err)
+	assert.Contains(t, text, "|  ITEM  |          DESCRIPTION           | PRICE  |", "Themes without native template should be converted from HTML")
+	assert.Contains(t, text, "* Firstname: Jon")
+
+	h.PlainTextOptions = hermes.PlainTextOptions{DisablePrettyTables: true}
+	text, err = h.GeneratePlainText(email)
+	assert.Nil(t, err)
+	assert.NotContains(t, text, "|  ITEM  |")
+	assert.Contains(t, text, "Open source programming language")
+
+	h, email = (&SimpleExample{new(themes.Default)}).getExample()
+	h.CompatLevel = hermes.CompatLevel1
+	text, err = h.GeneratePlainText(email)
+	assert.Nil(t, err)
+	assert.Contains(t, text, "|  ITEM  |", "Compat levels should keep the plain text of their release")
+}
+
+func TestPlainText_Markdown(t *testing.T) {
+	h, email := (&WithFreeMarkdownContent{new(themes.Default)}).getExample()
+	text, err := h.GeneratePlainText(email)
+	assert.Nil(t, err)
+	assert.Contains(t, text, "| Service A | 2AM to 3AM |", "Markdown tables should be drawn")
+	assert.NotContains(t, text, "**")
+}
+
+func TestPlainText_ValidateTheme(t *testing.T) {
+	assert.Nil(t, hermes.ValidateTheme(convertedTheme{}))
+	var issues hermes.Issues
+	assert.True(t, errors.As(hermes.ValidateTheme(invalidTextTheme{}), &issues))
+	assert.Len(t, issues, 1)
+	assert.Equal(t, "TextTemplate", issues[0].Path)
+
+	h := hermes.Hermes{Theme: invalidTextTheme{}}
+	_, err := h.GeneratePlainText(hermes.Email{})
+	assert.True(t, err != nil && strings.Contains(err.Error(), "unexpected EOF"), "%v", err)
+}
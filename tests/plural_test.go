@@ -0,0 +1,117 @@
+package hermes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+// allForms names the category of each form, to check the one selected
+var allForms = hermes.PluralForms{Zero: "zero {N}", One: "one {N}", Two: "two {N}", Few: "few {N}", Many: "many {N}", Other: "other {N}"}
+
+func TestPhrase_Categories(t *testing.T) {
+	tests := []struct {
+		locale   string
+		count    int
+		expected string
+	}{
+		{"en", 0, "other 0"},
+		{"en", 1, "one 1"},
+		{"en", 2, "other 2"},
+		{"en-US", 1, "one 1"},
+		{"", 1, "one 1"},
+		{"pl", 1, "one 1"},
+		{"pl", 2, "few 2"},
+		{"pl", 4, "few 4"},
+		{"pl", 5, "many 5"},
+		{"pl", 12, "many 12"},
+		{"pl", 22, "few 22"},
+		{"pl", 25, "many 25"},
+		{"pl_PL", 3, "few 3"},
+		{"ar", 0, "zero 0"},
+		{"ar", 1, "one 1"},
+		{"ar", 2, "two 2"},
+		{"ar", 3, "few 3"},
+		{"ar", 10, "few 10"},
+		{"ar", 11, "many 11"},
+		{"ar", 99, "many 99"},
+		{"ar", 100, "other 100"},
+		{"ja", 0, "other 0"},
+		{"ja", 1, "other 1"},
+		{"ja", 5, "other 5"},
+		{"not a locale", 1, "other 1"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, hermes.Phrase{Count: test.count, Forms: allForms}.Format(test.locale), "%s %d", test.locale, test.count)
+	}
+}
+
+func TestPhrase_MissingForms(t *testing.T) {
+	forms := hermes.PluralForms{One: "{N} wiadomość", Other: "{N} wiadomości"}
+	assert.Equal(t, "1 wiadomość", hermes.Phrase{Count: 1, Forms: forms}.Format("pl"))
+	assert.Equal(t, "5 wiadomości", hermes.Phrase{Count: 5, Forms: forms}.Format("pl"), "Many should fall back to other")
+	assert.Equal(t, "2 wiadomości", hermes.Phrase{Count: 2, Forms: forms}.Format("pl"), "Few should fall back to other")
+	assert.Equal(t, "", hermes.Phrase{Count: 2, Forms: hermes.PluralForms{One: "{N} item"}}.Format("en"))
+}
+
+func TestPhrase_Localized(t *testing.T) {
+	hermes.RegisterLocale("pl", hermes.Localization{Plurals: map[string]hermes.PluralForms{
+		"messages.new": {One: "Masz {N} nową wiadomość", Few: "Masz {N} nowe wiadomości", Many: "Masz {N} nowych wiadomości"},
+	}})
+	hermes.RegisterLocale("en-GB", hermes.Localization{Plurals: map[string]hermes.PluralForms{
+		"messages.new": {One: "You have {N} new message", Other: "You have {N} new messages"},
+	}})
+
+	phrase := hermes.Phrase{Count: 22, Key: "messages.new"}
+	assert.Equal(t, "Masz 22 nowe wiadomości", phrase.Format("pl"))
+	assert.Equal(t, "Masz 22 nowe wiadomości", phrase.Format("pl-PL"), "Forms of the language should be used")
+	assert.Equal(t, "You have 22 new messages", phrase.Format("en-GB"))
+	assert.Equal(t, "", phrase.Format("ja"))
+	assert.Equal(t, "2 days", hermes.Phrase{Count: 2, Key: "reltime.day"}.Format("ja"), "Forms of the default locale should be used")
+	assert.Equal(t, "1 dzień", hermes.Phrase{Count: 1, Key: "reltime.day", Forms: hermes.PluralForms{One: "{N} dzień"}}.Format("pl"))
+
+	phrase.Forms.Few = "{N} nowe"
+	assert.Equal(t, "22 nowe", phrase.Format("pl"), "Forms should replace the localized ones")
+
+	h := hermes.Hermes{Theme: new(themes.Default)}
+	email := hermes.Email{
+		Body: hermes.Body{Intros: []string{"{messages}."}},
+		LanguageVariants: []hermes.LanguageVariant{
+			{Tag: "en-GB", Body: hermes.Body{Intros: []string{"{messages}."}}},
+			{Tag: "pl", Body: hermes.Body{Intros: []string{"{messages}."}}},
+		},
+		Phrases: map[string]hermes.Phrase{"messages": {Count: 5, Key: "messages.new"}},
+	}
+	text, err := h.GeneratePlainText(email)
+	assert.Nil(t, err)
+	assert.Contains(t, text, "You have 5 new messages.")
+	assert.Contains(t, text, "Masz 5 nowych wiadomości.")
+
+	email.Params = map[string]string{"messages": "Params first"}
+	text, err = h.GeneratePlainText(email)
+	assert.Nil(t, err)
+	assert.Contains(t, text, "Params first.")
+	assert.NotContains(t, text, "new messages")
+}
+
+func TestPluralFunc(t *testing.T) {
+	h := hermes.Hermes{
+		Theme:              funcsTheme(`{{ plural .Hermes.Locale (len .Email.Body.Intros) "one={N} intro" "few={N} intra" "other={N} intros" }}`),
+		Locale:             "pl",
+		DisableCSSInlining: true,
+	}
+	r, err := h.GenerateHTML(hermes.Email{Body: hermes.Body{Intros: []string{"a", "b", "c"}}})
+	assert.Nil(t, err)
+	assert.Equal(t, "3 intra\n", r)
+
+	h.Locale = "ja"
+	r, err = h.GenerateHTML(hermes.Email{Body: hermes.Body{Intros: []string{"a"}}})
+	assert.Nil(t, err)
+	assert.Equal(t, "1 intros\n", r)
+
+	h.Theme = funcsTheme(`{{ plural .Hermes.Locale 1 "single={N} intro" }}`)
+	_, err = h.GenerateHTML(hermes.Email{})
+	assert.ErrorContains(t, err, `plural: unknown plural category "single"`)
+}
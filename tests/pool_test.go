@@ -0,0 +1,154 @@
+package hermes
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+func poolBase() hermes.Hermes {
+	return hermes.Hermes{
+		Brand: hermes.Branding{
+			Name:      "Hermes",
+			Copyright: "Copyright © 2024 Tenants. All rights reserved.",
+		},
+		DisableCSSInlining: true,
+	}
+}
+
+// get returns the engine of the pool, panicking on errors
+func get(pool *hermes.EnginePool, brand hermes.Branding, locale string) *hermes.Hermes {
+	h, err := pool.Get(brand, locale)
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
+func TestEnginePool_Get(t *testing.T) {
+	pool := hermes.NewEnginePool(poolBase())
+
+	acme, err := pool.Get(hermes.Branding{Name: "Acme", Link: "https://acme.com"}, "fr-FR")
+	assert.Nil(t, err)
+	assert.Equal(t, "Acme", acme.Brand.Name)
+	assert.Equal(t, "Copyright © 2024 Tenants. All rights reserved.", acme.Brand.Copyright, "Brand should default to the base one")
+	assert.Equal(t, "fr-FR", acme.Locale)
+	assert.Same(t, acme, get(pool, hermes.Branding{Name: "Acme", Link: "https://acme.com"}, "fr-FR"))
+	assert.NotSame(t, acme, get(pool, hermes.Branding{Name: "Acme", Link: "https://acme.com"}, "de-DE"))
+
+	_, email := (&SimpleExample{}).getExample()
+	r, err := acme.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, r, `lang="fr-FR"`)
+	assert.Contains(t, r, "https://acme.com")
+
+	assert.Equal(t, hermes.PoolStats{Hits: 1, Misses: 2, Engines: 2}, pool.Stats())
+}
+
+func TestEnginePool_EqualBrands(t *testing.T) {
+	pool := hermes.NewEnginePool(poolBase())
+	brand := func() hermes.Branding {
+		return hermes.Branding{
+			Name:        "Acme",
+			Unsubscribe: &hermes.Unsubscribe{URL: "https://acme.com/unsubscribe"},
+			SocialLinks: []hermes.SocialLink{{Name: "GitHub", URL: "https://github.com/acme"}},
+		}
+	}
+
+	first := brand()
+	acme := get(pool, first, "")
+	assert.Same(t, acme, get(pool, brand(), ""), "Equal brands should share their engine, whatever their pointers")
+	assert.Same(t, acme, get(pool, brand(), ""))
+	assert.Equal(t, hermes.PoolStats{Hits: 2, Misses: 1, Engines: 1}, pool.Stats())
+
+	// The engine does not share the pointers of the caller
+	first.Unsubscribe.URL = "https://evil.com/"
+	first.SocialLinks[0].URL = "https://evil.com/"
+	assert.Equal(t, "https://acme.com/unsubscribe", acme.Brand.Unsubscribe.URL)
+	assert.Equal(t, "https://github.com/acme", acme.Brand.SocialLinks[0].URL)
+	assert.NotSame(t, acme, get(pool, first, ""))
+}
+
+// unparsedTheme is a theme whose HTML template does not parse
+type unparsedTheme struct{ convertedTheme }
+
+func (unparsedTheme) HTMLTemplate() string {
+	return "{{ .Email.Body.Name"
+}
+
+func TestEnginePool_CompileError(t *testing.T) {
+	base := poolBase()
+	base.Theme = unparsedTheme{}
+	pool := hermes.NewEnginePool(base)
+
+	h, err := pool.Get(hermes.Branding{Name: "Acme"}, "")
+	assert.Nil(t, h)
+	assert.ErrorContains(t, err, "unclosed action")
+	_, again := pool.Get(hermes.Branding{Name: "Acme"}, "")
+	assert.Equal(t, err, again, "The error should be returned on every use of the engine")
+	assert.Equal(t, err, pool.WarmUp(context.Background(), []hermes.Branding{{Name: "Acme"}}))
+}
+
+func TestEnginePool_Eviction(t *testing.T) {
+	pool := hermes.NewEnginePool(poolBase())
+	pool.Resize(2)
+
+	a := get(pool, hermes.Branding{Name: "A", Link: "https://a.example"}, "")
+	get(pool, hermes.Branding{Name: "B"}, "")
+	get(pool, hermes.Branding{Name: "A", Link: "https://a.example"}, "")
+	get(pool, hermes.Branding{Name: "C"}, "") // Evicts B, the least recently used
+
+	assert.Same(t, a, get(pool, hermes.Branding{Name: "A", Link: "https://a.example"}, ""))
+	assert.Equal(t, hermes.PoolStats{Hits: 2, Misses: 3, Evictions: 1, Engines: 2}, pool.Stats())
+
+	pool.Resize(1)                            // Evicts C
+	get(pool, hermes.Branding{Name: "B"}, "") // Evicts A
+	assert.Equal(t, hermes.PoolStats{Hits: 2, Misses: 4, Evictions: 3, Engines: 1}, pool.Stats())
+
+	// Evicted engines are still usable
+	_, email := (&SimpleExample{}).getExample()
+	r, err := a.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, r, "https://a.example")
+}
+
+func TestEnginePool_WarmUp(t *testing.T) {
+	pool := hermes.NewEnginePool(poolBase())
+	brands := []hermes.Branding{{Name: "A"}, {Name: "B"}}
+
+	assert.Nil(t, pool.WarmUp(context.Background(), brands))
+	assert.Equal(t, hermes.PoolStats{Misses: 2, Engines: 2}, pool.Stats())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, pool.WarmUp(ctx, []hermes.Branding{{Name: "C"}}))
+	assert.Equal(t, 2, pool.Stats().Engines)
+}
+
+func TestEnginePool_Concurrent(t *testing.T) {
+	pool := hermes.NewEnginePool(poolBase())
+	pool.Resize(4)
+	_, email := (&SimpleExample{}).getExample()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			h := get(pool, hermes.Branding{Name: fmt.Sprintf("Tenant %d", i%8)}, "")
+			_, err := h.GenerateHTML(email)
+			assert.Nil(t, err)
+			_, err = h.GeneratePlainText(email)
+			assert.Nil(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	stats := pool.Stats()
+	assert.Equal(t, uint64(32), stats.Hits+stats.Misses)
+	assert.Equal(t, 4, stats.Engines)
+}
@@ -0,0 +1,35 @@
+package hermes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+func TestPreheader(t *testing.T) {
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+	h.DisableCSSInlining = false
+	email.Body.Preheader = "Your receipt & next steps"
+
+	html, text, err := h.Generate(email)
+	assert.Nil(t, err)
+	body := html[strings.Index(html, "<body"):]
+	// CSS inlining writes the padding entities as characters
+	div := strings.NewReplacer("\u200c", "&zwnj;", "\u00a0", "&nbsp;").Replace(body[strings.Index(body, "<div"):])
+	assert.True(t, strings.Index(body, "<div") < strings.Index(body, "email-wrapper"), "Preheader should be the first content of the body")
+	assert.Contains(t, div, `class="preheader"`)
+	assert.Contains(t, div, "display: none")
+	assert.Contains(t, div, "max-height: 0")
+	assert.True(t, strings.HasPrefix(div[strings.Index(div, ">")+1:], "Your receipt &amp; next steps&zwnj;&nbsp;&zwnj;&nbsp;"), "Preheader should be escaped and padded")
+	assert.NotContains(t, text, "Your receipt")
+}
+
+func TestPreheader_Empty(t *testing.T) {
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+	html, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.NotContains(t, html, "preheader")
+	assert.NotContains(t, html, "&zwnj;")
+}
@@ -0,0 +1,131 @@
+package hermes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/preview"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+func previewGet(handler http.Handler, method, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestPreviewServer(t *testing.T) {
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+	handler := preview.NewHandler(h, map[string]hermes.Email{"welcome": email, "a b": email})
+
+	w := previewGet(handler, http.MethodGet, "/?dir=rtl")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	index := w.Body.String()
+	assert.Contains(t, index, `<strong>a b</strong> <a href="/emails/a%20b/html?dir=rtl">html</a>`, "Emails should be sorted")
+	assert.Contains(t, index, `<a href="/emails/welcome/txt?dir=rtl">txt</a><a href="/emails/welcome/raw?dir=rtl">raw</a>`)
+	assert.Contains(t, index, `<a href="/emails/welcome/html?dir=rtl&amp;format=widths">widths</a>`)
+	assert.Contains(t, index, `<a href="/?dir=rtl&amp;inline=false">styled</a>`)
+	assert.Contains(t, index, `<a href="/?dir=ltr">left-to-right</a>`)
+
+	expected, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	w = previewGet(handler, http.MethodGet, "/emails/welcome/html")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, expected, w.Body.String())
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+
+	expected, err = h.GeneratePlainText(email)
+	assert.Nil(t, err)
+	w = previewGet(handler, http.MethodGet, "/emails/a%20b/txt")
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, expected, w.Body.String())
+
+	expected, err = h.GenerateHTMLRaw(email)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, previewGet(handler, http.MethodGet, "/emails/welcome/raw").Body.String())
+	assert.Equal(t, expected, previewGet(handler, http.MethodGet, "/emails/welcome/html?inline=false").Body.String())
+
+	rtl := h
+	rtl.TextDirection = hermes.TDRightToLeft
+	expected, err = rtl.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, previewGet(handler, http.MethodGet, "/emails/welcome/html?dir=rtl").Body.String())
+
+	w = previewGet(handler, http.MethodHead, "/emails/welcome/html")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+}
+
+func TestPreviewServer_PreviewHandler(t *testing.T) {
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+	handler := preview.NewHandler(h, map[string]hermes.Email{"welcome": email})
+
+	w := previewGet(handler, http.MethodGet, "/emails/welcome/html")
+	etag := w.Header().Get("ETag")
+	assert.Regexp(t, `^"[0-9a-f]{64}"$`, etag)
+	r := httptest.NewRequest(http.MethodGet, "/emails/welcome/html", nil)
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotModified, w.Code, "Unchanged emails should be revalidated")
+	assert.NotEqual(t, etag, previewGet(handler, http.MethodGet, "/emails/welcome/txt").Header().Get("ETag"))
+
+	w = previewGet(handler, http.MethodGet, "/emails/welcome/html?format=widths&dir=rtl")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `<iframe src="/emails/welcome/html?dir=rtl&amp;theme=flat" width="320"`)
+
+	flat := h
+	flat.Theme = new(themes.Flat)
+	expected, err := flat.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, previewGet(handler, http.MethodGet, "/emails/welcome/html?theme=flat").Body.String())
+	assert.Equal(t, http.StatusNotFound, previewGet(handler, http.MethodGet, "/emails/welcome/html?theme=unknown").Code)
+}
+
+func TestPreviewServer_Errors(t *testing.T) {
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+	handler := preview.NewHandler(h, map[string]hermes.Email{"welcome": email})
+	for target, code := range map[string]int{
+		"/emails/missing/html":            http.StatusNotFound,
+		"/emails/welcome/pdf":             http.StatusNotFound,
+		"/emails/welcome":                 http.StatusNotFound,
+		"/other":                          http.StatusNotFound,
+		"/emails/welcome/html?dir=up":     http.StatusBadRequest,
+		"/emails/welcome/html?inline=yes": http.StatusBadRequest,
+		"/?inline=yes":                    http.StatusBadRequest,
+	} {
+		assert.Equal(t, code, previewGet(handler, http.MethodGet, target).Code, target)
+	}
+	assert.Equal(t, http.StatusMethodNotAllowed, previewGet(handler, http.MethodPost, "/").Code)
+
+	broken := hermes.Hermes{Theme: funcsTheme("{{ .Email.Body.Missing }}")}
+	w := previewGet(preview.NewHandler(broken, map[string]hermes.Email{"welcome": email}), http.MethodGet, "/emails/welcome/html")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestPreviewServer_Reload(t *testing.T) {
+	dir := t.TempDir()
+	htmlPath, textPath := filepath.Join(dir, "theme.html"), filepath.Join(dir, "theme.txt")
+	assert.Nil(t, os.WriteFile(htmlPath, []byte(`<p>{{ .Email.Body.Name }}</p>`), 0o644))
+	assert.Nil(t, os.WriteFile(textPath, []byte(`{{ .Email.Body.Name }}`), 0o644))
+	theme, err := themes.NewFromFiles("files", htmlPath, textPath)
+	assert.Nil(t, err)
+	h := hermes.Hermes{Theme: theme, DisableCSSInlining: true}
+	handler := preview.NewHandler(h, map[string]hermes.Email{"welcome": {Body: hermes.Body{Name: "Jon"}}})
+	assert.Equal(t, "<p>Jon</p>\n", previewGet(handler, http.MethodGet, "/emails/welcome/html").Body.String())
+
+	assert.Nil(t, os.WriteFile(htmlPath, []byte(`<h1>{{ .Email.Body.Name }}</h1>`), 0o644))
+	assert.Equal(t, "<h1>Jon</h1>\n", previewGet(handler, http.MethodGet, "/emails/welcome/html").Body.String(),
+		"Changes of the templates should show on the next request")
+
+	assert.Nil(t, os.WriteFile(htmlPath, []byte(`<h1>{{ .Email.Body.Name }</h1>`), 0o644))
+	w := previewGet(handler, http.MethodGet, "/emails/welcome/html")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "theme files: template: ")
+}
@@ -0,0 +1,202 @@
+package hermes
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/pkg/send"
+)
+
+// fakeMXResolver answers the lookups of ValidateRecipients from its records, MX hosts being prefixed with "mx:".
+// Domains in failures fail that many times with a temporary error first.
+type fakeMXResolver struct {
+	records  map[string][]string
+	delay    time.Duration
+	mu       sync.Mutex
+	failures map[string]int
+	lookups  map[string]int
+	running  int32
+	maxRun   int32
+}
+
+func (r *fakeMXResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	n := atomic.AddInt32(&r.running, 1)
+	defer atomic.AddInt32(&r.running, -1)
+	for {
+		max := atomic.LoadInt32(&r.maxRun)
+		if n <= max || atomic.CompareAndSwapInt32(&r.maxRun, max, n) {
+			break
+		}
+	}
+	select {
+	case <-time.After(r.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.lookups == nil {
+		r.lookups = map[string]int{}
+	}
+	r.lookups[name]++
+	if r.failures[name] > 0 {
+		r.failures[name]--
+		return nil, &net.DNSError{Err: "i/o timeout", Name: name, IsTimeout: true}
+	}
+	var mxs []*net.MX
+	for _, record := range r.records[name] {
+		if host, ok := strings.CutPrefix(record, "mx:"); ok {
+			mxs = append(mxs, &net.MX{Host: host, Pref: 10})
+		}
+	}
+	if len(mxs) == 0 {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return mxs, nil
+}
+
+func (r *fakeMXResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	for _, record := range r.records[host] {
+		if !strings.HasPrefix(record, "mx:") {
+			return []string{record}, nil
+		}
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func TestValidateRecipients(t *testing.T) {
+	resolver := &fakeMXResolver{records: map[string][]string{
+		"hermes-example.com": {"mx:mx.hermes-example.com."},
+		"a-only.com":         {"192.0.2.1"},
+		"null-mx.com":        {"mx:."},
+	}}
+	checks := send.ValidateRecipients(context.Background(), []string{
+		"jon@hermes-example.com",
+		"Arya Stark <arya@HERMES-EXAMPLE.com>",
+		"not an address",
+		"jon@localhost",
+		"jon.snow@gmial.com",
+		"sansa@hotmial.com",
+		"bran@mailinator.com",
+		"rickon@eu.yopmail.com",
+		"hodor@a-only.com",
+		"ned@null-mx.com",
+		"cat@nowhere.example",
+	}, send.RecipientOptions{CheckMX: true, Resolver: resolver})
+
+	problems := make([]string, len(checks))
+	for i, check := range checks {
+		problems[i] = check.Problem
+	}
+	assert.Equal(t, []string{
+		"", "", send.RecipientInvalid, send.RecipientInvalid, send.RecipientTypo, send.RecipientTypo,
+		send.RecipientDisposable, send.RecipientDisposable, "", send.RecipientNoMX, send.RecipientNoMX,
+	}, problems)
+	assert.Equal(t, "Arya Stark <arya@HERMES-EXAMPLE.com>", checks[1].Address)
+	assert.Equal(t, "jon.snow@gmail.com", checks[4].Suggestion)
+	assert.Equal(t, "sansa@hotmail.com", checks[5].Suggestion)
+	assert.EqualError(t, checks[9].Err, "domain does not accept emails: null-mx.com has a null MX record")
+	assert.True(t, checks[10].Failed())
+	assert.False(t, checks[0].Failed())
+	assert.Equal(t, 1, resolver.lookups["hermes-example.com"], "Each domain should be looked up once")
+	assert.Zero(t, resolver.lookups["gmial.com"], "Typos should not be looked up")
+
+	checks = send.ValidateRecipients(context.Background(), []string{"jon@nowhere.example"}, send.RecipientOptions{})
+	assert.Equal(t, "", checks[0].Problem, "Domains should not be looked up without CheckMX")
+}
+
+func TestValidateRecipients_Retries(t *testing.T) {
+	resolver := &fakeMXResolver{
+		records:  map[string][]string{"hermes-example.com": {"mx:mx.hermes-example.com."}, "flaky.com": {"mx:mx.flaky.com."}},
+		failures: map[string]int{"hermes-example.com": 2, "flaky.com": 5},
+	}
+	cache := &send.MXCache{}
+	opts := send.RecipientOptions{CheckMX: true, Resolver: resolver, Retries: 2, Cache: cache}
+	checks := send.ValidateRecipients(context.Background(), []string{"jon@hermes-example.com", "arya@flaky.com"}, opts)
+	assert.Equal(t, "", checks[0].Problem)
+	assert.Equal(t, send.RecipientUnverified, checks[1].Problem)
+	assert.False(t, checks[1].Failed(), "Unverified addresses should not fail")
+	assert.ErrorContains(t, checks[1].Err, "i/o timeout")
+	assert.Equal(t, 3, resolver.lookups["flaky.com"])
+
+	checks = send.ValidateRecipients(context.Background(), []string{"jon@hermes-example.com", "arya@flaky.com"}, opts)
+	assert.Equal(t, "", checks[0].Problem)
+	assert.Equal(t, "", checks[1].Problem, "Temporary errors should not be cached")
+	assert.Equal(t, 3, resolver.lookups["hermes-example.com"], "Results should be cached")
+	assert.Equal(t, 6, resolver.lookups["flaky.com"])
+}
+
+func TestValidateRecipients_Concurrency(t *testing.T) {
+	records := map[string][]string{}
+	var addrs []string
+	for _, domain := range []string{"a.com", "b.com", "c.com", "d.com", "e.com", "f.com", "g.com", "h.com"} {
+		records[domain] = []string{"mx:mx." + domain}
+		addrs = append(addrs, "jon@"+domain)
+	}
+	resolver := &fakeMXResolver{records: records, delay: 10 * time.Millisecond}
+	checks := send.ValidateRecipients(context.Background(), addrs, send.RecipientOptions{CheckMX: true, Resolver: resolver, Concurrency: 3})
+	for _, check := range checks {
+		assert.Equal(t, "", check.Problem)
+	}
+	assert.Equal(t, int32(3), resolver.maxRun)
+
+	resolver = &fakeMXResolver{records: records, delay: time.Hour}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	checks = send.ValidateRecipients(ctx, addrs, send.RecipientOptions{CheckMX: true, Resolver: resolver, Concurrency: 2})
+	assert.Less(t, time.Since(start), time.Second, "Lookups should stop with the context")
+	for _, check := range checks {
+		assert.Equal(t, send.RecipientUnverified, check.Problem)
+		assert.True(t, errors.Is(check.Err, context.DeadlineExceeded), "%v", check.Err)
+	}
+}
+
+func TestParseDomainList(t *testing.T) {
+	domains, err := send.ParseDomainList(strings.NewReader("# Disposable\n\nTrash.example\n  temp.example  \n"))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]bool{"trash.example": true, "temp.example": true}, domains)
+	assert.True(t, send.DisposableDomains["mailinator.com"])
+
+	checks := send.ValidateRecipients(context.Background(), []string{"jon@trash.example", "jon@mailinator.com"},
+		send.RecipientOptions{DisposableDomains: domains})
+	assert.Equal(t, send.RecipientDisposable, checks[0].Problem)
+	assert.Equal(t, "", checks[1].Problem, "The list given should replace the embedded one")
+}
+
+func TestScheduler_Validate(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)}
+	sender := &recordingSender{clock: clock}
+	s := schedulerExample(clock, sender, send.NewMemoryCheckpointStore())
+	s.HourlyQuota = 0
+	s.Validate = &send.RecipientOptions{}
+	recipients := []string{"jon@example.com", "arya@gmial.com", "not an address", "sansa@example.com"}
+	assert.Nil(t, s.Run(context.Background(), recipients))
+	assert.Equal(t, []string{"jon@example.com", "sansa@example.com"}, sender.sent)
+	assert.Equal(t, send.Progress{Total: 4, Sent: 4, Skipped: 2}, s.Progress())
+
+	var quarantined []string
+	sender = &recordingSender{clock: clock}
+	s = schedulerExample(clock, sender, send.NewMemoryCheckpointStore())
+	s.Validate = &send.RecipientOptions{}
+	s.Quarantine = func(_ context.Context, check send.RecipientCheck) error {
+		quarantined = append(quarantined, check.Address+" "+check.Problem)
+		if check.Problem == send.RecipientInvalid {
+			return errors.New("quarantine full")
+		}
+		return nil
+	}
+	err := s.Run(context.Background(), recipients)
+	assert.EqualError(t, err, `send: quarantine not an address: quarantine full`)
+	assert.Equal(t, []string{"arya@gmial.com typo", "not an address invalid"}, quarantined)
+	assert.Equal(t, []string{"jon@example.com"}, sender.sent)
+	assert.Equal(t, 2, s.Progress().Sent, "The recipient failing to be quarantined should be handled on the next run")
+}
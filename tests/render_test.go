@@ -0,0 +1,111 @@
+package hermes
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+// renderExample returns a new engine and email on each call, with default values to apply, params to expand and HTML to sanitize
+func renderExample() (hermes.Hermes, hermes.Email) {
+	h := hermes.Hermes{
+		Brand: hermes.Branding{Name: "Hermes"},
+	}
+	email := hermes.Email{
+		Params: map[string]string{"name": "Jon", "token": "a b"},
+		Body: hermes.Body{
+			Name:       "Jon Snow",
+			Intros:     []string{"Welcome {name}!"},
+			Dictionary: []hermes.Entry{{Key: "Firstname", Value: "{name}"}, {Key: "House", HTMLValue: `<b onclick="x()">Stark</b>`}},
+			Tables: []hermes.Table{{
+				Title: "Items",
+				Data:  [][]hermes.Entry{{{Key: "Item", HTMLValue: "<script>x()</script>Golang"}}},
+			}},
+			Actions: []hermes.Action{{
+				Button: hermes.Button{Text: "Confirm", Link: "https://hermes-example.com/confirm?token={token}"},
+			}},
+		},
+	}
+	return h, email
+}
+
+func TestRender_DoesNotModifyArguments(t *testing.T) {
+	renders := map[string]func(hermes.Hermes, hermes.Email) error{
+		"RenderHTML": func(h hermes.Hermes, e hermes.Email) error {
+			_, err := hermes.RenderHTML(h, e)
+			return err
+		},
+		"RenderPlainText": func(h hermes.Hermes, e hermes.Email) error {
+			_, err := hermes.RenderPlainText(h, e)
+			return err
+		},
+		"Render": func(h hermes.Hermes, e hermes.Email) error {
+			_, _, err := hermes.Render(h, e)
+			return err
+		},
+		"GenerateHTML": func(h hermes.Hermes, e hermes.Email) error {
+			_, err := h.GenerateHTML(e)
+			return err
+		},
+	}
+	for name, render := range renders {
+		h, email := renderExample()
+		expectedHermes, expectedEmail := renderExample()
+		assert.Nil(t, render(h, email), name)
+		assert.True(t, reflect.DeepEqual(expectedHermes, h), "%s should not modify the engine", name)
+		assert.True(t, reflect.DeepEqual(expectedEmail, email), "%s should not modify the email", name)
+	}
+}
+
+func TestRender_SameAsMethods(t *testing.T) {
+	h, email := renderExample()
+	expectedHTML, expectedText, err := h.Generate(email)
+	assert.Nil(t, err)
+
+	r, err := hermes.RenderHTML(h, email)
+	assert.Nil(t, err)
+	assert.Equal(t, expectedHTML, r)
+	text, err := hermes.RenderPlainText(h, email)
+	assert.Nil(t, err)
+	assert.Equal(t, expectedText, text)
+
+	assert.Contains(t, r, "Welcome Jon!")
+	assert.Contains(t, r, "token=a%20b")
+	assert.NotContains(t, r, "onclick")
+	assert.NotContains(t, r, "<script>")
+}
+
+// Run with -race: the engine and the email, with their slices and maps, are shared by all the goroutines
+func TestRender_Concurrent(t *testing.T) {
+	h, email := renderExample()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 300)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := hermes.RenderHTML(h, email); err != nil {
+				errs <- err
+			}
+			if _, err := hermes.RenderPlainText(h, email); err != nil {
+				errs <- err
+			}
+			if _, _, err := hermes.Render(h, email); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.Nil(t, err)
+	}
+
+	expectedHermes, expectedEmail := renderExample()
+	assert.True(t, reflect.DeepEqual(expectedHermes, h))
+	assert.True(t, reflect.DeepEqual(expectedEmail, email))
+}
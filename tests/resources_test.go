@@ -0,0 +1,161 @@
+package hermes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+func resourcesExample() (hermes.Hermes, hermes.Email) {
+	h, email := trackingExample()
+	h.TrackingParams = nil
+	h.Brand.Logo = "https://cdn.example-hermes.com/logo.png"
+	h.Brand.WebFonts = []hermes.WebFont{{Family: "Inter", URL: "https://fonts.example.com/inter.woff2"}}
+	return h, email
+}
+
+func TestExternalResources(t *testing.T) {
+	h, email := resourcesExample()
+	html, _, err := h.Generate(email)
+	assert.Nil(t, err)
+
+	assert.Equal(t, []hermes.Resource{
+		{URL: "#details", Kind: hermes.ResourceAnchor, Section: "toc"},
+		{URL: "https://cdn.example-hermes.com/logo.png", Kind: hermes.ResourceImage, Section: "header"},
+		{URL: "https://example-hermes.com/", Kind: hermes.ResourceAnchor, Section: "header"},
+		{URL: "https://example-hermes.com/orders?id=1234#status", Kind: hermes.ResourceAnchor, Section: "action"},
+		{URL: "https://example-hermes.com/orders?id=1234#status", Kind: hermes.ResourceAnchor, Section: "body"},
+		{URL: "https://example-hermes.com/orders?id=1234#status", Kind: hermes.ResourceAnchor, Section: "trouble"},
+		{URL: "https://example-hermes.com/unsubscribe?token=abc", Kind: hermes.ResourceAnchor, Section: "unsubscribe"},
+		{URL: "https://fonts.example.com/inter.woff2", Kind: hermes.ResourceFont, Section: "head"},
+		{URL: "https://github.com/hermes?tab=repositories", Kind: hermes.ResourceAnchor, Section: "social"},
+		{URL: "mailto:support@example-hermes.com", Kind: hermes.ResourceAnchor, Section: "contact"},
+	}, hermes.ExternalResources(html))
+}
+
+func TestExternalResources_Markup(t *testing.T) {
+	doc := `<html><head>
+<link rel="stylesheet" href="https://fonts.googleapis.com/css2?family=Inter">
+<style>@import url('https://fonts.example.com/a.css'); .hero { background-image: url("https://cdn.example.com/hero.jpg"); }</style>
+</head><body background="https://cdn.example.com/bg.png">
+<img src="https://cdn.example.com/a.png" srcset="https://cdn.example.com/a.png 1x, https://cdn.example.com/a@2x.png 2x">
+<img src="data:image/png;base64,iVBORw0KGgo=" alt="">
+<img src="cid:logo@hermes" alt="">
+<img src="/relative.png" alt="">
+<img src="https://track.example.net/open?id=1" width="1" height="1" alt="">
+<img src="https://track.example.net/open?id=2" style="width:0;height:0px" alt="">
+<div style="background:url(https://cdn.example.com/a.png)"></div>
+<!--[if mso]><v:rect href="https://example.com/vml"><v:fill src="https://cdn.example.com/vml.png" /></v:rect><![endif]-->
+<a href="tel:+33123456789">Call</a> <a href="https://EXAMPLE.com:8443/a">A</a> <a href="javascript:void(0)">B</a>
+</body></html>`
+	assert.Equal(t, []hermes.Resource{
+		{URL: "https://EXAMPLE.com:8443/a", Kind: hermes.ResourceAnchor, Section: "body"},
+		{URL: "https://cdn.example.com/a.png", Kind: hermes.ResourceImage, Section: "body"},
+		{URL: "https://cdn.example.com/a@2x.png", Kind: hermes.ResourceImage, Section: "body"},
+		{URL: "https://cdn.example.com/bg.png", Kind: hermes.ResourceImage, Section: "body"},
+		{URL: "https://cdn.example.com/hero.jpg", Kind: hermes.ResourceImage, Section: "head"},
+		{URL: "https://cdn.example.com/vml.png", Kind: hermes.ResourceImage, Section: "body"},
+		{URL: "https://example.com/vml", Kind: hermes.ResourceAnchor, Section: "body"},
+		{URL: "https://fonts.example.com/a.css", Kind: hermes.ResourceFont, Section: "head"},
+		{URL: "https://fonts.googleapis.com/css2?family=Inter", Kind: hermes.ResourceFont, Section: "head"},
+		{URL: "https://track.example.net/open?id=1", Kind: hermes.ResourceTracking, Section: "body"},
+		{URL: "https://track.example.net/open?id=2", Kind: hermes.ResourceTracking, Section: "body"},
+		{URL: "tel:+33123456789", Kind: hermes.ResourceAnchor, Section: "body"},
+	}, hermes.ExternalResources(doc), "data: and cid: URIs and relative URLs should be left out, and resources listed once")
+	assert.Equal(t, "example.com", hermes.Resource{URL: "https://EXAMPLE.com:8443/a"}.Host())
+}
+
+func TestCheckResources(t *testing.T) {
+	resources := []hermes.Resource{
+		{URL: "#top", Kind: hermes.ResourceAnchor, Section: "toc"},
+		{URL: "mailto:jon@snow.com", Kind: hermes.ResourceAnchor, Section: "contact"},
+		{URL: "https://example.com/a", Kind: hermes.ResourceAnchor, Section: "body"},
+		{URL: "https://cdn.example.com/logo.png", Kind: hermes.ResourceImage, Section: "header"},
+		{URL: "https://img.cdn.example.com/logo.png", Kind: hermes.ResourceImage, Section: "header"},
+		{URL: "https://notexample.com/a", Kind: hermes.ResourceAnchor, Section: "body"},
+		{URL: "https://track.example.net/open", Kind: hermes.ResourceTracking, Section: "body"},
+	}
+	issues := hermes.CheckResources(resources, []string{"Example.com", " *.cdn.example.com"}, hermes.SeverityWarning)
+	assert.Equal(t, hermes.Issues{
+		{Code: hermes.IssueResourceHost, Severity: hermes.SeverityWarning, Message: "image https://cdn.example.com/logo.png in header: host cdn.example.com is not allowed"},
+		{Code: hermes.IssueResourceHost, Severity: hermes.SeverityWarning, Message: "anchor https://notexample.com/a in body: host notexample.com is not allowed"},
+		{Code: hermes.IssueResourceHost, Severity: hermes.SeverityWarning, Message: "tracking https://track.example.net/open in body: host track.example.net is not allowed"},
+	}, issues, `"*." entries should only allow subdomains, anchors and mailto: links should never be reported`)
+	assert.False(t, issues.HasErrors())
+	assert.Nil(t, hermes.CheckResources(resources, []string{"example.com", "*.example.com", "notexample.com", "*.example.net"}, hermes.SeverityError))
+}
+
+func TestCheckResources_Rendering(t *testing.T) {
+	h, email := resourcesExample()
+	h.AllowedResourceHosts = []string{"example-hermes.com", "*.example-hermes.com", "github.com"}
+	out, err := h.GenerateContext(context.Background(), email)
+	assert.Nil(t, err)
+	assert.Equal(t, hermes.Issues{{
+		Code:     hermes.IssueResourceHost,
+		Severity: hermes.SeverityWarning,
+		Message:  "font https://fonts.example.com/inter.woff2 in head: host fonts.example.com is not allowed",
+	}}, out.Warnings)
+
+	h.StrictValidation = true
+	_, err = h.GenerateHTML(email)
+	var issues hermes.Issues
+	assert.True(t, errors.As(err, &issues), "Resources should be errors in strict mode")
+	assert.True(t, issues.HasErrors())
+
+	// The hosts are checked once the links are rewritten by the click tracker
+	h.Brand.WebFonts = nil
+	_, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+	h.LinkRewriter = func(link string) string {
+		return "https://click.tracker.com/?u=" + url.QueryEscape(link)
+	}
+	_, err = h.GenerateHTML(email)
+	assert.ErrorContains(t, err, "host click.tracker.com is not allowed")
+	h.AllowedResourceHosts = append(h.AllowedResourceHosts, "click.tracker.com")
+	_, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+}
+
+func TestCLI_Resources(t *testing.T) {
+	run := buildCLI(t)
+	h, email := resourcesExample()
+	html, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	path := filepath.Join(t.TempDir(), "welcome.html")
+	assert.Nil(t, os.WriteFile(path, []byte(html), 0644))
+
+	var report struct {
+		Resources []hermes.Resource `json:"resources"`
+		Issues    []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"issues"`
+	}
+	status, stdout, _ := run("resources", path)
+	assert.Equal(t, 0, status)
+	assert.Nil(t, json.Unmarshal([]byte(stdout), &report))
+	assert.Equal(t, hermes.ExternalResources(html), report.Resources)
+	assert.Empty(t, report.Issues)
+
+	status, stdout, _ = run("resources", path, "--allow", "example-hermes.com,*.example-hermes.com,github.com")
+	assert.Equal(t, 1, status)
+	assert.Nil(t, json.Unmarshal([]byte(stdout), &report))
+	if assert.Len(t, report.Issues, 1) {
+		assert.Equal(t, hermes.IssueResourceHost, report.Issues[0].Code)
+		assert.Contains(t, report.Issues[0].Message, "fonts.example.com")
+	}
+
+	status, _, _ = run("resources", "--allow", "example-hermes.com,*.example-hermes.com,*.example.com,github.com", path)
+	assert.Equal(t, 0, status)
+	status, _, _ = run("resources", filepath.Join(t.TempDir(), "missing.html"))
+	assert.Equal(t, 2, status)
+	status, _, _ = run("resources", path, "other.html")
+	assert.Equal(t, 2, status)
+}
@@ -0,0 +1,50 @@
+package hermes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/examples/mails"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+func TestResponsiveStack_Golden(t *testing.T) {
+	email := new(mails.Receipt).Email()
+	email.Body.Table.ResponsiveMode = hermes.ResponsiveStack
+	for _, theme := range testedThemes {
+		h := hermes.Hermes{
+			Theme: theme,
+			Brand: hermes.Branding{Name: "Hermes", Link: "https://example-hermes.com/", Copyright: "Copyright © Hermes-Test"},
+		}
+		html, err := h.GenerateHTML(email)
+		assert.Nil(t, err)
+		assertGolden(t, "responsive/"+theme.Name()+".html", html)
+
+		assert.Contains(t, html, `class="data-table data-table--stack"`, theme.Name())
+		for _, key := range []string{"Item", "Description", "Price"} {
+			assert.Equal(t, 2, strings.Count(html, `data-label="`+key+`"`), "%s: every cell is labelled", theme.Name())
+		}
+		// The media queries are left out of CSS inlining, which has nothing to inline, the header row stays for Outlook
+		assert.Contains(t, html, `data-premailer="ignore" data-hermes-css="stack"`, theme.Name())
+		assert.Contains(t, html, "@media only screen and (max-width: 480px)", theme.Name())
+		assert.Contains(t, html, "content: attr(data-label)", theme.Name())
+		assert.Contains(t, html, "<th", theme.Name())
+	}
+}
+
+func TestResponsiveNone(t *testing.T) {
+	email := new(mails.Receipt).Email()
+	for _, theme := range testedThemes {
+		h := hermes.Hermes{Theme: theme}
+		html, err := h.GenerateHTML(email)
+		assert.Nil(t, err)
+		assert.NotContains(t, html, "data-label", theme.Name())
+		assert.NotContains(t, html, "data-table--stack", theme.Name())
+		assert.NotContains(t, html, `data-hermes-css="stack"`, theme.Name())
+	}
+
+	// The CSS is only added for tables with data
+	email.Body.Table = hermes.Table{ResponsiveMode: hermes.ResponsiveStack}
+	assert.False(t, email.Body.HasStackedTables())
+}
@@ -0,0 +1,90 @@
+package hermes
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/examples/mails"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+func TestRTL_Golden(t *testing.T) {
+	for _, theme := range []hermes.Theme{new(themes.Default), new(themes.Flat)} {
+		h := hermes.Hermes{
+			Theme: theme,
+			Brand: hermes.Branding{
+				Name:      "Hermes",
+				Link:      "https://example-hermes.com/",
+				Logo:      "https://github.com/matcornic/hermes/blob/master/examples/gopher.png?raw=true",
+				Copyright: "Copyright © Hermes-Test",
+			},
+			TextDirection: hermes.TDRightToLeft,
+		}
+		html, text, err := h.Generate(new(mails.Receipt).Email())
+		assert.Nil(t, err)
+		assertGolden(t, "rtl/"+theme.Name()+".receipt.html", html)
+		assertGolden(t, "rtl/"+theme.Name()+".receipt.txt", text)
+	}
+}
+
+func TestRTL_Alignment(t *testing.T) {
+	for _, theme := range testedThemes {
+		h, email := (&SimpleExample{theme}).getExample()
+		h.DisableCSSInlining = false
+		h.TextDirection = hermes.TDRightToLeft
+		email.Body.Table.Columns.CustomAlignment = map[string]string{"Item": "end", "Price": "right"}
+		r, err := h.GenerateHTML(email)
+		assert.Nil(t, err, theme.Name())
+		assert.Contains(t, r, `class="email-wrapper" `, theme.Name())
+		assert.Contains(t, r, `dir="rtl" width="100%"`, "The layout should be mirrored when the body loses its dir, with %s", theme.Name())
+		assert.Regexp(t, `class="content-cell" style="[^"]*text-align:right`, r, theme.Name())
+		assert.Regexp(t, `width="20%"[^>]*style="[^"]*text-align:left`, r, "End alignment should be on the left, with %s", theme.Name())
+		assert.Regexp(t, `width="15%"[^>]*style="[^"]*text-align:left`, r, "Right alignment should be mirrored, with %s", theme.Name())
+		assert.NotContains(t, r, "text-align:end", theme.Name())
+
+		h.TextDirection = hermes.TDLeftToRight
+		r, err = h.GenerateHTML(email)
+		assert.Nil(t, err, theme.Name())
+		assert.Regexp(t, `width="20%"[^>]*style="[^"]*text-align:right`, r, theme.Name())
+		assert.Regexp(t, `width="15%"[^>]*style="[^"]*text-align:right`, r, theme.Name())
+	}
+}
+
+// TestRTL_CustomCSS renders the mirrored rules in the stylesheet of the theme, along with CustomCSS
+func TestRTL_CustomCSS(t *testing.T) {
+	for _, theme := range testedThemes {
+		h, email := (&SimpleExample{theme}).getExample()
+		h.TextDirection = hermes.TDRightToLeft
+		h.CustomCSS = ".email-footer { letter-spacing: 2px; }"
+		h.DisableCSSInlining = true
+		raw, err := h.GenerateHTML(email)
+		assert.Nil(t, err, theme.Name())
+		assert.NotContains(t, raw, `data-hermes-css="rtl"`, theme.Name())
+		assert.Equal(t, 2, strings.Count(raw, `<style type="text/css" rel="stylesheet" media="all">`)+strings.Count(raw, `data-hermes-css="custom"`),
+			"Only the theme and the custom CSS should be inlined, with %s", theme.Name())
+
+		h.DisableCSSInlining = false
+		r, err := h.GenerateHTML(email)
+		assert.Nil(t, err, theme.Name())
+		assert.Regexp(t, `class="content-cell" style="[^"]*text-align:right`, r, theme.Name())
+		assert.Regexp(t, `class="email-footer"[^>]*style="[^"]*letter-spacing:2px`, r, theme.Name())
+	}
+}
+
+func TestRTL_InvalidTextDirection(t *testing.T) {
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+	h.TextDirection = "right-to-left"
+	_, err := h.GenerateHTML(email)
+	var verr hermes.ValidationError
+	assert.True(t, errors.As(err, &verr))
+	assert.Equal(t, hermes.CodeInvalidTextDirection, verr.Code)
+	assert.Equal(t, "right-to-left", verr.Value)
+	assert.Equal(t, `La direction du texte doit être « ltr » ou « rtl » (reçu « right-to-left »).`, verr.Localize("fr"))
+
+	assert.Nil(t, hermes.TDLeftToRight.Validate())
+	assert.Nil(t, hermes.TDRightToLeft.Validate())
+	assert.NotNil(t, hermes.TextDirection("RTL").Validate())
+}
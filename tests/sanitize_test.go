@@ -0,0 +1,96 @@
+package hermes
+
+import (
+	"html/template"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+func htmlValueExample() (hermes.Hermes, hermes.Email) {
+	h := hermes.Hermes{DisableCSSInlining: true}
+	email := hermes.Email{
+		Body: hermes.Body{
+			Dictionary: []hermes.Entry{
+				{Key: "Plan", HTMLValue: `<strong>Pro</strong> <a href="https://example.com/plans" onclick="steal()">details</a><script>alert("xss")</script>`},
+				{Key: "Note", Value: "<em>not markup</em>"},
+			},
+			Table: hermes.Table{
+				Data: [][]hermes.Entry{
+					{{Key: "Item", HTMLValue: `<em>Golang</em><img src="x" onerror="steal()"><a href="javascript:steal()">link</a>`}},
+				},
+			},
+		},
+	}
+	return h, email
+}
+
+func TestHTMLValue_RenderedSanitized(t *testing.T) {
+	h, email := htmlValueExample()
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+
+	assert.Contains(t, r, `<dd><strong>Pro</strong> <a href="https://example.com/plans">details</a></dd>`)
+	assert.Contains(t, r, `<em>Golang</em><a>link</a>`)
+	assert.Contains(t, r, `<dd>&lt;em&gt;not markup&lt;/em&gt;</dd>`, "Value should still be escaped")
+	assert.NotContains(t, r, "<script>alert")
+	assert.NotContains(t, r, "steal()")
+	assert.NotContains(t, r, "<img")
+
+	assert.Equal(t, template.HTML(`<strong>Pro</strong> <a href="https://example.com/plans" onclick="steal()">details</a><script>alert("xss")</script>`),
+		email.Body.Dictionary[0].HTMLValue, "Email should not be modified")
+}
+
+func TestHTMLValue_CustomSanitizerCannotBeBypassed(t *testing.T) {
+	h, email := htmlValueExample()
+	h.Sanitizer = stripAll{}
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+
+	assert.Contains(t, r, `<dd>sanitized</dd>`)
+	assert.NotContains(t, r, "<strong>Pro</strong>")
+	assert.NotContains(t, r, "<script>alert")
+}
+
+func TestHTMLValue_PlainText(t *testing.T) {
+	h, email := htmlValueExample()
+	r, err := h.GeneratePlainText(email)
+	assert.Nil(t, err)
+
+	assert.Contains(t, r, "Plan: *Pro* details ( https://example.com/plans )")
+	assert.NotContains(t, r, "xss")
+	assert.NotContains(t, r, "steal")
+}
+
+func TestDefaultSanitizer(t *testing.T) {
+	tests := map[string]string{
+		`<p>Hello <b>World</b><br>!</p>`:                                  `<p>Hello <b>World</b><br>!</p>`,
+		`<div class="x"><span dir="rtl" style="color:red">x</span></div>`: `<span dir="rtl">x</span>`,
+		`<a href=" JavaScript:alert(1)">x</a>`:                            `<a>x</a>`,
+		`<a href="mailto:jon@example.com" title="Mail">x</a>`:             `<a href="mailto:jon@example.com" title="Mail">x</a>`,
+		`<style>p{}</style><!-- comment --><iframe>x</iframe>y`:           `y`,
+		`<svg><script>alert(1)</script></svg>1 < 2`:                       `1 &lt; 2`,
+		`<a href="https://example.com/?a=1&b=2">x</a>`:                    `<a href="https://example.com/?a=1&amp;b=2">x</a>`,
+	}
+	for in, expected := range tests {
+		assert.Equal(t, expected, hermes.DefaultSanitizer.Sanitize(in), in)
+	}
+}
+
+func TestParseEmail_HTMLValue(t *testing.T) {
+	h, email := htmlValueExample()
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+
+	parsed, _, err := hermes.ParseEmail(r)
+	assert.Nil(t, err)
+	assert.Equal(t, template.HTML(`<strong>Pro</strong> <a href="https://example.com/plans">details</a>`), parsed.Body.Dictionary[0].HTMLValue)
+	assert.Equal(t, "<em>not markup</em>", parsed.Body.Dictionary[1].Value)
+}
+
+type stripAll struct{}
+
+func (stripAll) Sanitize(string) string {
+	return "sanitized"
+}
@@ -0,0 +1,106 @@
+package hermes
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+// scheduleTheme renders the helpers of schedules for each entry
+type scheduleTheme struct{}
+
+func (scheduleTheme) Name() string { return "schedule" }
+func (scheduleTheme) HTMLTemplate() string {
+	return `{{ range .Email.Body.Schedule }}{{ weekday $.Hermes .Start }}|{{ relTime $.Hermes .Start }}|{{ end }}`
+}
+func (scheduleTheme) PlainTextTemplate() string { return "" }
+
+// saturday is Saturday 3 August 2024, 2AM UTC
+var saturday = time.Date(2024, time.August, 3, 2, 0, 0, 0, time.UTC)
+
+func scheduleExample(entries ...hermes.ScheduleEntry) hermes.Email {
+	return hermes.Email{Body: hermes.Body{Name: "Jon Snow", Schedule: entries}}
+}
+
+func TestSchedule_TimeRange(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err)
+	// DST starts on Sunday 10 March 2024 at 2AM in New York
+	dst := time.Date(2024, time.March, 10, 6, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		zone     *time.Location
+		entry    hermes.ScheduleEntry
+		expected string
+	}{
+		{"Same day", nil, hermes.ScheduleEntry{Label: "Service A", Start: saturday, End: saturday.Add(time.Hour)}, "Saturday 2AM–3AM UTC"},
+		{"Without end", nil, hermes.ScheduleEntry{Label: "Service A", Start: saturday.Add(30 * time.Minute)}, "Saturday 2:30AM UTC"},
+		{"Across midnight", nil, hermes.ScheduleEntry{Label: "Service A", Start: saturday.Add(21*time.Hour + 30*time.Minute), End: saturday.Add(23 * time.Hour)}, "Saturday 11:30PM – Sunday 1AM UTC"},
+		{"Display time zone", newYork, hermes.ScheduleEntry{Label: "Service A", Start: saturday, End: saturday.Add(time.Hour)}, "Friday 10PM–11PM EDT"},
+		{"Across DST change", newYork, hermes.ScheduleEntry{Label: "Service A", Start: dst, End: dst.Add(2 * time.Hour)}, "Sunday 1:30AM EST–4:30AM EDT"},
+		{"Across midnight and DST change", newYork, hermes.ScheduleEntry{Label: "Service A", Start: dst.Add(-3 * time.Hour), End: dst.Add(2 * time.Hour)}, "Saturday 10:30PM EST – Sunday 4:30AM EDT"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h := hermes.Hermes{DisableCSSInlining: true, TimeZone: test.zone}
+			r, err := h.GenerateHTML(scheduleExample(test.entry))
+			assert.Nil(t, err)
+			assert.Contains(t, r, `<td class="body-schedule_label">Service A</td>`)
+			assert.Contains(t, r, "<td>"+test.expected+"</td>")
+
+			text, err := h.GeneratePlainText(scheduleExample(test.entry))
+			assert.Nil(t, err)
+			assert.Contains(t, text, "Service A: "+test.expected)
+		})
+	}
+}
+
+func TestSchedule_Localized(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	assert.Nil(t, err)
+	h := hermes.Hermes{DisableCSSInlining: true, Locale: "fr-FR", TimeZone: paris}
+	r, err := h.GenerateHTML(scheduleExample(hermes.ScheduleEntry{Label: "Service A", Start: saturday, End: saturday.Add(time.Hour)}))
+	assert.Nil(t, err)
+	assert.Contains(t, r, "<td>samedi 04:00–05:00 CEST</td>")
+}
+
+func TestSchedule_EndsBeforeStart(t *testing.T) {
+	h := hermes.Hermes{DisableCSSInlining: true}
+	_, err := h.GenerateHTML(scheduleExample(hermes.ScheduleEntry{Label: "Service A", Start: saturday, End: saturday.Add(-time.Hour)}))
+	assert.ErrorContains(t, err, "time range ends before it starts")
+}
+
+func TestSchedule_Helpers(t *testing.T) {
+	now := func() time.Time { return saturday }
+	email := scheduleExample(
+		hermes.ScheduleEntry{Start: saturday.Add(20 * time.Second)},
+		hermes.ScheduleEntry{Start: saturday.Add(time.Minute)},
+		hermes.ScheduleEntry{Start: saturday.Add(59*time.Minute + 50*time.Second)},
+		hermes.ScheduleEntry{Start: saturday.Add(-2 * time.Hour)},
+		hermes.ScheduleEntry{Start: saturday.Add(23*time.Hour + 50*time.Minute)},
+		hermes.ScheduleEntry{Start: saturday.Add(-72 * time.Hour)},
+	)
+
+	h := hermes.Hermes{Theme: scheduleTheme{}, DisableCSSInlining: true, Now: now}
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Equal(t, "Saturday|now|Saturday|in 1 minute|Saturday|in 1 hour|Saturday|2 hours ago|Sunday|in 1 day|Wednesday|3 days ago|\n", r)
+
+	h = hermes.Hermes{Theme: scheduleTheme{}, DisableCSSInlining: true, Now: now, Locale: "de"}
+	r, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Equal(t, "Samstag|jetzt|Samstag|in 1 Minute|Samstag|in 1 Stunde|Samstag|vor 2 Stunden|Sonntag|in 1 Tag|Mittwoch|vor 3 Tagen|\n", r)
+}
+
+func TestSchedule_Freeze(t *testing.T) {
+	h := hermes.Hermes{DisableCSSInlining: true}
+	frozen, err := h.Freeze(scheduleExample(hermes.ScheduleEntry{Label: "Service A", Start: saturday, End: saturday.Add(time.Hour)}), []string{"Body.Name"})
+	assert.Nil(t, err)
+	out, err := frozen.Instantiate(map[string]string{"Body.Name": "Arya Stark"})
+	assert.Nil(t, err)
+	assert.Contains(t, out.HTML, "<td>Saturday 2AM–3AM UTC</td>")
+}
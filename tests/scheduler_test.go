@@ -0,0 +1,378 @@
+package hermes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/send"
+	"github.com/unknowns24/hermes/pkg/send/events"
+)
+
+// fakeClock is the clock of schedulers in tests, waiting moves it forward instantly
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Wait(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	return nil
+}
+
+// recordingSender records the recipients of the messages and the time they are sent at.
+// It crashes the scheduler when crash returns true, and advances the clock by step after each message.
+type recordingSender struct {
+	clock *fakeClock
+	step  time.Duration
+	crash func(to string) bool
+	sent  []string
+	times []time.Time
+}
+
+// errCrash simulates a crash of the process while sending, which a scheduler cannot record
+type errCrash struct{}
+
+func (s *recordingSender) Send(_ context.Context, msg send.Message) error {
+	if s.crash != nil && s.crash(msg.To[0]) {
+		panic(errCrash{})
+	}
+	s.sent = append(s.sent, msg.To[0])
+	s.times = append(s.times, s.clock.Now())
+	s.clock.Wait(context.Background(), s.step)
+	return nil
+}
+
+// runUntilCrash runs the scheduler, returning whether it crashed
+func runUntilCrash(ctx context.Context, s *send.Scheduler, recipients []string) (crashed bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(errCrash); !ok {
+				panic(r)
+			}
+			crashed = true
+		}
+	}()
+	return false, s.Run(ctx, recipients)
+}
+
+func schedulerRecipients(n int) []string {
+	recipients := make([]string, n)
+	for i := range recipients {
+		recipients[i] = fmt.Sprintf("user%d@example.com", i)
+	}
+	return recipients
+}
+
+func schedulerExample(clock *fakeClock, sender send.Sender, store send.CheckpointStore) *send.Scheduler {
+	return &send.Scheduler{
+		Sender: sender,
+		Campaign: send.Campaign{
+			From:    "Hermes <hello@hermes-example.com>",
+			Subject: "Spring sale",
+			Content: hermes.Output{HTML: "<p>Sale</p>", PlainText: "Sale"},
+		},
+		ID:          "spring-sale",
+		DailyQuota:  5,
+		HourlyQuota: 2,
+		Checkpoints: store,
+		Now:         clock.Now,
+		Wait:        clock.Wait,
+	}
+}
+
+func TestScheduler_Quotas(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 22, 30, 0, 0, time.UTC)}
+	sender := &recordingSender{clock: clock, step: time.Minute}
+	s := schedulerExample(clock, sender, nil)
+	recipients := schedulerRecipients(9)
+
+	assert.Nil(t, s.Run(context.Background(), recipients))
+	assert.Equal(t, recipients, sender.sent)
+	var times []string
+	for _, at := range sender.times {
+		times = append(times, at.Format("Jan 2 15:04"))
+	}
+	assert.Equal(t, []string{
+		"Mar 1 22:30", "Mar 1 22:31", // Hourly quota reached
+		"Mar 1 23:00", "Mar 1 23:01",
+		"Mar 2 00:00", "Mar 2 00:01", // New day, and a new hour
+		"Mar 2 01:00", "Mar 2 01:01",
+		"Mar 2 02:00",
+	}, times)
+	assert.Equal(t, send.Progress{Total: 9, Sent: 9}, s.Progress())
+}
+
+func TestScheduler_DailyQuota(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)}
+	sender := &recordingSender{clock: clock, step: time.Second}
+	s := schedulerExample(clock, sender, nil)
+	s.HourlyQuota = 0
+	s.Location = time.FixedZone("UTC-3", -3*3600)
+
+	assert.Nil(t, s.Run(context.Background(), schedulerRecipients(12)))
+	assert.Len(t, sender.sent, 12)
+	assert.Equal(t, time.Date(2024, 3, 1, 9, 0, 4, 0, time.UTC), sender.times[4])
+	// Days start at midnight in the time zone of the scheduler
+	assert.Equal(t, time.Date(2024, 3, 2, 3, 0, 0, 0, time.UTC), sender.times[5].UTC())
+	assert.Equal(t, time.Date(2024, 3, 3, 3, 0, 0, 0, time.UTC), sender.times[10].UTC())
+}
+
+func TestScheduler_HalfHourZone(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 10, 40, 0, 0, time.UTC)} // 16:10 in India
+	sender := &recordingSender{clock: clock, step: time.Minute}
+	s := schedulerExample(clock, sender, nil)
+	s.DailyQuota = 0
+	s.Location = time.FixedZone("IST", 5*3600+30*60)
+
+	assert.Nil(t, s.Run(context.Background(), schedulerRecipients(5)))
+	var times []string
+	for _, at := range sender.times {
+		times = append(times, at.In(s.Location).Format("15:04"))
+	}
+	// Hours start on the hours of the time zone of the scheduler, 30 minutes past those of UTC
+	assert.Equal(t, []string{"16:10", "16:11", "17:00", "17:01", "18:00"}, times)
+
+	s.ID = "next-campaign"
+	batches, err := s.Plan(context.Background(), schedulerRecipients(3))
+	assert.Nil(t, err)
+	if assert.Len(t, batches, 2) {
+		assert.Equal(t, time.Date(2024, 3, 1, 13, 30, 0, 0, time.UTC), batches[1].Start.UTC())
+	}
+}
+
+func TestScheduler_DST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err)
+	for name, test := range map[string]struct {
+		start    time.Time
+		expected []string
+	}{
+		// 01:00 to 02:00 happens twice on November 1st, 2026
+		"fall back": {time.Date(2026, 11, 1, 5, 40, 0, 0, time.UTC), []string{"01:40 EDT", "01:41 EDT", "01:00 EST", "01:01 EST", "02:00 EST"}},
+		// 02:00 to 03:00 does not happen on March 8th, 2026
+		"spring forward": {time.Date(2026, 3, 8, 6, 40, 0, 0, time.UTC), []string{"01:40 EST", "01:41 EST", "03:00 EDT", "03:01 EDT", "04:00 EDT"}},
+	} {
+		clock := &fakeClock{now: test.start}
+		sender := &recordingSender{clock: clock, step: time.Minute}
+		s := schedulerExample(clock, sender, nil)
+		s.DailyQuota = 0
+		s.Location = newYork
+		var waits []time.Duration
+		s.Wait = func(ctx context.Context, d time.Duration) error {
+			waits = append(waits, d)
+			if len(waits) > 10 {
+				return errors.New("busy loop")
+			}
+			return clock.Wait(ctx, d)
+		}
+
+		assert.Nil(t, s.Run(context.Background(), schedulerRecipients(5)), name)
+		var times []string
+		for _, at := range sender.times {
+			times = append(times, at.In(newYork).Format("15:04 MST"))
+		}
+		assert.Equal(t, test.expected, times, name)
+		for _, d := range waits {
+			assert.Positive(t, d, name)
+		}
+	}
+}
+
+func TestScheduler_Plan(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 23, 15, 0, 0, time.UTC)}
+	s := schedulerExample(clock, &recordingSender{clock: clock}, nil)
+	recipients := schedulerRecipients(7)
+
+	batches, err := s.Plan(context.Background(), recipients)
+	assert.Nil(t, err)
+	assert.Equal(t, []send.Batch{
+		{Start: time.Date(2024, 3, 1, 23, 15, 0, 0, time.UTC), Recipients: recipients[0:2]},
+		{Start: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), Recipients: recipients[2:4]},
+		{Start: time.Date(2024, 3, 2, 1, 0, 0, 0, time.UTC), Recipients: recipients[4:6]},
+		{Start: time.Date(2024, 3, 2, 2, 0, 0, 0, time.UTC), Recipients: recipients[6:7]},
+	}, batches)
+
+	s.HourlyQuota, s.DailyQuota = 0, 0
+	batches, err = s.Plan(context.Background(), recipients)
+	assert.Nil(t, err)
+	assert.Equal(t, []send.Batch{{Start: clock.Now(), Recipients: recipients}}, batches)
+}
+
+func TestScheduler_ResumeAfterCrash(t *testing.T) {
+	for name, store := range map[string]send.CheckpointStore{
+		"memory": send.NewMemoryCheckpointStore(),
+		"file":   send.FileCheckpointStore{Dir: t.TempDir()},
+	} {
+		clock := &fakeClock{now: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)}
+		recipients := schedulerRecipients(8)
+		crashes := map[string]bool{recipients[1]: true, recipients[5]: true}
+		sender := &recordingSender{clock: clock, step: time.Minute, crash: func(to string) bool {
+			crashed := crashes[to]
+			delete(crashes, to)
+			return crashed
+		}}
+
+		runs := 0
+		for {
+			runs++
+			// Each run is a new process, only the store is left from the previous one
+			crashed, err := runUntilCrash(context.Background(), schedulerExample(clock, sender, store), recipients)
+			assert.Nil(t, err, name)
+			if !crashed {
+				break
+			}
+		}
+		assert.Equal(t, 3, runs, name)
+		// Messages interrupted by a crash are not sent again, the others are sent once
+		assert.Equal(t, []string{recipients[0], recipients[2], recipients[3], recipients[4], recipients[6], recipients[7]}, sender.sent, name)
+		// Quotas are kept across runs: the crashed messages count, as they may have been sent
+		assert.Equal(t, time.Date(2024, 3, 1, 11, 0, 0, 0, time.UTC), sender.times[1], name)
+		assert.Equal(t, time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), sender.times[4], name)
+
+		cp, ok, err := store.Load(context.Background(), "spring-sale")
+		assert.Nil(t, err, name)
+		assert.True(t, ok, name)
+		assert.Equal(t, 8, cp.Sent, name)
+	}
+}
+
+func TestScheduler_Cancel(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)}
+	store := send.NewMemoryCheckpointStore()
+	recipients := schedulerRecipients(6)
+	ctx, cancel := context.WithCancel(context.Background())
+	sender := &recordingSender{clock: clock, step: time.Minute}
+	s := schedulerExample(clock, sender, store)
+	// Cancelled while waiting for the next hour
+	s.Wait = func(ctx context.Context, d time.Duration) error {
+		cancel()
+		return ctx.Err()
+	}
+
+	err := s.Run(ctx, recipients)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, recipients[:2], sender.sent)
+	assert.Equal(t, send.Progress{Total: 6, Sent: 2}, s.Progress())
+	cp, _, _ := store.Load(context.Background(), "spring-sale")
+	assert.Equal(t, 2, cp.Sent)
+	assert.Equal(t, 2, cp.HourSent)
+
+	s = schedulerExample(clock, sender, store)
+	assert.Nil(t, s.Run(context.Background(), recipients))
+	assert.Equal(t, recipients, sender.sent)
+
+	// Cancelled before sending
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	s = schedulerExample(clock, sender, nil)
+	assert.True(t, errors.Is(s.Run(ctx, recipients), context.Canceled))
+	assert.Len(t, sender.sent, 6)
+}
+
+// failingSender fails for the given recipient
+type failingSender struct {
+	recordingSender
+	fail string
+}
+
+func (s *failingSender) Send(ctx context.Context, msg send.Message) error {
+	if msg.To[0] == s.fail {
+		return errors.New("mailbox unavailable")
+	}
+	return s.recordingSender.Send(ctx, msg)
+}
+
+func TestScheduler_SendError(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)}
+	store := send.NewMemoryCheckpointStore()
+	recipients := schedulerRecipients(3)
+	sender := &failingSender{recordingSender: recordingSender{clock: clock}, fail: recipients[1]}
+	s := schedulerExample(clock, sender, store)
+
+	err := s.Run(context.Background(), recipients)
+	assert.EqualError(t, err, "send: message to user1@example.com: mailbox unavailable")
+	cp, _, _ := store.Load(context.Background(), "spring-sale")
+	assert.Equal(t, 1, cp.Sent, "The failed message should be sent again")
+	assert.Equal(t, 1, cp.HourSent)
+
+	sender.fail = ""
+	assert.Nil(t, s.Run(context.Background(), recipients))
+	assert.Equal(t, recipients, sender.sent)
+}
+
+// bouncingSender suppresses a recipient of the list when sending to another one, like a webhook would mid-campaign
+type bouncingSender struct {
+	recordingSender
+	list    events.SuppressionList
+	trigger string
+	bounce  string
+}
+
+func (s *bouncingSender) Send(ctx context.Context, msg send.Message) error {
+	if msg.To[0] == s.trigger {
+		s.list.Suppress(ctx, events.Event{Type: events.EventBounce, Recipient: s.bounce})
+	}
+	return s.recordingSender.Send(ctx, msg)
+}
+
+// brokenSuppressionList fails the lookups of its addresses
+type brokenSuppressionList struct{ events.SuppressionList }
+
+func (brokenSuppressionList) IsSuppressed(context.Context, string) (bool, error) {
+	return false, errors.New("list unavailable")
+}
+
+func TestScheduler_Suppressions(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)}
+	store := send.NewMemoryCheckpointStore()
+	list := events.NewMemorySuppressionList()
+	list.Suppress(context.Background(), events.Event{Type: events.EventComplaint, Recipient: "USER0@example.com"})
+	recipients := schedulerRecipients(5)
+	sender := &bouncingSender{recordingSender: recordingSender{clock: clock, step: time.Minute}, list: list, trigger: recipients[1], bounce: recipients[3]}
+	s := schedulerExample(clock, sender, store)
+	s.HourlyQuota = 3
+	s.Campaign.Suppressions = list
+
+	assert.Nil(t, s.Run(context.Background(), recipients))
+	assert.Equal(t, []string{recipients[1], recipients[2], recipients[4]}, sender.sent, "Suppressed recipients should not be sent to")
+	assert.Equal(t, send.Progress{Total: 5, Sent: 5, Excluded: 2}, s.Progress())
+	assert.Equal(t, time.Date(2024, 3, 1, 10, 2, 0, 0, time.UTC), sender.times[2], "Suppressed recipients should not count in the quotas")
+	cp, _, _ := store.Load(context.Background(), "spring-sale")
+	assert.Equal(t, 2, cp.Excluded)
+
+	s.ID = "broken-list"
+	s.Campaign.Suppressions = brokenSuppressionList{}
+	assert.EqualError(t, s.Run(context.Background(), recipients), "send: suppression of user0@example.com: list unavailable")
+	cp, _, _ = store.Load(context.Background(), "broken-list")
+	assert.Equal(t, 0, cp.Sent)
+}
+
+func TestScheduler_Errors(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)}
+	store := send.NewMemoryCheckpointStore()
+	s := schedulerExample(clock, &recordingSender{clock: clock}, store)
+	assert.Nil(t, s.Run(context.Background(), schedulerRecipients(1)))
+	assert.EqualError(t, s.Run(context.Background(), schedulerRecipients(2)), `send: checkpoint of "spring-sale" is for 1 recipients, not 2`)
+
+	s.ID = ""
+	assert.EqualError(t, s.Run(context.Background(), schedulerRecipients(1)), "send: scheduler without campaign ID")
+	s.Sender = nil
+	assert.EqualError(t, s.Run(context.Background(), schedulerRecipients(1)), "send: scheduler without sender")
+}
@@ -0,0 +1,99 @@
+package hermes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/send"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+func segmentedExample() (hermes.Hermes, hermes.Email) {
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+	email.Body.Intros = []string{"Here is what happened this month."}
+	email.Body.Outros = []string{"See you next month."}
+	email.Body.SegmentedBlocks = map[string][]hermes.Block{
+		hermes.DefaultSegment: {{Intros: []string{"Thanks for reading."}}},
+		"free": {{
+			Actions: []hermes.Action{{
+				Instructions: "Unlock reports with Pro:",
+				Button:       hermes.Button{Text: "Upgrade", Link: "https://hermes-example.com/upgrade"},
+			}},
+		}},
+		"pro": {{
+			Tables: []hermes.Table{{
+				Title: "Your reports",
+				Data:  [][]hermes.Entry{{{Key: "Report", Value: "Weekly revenue"}}},
+			}},
+		}},
+		"enterprise": {
+			{Dictionary: []hermes.Entry{{Key: "Account manager", Value: "Daenerys"}}},
+			{Outros: []string{"Your SLA review is scheduled for June."}},
+		},
+	}
+	return h, email
+}
+
+func TestRenderForSegment(t *testing.T) {
+	h, email := segmentedExample()
+	specific := map[string]string{
+		"free":       "Unlock reports with Pro:",
+		"pro":        "Weekly revenue",
+		"enterprise": "Your SLA review is scheduled for June.",
+	}
+	for segment, text := range specific {
+		out, err := h.RenderForSegment(email, segment)
+		assert.Nil(t, err, segment)
+		assert.Contains(t, out.HTML, "Here is what happened this month.", segment)
+		assert.Contains(t, out.PlainText, "See you next month.", segment)
+		assert.Contains(t, out.HTML, text, segment)
+		assert.Contains(t, out.PlainText, text, segment)
+		for other, otherText := range specific {
+			if other != segment {
+				assert.NotContains(t, out.HTML, otherText, segment)
+			}
+		}
+		assert.NotContains(t, out.HTML, "Thanks for reading.", segment)
+	}
+
+	out, err := h.RenderForSegment(email, "enterprise")
+	assert.Nil(t, err)
+	assert.Contains(t, out.HTML, "Account manager")
+	assert.Less(t, strings.Index(out.PlainText, "Your SLA review"), strings.Index(out.PlainText, "See you next month."), "Outros of blocks come first")
+
+	// The caller's email is not changed
+	assert.Equal(t, []string{"See you next month."}, email.Body.Outros)
+	assert.Len(t, email.Body.Actions, 1)
+}
+
+func TestRenderForSegment_Unknown(t *testing.T) {
+	h, email := segmentedExample()
+	out, err := h.RenderForSegment(email, "trial")
+	assert.Nil(t, err)
+	assert.Contains(t, out.HTML, "Thanks for reading.")
+
+	h.StrictSegments = true
+	_, err = h.RenderForSegment(email, "trial")
+	assert.EqualError(t, err, `unknown segment "trial"`)
+}
+
+func TestCampaign_SegmentResolver(t *testing.T) {
+	c := send.Campaign{
+		From:     "hello@hermes-example.com",
+		Subject:  "News",
+		Content:  hermes.Output{HTML: "<p>Default</p>", PlainText: "Default"},
+		Segments: map[string]hermes.Output{"pro": {HTML: "<p>Pro</p>", PlainText: "Pro"}},
+	}
+	assert.Equal(t, "Default", c.Message("jon@snow.com").Text)
+
+	c.SegmentResolver = func(addr string) string {
+		if addr == "arya@stark.com" {
+			return "pro"
+		}
+		return "free"
+	}
+	assert.Equal(t, "<p>Pro</p>", c.Message("arya@stark.com").HTML)
+	assert.Equal(t, "Default", c.Message("jon@snow.com").Text)
+}
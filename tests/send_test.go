@@ -0,0 +1,163 @@
+package hermes
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/pkg/send"
+)
+
+func sendExample(preference send.ContentPreference) send.Message {
+	return send.Message{
+		From:              "Hermes <hello@hermes-example.com>",
+		To:                []string{"jon@snow.com"},
+		Subject:           "Your order",
+		HTML:              "<p>Your order has been processed successfully.</p>",
+		Text:              "Your order has been processed successfully.",
+		ContentPreference: preference,
+	}
+}
+
+// parts returns the media types and contents of the parts of the MIME message
+func parts(t *testing.T, raw []byte) []string {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	assert.Nil(t, err)
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	assert.Nil(t, err)
+	if mediaType != "multipart/alternative" {
+		body, _ := io.ReadAll(msg.Body)
+		return []string{mediaType, string(body)}
+	}
+
+	var found []string
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		partType, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(p)
+		found = append(found, partType, string(body))
+	}
+	return append([]string{mediaType}, found...)
+}
+
+func TestMessage_Both(t *testing.T) {
+	raw, err := sendExample(send.Both).Bytes()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"multipart/alternative",
+		"text/plain", "Your order has been processed successfully.",
+		"text/html", "<p>Your order has been processed successfully.</p>",
+	}, parts(t, raw))
+}
+
+func TestMessage_TextOnly(t *testing.T) {
+	raw, err := sendExample(send.TextOnly).Bytes()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"text/plain", "Your order has been processed successfully."}, parts(t, raw))
+}
+
+func TestMessage_HTMLOnly(t *testing.T) {
+	m := sendExample(send.HTMLOnly)
+	raw, err := m.Bytes()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"multipart/alternative",
+		"text/plain", "Your order has been processed successfully.",
+		"text/html", "<p>Your order has been processed successfully.</p>",
+	}, parts(t, raw), "HTMLOnly keeps the minimal text part")
+
+	m.Text = ""
+	_, err = m.Bytes()
+	assert.Equal(t, send.ErrHTMLOnlyWithoutText, err)
+
+	m.ForceHTMLOnly = true
+	raw, err = m.Bytes()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"text/html", "<p>Your order has been processed successfully.</p>"}, parts(t, raw))
+}
+
+func TestMessage_Headers(t *testing.T) {
+	m := sendExample(send.Both)
+	m.Subject = "Votre commande été expédiée"
+	raw, err := m.Bytes()
+	assert.Nil(t, err)
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	assert.Nil(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	assert.Nil(t, err)
+	assert.Equal(t, m.Subject, subject)
+	assert.Equal(t, "<jon@snow.com>", msg.Header.Get("To"))
+	assert.Equal(t, "1.0", msg.Header.Get("MIME-Version"))
+	date, err := msg.Header.Date()
+	assert.Nil(t, err, "Messages should have the Date header of RFC 5322")
+	assert.WithinDuration(t, time.Now(), date, time.Minute)
+
+	m.From = "Hermès <hello@hermes-example.com>"
+	m.To = []string{"Jon Snow <jon@snow.com>", "arya@stark.com"}
+	raw, err = m.BytesWith(send.MIMEOptions{Date: time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)})
+	assert.Nil(t, err)
+	msg, err = mail.ReadMessage(bytes.NewReader(raw))
+	assert.Nil(t, err)
+	assert.Equal(t, "=?utf-8?q?Herm=C3=A8s?= <hello@hermes-example.com>", msg.Header.Get("From"), "Addresses should be encoded")
+	assert.Equal(t, `"Jon Snow" <jon@snow.com>, <arya@stark.com>`, msg.Header.Get("To"))
+	assert.Equal(t, "Fri, 01 Mar 2024 09:30:00 +0000", msg.Header.Get("Date"))
+}
+
+func TestMessage_Validate(t *testing.T) {
+	m := sendExample(send.TextOnly)
+	m.Text = ""
+	assert.EqualError(t, m.Validate(), "send: TextOnly message without a plain text part")
+
+	m = sendExample(send.ContentPreference(7))
+	assert.EqualError(t, m.Validate(), "send: unknown content preference 7")
+
+	m = sendExample(send.Both)
+	m.Headers = map[string]string{"Date": "Fri, 01 Mar 2024 09:30:00 +0000"}
+	assert.EqualError(t, m.Validate(), "send: header Date is set from the fields of the message")
+}
+
+func TestMessage_InvalidAddresses(t *testing.T) {
+	for _, test := range []struct {
+		set func(m *send.Message)
+		err string
+	}{
+		{func(m *send.Message) { m.To = []string{"x@y.com\r\nBcc: victim@evil.com"} }, `send: To address "x@y.com\r\nBcc: victim@evil.com" has a line break`},
+		{func(m *send.Message) { m.Cc = []string{"Arya\n <arya@stark.com>"} }, `send: Cc address "Arya\n <arya@stark.com>" has a line break`},
+		{func(m *send.Message) { m.From = "hello@hermes-example.com\rX-Spam: no" }, `send: From address "hello@hermes-example.com\rX-Spam: no" has a line break`},
+		{func(m *send.Message) { m.From = "" }, `send: From address "": mail: no address`},
+		{func(m *send.Message) { m.To = []string{"jon@snow.com, arya@stark.com"} }, `send: To address "jon@snow.com, arya@stark.com": mail: `},
+		{func(m *send.Message) { m.Bcc = []string{"not an address"} }, `send: Bcc address "not an address": mail: `},
+	} {
+		m := sendExample(send.Both)
+		test.set(&m)
+		assert.ErrorContains(t, m.Validate(), test.err)
+		raw, err := m.Bytes()
+		assert.ErrorContains(t, err, test.err, "Messages with invalid addresses should not be written")
+		assert.Nil(t, raw)
+	}
+}
+
+func TestCampaign_PreferenceResolver(t *testing.T) {
+	c := send.Campaign{From: "hello@hermes-example.com", Subject: "News"}
+	assert.Equal(t, send.Both, c.Message("jon@snow.com").ContentPreference)
+
+	c.PreferenceResolver = func(addr string) send.ContentPreference {
+		if addr == "mutt@example.com" {
+			return send.TextOnly
+		}
+		return send.Both
+	}
+	assert.Equal(t, send.TextOnly, c.Message("mutt@example.com").ContentPreference)
+	assert.Equal(t, send.Both, c.Message("jon@snow.com").ContentPreference)
+	assert.Equal(t, []string{"mutt@example.com"}, c.Message("mutt@example.com").To)
+}
@@ -0,0 +1,109 @@
+package hermes
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+const shortTextIntro = "Welcome to Hermes! We're very excited to have you on board, and we hope you will enjoy every single one of our features, from themes to localization and campaigns."
+
+func shortTextExample() (hermes.Hermes, map[string]hermes.Email) {
+	h := hermes.Hermes{
+		Brand: hermes.Branding{Name: "Hermes", Link: "https://hermes-example.com"},
+		Now:   func() time.Time { return time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC) },
+	}
+	code := hermes.Action{Instructions: "Your verification code:", InviteCode: "123456", InviteCodeOptions: hermes.InviteCodeOptions{GroupSize: 3}}
+	button := hermes.Action{
+		Instructions: "Confirm your account:",
+		Button:       hermes.Button{Text: "Confirm", Link: "https://hermes-example.com/confirm?token=d9729feb74992cc3482b350163a1a010"},
+	}
+	return h, map[string]hermes.Email{
+		"invite code": {Body: hermes.Body{Name: "Jon Snow", Intros: []string{shortTextIntro}, Actions: []hermes.Action{code}}},
+		"button":      {Body: hermes.Body{Name: "Jon Snow", Intros: []string{shortTextIntro}, Actions: []hermes.Action{button}}},
+		"no action":   {Body: hermes.Body{Name: "Jon Snow", Intros: []string{shortTextIntro}}},
+	}
+}
+
+func TestGenerateShortText(t *testing.T) {
+	h, emails := shortTextExample()
+	expiresAt := time.Date(2024, 3, 1, 10, 10, 0, 0, time.UTC)
+	tests := []struct {
+		email     string
+		maxLength int
+		expected  string
+	}{
+		{"invite code", 160, "Hermes: Hi Jon Snow, Welcome to Hermes! We're very excited to have you on board, and we hope you will… Your verification code: 123 456 Expires in 10 minutes."},
+		{"invite code", 320, "Hermes: Hi Jon Snow, " + shortTextIntro + " Your verification code: 123 456 Expires in 10 minutes."},
+		{"button", 160, "Hermes: Hi Jon Snow, Welcome to Hermes… Confirm your account: https://hermes-example.com/confirm?token=d9729feb74992cc3482b350163a1a010 Expires in 10 minutes."},
+		{"button", 320, "Hermes: Hi Jon Snow, " + shortTextIntro + " Confirm your account: https://hermes-example.com/confirm?token=d9729feb74992cc3482b350163a1a010 Expires in 10 minutes."},
+		{"no action", 160, "Hermes: Hi Jon Snow, Welcome to Hermes! We're very excited to have you on board, and we hope you will enjoy every single one of our features, from themes to…"},
+		{"no action", 320, "Hermes: Hi Jon Snow, " + shortTextIntro},
+	}
+	for _, test := range tests {
+		opts := hermes.ShortTextOptions{MaxLength: test.maxLength}
+		if test.email != "no action" {
+			opts.ExpiresAt = expiresAt
+		}
+		text, err := h.GenerateShortText(emails[test.email], opts)
+		assert.Nil(t, err, test.email)
+		assert.Equal(t, test.expected, text, "%s at %d", test.email, test.maxLength)
+		assert.LessOrEqual(t, utf8.RuneCountInString(text), test.maxLength)
+
+		again, _ := h.GenerateShortText(emails[test.email], opts)
+		assert.Equal(t, text, again, "Short texts should be deterministic")
+	}
+}
+
+func TestGenerateShortText_Minimal(t *testing.T) {
+	h, emails := shortTextExample()
+
+	text, err := h.GenerateShortText(emails["invite code"], hermes.ShortTextOptions{MaxLength: 40})
+	assert.Nil(t, err)
+	assert.Equal(t, "Hermes: Welcome to Hermes… 123 456", text, "Greeting and instructions should be dropped first")
+	text, err = h.GenerateShortText(emails["invite code"], hermes.ShortTextOptions{MaxLength: 15})
+	assert.Nil(t, err)
+	assert.Equal(t, "Hermes: 123 456", text)
+
+	_, err = h.GenerateShortText(emails["invite code"], hermes.ShortTextOptions{MaxLength: 14})
+	assert.True(t, errors.Is(err, hermes.ErrShortTextTooLong))
+	assert.EqualError(t, err, "short text: minimal content exceeds the maximum length: 14 characters at most, 15 needed")
+	_, err = h.GenerateShortText(emails["no action"], hermes.ShortTextOptions{MaxLength: 10})
+	assert.EqualError(t, err, "short text: minimal content exceeds the maximum length: 10 characters at most, 16 needed")
+
+	text, err = h.GenerateShortText(emails["no action"], hermes.ShortTextOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, hermes.DefaultShortTextLength, 160)
+	assert.LessOrEqual(t, utf8.RuneCountInString(text), hermes.DefaultShortTextLength)
+}
+
+func TestGenerateShortText_URL(t *testing.T) {
+	h, emails := shortTextExample()
+	email := emails["invite code"]
+	email.Body.Actions[0].Button.Link = "https://hermes-example.com/verify?code=123456"
+	shorten := func(url string) (string, error) {
+		return "https://hrm.es/" + strings.Repeat("x", 4), nil
+	}
+
+	text, err := h.GenerateShortText(email, hermes.ShortTextOptions{ShortenURL: shorten})
+	assert.Nil(t, err)
+	assert.True(t, strings.HasSuffix(text, "Your verification code: 123 456"), "Codes should be preferred by default")
+	text, err = h.GenerateShortText(email, hermes.ShortTextOptions{ShortenURL: shorten, PreferURL: true})
+	assert.Nil(t, err)
+	assert.True(t, strings.HasSuffix(text, "Your verification code: https://hrm.es/xxxx"), text)
+
+	_, err = h.GenerateShortText(emails["button"], hermes.ShortTextOptions{ShortenURL: func(string) (string, error) {
+		return "", errors.New("quota exceeded")
+	}})
+	assert.ErrorContains(t, err, "quota exceeded")
+
+	h.Locale = "fr"
+	text, err = h.GenerateShortText(emails["invite code"], hermes.ShortTextOptions{ExpiresAt: h.Now().Add(2 * time.Hour)})
+	assert.Nil(t, err)
+	assert.True(t, strings.HasSuffix(text, "123 456 Expire dans 2 heures."), text)
+}
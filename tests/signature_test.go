@@ -0,0 +1,124 @@
+package hermes
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+func signatureEmail() hermes.Email {
+	return hermes.Email{Body: hermes.Body{
+		Name:   "Jon Snow",
+		Intros: []string{"Your ticket has been closed."},
+		SignatureBlock: &hermes.SignatureBlock{
+			Closing:  "Best regards",
+			Name:     "Jane Doe",
+			Title:    "Head of Support",
+			ImageURL: "https://example-hermes.com/jane.png",
+		},
+	}}
+}
+
+func TestSignatureBlock(t *testing.T) {
+	for _, theme := range testedThemes {
+		h := hermes.Hermes{Theme: theme, Brand: hermes.Branding{Name: "Hermes", Link: "https://example-hermes.com/"}}
+		html, text, err := h.Generate(signatureEmail())
+		assert.Nil(t, err, theme.Name())
+
+		assert.Contains(t, html, `class="signature-block"`, theme.Name())
+		assert.Contains(t, html, "Best regards,", theme.Name())
+		assert.Contains(t, html, "<strong>Jane Doe</strong>", theme.Name())
+		assert.Regexp(t, `<span class="signature-title" style="color: #9BA2AB;[^"]*">Head of Support</span>`, html, theme.Name())
+		assert.Contains(t, html, `<img src="https://example-hermes.com/jane.png" alt="Jane Doe" width="64"`, theme.Name())
+		assert.NotContains(t, html, "Yours truly", "The block should replace the default signature")
+
+		assert.Contains(t, text, "Best regards,\nJane Doe\nHead of Support", theme.Name())
+		assert.NotContains(t, text, "Hermes - https://example-hermes.com/", "The block should replace the name of the brand")
+	}
+}
+
+func TestSignatureBlock_ImageWidth(t *testing.T) {
+	assert.Equal(t, hermes.DefaultSignatureImageWidth, hermes.SignatureBlock{}.ImageDisplayWidth())
+	assert.Equal(t, 120, hermes.SignatureBlock{ImageWidth: 120}.ImageDisplayWidth())
+	assert.Equal(t, hermes.MaxSignatureImageWidth, hermes.SignatureBlock{ImageWidth: 1200}.ImageDisplayWidth())
+
+	h := hermes.Hermes{Theme: new(themes.Default)}
+	email := signatureEmail()
+	email.Body.SignatureBlock.ImageWidth = 1200
+	html, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, html, `width="200" style="display: block; width: 200px; max-width: 100%;`)
+
+	email.Body.SignatureBlock.ImageURL = ""
+	html, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.NotContains(t, html, "signature-image")
+}
+
+func TestSignatureBlock_Defaults(t *testing.T) {
+	email := signatureEmail()
+	email.Body.SignatureBlock.Closing = ""
+	email.Body.SignatureBlock.Title = ""
+
+	h := hermes.Hermes{Theme: new(themes.Default), Locale: "fr"}
+	_, text, err := h.Generate(email)
+	assert.Nil(t, err)
+	assert.Contains(t, text, "Cordialement,\nJane Doe\n\n", "The closing should default to the signature of the locale")
+	assert.Equal(t, "", email.Body.SignatureBlock.Closing, "The block of the caller should not be written")
+}
+
+func TestSignatureBlock_Fallback(t *testing.T) {
+	h := hermes.Hermes{Theme: new(themes.Default), Brand: hermes.Branding{Name: "Hermes", Link: "https://example-hermes.com/"}}
+	email := signatureEmail()
+	email.Body.SignatureBlock = nil
+	email.Body.Signature = "Thanks"
+	html, text, err := h.Generate(email)
+	assert.Nil(t, err)
+	assert.NotContains(t, html, "signature-block")
+	assert.Regexp(t, `<p data-hermes="signature"[^>]*>\s*Thanks,\s*<br/>\s*Hermes\s*</p>`, html)
+	assert.Contains(t, text, "Thanks,\nHermes - https://example-hermes.com/")
+}
+
+func TestSignatureBlock_Conflict(t *testing.T) {
+	h := hermes.Hermes{Theme: new(themes.Default)}
+	email := signatureEmail()
+	email.Body.Signature = "Thanks"
+
+	out, err := h.GenerateContext(context.Background(), email)
+	assert.Nil(t, err)
+	assert.Contains(t, out.HTML, "<strong>Jane Doe</strong>")
+	assert.NotContains(t, out.HTML, "Thanks,")
+	if assert.Len(t, out.Warnings, 1) {
+		assert.Equal(t, string(hermes.CodeSignatureConflict), out.Warnings[0].Code)
+		assert.Equal(t, hermes.SeverityWarning, out.Warnings[0].Severity)
+		assert.Equal(t, "Body.Signature", out.Warnings[0].Path)
+	}
+
+	var issues hermes.Issues
+	assert.True(t, errors.As(email.Validate(), &issues))
+	assert.Len(t, issues, 1)
+	assert.False(t, issues.HasErrors(), "Setting both should only be a warning")
+	assert.Equal(t, string(hermes.CodeSignatureConflict), issues[0].Code)
+
+	h.StrictValidation = true
+	_, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+}
+
+func TestSignatureBlock_Parse(t *testing.T) {
+	h := hermes.Hermes{Theme: new(themes.Default)}
+	email := signatureEmail()
+	email.Body.SignatureBlock.ImageWidth = 96
+	html, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+
+	parsed, _, err := hermes.ParseEmail(html)
+	assert.Nil(t, err)
+	assert.Equal(t, email.Body.SignatureBlock, parsed.Body.SignatureBlock)
+	assert.Empty(t, parsed.Body.Signature)
+	assert.NotContains(t, string(parsed.Body.FreeMarkdown), "signature")
+}
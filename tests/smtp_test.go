@@ -0,0 +1,284 @@
+package hermes
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/pkg/send"
+)
+
+// fakeMail is a message received by fakeSMTP
+type fakeMail struct {
+	Auth  string // Decoded PLAIN credentials
+	TLS   bool
+	From  string
+	Rcpts []string
+	Data  string
+}
+
+// fakeSMTP is a local SMTP server recording the messages it receives
+type fakeSMTP struct {
+	ln       net.Listener
+	tls      *tls.Config
+	startTLS bool         // Offers STARTTLS
+	mute     atomic.Bool  // Never replies, to test timeouts
+	reject   atomic.Value // Recipient refused by RCPT, to test failures
+	dropQuit atomic.Bool  // Closes the connection on QUIT without replying, once the message is accepted
+
+	mu    sync.Mutex
+	mails []fakeMail
+}
+
+// testCertificate returns a self-signed certificate of 127.0.0.1, and a pool trusting it
+func testCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "hermes test"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+// startFakeSMTP starts a server, over implicit TLS or offering STARTTLS following the mode.
+// It returns the SMTP sender configured for it.
+func startFakeSMTP(t *testing.T, mode send.TLSMode) (*fakeSMTP, send.SMTP) {
+	cert, pool := testCertificate(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	f := &fakeSMTP{ln: ln, tls: &tls.Config{Certificates: []tls.Certificate{cert}}, startTLS: mode == send.StartTLS}
+	if mode == send.ImplicitTLS {
+		f.ln = tls.NewListener(ln, f.tls)
+	}
+	t.Cleanup(func() { f.ln.Close() })
+	go func() {
+		for {
+			conn, err := f.ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	port := f.ln.Addr().(*net.TCPAddr).Port
+	return f, send.SMTP{Server: "127.0.0.1", Port: port, TLS: mode, TLSConfig: &tls.Config{RootCAs: pool}}
+}
+
+func (f *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	if f.mute.Load() {
+		_, _ = bufio.NewReader(conn).ReadString('\n')
+		return
+	}
+	var mail fakeMail
+	_, mail.TLS = conn.(*tls.Conn)
+	text := textproto.NewConn(conn)
+	reply := func(lines ...string) {
+		for _, line := range lines {
+			_ = text.PrintfLine("%s", line)
+		}
+	}
+	reply("220 fake ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			if f.startTLS && !mail.TLS {
+				reply("250-fake", "250-STARTTLS", "250 AUTH PLAIN")
+			} else {
+				reply("250-fake", "250 AUTH PLAIN")
+			}
+		case "STARTTLS":
+			reply("220 ready")
+			tlsConn := tls.Server(conn, f.tls)
+			if tlsConn.Handshake() != nil {
+				return
+			}
+			conn, text, mail.TLS = tlsConn, textproto.NewConn(tlsConn), true
+		case "AUTH":
+			_, credentials, _ := strings.Cut(arg, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(credentials)
+			mail.Auth = string(decoded)
+			reply("235 authenticated")
+		case "MAIL":
+			mail.From = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			reply("250 ok")
+		case "RCPT":
+			if rejected, _ := f.reject.Load().(string); rejected != "" && strings.Contains(arg, "<"+rejected+">") {
+				reply("550 no such user")
+				continue
+			}
+			mail.Rcpts = append(mail.Rcpts, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			mail.Data = string(data)
+			f.mu.Lock()
+			f.mails = append(f.mails, mail)
+			f.mu.Unlock()
+			reply("250 queued")
+		case "QUIT":
+			if f.dropQuit.Load() {
+				return
+			}
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func (f *fakeSMTP) received() []fakeMail {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fakeMail(nil), f.mails...)
+}
+
+func smtpMessage() send.Message {
+	m := sendExample(send.Both)
+	m.Cc = []string{"Arya Stark <arya@stark.com>"}
+	m.Bcc = []string{"sansa@stark.com"}
+	m.Headers = map[string]string{"reply-to": "support@hermes-example.com"}
+	return m
+}
+
+func TestSMTP_Send(t *testing.T) {
+	for _, mode := range []send.TLSMode{send.StartTLS, send.ImplicitTLS, send.NoTLS} {
+		t.Run(mode.String(), func(t *testing.T) {
+			f, s := startFakeSMTP(t, mode)
+			s.Username, s.Password = "hermes", "secret"
+			m := smtpMessage()
+			assert.Nil(t, s.Send(context.Background(), m))
+
+			received := f.received()
+			if assert.Len(t, received, 1) {
+				mail := received[0]
+				assert.Equal(t, mode != send.NoTLS, mail.TLS)
+				assert.Equal(t, "\x00hermes\x00secret", mail.Auth)
+				assert.Equal(t, "hello@hermes-example.com", mail.From)
+				assert.Equal(t, []string{"jon@snow.com", "arya@stark.com", "sansa@stark.com"}, mail.Rcpts)
+				raw, err := m.Bytes()
+				assert.Nil(t, err)
+				assert.Equal(t, parts(t, raw), parts(t, []byte(mail.Data)))
+				assert.Contains(t, mail.Data, "Cc: \"Arya Stark\" <arya@stark.com>\n")
+				assert.Contains(t, mail.Data, "Reply-To: support@hermes-example.com\n")
+				assert.NotContains(t, mail.Data, "sansa@stark.com", "Bcc recipients must not be written in the message")
+			}
+		})
+	}
+}
+
+func TestSMTP_QuitError(t *testing.T) {
+	f, s := startFakeSMTP(t, send.NoTLS)
+	f.dropQuit.Store(true)
+	var transmitted int
+	var quitErrors []error
+	s.OnTransmit = func(context.Context, send.Envelope, []byte) { transmitted++ }
+	s.OnQuitError = func(_ context.Context, envelope send.Envelope, err error) {
+		assert.Equal(t, "hello@hermes-example.com", envelope.From)
+		quitErrors = append(quitErrors, err)
+	}
+
+	assert.Nil(t, s.Send(context.Background(), smtpMessage()), "Messages accepted by the server should be sent, whatever QUIT gives")
+	assert.Len(t, f.received(), 1)
+	assert.Equal(t, 1, transmitted)
+	if assert.Len(t, quitErrors, 1) {
+		assert.ErrorContains(t, quitErrors[0], "send: quit: ")
+	}
+
+	s.OnQuitError = nil
+	assert.Nil(t, s.Send(context.Background(), smtpMessage()))
+	assert.Len(t, f.received(), 2)
+}
+
+func TestSMTP_StartTLSNotOffered(t *testing.T) {
+	_, s := startFakeSMTP(t, send.NoTLS)
+	s.TLS = send.StartTLS
+	assert.EqualError(t, s.Send(context.Background(), smtpMessage()), "send: SMTP server does not offer STARTTLS")
+}
+
+func TestSMTP_Timeout(t *testing.T) {
+	f, s := startFakeSMTP(t, send.NoTLS)
+	f.mute.Store(true)
+	s.Timeout = 100 * time.Millisecond
+	start := time.Now()
+	err := s.Send(context.Background(), smtpMessage())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Timeout = 0
+	time.AfterFunc(100*time.Millisecond, cancel)
+	assert.ErrorIs(t, s.Send(ctx, smtpMessage()), context.Canceled)
+}
+
+func TestSMTP_Validate(t *testing.T) {
+	valid := send.SMTP{Server: "smtp.hermes-example.com", Port: 587}
+	assert.Nil(t, valid.Validate())
+	for expected, s := range map[string]send.SMTP{
+		"send: SMTP server is empty":       {Port: 587},
+		"send: invalid SMTP port 0":        {Server: "smtp.hermes-example.com"},
+		"send: SMTP password without user": {Server: "smtp.hermes-example.com", Port: 587, Password: "secret"},
+		"send: unknown TLS mode 9":         {Server: "smtp.hermes-example.com", Port: 587, TLS: 9},
+	} {
+		assert.EqualError(t, s.Validate(), expected)
+		assert.EqualError(t, s.Send(context.Background(), smtpMessage()), expected)
+	}
+
+	m := smtpMessage()
+	m.From = ""
+	assert.EqualError(t, valid.Send(context.Background(), m), "send: message without sender")
+	m = smtpMessage()
+	m.To, m.Cc, m.Bcc = nil, nil, nil
+	assert.EqualError(t, valid.Send(context.Background(), m), "send: message without recipients")
+	m = smtpMessage()
+	m.To = []string{"jon"}
+	assert.ErrorContains(t, valid.Send(context.Background(), m), `send: recipient "jon": `)
+}
+
+func TestMessage_CustomHeaders(t *testing.T) {
+	m := smtpMessage()
+	m.Headers = map[string]string{"Subject": "Spoofed"}
+	assert.EqualError(t, m.Validate(), "send: header Subject is set from the fields of the message")
+	m.Headers = map[string]string{"X-Campaign": "spring\r\nBcc: everyone@example.com"}
+	assert.EqualError(t, m.Validate(), "send: header X-Campaign has a line break")
+	m.Headers = map[string]string{"X-Bad Name": "value"}
+	assert.EqualError(t, m.Validate(), `send: invalid header name "X-Bad Name"`)
+	assert.Equal(t, "TLSMode(7)", send.TLSMode(7).String())
+}
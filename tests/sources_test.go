@@ -0,0 +1,112 @@
+package hermes
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/preview"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+// hasPrivateUse reports whether the string has runes of the private use planes, left by annotations
+func hasPrivateUse(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool { return r >= 0xF0000 && unicode.Is(unicode.Co, r) }) >= 0
+}
+
+func TestAnnotateSources(t *testing.T) {
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+	h.DisableCSSInlining = false
+	h.AnnotateSources = true
+	email.Body.Title = "Welcome"
+	email.Body.Dictionary = append(email.Body.Dictionary, hermes.Entry{Key: "Card", Value: "4242 4242 4242 4242", Mask: hermes.MaskLeft})
+
+	html, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, html, `<span data-source="Body.Intros[0]">Welcome to Hermes! We&#39;re very excited to have you on board.</span>`)
+	assert.Contains(t, html, `<span data-source="Body.Dictionary[0].Value">Jon</span>`)
+	assert.Contains(t, html, `<span data-source="Body.Table.Data[1][0].Value">Hermes</span>`)
+	assert.Contains(t, html, `<span data-source="Body.Actions[0].Instructions">`)
+	assert.Contains(t, html, `<span data-source="localized default: signature">Yours truly</span>`)
+	assert.Contains(t, html, `<span data-source="Brand.Copyright">Copyright © Hermes-Test</span>`)
+	assert.Contains(t, html, `<span data-source="localized default: trouble_text">`)
+	assert.Contains(t, html, "<title>HermesName</title>", "Elements holding text only should not be annotated")
+	assert.Contains(t, html, `alt="HermesName"`, "Attributes should not be annotated")
+	assert.Contains(t, html, "•••• •••• •••• 4242", "Masked values should not be annotated")
+	assert.NotContains(t, html, `data-source="Body.Actions[0].Button.Text"`)
+	assert.Contains(t, html, `style="margin-top:0;color:#2F3133;font-size:19px;font-weight:bold"`, "CSS should be inlined")
+	assert.False(t, hasPrivateUse(html))
+
+	text, err := h.GeneratePlainText(email)
+	assert.Nil(t, err)
+	assert.Contains(t, text, "[Body.Intros[0]] Welcome to Hermes!")
+	assert.Contains(t, text, "[Body.Title] Welcome")
+	assert.Contains(t, text, "[localized default: signature] Yours truly")
+	assert.False(t, hasPrivateUse(text))
+}
+
+func TestAnnotateSources_Off(t *testing.T) {
+	for _, theme := range testedThemes {
+		h, email := (&SimpleExample{theme}).getExample()
+		for _, inline := range []bool{false, true} {
+			h.DisableCSSInlining = !inline
+			html, text, err := h.Generate(email)
+			assert.Nil(t, err)
+			for _, s := range []string{html, text} {
+				assert.NotContains(t, s, "data-source", theme.Name())
+				assert.NotContains(t, s, "[Body.", theme.Name())
+				assert.False(t, hasPrivateUse(s), theme.Name())
+			}
+		}
+
+		h.AnnotateSources = true
+		html, text, err := h.Generate(email)
+		assert.Nil(t, err)
+		assert.Contains(t, html, `data-source="Body.Outros[0]"`, theme.Name())
+		assert.Contains(t, text, "[Body.Outros[0]]", theme.Name())
+		assert.False(t, hasPrivateUse(html+text), theme.Name())
+	}
+}
+
+func TestAnnotateSources_Variants(t *testing.T) {
+	h := hermes.Hermes{Theme: new(themes.Default), AnnotateSources: true, DisableCSSInlining: true}
+	email := hermes.Email{
+		Body: hermes.Body{Summary: &hermes.Summary{Sections: []hermes.SummarySection{{Label: "Tickets", Value: 3, Highlights: []string{"All solved"}}}}},
+		LanguageVariants: []hermes.LanguageVariant{
+			{Tag: "en", Body: hermes.Body{Intros: []string{"Hello"}}},
+			{Tag: "fr", Body: hermes.Body{Intros: []string{"Bonjour"}}},
+		},
+	}
+	html, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, html, `<span data-source="LanguageVariants[0].Body.Intros[0]">Hello</span>`)
+	assert.Contains(t, html, `<span data-source="LanguageVariants[1].Body.Intros[0]">Bonjour</span>`)
+	assert.Contains(t, html, `<span data-source="localized default: greeting">Bonjour</span>`)
+
+	email.LanguageVariants = nil
+	html, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, html, `<span data-source="Body.Summary.Sections[0].Highlights[0]">All solved</span>`,
+		"Every field of the body should be annotated")
+}
+
+func TestPreviewServer_Sources(t *testing.T) {
+	h, email := (&SimpleExample{new(themes.Default)}).getExample()
+	handler := preview.NewHandler(h, map[string]hermes.Email{"welcome": email})
+
+	assert.Contains(t, previewGet(handler, http.MethodGet, "/").Body.String(), `<a href="/?sources=true">sources</a>`)
+
+	html := previewGet(handler, http.MethodGet, "/emails/welcome/html").Body.String()
+	assert.NotContains(t, html, "data-source")
+	html = previewGet(handler, http.MethodGet, "/emails/welcome/html?sources=true").Body.String()
+	assert.Contains(t, html, `<span data-source="Body.Intros[0]">`)
+	assert.Contains(t, html, `<style data-hermes-preview="sources">`)
+	assert.Less(t, strings.Index(html, `data-hermes-preview`), strings.Index(html, "</head>"))
+
+	text := previewGet(handler, http.MethodGet, "/emails/welcome/txt?sources=true").Body.String()
+	assert.Contains(t, text, "[Body.Intros[0]] Welcome to Hermes!")
+	assert.Equal(t, http.StatusBadRequest, previewGet(handler, http.MethodGet, "/emails/welcome/txt?sources=maybe").Code)
+}
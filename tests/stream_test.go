@@ -0,0 +1,132 @@
+package hermes
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+func TestRenderStream(t *testing.T) {
+	h, email := (&SimpleExample{testedThemes[0]}).getExample()
+	emails := make(chan hermes.Email)
+	out := make(chan hermes.RenderResult)
+	go hermes.RenderStream(context.Background(), h, emails, out, 4)
+	go func() {
+		defer close(emails)
+		for i := 0; i < 20; i++ {
+			e := email
+			e.Body.Name = fmt.Sprintf("Jon %d", i)
+			emails <- e
+		}
+	}()
+
+	var indexes []int
+	for result := range out {
+		assert.Nil(t, result.Err)
+		assert.Contains(t, result.Output.HTML, fmt.Sprintf("Jon %d,", result.Index), "Results should be reassembled by index")
+		assert.Equal(t, len(result.Output.HTML), result.Stats.HTMLSize)
+		assert.Equal(t, testedThemes[0].Name(), result.Stats.Theme)
+		indexes = append(indexes, result.Index)
+	}
+	sort.Ints(indexes)
+	for i, index := range indexes {
+		assert.Equal(t, i, index)
+	}
+	assert.Len(t, indexes, 20)
+}
+
+func TestRenderStream_Errors(t *testing.T) {
+	h, email := (&SimpleExample{testedThemes[0]}).getExample()
+	h.StrictValidation = true
+	broken := email
+	broken.Body.Actions = []hermes.Action{{Button: hermes.Button{Text: "Confirm"}}}
+	emails := make(chan hermes.Email, 3)
+	emails <- email
+	emails <- broken
+	emails <- email
+	close(emails)
+	out := make(chan hermes.RenderResult)
+	go hermes.RenderStream(context.Background(), h, emails, out, 0)
+
+	failed := map[int]bool{}
+	for result := range out {
+		failed[result.Index] = result.Err != nil
+		if result.Err != nil {
+			assert.Empty(t, result.Stats.HTMLHash)
+		}
+	}
+	assert.Equal(t, map[int]bool{0: false, 1: true, 2: false}, failed, "A failing email should not stop the others")
+}
+
+func TestRenderStream_Cancel(t *testing.T) {
+	h, email := (&SimpleExample{testedThemes[0]}).getExample()
+	ctx, cancel := context.WithCancel(context.Background())
+	emails := make(chan hermes.Email)
+	out := make(chan hermes.RenderResult)
+	done := make(chan struct{})
+	go func() {
+		hermes.RenderStream(ctx, h, emails, out, 2)
+		close(done)
+	}()
+	go func() {
+		for {
+			select {
+			case emails <- email:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	<-out
+	cancel()
+	// Results not received are dropped: RenderStream returns without waiting for the consumer
+	<-done
+	for range out {
+	}
+}
+
+func TestRenderStream_BoundedMemory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("renders 10k emails")
+	}
+	const count = 10000
+	h, email := (&SimpleExample{testedThemes[0]}).getExample()
+	emails := make(chan hermes.Email)
+	out := make(chan hermes.RenderResult)
+	go func() {
+		defer close(emails)
+		for i := 0; i < count; i++ {
+			emails <- email
+		}
+	}()
+
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	baseline := stats.HeapAlloc
+	var peak, total uint64
+	received := 0
+	go hermes.RenderStream(context.Background(), h, emails, out, runtime.GOMAXPROCS(0))
+	for result := range out {
+		assert.Nil(t, result.Err)
+		total += uint64(result.Output.Size() + len(result.Output.PlainText))
+		received++
+		if received%250 == 0 {
+			runtime.ReadMemStats(&stats)
+			if stats.HeapAlloc > peak {
+				peak = stats.HeapAlloc
+			}
+		}
+	}
+	assert.Equal(t, count, received)
+	// Outputs are not buffered: the heap grows by far less than their total size
+	const ceiling = 64 << 20
+	assert.Greater(t, total, uint64(ceiling), "The outputs should not fit under the ceiling")
+	assert.Less(t, int64(peak)-int64(baseline), int64(ceiling), "Heap grew by %d bytes", int64(peak)-int64(baseline))
+}
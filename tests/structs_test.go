@@ -0,0 +1,135 @@
+package hermes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+type address struct {
+	Street string
+	City   string `hermes:"label=Town"`
+}
+
+type status int
+
+func (s status) String() string {
+	return [...]string{"Pending", "Shipped"}[s]
+}
+
+type order struct {
+	ID       string    `hermes:"label=Order ID"`
+	Total    float64   `hermes:"format=money,align=right,width=15%"`
+	Items    int       `hermes:"format=number"`
+	Coupon   string    `hermes:"omit_empty"`
+	Gift     bool      `hermes:"label=Gift wrap"`
+	Date     time.Time `hermes:"format=datetime"`
+	Shipped  *time.Time
+	Status   status
+	Shipping address
+	Note     *string `hermes:"omit_empty"`
+	Internal string  `hermes:"-"`
+	secret   string
+}
+
+func TestDictionaryFromStruct(t *testing.T) {
+	shipped := time.Date(2024, time.March, 2, 0, 0, 0, 0, time.UTC)
+	o := order{
+		ID:       "A-42",
+		Total:    1234.5,
+		Items:    12000,
+		Date:     time.Date(2024, time.March, 1, 14, 30, 0, 0, time.UTC),
+		Shipped:  &shipped,
+		Status:   1,
+		Shipping: address{Street: "221B Baker Street", City: "London"},
+		Internal: "hidden",
+		secret:   "hidden",
+	}
+
+	entries, err := hermes.DictionaryFromStruct(&o)
+	assert.Nil(t, err)
+	assert.Equal(t, []hermes.Entry{
+		{Key: "Order ID", Value: "A-42"},
+		{Key: "Total", Value: "1,234.50"},
+		{Key: "Items", Value: "12,000"},
+		{Key: "Gift wrap", Value: "No"},
+		{Key: "Date", Value: "March 1, 2024 14:30"},
+		{Key: "Shipped", Value: "March 2, 2024"},
+		{Key: "Status", Value: "Shipped"},
+		{Key: "Shipping Street", Value: "221B Baker Street"},
+		{Key: "Shipping Town", Value: "London"},
+	}, entries)
+}
+
+func TestDictionaryFromStruct_NilsAndSeparator(t *testing.T) {
+	type customer struct {
+		Name    string
+		Address *address
+		Since   *time.Time
+	}
+
+	entries, err := hermes.StructMapper{Separator: " / "}.Dictionary(customer{Name: "Jon"})
+	assert.Nil(t, err)
+	assert.Equal(t, []hermes.Entry{
+		{Key: "Name", Value: "Jon"},
+		{Key: "Address / Street", Value: ""},
+		{Key: "Address / Town", Value: ""},
+		{Key: "Since", Value: ""},
+	}, entries)
+}
+
+func TestDictionaryFromStruct_Errors(t *testing.T) {
+	_, err := hermes.DictionaryFromStruct("not a struct")
+	assert.EqualError(t, err, "expected a struct, got string")
+
+	type withSlice struct {
+		Customer struct {
+			Tags []string
+		}
+	}
+	_, err = hermes.DictionaryFromStruct(withSlice{})
+	assert.EqualError(t, err, "field Customer.Tags: unsupported type []string")
+
+	type badFormat struct {
+		Name string `hermes:"format=money"`
+	}
+	_, err = hermes.DictionaryFromStruct(badFormat{Name: "Jon"})
+	assert.EqualError(t, err, `field Name: format "money" needs a number, got string`)
+
+	type badOption struct {
+		Name string `hermes:"color=red"`
+	}
+	_, err = hermes.DictionaryFromStruct(badOption{})
+	assert.EqualError(t, err, `field Name: unknown tag option "color"`)
+}
+
+func TestTableFromSlice(t *testing.T) {
+	type item struct {
+		Item   string
+		Coupon string  `hermes:"omit_empty"`
+		Price  float64 `hermes:"format=money,align=right,width=15%"`
+	}
+
+	table, err := hermes.TableFromSlice([]*item{
+		{Item: "Golang", Price: 10.99},
+		nil,
+		{Item: "Hermes", Price: 1.99},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, hermes.Table{
+		Data: [][]hermes.Entry{
+			{{Key: "Item", Value: "Golang"}, {Key: "Price", Value: "10.99"}},
+			{{Key: "Item", Value: ""}, {Key: "Price", Value: ""}},
+			{{Key: "Item", Value: "Hermes"}, {Key: "Price", Value: "1.99"}},
+		},
+		Columns: hermes.Columns{
+			CustomWidth:     map[string]string{"Price": "15%"},
+			CustomAlignment: map[string]string{"Price": "right"},
+		},
+	}, table)
+
+	_, err = hermes.TableFromSlice(item{})
+	assert.EqualError(t, err, "expected a slice, got hermes.item")
+}
@@ -0,0 +1,169 @@
+package hermes
+
+import (
+	"bytes"
+	"net/mail"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/examples/mails"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/send"
+)
+
+func TestLintSubject(t *testing.T) {
+	tests := []struct {
+		subject   string
+		preheader string
+		codes     []hermes.HintCode // Without HintLength, always given
+	}{
+		{"Your order has shipped", "Arriving Thursday, track it from your dashboard", nil},
+		{"Reset your password", "", []hermes.HintCode{hermes.HintMissingPreheader}},
+		{"", "", []hermes.HintCode{hermes.HintEmptySubject}},
+		{"Welcome to Hermes, Jon!", "Three steps to set up your account", nil},
+		{"NASA and ESA partner on a new mission", "Read the announcement", nil},
+		{"FREE SHIPPING this weekend only", "On every order", []hermes.HintCode{hermes.HintAllCaps}},
+		{"Last chance!!!", "Sale ends at midnight", []hermes.HintCode{hermes.HintSpammyPunctuation}},
+		{"Win big! Claim now!", "Limited offer", []hermes.HintCode{hermes.HintSpammyPunctuation}},
+		{"Earn $$$ from home", "No experience needed", []hermes.HintCode{hermes.HintSpammyPunctuation}},
+		{"Ready for summer? 🌞🏖️🍹🕶️", "Our new collection is here", []hermes.HintCode{hermes.HintTooManyEmoji}},
+		{"Happy holidays 🎄 from the team 👨‍👩‍👧‍👦", "See you in the new year", nil},
+		{"Bon voyage 🇫🇷🇮🇹", "Your tickets are attached", nil},
+		{"Your monthly statement is ready", "Your monthly statement is ready to download", []hermes.HintCode{hermes.HintPreheaderRepeats}},
+		{"Your invoice #1042 from Hermes", "Invoice 1042", []hermes.HintCode{hermes.HintPreheaderRepeats}},
+		{
+			"Important information about changes to your account and our terms of service",
+			"Nothing to do on your side",
+			[]hermes.HintCode{hermes.HintTruncatedGmail, hermes.HintTruncatedIOS},
+		},
+		{"Your weekly digest: 5 new followers", "See who joined", nil},
+		{"Important changes to your subscription plan", "", []hermes.HintCode{hermes.HintTruncatedIOS, hermes.HintMissingPreheader}},
+		{"ご注文の商品を発送しました。お届け予定日をご確認ください", "追跡番号はこちら", []hermes.HintCode{hermes.HintTruncatedIOS}},
+		{"주문하신 상품이 발송되었습니다", "배송 조회", nil},
+		{
+			"Thanks for your order",
+			"Your order will be prepared by our team and shipped within two business days, we will send you the tracking number as soon as it leaves our warehouse",
+			[]hermes.HintCode{hermes.HintTruncatedGmail, hermes.HintTruncatedIOS},
+		},
+	}
+	for _, test := range tests {
+		var codes []hermes.HintCode
+		for _, hint := range hermes.LintSubject(test.subject, test.preheader) {
+			if hint.Code != hermes.HintLength {
+				codes = append(codes, hint.Code)
+			}
+		}
+		assert.Equal(t, test.codes, codes, "%q / %q", test.subject, test.preheader)
+	}
+}
+
+func TestLintSubject_Width(t *testing.T) {
+	tests := []struct {
+		subject string
+		message string
+	}{
+		{"Hello", "5 characters, width 5"},
+		{"Café", "4 characters, width 4"},
+		{"Café", "4 characters, width 4"},
+		{"注文", "2 characters, width 4"},
+		{"🎄 Sale", "6 characters, width 7"},
+		{"👨‍👩‍👧", "1 characters, width 2"},
+		{"🇫🇷", "1 characters, width 2"},
+		{"👍🏽", "1 characters, width 2"},
+	}
+	for _, test := range tests {
+		hints := hermes.LintSubject(test.subject, "Preheader")
+		assert.Equal(t, hermes.HintLength, hints[0].Code, test.subject)
+		assert.Equal(t, test.message, hints[0].Message, test.subject)
+	}
+}
+
+func TestLintSubject_Preview(t *testing.T) {
+	hints := hermes.LintSubject("Important information about changes to your account and our terms of service", "")
+	previews := map[hermes.HintCode]string{}
+	for _, hint := range hints {
+		previews[hint.Code] = hint.Preview
+	}
+	assert.Equal(t, "Important information about changes to your account and our terms of…", previews[hermes.HintTruncatedGmail])
+	assert.Equal(t, "Important information about changes to y…", previews[hermes.HintTruncatedIOS])
+
+	// Wide characters are never split
+	hints = hermes.LintSubject("ご注文の商品を発送しました。お届け予定日をご確認ください", "")
+	assert.Equal(t, hermes.HintTruncatedIOS, hints[1].Code)
+	assert.Equal(t, "ご注文の商品を発送しました。お届け予定日…", hints[1].Preview)
+	assert.Equal(t, hermes.SeverityInfo, hints[1].Severity)
+	assert.Equal(t, "Subject", hints[1].Path)
+}
+
+func TestSubject_Defaults(t *testing.T) {
+	email := new(mails.Welcome).Email()
+	assert.Equal(t, "Welcome to Hermes", send.BuildMessage(email, "<p>Hi</p>", "Hi").Subject)
+
+	h := hermes.Hermes{}
+	raw, err := h.GenerateEML(email, hermes.EMLOptions{From: "hello@hermes-example.com"})
+	assert.Nil(t, err)
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	assert.Nil(t, err)
+	assert.Equal(t, "Welcome to Hermes", msg.Header.Get("Subject"))
+
+	raw, err = h.GenerateEML(email, hermes.EMLOptions{From: "hello@hermes-example.com", Subject: "Hi"})
+	assert.Nil(t, err)
+	msg, err = mail.ReadMessage(bytes.NewReader(raw))
+	assert.Nil(t, err)
+	assert.Equal(t, "Hi", msg.Header.Get("Subject"))
+}
+
+func TestGenerateSubject(t *testing.T) {
+	receipt := hermes.Email{Body: hermes.Body{
+		Name:       "Jon Snow",
+		Dictionary: []hermes.Entry{{Key: "Date", Value: "2024-05-01"}, {Key: "Order", Value: "#1042"}},
+	}}
+	tests := []struct {
+		subject  string
+		title    string
+		expected string
+	}{
+		{"", "", ""},
+		{"Welcome to Hermes", "Welcome", "Welcome to Hermes"},
+		{"", "Welcome, Jon", "Welcome, Jon"},
+		{"{{ .Email.Body.Name }}, your receipt for order {{ .Email.Body.DictionaryValue \"Order\" }}", "", "Jon Snow, your receipt for order #1042"},
+		{`{{ (index .Email.Body.Dictionary 0).Value }} & {{ .Hermes.Brand.Name }}`, "", "2024-05-01 & Hermes"},
+		{"", "{{ .Email.Body.Name | upper }}", "JON SNOW"},
+		{"Order\r\nBcc: {{ .Email.Body.DictionaryValue \"Missing\" }}\n  shipped", "", "Order Bcc: shipped"},
+	}
+	for _, test := range tests {
+		h := hermes.Hermes{Brand: hermes.Branding{Name: "Hermes"}}
+		email := receipt
+		email.Subject, email.Body.Title = test.subject, test.title
+		subject, err := h.GenerateSubject(email)
+		assert.Nil(t, err, test.subject)
+		assert.Equal(t, test.expected, subject, test.subject)
+	}
+
+	h := hermes.Hermes{}
+	_, err := h.GenerateSubject(hermes.Email{Subject: "{{ .Email.Body.Name "})
+	assert.ErrorContains(t, err, "subject:")
+	_, err = h.GenerateSubject(hermes.Email{Subject: "{{ .Email.Unknown }}"})
+	assert.ErrorContains(t, err, "subject:")
+}
+
+func TestGenerateSubject_Messages(t *testing.T) {
+	h := hermes.Hermes{}
+	email := hermes.Email{Subject: "Welcome, {{ .Email.Body.Name }}", Body: hermes.Body{Name: "Jon"}}
+	raw, err := h.GenerateEML(email, hermes.EMLOptions{From: "hello@hermes-example.com"})
+	assert.Nil(t, err)
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	assert.Nil(t, err)
+	assert.Equal(t, "Welcome, Jon", msg.Header.Get("Subject"))
+
+	m, err := send.GenerateMessage(&h, email)
+	assert.Nil(t, err)
+	assert.Equal(t, "Welcome, Jon", m.Subject)
+	html, text, err := h.Generate(email)
+	assert.Nil(t, err)
+	assert.Equal(t, html, m.HTML)
+	assert.Equal(t, text, m.Text)
+
+	email = hermes.Email{Body: hermes.Body{Title: "Your order has shipped"}}
+	assert.Equal(t, "Your order has shipped", send.BuildMessage(email, "<p>Hi</p>", "Hi").Subject)
+}
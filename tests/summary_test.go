@@ -0,0 +1,89 @@
+package hermes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/examples/mails"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+func TestSummary_Golden(t *testing.T) {
+	email := new(mails.Digest).Email()
+	for _, theme := range []hermes.Theme{new(themes.Default), new(themes.Corporate)} {
+		h := hermes.Hermes{
+			Theme: theme,
+			Brand: hermes.Branding{Name: "Hermes", Link: "https://example-hermes.com/", Copyright: "Copyright © Hermes-Test"},
+		}
+		html, text, err := h.Generate(email)
+		assert.Nil(t, err)
+		assertGolden(t, "summary/"+theme.Name()+".html", html)
+		assertGolden(t, "summary/"+theme.Name()+".txt", text)
+	}
+}
+
+func TestSummary_Delta(t *testing.T) {
+	tests := []struct {
+		name    string
+		summary *hermes.Summary
+		trend   string
+		tone    string
+		delta   string
+	}{
+		{"Increase", new(hermes.Summary).Add("Members", 5).Compare(4), hermes.TrendUp, "good", "▲ 1 (+25%)"},
+		{"Decrease", new(hermes.Summary).Add("Members", 1000).Compare(3000), hermes.TrendDown, "bad", "▼ 2,000 (-67%)"},
+		{"Lower is better", new(hermes.Summary).Add("Incidents", 1).Compare(2).LowerIsBetter(), hermes.TrendDown, "good", "▼ 1 (-50%)"},
+		{"No change", new(hermes.Summary).Add("Tickets", 12).Compare(12), hermes.TrendFlat, "neutral", ""},
+		{"No previous value", new(hermes.Summary).Add("Tickets", 12), "", "neutral", ""},
+		{"Previous value of zero", new(hermes.Summary).Add("Incidents", 3).Compare(0), hermes.TrendUp, "good", "▲ 3"},
+		{"Negative previous value", new(hermes.Summary).Add("Balance", 50).Compare(-100), hermes.TrendUp, "good", "▲ 150 (+150%)"},
+		{"Small decrease", new(hermes.Summary).Add("Members", 9999).Compare(10000), hermes.TrendDown, "bad", "▼ 1"},
+		{"Money", new(hermes.Summary).Add("Revenue", 1250.5).Compare(1000).Format(hermes.FormatMoney), hermes.TrendUp, "good", "▲ 250.50 (+25%)"},
+	}
+	for _, test := range tests {
+		section := test.summary.Sections[0]
+		assert.Equal(t, test.trend, section.Trend(), test.name)
+		assert.Equal(t, test.tone, section.Tone(), test.name)
+		assert.Equal(t, test.delta, section.Delta(), test.name)
+	}
+	assert.Equal(t, "1,250.50", tests[8].summary.Sections[0].FormattedValue())
+}
+
+func TestSummary_Builder(t *testing.T) {
+	// Calls before the first metric are ignored
+	summary := new(hermes.Summary).Compare(1).Highlight("Ignored").LowerIsBetter()
+	assert.Empty(t, summary.Sections)
+
+	summary.Add("A", 1).Highlight("a1").Highlight("a2").Add("B", 2).Add("C", 3).Compare(2)
+	assert.Equal(t, []string{"a1", "a2"}, summary.Sections[0].Highlights)
+	assert.Nil(t, summary.Sections[1].Previous)
+	assert.Equal(t, 2.0, *summary.Sections[2].Previous)
+
+	rows := summary.Rows()
+	assert.Len(t, rows, 2)
+	assert.Len(t, rows[0], 2)
+	assert.Equal(t, "C", rows[1][0].Label)
+}
+
+func TestSummary_Render(t *testing.T) {
+	h, email := (&SimpleExample{}).getExample()
+	email.Body.Summary = new(hermes.Summary).
+		Add("Open tickets", 4).Compare(4).
+		Add("Incidents", 2).Compare(1).LowerIsBetter()
+	h.Locale = "es-AR"
+
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, r, `<p class="summary-delta summary-delta--neutral">Sin cambios</p>`)
+	assert.Contains(t, r, `<p class="summary-delta summary-delta--bad">▲ 1 (&#43;100%)</p>`)
+
+	text, err := h.GeneratePlainText(email)
+	assert.Nil(t, err)
+	assert.Contains(t, text, "\nOpen tickets: 4 – Sin cambios\n")
+	assert.Contains(t, text, "\nIncidents: 2 – ▲ 1 (+100%)\n")
+
+	_, warnings, err := hermes.ParseEmail(r)
+	assert.Nil(t, err)
+	assert.Contains(t, warnings, hermes.ParseWarning{Path: warnings[0].Path, Message: "summary is ignored, its values are formatted"})
+}
@@ -0,0 +1,139 @@
+package hermes
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+const svgIcon = `<svg xmlns="http://www.w3.org/2000/svg" width="24" height="24"><defs><linearGradient id="g"/></defs><rect fill="url(#g)" width="24" height="24"/></svg>`
+
+func svgDataURI(svg string) string {
+	return "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString([]byte(svg))
+}
+
+// fakeRasterizer returns the dimensions of the image followed by the SVG as PNG
+type fakeRasterizer struct{ err error }
+
+func (r fakeRasterizer) Rasterize(svg []byte, width, height int) ([]byte, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return append([]byte{byte(width), byte(height)}, svg...), nil
+}
+
+func TestValidateSVG(t *testing.T) {
+	assert.Nil(t, hermes.ValidateSVG([]byte(svgIcon)))
+	assert.Nil(t, hermes.ValidateSVG([]byte(`<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink"><use xlink:href="#icon"/><image href="data:image/png;base64,AAAA"/></svg>`)))
+
+	for svg, err := range map[string]string{
+		`<svg><script>alert(1)</script></svg>`:                                                  "svg: <script> element",
+		`<svg><foreignObject><div/></foreignObject></svg>`:                                      "svg: <foreignObject> element",
+		`<svg onload="alert(1)"/>`:                                                              "svg: onload event handler",
+		`<svg><image href="https://tracker.com/pixel.png"/></svg>`:                              `svg: external reference "https://tracker.com/pixel.png"`,
+		`<svg xmlns:xlink="http://www.w3.org/1999/xlink"><use xlink:href="icons.svg#a"/></svg>`: `svg: external reference "icons.svg#a"`,
+		`<svg><image href="data:image/svg+xml;base64,AAAA"/></svg>`:                             `svg: external reference "data:image/svg+xml;base64,AAAA"`,
+		`<svg><style>@import "https://fonts.com/a.css";</style></svg>`:                          "svg: style with @import",
+		`<svg><rect style="fill: url('https://tracker.com/a')"/></svg>`:                         `svg: external reference "https://tracker.com/a"`,
+		`<?xml-stylesheet href="https://a.com/a.css"?><svg/>`:                                   "svg: external style sheet",
+		`<!DOCTYPE svg [<!ENTITY a "aaaa">]><svg>&a;</svg>`:                                     "svg: entity declaration",
+	} {
+		assert.EqualError(t, hermes.ValidateSVG([]byte(svg)), err, svg)
+	}
+	assert.NotNil(t, hermes.ValidateSVG([]byte(`<svg><rect></svg`)), "Malformed SVGs should be rejected")
+}
+
+func TestSVG_Fallback(t *testing.T) {
+	h := hermes.Hermes{
+		Theme: new(themes.Default),
+		Brand: hermes.Branding{
+			Name: "Hermes",
+			LogoImage: hermes.Logo{
+				URL:             "https://example-hermes.com/logo.svg",
+				FallbackPNG:     "https://example-hermes.com/logo.png",
+				DarkURL:         "https://example-hermes.com/logo-dark.svg",
+				DarkFallbackPNG: "https://example-hermes.com/logo-dark.png",
+			},
+			SocialLinks: []hermes.SocialLink{
+				{Name: "GitHub", URL: "https://github.com/hermes", IconURL: "https://example-hermes.com/github.svg", IconFallbackPNG: "https://example-hermes.com/github.png"},
+				{Name: "X", URL: "https://x.com/hermes", IconURL: "https://example-hermes.com/x.png"},
+			},
+		},
+	}
+	email := hermes.Email{Body: hermes.Body{Name: "Jon Snow"}}
+
+	html, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, html, `src="https://example-hermes.com/logo.png"`, "Clients not rendering SVG should get the fallback")
+	assert.Contains(t, html, `src="https://example-hermes.com/logo-dark.png"`)
+	assert.Contains(t, html, `src="https://example-hermes.com/github.png"`)
+	assert.Contains(t, html, `src="https://example-hermes.com/x.png"`)
+	assert.NotContains(t, html, ".svg")
+	assert.Equal(t, "https://example-hermes.com/github.svg", h.Brand.SocialLinks[0].IconURL, "The brand of the engine should not be changed")
+
+	h.ClientProfile = []string{hermes.ClientAppleMail}
+	html, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, html, `src="https://example-hermes.com/logo.svg"`, "SVG should be kept when every client renders it")
+	assert.Contains(t, html, `src="https://example-hermes.com/github.svg"`)
+	assert.NotContains(t, html, "logo.png")
+
+	h.ClientProfile = []string{hermes.ClientAppleMail, hermes.ClientGmail}
+	html, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, html, `src="https://example-hermes.com/logo.png"`)
+}
+
+func TestSVG_DataURI(t *testing.T) {
+	logo := svgDataURI(svgIcon)
+	h := hermes.Hermes{
+		Theme:         new(themes.Flat),
+		ClientProfile: []string{hermes.ClientAppleMail},
+		Brand:         hermes.Branding{Name: "Hermes", LogoImage: hermes.Logo{URL: logo, FallbackPNG: "https://example-hermes.com/logo.png"}},
+	}
+	email := hermes.Email{Body: hermes.Body{Name: "Jon Snow"}}
+	html, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, html, `src="https://example-hermes.com/logo.png"`, "SVG data: URIs should never be written")
+
+	h.Brand.LogoImage = hermes.Logo{URL: logo, Width: 120, Height: 40}
+	h.Rasterizer = fakeRasterizer{}
+	html, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+	png := base64.StdEncoding.EncodeToString(append([]byte{120, 40}, svgIcon...))
+	assert.Contains(t, html, `src="data:image/png;base64,`+png+`"`)
+
+	h.Brand.LogoImage.URL = "data:image/svg+xml," + url.PathEscape(svgIcon)
+	html, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, html, `src="data:image/png;base64,`+png+`"`, "Percent-encoded SVGs should be rasterized")
+
+	h.Rasterizer = fakeRasterizer{err: errors.New("no renderer")}
+	_, err = h.GenerateHTML(email)
+	assert.EqualError(t, err, "Brand.LogoImage.URL: rasterize: no renderer")
+
+	h.Rasterizer = fakeRasterizer{}
+	h.Brand.LogoImage = hermes.Logo{}
+	h.Brand.SocialLinks = []hermes.SocialLink{{Name: "GitHub", URL: "https://github.com/hermes", IconURL: svgDataURI(`<svg onload="alert(1)"/>`)}}
+	_, err = h.GenerateHTML(email)
+	assert.EqualError(t, err, "Brand.SocialLinks[0].IconURL: svg: onload event handler", "Unsafe SVGs should not be rasterized")
+}
+
+func TestSVG_Validate(t *testing.T) {
+	b := hermes.Branding{
+		Name:        "Hermes",
+		Logo:        svgDataURI(svgIcon),
+		SocialLinks: []hermes.SocialLink{{Name: "GitHub", URL: "https://github.com/hermes", IconURL: svgDataURI(`<svg><script/></svg>`)}},
+	}
+	issues, ok := b.Validate().(hermes.Issues)
+	assert.True(t, ok)
+	assert.Len(t, issues, 1)
+	assert.Equal(t, string(hermes.CodeUnsafeSVG), issues[0].Code)
+	assert.Equal(t, "Brand.SocialLinks[0].IconURL", issues[0].Path)
+	assert.Equal(t, "svg: <script> element", issues[0].Message)
+}
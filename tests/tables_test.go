@@ -0,0 +1,168 @@
+package hermes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/examples/mails"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+func tablesExample() (hermes.Hermes, hermes.Email) {
+	h, email := (&SimpleExample{}).getExample()
+	email.Body.Table.Title = "Items"
+	email.Body.Tables = []hermes.Table{
+		{
+			Title: "Taxes & Fees",
+			Data: [][]hermes.Entry{
+				{{Key: "Tax", Value: "VAT"}, {Key: "Amount", Value: "$2.20"}},
+				{{Key: "Tax", Value: "Shipping"}, {Key: "Amount", Value: "$4.00"}},
+			},
+			Columns: hermes.Columns{
+				CustomWidth:     map[string]string{"Tax": "60%"},
+				CustomAlignment: map[string]string{"Amount": "right"},
+			},
+		},
+		{
+			Data: [][]hermes.Entry{
+				{{Key: "Total", Value: "$19.18"}},
+			},
+		},
+	}
+	return h, email
+}
+
+func TestTables_HTML(t *testing.T) {
+	h, email := tablesExample()
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+
+	items := strings.Index(r, `<h3 class="data-title" id="items">Items</h3>`)
+	taxes := strings.Index(r, `<h3 class="data-title" id="taxes-fees">Taxes &amp; Fees</h3>`)
+	total := strings.Index(r, "$19.18")
+	assert.True(t, items > 0 && taxes > items && total > taxes, "Table should be displayed first, then Tables in order")
+	assert.Equal(t, 3, strings.Count(r, `<table class="data-table"`))
+	assert.Equal(t, 2, strings.Count(r, `class="data-title"`), "Tables without title should have no title")
+	assert.Contains(t, r, `width="60%"`)
+	assert.Contains(t, r, `width="20%"`)
+}
+
+func TestTables_PlainText(t *testing.T) {
+	h, email := tablesExample()
+	r, err := h.GeneratePlainText(email)
+	assert.Nil(t, err)
+	assert.Contains(t, r, "Taxes & Fees")
+	assert.Contains(t, r, `Taxes & Fees
+Tax       Amount
+---       ------
+VAT       $2.20
+Shipping  $4.00`)
+	assert.Contains(t, r, `Total
+-----
+$19.18`)
+	assert.Less(t, strings.Index(r, "Items"), strings.Index(r, "Golang  "))
+}
+
+func TestTables_OnlyTables(t *testing.T) {
+	h, email := tablesExample()
+	email.Body.Table = hermes.Table{}
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, strings.Count(r, `<table class="data-table"`))
+	assert.NotContains(t, r, "Golang")
+}
+
+func TestTables_Parse(t *testing.T) {
+	h, email := tablesExample()
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+
+	parsed, _, err := hermes.ParseEmail(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "Items", parsed.Body.Table.Title)
+	assert.Equal(t, email.Body.Table.Data, parsed.Body.Table.Data)
+	assert.Equal(t, email.Body.Tables, parsed.Body.Tables)
+}
+
+func TestTables_ValidateAndSanitize(t *testing.T) {
+	h, email := tablesExample()
+	email.Body.Tables[1].Data[0][0].HTMLValue = `<b>$19.18</b><script>alert(1)</script>`
+	assert.EqualError(t, email.Validate(), "Body.Tables[1].Data[0][0]: sets both Value and HTMLValue")
+
+	email.Body.Tables[1].Data[0][0].Value = ""
+	r, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, r, "<b>$19.18</b>")
+	assert.NotContains(t, r, "alert(1)")
+}
+
+// receiptWithTotals is the receipt example with a discount row and a footer of totals
+func receiptWithTotals() hermes.Email {
+	email := new(mails.Receipt).Email()
+	table := &email.Body.Table
+	table.Data = append(table.Data, []hermes.Entry{
+		{Key: "Item", Value: "Discount"},
+		{Key: "Description", Value: "Welcome offer"},
+		{Key: "Price", Value: "-$2.00"},
+	})
+	table.Rows = []hermes.RowOptions{2: {Color: "#1A7F45", Bold: true}}
+	table.Footer = []hermes.Entry{{Key: "Item", Value: "Total"}, {Key: "Price", Value: "$10.98"}}
+	return email
+}
+
+func TestTables_Footer_Golden(t *testing.T) {
+	h := hermes.Hermes{
+		Theme: new(themes.Default),
+		Brand: hermes.Branding{Name: "Hermes", Link: "https://example-hermes.com/", Copyright: "Copyright © Hermes-Test"},
+	}
+	html, text, err := h.Generate(receiptWithTotals())
+	assert.Nil(t, err)
+	assertGolden(t, "tables/footer.html", html)
+	assertGolden(t, "tables/footer.txt", text)
+
+	footer := html[strings.Index(html, "<tfoot>"):strings.Index(html, "</tfoot>")]
+	assert.Contains(t, footer, "font-weight:bold")
+	assert.Contains(t, footer, "border-top:2px solid #EDEFF2")
+	assert.Contains(t, footer, "Total")
+	assert.Contains(t, footer, "$10.98")
+	assert.Equal(t, 3, strings.Count(footer, "<td"), "Columns without total have an empty cell")
+	assert.Contains(t, html, "color:#1A7F45;font-weight:bold", "Highlighted row")
+
+	// The footer follows a separator line in plain text
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "Total ") {
+			assert.True(t, strings.HasPrefix(lines[i-1], "----  "), "Separator before the totals: %q", lines[i-1])
+			assert.Contains(t, line, "$10.98")
+		}
+	}
+	assert.Regexp(t, `(?m)^Total +\$10\.98$`, text)
+}
+
+func TestTables_Footer_Empty(t *testing.T) {
+	h := hermes.Hermes{Theme: new(themes.Default)}
+	email := new(mails.Receipt).Email()
+	html, text, err := h.Generate(email)
+	assert.Nil(t, err)
+	assert.NotContains(t, html, "tfoot")
+	assert.NotContains(t, text, "Total")
+
+	email.Body.Table.Footer = []hermes.Entry{}
+	email.Body.Table.Rows = []hermes.RowOptions{}
+	again, againText, err := h.Generate(email)
+	assert.Nil(t, err)
+	assert.Equal(t, html, again)
+	assert.Equal(t, text, againText)
+}
+
+func TestTables_RowStyle(t *testing.T) {
+	table := hermes.Table{Rows: []hermes.RowOptions{
+		{Color: "green", Background: "rgb(242, 244, 246)"},
+		{Color: "red; position: fixed", Background: "url(https://hermes-example.com/x.png)", Bold: true},
+	}}
+	assert.Equal(t, "color:green;background-color:rgb(242, 244, 246)", string(table.RowStyle(0)))
+	assert.Equal(t, "font-weight:bold", string(table.RowStyle(1)), "Values other than colors are ignored")
+	assert.Equal(t, "", string(table.RowStyle(2)))
+}
@@ -0,0 +1,125 @@
+package hermes
+
+import (
+	"html/template"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/examples/mails"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"golang.org/x/net/html"
+)
+
+// The escaping of html/template changes across Go releases, so these tests check properties of the output that must
+// hold with all supported releases rather than its bytes, which the golden files compare
+
+// compatLink has a query with several parameters and an encoded path segment, which escaping must keep as is
+const compatLink = "https://hermes-example.com/confirm?token=d9729feb&utm_source=email&next=%2Fhome#top"
+
+// attributeValues returns the values of the attribute in the HTML, unescaped, by element
+func attributeValues(t *testing.T, doc string, key string) map[string][]string {
+	root, err := html.Parse(strings.NewReader(doc))
+	assert.Nil(t, err)
+	values := map[string][]string{}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for _, a := range n.Attr {
+			if a.Key == key {
+				values[n.Data] = append(values[n.Data], a.Val)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+	return values
+}
+
+func TestTemplateCompat_Links(t *testing.T) {
+	t.Logf("html/template of %s, supported from %s", runtime.Version(), supportedGoVersion)
+	for _, theme := range testedThemes {
+		for _, example := range mails.All() {
+			name := theme.Name() + "/" + example.Name()
+			h := hermes.Hermes{
+				Theme: theme,
+				Brand: hermes.Branding{Name: "Hermes", Link: compatLink, Logo: "https://hermes-example.com/logo.png?v=2&size=large"},
+			}
+			email := example.Email()
+			email.Body.Actions = append(email.Body.Actions, hermes.Action{
+				Instructions: "Confirm your account:",
+				Button:       hermes.Button{Text: "Confirm", Link: compatLink},
+			})
+			email.Body.ContactInstructions = &hermes.ContactInstructions{Email: "support+emails@hermes-example.com", URL: "tel:+15555550100"}
+			out, err := h.GenerateHTML(email)
+			assert.Nil(t, err, name)
+
+			links := map[string]bool{}
+			for _, href := range attributeValues(t, out, "href")["a"] {
+				links[href] = true
+			}
+			var params []string
+			for key, value := range email.Params {
+				params = append(params, "{"+key+"}", value)
+			}
+			for _, action := range email.Body.Actions {
+				if link := strings.NewReplacer(params...).Replace(action.Button.Link); link != "" {
+					assert.True(t, links[link], "%s: link %s should be kept as is", name, link)
+				}
+			}
+			if email.Body.FreeMarkdown == "" {
+				assert.True(t, links["mailto:support+emails@hermes-example.com"], name)
+				assert.True(t, links["tel:+15555550100"], "%s: tel: links should be kept", name)
+			}
+			assert.Contains(t, attributeValues(t, out, "src")["img"], h.Brand.Logo, name)
+			assert.NotContains(t, out, "&amp;amp;", "%s: ampersands should not be escaped twice", name)
+			assert.NotContains(t, out, "ZgotmplZ", name)
+		}
+	}
+}
+
+func TestTemplateCompat_UnsafeLinks(t *testing.T) {
+	for _, theme := range testedThemes {
+		h, email := (&SimpleExample{theme}).getExample()
+		h.Brand.Link = "JavaScript:alert(1)"
+		email.Body.Actions[0].Button.Link = "java\tscript:alert(1)"
+		email.Body.ContactInstructions = &hermes.ContactInstructions{URL: "data:text/html;base64,PHNjcmlwdD4="}
+		out, err := h.GenerateHTML(email)
+		assert.Nil(t, err, theme.Name())
+		for _, href := range attributeValues(t, out, "href")["a"] {
+			assert.False(t, strings.Contains(strings.ToLower(href), "script") || strings.HasPrefix(href, "data:"), "%s: %s", theme.Name(), href)
+		}
+		assert.Contains(t, out, `href="#ZgotmplZ"`, theme.Name())
+	}
+
+	h, email := (&SimpleExample{testedThemes[0]}).getExample()
+	h.Brand.Logo = "data:image/png;base64,iVBORw0KGgo="
+	out, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, attributeValues(t, out, "src")["img"], h.Brand.Logo, "Embedded images should be kept")
+	h.Brand.Logo = "data:image/svg+xml;base64,PHN2Zz4="
+	out, err = h.GenerateHTML(email)
+	assert.Nil(t, err)
+	assert.Contains(t, attributeValues(t, out, "src")["img"], "#ZgotmplZ", "SVG can run scripts")
+}
+
+func TestTemplateCompat_Srcset(t *testing.T) {
+	const srcset = "https://cdn.hermes-example.com/mug.png?w=100&q=80 1x, https://cdn.hermes-example.com/mug.png?w=200&q=80 2x"
+	for _, theme := range testedThemes {
+		for _, inline := range []bool{true, false} {
+			h, email := (&SimpleExample{theme}).getExample()
+			h.DisableCSSInlining = !inline
+			h.Sanitizer = trustedHTML{}
+			email.Body.Dictionary = []hermes.Entry{{
+				Key:       "Your mug",
+				HTMLValue: template.HTML(`<img src="https://cdn.hermes-example.com/mug.png" srcset="` + html.EscapeString(srcset) + `" alt="Blue mug">`),
+			}}
+			out, err := h.GenerateHTML(email)
+			assert.Nil(t, err, theme.Name())
+			assert.Equal(t, []string{srcset}, attributeValues(t, out, "srcset")["img"], "%s: srcset should be kept as is", theme.Name())
+			assert.NotContains(t, out, "&amp;amp;", theme.Name())
+		}
+	}
+}
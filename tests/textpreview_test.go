@@ -0,0 +1,77 @@
+package hermes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+func TestPreviewPlainText(t *testing.T) {
+	text := "Hi Jon,  \n" +
+		"Total:\u00a010\u202f€\n" +
+		"\u200fשלום\u200e ok\u2068x\u2069\n" +
+		"a\tb\n" +
+		strings.Repeat("x", 12) + "\n"
+	p := hermes.PreviewPlainText(text, 10)
+
+	assert.Equal(t, 10, p.Width)
+	assert.Equal(t, []hermes.PreviewLine{
+		{Number: 1, Text: "Hi Jon,··", Columns: 9, Invisible: 2, Change: hermes.LineKept},
+		{Number: 2, Text: "Total:⍽10⍽€", Columns: 11, Overflow: true, Wrap: len("Total:⍽10⍽"), Invisible: 2, Change: hermes.LineKept},
+		{Number: 3, Text: "◂שלום▸ ok¦x¦", Columns: 8, Invisible: 4, Change: hermes.LineKept},
+		{Number: 4, Text: "a→b", Columns: 9, Invisible: 1, Change: hermes.LineKept},
+		{Number: 5, Text: strings.Repeat("x", 12), Columns: 12, Overflow: true, Wrap: 10, Change: hermes.LineKept},
+	}, p.Lines)
+	assert.Equal(t, 2, p.Overflows())
+
+	assert.Equal(t, hermes.DefaultPreviewWidth, hermes.PreviewPlainText("", 0).Width)
+	assert.Empty(t, hermes.PreviewPlainText("", 0).Lines)
+}
+
+func TestPreviewPlainText_Email(t *testing.T) {
+	h, email := (&SimpleExample{testedThemes[0]}).getExample()
+	text, err := h.GeneratePlainText(email)
+	assert.Nil(t, err)
+	p := hermes.PreviewPlainText(text, hermes.DefaultPreviewWidth)
+	assert.Len(t, p.Lines, strings.Count(strings.TrimSuffix(text, "\n"), "\n")+1)
+
+	email.Body.Intros = append(email.Body.Intros, strings.Repeat("Hermes ", 20))
+	text, err = h.GeneratePlainText(email)
+	assert.Nil(t, err)
+	assert.Equal(t, p.Overflows()+1, hermes.PreviewPlainText(text, hermes.DefaultPreviewWidth).Overflows(), "Plain text is not wrapped")
+}
+
+func TestDiffPlainText(t *testing.T) {
+	p := hermes.DiffPlainText("Hi Jon,\nWelcome!\nBye\n", "Hi Arya,\nWelcome!\nSee you\nBye\n", 72)
+	var changes []string
+	for _, line := range p.Lines {
+		changes = append(changes, string(line.Change)+line.Text)
+	}
+	assert.Equal(t, []string{"-Hi Jon,", "+Hi Arya,", " Welcome!", "+See you", " Bye"}, changes)
+	assert.Equal(t, 1, p.Lines[0].Number, "Removed lines are numbered in the previous text")
+	assert.Equal(t, 3, p.Lines[3].Number)
+
+	p = hermes.DiffPlainText(strings.Repeat("x", 80), "", 72)
+	assert.Equal(t, hermes.LineRemoved, p.Lines[0].Change)
+	assert.Equal(t, 0, p.Overflows(), "Removed lines do not overflow the new text")
+}
+
+func TestTextPreview_Write(t *testing.T) {
+	p := hermes.DiffPlainText("Hello\n", "Hello \nHello, world!\n", 12)
+
+	var plain strings.Builder
+	assert.Nil(t, p.Write(&plain, false))
+	assert.Equal(t, "         ----+----1--\n"+
+		"-    1 | Hello\n"+
+		"+    1 | Hello·\n"+
+		"+!   2 | Hello, world!\n", plain.String())
+	assert.NotContains(t, plain.String(), "\x1b", "Output without color should be plain text")
+
+	var colored strings.Builder
+	assert.Nil(t, p.Write(&colored, true))
+	assert.Contains(t, colored.String(), "\x1b[2m----+----1--\x1b[0m")
+	assert.Contains(t, colored.String(), "\x1b[32m+    1 | \x1b[0mHello\x1b[36m·\x1b[0m\n")
+	assert.Contains(t, colored.String(), "Hello, world\x1b[41;97m!\x1b[0m\n", "Columns beyond the width should be highlighted")
+}
@@ -0,0 +1,96 @@
+package hermes
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+func tocExample() hermes.Email {
+	rows := [][]hermes.Entry{{{Key: "Item", Value: "Golang"}}}
+	return hermes.Email{Body: hermes.Body{
+		Name:            "Jon Snow",
+		Intros:          []string{"Here is your monthly report."},
+		TableOfContents: true,
+		Dictionary:      []hermes.Entry{{Key: "Period", Value: "May 2024"}},
+		Summary: &hermes.Summary{Sections: []hermes.SummarySection{
+			{Label: "Tickets", Value: 12},
+			{Label: "Résumé du mois", Value: 3, Highlights: []string{"Two new members"}},
+		}},
+		Table: hermes.Table{Title: "Details", Data: rows},
+		Tables: []hermes.Table{
+			{Title: "Orders & refunds", Data: rows},
+			{Title: "Orders & refunds", Data: rows},
+			{Title: "Not rendered"},
+			{Data: rows},
+			{Title: "注文", ShowEmpty: true},
+			{Title: "!!!", Data: rows},
+		},
+	}}
+}
+
+func TestBody_Anchors(t *testing.T) {
+	body := tocExample().Body
+	expected := []hermes.Anchor{
+		{ID: "details", Section: hermes.AnchorDictionary},
+		{ID: "résumé-du-mois", Title: "Résumé du mois", Section: hermes.AnchorHighlights, Index: 1},
+		{ID: "details-2", Title: "Details", Section: hermes.AnchorTable},
+		{ID: "orders-refunds", Title: "Orders & refunds", Section: hermes.AnchorTable, Index: 1},
+		{ID: "orders-refunds-2", Title: "Orders & refunds", Section: hermes.AnchorTable, Index: 2},
+		{ID: "注文", Title: "注文", Section: hermes.AnchorTable, Index: 5},
+		{ID: "section", Title: "!!!", Section: hermes.AnchorTable, Index: 6},
+	}
+	assert.Equal(t, expected, body.Anchors())
+	assert.Equal(t, expected, body.Anchors(), "Anchors should be deterministic")
+	assert.Equal(t, "orders-refunds-2", body.AnchorID(hermes.AnchorTable, 2))
+	assert.Equal(t, "", body.AnchorID(hermes.AnchorTable, 3), "Tables which are not rendered have no anchor")
+
+	// Ids do not depend on the titles after them
+	body.Tables = append([]hermes.Table{{Title: "Orders-refunds-2", Data: body.Table.Data}}, body.Tables...)
+	assert.Equal(t, []string{"orders-refunds-2", "orders-refunds", "orders-refunds-3"}, []string{
+		body.AnchorID(hermes.AnchorTable, 1), body.AnchorID(hermes.AnchorTable, 2), body.AnchorID(hermes.AnchorTable, 3),
+	})
+
+	body.FreeMarkdown = "# Report"
+	assert.Empty(t, body.Anchors(), "Free markdown replaces the sections")
+}
+
+var tocHref = regexp.MustCompile(`<a href="#([^"]*)"`)
+
+func TestTableOfContents_Themes(t *testing.T) {
+	for _, theme := range testedThemes {
+		for _, minify := range []bool{false, true} {
+			h := hermes.Hermes{Theme: theme, MinifyHTML: minify}
+			html, err := h.GenerateHTML(tocExample())
+			assert.Nil(t, err)
+			assert.Contains(t, html, `data-hermes="toc"`, theme.Name())
+			hrefs := tocHref.FindAllStringSubmatch(html, -1)
+			assert.Len(t, hrefs, 7, theme.Name())
+			for _, href := range hrefs {
+				id, err := url.PathUnescape(href[1])
+				assert.Nil(t, err)
+				assert.Regexp(t, `id="?`+regexp.QuoteMeta(id)+`[" >]`, html, "%s: the link to %s should survive the pipeline", theme.Name(), id)
+			}
+			assert.Less(t, strings.Index(html, "monthly report"), strings.Index(html, `data-hermes="toc"`), "The table of contents follows the intros")
+		}
+
+		email := tocExample()
+		email.Body.TableOfContents = false
+		html, err := (&hermes.Hermes{Theme: theme}).GenerateHTML(email)
+		assert.Nil(t, err)
+		assert.NotContains(t, html, `data-hermes="toc"`, theme.Name())
+		assert.Contains(t, html, `id="orders-refunds-2"`, "Sections have ids without table of contents")
+	}
+}
+
+func TestTableOfContents_PlainText(t *testing.T) {
+	h := hermes.Hermes{Locale: "fr"}
+	text, err := h.GeneratePlainText(tocExample())
+	assert.Nil(t, err)
+	assert.Contains(t, text, "1. Détails\n2. Résumé du mois\n3. Details\n4. Orders & refunds\n5. Orders & refunds\n6. 注文\n7. !!!")
+	assert.NotContains(t, text, "#")
+}
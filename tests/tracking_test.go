@@ -0,0 +1,119 @@
+package hermes
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/themes"
+)
+
+var utm = map[string]string{"utm_source": "hermes", "utm_medium": "email"}
+
+func TestAddQueryParams(t *testing.T) {
+	for link, expected := range map[string]string{
+		"https://hermes.com":                             "https://hermes.com?utm_medium=email&utm_source=hermes",
+		"https://hermes.com/a?b=1":                       "https://hermes.com/a?b=1&utm_medium=email&utm_source=hermes",
+		"https://hermes.com/a?b=1&":                      "https://hermes.com/a?b=1&utm_medium=email&utm_source=hermes",
+		"https://hermes.com/a#top":                       "https://hermes.com/a?utm_medium=email&utm_source=hermes#top",
+		"https://hermes.com/a?b=1#section?c=2":           "https://hermes.com/a?b=1&utm_medium=email&utm_source=hermes#section?c=2",
+		"https://hermes.com/a?utm_source=blog&b=%20x":    "https://hermes.com/a?utm_source=blog&b=%20x&utm_medium=email",
+		"https://hermes.com/a?utm_source=a&utm_medium=b": "https://hermes.com/a?utm_source=a&utm_medium=b",
+	} {
+		assert.Equal(t, expected, hermes.AddQueryParams(link, utm), link)
+	}
+	assert.Equal(t, "https://hermes.com?q=a+%26+b", hermes.AddQueryParams("https://hermes.com", map[string]string{"q": "a & b"}))
+}
+
+func trackingExample() (hermes.Hermes, hermes.Email) {
+	h := hermes.Hermes{
+		Theme:          new(themes.Default),
+		TrackingParams: utm,
+		Brand: hermes.Branding{
+			Name:        "Hermes",
+			Link:        "https://example-hermes.com/",
+			SocialLinks: []hermes.SocialLink{{Name: "GitHub", URL: "https://github.com/hermes?tab=repositories"}},
+		},
+	}
+	email := hermes.Email{
+		Body: hermes.Body{
+			Name:            "Jon Snow",
+			Intros:          []string{"Winter is coming."},
+			TableOfContents: true,
+			Dictionary:      []hermes.Entry{{Key: "Order", Value: "1234"}},
+			Actions: []hermes.Action{{
+				Instructions: "Check your order:",
+				Button:       hermes.Button{Text: "Open", Link: "https://example-hermes.com/orders?id=1234#status"},
+			}},
+			ContactInstructions: &hermes.ContactInstructions{Email: "support@example-hermes.com"},
+		},
+		Unsubscribe: &hermes.Unsubscribe{URL: "https://example-hermes.com/unsubscribe?token=abc"},
+	}
+	return h, email
+}
+
+func TestTrackLinks(t *testing.T) {
+	h, email := trackingExample()
+	html, text, err := h.Generate(email)
+	assert.Nil(t, err)
+
+	button := "https://example-hermes.com/orders?id=1234&utm_medium=email&utm_source=hermes#status"
+	assert.Contains(t, html, `href="https://example-hermes.com/?utm_medium=email&amp;utm_source=hermes"`)
+	assert.Contains(t, html, `href="https://github.com/hermes?tab=repositories&amp;utm_medium=email&amp;utm_source=hermes"`)
+	assert.Equal(t, 3, strings.Count(html, `href="`+strings.ReplaceAll(button, "&", "&amp;")+`"`), "The button, its VML version for Outlook and the link under it should be tracked")
+	assert.Contains(t, html, `href="mailto:support@example-hermes.com"`)
+	assert.Contains(t, html, `href="#`, "Anchors should not be tracked")
+	assert.Contains(t, html, `href="https://example-hermes.com/unsubscribe?token=abc"`, "Unsubscribe links should not be tracked")
+	assert.NotContains(t, html, "abc&amp;utm")
+
+	assert.Contains(t, text, button)
+	assert.Contains(t, text, "https://example-hermes.com/unsubscribe?token=abc\n")
+	assert.NotContains(t, text, "orders?id=1234#status")
+
+	h.TrackingParams = nil
+	untracked, _, err := h.Generate(email)
+	assert.Nil(t, err)
+	params := strings.NewReplacer("&amp;utm_medium=email&amp;utm_source=hermes", "", "?utm_medium=email&amp;utm_source=hermes", "")
+	assert.Equal(t, untracked, params.Replace(html), "Only the links should change")
+}
+
+func TestTrackLinks_FreeMarkdown(t *testing.T) {
+	for _, theme := range []hermes.Theme{new(themes.Default), new(themes.Flat)} {
+		h := hermes.Hermes{Theme: theme, TrackingParams: utm}
+		email := hermes.Email{Body: hermes.Body{
+			Name:         "Jon Snow",
+			FreeMarkdown: "Read [the news](https://example-hermes.com/news?page=2#latest), or call [us](tel:+33123456789).",
+		}}
+		html, text, err := h.Generate(email)
+		assert.Nil(t, err)
+		assert.Contains(t, html, `href="https://example-hermes.com/news?page=2&amp;utm_medium=email&amp;utm_source=hermes#latest"`, theme.Name())
+		assert.Contains(t, html, `href="tel:+33123456789"`, theme.Name())
+		assert.Contains(t, text, "https://example-hermes.com/news?page=2&utm_medium=email&utm_source=hermes#latest", theme.Name())
+		assert.NotContains(t, text, "tel:+33123456789?", theme.Name())
+	}
+}
+
+func TestTrackLinks_Rewriter(t *testing.T) {
+	h, email := trackingExample()
+	h.LinkRewriter = func(link string) string {
+		return "https://click.example-hermes.com/?u=" + url.QueryEscape(link)
+	}
+	html, text, err := h.Generate(email)
+	assert.Nil(t, err)
+	tracked := "https://click.example-hermes.com/?u=" + url.QueryEscape("https://example-hermes.com/orders?id=1234&utm_medium=email&utm_source=hermes#status")
+	assert.Contains(t, html, `href="`+strings.ReplaceAll(tracked, "&", "&amp;")+`"`, "Links should be rewritten once the parameters are added")
+	assert.Contains(t, text, tracked)
+	assert.Contains(t, html, `href="https://example-hermes.com/unsubscribe?token=abc"`)
+
+	// Streamed renderings are buffered to rewrite their links
+	h.DisableCSSInlining = true
+	expected, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	var b bytes.Buffer
+	assert.Nil(t, h.GenerateHTMLTo(email, &b))
+	assert.Equal(t, expected, b.String())
+	assert.Contains(t, b.String(), tracked[:40])
+}
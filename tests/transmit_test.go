@@ -0,0 +1,115 @@
+package hermes
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/send"
+)
+
+// transmitted is a message given to SMTP.OnTransmit
+type transmitted struct {
+	envelope send.Envelope
+	raw      []byte
+}
+
+// archive records the messages given to SMTP.OnTransmit and the bytes written to SMTP.Tee
+type archive struct {
+	mu          sync.Mutex
+	transmitted []transmitted
+	teed        []*bytes.Buffer
+}
+
+func (a *archive) onTransmit(_ context.Context, envelope send.Envelope, raw []byte) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.transmitted = append(a.transmitted, transmitted{envelope, raw})
+	// The copy is the hook's own: changing it must not change what was sent
+	raw[0] = 'X'
+}
+
+func (a *archive) tee(context.Context, send.Envelope) io.Writer {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	b := new(bytes.Buffer)
+	a.teed = append(a.teed, b)
+	return b
+}
+
+// wire returns the data of the message as received by fakeSMTP, whose lines end with LF
+func wire(raw []byte) string {
+	return strings.ReplaceAll(string(raw), "\r\n", "\n")
+}
+
+func TestSMTP_OnTransmit(t *testing.T) {
+	f, s := startFakeSMTP(t, send.NoTLS)
+	a := &archive{}
+	s.OnTransmit, s.Tee = a.onTransmit, a.tee
+	m := smtpMessage()
+	m.Text = "Lines starting with a dot\n.are escaped on the wire"
+
+	assert.Nil(t, s.Send(context.Background(), m))
+	assert.Nil(t, s.Send(send.WithAttempt(context.Background(), 3), m))
+	received := f.received()
+	assert.Len(t, received, 2)
+	assert.Len(t, a.transmitted, 2)
+	for i, mail := range received {
+		tx := a.transmitted[i]
+		tx.raw[0] = mail.Data[0]
+		assert.Equal(t, mail.Data, wire(tx.raw), "The hook should get the bytes received by the server")
+		assert.Equal(t, mail.Data, wire(a.teed[i].Bytes()), "The tee should get the bytes received by the server")
+		assert.Equal(t, "hello@hermes-example.com", tx.envelope.From)
+		assert.Equal(t, mail.Rcpts, tx.envelope.To)
+		assert.Contains(t, tx.envelope.To, "sansa@stark.com", "Bcc recipients are part of the envelope")
+	}
+	assert.Equal(t, 1, a.transmitted[0].envelope.Attempt)
+	assert.Equal(t, 3, a.transmitted[1].envelope.Attempt)
+}
+
+func TestSMTP_OnTransmitFailures(t *testing.T) {
+	f, s := startFakeSMTP(t, send.NoTLS)
+	a := &archive{}
+	s.OnTransmit = a.onTransmit
+	f.reject.Store("sansa@stark.com")
+	assert.NotNil(t, s.Send(context.Background(), smtpMessage()))
+	assert.Empty(t, a.transmitted, "Rejected messages should not be given to the hook")
+
+	// A failing tee stops the message before the server gets it
+	f.reject.Store("")
+	s.Tee = func(context.Context, send.Envelope) io.Writer { return failingWriter{} }
+	err := s.Send(context.Background(), smtpMessage())
+	assert.ErrorContains(t, err, "disk full")
+	assert.Empty(t, f.received())
+	assert.Empty(t, a.transmitted)
+}
+
+func TestSMTP_OnTransmitBatch(t *testing.T) {
+	f, s := startFakeSMTP(t, send.NoTLS)
+	a := &archive{}
+	s.OnTransmit = a.onTransmit
+	f.reject.Store("arya@stark.com")
+	scheduler := &send.Scheduler{
+		Sender:   s,
+		ID:       "batch",
+		Campaign: send.Campaign{From: "Hermes <hello@hermes-example.com>", Subject: "News", Content: hermes.Output{HTML: "<p>News</p>", PlainText: "News"}},
+	}
+	recipients := []string{"jon@snow.com", "robb@stark.com", "arya@stark.com", "bran@stark.com"}
+	assert.NotNil(t, scheduler.Run(context.Background(), recipients))
+	f.reject.Store("")
+	assert.Nil(t, scheduler.Run(context.Background(), recipients))
+
+	received := f.received()
+	assert.Len(t, received, 4)
+	assert.Len(t, a.transmitted, 4, "The hook should be called once per message sent")
+	for i, mail := range received {
+		a.transmitted[i].raw[0] = mail.Data[0]
+		assert.Equal(t, mail.Data, wire(a.transmitted[i].raw))
+		assert.Equal(t, []string{recipients[i]}, a.transmitted[i].envelope.To)
+	}
+}
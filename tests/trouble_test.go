@@ -0,0 +1,54 @@
+package hermes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+func TestTroubleText_PerAction(t *testing.T) {
+	for _, theme := range testedThemes {
+		h, email := (&SimpleExample{theme}).getExample()
+		h.Brand.TroubleText = "Trouble with {ACTION}? Open:"
+		email.Body.Actions = []hermes.Action{
+			{Button: hermes.Button{Text: "Confirm", Link: "https://hermes-example.com/confirm"}},
+			{Button: hermes.Button{Text: "Track", Link: "https://hermes-example.com/track"}, TroubleText: "Cannot click {ACTION}? Track your parcel at:"},
+			{Button: hermes.Button{Text: "Write to us", Link: "mailto:help@hermes-example.com"}, HideFallbackLink: true},
+			{Button: hermes.Button{Text: "Join", Link: "https://hermes-example.com/join"}, InviteCode: "123456"},
+		}
+		html, err := h.GenerateHTML(email)
+		assert.Nil(t, err)
+		name := theme.Name()
+		assert.Contains(t, html, "Trouble with Confirm? Open:", name)
+		assert.Contains(t, html, ">https://hermes-example.com/confirm</a>", name)
+		assert.Contains(t, html, "Cannot click Track? Track your parcel at:", name)
+		assert.NotContains(t, html, "Trouble with Track", name)
+		assert.Contains(t, html, ">https://hermes-example.com/track</a>", name)
+		assert.NotContains(t, html, "Write to us?", name)
+		assert.NotContains(t, html, ">mailto:help@hermes-example.com</a>", name)
+		assert.NotContains(t, html, "Trouble with Join", name)
+		assert.NotContains(t, html, ">https://hermes-example.com/join</a>", name)
+		assert.Equal(t, 2, strings.Count(html, `<p class="sub"><a href=`), name)
+	}
+}
+
+func TestAction_FallbackText(t *testing.T) {
+	brand := "Trouble with '{ACTION}'?"
+	button := hermes.Button{Text: "Go", Link: "https://hermes-example.com/"}
+	tests := []struct {
+		name   string
+		action hermes.Action
+		want   string
+	}{
+		{"Brand default", hermes.Action{Button: button}, "Trouble with 'Go'?"},
+		{"Override", hermes.Action{Button: button, TroubleText: "{ACTION} not working? {ACTION}!"}, "Go not working? Go!"},
+		{"Hidden", hermes.Action{Button: button, TroubleText: "Hidden", HideFallbackLink: true}, ""},
+		{"Invite code", hermes.Action{Button: button, InviteCode: "123456"}, ""},
+		{"No button", hermes.Action{Instructions: "Read this"}, ""},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, test.action.FallbackText(brand), test.name)
+	}
+}
@@ -0,0 +1,124 @@
+package hermes
+
+import (
+	"bytes"
+	"net/mail"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+	"github.com/unknowns24/hermes/pkg/send"
+)
+
+func TestUnsubscribe_Footer(t *testing.T) {
+	for _, theme := range testedThemes {
+		h, email := (&SimpleExample{theme}).getExample()
+		h.Brand.UnsubscribeLink = "https://hermes-example.com/unsubscribe?list=news"
+		legacy, legacyText, err := h.Generate(email)
+		assert.Nil(t, err)
+
+		// The link of the email overrides the one of the brand
+		h.Brand.UnsubscribeLink = "https://hermes-example.com/unsubscribe"
+		email.Unsubscribe = &hermes.Unsubscribe{URL: "https://hermes-example.com/unsubscribe?list=news", OneClickURL: "https://hermes-example.com/one-click"}
+		html, text, err := h.Generate(email)
+		assert.Nil(t, err)
+		assert.Equal(t, legacy, html, theme.Name())
+		assert.Equal(t, legacyText, text, theme.Name())
+		assert.Contains(t, attributeValues(t, html, "href")["a"], "https://hermes-example.com/unsubscribe?list=news", theme.Name())
+		assert.NotContains(t, html, "one-click", "The one-click URL is for the headers only")
+		assert.Contains(t, text, "Unsubscribe: https://hermes-example.com/unsubscribe?list=news", theme.Name())
+
+		h.Brand.UnsubscribeLink = ""
+		email.Unsubscribe = &hermes.Unsubscribe{Mailto: "unsubscribe@hermes-example.com"}
+		html, text, err = h.Generate(email)
+		assert.Nil(t, err)
+		assert.Contains(t, attributeValues(t, html, "href")["a"], "mailto:unsubscribe@hermes-example.com", theme.Name())
+		assert.Contains(t, text, "Unsubscribe: mailto:unsubscribe@hermes-example.com", theme.Name())
+	}
+}
+
+func TestUnsubscribe_Unset(t *testing.T) {
+	for _, theme := range testedThemes {
+		h, email := (&SimpleExample{theme}).getExample()
+		html, text, err := h.Generate(email)
+		assert.Nil(t, err)
+		assert.NotContains(t, html, `data-hermes="unsubscribe"`, theme.Name())
+
+		// Without visible link, the footer is the same
+		email.Unsubscribe = &hermes.Unsubscribe{OneClickURL: "https://hermes-example.com/one-click"}
+		withOneClick, withOneClickText, err := h.Generate(email)
+		assert.Nil(t, err)
+		assert.Equal(t, html, withOneClick, theme.Name())
+		assert.Equal(t, text, withOneClickText, theme.Name())
+	}
+	assert.Nil(t, new(hermes.Hermes).UnsubscribeOf(hermes.Email{}))
+	assert.Nil(t, new(hermes.Hermes).UnsubscribeOf(hermes.Email{Unsubscribe: &hermes.Unsubscribe{}}))
+}
+
+func TestUnsubscribeOf(t *testing.T) {
+	h := hermes.Hermes{Brand: hermes.Branding{
+		UnsubscribeLink: "https://hermes-example.com/preferences",
+		Unsubscribe:     &hermes.Unsubscribe{Mailto: "unsubscribe@hermes-example.com", OneClickURL: "https://hermes-example.com/one-click"},
+	}}
+	email := hermes.Email{Unsubscribe: &hermes.Unsubscribe{OneClickURL: "https://hermes-example.com/one-click?token=d9729feb"}}
+	assert.Equal(t, &hermes.Unsubscribe{
+		URL:         "https://hermes-example.com/preferences",
+		Mailto:      "unsubscribe@hermes-example.com",
+		OneClickURL: "https://hermes-example.com/one-click?token=d9729feb",
+	}, h.UnsubscribeOf(email))
+	assert.Equal(t, "https://hermes-example.com/one-click?token=d9729feb", email.Unsubscribe.OneClickURL, "The email should not be changed")
+	assert.Equal(t, "https://hermes-example.com/one-click", h.Brand.Unsubscribe.OneClickURL, "The brand should not be changed")
+}
+
+// unsubscribeHeaders returns the List-Unsubscribe headers of the message
+func unsubscribeHeaders(t *testing.T, m send.Message) (string, string) {
+	m.From, m.To = "Hermes <hello@hermes-example.com>", []string{"jon@snow.com"}
+	raw, err := m.Bytes()
+	assert.Nil(t, err)
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	assert.Nil(t, err)
+	return msg.Header.Get("List-Unsubscribe"), msg.Header.Get("List-Unsubscribe-Post")
+}
+
+func TestUnsubscribe_Headers(t *testing.T) {
+	h, email := (&SimpleExample{}).getExample()
+	html, text, err := h.Generate(email)
+	assert.Nil(t, err)
+	list, post := unsubscribeHeaders(t, send.BuildMessage(email, html, text))
+	assert.Empty(t, list)
+	assert.Empty(t, post)
+
+	email.Unsubscribe = &hermes.Unsubscribe{
+		URL:         "https://hermes-example.com/preferences",
+		Mailto:      "unsubscribe@hermes-example.com?subject=d9729feb",
+		OneClickURL: "https://hermes-example.com/one-click?token=d9729feb",
+	}
+	list, post = unsubscribeHeaders(t, send.BuildMessage(email, html, text))
+	assert.Equal(t, "<mailto:unsubscribe@hermes-example.com?subject=d9729feb>, <https://hermes-example.com/one-click?token=d9729feb>", list)
+	assert.Equal(t, "List-Unsubscribe=One-Click", post)
+
+	email.Unsubscribe.OneClickURL = ""
+	list, post = unsubscribeHeaders(t, send.BuildMessage(email, html, text))
+	assert.Equal(t, "<mailto:unsubscribe@hermes-example.com?subject=d9729feb>", list)
+	assert.Empty(t, post, "Only one-click URLs take POST requests")
+
+	// The options of the brand are taken into account by GenerateMessage
+	h.Brand.Unsubscribe = &hermes.Unsubscribe{OneClickURL: "https://hermes-example.com/one-click"}
+	m, err := send.GenerateMessage(&h, email)
+	assert.Nil(t, err)
+	list, post = unsubscribeHeaders(t, m)
+	assert.Equal(t, "<mailto:unsubscribe@hermes-example.com?subject=d9729feb>, <https://hermes-example.com/one-click>", list)
+	assert.Equal(t, "List-Unsubscribe=One-Click", post)
+}
+
+func TestUnsubscribe_Validate(t *testing.T) {
+	m := send.Message{From: "hello@hermes-example.com", HTML: "<p>Hi</p>", Text: "Hi", Unsubscribe: &hermes.Unsubscribe{OneClickURL: "http://hermes-example.com/one-click"}}
+	assert.ErrorContains(t, m.Validate(), "must start with https://")
+	m.Unsubscribe.OneClickURL = "https://hermes-example.com/one-click>, <https://evil.com"
+	assert.NotNil(t, m.Validate())
+	m.Unsubscribe.OneClickURL = "https://hermes-example.com/one-click"
+	m.Headers = map[string]string{"list-unsubscribe": "<https://hermes-example.com/other>"}
+	assert.ErrorContains(t, m.Validate(), "set from Unsubscribe")
+	m.Unsubscribe = nil
+	assert.Nil(t, m.Validate(), "Headers may be set by hand without Unsubscribe")
+}
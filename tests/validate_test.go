@@ -0,0 +1,155 @@
+package hermes
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+func TestValidate(t *testing.T) {
+	_, email := (&SimpleExample{}).getExample()
+	assert.Nil(t, email.Validate())
+
+	email.Body.Dictionary[1].HTMLValue = "<b>Snow</b>"
+	email.Body.Table.Data[0][0].HTMLValue = "<b>Golang</b>"
+	email.Body.Actions[0].Button.Link = "/confirm"
+
+	err := email.Validate()
+	issues, ok := err.(hermes.Issues)
+	assert.True(t, ok)
+	var errs []hermes.ValidationError
+	for _, issue := range issues {
+		assert.Equal(t, hermes.SeverityError, issue.Severity)
+		errs = append(errs, issue.Err.(hermes.ValidationError))
+	}
+	assert.Equal(t, []hermes.ValidationError{
+		{Code: hermes.CodeEntryValueConflict, Path: "Body.Dictionary[1]", Message: "sets both Value and HTMLValue"},
+		{Code: hermes.CodeEntryValueConflict, Path: "Body.Table.Data[0][0]", Message: "sets both Value and HTMLValue"},
+		{Code: hermes.CodeButtonLinkNotAbsolute, Path: "Body.Actions[0].Button.Link", Value: "/confirm", Message: "must be an absolute URL"},
+	}, errs)
+	assert.EqualError(t, err, "Body.Dictionary[1]: sets both Value and HTMLValue; Body.Table.Data[0][0]: sets both Value and HTMLValue; Body.Actions[0].Button.Link: must be an absolute URL")
+}
+
+func TestValidationError_Localize(t *testing.T) {
+	err := hermes.ValidationError{Code: hermes.CodeButtonLinkNotAbsolute, Path: "Body.Actions[0].Button.Link", Value: "/confirm"}
+
+	assert.Equal(t, `The button link Body.Actions[0].Button.Link must be a complete address, starting with https:// (got "/confirm").`, err.Localize("en"))
+	assert.Equal(t, `El enlace del botón Body.Actions[0].Button.Link debe ser una dirección completa, que empiece por https:// (se recibió "/confirm").`, err.Localize("es-AR"))
+	assert.Equal(t, "Le lien du bouton Body.Actions[0].Button.Link doit être une adresse complète, commençant par https:// (reçu « /confirm »).", err.Localize("fr_FR"))
+	assert.Equal(t, err.Localize("en"), err.Localize("ja-JP"), "Unknown locales should fall back to English")
+	assert.Equal(t, err.Localize("en"), err.Localize(""))
+}
+
+func TestValidationCodes_HaveEnglishMessages(t *testing.T) {
+	for _, code := range hermes.ValidationCodes {
+		err := hermes.ValidationError{Code: code, Path: "Body"}
+		assert.NotEmpty(t, err.Localize("en"), "code %q has no English message", code)
+	}
+}
+
+func TestValidateSender(t *testing.T) {
+	_, email := (&SimpleExample{}).getExample()
+
+	err := hermes.ValidateSender("Hermes <no-reply@hermes-example.com>", "", email)
+	cause := hermes.ValidationError{
+		Code:    hermes.CodeNoReplyWithoutContact,
+		Path:    "From",
+		Value:   "Hermes <no-reply@hermes-example.com>",
+		Message: "no-reply address without reply-to address nor contact instructions",
+	}
+	assert.Equal(t, hermes.Issues{{
+		Code:     "no_reply_without_contact",
+		Severity: hermes.SeverityWarning,
+		Path:     "From",
+		Message:  "no-reply address without reply-to address nor contact instructions",
+		Err:      cause,
+	}}, err)
+
+	for _, from := range []string{"noreply@example.com", "DoNotReply@example.com", "do-not-reply+billing@example.com", "no_reply@example.com"} {
+		assert.NotNil(t, hermes.ValidateSender(from, "", email), from)
+	}
+	assert.Nil(t, hermes.ValidateSender("support@hermes-example.com", "", email))
+	assert.Nil(t, hermes.ValidateSender("replyall@hermes-example.com", "", email))
+	assert.Nil(t, hermes.ValidateSender("no-reply@hermes-example.com", "support@hermes-example.com", email))
+
+	email.Body.ContactInstructions = &hermes.ContactInstructions{Text: "Questions?"}
+	assert.NotNil(t, hermes.ValidateSender("no-reply@hermes-example.com", "", email), "Text alone does not tell how to reach you")
+	email.Body.ContactInstructions.URL = "https://hermes-example.com/help"
+	assert.Nil(t, hermes.ValidateSender("no-reply@hermes-example.com", "", email))
+}
+
+func TestValidate_ButtonLinkMissing(t *testing.T) {
+	_, email := (&SimpleExample{}).getExample()
+	email.Body.Actions = append(email.Body.Actions, hermes.Action{Button: hermes.Button{Text: "Track"}})
+	assert.EqualError(t, email.Validate(), "Body.Actions[1].Button.Link: empty link with non-empty text")
+
+	email.Body.Actions[1].Button.Text = ""
+	assert.Nil(t, email.Validate(), "Actions without button should not need a link")
+}
+
+func TestValidate_TableColumnMismatch(t *testing.T) {
+	_, email := (&SimpleExample{}).getExample()
+	email.Body.Table.Data = append(email.Body.Table.Data, []hermes.Entry{{Key: "Item", Value: "Gopher"}, {Key: "Price", Value: "$5"}})
+	email.Body.Tables = []hermes.Table{{Data: [][]hermes.Entry{{{Key: "Tax", Value: "$1"}}, {{Key: "Tax", Value: "$1"}}}}}
+
+	err := email.Validate()
+	assert.EqualError(t, err, "Body.Table.Data[2]: has 2 columns, expected 3")
+	var v hermes.ValidationError
+	assert.True(t, errors.As(err, &v))
+	assert.Equal(t, hermes.ValidationError{Code: hermes.CodeTableColumnMismatch, Path: "Body.Table.Data[2]", Value: "2", Message: "has 2 columns, expected 3"}, v)
+	assert.Equal(t, "The row Body.Table.Data[2] has 2 columns, not as many as the first row of its table.", v.Localize("en"))
+}
+
+func TestValidate_LogoURL(t *testing.T) {
+	for _, logo := range []string{"", "https://hermes-example.com/logo.png", "http://hermes-example.com/logo.png", "data:image/png;base64,iVBORw0KGgo=", "cid:logo@hermes"} {
+		assert.Nil(t, hermes.Branding{Logo: logo}.Validate(), logo)
+	}
+	for _, logo := range []string{"logo.png", "/static/logo.png", "https://", "javascript:alert(1)", "data:text/html,<b>", "http://[::1"} {
+		err := hermes.Branding{Logo: logo}.Validate()
+		var v hermes.ValidationError
+		if assert.True(t, errors.As(err, &v), logo) {
+			assert.Equal(t, hermes.CodeInvalidLogoURL, v.Code, logo)
+			assert.Equal(t, "Brand.Logo", v.Path, logo)
+			assert.Equal(t, logo, v.Value, logo)
+		}
+	}
+}
+
+func TestStrictValidation(t *testing.T) {
+	h, email := (&SimpleExample{}).getExample()
+	h.Brand.Logo = "logo.png"
+	email.Body.Actions[0].Button.Link = ""
+
+	// Without StrictValidation, broken emails are rendered and their issues reported as warnings
+	html, err := h.GenerateHTML(email)
+	assert.Nil(t, err)
+	withWarnings, warnings, err := h.GenerateHTMLWithWarnings(email)
+	assert.Nil(t, err)
+	assert.Equal(t, html, withWarnings)
+	assert.EqualError(t, warnings, "Brand.Logo: must be an absolute http(s) URL, a data: image or a cid: attachment; Body.Actions[0].Button.Link: empty link with non-empty text")
+
+	h.StrictValidation = true
+	_, _, err = h.Generate(email)
+	assert.Equal(t, warnings, err)
+	_, err = h.GenerateHTML(email)
+	assert.Equal(t, warnings, err)
+	_, err = h.GeneratePlainText(email)
+	assert.Equal(t, warnings, err)
+	_, _, err = h.GenerateHTMLWithWarnings(email)
+	assert.Equal(t, warnings, err)
+
+	// Warnings alone do not fail generation
+	h.Brand.Logo = ""
+	email.Body.Actions[0].Button.Link = "https://hermes-example.com/confirm"
+	h.Brand.WebFonts = []hermes.WebFont{
+		{Family: "Inter", URL: "https://fonts.hermes-example.com/inter.woff2"},
+		{Family: "Inter", URL: "https://fonts.hermes-example.com/inter-bold.woff2", Weight: "700"},
+		{Family: "Lora", URL: "https://fonts.hermes-example.com/lora.woff2"},
+	}
+	_, warnings, err = h.GenerateHTMLWithWarnings(email)
+	assert.Nil(t, err)
+	assert.Len(t, warnings, 1)
+	assert.Equal(t, hermes.SeverityWarning, warnings[0].Severity)
+}
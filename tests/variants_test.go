@@ -0,0 +1,156 @@
+package hermes
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+// bilingualExample returns an email in English then in French, for recipients whose language is not known
+func bilingualExample() hermes.Email {
+	return hermes.Email{
+		Subject: "{{ .Email.Body.Title }}",
+		LanguageVariants: []hermes.LanguageVariant{
+			{Tag: "en", Body: hermes.Body{
+				Name:       "Jon Snow",
+				Preheader:  "Your order has shipped",
+				Intros:     []string{"Your order has shipped."},
+				Dictionary: []hermes.Entry{{Key: "Order", Value: "A-12345"}},
+				Actions: []hermes.Action{{
+					Instructions: "Track your package:",
+					Button:       hermes.Button{Text: "Track", Link: "https://hermes-example.com/track/A-12345"},
+				}},
+			}},
+			{Tag: "fr", Body: hermes.Body{
+				Name:       "Jon Snow",
+				Intros:     []string{"Votre commande a été expédiée."},
+				Dictionary: []hermes.Entry{{Key: "Commande", Value: "A-12345"}},
+				Actions: []hermes.Action{{
+					Instructions: "Suivez votre colis :",
+					Button:       hermes.Button{Text: "Suivre", Link: "https://hermes-example.com/track/A-12345?lang=fr"},
+				}},
+			}},
+		},
+	}
+}
+
+func TestLanguageVariants_Golden(t *testing.T) {
+	for _, theme := range testedThemes {
+		h := hermes.Hermes{
+			Theme: theme,
+			Brand: hermes.Branding{Name: "Hermes", Link: "https://example-hermes.com/"},
+		}
+		html, err := h.GenerateHTML(bilingualExample())
+		assert.Nil(t, err, theme.Name())
+		assertGolden(t, "variants/"+theme.Name()+".html", html)
+
+		text, err := h.GeneratePlainText(bilingualExample())
+		assert.Nil(t, err, theme.Name())
+		assertGolden(t, "variants/"+theme.Name()+".txt", text)
+	}
+}
+
+func TestLanguageVariants(t *testing.T) {
+	for _, theme := range testedThemes {
+		h := hermes.Hermes{Theme: theme, Brand: hermes.Branding{Name: "Hermes"}}
+		email := bilingualExample()
+		html, err := h.GenerateHTML(email)
+		assert.Nil(t, err, theme.Name())
+		text, err := h.GeneratePlainText(email)
+		assert.Nil(t, err, theme.Name())
+
+		for _, out := range []string{html, text} {
+			en, fr := strings.Index(out, "Hi Jon Snow,"), strings.Index(out, "Bonjour Jon Snow,")
+			assert.True(t, en >= 0 && fr > en, "%s: the variants should be rendered in order", theme.Name())
+			assert.Contains(t, out, "Yours truly,", theme.Name())
+			assert.Contains(t, out, "Cordialement,", theme.Name())
+			assert.Equal(t, 1, strings.Count(out, "English / Français"), "%s: the divider should be between the variants", theme.Name())
+			divider := strings.Index(out, "English / Français")
+			assert.True(t, divider > en && divider < fr, theme.Name())
+			assert.Equal(t, 1, strings.Count(out, "Copyright © 2024 Hermes. All rights reserved."),
+				"%s: the footer should be shared, in the locale of the first variant", theme.Name())
+		}
+		assert.Contains(t, html, `<html xmlns="http://www.w3.org/1999/xhtml" lang="en">`, theme.Name())
+		assert.Contains(t, html, `<div lang="en" dir="ltr" data-hermes="language-variant"`, theme.Name())
+		assert.Contains(t, html, `<div lang="fr" dir="ltr" data-hermes="language-variant"`, theme.Name())
+		assert.Equal(t, bilingualExample(), email, "The email should not be changed")
+	}
+
+	h := hermes.Hermes{}
+	subject, err := h.GenerateSubject(hermes.Email{LanguageVariants: []hermes.LanguageVariant{
+		{Tag: "fr", Body: hermes.Body{Title: "Bienvenue"}}, {Tag: "en", Body: hermes.Body{Title: "Welcome"}},
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, "Bienvenue", subject, "The subject should default to the title of the first variant")
+}
+
+func TestLanguageVariants_Direction(t *testing.T) {
+	h := hermes.Hermes{Brand: hermes.Branding{Name: "Hermes"}}
+	html, err := h.GenerateHTMLRaw(hermes.Email{LanguageVariants: []hermes.LanguageVariant{
+		{Tag: "en", Body: hermes.Body{Name: "Jon"}},
+		{Tag: "ar", Body: hermes.Body{Greeting: "مرحبا", Name: "Jon"}},
+	}})
+	assert.Nil(t, err)
+	assert.Contains(t, html, `<body dir="ltr">`, "The header and the footer should follow the first variant")
+	assert.Contains(t, html, `<div lang="ar" dir="rtl" data-hermes="language-variant">`)
+	assert.Contains(t, html, ">English / ar</div>", "Languages without localization should be named by their tag")
+}
+
+func TestLanguageVariants_Single(t *testing.T) {
+	h := hermes.Hermes{}
+	html, err := h.GenerateHTML(hermes.Email{LanguageVariants: []hermes.LanguageVariant{{Tag: "de", Body: hermes.Body{Name: "Jon"}}}})
+	assert.Nil(t, err)
+	assert.Contains(t, html, "Hallo Jon,")
+	assert.NotContains(t, html, `data-hermes="language-divider"`)
+}
+
+func TestLanguageVariants_Validate(t *testing.T) {
+	tests := []struct {
+		variants []hermes.LanguageVariant
+		paths    []string
+	}{
+		{[]hermes.LanguageVariant{}, []string{"LanguageVariants"}},
+		{[]hermes.LanguageVariant{{Body: hermes.Body{Name: "Jon"}}}, []string{"LanguageVariants[0].Tag"}},
+		{[]hermes.LanguageVariant{{Tag: "en"}, {Tag: "fr"}, {Tag: "EN"}}, []string{"LanguageVariants[2].Tag"}},
+		{[]hermes.LanguageVariant{{Tag: "fr_FR"}, {Tag: "fr-fr"}}, []string{"LanguageVariants[1].Tag"}},
+	}
+	for _, test := range tests {
+		err := hermes.Email{LanguageVariants: test.variants}.Validate()
+		var issues hermes.Issues
+		assert.True(t, errors.As(err, &issues), "%v", test.variants)
+		var paths []string
+		for _, issue := range issues {
+			assert.Equal(t, string(hermes.CodeInvalidLanguageVariants), issue.Code)
+			paths = append(paths, issue.Path)
+		}
+		assert.Equal(t, test.paths, paths)
+	}
+
+	email := bilingualExample()
+	email.LanguageVariants[1].Body.Actions[0].Button.Link = "/track"
+	var issues hermes.Issues
+	assert.True(t, errors.As(email.Validate(), &issues))
+	assert.Len(t, issues, 1)
+	assert.Equal(t, "LanguageVariants[1].Body.Actions[0].Button.Link", issues[0].Path)
+
+	h := hermes.Hermes{StrictValidation: true}
+	_, err := h.GenerateHTML(email)
+	assert.ErrorContains(t, err, "LanguageVariants[1].Body.Actions[0].Button.Link: must be an absolute URL")
+	assert.Nil(t, bilingualExample().Validate())
+
+	var validation hermes.ValidationError
+	assert.True(t, errors.As(hermes.Email{LanguageVariants: []hermes.LanguageVariant{}}.Validate().(hermes.Issues)[0].Err, &validation))
+	assert.Equal(t, `The language variants LanguageVariants must list at least one variant, each with its own tag (got "").`,
+		validation.Localize("en"))
+}
+
+func TestLanguageVariants_Errors(t *testing.T) {
+	email := bilingualExample()
+	email.LanguageVariants[1].Body.Dictionary[0].Mask = "middle"
+	h := hermes.Hermes{}
+	_, err := h.GenerateHTML(email)
+	assert.EqualError(t, err, `LanguageVariants[1].Body.Dictionary[0].Mask: unknown mask "middle"`)
+}
@@ -0,0 +1,104 @@
+package hermes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+// readVCard reads the properties of a vCard as specified by RFC 2425 and RFC 2426: lines are unfolded,
+// then split into name and value at the first colon, and the components of values are unescaped
+func readVCard(t *testing.T, card string) map[string][]string {
+	assert.True(t, strings.HasSuffix(card, "\r\n"), "Lines should end with CRLF")
+	for _, line := range strings.Split(strings.TrimSuffix(card, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75, "Lines should be folded at 75 octets: %q", line)
+	}
+	properties := map[string][]string{}
+	for _, line := range strings.Split(strings.ReplaceAll(card, "\r\n ", ""), "\r\n") {
+		if line == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		assert.True(t, ok, "Line without value: %q", line)
+		var components []string
+		var b strings.Builder
+		for i := 0; i < len(value); i++ {
+			switch c := value[i]; {
+			case c == '\\' && i+1 < len(value):
+				i++
+				if value[i] == 'n' || value[i] == 'N' {
+					b.WriteByte('\n')
+				} else {
+					b.WriteByte(value[i])
+				}
+			case c == ';':
+				components = append(components, b.String())
+				b.Reset()
+			default:
+				b.WriteByte(c)
+			}
+		}
+		properties[name] = append(components, b.String())
+	}
+	return properties
+}
+
+func TestVCard(t *testing.T) {
+	card := hermes.VCard{
+		Name:  "Hermes Support",
+		Org:   "Hermes, Inc.; Customer Care\nWinterfell",
+		Email: "support@hermes-example.com",
+		Phone: "+33 1 23 45 67 89",
+		URL:   "https://hermes-example.com/contact?team=support,billing&lang=fr",
+	}
+	assert.Equal(t, map[string][]string{
+		"BEGIN":               {"VCARD"},
+		"VERSION":             {"3.0"},
+		"N":                   {"Support", "Hermes", "", "", ""},
+		"FN":                  {"Hermes Support"},
+		"ORG":                 {"Hermes, Inc.; Customer Care\nWinterfell"},
+		"EMAIL;TYPE=INTERNET": {"support@hermes-example.com"},
+		"TEL;TYPE=WORK,VOICE": {"+33 1 23 45 67 89"},
+		"URL":                 {"https://hermes-example.com/contact?team=support,billing&lang=fr"},
+		"END":                 {"VCARD"},
+	}, readVCard(t, card.String()))
+	assert.Contains(t, card.String(), `ORG:Hermes\, Inc.\; Customer Care\nWinterfell`)
+
+	// Empty fields are left out
+	assert.Equal(t, "BEGIN:VCARD\r\nVERSION:3.0\r\nN:Snow;Jon;;;\r\nFN:Jon Snow\r\nEND:VCARD\r\n", hermes.VCard{Name: "Jon Snow"}.String())
+}
+
+func TestVCard_Folding(t *testing.T) {
+	card := hermes.VCard{
+		Name: "Daenerys Targaryen",
+		Org:  strings.Repeat("Mère des dragons, ", 10),
+		URL:  "https://hermes-example.com/" + strings.Repeat("a", 200),
+	}
+	properties := readVCard(t, card.String())
+	assert.Equal(t, []string{strings.Repeat("Mère des dragons, ", 10)}, properties["ORG"], "Characters should not be split by folding")
+	assert.Equal(t, []string{card.URL}, properties["URL"])
+}
+
+func TestVCard_Attachment(t *testing.T) {
+	card := hermes.VCard{Name: "Hermes Support", Email: "support@hermes-example.com"}
+	a := card.Attachment()
+	assert.Equal(t, "Hermes-Support.vcf", a.Filename)
+	assert.Equal(t, "text/vcard; charset=utf-8", a.MediaType())
+	assert.False(t, a.Inline)
+	assert.Equal(t, card.String(), string(a.Data))
+	assert.Equal(t, "contact.vcf", hermes.VCard{Name: " / "}.Attachment().Filename)
+}
+
+func TestVCard_Action(t *testing.T) {
+	card := hermes.VCard{Name: "Hermes Support"}
+	action := card.Action("fr-FR", "https://hermes-example.com/support.vcf")
+	assert.Equal(t, "Enregistrez Hermes Support dans vos contacts :", action.Instructions)
+	assert.Equal(t, "Télécharger la fiche contact", action.Button.Text)
+	assert.Equal(t, "https://hermes-example.com/support.vcf", action.Button.Link)
+	assert.Equal(t, "Download contact card", card.Action("ja-JP", "").Button.Text)
+
+	email := hermes.Email{Body: hermes.Body{Actions: []hermes.Action{action}}}
+	assert.Nil(t, email.Validate())
+}
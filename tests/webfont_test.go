@@ -0,0 +1,81 @@
+package hermes
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknowns24/hermes/examples/mails"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+var webFonts = []hermes.WebFont{
+	{Family: "Inter", URL: "https://fonts.hermes-example.com/inter-regular.woff2"},
+	{Family: "Inter", URL: "https://fonts.hermes-example.com/inter-bold.woff2", Weight: "700"},
+}
+
+func TestWebFonts_Golden(t *testing.T) {
+	email := new(mails.Welcome).Email()
+	for _, theme := range testedThemes {
+		h := hermes.Hermes{
+			Theme: theme,
+			Brand: hermes.Branding{Name: "Hermes", Link: "https://example-hermes.com/", Copyright: "Copyright © Hermes-Test", WebFonts: webFonts},
+		}
+		html, err := h.GenerateHTML(email)
+		assert.Nil(t, err)
+		assertGolden(t, "webfont/"+theme.Name()+".html", html)
+
+		// The rules are kept as is by CSS inlining, the fonts of the theme follow the ones of the brand
+		assert.Contains(t, html, `<style type="text/css" rel="stylesheet" media="all" data-premailer="ignore" data-hermes-css="fonts">`, theme.Name())
+		assert.Contains(t, html, "@font-face { font-family: 'Inter'; font-weight: normal; font-style: normal; src: url('https://fonts.hermes-example.com/inter-regular.woff2') format('woff2'); }", theme.Name())
+		assert.Contains(t, html, "font-weight: 700;", theme.Name())
+		assert.Contains(t, html, "font-family: 'Inter', ", theme.Name())
+		assert.Contains(t, html, "sans-serif", theme.Name())
+
+		h.Brand.WebFonts = nil
+		html, err = h.GenerateHTML(email)
+		assert.Nil(t, err)
+		assert.NotContains(t, html, "@font-face", theme.Name())
+		assert.NotContains(t, html, `data-hermes-css="fonts"`, theme.Name())
+		assert.NotContains(t, html, "'Inter'", theme.Name())
+	}
+}
+
+func TestWebFonts_Invalid(t *testing.T) {
+	brand := hermes.Branding{WebFonts: []hermes.WebFont{
+		{Family: "Inter", URL: "http://fonts.hermes-example.com/inter.woff2"},
+		{Family: "Inter'; } body { display: none", URL: "https://fonts.hermes-example.com/inter.woff2"},
+		{Family: "Inter", URL: "https://fonts.hermes-example.com/inter.woff2') format('woff2"},
+		{Family: "Inter", URL: "https://fonts.hermes-example.com/inter.woff2", Weight: "heavy"},
+	}}
+	assert.Equal(t, "", string(brand.FontFaces()))
+	assert.Equal(t, "Arial, sans-serif", string(brand.FontStack("Arial, sans-serif")))
+}
+
+func TestBranding_Validate(t *testing.T) {
+	assert.Nil(t, hermes.Branding{WebFonts: webFonts}.Validate())
+
+	brand := hermes.Branding{WebFonts: append([]hermes.WebFont{
+		{Family: "Lora", URL: "http://fonts.hermes-example.com/lora.woff2"},
+	}, webFonts...)}
+	err := brand.Validate()
+	var issues hermes.Issues
+	assert.True(t, errors.As(err, &issues))
+	assert.Len(t, issues, 2)
+	assert.Equal(t, string(hermes.CodeWebFontNotHTTPS), issues[0].Code)
+	assert.Equal(t, hermes.SeverityError, issues[0].Severity)
+	assert.Equal(t, "Brand.WebFonts[0].URL", issues[0].Path)
+	assert.Equal(t, string(hermes.CodeTooManyWebFonts), issues[1].Code)
+	assert.Equal(t, hermes.SeverityWarning, issues[1].Severity)
+	assert.Equal(t, "Use at most 2 web font files: each of the 3 files slows down the display of the email.", issues[1].Localize("en"))
+}
+
+func TestEnginePool_WebFonts(t *testing.T) {
+	pool := hermes.NewEnginePool(hermes.Hermes{})
+	brand := hermes.Branding{Name: "Hermes", WebFonts: webFonts}
+	assert.Nil(t, pool.WarmUp(context.Background(), []hermes.Branding{brand}))
+	assert.Same(t, get(pool, brand, ""), get(pool, hermes.Branding{Name: "Hermes", WebFonts: webFonts}, ""))
+	assert.NotSame(t, get(pool, brand, ""), get(pool, hermes.Branding{Name: "Hermes", WebFonts: webFonts[:1]}, ""))
+	assert.Equal(t, hermes.PoolStats{Hits: 3, Misses: 2, Engines: 2}, pool.Stats())
+}
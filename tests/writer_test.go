@@ -0,0 +1,152 @@
+package hermes
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	hermes "github.com/unknowns24/hermes/pkg/mails"
+)
+
+func TestGenerateTo_SameAsStrings(t *testing.T) {
+	for _, inline := range []bool{false, true} {
+		h, email := (&SimpleExample{}).getExample()
+		h.DisableCSSInlining = !inline
+
+		expected, err := h.GenerateHTML(email)
+		assert.Nil(t, err)
+		var b bytes.Buffer
+		assert.Nil(t, h.GenerateHTMLTo(email, &b))
+		assert.Equal(t, expected, b.String(), "inline: %v", inline)
+
+		expected, err = h.GeneratePlainText(email)
+		assert.Nil(t, err)
+		b.Reset()
+		assert.Nil(t, h.GeneratePlainTextTo(email, &b))
+		assert.Equal(t, expected, b.String(), "inline: %v", inline)
+	}
+}
+
+// countingWriter counts the writes, to tell whether the output is streamed or buffered
+type countingWriter struct {
+	b      bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.b.Write(p)
+}
+
+func TestGenerateHTMLTo_Streams(t *testing.T) {
+	h, email := (&SimpleExample{}).getExample()
+	h.DisableCSSInlining = true
+	var w countingWriter
+	assert.Nil(t, h.GenerateHTMLTo(email, &w))
+	assert.Greater(t, w.writes, 1, "Template should be executed straight into the writer")
+
+	h.DisableCSSInlining = false
+	w = countingWriter{}
+	assert.Nil(t, h.GenerateHTMLTo(email, &w))
+	assert.Equal(t, 1, w.writes, "Inlined output should be written at once")
+
+	// Custom stages need the whole output
+	h.DisableCSSInlining = true
+	h.Pipeline = hermes.DefaultPipeline()
+	h.Pipeline.Stats = &hermes.PipelineStats{}
+	assert.Nil(t, h.Pipeline.InsertAfter(hermes.StageInline, hermes.Stage{
+		Name: "Shout",
+		Run: func(r *hermes.Rendering) error {
+			r.HTML = strings.ReplaceAll(r.HTML, "Jon Snow", "JON SNOW")
+			return nil
+		},
+	}))
+	w = countingWriter{}
+	assert.Nil(t, h.GenerateHTMLTo(email, &w))
+	assert.Equal(t, 1, w.writes)
+	assert.Contains(t, w.b.String(), "JON SNOW")
+
+	assert.Nil(t, h.Pipeline.Remove("Shout"))
+	w = countingWriter{}
+	assert.Nil(t, h.GenerateHTMLTo(email, &w))
+	assert.Greater(t, w.writes, 1)
+	stats := h.Pipeline.Stats.Stages()
+	assert.Equal(t, uint64(2), stats[hermes.StageTemplateExecute].Runs)
+	assert.Equal(t, uint64(2), stats[hermes.StageInline].Runs)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestGenerateTo_WriterError(t *testing.T) {
+	h, email := (&SimpleExample{}).getExample()
+	for _, inline := range []bool{false, true} {
+		h.DisableCSSInlining = !inline
+		assert.EqualError(t, h.GenerateHTMLTo(email, failingWriter{}), "disk full", "inline: %v", inline)
+	}
+	assert.EqualError(t, h.GeneratePlainTextTo(email, failingWriter{}), "disk full")
+}
+
+// Compare with BenchmarkGenerateHTML, which does not inline CSS either
+func BenchmarkGenerateHTMLTo(b *testing.B) {
+	h, email := (&SimpleExample{}).getExample()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := h.GenerateHTMLTo(email, io.Discard); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGenerateHTML_Inlined(b *testing.B) {
+	h, email := (&SimpleExample{}).getExample()
+	h.DisableCSSInlining = false
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := h.GenerateHTML(email); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGenerateHTMLTo_Inlined(b *testing.B) {
+	h, email := (&SimpleExample{}).getExample()
+	h.DisableCSSInlining = false
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := h.GenerateHTMLTo(email, io.Discard); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGeneratePlainText(b *testing.B) {
+	h, email := (&SimpleExample{}).getExample()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := h.GeneratePlainText(email); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGeneratePlainTextTo(b *testing.B) {
+	h, email := (&SimpleExample{}).getExample()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := h.GeneratePlainTextTo(email, io.Discard); err != nil {
+			b.Fatal(err)
+		}
+	}
+}